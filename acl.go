@@ -0,0 +1,176 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// ACLOptions are the settings of generated Cisco ACL entries
+type ACLOptions struct {
+	Action      string // permit or deny
+	Protocol    string // ip, tcp, udp, icmp, ...
+	Destination string // "any" or an IPv4 CIDR
+	Port        string // destination port for tcp and udp; empty for any
+	Name        string // named extended ACL; empty for bare entries
+}
+
+// aclActions are the actions an ACL entry takes
+var aclActions = []string{"permit", "deny"}
+
+// ACLEntry is one source network of an ACL, with the plan file comment that
+// becomes its remark
+type ACLEntry struct {
+	Network *NetworkInfo
+	Remark  string
+}
+
+// aclAddress writes a network the way Cisco IOS matches it: "any" for
+// 0.0.0.0/0, "host A.B.C.D" for a /32 and otherwise the network ID with its
+// wildcard mask
+func (f *OutputFormatter) aclAddress(info *NetworkInfo) string {
+	switch info.PrefixLength {
+	case 0:
+		return "any"
+	case 32:
+		return "host " + info.NetworkID.String()
+	}
+	return info.NetworkID.String() + " " + f.formatIPMask(info.WildcardMask)
+}
+
+// FormatACL renders one entry per source network, e.g.
+// "permit ip 192.168.1.0 0.0.0.255 any". With a name the entries are wrapped
+// in an "ip access-list extended" block; remarks precede their entries.
+func (f *OutputFormatter) FormatACL(entries []ACLEntry, destination *NetworkInfo, options ACLOptions) string {
+	var output strings.Builder
+
+	indent := ""
+	if options.Name != "" {
+		output.WriteString(fmt.Sprintf("ip access-list extended %s\n", options.Name))
+		indent = " "
+	}
+
+	target := "any"
+	if destination != nil {
+		target = f.aclAddress(destination)
+	}
+	if options.Port != "" {
+		target += " eq " + options.Port
+	}
+
+	for _, entry := range entries {
+		if entry.Remark != "" {
+			output.WriteString(fmt.Sprintf("%sremark %s\n", indent, entry.Remark))
+		}
+		output.WriteString(fmt.Sprintf("%s%s %s %s %s\n", indent, options.Action, options.Protocol, f.aclAddress(entry.Network), target))
+	}
+
+	return output.String()
+}
+
+// runACL implements the acl subcommand
+func (c *CLIHandler) runACL(args []string) error {
+	flagSet := flag.NewFlagSet("acl", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	options := ACLOptions{}
+	var inputFile, outputFile string
+	var split int
+	var tags tagFilterList
+	flagSet.StringVar(&inputFile, "f", "", "Read source CIDRs from file, stdin or URL")
+	flagSet.StringVar(&inputFile, "file", "", "Read source CIDRs from file, stdin or URL")
+	flagSet.Var(&tags, "tag", "Only use -f entries with this key=value tag, or with the key (repeatable)")
+	flagSet.IntVar(&split, "split", 0, "Write one entry per subnet at this prefix length")
+	flagSet.StringVar(&options.Action, "action", "permit", "Action of the entries: permit or deny")
+	flagSet.StringVar(&options.Protocol, "protocol", "ip", "Protocol keyword or number, e.g. ip, tcp, udp, icmp")
+	flagSet.StringVar(&options.Destination, "destination", "any", "Destination CIDR, or any")
+	flagSet.StringVar(&options.Port, "port", "", "Destination port for tcp and udp entries")
+	flagSet.StringVar(&options.Name, "name", "", "Wrap the entries in a named extended ACL")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept CIDRs anywhere among the flags
+	cidrs, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	if len(cidrs) == 0 && inputFile == "" {
+		return fmt.Errorf("acl requires CIDR arguments or -f")
+	}
+	if len(tags) > 0 && inputFile == "" {
+		return fmt.Errorf("--tag filters the entries of a -f plan file")
+	}
+	options.Action = strings.ToLower(options.Action)
+	if options.Action != aclActions[0] && options.Action != aclActions[1] {
+		return fmt.Errorf("unsupported --action %s (available: %s)", options.Action, strings.Join(aclActions, ", "))
+	}
+	options.Protocol = strings.ToLower(options.Protocol)
+	if options.Port != "" && options.Protocol != "tcp" && options.Protocol != "udp" {
+		return fmt.Errorf("--port requires --protocol tcp or udp")
+	}
+
+	var destination *NetworkInfo
+	if options.Destination != "any" {
+		info, err := c.calculator.ParseCIDR(options.Destination)
+		if err != nil {
+			return fmt.Errorf("failed to parse --destination %s: %v", options.Destination, err)
+		}
+		destination = info
+	}
+
+	sources := make([]ACLEntry, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		info, err := c.calculator.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("failed to parse CIDR %s: %v", cidr, err)
+		}
+		sources = append(sources, ACLEntry{Network: info})
+	}
+	if inputFile != "" {
+		entries, err := NewBatchReader(defaultFetchTimeout, nil).Read(inputFile)
+		if err != nil {
+			return err
+		}
+		if entries, err = filterEntries(entries, tags, inputFile); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			info, err := c.calculator.ParseCIDR(entry.CIDR)
+			if err != nil {
+				return fmt.Errorf("%s line %d: failed to parse CIDR: %v", entry.Source, entry.Line, err)
+			}
+			sources = append(sources, ACLEntry{Network: info, Remark: entry.Comment})
+		}
+	}
+
+	// Wildcard masks only exist for IPv4
+	if destination != nil && destination.IsIPv6() {
+		return fmt.Errorf("wildcard ACLs support IPv4 networks only, got %s", destination.CIDR())
+	}
+	for _, source := range sources {
+		if source.Network.IsIPv6() {
+			return fmt.Errorf("wildcard ACLs support IPv4 networks only, got %s", source.Network.CIDR())
+		}
+	}
+
+	entries := sources
+	if split > 0 {
+		entries = nil
+		for _, source := range sources {
+			subnets, err := c.calculator.SplitNetworks(source.Network, split)
+			if err != nil {
+				return err
+			}
+			for i := range subnets {
+				entry := ACLEntry{Network: &subnets[i]}
+				if i == 0 {
+					entry.Remark = source.Remark
+				}
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return c.writeOutput(c.formatter.FormatACL(entries, destination, options), outputFile)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputFormatter_FormatACL(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	entries := []ACLEntry{
+		{Network: mustParseCIDR(t, calculator, "192.168.1.0/24"), Remark: "office LAN"},
+		{Network: mustParseCIDR(t, calculator, "10.0.0.5/32")},
+		{Network: mustParseCIDR(t, calculator, "0.0.0.0/0")},
+	}
+
+	output := formatter.FormatACL(entries, nil, ACLOptions{Action: "permit", Protocol: "ip"})
+	expected := "remark office LAN\npermit ip 192.168.1.0 0.0.0.255 any\npermit ip host 10.0.0.5 any\npermit ip any any\n"
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+
+	destination := mustParseCIDR(t, calculator, "172.16.0.0/12")
+	output = formatter.FormatACL(entries[1:2], destination, ACLOptions{Action: "deny", Protocol: "udp", Port: "53", Name: "DNS"})
+	expected = "ip access-list extended DNS\n deny udp host 10.0.0.5 172.16.0.0 0.15.255.255 eq 53\n"
+	if output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestCLIHandler_ACL(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	plan := filepath.Join(dir, "plan.txt")
+	if err := os.WriteFile(plan, []byte("10.1.0.0/25 # branch office\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "acl.txt")
+	if err := handler.Run([]string{"cidr-calc", "acl", "-f", plan, "--split", "26", "--action", "deny", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	expected := "remark branch office\ndeny ip 10.1.0.0 0.0.0.63 any\ndeny ip 10.1.0.64 0.0.0.63 any\n"
+	if string(content) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, content)
+	}
+
+	errorCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"acl"}, "acl requires CIDR arguments or -f"},
+		{[]string{"acl", "10.0.0.0/8", "--action", "allow"}, "unsupported --action allow"},
+		{[]string{"acl", "10.0.0.0/8", "--port", "80"}, "--port requires --protocol tcp or udp"},
+		{[]string{"acl", "2001:db8::/32"}, "wildcard ACLs support IPv4 networks only"},
+		{[]string{"acl", "10.0.0.0/8", "--destination", "2001:db8::/32"}, "wildcard ACLs support IPv4 networks only"},
+	}
+	for _, tt := range errorCases {
+		if err := handler.Run(append([]string{"cidr-calc"}, tt.args...)); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
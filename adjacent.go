@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+)
+
+// Adjacent returns the network of the same size steps networks after this
+// one, or before it when steps is negative: the next /26 after 10.0.0.64/26 is
+// 10.0.0.128/26. Stepping past either end of the address space is an error.
+func (n *NetworkInfo) Adjacent(steps int64) (*NetworkInfo, error) {
+	width := len(addressBytes(n.NetworkID))
+	size := new(big.Int).Lsh(big.NewInt(1), uint(n.MaxPrefix()-n.PrefixLength))
+	start := new(big.Int).SetBytes(addressBytes(n.NetworkID))
+	start.Add(start, new(big.Int).Mul(size, big.NewInt(steps)))
+
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(width*8))
+	if start.Sign() < 0 || start.Cmp(limit) >= 0 {
+		direction := "after"
+		if steps < 0 {
+			direction, steps = "before", -steps
+		}
+		return nil, fmt.Errorf("stepping %d /%d networks %s %s leaves the address space", steps, n.PrefixLength, direction, n.CIDR())
+	}
+
+	address := make(net.IP, width)
+	start.FillBytes(address)
+	return NewCIDRCalculator().ParseCIDR(fmt.Sprintf("%s/%d", address, n.PrefixLength))
+}
+
+// runAdjacent returns the next or prev subcommand, which steps forward or
+// backward from a network
+func (c *CLIHandler) runAdjacent(command string, direction int64) subcommand {
+	return func(args []string) error {
+		flagSet := flag.NewFlagSet(command, flag.ContinueOnError)
+		flagSet.SetOutput(c.stderr)
+
+		var steps int64
+		var format, outputFile string
+		flagSet.Int64Var(&steps, "step", 1, "Number of networks to step")
+		flagSet.Int64Var(&steps, "n", 1, "Number of networks to step")
+		flagSet.StringVar(&format, "format", FormatText, "Output format: text (the CIDR) or any output format")
+		flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+		flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+		// Accept the CIDR anywhere among the flags
+		positional, err := parseInterspersed(flagSet, args)
+		if err != nil {
+			return fmt.Errorf("flag parsing error: %v", err)
+		}
+
+		if !IsSupportedFormat(format) {
+			return fmt.Errorf("unsupported output format: %s (supported: %s)", format, strings.Join(SupportedFormats, ", "))
+		}
+		if len(positional) != 1 {
+			return fmt.Errorf("%s takes a single CIDR, got %d", command, len(positional))
+		}
+		if steps < 1 {
+			return fmt.Errorf("--step must be at least 1, got %d", steps)
+		}
+
+		network, err := c.calculator.ParseCIDR(positional[0])
+		if err != nil {
+			return fmt.Errorf("failed to parse CIDR %s: %v", positional[0], err)
+		}
+		adjacent, err := network.Adjacent(direction * steps)
+		if err != nil {
+			return err
+		}
+
+		if format == FormatText {
+			return c.writeOutput(adjacent.CIDR()+"\n", outputFile)
+		}
+		content, err := c.formatter.RenderReports(format, []NetworkReport{{Info: adjacent, Subnets: c.calculator.CalculateSubnets(adjacent)}})
+		if err != nil {
+			return err
+		}
+		return c.writeOutput(content, outputFile)
+	}
+}
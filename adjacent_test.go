@@ -0,0 +1,70 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNetworkInfo_Adjacent(t *testing.T) {
+	calculator := NewCIDRCalculator()
+
+	tests := []struct {
+		cidr     string
+		steps    int64
+		expected string
+		err      string
+	}{
+		{cidr: "10.0.0.64/26", steps: 1, expected: "10.0.0.128/26"},
+		{cidr: "10.0.0.64/26", steps: -1, expected: "10.0.0.0/26"},
+		{cidr: "10.0.0.64/26", steps: 4, expected: "10.0.1.64/26"},
+		{cidr: "10.0.0.0/24", steps: -2, expected: "9.255.254.0/24"},
+		{cidr: "2001:db8::/48", steps: 16, expected: "2001:db8:10::/48"},
+		{cidr: "255.255.255.0/24", steps: 1, err: "stepping 1 /24 networks after 255.255.255.0/24 leaves the address space"},
+		{cidr: "0.0.0.0/8", steps: -1, err: "stepping 1 /8 networks before 0.0.0.0/8 leaves the address space"},
+		{cidr: "0.0.0.0/0", steps: 1, err: "leaves the address space"},
+	}
+
+	for _, tt := range tests {
+		adjacent, err := mustParseCIDR(t, calculator, tt.cidr).Adjacent(tt.steps)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s %+d: expected error %q, got %v", tt.cidr, tt.steps, tt.err, err)
+			}
+			continue
+		}
+		if err != nil || adjacent.CIDR() != tt.expected {
+			t.Errorf("%s %+d: expected %s, got %v, %v", tt.cidr, tt.steps, tt.expected, adjacent, err)
+		}
+	}
+}
+
+func TestCLIHandler_NextPrev(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	output := filepath.Join(dir, "next.txt")
+	if err := handler.Run([]string{"cidr-calc", "next", "10.0.0.64/26", "--step", "2", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); string(content) != "10.0.0.192/26\n" {
+		t.Errorf("expected 10.0.0.192/26, got %q", content)
+	}
+
+	output = filepath.Join(dir, "prev.json")
+	if err := handler.Run([]string{"cidr-calc", "prev", "10.0.0.64/26", "--format", "json", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); !strings.Contains(string(content), `"cidr": "10.0.0.0/26"`) {
+		t.Errorf("expected a JSON report of 10.0.0.0/26, got:\n%s", content)
+	}
+
+	for _, args := range [][]string{{"next"}, {"next", "10.0.0.0/24", "10.0.1.0/24"}, {"prev", "10.0.0.0/24", "--step", "0"}} {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// runAggregate implements the aggregate subcommand
+func (c *CLIHandler) runAggregate(args []string) error {
+	flagSet := flag.NewFlagSet("aggregate", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var inputFile, format, outputFile string
+	var tags tagFilterList
+	var write bool
+	flagSet.StringVar(&inputFile, "f", "", "Read CIDRs from file, stdin or URL")
+	flagSet.StringVar(&inputFile, "file", "", "Read CIDRs from file, stdin or URL")
+	flagSet.StringVar(&format, "format", FormatText, "Output format: text (one CIDR per line) or any output format")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	flagSet.Var(&tags, "tag", "Only aggregate -f entries with this key=value tag, or with the key (repeatable)")
+	flagSet.BoolVar(&write, "w", false, "Rewrite the -f plan file in place with the aggregated entries")
+	flagSet.BoolVar(&write, "write", false, "Rewrite the -f plan file in place with the aggregated entries")
+
+	// Accept CIDRs anywhere among the flags
+	cidrs, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	if !IsSupportedFormat(format) {
+		return fmt.Errorf("unsupported output format: %s (supported: %s)", format, strings.Join(SupportedFormats, ", "))
+	}
+
+	if len(cidrs) == 0 && inputFile == "" {
+		return fmt.Errorf("aggregate requires CIDR arguments or -f")
+	}
+	if len(tags) > 0 && inputFile == "" {
+		return fmt.Errorf("--tag filters the entries of a -f plan file")
+	}
+	if write {
+		if inputFile == "" || len(cidrs) > 0 || len(tags) > 0 || outputFile != "" || format != FormatText {
+			return fmt.Errorf("--write rewrites the -f plan file in place and takes no CIDRs, --tag, -o or --format")
+		}
+		return c.aggregateInPlace(inputFile)
+	}
+
+	var networks []*NetworkInfo
+	for _, cidr := range cidrs {
+		info, err := c.calculator.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("failed to parse CIDR %s: %v", cidr, err)
+		}
+		networks = append(networks, info)
+	}
+
+	if inputFile != "" {
+		entries, err := NewBatchReader(defaultFetchTimeout, nil).Read(inputFile)
+		if err != nil {
+			return err
+		}
+		if entries, err = filterEntries(entries, tags, inputFile); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			info, err := c.calculator.ParseCIDR(entry.CIDR)
+			if err != nil {
+				return fmt.Errorf("%s line %d: failed to parse CIDR: %v", entry.Source, entry.Line, err)
+			}
+			networks = append(networks, info)
+		}
+	}
+
+	aggregated := c.calculator.Aggregate(networks)
+	c.notef("aggregated %d CIDRs into %d", len(networks), len(aggregated))
+
+	if format == FormatText {
+		var output strings.Builder
+		for _, network := range aggregated {
+			output.WriteString(network.CIDR() + "\n")
+		}
+		return c.writeOutput(output.String(), outputFile)
+	}
+
+	reports := make([]NetworkReport, 0, len(aggregated))
+	for _, network := range aggregated {
+		reports = append(reports, NetworkReport{Info: network, Subnets: c.calculator.CalculateSubnets(network)})
+	}
+	content, err := c.formatter.RenderReports(format, reports)
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(content, outputFile)
+}
+
+// aggregateInPlace rewrites a plan file with its aggregated entries
+func (c *CLIHandler) aggregateInPlace(source string) error {
+	content, err := readPlanFileToWrite(source)
+	if err != nil {
+		return err
+	}
+	aggregated, merges, err := c.calculator.AggregatePlanFile(source, content)
+	if err != nil {
+		return err
+	}
+	for _, merge := range merges {
+		c.notef("merged %s", merge)
+	}
+	if _, err := rewriteInPlace(source, content, aggregated); err != nil {
+		return err
+	}
+	return nil
+}
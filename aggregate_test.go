@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCLIHandler_Aggregate(t *testing.T) {
+	dir := t.TempDir()
+	routes := filepath.Join(dir, "routes.txt")
+	if err := os.WriteFile(routes, []byte("# branch routes\n10.0.2.0/24\n10.0.3.0/24\n2001:db8::/33\n"), 0644); err != nil {
+		t.Fatalf("failed to write routes: %v", err)
+	}
+
+	handler := NewCLIHandler()
+	var stderr strings.Builder
+	handler.stderr = &stderr
+
+	output := filepath.Join(dir, "aggregated.txt")
+	if err := handler.Run([]string{"cidr-calc", "aggregate", "10.0.0.0/24", "-f", routes, "10.0.1.0/24", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if string(content) != "10.0.0.0/22\n2001:db8::/33\n" {
+		t.Errorf("unexpected aggregates:\n%s", content)
+	}
+	if !strings.Contains(stderr.String(), "Note: aggregated 5 CIDRs into 2") {
+		t.Errorf("expected a summary note, got %q", stderr.String())
+	}
+
+	mdOutput := filepath.Join(dir, "aggregated.md")
+	if err := handler.Run([]string{"cidr-calc", "aggregate", "--format", "md", "-o", mdOutput, "10.0.0.0/25", "10.0.0.128/25"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err = os.ReadFile(mdOutput)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !strings.Contains(string(content), "10.0.0.0/24") {
+		t.Errorf("expected markdown report for 10.0.0.0/24, got:\n%s", content)
+	}
+
+	badRoutes := filepath.Join(dir, "bad.txt")
+	if err := os.WriteFile(badRoutes, []byte("10.0.0.0/24\n10.0.0.0/33\n"), 0644); err != nil {
+		t.Fatalf("failed to write routes: %v", err)
+	}
+	err = handler.Run([]string{"cidr-calc", "aggregate", "-f", badRoutes})
+	if err == nil || !strings.Contains(err.Error(), "bad.txt line 2") {
+		t.Errorf("expected error naming line 2, got %v", err)
+	}
+
+	errorCases := [][]string{
+		{"cidr-calc", "aggregate"},
+		{"cidr-calc", "aggregate", "10.0.0.0"},
+		{"cidr-calc", "aggregate", "--format", "pdf", "10.0.0.0/24"},
+	}
+	for _, args := range errorCases {
+		if err := handler.Run(args); err == nil {
+			t.Errorf("%v: expected error but got none", args)
+		}
+	}
+}
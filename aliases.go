@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// aliasesFile is the file of the configuration directory defining aliases
+const aliasesFile = "aliases"
+
+// aliasNamePattern matches alias names; a name can never be read as an
+// address or CIDR, so aliases cannot shadow them
+var aliasNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// SetAliases makes the alias names usable wherever the calculator parses a
+// CIDR; each name maps to its canonical CIDR
+func (c *CIDRCalculator) SetAliases(aliases map[string]string) {
+	c.aliases = aliases
+}
+
+// expandAlias returns the CIDR of an alias name and the name, or the text
+// itself and no name when it is not an alias
+func (c *CIDRCalculator) expandAlias(text string) (string, string) {
+	if cidr, ok := c.aliases[text]; ok {
+		return cidr, text
+	}
+	return text, ""
+}
+
+// ParseAliases reads alias definitions, one NAME=CIDR per line with # comments,
+// e.g. "corp=10.0.0.0/12". A single address stands for its host CIDR. Names
+// may not repeat or be subcommand names, which would never reach the parser.
+func (c *CIDRCalculator) ParseAliases(source string, content string, reserved map[string]subcommand) (map[string]string, error) {
+	aliases := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for number := 1; scanner.Scan(); number++ {
+		line := scanner.Text()
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%s:%d: expected NAME=CIDR, got %q", source, number, line)
+		case !aliasNamePattern.MatchString(name):
+			return nil, fmt.Errorf("%s:%d: invalid alias name %q (use letters, digits, - and _, starting with a letter)", source, number, name)
+		case aliases[name] != "":
+			return nil, fmt.Errorf("%s:%d: alias %s is defined twice", source, number, name)
+		}
+		if _, ok := reserved[name]; ok {
+			return nil, fmt.Errorf("%s:%d: alias %s is the name of a command", source, number, name)
+		}
+
+		cidr, err := c.NormalizeCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: alias %s: %v", source, number, name, err)
+		}
+		aliases[name] = cidr
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", source, err)
+	}
+	return aliases, nil
+}
+
+// loadAliases reads the aliases file of the configuration directory, if
+// there is one, and lets the calculator expand them
+func (c *CLIHandler) loadAliases() error {
+	if c.configDir == "" {
+		return nil
+	}
+	filename := filepath.Join(c.configDir, aliasesFile)
+	content, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read aliases: %v", err)
+	}
+
+	aliases, err := c.calculator.ParseAliases(filename, string(content), c.subcommands())
+	if err != nil {
+		return err
+	}
+	c.calculator.SetAliases(aliases)
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIDRCalculator_ParseAliases(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	reserved := map[string]subcommand{"stats": nil}
+
+	aliases, err := calculator.ParseAliases("aliases", "# shared networks\ncorp = 10.0.0.0/12\n\ndmz=203.0.113.7/24  # public\ngw=10.0.0.1\nv6-lab=2001:DB8::/48\n", reserved)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"corp": "10.0.0.0/12", "dmz": "203.0.113.0/24", "gw": "10.0.0.1/32", "v6-lab": "2001:db8::/48"}
+	if len(aliases) != len(expected) {
+		t.Errorf("expected %v, got %v", expected, aliases)
+	}
+	for name, cidr := range expected {
+		if aliases[name] != cidr {
+			t.Errorf("%s: expected %s, got %s", name, cidr, aliases[name])
+		}
+	}
+
+	errorCases := []struct {
+		content  string
+		expected string
+	}{
+		{"corp 10.0.0.0/12", "aliases:1: expected NAME=CIDR"},
+		{"\n10x=10.0.0.0/8", "aliases:2: invalid alias name \"10x\""},
+		{"a.b=10.0.0.0/8", "invalid alias name \"a.b\""},
+		{"corp=10.0.0.0/8\ncorp=10.0.0.0/12", "aliases:2: alias corp is defined twice"},
+		{"stats=10.0.0.0/8", "alias stats is the name of a command"},
+		{"corp=10.0.0.0/33", "aliases:1: alias corp:"},
+	}
+	for _, tt := range errorCases {
+		if _, err := calculator.ParseAliases("aliases", tt.content, reserved); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%q: expected error %q, got %v", tt.content, tt.expected, err)
+		}
+	}
+}
+
+func TestCIDRCalculator_ParseCIDRAlias(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	calculator.SetAliases(map[string]string{"corp": "10.0.0.0/12"})
+
+	info, err := calculator.ParseCIDR("corp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.CIDR() != "10.0.0.0/12" || info.Alias != "corp" {
+		t.Errorf("expected corp to be 10.0.0.0/12, got %s (alias %q)", info.CIDR(), info.Alias)
+	}
+	if info := mustParseCIDR(t, calculator, "10.0.0.0/12"); info.Alias != "" {
+		t.Errorf("expected no alias for a CIDR, got %q", info.Alias)
+	}
+	if cidr, err := calculator.NormalizeCIDR(" corp "); err != nil || cidr != "10.0.0.0/12" {
+		t.Errorf("expected corp to normalize to 10.0.0.0/12, got %s, %v", cidr, err)
+	}
+	if _, err := calculator.ParseCIDR("Corp"); err == nil {
+		t.Error("expected alias names to be case sensitive")
+	}
+}
+
+func TestCLIHandler_Aliases(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	handler.configDir = t.TempDir()
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(handler.configDir, aliasesFile), []byte("corp=10.0.0.0/12\ndmz=203.0.113.0/24\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "contains.txt")
+	if err := handler.Run([]string{"cidr-calc", "contains", "corp", "10.3.4.5", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); string(content) != "10.0.0.0/12 contains 10.3.4.5/32\n" {
+		t.Errorf("unexpected contains output %q", content)
+	}
+
+	output = filepath.Join(dir, "report.json")
+	if err := handler.Run([]string{"cidr-calc", "dmz", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	for _, exp := range []string{`"cidr": "203.0.113.0/24"`, `"alias": "dmz"`} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected %q in:\n%s", exp, content)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(handler.configDir, aliasesFile), []byte("corp=10.0.0.0/12\ncorp=10.0.0.0/8\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.Run([]string{"cidr-calc", "10.0.0.0/24"}); err == nil || !strings.Contains(err.Error(), "alias corp is defined twice") {
+		t.Errorf("expected a malformed aliases file to stop the run, got %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FormatPlanFile writes an addressing plan as plan file lines
+const FormatPlanFile = "plan"
+
+// SubnetRequest is one named subnet of an allocation request: a prefix length
+// or the number of hosts it must hold
+type SubnetRequest struct {
+	Name    string
+	Prefix  int
+	Hosts   int
+	Labels  Tags
+	Comment string
+}
+
+// AllocationRequest is a parent network and the subnets wanted in it, read
+// from a YAML or JSON file
+type AllocationRequest struct {
+	Network string
+	Subnets []SubnetRequest
+}
+
+// PlannedSubnet is the subnet assigned to a request
+type PlannedSubnet struct {
+	Request SubnetRequest
+	Subnet  *NetworkInfo
+}
+
+// AddressingPlan is an allocation request with every subnet placed
+type AddressingPlan struct {
+	Network   *NetworkInfo
+	Subnets   []PlannedSubnet // in address order
+	Allocated *big.Int        // addresses in all subnets
+	Free      []string        // free aligned blocks; IPv4 only
+}
+
+// ParseAllocationRequest decodes an allocation request such as
+//
+//	network: 10.20.0.0/16
+//	subnets:
+//	  - name: web
+//	    hosts: 500
+//	  - name: transit
+//	    prefix: 30
+func ParseAllocationRequest(source string, content []byte) (*AllocationRequest, error) {
+	value, err := decodePlanValue(source, content)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := yamlFields(source, "the request", value, "network", "subnets")
+	if err != nil {
+		return nil, err
+	}
+
+	request := &AllocationRequest{}
+	if request.Network, err = yamlString(source, "network", fields["network"]); err != nil {
+		return nil, err
+	}
+	if request.Network == "" {
+		return nil, fmt.Errorf("%s: network is required", source)
+	}
+	items, err := yamlItems(source, "subnets", fields["subnets"])
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%s: subnets lists no requests", source)
+	}
+
+	names := make(map[string]bool)
+	for i, item := range items {
+		what := fmt.Sprintf("subnet %d", i+1)
+		subnetFields, err := yamlFields(source, what, item, "name", "prefix", "hosts", "labels", "comment")
+		if err != nil {
+			return nil, err
+		}
+
+		var subnet SubnetRequest
+		if subnet.Name, err = yamlString(source, what+" name", subnetFields["name"]); err != nil {
+			return nil, err
+		}
+		if subnet.Name == "" || strings.ContainsAny(subnet.Name, " \t#=") {
+			return nil, fmt.Errorf("%s: %s needs a name without spaces, # or =", source, what)
+		}
+		if names[subnet.Name] {
+			return nil, fmt.Errorf("%s: subnet %s is requested twice", source, subnet.Name)
+		}
+		names[subnet.Name] = true
+		what = "subnet " + subnet.Name
+
+		for _, number := range []struct {
+			key    string
+			target *int
+		}{{"prefix", &subnet.Prefix}, {"hosts", &subnet.Hosts}} {
+			text, err := yamlString(source, what+" "+number.key, subnetFields[number.key])
+			if err != nil {
+				return nil, err
+			}
+			if text == "" {
+				continue
+			}
+			if *number.target, err = strconv.Atoi(strings.TrimPrefix(text, "/")); err != nil || *number.target < 1 {
+				return nil, fmt.Errorf("%s: %s %s must be a positive number, got %q", source, what, number.key, text)
+			}
+		}
+		if (subnet.Prefix == 0) == (subnet.Hosts == 0) {
+			return nil, fmt.Errorf("%s: %s needs either prefix or hosts", source, what)
+		}
+
+		if subnet.Comment, err = yamlString(source, what+" comment", subnetFields["comment"]); err != nil {
+			return nil, err
+		}
+		labels, ok := subnetFields["labels"].(map[string]interface{})
+		if subnetFields["labels"] != nil && !ok {
+			return nil, fmt.Errorf("%s: %s labels must be a mapping", source, what)
+		}
+		for key, value := range labels {
+			text, err := yamlString(source, what+" label "+key, value)
+			if err != nil {
+				return nil, err
+			}
+			if key == "name" || strings.ContainsAny(key, " \t#=") || strings.ContainsAny(text, " \t#") {
+				return nil, fmt.Errorf("%s: %s has an invalid label %q", source, what, key+"="+text)
+			}
+			if subnet.Labels == nil {
+				subnet.Labels = make(Tags)
+			}
+			subnet.Labels[key] = text
+		}
+		request.Subnets = append(request.Subnets, subnet)
+	}
+	return request, nil
+}
+
+// PlanAllocation places every requested subnet in the network. Hosts are
+// turned into the smallest prefix that holds them, as with --hosts, and the
+// subnets are carved largest first so they stay aligned without gaps. It is
+// an error when they do not all fit.
+func (c *CIDRCalculator) PlanAllocation(request *AllocationRequest) (*AddressingPlan, error) {
+	network, err := c.ParseCIDR(request.Network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse network %s: %v", request.Network, err)
+	}
+
+	prefixes := make([]int, len(request.Subnets))
+	for i, subnet := range request.Subnets {
+		prefixes[i] = subnet.Prefix
+		if subnet.Hosts > 0 {
+			prefixes[i] = prefixForHosts(network.IsIPv6(), subnet.Hosts)
+		}
+		if prefixes[i] < network.PrefixLength || prefixes[i] > network.MaxPrefix() {
+			return nil, fmt.Errorf("subnet %s (%s) does not fit in %s", subnet.Name, subnet.size(prefixes[i]), network.CIDR())
+		}
+	}
+
+	order := make([]int, len(request.Subnets))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return prefixes[order[i]] < prefixes[order[j]] })
+	sizes := make([]int, len(order))
+	for i, index := range order {
+		sizes[i] = prefixes[index]
+	}
+
+	// Carve names the first prefix that does not fit; name its request instead
+	subnets, err := network.Carve(sizes)
+	if err != nil {
+		for i := 1; i <= len(sizes); i++ {
+			if _, partial := network.Carve(sizes[:i]); partial != nil {
+				subnet := request.Subnets[order[i-1]]
+				return nil, fmt.Errorf("%s cannot fit all subnets: no room left for %s (%s)", network.CIDR(), subnet.Name, subnet.size(sizes[i-1]))
+			}
+		}
+		return nil, err
+	}
+
+	plan := &AddressingPlan{Network: network}
+	var used []*NetworkInfo
+	allocated := new(big.Int)
+	for i, index := range order {
+		subnet := subnets[i]
+		subnet.Tags = Tags{"name": request.Subnets[index].Name}
+		subnet.Derivation = hostsDerivation(network, "plan", request.Subnets[index].Hosts, prefixes[index])
+		for key, value := range request.Subnets[index].Labels {
+			subnet.Tags[key] = value
+		}
+		plan.Subnets = append(plan.Subnets, PlannedSubnet{Request: request.Subnets[index], Subnet: &subnet})
+		used = append(used, &subnet)
+		allocated.Add(allocated, new(big.Int).Lsh(big.NewInt(1), uint(subnet.MaxPrefix()-subnet.PrefixLength)))
+	}
+	sort.SliceStable(plan.Subnets, func(i, j int) bool {
+		return addressInt(plan.Subnets[i].Subnet.NetworkID).Cmp(addressInt(plan.Subnets[j].Subnet.NetworkID)) < 0
+	})
+	plan.Allocated = allocated
+	plan.Free = c.FreeBlocks(network, used)
+	return plan, nil
+}
+
+// size describes what a request asked for and the prefix it gets
+func (r SubnetRequest) size(prefix int) string {
+	if r.Hosts > 0 {
+		return fmt.Sprintf("%d hosts, /%d", r.Hosts, prefix)
+	}
+	return fmt.Sprintf("/%d", prefix)
+}
+
+// Reports returns a network report per planned subnet, tagged with its name
+// and labels, for the regular output formats
+func (p *AddressingPlan) Reports(calculator *CIDRCalculator) []NetworkReport {
+	reports := make([]NetworkReport, 0, len(p.Subnets))
+	for _, planned := range p.Subnets {
+		reports = append(reports, NetworkReport{Info: planned.Subnet, Subnets: calculator.CalculateSubnets(planned.Subnet)})
+	}
+	return reports
+}
+
+// PlanFile returns the planned subnets as plan file lines, with the name and
+// labels as tags
+func (p *AddressingPlan) PlanFile() string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("# addressing plan for %s\n", p.Network.CIDR()))
+	for _, planned := range p.Subnets {
+		line := PlanRange{CIDR: planned.Subnet.CIDR(), Labels: planned.Subnet.Tags, Comment: planned.Request.Comment}
+		output.WriteString(line.Line() + "\n")
+	}
+	return output.String()
+}
+
+// FormatAddressingPlan renders the planned subnets, what each was requested
+// as, and the free space left
+func (f *OutputFormatter) FormatAddressingPlan(plan *AddressingPlan) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Addressing Plan for %s:\n", plan.Network.CIDR()))
+	output.WriteString(fmt.Sprintf("  %-16s %-12s %-22s %-40s %s\n", "Name", "Requested", "Subnet", "Usable Range", "Usable"))
+	for _, planned := range plan.Subnets {
+		subnet := planned.Subnet
+		requested := fmt.Sprintf("/%d", planned.Request.Prefix)
+		if planned.Request.Hosts > 0 {
+			requested = fmt.Sprintf("%d hosts", planned.Request.Hosts)
+		}
+		output.WriteString(fmt.Sprintf("  %-16s %-12s %-22s %-40s %s\n", planned.Request.Name, requested, subnet.CIDR(),
+			fmt.Sprintf("%s - %s", subnet.FirstUsableIP, subnet.LastUsableIP), subnet.HostCount()))
+	}
+
+	size := new(big.Int).Lsh(big.NewInt(1), uint(plan.Network.MaxPrefix()-plan.Network.PrefixLength))
+	percent, _ := new(big.Rat).SetFrac(new(big.Int).Mul(plan.Allocated, big.NewInt(100)), size).Float64()
+	output.WriteString(fmt.Sprintf("\n  %-15s %s of %s addresses (%.1f%%)\n", "Allocated:", plan.Allocated, size, percent))
+	if !plan.Network.IsIPv6() {
+		output.WriteString("\nFree Space:\n")
+		if len(plan.Free) == 0 {
+			output.WriteString("  none\n")
+		}
+		for _, block := range plan.Free {
+			output.WriteString(fmt.Sprintf("  %s\n", block))
+		}
+	}
+
+	return output.String()
+}
+
+// runPlanBuild implements the plan build command
+func (c *CLIHandler) runPlanBuild(args []string) error {
+	flagSet := flag.NewFlagSet("plan build", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var format, outputFile string
+	flagSet.StringVar(&format, "format", FormatText, "Output format: text, plan (a plan file) or any output format")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept the request file anywhere among the flags
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	if len(positional) != 1 {
+		return fmt.Errorf("plan build takes one YAML or JSON request file, got %d", len(positional))
+	}
+	if format != FormatPlanFile && !IsSupportedFormat(format) {
+		return fmt.Errorf("unsupported output format: %s (supported: %s, %s)", format, FormatPlanFile, strings.Join(SupportedFormats, ", "))
+	}
+
+	content, err := os.ReadFile(positional[0])
+	if err != nil {
+		return fmt.Errorf("failed to read request: %v", err)
+	}
+	request, err := ParseAllocationRequest(positional[0], content)
+	if err != nil {
+		return err
+	}
+	plan, err := c.calculator.PlanAllocation(request)
+	if err != nil {
+		return err
+	}
+
+	var output string
+	switch format {
+	case FormatText:
+		output = c.formatter.FormatAddressingPlan(plan)
+	case FormatPlanFile:
+		output = plan.PlanFile()
+	default:
+		if output, err = c.formatter.RenderReports(format, plan.Reports(c.calculator)); err != nil {
+			return err
+		}
+	}
+	return c.writeOutput(output, outputFile)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseAllocationRequest(t *testing.T) {
+	request, err := ParseAllocationRequest("request.yaml", []byte(`network: 10.20.0.0/22
+subnets:
+  - name: web
+    hosts: 200
+    labels:
+      env: prod
+  - name: db
+    prefix: /25
+    comment: primary
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if request.Network != "10.20.0.0/22" || len(request.Subnets) != 2 {
+		t.Fatalf("unexpected request %+v", request)
+	}
+	if web := request.Subnets[0]; web.Name != "web" || web.Hosts != 200 || web.Labels["env"] != "prod" {
+		t.Errorf("unexpected web request %+v", web)
+	}
+	if db := request.Subnets[1]; db.Prefix != 25 || db.Hosts != 0 || db.Comment != "primary" {
+		t.Errorf("unexpected db request %+v", db)
+	}
+
+	json, err := ParseAllocationRequest("request.json", []byte(`{"network": "2001:db8::/48", "subnets": [{"name": "lan", "prefix": 64}]}`))
+	if err != nil || json.Subnets[0].Prefix != 64 {
+		t.Errorf("expected a JSON request, got %+v, %v", json, err)
+	}
+
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{content: "subnets:\n  - name: a\n    hosts: 2\n", err: "network is required"},
+		{content: "network: 10.0.0.0/24\n", err: "subnets lists no requests"},
+		{content: "network: 10.0.0.0/24\nsubnets:\n  - hosts: 2\n", err: "needs a name"},
+		{content: "network: 10.0.0.0/24\nsubnets:\n  - name: a\n    hosts: 2\n  - name: a\n    hosts: 2\n", err: "subnet a is requested twice"},
+		{content: "network: 10.0.0.0/24\nsubnets:\n  - name: a\n", err: "subnet a needs either prefix or hosts"},
+		{content: "network: 10.0.0.0/24\nsubnets:\n  - name: a\n    hosts: 2\n    prefix: 30\n", err: "needs either prefix or hosts"},
+		{content: "network: 10.0.0.0/24\nsubnets:\n  - name: a\n    hosts: many\n", err: "hosts must be a positive number"},
+		{content: "network: 10.0.0.0/24\nsubnets:\n  - name: a\n    size: 2\n", err: `unknown field "size"`},
+	}
+	for _, tt := range tests {
+		if _, err := ParseAllocationRequest("request.yaml", []byte(tt.content)); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: expected error %q, got %v", tt.content, tt.err, err)
+		}
+	}
+}
+
+func TestCIDRCalculator_PlanAllocation(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	request := &AllocationRequest{Network: "10.20.0.0/22", Subnets: []SubnetRequest{
+		{Name: "transit", Hosts: 2},
+		{Name: "web", Hosts: 200},
+		{Name: "db", Prefix: 25},
+	}}
+
+	plan, err := calculator.PlanAllocation(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, planned := range plan.Subnets {
+		got = append(got, planned.Request.Name+"="+planned.Subnet.CIDR())
+	}
+	if strings.Join(got, " ") != "web=10.20.0.0/24 db=10.20.1.0/25 transit=10.20.1.128/31" {
+		t.Errorf("unexpected plan %v", got)
+	}
+	if plan.Allocated.String() != "386" || plan.Free[len(plan.Free)-1] != "10.20.2.0/23" {
+		t.Errorf("expected 386 allocated addresses and 10.20.2.0/23 free, got %s and %v", plan.Allocated, plan.Free)
+	}
+	if plan.Subnets[0].Subnet.Tags["name"] != "web" {
+		t.Errorf("expected the subnet to be tagged with its name, got %v", plan.Subnets[0].Subnet.Tags)
+	}
+
+	tests := []struct {
+		request *AllocationRequest
+		err     string
+	}{
+		{request: &AllocationRequest{Network: "10.20.0.0/22", Subnets: []SubnetRequest{{Name: "a", Prefix: 23}, {Name: "b", Hosts: 500}, {Name: "c", Prefix: 30}}},
+			err: "10.20.0.0/22 cannot fit all subnets: no room left for c (/30)"},
+		{request: &AllocationRequest{Network: "10.20.0.0/22", Subnets: []SubnetRequest{{Name: "huge", Hosts: 5000}}},
+			err: "subnet huge (5000 hosts, /19) does not fit in 10.20.0.0/22"},
+		{request: &AllocationRequest{Network: "10.20.0.0/22", Subnets: []SubnetRequest{{Name: "a", Prefix: 33}}},
+			err: "does not fit"},
+		{request: &AllocationRequest{Network: "10.20.0.0", Subnets: []SubnetRequest{{Name: "a", Prefix: 24}}},
+			err: "failed to parse network"},
+	}
+	for _, tt := range tests {
+		if _, err := calculator.PlanAllocation(tt.request); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%+v: expected error %q, got %v", tt.request, tt.err, err)
+		}
+	}
+}
+
+func TestCLIHandler_PlanBuild(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	request := filepath.Join(dir, "request.yaml")
+	os.WriteFile(request, []byte("network: 2001:db8::/48\nsubnets:\n  - name: lan\n    prefix: 64\n    comment: office\n  - name: dmz\n    prefix: 56\n"), 0644)
+
+	output := filepath.Join(dir, "plan.txt")
+	if err := handler.Run([]string{"cidr-calc", "plan", "build", request, "--format", "plan", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "# addressing plan for 2001:db8::/48\n2001:db8::/56 name=dmz\n2001:db8:0:100::/64 name=lan # office\n"
+	if content, _ := os.ReadFile(output); string(content) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, content)
+	}
+	entries, err := NewBatchReader(defaultFetchTimeout, nil).Read(output)
+	if err != nil || len(entries) != 2 || entries[1].Tags["name"] != "lan" {
+		t.Errorf("expected the plan file to read back, got %+v, %v", entries, err)
+	}
+
+	output = filepath.Join(dir, "plan.json")
+	if err := handler.Run([]string{"cidr-calc", "plan", "build", request, "--format", "json", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); !strings.Contains(string(content), `"cidr": "2001:db8:0:100::/64"`) || !strings.Contains(string(content), `"name": "lan"`) {
+		t.Errorf("expected a JSON report of the tagged subnets, got:\n%s", content)
+	}
+
+	for _, args := range [][]string{{"plan", "build"}, {"plan", "build", request, "--format", "yaml"}, {"plan", "build", filepath.Join(dir, "missing.yaml")}} {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+)
+
+// awsTag is a key/value tag on an AWS resource
+type awsTag struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+// awsVPCs is the output of aws ec2 describe-vpcs
+type awsVPCs struct {
+	Vpcs []struct {
+		VpcId                   string `json:"VpcId"`
+		CidrBlock               string `json:"CidrBlock"`
+		CidrBlockAssociationSet []struct {
+			CidrBlock      string `json:"CidrBlock"`
+			CidrBlockState struct {
+				State string `json:"State"`
+			} `json:"CidrBlockState"`
+		} `json:"CidrBlockAssociationSet"`
+		Tags []awsTag `json:"Tags"`
+	} `json:"Vpcs"`
+}
+
+// awsSubnets is the output of aws ec2 describe-subnets
+type awsSubnets struct {
+	Subnets []struct {
+		SubnetId         string   `json:"SubnetId"`
+		VpcId            string   `json:"VpcId"`
+		CidrBlock        string   `json:"CidrBlock"`
+		AvailabilityZone string   `json:"AvailabilityZone"`
+		Tags             []awsTag `json:"Tags"`
+	} `json:"Subnets"`
+}
+
+// AWSInventorySource lists VPCs and subnets through the AWS CLI, which picks up
+// the standard credential chain (environment, shared config, SSO, instance roles)
+type AWSInventorySource struct {
+	region  string
+	profile string
+	run     commandRunner
+}
+
+// NewAWSInventorySource creates an inventory source for one region
+func NewAWSInventorySource(region, profile string, run commandRunner) *AWSInventorySource {
+	return &AWSInventorySource{region: region, profile: profile, run: run}
+}
+
+// Inventory returns every VPC in the region together with its subnets
+func (a *AWSInventorySource) Inventory() (*CloudInventory, error) {
+	var vpcs awsVPCs
+	if err := a.describe("describe-vpcs", &vpcs); err != nil {
+		return nil, err
+	}
+
+	var subnets awsSubnets
+	if err := a.describe("describe-subnets", &subnets); err != nil {
+		return nil, err
+	}
+
+	// Qualify the region with the profile so several accounts stay distinguishable
+	scope := a.region
+	if a.profile != "" {
+		scope = a.profile + "/" + a.region
+	}
+	inventory := &CloudInventory{Provider: "AWS", Region: scope}
+	index := make(map[string]int)
+
+	for _, vpc := range vpcs.Vpcs {
+		network := CloudNetwork{ID: vpc.VpcId, Name: awsName(vpc.Tags)}
+
+		for _, association := range vpc.CidrBlockAssociationSet {
+			if association.CidrBlockState.State == "associated" {
+				network.CIDRs = append(network.CIDRs, association.CidrBlock)
+			}
+		}
+		if len(network.CIDRs) == 0 && vpc.CidrBlock != "" {
+			network.CIDRs = []string{vpc.CidrBlock}
+		}
+
+		index[vpc.VpcId] = len(inventory.Networks)
+		inventory.Networks = append(inventory.Networks, network)
+	}
+
+	for _, subnet := range subnets.Subnets {
+		i, ok := index[subnet.VpcId]
+		if !ok {
+			continue
+		}
+		inventory.Networks[i].Subnets = append(inventory.Networks[i].Subnets, CloudSubnet{
+			ID:   subnet.SubnetId,
+			Name: awsName(subnet.Tags),
+			CIDR: subnet.CidrBlock,
+			Zone: subnet.AvailabilityZone,
+		})
+	}
+
+	return inventory, nil
+}
+
+// describe runs an aws ec2 describe command and decodes its JSON output
+func (a *AWSInventorySource) describe(command string, result interface{}) error {
+	args := []string{"ec2", command, "--region", a.region, "--output", "json"}
+	if a.profile != "" {
+		args = append(args, "--profile", a.profile)
+	}
+
+	output, err := a.run("aws", args...)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(output, result); err != nil {
+		return fmt.Errorf("failed to parse aws ec2 %s output: %v", command, err)
+	}
+
+	return nil
+}
+
+// awsName returns the value of the Name tag, if any
+func awsName(tags []awsTag) string {
+	for _, tag := range tags {
+		if tag.Key == "Name" {
+			return tag.Value
+		}
+	}
+	return ""
+}
+
+// runAWSAudit implements the aws-audit subcommand
+func (c *CLIHandler) runAWSAudit(args []string) error {
+	flagSet := flag.NewFlagSet("aws-audit", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var region, profile, format, outputFile string
+	var emit stringList
+	flagSet.StringVar(&region, "region", "", "AWS region to audit")
+	flagSet.StringVar(&profile, "profile", "", "AWS CLI profile to use")
+	flagSet.StringVar(&format, "format", FormatText, "Report format: text, gh-annotations or any output format")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	flagSet.Var(&emit, "emit", emitFlagUsage)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	emitTargets, err := parseEmitTargets(emit)
+	if err != nil {
+		return err
+	}
+
+	if region == "" {
+		return fmt.Errorf("aws-audit requires --region")
+	}
+
+	inventory, err := NewAWSInventorySource(region, profile, c.run).Inventory()
+	if err != nil {
+		return err
+	}
+
+	return c.writeCloudAudit(format, outputFile, emitTargets, NewCloudAuditor().Audit(inventory))
+}
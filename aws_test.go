@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testAWSVPCs = `{"Vpcs": [
+  {"VpcId": "vpc-1", "CidrBlock": "10.0.0.0/16",
+   "CidrBlockAssociationSet": [
+     {"CidrBlock": "10.0.0.0/16", "CidrBlockState": {"State": "associated"}},
+     {"CidrBlock": "10.1.0.0/16", "CidrBlockState": {"State": "disassociated"}}
+   ],
+   "Tags": [{"Key": "env", "Value": "prod"}, {"Key": "Name", "Value": "prod"}]},
+  {"VpcId": "vpc-2", "CidrBlock": "172.16.0.0/24"}
+]}`
+
+const testAWSSubnets = `{"Subnets": [
+  {"SubnetId": "subnet-1", "VpcId": "vpc-1", "CidrBlock": "10.0.0.0/24", "AvailabilityZone": "eu-central-1a", "Tags": [{"Key": "Name", "Value": "app"}]},
+  {"SubnetId": "subnet-2", "VpcId": "vpc-1", "CidrBlock": "10.0.1.0/24", "AvailabilityZone": "eu-central-1b"},
+  {"SubnetId": "subnet-9", "VpcId": "vpc-gone", "CidrBlock": "10.9.0.0/24"}
+]}`
+
+// fakeAWS returns a command runner that answers describe calls with canned JSON
+func fakeAWS(vpcs, subnets string) commandRunner {
+	return func(name string, args ...string) ([]byte, error) {
+		command := strings.Join(append([]string{name}, args...), " ")
+		switch {
+		case strings.HasPrefix(command, "aws ec2 describe-vpcs --region eu-central-1"):
+			return []byte(vpcs), nil
+		case strings.HasPrefix(command, "aws ec2 describe-subnets --region eu-central-1"):
+			return []byte(subnets), nil
+		}
+		return nil, fmt.Errorf("unexpected command: %s", command)
+	}
+}
+
+func TestAWSInventorySource_Inventory(t *testing.T) {
+	var calls []string
+	fake := fakeAWS(testAWSVPCs, testAWSSubnets)
+	run := func(name string, args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		return fake(name, args...)
+	}
+
+	inventory, err := NewAWSInventorySource("eu-central-1", "audit", run).Inventory()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(calls) != 2 || !strings.HasSuffix(calls[0], "--output json --profile audit") {
+		t.Errorf("unexpected aws calls: %v", calls)
+	}
+
+	if inventory.Provider != "AWS" || inventory.Region != "audit/eu-central-1" || len(inventory.Networks) != 2 {
+		t.Fatalf("unexpected inventory: %+v", inventory)
+	}
+
+	prod := inventory.Networks[0]
+	if prod.ID != "vpc-1" || prod.Name != "prod" || strings.Join(prod.CIDRs, ",") != "10.0.0.0/16" {
+		t.Errorf("unexpected VPC: %+v", prod)
+	}
+	if len(prod.Subnets) != 2 || prod.Subnets[0] != (CloudSubnet{ID: "subnet-1", Name: "app", CIDR: "10.0.0.0/24", Zone: "eu-central-1a"}) {
+		t.Errorf("unexpected subnets: %+v", prod.Subnets)
+	}
+
+	lab := inventory.Networks[1]
+	if strings.Join(lab.CIDRs, ",") != "172.16.0.0/24" || len(lab.Subnets) != 0 {
+		t.Errorf("expected VPC without associations to fall back to CidrBlock: %+v", lab)
+	}
+
+	if _, err := NewAWSInventorySource("eu-central-1", "", fakeAWS("not json", testAWSSubnets)).Inventory(); err == nil {
+		t.Errorf("expected error for invalid JSON")
+	}
+}
+
+func TestCLIHandler_AWSAudit(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	handler.run = fakeAWS(testAWSVPCs, testAWSSubnets)
+
+	output := filepath.Join(t.TempDir(), "audit.txt")
+	if err := handler.Run([]string{"cidr-calc", "aws-audit", "--region", "eu-central-1", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	for _, exp := range []string{"AWS Audit (eu-central-1):", "Network vpc-1 (prod): 10.0.0.0/16", "10.0.2.0/23", "No problems found"} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected report to contain %q, got:\n%s", exp, content)
+		}
+	}
+
+	csvOutput := filepath.Join(t.TempDir(), "audit.csv")
+	if err := handler.Run([]string{"cidr-calc", "aws-audit", "--region", "eu-central-1", "--format", "csv", "-o", csvOutput}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err = os.ReadFile(csvOutput)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	if !strings.Contains(string(content), "172.16.0.0/24,172.16.0.128/25") {
+		t.Errorf("expected CSV rows for every VPC, got:\n%s", content)
+	}
+
+	overlapping := `{"Subnets": [
+	  {"SubnetId": "subnet-1", "VpcId": "vpc-1", "CidrBlock": "10.0.0.0/24"},
+	  {"SubnetId": "subnet-2", "VpcId": "vpc-1", "CidrBlock": "10.0.0.0/25"}
+	]}`
+	handler.run = fakeAWS(testAWSVPCs, overlapping)
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectError string
+	}{
+		{name: "findings with errors", args: []string{"--region", "eu-central-1", "--format", "gh-annotations", "-o", output}, expectError: "audit found 1 error"},
+		{name: "missing region", args: []string{}, expectError: "requires --region"},
+		{name: "unsupported format", args: []string{"--region", "eu-central-1", "--format", "pdf"}, expectError: "unsupported audit format"},
+		{name: "aws failure", args: []string{"--region", "us-east-1"}, expectError: "unexpected command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := handler.Run(append([]string{"cidr-calc", "aws-audit"}, tt.args...))
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
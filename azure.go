@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// azureVNet is a virtual network as returned by az network vnet list
+type azureVNet struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Location     string `json:"location"`
+	AddressSpace struct {
+		AddressPrefixes []string `json:"addressPrefixes"`
+	} `json:"addressSpace"`
+	Subnets []struct {
+		ID              string   `json:"id"`
+		Name            string   `json:"name"`
+		AddressPrefix   string   `json:"addressPrefix"`
+		AddressPrefixes []string `json:"addressPrefixes"`
+	} `json:"subnets"`
+}
+
+// AzureInventorySource lists virtual networks and subnets through the Azure CLI,
+// using whatever account az login (or a managed identity) has configured
+type AzureInventorySource struct {
+	subscription  string
+	resourceGroup string
+	location      string
+	run           commandRunner
+}
+
+// NewAzureInventorySource creates an inventory source, optionally limited to a
+// resource group and a location
+func NewAzureInventorySource(subscription, resourceGroup, location string, run commandRunner) *AzureInventorySource {
+	return &AzureInventorySource{subscription: subscription, resourceGroup: resourceGroup, location: location, run: run}
+}
+
+// Inventory returns every virtual network together with its subnets
+func (a *AzureInventorySource) Inventory() (*CloudInventory, error) {
+	args := []string{"network", "vnet", "list", "--output", "json"}
+	if a.subscription != "" {
+		args = append(args, "--subscription", a.subscription)
+	}
+	if a.resourceGroup != "" {
+		args = append(args, "--resource-group", a.resourceGroup)
+	}
+
+	output, err := a.run("az", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var vnets []azureVNet
+	if err := json.Unmarshal(output, &vnets); err != nil {
+		return nil, fmt.Errorf("failed to parse az network vnet list output: %v", err)
+	}
+
+	inventory := &CloudInventory{Provider: "Azure", Region: a.scope()}
+
+	for _, vnet := range vnets {
+		if a.location != "" && !strings.EqualFold(vnet.Location, a.location) {
+			continue
+		}
+
+		network := CloudNetwork{ID: vnet.Name, CIDRs: vnet.AddressSpace.AddressPrefixes}
+		for _, subnet := range vnet.Subnets {
+			prefixes := subnet.AddressPrefixes
+			if len(prefixes) == 0 && subnet.AddressPrefix != "" {
+				prefixes = []string{subnet.AddressPrefix}
+			}
+			for _, prefix := range prefixes {
+				network.Subnets = append(network.Subnets, CloudSubnet{ID: subnet.Name, CIDR: prefix, Zone: vnet.Location})
+			}
+		}
+
+		inventory.Networks = append(inventory.Networks, network)
+	}
+
+	return inventory, nil
+}
+
+// scope describes which part of the account the inventory covers
+func (a *AzureInventorySource) scope() string {
+	var parts []string
+	if a.subscription != "" {
+		parts = append(parts, a.subscription)
+	}
+	if a.resourceGroup != "" {
+		parts = append(parts, a.resourceGroup)
+	}
+	if a.location != "" {
+		parts = append(parts, a.location)
+	}
+	if len(parts) == 0 {
+		return "default subscription"
+	}
+	return strings.Join(parts, "/")
+}
+
+// runAzureAudit implements the azure-audit subcommand
+func (c *CLIHandler) runAzureAudit(args []string) error {
+	flagSet := flag.NewFlagSet("azure-audit", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var subscription, resourceGroup, location, format, outputFile string
+	var emit stringList
+	flagSet.StringVar(&subscription, "subscription", "", "Azure subscription name or ID")
+	flagSet.StringVar(&resourceGroup, "resource-group", "", "Only audit this resource group")
+	flagSet.StringVar(&location, "location", "", "Only audit virtual networks in this location")
+	flagSet.StringVar(&format, "format", FormatText, "Report format: text, gh-annotations or any output format")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	flagSet.Var(&emit, "emit", emitFlagUsage)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	emitTargets, err := parseEmitTargets(emit)
+	if err != nil {
+		return err
+	}
+
+	inventory, err := NewAzureInventorySource(subscription, resourceGroup, location, c.run).Inventory()
+	if err != nil {
+		return err
+	}
+
+	return c.writeCloudAudit(format, outputFile, emitTargets, NewCloudAuditor().Audit(inventory))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testAzureVNets = `[
+  {"id": "/subscriptions/s/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/hub",
+   "name": "hub", "location": "westeurope",
+   "addressSpace": {"addressPrefixes": ["10.10.0.0/16"]},
+   "subnets": [
+     {"name": "GatewaySubnet", "addressPrefix": "10.10.0.0/27"},
+     {"name": "app", "addressPrefixes": ["10.10.1.0/24", "10.10.2.0/24"]}
+   ]},
+  {"name": "spoke", "location": "northeurope",
+   "addressSpace": {"addressPrefixes": ["10.20.0.0/16"]},
+   "subnets": []}
+]`
+
+// fakeCLI returns a command runner that answers one command prefix with canned output
+func fakeCLI(prefix, output string) commandRunner {
+	return func(name string, args ...string) ([]byte, error) {
+		command := strings.Join(append([]string{name}, args...), " ")
+		if strings.HasPrefix(command, prefix) {
+			return []byte(output), nil
+		}
+		return nil, fmt.Errorf("unexpected command: %s", command)
+	}
+}
+
+func TestAzureInventorySource_Inventory(t *testing.T) {
+	run := fakeCLI("az network vnet list --output json --subscription prod --resource-group rg", testAzureVNets)
+
+	inventory, err := NewAzureInventorySource("prod", "rg", "", run).Inventory()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if inventory.Provider != "Azure" || inventory.Region != "prod/rg" || len(inventory.Networks) != 2 {
+		t.Fatalf("unexpected inventory: %+v", inventory)
+	}
+
+	hub := inventory.Networks[0]
+	if hub.ID != "hub" || strings.Join(hub.CIDRs, ",") != "10.10.0.0/16" {
+		t.Errorf("unexpected VNet: %+v", hub)
+	}
+
+	var subnets []string
+	for _, subnet := range hub.Subnets {
+		subnets = append(subnets, subnet.ID+"="+subnet.CIDR)
+	}
+	if strings.Join(subnets, " ") != "GatewaySubnet=10.10.0.0/27 app=10.10.1.0/24 app=10.10.2.0/24" {
+		t.Errorf("unexpected subnets: %v", subnets)
+	}
+
+	filtered, err := NewAzureInventorySource("prod", "rg", "NorthEurope", run).Inventory()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(filtered.Networks) != 1 || filtered.Networks[0].ID != "spoke" {
+		t.Errorf("expected location filter to keep only spoke, got %+v", filtered.Networks)
+	}
+
+	if _, err := NewAzureInventorySource("", "", "", fakeCLI("az", "{")).Inventory(); err == nil {
+		t.Errorf("expected error for invalid JSON")
+	}
+}
+
+func TestCLIHandler_AzureAudit(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.run = fakeCLI("az network vnet list", testAzureVNets)
+
+	output := filepath.Join(t.TempDir(), "audit.txt")
+	if err := handler.Run([]string{"cidr-calc", "azure-audit", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	for _, exp := range []string{"Azure Audit (default subscription):", "Network hub: 10.10.0.0/16", "10.10.0.32/27", "No problems found"} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected report to contain %q, got:\n%s", exp, content)
+		}
+	}
+
+	handler.run = fakeCLI("gcloud", "")
+	if err := handler.Run([]string{"cidr-calc", "azure-audit"}); err == nil {
+		t.Errorf("expected error when az fails")
+	}
+}
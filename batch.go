@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultFetchTimeout bounds how long a batch list download may take
+	defaultFetchTimeout = 30 * time.Second
+
+	// stdinFilename is the -f value that reads the list from standard input
+	stdinFilename = "-"
+)
+
+// BatchEntry is a single CIDR read from a batch input source
+type BatchEntry struct {
+	Source  string
+	Line    int
+	CIDR    string
+	Tags    Tags
+	Comment string // text after # on the line, e.g. why a range is reserved
+
+	// Annotation is the other words after the CIDR, such as a name or VLAN
+	Annotation string
+}
+
+// Context returns what the entry has after its CIDR on a plan file line: the
+// annotation, tags and comment, or "" when it has none
+func (e BatchEntry) Context() string {
+	return strings.TrimSpace(strings.TrimPrefix(planRangeFromEntry(e).Line(), e.CIDR))
+}
+
+// BatchReader loads CIDR lists from files, standard input or HTTP(S) URLs
+type BatchReader struct {
+	client  *http.Client
+	headers http.Header
+	stdin   io.Reader
+}
+
+// NewBatchReader creates a batch reader whose HTTP requests use the given timeout and headers
+func NewBatchReader(timeout time.Duration, headers http.Header) *BatchReader {
+	if timeout <= 0 {
+		timeout = defaultFetchTimeout
+	}
+
+	return &BatchReader{
+		client:  &http.Client{Timeout: timeout},
+		headers: headers,
+		stdin:   os.Stdin,
+	}
+}
+
+// Read loads all CIDR entries from a file path, "-" for standard input, or an http(s) URL
+func (b *BatchReader) Read(source string) ([]BatchEntry, error) {
+	return b.ReadSection(source, SectionAllocations)
+}
+
+// ReadSection loads the CIDR entries of a source like Read; when the source is
+// a plan document, they are the ranges of the section. The files a plan file
+// pulls in with !include directives are read in their place.
+func (b *BatchReader) ReadSection(source, section string) ([]BatchEntry, error) {
+	entries, err := b.readSection(source, section, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no CIDRs found in %s", source)
+	}
+
+	return entries, nil
+}
+
+// readSection reads the entries of a source and of the files it includes;
+// including holds the files whose directives led to it, to catch cycles
+func (b *BatchReader) readSection(source, section string, including []string) ([]BatchEntry, error) {
+	reader, err := b.open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", source, err)
+	}
+	if isPlanDocument(content) {
+		return parsePlanSection(source, bytes.NewReader(content), section)
+	}
+
+	return scanPlanLines(source, bytes.NewReader(content), func(line int, pattern string) ([]BatchEntry, error) {
+		return b.include(source, line, pattern, section, append(append([]string(nil), including...), source))
+	})
+}
+
+// include reads the files matching an !include pattern of a plan file, in
+// name order. A relative pattern is relative to the directory of the plan
+// file, or the working directory for standard input.
+func (b *BatchReader) include(source string, line int, pattern, section string, including []string) ([]BatchEntry, error) {
+	if isURL(source) {
+		return nil, fmt.Errorf("%s line %d: %s is not supported in plan files fetched over HTTP", source, line, includeDirective)
+	}
+	if !filepath.IsAbs(pattern) && source != stdinFilename {
+		pattern = filepath.Join(filepath.Dir(source), pattern)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s line %d: invalid %s pattern %s: %v", source, line, includeDirective, pattern, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%s line %d: %s %s matches no files", source, line, includeDirective, pattern)
+	}
+
+	var entries []BatchEntry
+	for _, match := range matches {
+		for i, file := range including {
+			if file != stdinFilename && sameFile(file, match) {
+				return nil, fmt.Errorf("%s line %d: %s cycle: %s", source, line, includeDirective,
+					strings.Join(append(including[i:], match), " -> "))
+			}
+		}
+		matchEntries, err := b.readSection(match, section, including)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, matchEntries...)
+	}
+	return entries, nil
+}
+
+// sameFile reports whether two paths name the same file
+func sameFile(a, b string) bool {
+	first, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	second, err := os.Stat(b)
+	return err == nil && os.SameFile(first, second)
+}
+
+// open returns a reader for the batch source
+func (b *BatchReader) open(source string) (io.ReadCloser, error) {
+	if source == stdinFilename {
+		return io.NopCloser(b.stdin), nil
+	}
+
+	if isURL(source) {
+		return b.fetch(source)
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %v", err)
+	}
+	return file, nil
+}
+
+// fetch downloads a batch list over HTTP(S)
+func (b *BatchReader) fetch(url string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input URL %s: %v", url, err)
+	}
+
+	for name, values := range b.headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", url, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// parseBatch extracts one CIDR per line, skipping blank lines and # comments.
+// A comment after a CIDR is kept with its entry. A plan document gives its
+// allocations.
+func parseBatch(source string, reader io.Reader) ([]BatchEntry, error) {
+	return parsePlanSection(source, reader, SectionAllocations)
+}
+
+// parsePlanSection reads the entries of a plan file, or the ranges of a
+// section of a plan document
+func parsePlanSection(source string, reader io.Reader, section string) ([]BatchEntry, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", source, err)
+	}
+	if isPlanDocument(content) {
+		document, err := parsePlanDocument(source, content)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := document.Entries(source, section)
+		if err != nil {
+			return nil, err
+		}
+		documentLines(source, content, section, entries)
+		return entries, nil
+	}
+	return parsePlanLines(source, bytes.NewReader(content))
+}
+
+// parsePlanLines extracts the entries of a plan file. Its !include directives
+// are skipped, as the files they name are only read through a BatchReader.
+func parsePlanLines(source string, reader io.Reader) ([]BatchEntry, error) {
+	return scanPlanLines(source, reader, nil)
+}
+
+// scanPlanLines extracts the entries of a plan file, calling include for the
+// patterns of every !include directive and putting the entries it returns in
+// place of the directive. A nil include skips the directives.
+func scanPlanLines(source string, reader io.Reader, include func(line int, pattern string) ([]BatchEntry, error)) ([]BatchEntry, error) {
+	var entries []BatchEntry
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line, comment, _ := strings.Cut(scanner.Text(), "#")
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if patterns, ok := parseInclude(fields); ok {
+			if len(patterns) == 0 {
+				return nil, fmt.Errorf("%s line %d: %s needs a file or pattern", source, lineNumber, includeDirective)
+			}
+			for _, pattern := range patterns {
+				if include == nil {
+					continue
+				}
+				included, err := include(lineNumber, pattern)
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, included...)
+			}
+			continue
+		}
+
+		entries = append(entries, BatchEntry{
+			Source:  source,
+			Line:    lineNumber,
+			CIDR:    fields[0],
+			Tags:    parseTags(fields[1:]),
+			Comment: strings.TrimSpace(comment),
+
+			Annotation: parseAnnotation(fields[1:]),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", source, err)
+	}
+
+	return entries, nil
+}
+
+// includeDirective starts a plan file line naming other plan files to read
+// in its place, e.g. "!include sites/*.txt"
+const includeDirective = "!include"
+
+// parseInclude returns the file patterns of an !include line
+func parseInclude(fields []string) ([]string, bool) {
+	if len(fields) == 0 || fields[0] != includeDirective {
+		return nil, false
+	}
+	return fields[1:], true
+}
+
+// isURL reports whether the input source should be fetched over HTTP(S)
+func isURL(source string) bool {
+	lower := strings.ToLower(source)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// headerList collects repeated --header "Name: value" flags
+type headerList http.Header
+
+// String implements flag.Value
+func (h headerList) String() string {
+	var parts []string
+	for name, values := range h {
+		for _, value := range values {
+			parts = append(parts, name+": "+value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Set implements flag.Value, parsing a single "Name: value" header
+func (h headerList) Set(value string) error {
+	name, headerValue, found := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !found || name == "" {
+		return fmt.Errorf("invalid header %q (expected \"Name: value\")", value)
+	}
+
+	http.Header(h).Add(name, strings.TrimSpace(headerValue))
+	return nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBatch(t *testing.T) {
+	input := `# production blocks
+10.0.0.0/16
+
+  172.16.0.0/22   # office
+192.168.1.0/24 vlan-10
+10.20.0.0/16 env=prod team=payments # tagged
+`
+
+	entries, err := parseBatch("plan.txt", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []BatchEntry{
+		{Source: "plan.txt", Line: 2, CIDR: "10.0.0.0/16"},
+		{Source: "plan.txt", Line: 4, CIDR: "172.16.0.0/22", Comment: "office"},
+		{Source: "plan.txt", Line: 5, CIDR: "192.168.1.0/24", Annotation: "vlan-10"},
+		{Source: "plan.txt", Line: 6, CIDR: "10.20.0.0/16", Tags: Tags{"env": "prod", "team": "payments"}, Comment: "tagged"},
+	}
+
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(expected), len(entries), entries)
+	}
+
+	for i, exp := range expected {
+		if !reflect.DeepEqual(entries[i], exp) {
+			t.Errorf("entry %d: expected %+v, got %+v", i, exp, entries[i])
+		}
+	}
+}
+
+func TestBatchReader_ReadFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cidrs.txt")
+	if err := os.WriteFile(filename, []byte("10.0.0.0/24\n10.0.1.0/24\n"), 0644); err != nil {
+		t.Fatalf("failed to write input file: %v", err)
+	}
+
+	reader := NewBatchReader(0, nil)
+
+	entries, err := reader.Read(filename)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 entries, got %d", len(entries))
+	}
+
+	if _, err := reader.Read(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
+
+func TestBatchReader_ReadInclude(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"plan.txt":          "10.0.0.0/24 core\n!include sites/*.txt # per-site files\n10.9.0.0/24\n",
+		"sites/berlin.txt":  "10.1.0.0/24 berlin\n",
+		"sites/paris.txt":   "!include ../shared/paris.yaml\n",
+		"shared/paris.yaml": "version: 1\nallocations:\n  - cidr: 10.2.0.0/24\n    annotation: paris\n",
+		"cycle.txt":         "!include cycle.txt\n",
+		"empty.txt":         "!include\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	reader := NewBatchReader(0, nil)
+	entries, err := reader.Read(filepath.Join(dir, "plan.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, fmt.Sprintf("%s:%d %s %s", filepath.Base(entry.Source), entry.Line, entry.CIDR, entry.Annotation))
+	}
+	expected := []string{"plan.txt:1 10.0.0.0/24 core", "berlin.txt:1 10.1.0.0/24 berlin", "paris.yaml:3 10.2.0.0/24 paris", "plan.txt:3 10.9.0.0/24 "}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	// Outside a BatchReader the directives are skipped
+	if entries, err := parseBatch("plan.txt", strings.NewReader(files["plan.txt"])); err != nil || len(entries) != 2 {
+		t.Errorf("expected the 2 entries of the file itself, got %v, %v", entries, err)
+	}
+
+	for name, exp := range map[string]string{
+		"cycle.txt": "line 1: !include cycle: ",
+		"empty.txt": "line 1: !include needs a file or pattern",
+	} {
+		if _, err := reader.Read(filepath.Join(dir, name)); err == nil || !strings.Contains(err.Error(), exp) {
+			t.Errorf("%s: expected %q, got %v", name, exp, err)
+		}
+	}
+	reader.stdin = strings.NewReader("!include missing/*.txt\n")
+	if _, err := reader.Read("-"); err == nil || err.Error() != "- line 1: !include missing/*.txt matches no files" {
+		t.Errorf("expected no matches, got %v", err)
+	}
+}
+
+func TestBatchReader_ReadStdin(t *testing.T) {
+	reader := NewBatchReader(0, nil)
+	reader.stdin = strings.NewReader("192.168.0.0/16\n")
+
+	entries, err := reader.Read("-")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].CIDR != "192.168.0.0/16" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestBatchReader_ReadURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/plans/prod-cidrs.txt":
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("# prod\n10.10.0.0/16\n10.20.0.0/16\n"))
+		case "/empty.txt":
+			w.Write([]byte("# nothing here\n"))
+		case "/slow.txt":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("10.0.0.0/8\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	authorized := http.Header{}
+	authorized.Set("Authorization", "Bearer secret")
+
+	tests := []struct {
+		name        string
+		path        string
+		headers     http.Header
+		timeout     time.Duration
+		expectCount int
+		expectError string
+	}{
+		{
+			name:        "authorized fetch",
+			path:        "/plans/prod-cidrs.txt",
+			headers:     authorized,
+			expectCount: 2,
+		},
+		{
+			name:        "missing auth header",
+			path:        "/plans/prod-cidrs.txt",
+			expectError: "401 Unauthorized",
+		},
+		{
+			name:        "not found",
+			path:        "/missing.txt",
+			expectError: "404 Not Found",
+		},
+		{
+			name:        "empty list",
+			path:        "/empty.txt",
+			expectError: "no CIDRs found",
+		},
+		{
+			name:        "timeout",
+			path:        "/slow.txt",
+			timeout:     50 * time.Millisecond,
+			expectError: "failed to fetch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := NewBatchReader(tt.timeout, tt.headers)
+
+			entries, err := reader.Read(server.URL + tt.path)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(entries) != tt.expectCount {
+				t.Errorf("expected %d entries, got %d", tt.expectCount, len(entries))
+			}
+		})
+	}
+}
+
+func TestHeaderList_Set(t *testing.T) {
+	headers := http.Header{}
+	list := headerList(headers)
+
+	if err := list.Set("Authorization: Bearer abc:def"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := list.Set("X-Team:netops"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := headers.Get("Authorization"); got != "Bearer abc:def" {
+		t.Errorf("unexpected Authorization header %q", got)
+	}
+	if got := headers.Get("X-Team"); got != "netops" {
+		t.Errorf("unexpected X-Team header %q", got)
+	}
+
+	for _, invalid := range []string{"no-colon", ": value"} {
+		if err := list.Set(invalid); err == nil {
+			t.Errorf("expected error for header %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// exitBatchFailures is the exit status of a batch run whose report leaves out
+// entries that failed, telling it apart from a run that produced no report
+const exitBatchFailures = 3
+
+// BatchError is a batch entry that could not be calculated
+type BatchError struct {
+	Source string
+	Line   int
+	CIDR   string
+	Err    error
+}
+
+// Error names the source line of the failed entry
+func (e BatchError) Error() string {
+	return fmt.Sprintf("%s line %d: %v", e.Source, e.Line, e.Err)
+}
+
+// reportBatch writes the report of the batch entries that were calculated,
+// listing the failed ones in it, and then returns an error naming the first
+// failure and how many failed. When every entry failed there is nothing to
+// report and only the error is returned.
+func (c *CLIHandler) reportBatch(failures []BatchError, total int, write func() error) error {
+	if len(failures) == 0 {
+		return write()
+	}
+
+	summary := fmt.Errorf("%v (%d of %d batch entries failed)", failures[0], len(failures), total)
+	if len(failures) == total {
+		return summary
+	}
+
+	c.formatter.BatchErrors = failures
+	defer func() { c.formatter.BatchErrors = nil }()
+	if err := write(); err != nil {
+		return err
+	}
+	return &ExitError{Code: exitBatchFailures, Err: summary}
+}
+
+// jsonBatchError is a failed batch entry in JSON reports
+type jsonBatchError struct {
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+	CIDR   string `json:"cidr"`
+	Error  string `json:"error"`
+}
+
+// xmlBatchErrors lists the failed batch entries of XML reports
+type xmlBatchErrors struct {
+	Errors []xmlBatchError `xml:"error"`
+}
+
+// xmlBatchError is a single failed batch entry; the message is its text
+type xmlBatchError struct {
+	Source  string `xml:"source,attr"`
+	Line    int    `xml:"line,attr"`
+	CIDR    string `xml:"cidr,attr"`
+	Message string `xml:",chardata"`
+}
+
+// batchErrorRows returns a Source, Line, CIDR and Error row per failed entry
+func (f *OutputFormatter) batchErrorRows() [][]string {
+	rows := make([][]string, 0, len(f.BatchErrors))
+	for _, failure := range f.BatchErrors {
+		rows = append(rows, []string{failure.Source, strconv.Itoa(failure.Line), failure.CIDR, failure.Err.Error()})
+	}
+	return rows
+}
+
+// batchErrorsTitle heads the failed entries section of a report
+func (f *OutputFormatter) batchErrorsTitle() string {
+	if len(f.BatchErrors) == 1 {
+		return "Batch Errors (1 failed entry)"
+	}
+	return fmt.Sprintf("Batch Errors (%d failed entries)", len(f.BatchErrors))
+}
+
+// FormatBatchErrors formats the failed batch entries as a section to append
+// to a text or document report, or returns "" when none failed
+func (f *OutputFormatter) FormatBatchErrors(format string) string {
+	if len(f.BatchErrors) == 0 {
+		return ""
+	}
+
+	headers := []string{"Source", "Line", "CIDR", "Error"}
+	switch format {
+	case FormatMD:
+		return "\n## " + f.batchErrorsTitle() + "\n\n" + markdownTable(headers, f.batchErrorRows())
+	case FormatOrg:
+		return "\n* " + f.batchErrorsTitle() + "\n\n" + orgTable(headers, f.batchErrorRows())
+	case FormatRST:
+		return "\n" + rstHeading(f.batchErrorsTitle(), "=") + rstTable(headers, f.batchErrorRows())
+	case FormatLaTeX:
+		return "\n" + latexTable("Failed batch entries", headers, f.batchErrorRows())
+	}
+
+	var output strings.Builder
+	output.WriteString("\n" + f.batchErrorsTitle() + ":\n")
+	for _, failure := range f.BatchErrors {
+		output.WriteString(fmt.Sprintf("  %s line %d: %s: %v\n", failure.Source, failure.Line, failure.CIDR, failure.Err))
+	}
+	return output.String()
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCLIHandler_BatchErrors(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = &strings.Builder{}
+	dir := t.TempDir()
+
+	input := filepath.Join(dir, "plan.txt")
+	if err := os.WriteFile(input, []byte("10.0.0.0/24\n10.0.1.0/33\n10.0.2.0/24\n10.0.3.0/24x\n"), 0644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+
+	output := filepath.Join(dir, "report.txt")
+	err := handler.Run([]string{"cidr-calc", "-f", input, "-o", output})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != exitBatchFailures {
+		t.Fatalf("expected exit status %d, got %v", exitBatchFailures, err)
+	}
+	if !strings.HasPrefix(err.Error(), input+" line 2: failed to parse CIDR") || !strings.HasSuffix(err.Error(), "(2 of 4 batch entries failed)") {
+		t.Errorf("unexpected summary: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	for _, exp := range []string{
+		"CIDR:           10.0.0.0/24",
+		"CIDR:           10.0.2.0/24",
+		"\nBatch Errors (2 failed entries):\n  " + input + " line 2: 10.0.1.0/33: failed to parse CIDR",
+		"\n  " + input + " line 4: 10.0.3.0/24x: failed to parse CIDR",
+	} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected report to contain %q, got:\n%s", exp, content)
+		}
+	}
+
+	// JSON lists the failures next to the networks
+	jsonOutput := filepath.Join(dir, "report.json")
+	if err := handler.Run([]string{"cidr-calc", "-f", input, "-o", jsonOutput}); err == nil {
+		t.Fatal("expected an error for the failed entries")
+	}
+	content, err = os.ReadFile(jsonOutput)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	var document jsonReport
+	if err := json.Unmarshal(content, &document); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(document.Networks) != 2 || len(document.Errors) != 2 {
+		t.Fatalf("expected 2 networks and 2 errors, got %d and %d", len(document.Networks), len(document.Errors))
+	}
+	if failure := document.Errors[1]; failure.Line != 4 || failure.CIDR != "10.0.3.0/24x" || !strings.HasPrefix(failure.Error, "failed to parse CIDR") {
+		t.Errorf("unexpected error entry: %+v", failure)
+	}
+
+	// CSV gains an Error column
+	csvOutput := filepath.Join(dir, "report.csv")
+	if err := handler.Run([]string{"cidr-calc", "-f", input, "-o", csvOutput}); err == nil {
+		t.Fatal("expected an error for the failed entries")
+	}
+	content, err = os.ReadFile(csvOutput)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if header := records[0]; header[len(header)-1] != "Error" {
+		t.Errorf("expected an Error column, got %v", header)
+	}
+	last := records[len(records)-1]
+	if last[0] != "10.0.3.0/24x" || !strings.HasPrefix(last[len(last)-1], input+" line 4: failed to parse CIDR") {
+		t.Errorf("unexpected error row: %v", last)
+	}
+
+	// --fail-fast keeps the old behaviour: no report
+	failFast := filepath.Join(dir, "fail-fast.txt")
+	err = handler.Run([]string{"cidr-calc", "--fail-fast", "-f", input, "-o", failFast})
+	if err == nil || err.Error() != input+" line 2: failed to parse CIDR: prefix length must be between 0 and 32, got: 33" {
+		t.Errorf("expected the first failure, got %v", err)
+	}
+	if _, err := os.Stat(failFast); !os.IsNotExist(err) {
+		t.Errorf("expected no output file with --fail-fast")
+	}
+
+	// Nothing is written when every entry failed
+	bad := filepath.Join(dir, "bad.txt")
+	if err := os.WriteFile(bad, []byte("10.0.0.0/33\n"), 0644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+	none := filepath.Join(dir, "none.txt")
+	err = handler.Run([]string{"cidr-calc", "-f", bad, "-o", none})
+	if err == nil || errors.As(err, &exitErr) || !strings.HasSuffix(err.Error(), "(1 of 1 batch entries failed)") {
+		t.Errorf("expected a plain error, got %v", err)
+	}
+	if _, err := os.Stat(none); !os.IsNotExist(err) {
+		t.Errorf("expected no output file when every entry failed")
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "--fail-fast", "10.0.0.0/24"}); err == nil || err.Error() != "--fail-fast applies to -f batch runs" {
+		t.Errorf("expected --fail-fast to require -f, got %v", err)
+	}
+}
+
+func TestOutputFormatter_FormatBatchErrors(t *testing.T) {
+	formatter := NewOutputFormatter()
+	if out := formatter.FormatBatchErrors(FormatText); out != "" {
+		t.Errorf("expected no section without errors, got %q", out)
+	}
+
+	formatter.BatchErrors = []BatchError{{Source: "plan.txt", Line: 3, CIDR: "10.0.0.0/33", Err: errors.New("bad prefix")}}
+	tests := map[string]string{
+		FormatText:  "\nBatch Errors (1 failed entry):\n  plan.txt line 3: 10.0.0.0/33: bad prefix\n",
+		FormatMD:    "\n## Batch Errors (1 failed entry)\n\n| Source   | Line | CIDR        | Error      |",
+		FormatOrg:   "\n* Batch Errors (1 failed entry)\n\n| Source",
+		FormatRST:   "\nBatch Errors (1 failed entry)\n=============================\n\n",
+		FormatLaTeX: "\\caption{Failed batch entries}",
+	}
+	for format, exp := range tests {
+		if out := formatter.FormatBatchErrors(format); !strings.Contains(out, exp) {
+			t.Errorf("%s: expected %q in:\n%s", format, exp, out)
+		}
+	}
+
+	info, err := NewCIDRCalculator().ParseCIDR("10.0.0.0/24")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+	reports := []NetworkReport{{Info: info}}
+	for format, exp := range map[string]string{
+		FormatHTML:  "<h2>Batch Errors (1 failed entry)</h2>",
+		FormatXML:   `<error source="plan.txt" line="3" cidr="10.0.0.0/33">bad prefix</error>`,
+		FormatSlack: "plan.txt line 3 `10.0.0.0/33`: bad prefix",
+		FormatTeams: `"title": "plan.txt line 3"`,
+	} {
+		out, err := formatter.RenderReports(format, reports)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		if !strings.Contains(out, exp) {
+			t.Errorf("%s: expected %q in:\n%s", format, exp, out)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// binaryRow is an address or mask written in binary, split at the prefix
+// length into its network bits and host bits
+type binaryRow struct {
+	Label   string
+	Network string
+	Host    string
+}
+
+// binaryGroups writes the bits of an address or mask in octets separated by
+// dots for IPv4, or in 16-bit groups separated by colons for IPv6, and splits
+// them at the prefix length. A separator at the boundary is dropped.
+func binaryGroups(value []byte, prefix int) (string, string) {
+	groupBits, separator := 8, "."
+	if len(value) == net.IPv6len {
+		groupBits, separator = 16, ":"
+	}
+
+	var network, host strings.Builder
+	for bit := 0; bit < len(value)*8; bit++ {
+		part := &network
+		if bit >= prefix {
+			part = &host
+		}
+		if bit > 0 && bit%groupBits == 0 && bit != prefix {
+			part.WriteString(separator)
+		}
+		if value[bit/8]&(0x80>>uint(bit%8)) != 0 {
+			part.WriteByte('1')
+		} else {
+			part.WriteByte('0')
+		}
+	}
+	return network.String(), host.String()
+}
+
+// binaryRows returns the network ID, subnet mask and wildcard mask in binary
+func (f *OutputFormatter) binaryRows(info *NetworkInfo) []binaryRow {
+	address := []byte(info.NetworkID.To4())
+	bits := 32
+	if info.IsIPv6() {
+		address = []byte(info.NetworkID.To16())
+		bits = 128
+	}
+	mask := []byte(net.CIDRMask(info.PrefixLength, bits))
+	wildcard := make([]byte, len(mask))
+	for i := range mask {
+		wildcard[i] = ^mask[i]
+	}
+
+	rows := make([]binaryRow, 0, 3)
+	for _, value := range []struct {
+		label string
+		bytes []byte
+	}{{"Network ID", address}, {"Subnet Mask", mask}, {"Wildcard Mask", wildcard}} {
+		network, host := binaryGroups(value.bytes, info.PrefixLength)
+		rows = append(rows, binaryRow{Label: value.label, Network: network, Host: host})
+	}
+	return rows
+}
+
+// binaryHeading describes the split of the bits, e.g. "24 network bits, 8 host bits"
+func binaryHeading(info *NetworkInfo) string {
+	return fmt.Sprintf("%d network bits, %d host bits", info.PrefixLength, info.MaxPrefix()-info.PrefixLength)
+}
+
+// FormatBinary formats the bit breakdown for console display; a bar marks the
+// boundary between the network and host bits
+func (f *OutputFormatter) FormatBinary(info *NetworkInfo) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Binary Breakdown (%s):\n", binaryHeading(info)))
+	for _, row := range f.binaryRows(info) {
+		output.WriteString(fmt.Sprintf("  %-15s %s\n", row.Label+":", strings.TrimSpace(row.Network+" | "+row.Host)))
+	}
+
+	return output.String()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBinaryGroups(t *testing.T) {
+	tests := []struct {
+		value   []byte
+		prefix  int
+		network string
+		host    string
+	}{
+		{[]byte{192, 168, 1, 64}, 26, "11000000.10101000.00000001.01", "000000"},
+		{[]byte{255, 0, 0, 0}, 8, "11111111", "00000000.00000000.00000000"},
+		{[]byte{10, 0, 0, 1}, 32, "00001010.00000000.00000000.00000001", ""},
+		{[]byte{0, 0, 0, 255}, 0, "", "00000000.00000000.00000000.11111111"},
+	}
+
+	for _, tt := range tests {
+		network, host := binaryGroups(tt.value, tt.prefix)
+		if network != tt.network || host != tt.host {
+			t.Errorf("%v/%d: expected %q | %q, got %q | %q", tt.value, tt.prefix, tt.network, tt.host, network, host)
+		}
+	}
+}
+
+func TestOutputFormatter_Binary(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := &OutputFormatter{Binary: true}
+
+	info := mustParseCIDR(t, calculator, "192.168.1.64/26")
+	text := formatter.FormatNetworkInfo(info)
+	for _, expected := range []string{
+		"Binary Breakdown (26 network bits, 6 host bits):",
+		"Network ID:     11000000.10101000.00000001.01 | 000000",
+		"Subnet Mask:    11111111.11111111.11111111.11 | 000000",
+		"Wildcard Mask:  00000000.00000000.00000000.00 | 111111",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("expected %q in:\n%s", expected, text)
+		}
+	}
+
+	html := formatter.FormatAsHTML(info, nil)
+	if !strings.Contains(html, `<span class="network-bits">11111111.11111111.11111111.11</span><span class="host-bits">000000</span>`) {
+		t.Errorf("expected the split subnet mask in the HTML report")
+	}
+
+	ipv6 := mustParseCIDR(t, calculator, "2001:db8::/32")
+	if rows := formatter.binaryRows(ipv6); rows[0].Network != "0010000000000001:0000110110111000" || strings.Count(rows[0].Host, ":") != 5 {
+		t.Errorf("unexpected IPv6 breakdown %+v", rows[0])
+	}
+
+	if plain := (&OutputFormatter{}).FormatAsHTML(info, nil); strings.Contains(plain, "Binary Breakdown") {
+		t.Error("expected no binary breakdown without Binary")
+	}
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"html/template"
+	"path/filepath"
+	"strings"
+)
+
+// Buddy tree node states
+const (
+	BuddyFree      = "free"
+	BuddyAllocated = "allocated"
+	BuddyReserved  = "reserved"
+	BuddySplit     = "split"
+)
+
+// svgWidth and svgRowHeight size the buddy tree diagram
+const (
+	svgWidth     = 960
+	svgRowHeight = 28
+)
+
+// BuddyNode is a block of a pool in its binary buddy tree. Free and allocated
+// nodes are leaves; a split node has the two halves as children.
+type BuddyNode struct {
+	Network    *NetworkInfo
+	State      string
+	Allocation *BatchEntry // the state or reserved entry covering an allocated node
+	Children   []*BuddyNode
+}
+
+// Label describes the allocation of a node: its tags and comment, and the
+// covering CIDR when that is larger than the node
+func (n *BuddyNode) Label() string {
+	if n.Allocation == nil {
+		return ""
+	}
+	var parts []string
+	if n.Allocation.CIDR != n.Network.CIDR() {
+		parts = append(parts, "part of "+n.Allocation.CIDR)
+	}
+	if len(n.Allocation.Tags) > 0 {
+		parts = append(parts, n.Allocation.Tags.String())
+	}
+	if n.Allocation.Comment != "" {
+		parts = append(parts, "# "+n.Allocation.Comment)
+	}
+	return strings.Join(parts, " ")
+}
+
+// BuddyMerge is an allocated block whose buddy is free: releasing or moving it
+// merges the two into one free block of the parent size
+type BuddyMerge struct {
+	Allocated *BuddyNode
+	Buddy     *BuddyNode
+	Parent    *BuddyNode
+}
+
+// BuddyTree is the buddy tree of a pool with a summary of its free space
+type BuddyTree struct {
+	Root     *BuddyNode
+	Free     []*BuddyNode // free leaves in address order
+	FreeSize uint64
+	Largest  *BuddyNode // the largest free block, lowest address first
+	Merges   []BuddyMerge
+	Depth    int    // levels below the root
+	Used     uint64 // allocated and reserved addresses
+}
+
+// Fragmentation returns the percentage of free addresses outside the largest
+// free block: 0 when all free space is one block
+func (t *BuddyTree) Fragmentation() float64 {
+	if t.FreeSize == 0 {
+		return 0
+	}
+	return float64(t.FreeSize-blockSize(t.Largest.Network)) * 100 / float64(t.FreeSize)
+}
+
+// blockSize returns the number of addresses in an IPv4 network
+func blockSize(network *NetworkInfo) uint64 {
+	return uint64(1) << uint(32-network.PrefixLength)
+}
+
+// BuildBuddyTree splits the IPv4 pool in halves until every block is either
+// free or inside one allocation. Reserved ranges are marked apart from allocations.
+func (c *CIDRCalculator) BuildBuddyTree(pool *NetworkInfo, allocations, reserved []planNetwork) (*BuddyTree, error) {
+	if pool.IsIPv6() {
+		return nil, fmt.Errorf("buddy trees support IPv4 pools only")
+	}
+
+	root, err := c.buddyNode(pool, allocations, reserved)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &BuddyTree{Root: root}
+	tree.summarize(root, 0)
+	return tree, nil
+}
+
+// buddyNode builds the subtree of a block from the allocations inside it
+func (c *CIDRCalculator) buddyNode(network *NetworkInfo, allocations, reserved []planNetwork) (*BuddyNode, error) {
+	if entry := coveringEntry(reserved, network); entry != nil {
+		return &BuddyNode{Network: network, State: BuddyReserved, Allocation: entry}, nil
+	}
+	if entry := coveringEntry(allocations, network); entry != nil {
+		return &BuddyNode{Network: network, State: BuddyAllocated, Allocation: entry}, nil
+	}
+
+	node := &BuddyNode{Network: network, State: BuddyFree}
+	inside, insideReserved := rangesWithin(allocations, network), rangesWithin(reserved, network)
+	if len(inside) == 0 && len(insideReserved) == 0 {
+		return node, nil
+	}
+
+	node.State = BuddySplit
+	start, _ := ipRange(network)
+	half := blockSize(network) / 2
+	for _, offset := range []uint64{0, half} {
+		info, err := c.ParseCIDR(fmt.Sprintf("%s/%d", uint32ToIPv4(uint32(start+offset)), network.PrefixLength+1))
+		if err != nil {
+			return nil, err
+		}
+		child, err := c.buddyNode(info, inside, insideReserved)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+	}
+	return node, nil
+}
+
+// coveringEntry returns the entry of the first range that contains the network
+func coveringEntry(ranges []planNetwork, network *NetworkInfo) *BatchEntry {
+	for i := range ranges {
+		if ranges[i].info.Contains(network) {
+			return &ranges[i].entry
+		}
+	}
+	return nil
+}
+
+// rangesWithin returns the ranges that lie inside the network
+func rangesWithin(ranges []planNetwork, network *NetworkInfo) []planNetwork {
+	var within []planNetwork
+	for _, r := range ranges {
+		if network.Contains(r.info) {
+			within = append(within, r)
+		}
+	}
+	return within
+}
+
+// summarize collects the free blocks and merge opportunities below node
+func (t *BuddyTree) summarize(node *BuddyNode, depth int) {
+	if depth > t.Depth {
+		t.Depth = depth
+	}
+
+	switch node.State {
+	case BuddyFree:
+		t.Free = append(t.Free, node)
+		t.FreeSize += blockSize(node.Network)
+		if t.Largest == nil || blockSize(node.Network) > blockSize(t.Largest.Network) {
+			t.Largest = node
+		}
+	case BuddyAllocated, BuddyReserved:
+		t.Used += blockSize(node.Network)
+	case BuddySplit:
+		low, high := node.Children[0], node.Children[1]
+		if low.State == BuddyAllocated && high.State == BuddyFree {
+			t.Merges = append(t.Merges, BuddyMerge{Allocated: low, Buddy: high, Parent: node})
+		}
+		if high.State == BuddyAllocated && low.State == BuddyFree {
+			t.Merges = append(t.Merges, BuddyMerge{Allocated: high, Buddy: low, Parent: node})
+		}
+		for _, child := range node.Children {
+			t.summarize(child, depth+1)
+		}
+	}
+}
+
+// FormatBuddyTree renders the buddy tree as ASCII art followed by the free
+// space summary and merge opportunities
+func (f *OutputFormatter) FormatBuddyTree(tree *BuddyTree) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Buddy Tree for %s:\n", tree.Root.Network.CIDR()))
+	width := 0
+	f.buddyWidth(tree.Root, 0, &width)
+	f.writeBuddyNode(&output, tree.Root, "", "", width)
+
+	output.WriteString("\n")
+	for _, line := range f.buddySummary(tree) {
+		output.WriteString("  " + line + "\n")
+	}
+
+	output.WriteString("\nMerge Opportunities:\n")
+	if len(tree.Merges) == 0 {
+		output.WriteString("  none\n")
+	}
+	for _, merge := range tree.Merges {
+		output.WriteString("  " + describeMerge(merge) + "\n")
+	}
+
+	return output.String()
+}
+
+// buddyWidth finds the width of the widest indented CIDR in the tree
+func (f *OutputFormatter) buddyWidth(node *BuddyNode, depth int, width *int) {
+	if w := 4*depth + len(node.Network.CIDR()); w > *width {
+		*width = w
+	}
+	for _, child := range node.Children {
+		f.buddyWidth(child, depth+1, width)
+	}
+}
+
+// writeBuddyNode writes a node line and, indented below it, its children
+func (f *OutputFormatter) writeBuddyNode(output *strings.Builder, node *BuddyNode, branch, indent string, width int) {
+	line := fmt.Sprintf("  %-*s  %s", width, branch+node.Network.CIDR(), node.State)
+	if label := node.Label(); label != "" {
+		line += "  " + label
+	}
+	output.WriteString(line + "\n")
+
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			f.writeBuddyNode(output, child, indent+"`-- ", indent+"    ", width)
+		} else {
+			f.writeBuddyNode(output, child, indent+"|-- ", indent+"|   ", width)
+		}
+	}
+}
+
+// buddySummary returns the free space, largest free block and fragmentation lines
+func (f *OutputFormatter) buddySummary(tree *BuddyTree) []string {
+	total := blockSize(tree.Root.Network)
+	lines := []string{
+		fmt.Sprintf("%-15s %d of %d addresses (%.1f%%)", "Used:", tree.Used, total, float64(tree.Used)*100/float64(total)),
+		fmt.Sprintf("%-15s %d addresses", "Free:", tree.FreeSize),
+		fmt.Sprintf("%-15s %d", "Free Blocks:", len(tree.Free)),
+	}
+	if tree.Largest != nil {
+		lines = append(lines,
+			fmt.Sprintf("%-15s %s (%d addresses)", "Largest Free:", tree.Largest.Network.CIDR(), blockSize(tree.Largest.Network)),
+			fmt.Sprintf("%-15s %.1f%% of free space is outside the largest free block", "Fragmentation:", tree.Fragmentation()))
+	}
+	return lines
+}
+
+// describeMerge explains what releasing the allocated half of a merge gains
+func describeMerge(merge BuddyMerge) string {
+	return fmt.Sprintf("releasing %s would merge it with its free buddy %s into %s",
+		merge.Allocated.Network.CIDR(), merge.Buddy.Network.CIDR(), merge.Parent.Network.CIDR())
+}
+
+// buddyColors fill the diagram blocks by state
+var buddyColors = map[string]string{
+	BuddyFree:      "#2ecc71",
+	BuddyAllocated: "#e74c3c",
+	BuddyReserved:  "#f39c12",
+	BuddySplit:     "#bdc3c7",
+}
+
+// FormatBuddyTreeAsSVG draws the tree as an icicle diagram: the pool is the
+// top row and every level below halves the blocks, each as wide as its share
+// of the pool
+func (f *OutputFormatter) FormatBuddyTreeAsSVG(tree *BuddyTree) string {
+	var output strings.Builder
+
+	height := (tree.Depth + 1) * svgRowHeight
+	output.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		svgWidth, height, svgWidth, height))
+	start, _ := ipRange(tree.Root.Network)
+	f.writeSVGNode(&output, tree.Root, start, float64(svgWidth)/float64(blockSize(tree.Root.Network)), 0)
+	output.WriteString("</svg>\n")
+
+	return output.String()
+}
+
+// writeSVGNode draws a node and its children; scale is pixels per address
+func (f *OutputFormatter) writeSVGNode(output *strings.Builder, node *BuddyNode, poolStart uint64, scale float64, depth int) {
+	start, _ := ipRange(node.Network)
+	x := float64(start-poolStart) * scale
+	width := float64(blockSize(node.Network)) * scale
+	y := depth * svgRowHeight
+
+	title := node.Network.CIDR() + " " + node.State
+	if label := node.Label(); label != "" {
+		title += " " + label
+	}
+	output.WriteString(fmt.Sprintf(`  <g><title>%s</title><rect x="%.2f" y="%d" width="%.2f" height="%d" fill="%s" stroke="#fff"/>`,
+		html.EscapeString(title), x, y, width, svgRowHeight, buddyColors[node.State]))
+	// Only label blocks wide enough for their CIDR
+	if width >= 80 {
+		output.WriteString(fmt.Sprintf(`<text x="%.2f" y="%d" text-anchor="middle">%s</text>`,
+			x+width/2, y+svgRowHeight/2+4, node.Network.CIDR()))
+	}
+	output.WriteString("</g>\n")
+
+	for _, child := range node.Children {
+		f.writeSVGNode(output, child, poolStart, scale, depth+1)
+	}
+}
+
+// buddyPage is the data of the buddy tree HTML page
+type buddyPage struct {
+	Pool    string
+	Diagram template.HTML
+	Legend  []buddyLegend
+	Summary []string
+	Merges  []string
+}
+
+// buddyLegend is a state and its diagram color
+type buddyLegend struct {
+	State string
+	Color template.CSS
+}
+
+// FormatBuddyTreeAsHTML renders a page with the diagram, a legend, the summary
+// and the merge opportunities
+func (f *OutputFormatter) FormatBuddyTreeAsHTML(tree *BuddyTree) string {
+	page := buddyPage{
+		Pool:    tree.Root.Network.CIDR(),
+		Diagram: template.HTML(f.FormatBuddyTreeAsSVG(tree)),
+		Summary: f.buddySummary(tree),
+	}
+	for _, state := range []string{BuddyAllocated, BuddyReserved, BuddyFree, BuddySplit} {
+		page.Legend = append(page.Legend, buddyLegend{State: state, Color: template.CSS(buddyColors[state])})
+	}
+	for _, merge := range tree.Merges {
+		page.Merges = append(page.Merges, describeMerge(merge))
+	}
+
+	var output strings.Builder
+	tmpl := template.Must(template.New("buddy-tree").Parse(buddyHTMLTemplate))
+	if err := tmpl.Execute(&output, page); err != nil {
+		return fmt.Sprintf("Error generating HTML: %v", err)
+	}
+	return output.String()
+}
+
+// runBuddyTree implements the buddy-tree subcommand
+func (c *CLIHandler) runBuddyTree(args []string) error {
+	flagSet := flag.NewFlagSet("buddy-tree", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var poolCIDR, stateFile, format, outputFile string
+	var reserved *ReservedPolicy
+	flagSet.StringVar(&poolCIDR, "pool", "", "Pool to draw")
+	flagSet.StringVar(&stateFile, "state", "", "Plan file of allocations")
+	flagSet.Var(&policyFlag{target: &reserved}, "reserved", "File of reserved CIDRs")
+	flagSet.StringVar(&format, "format", "", "Output format: text, html or svg")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if flagSet.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", flagSet.Arg(0))
+	}
+	if poolCIDR == "" {
+		return fmt.Errorf("buddy-tree requires --pool")
+	}
+
+	pool, err := c.calculator.ParseCIDR(poolCIDR)
+	if err != nil {
+		return fmt.Errorf("failed to parse pool %s: %v", poolCIDR, err)
+	}
+	allocations, _, err := c.usedRanges(stateFile, nil)
+	if err != nil {
+		return err
+	}
+	var reservedRanges []planNetwork
+	if reserved != nil {
+		reservedRanges = reserved.ranges
+	}
+
+	tree, err := c.calculator.BuildBuddyTree(pool, allocations, reservedRanges)
+	if err != nil {
+		return err
+	}
+
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(outputFile)) {
+		case ".svg":
+			format = FormatSVG
+		case ".html", ".htm":
+			format = FormatHTML
+		default:
+			format = FormatText
+		}
+	}
+
+	var content string
+	switch format {
+	case FormatText:
+		content = c.formatter.FormatBuddyTree(tree)
+	case FormatHTML:
+		content = c.formatter.FormatBuddyTreeAsHTML(tree)
+	case FormatSVG:
+		content = c.formatter.FormatBuddyTreeAsSVG(tree)
+	default:
+		return fmt.Errorf("buddy-tree supports %s, %s and %s output, not %s", FormatText, FormatHTML, FormatSVG, format)
+	}
+
+	return c.writeOutput(content, outputFile)
+}
+
+// buddyHTMLTemplate is the page around the buddy tree diagram
+const buddyHTMLTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Buddy Tree - {{.Pool}}</title>
+    <style>
+        body {
+            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            background-color: #f5f5f5;
+            padding: 20px;
+        }
+
+        .container {
+            max-width: 1000px;
+            margin: 0 auto;
+            background: white;
+            border-radius: 8px;
+            box-shadow: 0 2px 10px rgba(0,0,0,0.1);
+            padding: 20px;
+        }
+
+        .diagram {
+            overflow-x: auto;
+            margin: 20px 0;
+        }
+
+        .legend span {
+            display: inline-block;
+            width: 12px;
+            height: 12px;
+            margin: 0 4px 0 12px;
+            vertical-align: middle;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>Buddy Tree for {{.Pool}}</h1>
+        <div class="legend">{{range .Legend}}<span style="background: {{.Color}}"></span>{{.State}}{{end}}</div>
+        <div class="diagram">{{.Diagram}}</div>
+        <h2>Free Space</h2>
+        <ul>{{range .Summary}}
+            <li>{{.}}</li>{{end}}
+        </ul>
+        <h2>Merge Opportunities</h2>
+        <ul>{{range .Merges}}
+            <li>{{.}}</li>{{else}}
+            <li>none</li>{{end}}
+        </ul>
+    </div>
+</body>
+</html>
+`
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIDRCalculator_BuildBuddyTree(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	pool, _ := calculator.ParseCIDR("10.0.0.0/22")
+
+	plan := func(lines ...BatchEntry) []planNetwork {
+		var networks []planNetwork
+		for _, entry := range lines {
+			info, err := calculator.ParseCIDR(entry.CIDR)
+			if err != nil {
+				t.Fatalf("failed to parse CIDR: %v", err)
+			}
+			networks = append(networks, planNetwork{entry: entry, info: info})
+		}
+		return networks
+	}
+	allocations := plan(
+		BatchEntry{CIDR: "10.0.0.0/24", Tags: Tags{"name": "core"}},
+		BatchEntry{CIDR: "10.0.1.0/25"},
+		BatchEntry{CIDR: "10.0.2.64/26"},
+	)
+	reserved := plan(BatchEntry{CIDR: "10.0.3.0/24", Comment: "lab"})
+
+	tree, err := calculator.BuildBuddyTree(pool, allocations, reserved)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var free []string
+	for _, node := range tree.Free {
+		free = append(free, node.Network.CIDR())
+	}
+	if strings.Join(free, " ") != "10.0.1.128/25 10.0.2.0/26 10.0.2.128/25" {
+		t.Errorf("unexpected free blocks: %v", free)
+	}
+	if tree.FreeSize != 320 || tree.Used != 704 || tree.Depth != 4 {
+		t.Errorf("unexpected totals: free %d, used %d, depth %d", tree.FreeSize, tree.Used, tree.Depth)
+	}
+	if tree.Largest.Network.CIDR() != "10.0.1.128/25" || tree.Fragmentation() != 60 {
+		t.Errorf("unexpected largest block %s and fragmentation %.1f", tree.Largest.Network.CIDR(), tree.Fragmentation())
+	}
+
+	var merges []string
+	for _, merge := range tree.Merges {
+		merges = append(merges, describeMerge(merge))
+	}
+	expected := []string{
+		"releasing 10.0.1.0/25 would merge it with its free buddy 10.0.1.128/25 into 10.0.1.0/24",
+		"releasing 10.0.2.64/26 would merge it with its free buddy 10.0.2.0/26 into 10.0.2.0/25",
+	}
+	if strings.Join(merges, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected merges:\n%s", strings.Join(merges, "\n"))
+	}
+
+	// An empty pool is one free block; a fully covered one a single allocation
+	empty, _ := calculator.BuildBuddyTree(pool, nil, nil)
+	if empty.Root.State != BuddyFree || empty.Fragmentation() != 0 || len(empty.Free) != 1 {
+		t.Errorf("unexpected empty tree: %+v", empty)
+	}
+	full, _ := calculator.BuildBuddyTree(pool, plan(BatchEntry{CIDR: "10.0.0.0/16"}), nil)
+	if full.Root.State != BuddyAllocated || full.Root.Label() != "part of 10.0.0.0/16" || full.FreeSize != 0 {
+		t.Errorf("unexpected full tree: %+v", full.Root)
+	}
+
+	ipv6, _ := calculator.ParseCIDR("2001:db8::/48")
+	if _, err := calculator.BuildBuddyTree(ipv6, nil, nil); err == nil {
+		t.Errorf("expected an error for an IPv6 pool")
+	}
+}
+
+func TestCLIHandler_BuddyTree(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	state := filepath.Join(dir, "ipam.txt")
+	if err := os.WriteFile(state, []byte("10.0.0.0/24 name=core\n10.0.1.0/25 # web\n"), 0644); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+
+	tests := []struct {
+		output   string
+		args     []string
+		expected []string
+	}{
+		{
+			output: "tree.txt",
+			expected: []string{
+				"Buddy Tree for 10.0.0.0/23:\n  10.0.0.0/23            split\n",
+				"  |-- 10.0.0.0/24        allocated  name=core\n",
+				"      |-- 10.0.1.0/25    allocated  # web\n",
+				"      `-- 10.0.1.128/25  free\n",
+				"Fragmentation:  0.0% of free space",
+				"releasing 10.0.1.0/25 would merge it with its free buddy 10.0.1.128/25 into 10.0.1.0/24",
+			},
+		},
+		{
+			output:   "tree.svg",
+			expected: []string{`<svg xmlns="http://www.w3.org/2000/svg" width="960" height="84"`, `<title>10.0.1.0/25 allocated # web</title>`, `fill="#2ecc71"`},
+		},
+		{
+			output:   "tree.html",
+			expected: []string{"<title>Buddy Tree - 10.0.0.0/23</title>", "<svg ", "<li>Free Blocks:    1</li>"},
+		},
+		{
+			output:   "tree.out",
+			args:     []string{"--format", "svg"},
+			expected: []string{"<svg "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			output := filepath.Join(dir, tt.output)
+			args := append([]string{"cidr-calc", "buddy-tree", "--pool", "10.0.0.0/23", "--state", state, "-o", output}, tt.args...)
+			if err := handler.Run(args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			content, _ := os.ReadFile(output)
+			for _, exp := range tt.expected {
+				if !strings.Contains(string(content), exp) {
+					t.Errorf("expected output to contain %q, got:\n%s", exp, content)
+				}
+			}
+		})
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "buddy-tree", "--pool", "10.0.0.0/23", "--format", "csv"}); err == nil ||
+		!strings.Contains(err.Error(), "not csv") {
+		t.Errorf("expected an unsupported format error, got %v", err)
+	}
+}
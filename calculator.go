@@ -1,14 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"math/big"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 // CIDRCalculator handles CIDR parsing and network calculations
-type CIDRCalculator struct{}
+type CIDRCalculator struct {
+	limits  Limits
+	aliases map[string]string // alias names and their CIDRs
+}
 
 // NewCIDRCalculator creates a new CIDR calculator instance
 func NewCIDRCalculator() *CIDRCalculator {
@@ -17,20 +23,21 @@ func NewCIDRCalculator() *CIDRCalculator {
 
 // ParseCIDR parses CIDR notation and returns comprehensive network information
 func (c *CIDRCalculator) ParseCIDR(cidr string) (*NetworkInfo, error) {
+	// Expand an alias name to its CIDR
+	cidr, alias := c.expandAlias(cidr)
+
 	// Validate input format
 	if err := c.validateCIDRFormat(cidr); err != nil {
 		return nil, err
 	}
 
 	// Parse CIDR using Go's net package
-	ip, ipNet, err := net.ParseCIDR(cidr)
+	address, ipNet, err := net.ParseCIDR(cidr)
 	if err != nil {
 		return nil, fmt.Errorf("invalid CIDR notation: %v", err)
 	}
-
-	// Ensure we're working with IPv4
-	if ip.To4() == nil {
-		return nil, fmt.Errorf("IPv6 is not supported, please provide an IPv4 CIDR")
+	if ipv4 := address.To4(); ipv4 != nil {
+		address = ipv4
 	}
 
 	// Get prefix length
@@ -39,9 +46,11 @@ func (c *CIDRCalculator) ParseCIDR(cidr string) (*NetworkInfo, error) {
 	// Calculate network information
 	networkInfo := &NetworkInfo{
 		Network:      *ipNet,
+		Address:      address,
 		NetworkID:    ipNet.IP,
 		PrefixLength: prefixLength,
 		SubnetMask:   ipNet.Mask,
+		Alias:        alias,
 	}
 
 	// Calculate wildcard mask
@@ -82,19 +91,20 @@ func (c *CIDRCalculator) validateCIDRFormat(cidr string) error {
 		return fmt.Errorf("invalid IP address format: %s", ipStr)
 	}
 
-	// Ensure IPv4
+	// IPv4 prefixes go up to /32, IPv6 prefixes up to /128
+	maxPrefix := 32
 	if ip.To4() == nil {
-		return fmt.Errorf("IPv6 is not supported, please provide an IPv4 address")
+		maxPrefix = 128
 	}
 
 	// Validate prefix length
 	prefix, err := strconv.Atoi(prefixStr)
 	if err != nil {
-		return fmt.Errorf("invalid prefix length: %s (must be a number between 0 and 32)", prefixStr)
+		return fmt.Errorf("invalid prefix length: %s (must be a number between 0 and %d)", prefixStr, maxPrefix)
 	}
 
-	if prefix < 0 || prefix > 32 {
-		return fmt.Errorf("prefix length must be between 0 and 32, got: %d", prefix)
+	if prefix < 0 || prefix > maxPrefix {
+		return fmt.Errorf("prefix length must be between 0 and %d, got: %d", maxPrefix, prefix)
 	}
 
 	return nil
@@ -121,6 +131,14 @@ func (c *CIDRCalculator) calculateBroadcastAddress(networkID net.IP, wildcardMas
 // calculateUsableRange calculates first/last usable IPs and total host count
 // Handles edge cases for /31 and /32 networks
 func (c *CIDRCalculator) calculateUsableRange(info *NetworkInfo) {
+	// IPv6 has no network or broadcast address: every address in the prefix is usable.
+	// TotalHosts cannot hold IPv6 counts; use NetworkInfo.HostCount instead.
+	if info.IsIPv6() {
+		info.FirstUsableIP = info.NetworkID
+		info.LastUsableIP = info.BroadcastAddr
+		return
+	}
+
 	switch info.PrefixLength {
 	case 32:
 		// /32 is a single host - no usable range for other hosts
@@ -177,58 +195,196 @@ func (c *CIDRCalculator) decrementIP(ip net.IP) net.IP {
 	return result
 }
 
-// CalculateSubnets generates all possible subnets for the next prefix level
-// Implements performance optimization by limiting display for large networks
+// CalculateSubnets generates all possible subnets for the next prefix level:
+// the two halves of an IPv4 network, or the subnets at the next nibble of an
+// IPv6 one (at most 16). So few subnets cannot run into the limits of the
+// calculator, which apply to splits only.
 func (c *CIDRCalculator) CalculateSubnets(network *NetworkInfo) []SubnetInfo {
 	// Cannot subnet /32 networks
-	if network.PrefixLength >= 32 {
+	if network.PrefixLength >= network.MaxPrefix() {
 		return []SubnetInfo{}
 	}
 
-	nextPrefixLength := network.PrefixLength + 1
-	subnetSize := uint32(1) << uint(32-nextPrefixLength)
+	subnets := make([]SubnetInfo, 0, 16)
+	c.Subnets(network, network.NextPrefix())(func(subnet SubnetInfo) bool {
+		subnets = append(subnets, subnet)
+		return true
+	})
+	return subnets
+}
 
-	// Calculate number of possible subnets
-	numSubnets := uint32(1) << uint(nextPrefixLength-network.PrefixLength)
+// maxSplitSubnets caps SplitSubnets so a mistyped prefix cannot exhaust memory
+const maxSplitSubnets = 65536
 
-	// Performance optimization: limit display for very large networks
-	// For networks larger than /16, limit to first 100 subnets to prevent memory issues
-	maxSubnetsToDisplay := uint32(100)
-	if network.PrefixLength <= 16 && numSubnets > maxSubnetsToDisplay {
-		numSubnets = maxSubnetsToDisplay
+// SplitSubnets lists every subnet of the network at the given prefix length,
+// e.g. all sixteen /28s of a /24. Unlike CalculateSubnets the list is never
+// truncated, so splits producing more than maxSplitSubnets subnets, or the
+// subnet limit of the calculator when it is set, are rejected.
+func (c *CIDRCalculator) SplitSubnets(network *NetworkInfo, prefixLength int) ([]SubnetInfo, error) {
+	count, err := c.SplitCount(network, prefixLength, c.splitLimit())
+	if err != nil {
+		return nil, err
 	}
+	return c.enumerateSubnets(network, prefixLength, count)
+}
 
-	subnets := make([]SubnetInfo, 0, numSubnets)
+// SplitCount returns how many subnets splitting the network at the given
+// prefix length produces, rejecting invalid prefixes and counts above limit
+func (c *CIDRCalculator) SplitCount(network *NetworkInfo, prefixLength, limit int) (int, error) {
+	count, err := c.SubnetTotal(network, prefixLength)
+	if err != nil {
+		return 0, err
+	}
 
-	// Start with the network ID
-	currentNetworkID := make(net.IP, len(network.NetworkID))
-	copy(currentNetworkID, network.NetworkID)
+	if !count.IsInt64() || count.Int64() > int64(limit) {
+		return 0, fmt.Errorf("splitting %s into /%d subnets would list %s subnets (limit %d)",
+			network.CIDR(), prefixLength, count.String(), limit)
+	}
+	return int(count.Int64()), nil
+}
 
-	for i := uint32(0); i < numSubnets; i++ {
-		// Calculate broadcast address for this subnet
-		broadcastAddr := c.calculateSubnetBroadcast(currentNetworkID, nextPrefixLength)
+// SubnetTotal returns the exact number of subnets of the network at the given
+// prefix length, which for IPv6 can exceed any integer type
+func (c *CIDRCalculator) SubnetTotal(network *NetworkInfo, prefixLength int) (*big.Int, error) {
+	if prefixLength <= network.PrefixLength || prefixLength > network.MaxPrefix() {
+		return nil, fmt.Errorf("split prefix for %s must be between /%d and /%d, got /%d",
+			network.CIDR(), network.PrefixLength+1, network.MaxPrefix(), prefixLength)
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(prefixLength-network.PrefixLength)), nil
+}
 
-		// Create subnet info
-		subnet := SubnetInfo{
-			NetworkID:     make(net.IP, len(currentNetworkID)),
-			CIDR:          fmt.Sprintf("%s/%d", currentNetworkID.String(), nextPrefixLength),
-			BroadcastAddr: broadcastAddr,
-		}
-		copy(subnet.NetworkID, currentNetworkID)
+// PartsPrefix returns the longest prefix that still divides the network into at
+// least the given number of equal subnets, e.g. /27 for 6 parts of a /24
+func (c *CIDRCalculator) PartsPrefix(network *NetworkInfo, parts int) (int, error) {
+	if parts < 2 {
+		return 0, fmt.Errorf("number of parts must be at least 2, got %d", parts)
+	}
 
+	bits := 0
+	for 1<<uint(bits) < parts {
+		bits++
+	}
+
+	if network.PrefixLength+bits > network.MaxPrefix() {
+		return 0, fmt.Errorf("%s cannot be divided into %d subnets", network.CIDR(), parts)
+	}
+	return network.PrefixLength + bits, nil
+}
+
+// HostsPrefix returns the longest prefix whose subnets still have at least the
+// given number of usable hosts, e.g. /23 for 500 hosts of a /20
+func (c *CIDRCalculator) HostsPrefix(network *NetworkInfo, hosts int) (int, error) {
+	if hosts < 1 {
+		return 0, fmt.Errorf("number of hosts must be at least 1, got %d", hosts)
+	}
+
+	prefix := prefixForHosts(network.IsIPv6(), hosts)
+	if prefix <= network.PrefixLength {
+		return 0, fmt.Errorf("%s cannot be split into subnets of %d usable hosts", network.CIDR(), hosts)
+	}
+	return prefix, nil
+}
+
+// prefixForHosts returns the longest prefix with at least the given number of
+// usable hosts. The result is below zero when no IPv4 prefix is large enough.
+func prefixForHosts(ipv6 bool, hosts int) int {
+	maxPrefix := 32
+	if ipv6 {
+		maxPrefix = 128
+	}
+
+	bits := 0
+	for bits < 63 && usableHosts(ipv6, bits) < uint64(hosts) {
+		bits++
+	}
+	return maxPrefix - bits
+}
+
+// usableHosts returns the usable addresses of a prefix with the given host bits.
+// IPv4 loses the network and broadcast addresses except on /31 and /32.
+func usableHosts(ipv6 bool, hostBits int) uint64 {
+	if ipv6 || hostBits < 2 {
+		return 1 << uint(hostBits)
+	}
+	return 1<<uint(hostBits) - 2
+}
+
+// enumerateSubnets lists the first count subnets of the network at the given
+// prefix length, for either address family
+func (c *CIDRCalculator) enumerateSubnets(network *NetworkInfo, prefixLength, count int) ([]SubnetInfo, error) {
+	subnets := make([]SubnetInfo, 0, count)
+	err := c.EachSubnet(network, prefixLength, count, func(subnet SubnetInfo) error {
 		subnets = append(subnets, subnet)
+		return nil
+	})
+	return subnets, err
+}
+
+// SubnetSeq is a lazy sequence of subnets. It calls yield with each subnet in
+// address order until yield returns false, like an iter.Seq[SubnetInfo].
+type SubnetSeq func(yield func(SubnetInfo) bool)
+
+// Subnets returns every subnet of the network at the given prefix length, in
+// address order. Nothing is generated until the sequence is called, and each
+// subnet is built only when it is yielded, so callers can walk millions of
+// subnets, or stop after the first few, without allocating a list. A prefix
+// outside /(network prefix + 1) to the maximum yields nothing.
+func (c *CIDRCalculator) Subnets(network *NetworkInfo, prefixLength int) SubnetSeq {
+	return func(yield func(SubnetInfo) bool) {
+		if prefixLength <= network.PrefixLength || prefixLength > network.MaxPrefix() {
+			return
+		}
 
-		// Move to next subnet by adding subnet size to current network ID
-		currentNetworkID = c.addToIP(currentNetworkID, subnetSize)
+		size := len(network.NetworkID)
+		subnetSize := new(big.Int).Lsh(big.NewInt(1), uint(size*8-prefixLength))
+		current := new(big.Int).SetBytes(network.NetworkID)
+		remaining := new(big.Int).Lsh(big.NewInt(1), uint(prefixLength-network.PrefixLength))
+		one := big.NewInt(1)
+
+		for ; remaining.Sign() > 0; remaining.Sub(remaining, one) {
+			networkID := make(net.IP, size)
+			current.FillBytes(networkID)
+			subnet := SubnetInfo{
+				NetworkID:     networkID,
+				CIDR:          fmt.Sprintf("%s/%d", networkID.String(), prefixLength),
+				BroadcastAddr: c.calculateSubnetBroadcast(networkID, prefixLength),
+			}
+			if !yield(subnet) {
+				return
+			}
+			current.Add(current, subnetSize)
+		}
 	}
+}
 
-	return subnets
+// EachSubnet calls fn with the first count subnets of the network at the given
+// prefix length, one at a time, and stops at the first error fn returns or
+// when the timeout of the calculator runs out
+func (c *CIDRCalculator) EachSubnet(network *NetworkInfo, prefixLength, count int, fn func(SubnetInfo) error) error {
+	var err error
+	listed := 0
+	deadline := c.startDeadline()
+	c.Subnets(network, prefixLength)(func(subnet SubnetInfo) bool {
+		if listed == count {
+			return false
+		}
+		if listed%deadlineCheckInterval == 0 {
+			if err = c.checkDeadline(deadline); err != nil {
+				return false
+			}
+		}
+		listed++
+		err = fn(subnet)
+		return err == nil
+	})
+	return err
 }
 
 // calculateSubnetBroadcast calculates the broadcast address for a subnet
+// (for IPv6, the last address of the subnet)
 func (c *CIDRCalculator) calculateSubnetBroadcast(networkID net.IP, prefixLength int) net.IP {
 	// Create subnet mask for the given prefix length
-	subnetMask := net.CIDRMask(prefixLength, 32)
+	subnetMask := net.CIDRMask(prefixLength, len(networkID)*8)
 	wildcardMask := c.calculateWildcardMask(subnetMask)
 
 	// Calculate broadcast: Network ID OR Wildcard Mask
@@ -240,19 +396,140 @@ func (c *CIDRCalculator) calculateSubnetBroadcast(networkID net.IP, prefixLength
 	return broadcast
 }
 
-// addToIP adds a value to an IP address (used for subnet iteration)
-func (c *CIDRCalculator) addToIP(ip net.IP, value uint32) net.IP {
-	result := make(net.IP, len(ip))
-	copy(result, ip)
+// FreeBlocks returns the largest aligned CIDR blocks of parent not covered by any used network.
+// Only IPv4 parents are supported; IPv6 parents have no free block list.
+func (c *CIDRCalculator) FreeBlocks(parent *NetworkInfo, used []*NetworkInfo) []string {
+	var blocks []string
+	for _, gap := range c.freeRanges(parent, used) {
+		blocks = append(blocks, alignedBlocks(gap[0], gap[1])...)
+	}
+	return blocks
+}
 
-	// Convert IP to uint32, add value, convert back
-	ipUint32 := uint32(result[0])<<24 + uint32(result[1])<<16 + uint32(result[2])<<8 + uint32(result[3])
-	ipUint32 += value
+// freeRanges returns the first and last address of every gap in parent not
+// covered by a used network, in address order. IPv4 only.
+func (c *CIDRCalculator) freeRanges(parent *NetworkInfo, used []*NetworkInfo) [][2]uint64 {
+	if parent.IsIPv6() {
+		return nil
+	}
 
-	result[0] = byte(ipUint32 >> 24)
-	result[1] = byte(ipUint32 >> 16)
-	result[2] = byte(ipUint32 >> 8)
-	result[3] = byte(ipUint32)
+	var ranges [][2]uint64
+	for _, network := range used {
+		if network.IsIPv6() || !parent.Overlaps(network) {
+			continue
+		}
+		start, end := ipRange(network)
+		ranges = append(ranges, [2]uint64{start, end})
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
 
-	return result
+	var gaps [][2]uint64
+	next, last := ipRange(parent)
+	for _, r := range ranges {
+		if r[0] > next {
+			gaps = append(gaps, [2]uint64{next, r[0] - 1})
+		}
+		if r[1]+1 > next {
+			next = r[1] + 1
+		}
+	}
+	if next <= last {
+		gaps = append(gaps, [2]uint64{next, last})
+	}
+
+	return gaps
+}
+
+// Aggregate returns the fewest CIDRs covering exactly the addresses of the networks.
+// Networks inside another are dropped and adjacent halves are merged into their
+// supernet. IPv4 networks come before IPv6 networks, each sorted by address.
+func (c *CIDRCalculator) Aggregate(networks []*NetworkInfo) []*NetworkInfo {
+	sorted := append([]*NetworkInfo(nil), networks...)
+	sort.SliceStable(sorted, func(i, j int) bool { return networkLess(sorted[i], sorted[j]) })
+
+	var aggregated []*NetworkInfo
+	for _, network := range sorted {
+		if last := len(aggregated) - 1; last >= 0 && aggregated[last].Contains(network) {
+			continue
+		}
+		aggregated = append(aggregated, network)
+
+		// Keep merging while the two newest blocks are halves of the same supernet
+		for len(aggregated) >= 2 {
+			last := len(aggregated) - 1
+			supernet, ok := c.mergeSiblings(aggregated[last-1], aggregated[last])
+			if !ok {
+				break
+			}
+			aggregated = append(aggregated[:last-1], supernet)
+		}
+	}
+
+	return aggregated
+}
+
+// networkLess orders IPv4 networks before IPv6 networks, each by address and
+// then by prefix length, so a network comes before the networks inside it
+func networkLess(a, b *NetworkInfo) bool {
+	if a.IsIPv6() != b.IsIPv6() {
+		return !a.IsIPv6()
+	}
+	if cmp := bytes.Compare(a.NetworkID.To16(), b.NetworkID.To16()); cmp != 0 {
+		return cmp < 0
+	}
+	return a.PrefixLength < b.PrefixLength
+}
+
+// mergeSiblings returns the supernet of a and b when a is its lower and b its upper half
+func (c *CIDRCalculator) mergeSiblings(a, b *NetworkInfo) (*NetworkInfo, bool) {
+	if a.IsIPv6() != b.IsIPv6() || a.PrefixLength != b.PrefixLength || a.PrefixLength == 0 {
+		return nil, false
+	}
+
+	mask := net.CIDRMask(a.PrefixLength-1, a.MaxPrefix())
+	if !a.NetworkID.Mask(mask).Equal(a.NetworkID) || !b.NetworkID.Mask(mask).Equal(a.NetworkID) || a.NetworkID.Equal(b.NetworkID) {
+		return nil, false
+	}
+
+	supernet, err := c.ParseCIDR(fmt.Sprintf("%s/%d", a.NetworkID, a.PrefixLength-1))
+	if err != nil {
+		return nil, false
+	}
+	return supernet, true
+}
+
+// ipRange returns the first and last address of a network as integers
+func ipRange(network *NetworkInfo) (uint64, uint64) {
+	start := uint64(ipv4ToUint32(network.NetworkID))
+	size := uint64(1) << uint(32-network.PrefixLength)
+	return start, start + size - 1
+}
+
+// alignedBlocks splits the inclusive address range [start, end] into the fewest aligned CIDR blocks
+func alignedBlocks(start, end uint64) []string {
+	var blocks []string
+	for start <= end {
+		prefix := 32
+		for prefix > 0 {
+			size := uint64(1) << uint(32-prefix+1)
+			if start%size != 0 || start+size-1 > end {
+				break
+			}
+			prefix--
+		}
+		blocks = append(blocks, fmt.Sprintf("%s/%d", uint32ToIPv4(uint32(start)).String(), prefix))
+		start += uint64(1) << uint(32-prefix)
+	}
+	return blocks
+}
+
+// ipv4ToUint32 converts an IPv4 address to its integer value
+func ipv4ToUint32(ip net.IP) uint32 {
+	ip4 := ip.To4()
+	return uint32(ip4[0])<<24 | uint32(ip4[1])<<16 | uint32(ip4[2])<<8 | uint32(ip4[3])
+}
+
+// uint32ToIPv4 converts an integer value to an IPv4 address
+func uint32ToIPv4(value uint32) net.IP {
+	return net.IPv4(byte(value>>24), byte(value>>16), byte(value>>8), byte(value)).To4()
 }
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"net"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -136,6 +139,24 @@ func TestCIDRCalculator_ParseCIDR(t *testing.T) {
 					t.Errorf("Expected last usable 192.168.1.2, got %s", info.LastUsableIP.String())
 				}
 			},
+		}, {
+			name:    "IPv6 network with host bits set",
+			cidr:    "2001:db8::1/64",
+			wantErr: false,
+			checks: func(t *testing.T, info *NetworkInfo) {
+				if info.NetworkID.String() != "2001:db8::" {
+					t.Errorf("Expected network ID 2001:db8::, got %s", info.NetworkID.String())
+				}
+				if info.BroadcastAddr.String() != "2001:db8::ffff:ffff:ffff:ffff" {
+					t.Errorf("Expected last address 2001:db8::ffff:ffff:ffff:ffff, got %s", info.BroadcastAddr.String())
+				}
+				if info.FirstUsableIP.String() != "2001:db8::" {
+					t.Errorf("Expected first address 2001:db8::, got %s", info.FirstUsableIP.String())
+				}
+				if !info.IsIPv6() || info.PrefixLength != 64 {
+					t.Errorf("Expected IPv6 /64, got /%d", info.PrefixLength)
+				}
+			},
 		},
 	}
 
@@ -202,9 +223,9 @@ func TestCIDRCalculator_ParseCIDR_InvalidInputs(t *testing.T) {
 			expectedErr: "invalid CIDR notation. Expected format: x.x.x.x/y",
 		},
 		{
-			name:        "IPv6 address",
-			cidr:        "2001:db8::1/64",
-			expectedErr: "IPv6 is not supported",
+			name:        "IPv6 prefix too long",
+			cidr:        "2001:db8::/129",
+			expectedErr: "prefix length must be between 0 and 128",
 		},
 	}
 
@@ -619,3 +640,358 @@ func TestCIDRCalculator_CalculateSubnets_VariousNetworkSizes(t *testing.T) {
 		})
 	}
 }
+
+func TestCIDRCalculator_FreeBlocks(t *testing.T) {
+	calc := NewCIDRCalculator()
+
+	tests := []struct {
+		name   string
+		parent string
+		used   []string
+		expect []string
+	}{
+		{
+			name:   "nothing used",
+			parent: "10.0.0.0/24",
+			expect: []string{"10.0.0.0/24"},
+		},
+		{
+			name:   "first quarter used",
+			parent: "10.0.0.0/24",
+			used:   []string{"10.0.0.0/26"},
+			expect: []string{"10.0.0.64/26", "10.0.0.128/25"},
+		},
+		{
+			name:   "gap in the middle",
+			parent: "10.0.0.0/24",
+			used:   []string{"10.0.0.128/25", "10.0.0.0/26"},
+			expect: []string{"10.0.0.64/26"},
+		},
+		{
+			name:   "unaligned gap",
+			parent: "10.0.0.0/28",
+			used:   []string{"10.0.0.0/31", "10.0.0.12/30"},
+			expect: []string{"10.0.0.2/31", "10.0.0.4/30", "10.0.0.8/30"},
+		},
+		{
+			name:   "fully used",
+			parent: "10.0.0.0/24",
+			used:   []string{"10.0.0.0/25", "10.0.0.128/25"},
+		},
+		{
+			name:   "used networks outside the parent are ignored",
+			parent: "10.0.0.0/25",
+			used:   []string{"192.168.0.0/24", "10.0.0.0/16"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent, err := calc.ParseCIDR(tt.parent)
+			if err != nil {
+				t.Fatalf("failed to parse parent: %v", err)
+			}
+
+			var used []*NetworkInfo
+			for _, cidr := range tt.used {
+				info, err := calc.ParseCIDR(cidr)
+				if err != nil {
+					t.Fatalf("failed to parse %s: %v", cidr, err)
+				}
+				used = append(used, info)
+			}
+
+			blocks := calc.FreeBlocks(parent, used)
+			if strings.Join(blocks, " ") != strings.Join(tt.expect, " ") {
+				t.Errorf("expected %v, got %v", tt.expect, blocks)
+			}
+		})
+	}
+}
+
+func TestCIDRCalculator_CalculateSubnets_IPv6(t *testing.T) {
+	calc := NewCIDRCalculator()
+
+	tests := []struct {
+		name      string
+		cidr      string
+		wantCount int
+		wantFirst string
+		wantLast  string
+	}{
+		{"nibble aligned /48", "2001:db8:abcd::/48", 16, "2001:db8:abcd::/52", "2001:db8:abcd:f000::/52"},
+		{"unaligned /50", "2001:db8:abcd::/50", 4, "2001:db8:abcd::/52", "2001:db8:abcd:3000::/52"},
+		{"/127 splits into hosts", "2001:db8::/127", 2, "2001:db8::/128", "2001:db8::1/128"},
+		{"/128 has no subnets", "2001:db8::1/128", 0, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := calc.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("ParseCIDR() error = %v", err)
+			}
+
+			subnets := calc.CalculateSubnets(info)
+			if len(subnets) != tt.wantCount {
+				t.Fatalf("Expected %d subnets, got %d", tt.wantCount, len(subnets))
+			}
+			if tt.wantCount == 0 {
+				return
+			}
+			if subnets[0].CIDR != tt.wantFirst {
+				t.Errorf("Expected first subnet %s, got %s", tt.wantFirst, subnets[0].CIDR)
+			}
+			if last := subnets[len(subnets)-1].CIDR; last != tt.wantLast {
+				t.Errorf("Expected last subnet %s, got %s", tt.wantLast, last)
+			}
+		})
+	}
+}
+
+func TestCIDRCalculator_SplitSubnets(t *testing.T) {
+	calc := NewCIDRCalculator()
+
+	tests := []struct {
+		name      string
+		cidr      string
+		prefix    int
+		wantCount int
+		wantFirst string
+		wantLast  string
+		wantErr   string
+	}{
+		{name: "/24 into /28", cidr: "192.168.1.0/24", prefix: 28, wantCount: 16, wantFirst: "192.168.1.0/28", wantLast: "192.168.1.240/28"},
+		{name: "/30 into hosts", cidr: "10.0.0.0/30", prefix: 32, wantCount: 4, wantFirst: "10.0.0.0/32", wantLast: "10.0.0.3/32"},
+		{name: "/8 into /24 is not truncated", cidr: "10.0.0.0/8", prefix: 24, wantCount: 65536, wantFirst: "10.0.0.0/24", wantLast: "10.255.255.0/24"},
+		{name: "IPv6 /48 into /56", cidr: "2001:db8::/48", prefix: 56, wantCount: 256, wantFirst: "2001:db8::/56", wantLast: "2001:db8:0:ff00::/56"},
+		{name: "prefix not longer", cidr: "10.0.0.0/24", prefix: 24, wantErr: "must be between /25 and /32"},
+		{name: "prefix beyond family", cidr: "10.0.0.0/24", prefix: 33, wantErr: "must be between /25 and /32"},
+		{name: "too many subnets", cidr: "10.0.0.0/8", prefix: 28, wantErr: "would list 1048576 subnets (limit 65536)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := calc.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("ParseCIDR() error = %v", err)
+			}
+
+			subnets, err := calc.SplitSubnets(info, tt.prefix)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SplitSubnets() error = %v", err)
+			}
+
+			if len(subnets) != tt.wantCount {
+				t.Fatalf("Expected %d subnets, got %d", tt.wantCount, len(subnets))
+			}
+			if subnets[0].CIDR != tt.wantFirst {
+				t.Errorf("Expected first subnet %s, got %s", tt.wantFirst, subnets[0].CIDR)
+			}
+			if last := subnets[len(subnets)-1].CIDR; last != tt.wantLast {
+				t.Errorf("Expected last subnet %s, got %s", tt.wantLast, last)
+			}
+		})
+	}
+}
+
+func TestCIDRCalculator_SplitCount(t *testing.T) {
+	calc := NewCIDRCalculator()
+
+	tests := []struct {
+		cidr      string
+		prefix    int
+		limit     int
+		wantCount int
+		wantErr   bool
+	}{
+		{cidr: "10.0.0.0/8", prefix: 30, limit: maxStreamedSplitSubnets, wantCount: 4194304},
+		{cidr: "10.0.0.0/8", prefix: 30, limit: maxSplitSubnets, wantErr: true},
+		{cidr: "2001:db8::/32", prefix: 128, limit: maxStreamedSplitSubnets, wantErr: true},
+		{cidr: "10.0.0.0/24", prefix: 20, limit: maxSplitSubnets, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		info, err := calc.ParseCIDR(tt.cidr)
+		if err != nil {
+			t.Fatalf("ParseCIDR() error = %v", err)
+		}
+		count, err := calc.SplitCount(info, tt.prefix, tt.limit)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SplitCount(%s, /%d) error = %v, wantErr %v", tt.cidr, tt.prefix, err, tt.wantErr)
+			continue
+		}
+		if count != tt.wantCount {
+			t.Errorf("SplitCount(%s, /%d) = %d, want %d", tt.cidr, tt.prefix, count, tt.wantCount)
+		}
+	}
+}
+
+func TestCIDRCalculator_PartsPrefix(t *testing.T) {
+	calc := NewCIDRCalculator()
+
+	tests := []struct {
+		cidr    string
+		parts   int
+		want    int
+		wantErr bool
+	}{
+		{cidr: "10.0.0.0/24", parts: 6, want: 27},
+		{cidr: "10.0.0.0/24", parts: 8, want: 27},
+		{cidr: "10.0.0.0/24", parts: 9, want: 28},
+		{cidr: "10.0.0.0/24", parts: 2, want: 25},
+		{cidr: "2001:db8::/48", parts: 200, want: 56},
+		{cidr: "10.0.0.0/30", parts: 4, want: 32},
+		{cidr: "10.0.0.0/30", parts: 5, wantErr: true},
+		{cidr: "10.0.0.0/24", parts: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s into %d", tt.cidr, tt.parts), func(t *testing.T) {
+			info, err := calc.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("ParseCIDR() error = %v", err)
+			}
+
+			got, err := calc.PartsPrefix(info, tt.parts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PartsPrefix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("PartsPrefix() = /%d, want /%d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCIDRCalculator_HostsPrefix(t *testing.T) {
+	calc := NewCIDRCalculator()
+
+	tests := []struct {
+		cidr    string
+		hosts   int
+		want    int
+		wantErr bool
+	}{
+		{cidr: "10.0.0.0/20", hosts: 500, want: 23},
+		{cidr: "10.0.0.0/20", hosts: 510, want: 23},
+		{cidr: "10.0.0.0/20", hosts: 511, want: 22},
+		{cidr: "10.0.0.0/24", hosts: 1, want: 32},
+		{cidr: "10.0.0.0/24", hosts: 2, want: 31},
+		{cidr: "10.0.0.0/24", hosts: 3, want: 29},
+		{cidr: "2001:db8::/48", hosts: 256, want: 120},
+		{cidr: "10.0.0.0/23", hosts: 500, wantErr: true},
+		{cidr: "0.0.0.0/0", hosts: 1 << 40, wantErr: true},
+		{cidr: "10.0.0.0/24", hosts: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s for %d hosts", tt.cidr, tt.hosts), func(t *testing.T) {
+			info, err := calc.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("ParseCIDR() error = %v", err)
+			}
+
+			got, err := calc.HostsPrefix(info, tt.hosts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HostsPrefix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("HostsPrefix() = /%d, want /%d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCIDRCalculator_Aggregate(t *testing.T) {
+	calc := NewCIDRCalculator()
+
+	tests := []struct {
+		name  string
+		cidrs []string
+		want  []string
+	}{
+		{name: "adjacent halves", cidrs: []string{"10.0.1.0/24", "10.0.0.0/24"}, want: []string{"10.0.0.0/23"}},
+		{name: "cascading merge", cidrs: []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/23"}, want: []string{"10.0.0.0/22"}},
+		{name: "contained and duplicate", cidrs: []string{"10.0.0.0/16", "10.0.5.0/24", "10.0.0.0/16"}, want: []string{"10.0.0.0/16"}},
+		{name: "adjacent but unaligned", cidrs: []string{"10.0.1.0/24", "10.0.2.0/24"}, want: []string{"10.0.1.0/24", "10.0.2.0/24"}},
+		{name: "gap", cidrs: []string{"10.0.0.0/24", "10.0.2.0/24"}, want: []string{"10.0.0.0/24", "10.0.2.0/24"}},
+		{name: "whole space", cidrs: []string{"0.0.0.0/1", "128.0.0.0/1"}, want: []string{"0.0.0.0/0"}},
+		{name: "mixed families", cidrs: []string{"2001:db8:8000::/33", "192.168.0.0/24", "2001:db8::/33"}, want: []string{"192.168.0.0/24", "2001:db8::/32"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var networks []*NetworkInfo
+			for _, cidr := range tt.cidrs {
+				info, err := calc.ParseCIDR(cidr)
+				if err != nil {
+					t.Fatalf("ParseCIDR(%s) error = %v", cidr, err)
+				}
+				networks = append(networks, info)
+			}
+
+			var got []string
+			for _, network := range calc.Aggregate(networks) {
+				got = append(got, network.CIDR())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Aggregate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCIDRCalculator_Subnets(t *testing.T) {
+	calc := NewCIDRCalculator()
+
+	tests := []struct {
+		name     string
+		cidr     string
+		prefix   int
+		take     int
+		expected []string
+	}{
+		{"every subnet", "192.168.1.0/24", 26, -1, []string{"192.168.1.0/26", "192.168.1.64/26", "192.168.1.128/26", "192.168.1.192/26"}},
+		{"first of sixteen million", "10.0.0.0/8", 32, 3, []string{"10.0.0.0/32", "10.0.0.1/32", "10.0.0.2/32"}},
+		{"IPv6 beyond any count", "2001:db8::/32", 128, 2, []string{"2001:db8::/128", "2001:db8::1/128"}},
+		{"last IPv4 subnets", "255.255.255.252/30", 31, -1, []string{"255.255.255.252/31", "255.255.255.254/31"}},
+		{"prefix too short", "192.168.1.0/24", 24, -1, nil},
+		{"prefix too long", "192.168.1.0/24", 33, -1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network, err := calc.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("Failed to parse CIDR %s: %v", tt.cidr, err)
+			}
+
+			var got []string
+			calc.Subnets(network, tt.prefix)(func(subnet SubnetInfo) bool {
+				got = append(got, subnet.CIDR)
+				return len(got) != tt.take
+			})
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	// Each call of the sequence starts over
+	network, _ := calc.ParseCIDR("10.0.0.0/30")
+	seq := calc.Subnets(network, 31)
+	for i := 0; i < 2; i++ {
+		count := 0
+		seq(func(SubnetInfo) bool { count++; return true })
+		if count != 2 {
+			t.Errorf("pass %d: expected 2 subnets, got %d", i+1, count)
+		}
+	}
+}
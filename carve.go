@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+)
+
+// Split returns every subnet of the network at the given prefix length as a
+// fully populated NetworkInfo, with masks, usable range and host count. It has
+// the limits of CIDRCalculator.SplitSubnets.
+func (n *NetworkInfo) Split(toPrefix int) ([]NetworkInfo, error) {
+	return NewCIDRCalculator().SplitNetworks(n, toPrefix)
+}
+
+// SplitNetworks splits a network like NetworkInfo.Split, within the limits
+// set on the calculator
+func (c *CIDRCalculator) SplitNetworks(n *NetworkInfo, toPrefix int) ([]NetworkInfo, error) {
+	subnets, err := c.SplitSubnets(n, toPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]NetworkInfo, 0, len(subnets))
+	for _, subnet := range subnets {
+		child, err := c.ParseCIDR(subnet.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse subnet %s: %v", subnet.CIDR, err)
+		}
+		children = append(children, *child)
+	}
+	return children, nil
+}
+
+// Carve cuts one subnet per prefix length out of the network, in the order
+// given. Each subnet starts at the first free address aligned to its size, so
+// listing the prefixes largest first packs them without gaps. The subnets are
+// returned in the order of sizes, fully populated like those of Split.
+func (n *NetworkInfo) Carve(sizes []int) ([]NetworkInfo, error) {
+	if len(sizes) == 0 {
+		return nil, fmt.Errorf("no prefix lengths to carve from %s", n.CIDR())
+	}
+
+	calculator := NewCIDRCalculator()
+	bits := n.MaxPrefix()
+	width := len(n.NetworkID)
+	if !n.IsIPv6() {
+		width = net.IPv4len
+	}
+	start := new(big.Int).SetBytes(n.NetworkID[len(n.NetworkID)-width:])
+	end := new(big.Int).Add(start, new(big.Int).Lsh(big.NewInt(1), uint(bits-n.PrefixLength)))
+
+	next := new(big.Int).Set(start)
+	children := make([]NetworkInfo, 0, len(sizes))
+	for _, prefix := range sizes {
+		if prefix < n.PrefixLength || prefix > bits {
+			return nil, fmt.Errorf("cannot carve a /%d from %s: prefix must be between /%d and /%d", prefix, n.CIDR(), n.PrefixLength, bits)
+		}
+
+		// Round up to the next boundary of the subnet size
+		size := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefix))
+		offset := new(big.Int).Mod(next, size)
+		if offset.Sign() > 0 {
+			next.Add(next, size).Sub(next, offset)
+		}
+		if new(big.Int).Add(next, size).Cmp(end) > 0 {
+			return nil, fmt.Errorf("cannot carve a /%d from %s: not enough free space left", prefix, n.CIDR())
+		}
+
+		address := make(net.IP, width)
+		next.FillBytes(address)
+		child, err := calculator.ParseCIDR(fmt.Sprintf("%s/%d", address, prefix))
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, *child)
+		next.Add(next, size)
+	}
+	return children, nil
+}
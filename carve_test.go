@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNetworkInfo_Split(t *testing.T) {
+	info := mustParseCIDR(t, NewCIDRCalculator(), "192.168.1.0/24")
+
+	children, err := info.Split(26)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(children) != 4 {
+		t.Fatalf("expected 4 subnets, got %d", len(children))
+	}
+	last := children[3]
+	if last.CIDR() != "192.168.1.192/26" || last.FirstUsableIP.String() != "192.168.1.193" ||
+		last.LastUsableIP.String() != "192.168.1.254" || last.TotalHosts != 62 || last.WildcardMask.String() != "0000003f" {
+		t.Errorf("expected a fully populated 192.168.1.192/26, got %+v", last)
+	}
+
+	if _, err := info.Split(24); err == nil {
+		t.Error("expected an error splitting at the network's own prefix")
+	}
+}
+
+func TestNetworkInfo_Carve(t *testing.T) {
+	calculator := NewCIDRCalculator()
+
+	tests := []struct {
+		cidr     string
+		sizes    []int
+		expected []string
+		err      string
+	}{
+		{cidr: "10.0.0.0/24", sizes: []int{26, 27, 28, 28}, expected: []string{"10.0.0.0/26", "10.0.0.64/27", "10.0.0.96/28", "10.0.0.112/28"}},
+		// Smaller subnets first leave gaps to keep the larger ones aligned
+		{cidr: "10.0.0.0/24", sizes: []int{28, 26}, expected: []string{"10.0.0.0/28", "10.0.0.64/26"}},
+		{cidr: "10.0.0.0/24", sizes: []int{24}, expected: []string{"10.0.0.0/24"}},
+		{cidr: "2001:db8::/48", sizes: []int{52, 64, 56}, expected: []string{"2001:db8::/52", "2001:db8:0:1000::/64", "2001:db8:0:1100::/56"}},
+		{cidr: "10.0.0.0/24", sizes: []int{25, 25, 30}, err: "not enough free space"},
+		{cidr: "10.0.0.0/24", sizes: []int{23}, err: "prefix must be between /24 and /32"},
+		{cidr: "10.0.0.0/24", err: "no prefix lengths"},
+	}
+
+	for _, tt := range tests {
+		info := mustParseCIDR(t, calculator, tt.cidr)
+		children, err := info.Carve(tt.sizes)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s %v: expected error %q, got %v", tt.cidr, tt.sizes, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s %v: unexpected error: %v", tt.cidr, tt.sizes, err)
+		}
+		var got []string
+		for _, child := range children {
+			got = append(got, child.CIDR())
+		}
+		if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("%s %v: expected %v, got %v", tt.cidr, tt.sizes, tt.expected, got)
+		}
+	}
+}
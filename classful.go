@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// addressClass is one of the historical IPv4 address classes
+type addressClass struct {
+	Name          string
+	First, Last   string
+	DefaultPrefix int    // the classful mask; 0 for classes D and E
+	Use           string // why classes D and E have no default mask
+}
+
+// addressClasses are the classes by their leading bits, A to E
+var addressClasses = []addressClass{
+	{Name: "A", First: "0.0.0.0", Last: "127.255.255.255", DefaultPrefix: 8},
+	{Name: "B", First: "128.0.0.0", Last: "191.255.255.255", DefaultPrefix: 16},
+	{Name: "C", First: "192.0.0.0", Last: "223.255.255.255", DefaultPrefix: 24},
+	{Name: "D", First: "224.0.0.0", Last: "239.255.255.255", Use: "multicast"},
+	{Name: "E", First: "240.0.0.0", Last: "255.255.255.255", Use: "reserved"},
+}
+
+// classOf returns the class of an IPv4 address from its first octet
+func classOf(ip net.IP) addressClass {
+	switch first := ip.To4()[0]; {
+	case first < 128:
+		return addressClasses[0]
+	case first < 192:
+		return addressClasses[1]
+	case first < 224:
+		return addressClasses[2]
+	case first < 240:
+		return addressClasses[3]
+	}
+	return addressClasses[4]
+}
+
+// classfulFacts returns the class, default mask and classful network of an
+// IPv4 network, and how its prefix relates to the classful boundary
+func (f *OutputFormatter) classfulFacts(info *NetworkInfo) []reportFact {
+	class := classOf(info.NetworkID)
+	if last := classOf(info.BroadcastAddr); last.Name != class.Name {
+		return []reportFact{
+			{"Class", fmt.Sprintf("%s to %s", class.Name, last.Name)},
+			{"Boundary", fmt.Sprintf("crosses class boundaries: /%d spans classes %s to %s", info.PrefixLength, class.Name, last.Name)},
+		}
+	}
+
+	facts := []reportFact{{"Class", fmt.Sprintf("%s (%s - %s)", class.Name, class.First, class.Last)}}
+	if class.DefaultPrefix == 0 {
+		return append(facts,
+			reportFact{"Default Mask", fmt.Sprintf("none (%s)", class.Use)},
+			reportFact{"Boundary", fmt.Sprintf("class %s has no classful networks", class.Name)},
+		)
+	}
+
+	// A supernet holds several classful (major) networks; name the first and last
+	mask := net.CIDRMask(class.DefaultPrefix, 32)
+	major := (&net.IPNet{IP: info.NetworkID.Mask(mask), Mask: mask}).String()
+	if info.PrefixLength < class.DefaultPrefix {
+		major += " - " + (&net.IPNet{IP: info.BroadcastAddr.Mask(mask), Mask: mask}).String()
+	}
+	facts = append(facts,
+		reportFact{"Default Mask", fmt.Sprintf("%s (/%d)", f.formatIPMask(mask), class.DefaultPrefix)},
+		reportFact{"Major Network", major},
+	)
+
+	var boundary string
+	switch bits := info.PrefixLength - class.DefaultPrefix; {
+	case bits == 0:
+		boundary = "on the classful boundary"
+	case bits > 0:
+		boundary = fmt.Sprintf("subnetted: %d bits longer than the classful /%d", bits, class.DefaultPrefix)
+	default:
+		boundary = fmt.Sprintf("crosses classful boundaries: supernet of %d class %s networks", 1<<uint(-bits), class.Name)
+	}
+	return append(facts, reportFact{"Boundary", boundary})
+}
+
+// FormatClassful formats the legacy class information for console display;
+// IPv6 has no address classes
+func (f *OutputFormatter) FormatClassful(info *NetworkInfo) string {
+	var output strings.Builder
+
+	output.WriteString("Classful Addressing:\n")
+	if info.IsIPv6() {
+		output.WriteString("  IPv6 has no address classes\n")
+		return output.String()
+	}
+	for _, fact := range f.classfulFacts(info) {
+		output.WriteString(fmt.Sprintf("  %-15s %s\n", fact.Label+":", fact.Value))
+	}
+
+	return output.String()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestClassOf(t *testing.T) {
+	for ip, expected := range map[string]string{
+		"0.0.0.0": "A", "127.255.255.255": "A", "128.0.0.0": "B", "191.255.0.1": "B",
+		"192.0.0.0": "C", "223.1.2.3": "C", "224.0.0.1": "D", "239.255.255.255": "D",
+		"240.0.0.0": "E", "255.255.255.255": "E",
+	} {
+		if class := classOf(net.ParseIP(ip)); class.Name != expected {
+			t.Errorf("%s: expected class %s, got %s", ip, expected, class.Name)
+		}
+	}
+}
+
+func TestOutputFormatter_ClassfulFacts(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	tests := []struct {
+		cidr     string
+		expected []string
+	}{
+		{"192.168.1.0/24", []string{"C (192.0.0.0 - 223.255.255.255)", "255.255.255.0 (/24)", "192.168.1.0/24", "on the classful boundary"}},
+		{"10.1.0.0/16", []string{"A (0.0.0.0 - 127.255.255.255)", "255.0.0.0 (/8)", "10.0.0.0/8", "subnetted: 8 bits longer than the classful /8"}},
+		{"172.16.0.0/12", []string{"B (128.0.0.0 - 191.255.255.255)", "255.255.0.0 (/16)", "172.16.0.0/16 - 172.31.0.0/16", "crosses classful boundaries: supernet of 16 class B networks"}},
+		{"224.0.0.0/24", []string{"D (224.0.0.0 - 239.255.255.255)", "none (multicast)", "class D has no classful networks"}},
+		{"128.0.0.0/1", []string{"B to E", "crosses class boundaries: /1 spans classes B to E"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			facts := formatter.classfulFacts(mustParseCIDR(t, calculator, tt.cidr))
+			if len(facts) != len(tt.expected) {
+				t.Fatalf("expected %d facts, got %+v", len(tt.expected), facts)
+			}
+			for i, expected := range tt.expected {
+				if facts[i].Value != expected {
+					t.Errorf("%s: expected %q, got %q", facts[i].Label, expected, facts[i].Value)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputFormatter_Classful(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := &OutputFormatter{Classful: true}
+
+	text := formatter.FormatNetworkInfo(mustParseCIDR(t, calculator, "192.168.1.0/26"))
+	if !strings.Contains(text, "Classful Addressing:\n  Class:          C") || !strings.Contains(text, "  Major Network:  192.168.1.0/24\n") {
+		t.Errorf("expected the classful section, got:\n%s", text)
+	}
+	if text := formatter.FormatNetworkInfo(mustParseCIDR(t, calculator, "2001:db8::/32")); !strings.Contains(text, "IPv6 has no address classes") {
+		t.Errorf("expected IPv6 to have no class, got:\n%s", text)
+	}
+
+	html := formatter.FormatAsHTML(mustParseCIDR(t, calculator, "192.168.1.0/26"), nil)
+	if !strings.Contains(html, "<h2>Classful Addressing</h2>") || !strings.Contains(html, "<td>subnetted: 2 bits longer than the classful /24</td>") {
+		t.Error("expected the classful section in the HTML report")
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// SpecialRange is an entry of the IANA special-purpose address registries, or
+// a range a --class-rules pack gives a meaning of its own
+type SpecialRange struct {
+	Network *net.IPNet
+	Name    string
+	RFC     string // for a rule pack, its optional reference
+	Pack    string // the rule pack defining the range; empty for the registries
+}
+
+// specialRanges are the IANA IPv4 and IPv6 special-purpose address registries
+// together with the multicast blocks. Ranges nest, e.g. the PCP anycast
+// address inside the IETF protocol assignments; the most specific one wins.
+var specialRanges = parseSpecialRanges([][3]string{
+	{"0.0.0.0/8", "This Network", "RFC 791"},
+	{"10.0.0.0/8", "Private-Use", "RFC 1918"},
+	{"100.64.0.0/10", "Shared Address Space (CGN)", "RFC 6598"},
+	{"127.0.0.0/8", "Loopback", "RFC 1122"},
+	{"169.254.0.0/16", "Link-Local", "RFC 3927"},
+	{"172.16.0.0/12", "Private-Use", "RFC 1918"},
+	{"192.0.0.0/24", "IETF Protocol Assignments", "RFC 6890"},
+	{"192.0.0.0/29", "IPv4 Service Continuity Prefix", "RFC 7335"},
+	{"192.0.0.8/32", "IPv4 Dummy Address", "RFC 7600"},
+	{"192.0.0.9/32", "Port Control Protocol Anycast", "RFC 7723"},
+	{"192.0.0.10/32", "TURN Anycast", "RFC 8155"},
+	{"192.0.0.170/31", "NAT64/DNS64 Discovery", "RFC 8880"},
+	{"192.0.2.0/24", "Documentation (TEST-NET-1)", "RFC 5737"},
+	{"192.31.196.0/24", "AS112-v4", "RFC 7535"},
+	{"192.52.193.0/24", "AMT", "RFC 7450"},
+	{"192.88.99.0/24", "Deprecated 6to4 Relay Anycast", "RFC 7526"},
+	{"192.168.0.0/16", "Private-Use", "RFC 1918"},
+	{"192.175.48.0/24", "Direct Delegation AS112 Service", "RFC 7534"},
+	{"198.18.0.0/15", "Benchmarking", "RFC 2544"},
+	{"198.51.100.0/24", "Documentation (TEST-NET-2)", "RFC 5737"},
+	{"203.0.113.0/24", "Documentation (TEST-NET-3)", "RFC 5737"},
+	{"224.0.0.0/4", "Multicast", "RFC 5771"},
+	{"240.0.0.0/4", "Reserved", "RFC 1112"},
+	{"255.255.255.255/32", "Limited Broadcast", "RFC 919"},
+
+	{"::/128", "Unspecified Address", "RFC 4291"},
+	{"::1/128", "Loopback", "RFC 4291"},
+	{"::ffff:0:0/96", "IPv4-Mapped Address", "RFC 4291"},
+	{"64:ff9b::/96", "IPv4-IPv6 Translation", "RFC 6052"},
+	{"64:ff9b:1::/48", "Local-Use IPv4-IPv6 Translation", "RFC 8215"},
+	{"100::/64", "Discard-Only", "RFC 6666"},
+	{"2001::/23", "IETF Protocol Assignments", "RFC 2928"},
+	{"2001::/32", "TEREDO", "RFC 4380"},
+	{"2001:1::1/128", "Port Control Protocol Anycast", "RFC 7723"},
+	{"2001:1::2/128", "TURN Anycast", "RFC 8155"},
+	{"2001:2::/48", "Benchmarking", "RFC 5180"},
+	{"2001:3::/32", "AMT", "RFC 7450"},
+	{"2001:4:112::/48", "AS112-v6", "RFC 7535"},
+	{"2001:10::/28", "Deprecated ORCHID", "RFC 4843"},
+	{"2001:20::/28", "ORCHIDv2", "RFC 7343"},
+	{"2001:db8::/32", "Documentation", "RFC 3849"},
+	{"2002::/16", "6to4", "RFC 3056"},
+	{"2620:4f:8000::/48", "Direct Delegation AS112 Service", "RFC 7534"},
+	{"fc00::/7", "Unique-Local", "RFC 4193"},
+	{"fe80::/10", "Link-Local Unicast", "RFC 4291"},
+	{"ff00::/8", "Multicast", "RFC 4291"},
+})
+
+// globalUnicast is the IPv6 space allocated for global unicast addresses
+var globalUnicast = parseSpecialRanges([][3]string{{"2000::/3", "Global Unicast", "RFC 4291"}})[0]
+
+// parseSpecialRanges parses the registry table; its entries are constant, so
+// a parse error is a bug
+func parseSpecialRanges(entries [][3]string) []SpecialRange {
+	ranges := make([]SpecialRange, 0, len(entries))
+	for _, entry := range entries {
+		_, network, err := net.ParseCIDR(entry[0])
+		if err != nil {
+			panic(fmt.Sprintf("invalid special-purpose range %s: %v", entry[0], err))
+		}
+		ranges = append(ranges, SpecialRange{Network: network, Name: entry[1], RFC: entry[2]})
+	}
+	return ranges
+}
+
+// AddressClass is the classification of a network against the special-purpose
+// registries
+type AddressClass struct {
+	Name string // e.g. "Private-Use", "Public" or "Mixed"
+	RFC  string // the RFC defining the range, if any
+	// Special marks a network inside a special-purpose range
+	Special bool
+	Pack    string // the rule pack of the range, if it is not a registry one
+}
+
+// String returns the class with its RFC, e.g. "Private-Use (RFC 1918)", or
+// with its rule pack, e.g. "Guest Wi-Fi (corp, NET-7)"
+func (a AddressClass) String() string {
+	var details []string
+	for _, detail := range []string{a.Pack, a.RFC} {
+		if detail != "" {
+			details = append(details, detail)
+		}
+	}
+	if len(details) == 0 {
+		return a.Name
+	}
+	return fmt.Sprintf("%s (%s)", a.Name, strings.Join(details, ", "))
+}
+
+// sameFamily reports whether a registry range is of the network's address
+// family; ::ffff:0:0/96 would otherwise contain every IPv4 address
+func (r SpecialRange) sameFamily(info *NetworkInfo) bool {
+	_, bits := r.Network.Mask.Size()
+	return bits == info.MaxPrefix()
+}
+
+// Classify classifies the network against the special-purpose registries
+func (n *NetworkInfo) Classify() AddressClass {
+	return defaultClassRules.Classify(n)
+}
+
+// Classify returns the most specific range of the rules holding the whole
+// network; of two equally specific ones, the later wins, so packs override
+// the registries. A network outside every range is public (global unicast
+// for IPv6), unless it spans ranges, which makes it mixed.
+func (r *ClassRules) Classify(n *NetworkInfo) AddressClass {
+	var match *SpecialRange
+	spanned := 0
+	for i, special := range r.Ranges {
+		if !special.sameFamily(n) {
+			continue
+		}
+		prefix, _ := special.Network.Mask.Size()
+		switch {
+		case prefix <= n.PrefixLength && special.Network.Contains(n.NetworkID):
+			if match == nil || prefix >= matchPrefix(match) {
+				match = &r.Ranges[i]
+			}
+		case prefix > n.PrefixLength && n.Network.Contains(special.Network.IP):
+			spanned++
+		}
+	}
+
+	switch {
+	case match != nil:
+		return AddressClass{Name: match.Name, RFC: match.RFC, Special: true, Pack: match.Pack}
+	case spanned > 0:
+		return AddressClass{Name: fmt.Sprintf("Mixed (spans %d special-purpose ranges)", spanned)}
+	case !n.IsIPv6():
+		return AddressClass{Name: "Public"}
+	case globalUnicast.Network.Contains(n.NetworkID) && n.PrefixLength >= 3:
+		return AddressClass{Name: globalUnicast.Name, RFC: globalUnicast.RFC}
+	}
+	return AddressClass{Name: "Reserved by IETF", RFC: "RFC 4291", Special: true}
+}
+
+// matchPrefix returns the prefix length of a registry range
+func matchPrefix(r *SpecialRange) int {
+	prefix, _ := r.Network.Mask.Size()
+	return prefix
+}
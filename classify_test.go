@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestNetworkInfo_Classify(t *testing.T) {
+	calculator := NewCIDRCalculator()
+
+	tests := []struct {
+		cidr     string
+		expected string
+		special  bool
+	}{
+		{"10.1.2.0/24", "Private-Use (RFC 1918)", true},
+		{"172.31.255.255/32", "Private-Use (RFC 1918)", true},
+		{"192.168.0.0/16", "Private-Use (RFC 1918)", true},
+		{"100.64.0.0/10", "Shared Address Space (CGN) (RFC 6598)", true},
+		{"127.0.0.1/32", "Loopback (RFC 1122)", true},
+		{"169.254.10.0/24", "Link-Local (RFC 3927)", true},
+		{"239.1.1.0/24", "Multicast (RFC 5771)", true},
+		{"198.19.0.0/16", "Benchmarking (RFC 2544)", true},
+		{"203.0.113.0/25", "Documentation (TEST-NET-3) (RFC 5737)", true},
+		{"192.0.0.9/32", "Port Control Protocol Anycast (RFC 7723)", true},
+		{"192.0.0.128/25", "IETF Protocol Assignments (RFC 6890)", true},
+		{"8.8.8.0/24", "Public", false},
+		{"172.32.0.0/16", "Public", false},
+		{"192.0.0.0/16", "Mixed (spans 7 special-purpose ranges)", false},
+		{"::1/128", "Loopback (RFC 4291)", true},
+		{"fe80::/64", "Link-Local Unicast (RFC 4291)", true},
+		{"fd12:3456::/48", "Unique-Local (RFC 4193)", true},
+		{"ff02::/16", "Multicast (RFC 4291)", true},
+		{"2001:db8:abcd::/48", "Documentation (RFC 3849)", true},
+		{"2001:2::/64", "Benchmarking (RFC 5180)", true},
+		{"2a00:1450::/32", "Global Unicast (RFC 4291)", false},
+		{"4000::/3", "Reserved by IETF (RFC 4291)", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			info, err := calculator.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("failed to parse CIDR: %v", err)
+			}
+			class := info.Classify()
+			if class.String() != tt.expected || class.Special != tt.special {
+				t.Errorf("expected %q (special %v), got %q (special %v)", tt.expected, tt.special, class.String(), class.Special)
+			}
+		})
+	}
+}
+
+func TestNetworkInfo_ClassifyMixed(t *testing.T) {
+	info, err := NewCIDRCalculator().ParseCIDR("0.0.0.0/0")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+	// IPv4-mapped IPv6 space must not count towards IPv4 networks
+	if class := info.Classify(); class.Name != "Mixed (spans 24 special-purpose ranges)" {
+		t.Errorf("unexpected classification %q", class.Name)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ClassRules are the ranges networks are classified against: the embedded
+// special-purpose registries, followed by the ranges of any rule packs
+type ClassRules struct {
+	Ranges []SpecialRange
+}
+
+// defaultClassRules is the embedded pack of the IANA registries
+var defaultClassRules = &ClassRules{Ranges: specialRanges}
+
+// ParseClassRules decodes a YAML or JSON rule pack such as
+//
+//	name: corp
+//	rules:
+//	  - cidr: 10.66.0.0/16
+//	    name: Guest Wi-Fi
+//	    reference: NET-7
+//
+// The pack is named after the file unless it has a name.
+func ParseClassRules(source string, content []byte) ([]SpecialRange, error) {
+	value, err := decodePlanValue(source, content)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := yamlFields(source, "the rule pack", value, "name", "rules")
+	if err != nil {
+		return nil, err
+	}
+
+	pack, err := yamlString(source, "name", fields["name"])
+	if err != nil {
+		return nil, err
+	}
+	if pack == "" {
+		pack = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	}
+	items, err := yamlItems(source, "rules", fields["rules"])
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%s: rules lists no ranges", source)
+	}
+
+	ranges := make([]SpecialRange, 0, len(items))
+	for i, item := range items {
+		what := fmt.Sprintf("rule %d", i+1)
+		ruleFields, err := yamlFields(source, what, item, "cidr", "name", "reference")
+		if err != nil {
+			return nil, err
+		}
+		var cidr, name, reference string
+		for _, field := range []struct {
+			key    string
+			target *string
+		}{{"cidr", &cidr}, {"name", &name}, {"reference", &reference}} {
+			if *field.target, err = yamlString(source, what+" "+field.key, ruleFields[field.key]); err != nil {
+				return nil, err
+			}
+		}
+		if cidr == "" || name == "" {
+			return nil, fmt.Errorf("%s: %s needs a cidr and a name", source, what)
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: invalid CIDR %q", source, what, cidr)
+		}
+		ranges = append(ranges, SpecialRange{Network: network, Name: name, RFC: reference, Pack: pack})
+	}
+	return ranges, nil
+}
+
+// classify classifies a network against the rules of the formatter
+func (f *OutputFormatter) classify(info *NetworkInfo) AddressClass {
+	if f.ClassRules == nil {
+		return info.Classify()
+	}
+	return f.ClassRules.Classify(info)
+}
+
+// LoadClassRules returns the embedded registries followed by the rule packs
+// of the files, so later packs win over earlier ones
+func LoadClassRules(filenames []string) (*ClassRules, error) {
+	rules := &ClassRules{Ranges: append([]SpecialRange(nil), specialRanges...)}
+	for _, filename := range filenames {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read class rules: %v", err)
+		}
+		ranges, err := ParseClassRules(filename, content)
+		if err != nil {
+			return nil, err
+		}
+		rules.Ranges = append(rules.Ranges, ranges...)
+	}
+	return rules, nil
+}
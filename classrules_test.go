@@ -0,0 +1,124 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseClassRules(t *testing.T) {
+	ranges, err := ParseClassRules("packs/corp.yaml", []byte("rules:\n  - cidr: 10.66.0.0/16\n    name: Guest Wi-Fi\n    reference: NET-7\n  - cidr: 2001:db8:66::/48\n    name: Lab\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ranges) != 2 || ranges[0].Network.String() != "10.66.0.0/16" || ranges[0].Name != "Guest Wi-Fi" ||
+		ranges[0].RFC != "NET-7" || ranges[0].Pack != "corp" || ranges[1].Network.String() != "2001:db8:66::/48" {
+		t.Errorf("unexpected ranges %+v", ranges)
+	}
+
+	// JSON packs work too, with their own name
+	ranges, err = ParseClassRules("corp.json", []byte(`{"name": "acme", "rules": [{"cidr": "10.66.1.7/16", "name": "Guest Wi-Fi"}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ranges) != 1 || ranges[0].Pack != "acme" || ranges[0].Network.String() != "10.66.0.0/16" {
+		t.Errorf("unexpected ranges %+v", ranges)
+	}
+
+	errorCases := []struct {
+		content  string
+		expected string
+	}{
+		{"name: corp\n", "corp.yaml: rules lists no ranges"},
+		{"rules:\n  - cidr: 10.66.0.0/16\n", "corp.yaml: rule 1 needs a cidr and a name"},
+		{"rules:\n  - cidr: 10.66.0.0/33\n    name: Guest\n", "corp.yaml: rule 1: invalid CIDR \"10.66.0.0/33\""},
+		{"rules:\n  - cidr: 10.66.0.0/16\n    name: Guest\n    color: red\n", "corp.yaml: unknown field \"color\" in rule 1"},
+	}
+	for _, tt := range errorCases {
+		if _, err := ParseClassRules("corp.yaml", []byte(tt.content)); err == nil || err.Error() != tt.expected {
+			t.Errorf("%q: expected error %q, got %v", tt.content, tt.expected, err)
+		}
+	}
+}
+
+func TestClassRules_Classify(t *testing.T) {
+	dir := t.TempDir()
+	corp := filepath.Join(dir, "corp.yaml")
+	os.WriteFile(corp, []byte("rules:\n  - cidr: 10.66.0.0/16\n    name: Guest Wi-Fi\n    reference: NET-7\n  - cidr: 10.0.0.0/8\n    name: Corporate LAN\n"), 0644)
+	lab := filepath.Join(dir, "lab.yaml")
+	os.WriteFile(lab, []byte("rules:\n  - cidr: 10.66.0.0/16\n    name: Lab Wi-Fi\n"), 0644)
+
+	rules, err := LoadClassRules([]string{corp})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	calculator := NewCIDRCalculator()
+	testCases := []struct {
+		cidr     string
+		expected string
+	}{
+		{"10.66.12.0/24", "Guest Wi-Fi (corp, NET-7)"},
+		{"10.1.0.0/16", "Corporate LAN (corp)"},
+		{"192.168.1.0/24", "Private-Use (RFC 1918)"},
+		{"8.8.8.0/24", "Public"},
+	}
+	for _, tt := range testCases {
+		if class := rules.Classify(mustParseCIDR(t, calculator, tt.cidr)); class.String() != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.cidr, tt.expected, class)
+		}
+	}
+
+	// A later pack wins over an earlier one; the registries stay untouched
+	rules, _ = LoadClassRules([]string{corp, lab})
+	info := mustParseCIDR(t, calculator, "10.66.12.0/24")
+	if class := rules.Classify(info); class.String() != "Lab Wi-Fi (lab)" {
+		t.Errorf("expected the later pack to win, got %q", class)
+	}
+	if class := info.Classify(); class.String() != "Private-Use (RFC 1918)" {
+		t.Errorf("expected the registries alone without packs, got %q", class)
+	}
+
+	if _, err := LoadClassRules([]string{filepath.Join(dir, "missing.yaml")}); err == nil || !strings.Contains(err.Error(), "failed to read class rules") {
+		t.Errorf("expected a read error, got %v", err)
+	}
+}
+
+func TestCLIHandler_ClassRules(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+	corp := filepath.Join(dir, "corp.yaml")
+	os.WriteFile(corp, []byte("name: corp\nrules:\n  - cidr: 10.66.0.0/16\n    name: Guest Wi-Fi\n"), 0644)
+
+	output := filepath.Join(dir, "report.json")
+	if err := handler.Run([]string{"cidr-calc", "--class-rules", corp, "-o", output, "10.66.1.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	if !strings.Contains(string(content), "\"classification\": {\n        \"name\": \"Guest Wi-Fi\",\n        \"special\": true,\n        \"pack\": \"corp\"\n      },") {
+		t.Errorf("expected the pack's classification, got:\n%s", content)
+	}
+
+	output = filepath.Join(dir, "report.csv")
+	if err := handler.Run([]string{"cidr-calc", "--class-rules", corp, "-o", output, "10.66.1.0/25"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); !strings.Contains(string(content), ",Guest Wi-Fi (corp)\n") {
+		t.Errorf("expected the pack's classification for every subnet, got:\n%s", content)
+	}
+
+	// Without the flag the next run is back to the registries
+	if err := handler.Run([]string{"cidr-calc", "-o", output, "10.66.1.0/25"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); strings.Contains(string(content), "Guest Wi-Fi") {
+		t.Errorf("expected the registries without --class-rules, got:\n%s", content)
+	}
+
+	os.WriteFile(corp, []byte("rules: []\n"), 0644)
+	if err := handler.Run([]string{"cidr-calc", "--class-rules", corp, "10.66.1.0/24"}); err == nil || !strings.Contains(err.Error(), "rules lists no ranges") {
+		t.Errorf("expected an invalid pack to stop the run, got %v", err)
+	}
+}
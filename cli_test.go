@@ -1,6 +1,7 @@
 package main
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,15 +10,18 @@ import (
 
 func TestCLIHandler_parseFlags(t *testing.T) {
 	handler := NewCLIHandler()
+	handler.stderr = io.Discard
 
 	tests := []struct {
-		name        string
-		args        []string
-		expectCIDR  string
-		expectFile  string
-		expectHTML  bool
-		expectHelp  bool
-		expectError bool
+		name         string
+		args         []string
+		expectCIDR   string
+		expectCIDRs  []string
+		expectFile   string
+		expectHTML   bool
+		expectFormat string
+		expectHelp   bool
+		expectError  bool
 	}{
 		{
 			name:       "basic CIDR input",
@@ -25,16 +29,18 @@ func TestCLIHandler_parseFlags(t *testing.T) {
 			expectCIDR: "192.168.1.0/24",
 		},
 		{
-			name:       "output file flag short",
-			args:       []string{"cidr-calc", "-o", "output.txt", "10.0.0.0/8"},
-			expectCIDR: "10.0.0.0/8",
-			expectFile: "output.txt",
+			name:         "output file flag short",
+			args:         []string{"cidr-calc", "-o", "output.txt", "10.0.0.0/8"},
+			expectCIDR:   "10.0.0.0/8",
+			expectFile:   "output.txt",
+			expectFormat: FormatText,
 		},
 		{
-			name:       "output file flag long",
-			args:       []string{"cidr-calc", "--output", "report.txt", "172.16.0.0/16"},
-			expectCIDR: "172.16.0.0/16",
-			expectFile: "report.txt",
+			name:         "output file flag long",
+			args:         []string{"cidr-calc", "--output", "report.txt", "172.16.0.0/16"},
+			expectCIDR:   "172.16.0.0/16",
+			expectFile:   "report.txt",
+			expectFormat: FormatText,
 		},
 		{
 			name:       "HTML flag short",
@@ -61,13 +67,85 @@ func TestCLIHandler_parseFlags(t *testing.T) {
 			expectHelp: true,
 		},
 		{
-			name:        "HTML output with non-HTML file extension",
-			args:        []string{"cidr-calc", "--html", "-o", "output.txt", "192.168.1.0/24"},
+			name:       "HTML output with non-HTML file extension warns",
+			args:       []string{"cidr-calc", "--html", "-o", "output.txt", "192.168.1.0/24"},
+			expectCIDR: "192.168.1.0/24",
+			expectFile: "output.txt",
+			expectHTML: true,
+		},
+		{
+			name:         "HTML file extension infers HTML format",
+			args:         []string{"cidr-calc", "-o", "output.html", "192.168.1.0/24"},
+			expectCIDR:   "192.168.1.0/24",
+			expectFile:   "output.html",
+			expectFormat: FormatHTML,
+		},
+		{
+			name:         "org file extension infers org format",
+			args:         []string{"cidr-calc", "-o", "plan.org", "192.168.1.0/24"},
+			expectCIDR:   "192.168.1.0/24",
+			expectFile:   "plan.org",
+			expectFormat: FormatOrg,
+		},
+		{
+			name:         "csv file extension infers CSV format",
+			args:         []string{"cidr-calc", "-o", "subnets.csv", "192.168.1.0/24"},
+			expectCIDR:   "192.168.1.0/24",
+			expectFile:   "subnets.csv",
+			expectFormat: FormatCSV,
+		},
+		{
+			name:         "markdown file extension infers Markdown format",
+			args:         []string{"cidr-calc", "-o", "NOTES.md", "192.168.1.0/24"},
+			expectCIDR:   "192.168.1.0/24",
+			expectFile:   "NOTES.md",
+			expectFormat: FormatMD,
+		},
+		{
+			name:         "xml file extension infers XML format",
+			args:         []string{"cidr-calc", "-o", "inventory.xml", "192.168.1.0/24"},
+			expectCIDR:   "192.168.1.0/24",
+			expectFile:   "inventory.xml",
+			expectFormat: FormatXML,
+		},
+		{
+			name:       "stdout sentinel keeps HTML format",
+			args:       []string{"cidr-calc", "--strict-ext", "--html", "-o", "-", "192.168.1.0/24"},
+			expectCIDR: "192.168.1.0/24",
+			expectFile: "-",
+			expectHTML: true,
+		},
+		{
+			name:        "strict HTML output with non-HTML file extension",
+			args:        []string{"cidr-calc", "--strict-ext", "--html", "-o", "output.txt", "192.168.1.0/24"},
+			expectError: true,
+		},
+		{
+			name:        "strict HTML file extension without HTML flag",
+			args:        []string{"cidr-calc", "--strict-ext", "-o", "output.html", "192.168.1.0/24"},
+			expectError: true,
+		},
+		{
+			name:        "split combined with parts",
+			args:        []string{"cidr-calc", "--split", "28", "--parts", "6", "192.168.1.0/24"},
 			expectError: true,
 		},
 		{
-			name:        "HTML file extension without HTML flag",
-			args:        []string{"cidr-calc", "-o", "output.html", "192.168.1.0/24"},
+			name:         "several CIDRs with flags in between",
+			args:         []string{"cidr-calc", "10.0.0.0/24", "-o", "report.md", "10.0.1.0/24", "172.16.0.0/22"},
+			expectCIDR:   "10.0.0.0/24",
+			expectCIDRs:  []string{"10.0.0.0/24", "10.0.1.0/24", "172.16.0.0/22"},
+			expectFile:   "report.md",
+			expectFormat: "md",
+		},
+		{
+			name:        "several CIDRs with vlsm",
+			args:        []string{"cidr-calc", "--vlsm", "50,20", "10.0.0.0/24", "10.0.1.0/24"},
+			expectError: true,
+		},
+		{
+			name:        "parts combined with hosts",
+			args:        []string{"cidr-calc", "--parts", "6", "--hosts", "20", "192.168.1.0/24"},
 			expectError: true,
 		},
 		{
@@ -75,6 +153,22 @@ func TestCLIHandler_parseFlags(t *testing.T) {
 			args:        []string{"cidr-calc", "--invalid", "192.168.1.0/24"},
 			expectError: true,
 		},
+		{
+			name:         "slack format",
+			args:         []string{"cidr-calc", "--format", "slack", "10.0.0.0/24"},
+			expectCIDR:   "10.0.0.0/24",
+			expectFormat: FormatSlack,
+		},
+		{
+			name:        "unknown format",
+			args:        []string{"cidr-calc", "--format", "pdf", "10.0.0.0/24"},
+			expectError: true,
+		},
+		{
+			name:        "HTML flag with conflicting format",
+			args:        []string{"cidr-calc", "--html", "--format", "teams", "10.0.0.0/24"},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -97,6 +191,10 @@ func TestCLIHandler_parseFlags(t *testing.T) {
 				t.Errorf("expected CIDR %q, got %q", tt.expectCIDR, config.CIDR)
 			}
 
+			if tt.expectCIDRs != nil && strings.Join(config.CIDRs, " ") != strings.Join(tt.expectCIDRs, " ") {
+				t.Errorf("expected CIDRs %v, got %v", tt.expectCIDRs, config.CIDRs)
+			}
+
 			if config.OutputFile != tt.expectFile {
 				t.Errorf("expected output file %q, got %q", tt.expectFile, config.OutputFile)
 			}
@@ -105,6 +203,10 @@ func TestCLIHandler_parseFlags(t *testing.T) {
 				t.Errorf("expected HTML output %v, got %v", tt.expectHTML, config.HTMLOutput)
 			}
 
+			if config.Format != tt.expectFormat {
+				t.Errorf("expected format %q, got %q", tt.expectFormat, config.Format)
+			}
+
 			if config.ShowHelp != tt.expectHelp {
 				t.Errorf("expected show help %v, got %v", tt.expectHelp, config.ShowHelp)
 			}
@@ -114,6 +216,7 @@ func TestCLIHandler_parseFlags(t *testing.T) {
 
 func TestCLIHandler_validateConfig(t *testing.T) {
 	handler := NewCLIHandler()
+	handler.stderr = io.Discard
 
 	tests := []struct {
 		name        string
@@ -154,7 +257,7 @@ func TestCLIHandler_validateConfig(t *testing.T) {
 				OutputFile: "output.txt",
 				HTMLOutput: true,
 			},
-			expectError: true,
+			expectError: false,
 		},
 		{
 			name: "HTML extension without HTML flag",
@@ -163,6 +266,36 @@ func TestCLIHandler_validateConfig(t *testing.T) {
 				OutputFile: "output.html",
 				HTMLOutput: false,
 			},
+			expectError: false,
+		},
+		{
+			name: "strict HTML flag with non-HTML extension",
+			config: &Config{
+				CIDR:       "192.168.1.0/24",
+				OutputFile: "output.txt",
+				HTMLOutput: true,
+				StrictExt:  true,
+			},
+			expectError: true,
+		},
+		{
+			name: "strict HTML extension without HTML flag",
+			config: &Config{
+				CIDR:       "192.168.1.0/24",
+				OutputFile: "output.html",
+				HTMLOutput: false,
+				StrictExt:  true,
+			},
+			expectError: true,
+		},
+		{
+			name: "strict format mismatch with known extension",
+			config: &Config{
+				CIDR:       "192.168.1.0/24",
+				OutputFile: "output.txt",
+				Format:     FormatRST,
+				StrictExt:  true,
+			},
 			expectError: true,
 		},
 		{
@@ -277,6 +410,12 @@ func TestCLIHandler_FileOutput_Integration(t *testing.T) {
 			checkFile:   true,
 			isHTML:      true,
 		},
+		{
+			name:        "Teams JSON file output",
+			args:        []string{"cidr-calc", "--format", "teams", "-o", filepath.Join(tempDir, "card.json"), "10.1.0.0/16"},
+			expectError: false,
+			checkFile:   false,
+		},
 		{
 			name:        "invalid file path",
 			args:        []string{"cidr-calc", "-o", "/invalid/path/output.txt", "192.168.1.0/24"},
@@ -353,6 +492,413 @@ func TestCLIHandler_FileOutput_Integration(t *testing.T) {
 	}
 }
 
+func TestCLIHandler_ExtensionMismatchWarning(t *testing.T) {
+	handler := NewCLIHandler()
+	var stderr strings.Builder
+	handler.stderr = &stderr
+
+	filename := filepath.Join(t.TempDir(), "report.txt")
+	if err := handler.Run([]string{"cidr-calc", "--html", "-o", filename, "192.168.1.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(stderr.String(), "Warning: writing html output to "+filename+", whose extension suggests text") {
+		t.Errorf("expected extension mismatch warning, got %q", stderr.String())
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	if !strings.Contains(string(content), "<!DOCTYPE html>") {
+		t.Errorf("expected explicit HTML format to win over the .txt extension")
+	}
+}
+
+func TestCLIHandler_StdoutSentinel(t *testing.T) {
+	handler := NewCLIHandler()
+
+	// Run from an empty directory so any file named "-" would be noticed
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	for _, args := range [][]string{
+		{"cidr-calc", "-o", "-", "10.0.0.0/24"},
+		{"cidr-calc", "--html", "-o", "-", "10.0.0.0/24"},
+		{"cidr-calc", "--format", "latex", "--output", "-", "10.0.0.0/24"},
+	} {
+		if err := handler.Run(args); err != nil {
+			t.Errorf("%v: unexpected error: %v", args, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "-")); !os.IsNotExist(err) {
+		t.Errorf("expected no file named \"-\" to be created")
+	}
+}
+
+func TestCLIHandler_BatchInput(t *testing.T) {
+	handler := NewCLIHandler()
+	tempDir := t.TempDir()
+
+	input := filepath.Join(tempDir, "plan.txt")
+	if err := os.WriteFile(input, []byte("# plan\n10.0.0.0/24\n10.0.1.0/30\n"), 0644); err != nil {
+		t.Fatalf("failed to write input file: %v", err)
+	}
+
+	invalid := filepath.Join(tempDir, "invalid.txt")
+	if err := os.WriteFile(invalid, []byte("10.0.0.0/24\n10.0.1.0/99\n"), 0644); err != nil {
+		t.Fatalf("failed to write input file: %v", err)
+	}
+
+	output := filepath.Join(tempDir, "report.html")
+	if err := handler.Run([]string{"cidr-calc", "-f", input, "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	for _, exp := range []string{"CIDR Calculator Report - 2 networks", "10.0.0.0/24", "10.0.1.0/30"} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected report to contain %q", exp)
+		}
+	}
+
+	err = handler.Run([]string{"cidr-calc", "-f", invalid})
+	if err == nil || !strings.Contains(err.Error(), "invalid.txt line 2") {
+		t.Errorf("expected error naming line 2, got %v", err)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "-f", input, "10.0.0.0/8"}); err == nil {
+		t.Errorf("expected error when combining -f with a CIDR argument")
+	}
+}
+
+func TestCLIHandler_Split(t *testing.T) {
+	handler := NewCLIHandler()
+	tempDir := t.TempDir()
+
+	output := filepath.Join(tempDir, "split.txt")
+	if err := handler.Run([]string{"cidr-calc", "--split", "28", "-o", output, "192.168.1.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	for _, exp := range []string{"Possible /28 Subnets: 16", "192.168.1.0/28     (192.168.1.0 - 192.168.1.15)", "192.168.1.240/28   (192.168.1.240 - 192.168.1.255)"} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected report to contain %q, got:\n%s", exp, content)
+		}
+	}
+
+	input := filepath.Join(tempDir, "plan.txt")
+	if err := os.WriteFile(input, []byte("10.0.0.0/24\n10.0.1.0/27\n"), 0644); err != nil {
+		t.Fatalf("failed to write input file: %v", err)
+	}
+	err = handler.Run([]string{"cidr-calc", "--split", "26", "-f", input})
+	if err == nil || !strings.Contains(err.Error(), "plan.txt line 2: split prefix for 10.0.1.0/27 must be between /28 and /32") {
+		t.Errorf("expected split error naming line 2, got %v", err)
+	}
+
+	for _, prefix := range []string{"0", "-1"} {
+		if err := handler.Run([]string{"cidr-calc", "--split", prefix, "10.0.0.0/24"}); err == nil || err.Error() != "--split must be a prefix length of at least 1, got "+prefix {
+			t.Errorf("--split %s: expected a usage error, got %v", prefix, err)
+		}
+	}
+}
+
+func TestCLIHandler_Parts(t *testing.T) {
+	handler := NewCLIHandler()
+	var stderr strings.Builder
+	handler.stderr = &stderr
+
+	output := filepath.Join(t.TempDir(), "parts.md")
+	if err := handler.Run([]string{"cidr-calc", "--parts", "6", "-o", output, "10.0.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !strings.Contains(string(content), "Possible /27 subnets: 8") {
+		t.Errorf("expected eight /27 subnets, got:\n%s", content)
+	}
+	if !strings.Contains(stderr.String(), "Note: 10.0.0.0/24 splits into 8 /27 subnets for 6 parts; 2 remain unused") {
+		t.Errorf("expected a note about unused subnets, got %q", stderr.String())
+	}
+
+	stderr.Reset()
+	if err := handler.Run([]string{"cidr-calc", "--parts", "4", "-o", output, "10.0.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("expected no note for an exact split, got %q", stderr.String())
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "--parts", "3", "10.0.0.0/31"}); err == nil {
+		t.Errorf("expected error when the network is too small")
+	}
+	for _, parts := range []string{"0", "1", "-2"} {
+		if err := handler.Run([]string{"cidr-calc", "--parts", parts, "10.0.0.0/24"}); err == nil || err.Error() != "--parts must be at least 2, got "+parts {
+			t.Errorf("--parts %s: expected a usage error, got %v", parts, err)
+		}
+	}
+}
+
+func TestCLIHandler_Hosts(t *testing.T) {
+	handler := NewCLIHandler()
+	var stderr strings.Builder
+	handler.stderr = &stderr
+
+	output := filepath.Join(t.TempDir(), "hosts.md")
+	if err := handler.Run([]string{"cidr-calc", "--hosts", "500", "-o", output, "10.0.0.0/20"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !strings.Contains(string(content), "Possible /23 subnets: 8") {
+		t.Errorf("expected eight /23 subnets, got:\n%s", content)
+	}
+	if !strings.Contains(stderr.String(), "Note: each /23 subnet has 510 usable hosts for 500 requested") {
+		t.Errorf("expected a note about spare hosts, got %q", stderr.String())
+	}
+
+	stderr.Reset()
+	if err := handler.Run([]string{"cidr-calc", "--hosts", "62", "-o", output, "10.0.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("expected no note for an exact fit, got %q", stderr.String())
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "--hosts", "500", "10.0.0.0/24"}); err == nil {
+		t.Errorf("expected error when the network is too small")
+	}
+	for _, hosts := range []string{"0", "-5"} {
+		if err := handler.Run([]string{"cidr-calc", "--hosts", hosts, "10.0.0.0/24"}); err == nil || err.Error() != "--hosts must be at least 1, got "+hosts {
+			t.Errorf("--hosts %s: expected a usage error, got %v", hosts, err)
+		}
+	}
+}
+
+func TestCLIHandler_MaxSubnets(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	output := filepath.Join(t.TempDir(), "subnets.txt")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected []string
+		missing  string
+	}{
+		{
+			name:     "beyond the split limit",
+			args:     []string{"--split", "32", "--max-subnets", "3", "10.0.0.0/8"},
+			expected: []string{"Possible /32 Subnets: 16777216", "(Showing 3 of 16777216 subnets)", "10.0.0.2/32"},
+			missing:  "10.0.0.3/32",
+		},
+		{
+			name:     "next prefix",
+			args:     []string{"--max-subnets", "1", "192.168.1.0/24"},
+			expected: []string{"Possible /25 Subnets: 2", "(Showing 1 of 2 subnets)", "192.168.1.0/25"},
+			missing:  "192.168.1.128/25",
+		},
+		{
+			name:     "limit above the total",
+			args:     []string{"--split", "26", "--max-subnets", "10", "192.168.1.0/24"},
+			expected: []string{"Possible /26 Subnets: 4", "192.168.1.192/26"},
+			missing:  "Showing",
+		},
+		{
+			name:     "IPv6 total beyond 64 bits",
+			args:     []string{"--split", "128", "--max-subnets", "2", "2001:db8::/48"},
+			expected: []string{"Possible /128 Subnets: 1208925819614629174706176", "2001:db8::1/128"},
+		},
+		{
+			name:     "all lifts the split limit",
+			args:     []string{"--split", "28", "--all", "10.0.0.0/8"},
+			expected: []string{"Possible /28 Subnets: 1048576", "10.255.255.240/28"},
+			missing:  "Showing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"cidr-calc", "-o", output}, tt.args...)
+			if err := handler.Run(args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			content, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatalf("failed to read output: %v", err)
+			}
+			for _, exp := range tt.expected {
+				if !strings.Contains(string(content), exp) {
+					t.Errorf("expected report to contain %q, got:\n%s", exp, content)
+				}
+			}
+			if tt.missing != "" && strings.Contains(string(content), tt.missing) {
+				t.Errorf("expected report not to contain %q", tt.missing)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--split", "28", "10.0.0.0/8"}, "(limit 65536); use --max-subnets N to list the first N, or --all to list them all"},
+		{[]string{"--split", "32", "--all", "0.0.0.0/0"}, "(limit 16777216); use --max-subnets N to list the first N"},
+		{[]string{"--max-subnets", "-1", "10.0.0.0/24"}, "--max-subnets must be at least 1"},
+		{[]string{"--max-subnets", "2", "--all", "10.0.0.0/24"}, "--max-subnets cannot be combined with --all"},
+		{[]string{"--max-subnets", "2", "--vlsm", "50,20", "10.0.0.0/24"}, "--max-subnets and --all do not apply to --vlsm"},
+	}
+	for _, tt := range errorTests {
+		if err := handler.Run(append([]string{"cidr-calc"}, tt.args...)); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
+
+func TestCLIHandler_MultipleCIDRs(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	output := filepath.Join(dir, "combined.txt")
+	if err := handler.Run([]string{"cidr-calc", "-o", output, "10.0.0.0/24", "10.0.1.0/24", "2001:db8::/48"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	for _, cidr := range []string{"CIDR:           10.0.0.0/24", "CIDR:           10.0.1.0/24", "CIDR:           2001:db8::/48"} {
+		if !strings.Contains(string(content), cidr) {
+			t.Errorf("expected a section for %q, got:\n%s", cidr, content)
+		}
+	}
+
+	htmlOutput := filepath.Join(dir, "combined.html")
+	if err := handler.Run([]string{"cidr-calc", "--split", "26", "10.0.0.0/24", "-o", htmlOutput, "172.16.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err = os.ReadFile(htmlOutput)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !strings.Contains(string(content), "10.0.0.192/26") || !strings.Contains(string(content), "172.16.0.192/26") {
+		t.Errorf("expected /26 subnets of both networks in the HTML report")
+	}
+
+	err = handler.Run([]string{"cidr-calc", "-o", output, "10.0.0.0/24", "10.0.0.0/33"})
+	if err == nil || !strings.HasPrefix(err.Error(), "10.0.0.0/33: failed to parse CIDR") {
+		t.Errorf("expected an error naming the bad CIDR, got %v", err)
+	}
+}
+
+func TestCLIHandler_Range(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	output := filepath.Join(dir, "range.csv")
+	if err := handler.Run([]string{"cidr-calc", "--range", "10.0.0.5-10.0.0.20", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	var networks []string
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n")[1:] {
+		network := strings.Split(line, ",")[0]
+		if len(networks) == 0 || networks[len(networks)-1] != network {
+			networks = append(networks, network)
+		}
+	}
+	if strings.Join(networks, " ") != "10.0.0.5/32 10.0.0.6/31 10.0.0.8/29 10.0.0.16/30 10.0.0.20/32" {
+		t.Errorf("expected the CIDRs covering the range, got %v", networks)
+	}
+
+	errorCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--range", "10.0.0.0/24"}, "--range must be FIRST-LAST"},
+		{[]string{"--range", "10.0.0.9-10.0.0.1"}, "address range ends before it starts"},
+		{[]string{"--range", "2001:db8::1-2001:db8::9"}, "invalid IPv4 address range"},
+		{[]string{"--range", "10.0.0.1-10.0.0.9", "-f", "plan.txt"}, "--range cannot be combined with -f"},
+	}
+	for _, tt := range errorCases {
+		if err := handler.Run(append([]string{"cidr-calc"}, tt.args...)); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
+
+func TestCLIHandler_TagFilter(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	plan := filepath.Join(dir, "master.txt")
+	content := "10.10.0.0/24 env=prod team=payments\n10.20.0.0/24 env=staging # lab\n10.30.0.0/24 env=prod\n"
+	if err := os.WriteFile(plan, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+
+	output := filepath.Join(dir, "prod.csv")
+	if err := handler.Run([]string{"cidr-calc", "-f", plan, "--tag", "env=prod", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if strings.Contains(string(report), "10.20.0.0") {
+		t.Errorf("expected staging networks to be filtered out, got:\n%s", report)
+	}
+	if !strings.Contains(string(report), ",Tags\n") || !strings.Contains(string(report), "10.10.0.128,10.10.0.255,10.10.0.129,10.10.0.254,126,Private-Use (RFC 1918),env=prod team=payments") {
+		t.Errorf("expected a Tags column, got:\n%s", report)
+	}
+
+	output = filepath.Join(dir, "payments.txt")
+	if err := handler.Run([]string{"cidr-calc", "-f", plan, "--tag", "env=prod", "--tag", "team", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report, err = os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if strings.Count(string(report), "Network Information:") != 1 || !strings.Contains(string(report), "Tags:           env=prod team=payments") {
+		t.Errorf("expected only the tagged payments network, got:\n%s", report)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "-f", plan, "--tag", "env=dev", "-o", output}); err == nil {
+		t.Errorf("expected an error when no entry matches")
+	}
+	if _, err := handler.parseFlags([]string{"cidr-calc", "--tag", "env=prod", "10.0.0.0/24"}); err == nil {
+		t.Errorf("expected an error for --tag without -f")
+	}
+}
+
 func TestCLIHandler_showUsage(t *testing.T) {
 	handler := NewCLIHandler()
 
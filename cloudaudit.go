@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Cloud audit rule names
+const (
+	RuleOutsideNetwork = "outside-network"
+	RuleSubnetOverlap  = "subnet-overlap"
+	RuleNetworkOverlap = "network-overlap"
+)
+
+// CloudSubnet is a subnet deployed in a cloud network
+type CloudSubnet struct {
+	ID   string
+	Name string
+	CIDR string
+	Zone string
+}
+
+// CloudNetwork is a deployed virtual network (an AWS VPC, Azure VNet or GCP VPC)
+type CloudNetwork struct {
+	ID      string
+	Name    string
+	CIDRs   []string
+	Subnets []CloudSubnet
+}
+
+// CloudInventory is the set of networks discovered in one provider region
+type CloudInventory struct {
+	Provider string
+	Region   string
+	Networks []CloudNetwork
+}
+
+// CloudAudit is the result of analysing a cloud inventory
+type CloudAudit struct {
+	Inventory *CloudInventory
+	Findings  []Finding
+	FreeSpace map[string][]string
+}
+
+// freeSpaceKey identifies one CIDR of one network in CloudAudit.FreeSpace;
+// the same CIDR may be reused by networks in different accounts
+func freeSpaceKey(networkID, cidr string) string {
+	return networkID + " " + cidr
+}
+
+// commandRunner runs an external command and returns its standard output
+type commandRunner func(name string, args ...string) ([]byte, error)
+
+// runCommand runs an external command, including its stderr in the error on failure
+func runCommand(name string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("%s CLI not found in PATH: %v", name, err)
+	}
+
+	cmd := exec.Command(name, args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, fmt.Errorf("%s failed: %s", strings.Join(append([]string{name}, args...), " "), message)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// CloudAuditor checks deployed networks for overlaps, misaligned subnets and free space
+type CloudAuditor struct {
+	calculator *CIDRCalculator
+}
+
+// NewCloudAuditor creates a new cloud auditor
+func NewCloudAuditor() *CloudAuditor {
+	return &CloudAuditor{calculator: NewCIDRCalculator()}
+}
+
+// Audit analyses the inventory. Subnets must lie inside one of their network's CIDRs
+// and must not overlap each other; networks that overlap each other cannot be peered.
+// Free space lists the unallocated blocks of every network CIDR, keyed by freeSpaceKey.
+func (a *CloudAuditor) Audit(inventory *CloudInventory) *CloudAudit {
+	audit := &CloudAudit{Inventory: inventory, FreeSpace: make(map[string][]string)}
+	var allNetworks []cloudBlock
+
+	for _, network := range inventory.Networks {
+		var blocks []*NetworkInfo
+		for _, cidr := range network.CIDRs {
+			info, err := a.calculator.ParseCIDR(cidr)
+			if err != nil {
+				continue
+			}
+			blocks = append(blocks, info)
+			allNetworks = append(allNetworks, cloudBlock{owner: network.ID, info: info})
+		}
+
+		var subnets []cloudBlock
+		for _, subnet := range network.Subnets {
+			info, err := a.calculator.ParseCIDR(subnet.CIDR)
+			if err != nil {
+				continue
+			}
+
+			// Networks without address space of their own (GCP VPCs) are
+			// compared through their subnets instead
+			if len(network.CIDRs) == 0 {
+				allNetworks = append(allNetworks, cloudBlock{owner: network.ID, info: info})
+			} else if !containedInAny(info, blocks) {
+				audit.Findings = append(audit.Findings, Finding{
+					Severity: SeverityError,
+					Rule:     RuleOutsideNetwork,
+					Message:  fmt.Sprintf("subnet %s (%s) is not inside any CIDR of %s", info.CIDR(), subnet.ID, network.ID),
+					CIDR:     subnet.CIDR,
+				})
+			}
+
+			for _, other := range subnets {
+				if info.Overlaps(other.info) {
+					audit.Findings = append(audit.Findings, Finding{
+						Severity: SeverityError,
+						Rule:     RuleSubnetOverlap,
+						Message:  fmt.Sprintf("subnet %s (%s) overlaps %s (%s) in %s", info.CIDR(), subnet.ID, other.info.CIDR(), other.owner, network.ID),
+						CIDR:     subnet.CIDR,
+					})
+				}
+			}
+
+			subnets = append(subnets, cloudBlock{owner: subnet.ID, info: info})
+		}
+
+		used := make([]*NetworkInfo, 0, len(subnets))
+		for _, subnet := range subnets {
+			used = append(used, subnet.info)
+		}
+		for _, block := range blocks {
+			// Free space is only tracked for IPv4; IPv6 networks are never short of it
+			if block.IsIPv6() {
+				continue
+			}
+			audit.FreeSpace[freeSpaceKey(network.ID, block.CIDR())] = a.calculator.FreeBlocks(block, used)
+		}
+	}
+
+	for i, network := range allNetworks {
+		for _, other := range allNetworks[:i] {
+			if network.owner != other.owner && network.info.Overlaps(other.info) {
+				audit.Findings = append(audit.Findings, Finding{
+					Severity: SeverityWarning,
+					Rule:     RuleNetworkOverlap,
+					Message:  fmt.Sprintf("%s (%s) overlaps %s (%s); they cannot be peered", network.info.CIDR(), network.owner, other.info.CIDR(), other.owner),
+					CIDR:     network.info.CIDR(),
+				})
+			}
+		}
+	}
+
+	return audit
+}
+
+// Reports returns a standard network report for every network CIDR in the inventory,
+// using the subnets of networks that have no address space of their own
+func (a *CloudAuditor) Reports(inventory *CloudInventory) []NetworkReport {
+	var reports []NetworkReport
+	for _, network := range inventory.Networks {
+		cidrs := network.CIDRs
+		if len(cidrs) == 0 {
+			for _, subnet := range network.Subnets {
+				cidrs = append(cidrs, subnet.CIDR)
+			}
+		}
+
+		for _, cidr := range cidrs {
+			info, err := a.calculator.ParseCIDR(cidr)
+			if err != nil {
+				continue
+			}
+			reports = append(reports, NetworkReport{Info: info, Subnets: a.calculator.CalculateSubnets(info)})
+		}
+	}
+	return reports
+}
+
+// cloudBlock is a parsed CIDR together with the ID of the resource that owns it
+type cloudBlock struct {
+	owner string
+	info  *NetworkInfo
+}
+
+// containedInAny reports whether info lies inside one of the networks
+func containedInAny(info *NetworkInfo, networks []*NetworkInfo) bool {
+	for _, network := range networks {
+		if network.Contains(info) {
+			return true
+		}
+	}
+	return false
+}
+
+// cloudNetworkLabel returns the ID of a network, followed by its name when it has one
+func cloudNetworkLabel(id, name string) string {
+	if name == "" || name == id {
+		return id
+	}
+	return fmt.Sprintf("%s (%s)", id, name)
+}
+
+// FormatCloudAudit renders an audit as text: each network with its subnets and
+// free space, followed by the findings
+func (f *OutputFormatter) FormatCloudAudit(audit *CloudAudit) string {
+	var output strings.Builder
+	inventory := audit.Inventory
+
+	output.WriteString(fmt.Sprintf("%s Audit (%s):\n", inventory.Provider, inventory.Region))
+	if len(inventory.Networks) == 0 {
+		output.WriteString("  No networks found\n\n")
+	}
+
+	for _, network := range inventory.Networks {
+		output.WriteString(fmt.Sprintf("\nNetwork %s:", cloudNetworkLabel(network.ID, network.Name)))
+		if len(network.CIDRs) > 0 {
+			output.WriteString(" " + strings.Join(network.CIDRs, ", "))
+		}
+		output.WriteString("\n")
+
+		output.WriteString("  Subnets:\n")
+		if len(network.Subnets) == 0 {
+			output.WriteString("    none\n")
+		}
+		for _, subnet := range network.Subnets {
+			label := cloudNetworkLabel(subnet.ID, subnet.Name)
+			if subnet.Zone != "" {
+				label += " " + subnet.Zone
+			}
+			output.WriteString(fmt.Sprintf("    %-18s %s\n", subnet.CIDR, label))
+		}
+
+		for _, cidr := range network.CIDRs {
+			free, ok := audit.FreeSpace[freeSpaceKey(network.ID, cidr)]
+			if !ok {
+				continue
+			}
+			output.WriteString(fmt.Sprintf("  Free Space in %s:\n", cidr))
+			if len(free) == 0 {
+				output.WriteString("    none\n")
+			}
+			for _, block := range free {
+				output.WriteString(fmt.Sprintf("    %s\n", block))
+			}
+		}
+	}
+
+	output.WriteString("\n")
+	output.WriteString(f.FormatFindings(audit.Findings))
+
+	return output.String()
+}
+
+// writeCloudAudit writes the rendered audit and fails when it found errors
+func (c *CLIHandler) writeCloudAudit(format, outputFile string, emit []EmitTarget, audit *CloudAudit) error {
+	content, err := c.renderCloudAudit(format, audit)
+	if err != nil {
+		return err
+	}
+
+	return c.writeAuditContent(content, outputFile, emit, audit.Findings)
+}
+
+// writeAuditContent writes rendered audit output, sends the findings to the
+// --emit targets, and fails when the findings include errors
+func (c *CLIHandler) writeAuditContent(content, outputFile string, emit []EmitTarget, findings []Finding) error {
+	if err := c.emitFindings(emit, findings); err != nil {
+		return err
+	}
+
+	// A clean audit produces no GitHub annotations at all
+	if content != "" {
+		if err := c.writeOutput(content, outputFile); err != nil {
+			return err
+		}
+	}
+
+	if errors := countFindings(findings, SeverityError); errors > 0 {
+		return fmt.Errorf("audit found %s", plural(errors, "error"))
+	}
+
+	return nil
+}
+
+// renderCloudAudit formats an audit as text, GitHub annotations, or any
+// standard report format covering the discovered network CIDRs
+func (c *CLIHandler) renderCloudAudit(format string, audit *CloudAudit) (string, error) {
+	switch format {
+	case "", FormatText:
+		return c.formatter.FormatCloudAudit(audit), nil
+	case FormatGitHubAnnotations:
+		return c.formatter.FormatFindingsAsGitHub(audit.Findings), nil
+	}
+
+	if !IsSupportedFormat(format) {
+		return "", fmt.Errorf("unsupported audit format: %s (supported: %s, %s, %s)", format, FormatText, FormatGitHubAnnotations, strings.Join(SupportedFormats[1:], ", "))
+	}
+
+	reports := NewCloudAuditor().Reports(audit.Inventory)
+	if len(reports) == 0 {
+		return "", fmt.Errorf("no networks found to report")
+	}
+	return c.formatter.RenderReports(format, reports)
+}
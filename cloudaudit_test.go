@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func testCloudInventory() *CloudInventory {
+	return &CloudInventory{
+		Provider: "AWS",
+		Region:   "eu-central-1",
+		Networks: []CloudNetwork{
+			{
+				ID:    "vpc-prod",
+				Name:  "prod",
+				CIDRs: []string{"10.0.0.0/22", "2001:db8::/56"},
+				Subnets: []CloudSubnet{
+					{ID: "subnet-a", Name: "app-a", CIDR: "10.0.0.0/24", Zone: "eu-central-1a"},
+					{ID: "subnet-b", CIDR: "10.0.1.0/24"},
+					{ID: "subnet-c", CIDR: "10.0.1.128/25"},
+					{ID: "subnet-x", CIDR: "10.9.0.0/24"},
+				},
+			},
+			{ID: "vpc-lab", CIDRs: []string{"10.0.2.0/24"}},
+		},
+	}
+}
+
+func TestCloudAuditor_Audit(t *testing.T) {
+	audit := NewCloudAuditor().Audit(testCloudInventory())
+
+	expected := []struct {
+		rule     string
+		severity FindingSeverity
+		cidr     string
+	}{
+		{RuleSubnetOverlap, SeverityError, "10.0.1.128/25"},
+		{RuleOutsideNetwork, SeverityError, "10.9.0.0/24"},
+		{RuleNetworkOverlap, SeverityWarning, "10.0.2.0/24"},
+	}
+
+	if len(audit.Findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(audit.Findings), audit.Findings)
+	}
+	for i, exp := range expected {
+		got := audit.Findings[i]
+		if got.Rule != exp.rule || got.Severity != exp.severity || got.CIDR != exp.cidr {
+			t.Errorf("finding %d: expected %s/%s for %s, got %+v", i, exp.severity, exp.rule, exp.cidr, got)
+		}
+	}
+
+	if free := strings.Join(audit.FreeSpace[freeSpaceKey("vpc-prod", "10.0.0.0/22")], " "); free != "10.0.2.0/23" {
+		t.Errorf("unexpected free space for 10.0.0.0/22: %s", free)
+	}
+	if free := strings.Join(audit.FreeSpace[freeSpaceKey("vpc-lab", "10.0.2.0/24")], " "); free != "10.0.2.0/24" {
+		t.Errorf("unexpected free space for 10.0.2.0/24: %s", free)
+	}
+	if _, ok := audit.FreeSpace[freeSpaceKey("vpc-prod", "2001:db8::/56")]; ok {
+		t.Errorf("expected IPv6 blocks to be skipped")
+	}
+}
+
+func TestOutputFormatter_FormatCloudAudit(t *testing.T) {
+	audit := NewCloudAuditor().Audit(testCloudInventory())
+	output := NewOutputFormatter().FormatCloudAudit(audit)
+
+	expected := []string{
+		"AWS Audit (eu-central-1):",
+		"Network vpc-prod (prod): 10.0.0.0/22, 2001:db8::/56",
+		"    10.0.0.0/24        subnet-a (app-a) eu-central-1a",
+		"    10.0.1.0/24        subnet-b\n",
+		"  Free Space in 10.0.0.0/22:\n    10.0.2.0/23\n",
+		"Network vpc-lab: 10.0.2.0/24\n  Subnets:\n    none\n",
+		"Summary: 2 errors, 1 warning, 0 notices",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(output, exp) {
+			t.Errorf("expected output to contain %q, got:\n%s", exp, output)
+		}
+	}
+
+	empty := NewOutputFormatter().FormatCloudAudit(NewCloudAuditor().Audit(&CloudInventory{Provider: "AWS", Region: "us-east-1"}))
+	if !strings.Contains(empty, "No networks found") {
+		t.Errorf("expected empty inventory message, got:\n%s", empty)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Computed field names --cloud adds to every subnet
+const (
+	cloudFieldZone   = "az"
+	cloudFieldUsable = "usable"
+)
+
+// maxAvailabilityZones bounds --azs given as a count
+const maxAvailabilityZones = 32
+
+// cloudProviders are the providers --cloud plans subnets for
+var cloudProviders = map[string]bool{
+	"aws":   true,
+	"azure": true,
+	"gcp":   true,
+}
+
+// cloudFlag parses --cloud into the rules of a provider it can plan for
+type cloudFlag struct {
+	target **ProviderRules
+}
+
+// String returns the provider name
+func (f cloudFlag) String() string {
+	return providerFlag(f).String()
+}
+
+// Set looks up the provider
+func (f cloudFlag) Set(value string) error {
+	if !cloudProviders[strings.ToLower(value)] {
+		names := make([]string, 0, len(cloudProviders))
+		for name := range cloudProviders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unsupported cloud %q (supported: %s)", value, strings.Join(names, ", "))
+	}
+	return providerFlag(f).Set(value)
+}
+
+// zoneList parses --azs: a number of zones, named az1, az2, ..., or the
+// comma separated zone names
+type zoneList []string
+
+// String returns the zone names
+func (z *zoneList) String() string {
+	return strings.Join(*z, ",")
+}
+
+// Set reads the zone count or names
+func (z *zoneList) Set(value string) error {
+	if count, err := strconv.Atoi(value); err == nil {
+		if count < 1 || count > maxAvailabilityZones {
+			return fmt.Errorf("the number of availability zones must be between 1 and %d, got %d", maxAvailabilityZones, count)
+		}
+		zones := make([]string, count)
+		for i := range zones {
+			zones[i] = fmt.Sprintf("az%d", i+1)
+		}
+		*z = zones
+		return nil
+	}
+
+	zones, err := parseNameList(value, "availability zone")
+	if err != nil {
+		return err
+	}
+	*z = zones
+	return nil
+}
+
+// parseNameList splits a comma separated list of zone or region names,
+// rejecting empty, repeated and malformed names
+func parseNameList(value, kind string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsAny(name, " \t#=") {
+			return nil, fmt.Errorf("invalid %s %q", kind, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s %s is listed twice", kind, name)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// CloudUsable returns the addresses of a subnet left for hosts once the
+// provider's reserved addresses are taken out
+func (r *ProviderRules) CloudUsable(ipv6 bool, prefix int) *big.Int {
+	bits := 32
+	if ipv6 {
+		bits = 128
+	}
+	usable := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefix))
+	usable.Sub(usable, big.NewInt(int64(r.Reserved)))
+	if usable.Sign() < 0 {
+		return new(big.Int)
+	}
+	return usable
+}
+
+// SmallestPrefix returns the prefix of the smallest subnet the provider allows
+func (r *ProviderRules) SmallestPrefix(ipv6 bool) int {
+	if ipv6 {
+		return r.IPv6Max
+	}
+	return r.IPv4Max
+}
+
+// cloudHosts returns the hosts --hosts asks prefixForHosts for, so that the
+// requested hosts remain once --cloud takes out the reserved addresses
+func cloudHosts(config *Config, ipv6 bool) int {
+	if config.Cloud == nil {
+		return config.Hosts
+	}
+	if ipv6 {
+		return config.Hosts + config.Cloud.Reserved
+	}
+	return config.Hosts + config.Cloud.Reserved - 2
+}
+
+// validateCloud checks the --cloud flags and makes --cloud imply
+// --validate-for the same provider
+func validateCloud(config *Config) error {
+	if config.Cloud == nil {
+		if len(config.Zones) > 0 {
+			return fmt.Errorf("--azs requires --cloud")
+		}
+		if len(config.Regions) > 0 || config.Pods != 0 || config.Services != 0 {
+			return fmt.Errorf("--regions, --pods and --services require --cloud gcp")
+		}
+		return nil
+	}
+
+	if config.LowMemory {
+		return fmt.Errorf("--cloud cannot be combined with --low-memory")
+	}
+	if err := validateRegions(config); err != nil {
+		return err
+	}
+	for _, field := range config.Compute {
+		if field.Name == cloudFieldZone || field.Name == cloudFieldUsable {
+			return fmt.Errorf("--compute %s clashes with the field --cloud adds", field.Name)
+		}
+	}
+	if config.Provider == nil {
+		config.Provider = config.Cloud
+	} else if config.Provider.Name != config.Cloud.Name {
+		return fmt.Errorf("--cloud %s cannot be combined with --validate-for %s", strings.ToLower(config.Cloud.Name), strings.ToLower(config.Provider.Name))
+	}
+	return nil
+}
+
+// cloudPrefix raises the prefix --hosts picked to the smallest subnet the
+// --cloud provider allows, as fewer hosts cannot get a smaller subnet there
+func cloudPrefix(config *Config, ipv6 bool, prefix int) int {
+	if config.Cloud == nil {
+		return prefix
+	}
+	if smallest := config.Cloud.SmallestPrefix(ipv6); prefix > smallest {
+		return smallest
+	}
+	return prefix
+}
+
+// applyCloud adds the usable hosts left by the --cloud provider and the
+// availability zone, assigned round-robin over --azs, to every listed subnet,
+// and notes the totals of each network. A network whose default subnets are
+// too small for the provider is planned as one subnet. It warns when the subnets are the
+// smallest the provider allows, which --hosts may have asked for fewer hosts than.
+func (c *CLIHandler) applyCloud(reports []NetworkReport, config *Config) error {
+	rules := config.Cloud
+	if rules == nil {
+		return nil
+	}
+
+	for r := range reports {
+		report := &reports[r]
+		prefix, err := c.reportPrefix(*report)
+		if err != nil {
+			return err
+		}
+		ipv6 := report.Info.IsIPv6()
+		subnets := report.Subnets
+		// The next prefix listed by default may be smaller than the provider
+		// allows; the network itself is the subnet to plan then
+		if !config.RequestsSplit() && rules.CheckPrefix(ipv6, prefix) != nil {
+			prefix, subnets = report.Info.PrefixLength, nil
+		}
+		usable := rules.CloudUsable(ipv6, prefix)
+		if smallest := rules.SmallestPrefix(ipv6); prefix == smallest {
+			switch {
+			case config.Hosts > 0 && prefixForHosts(ipv6, cloudHosts(config, ipv6)) > smallest:
+				c.warnf("the smallest %s subnet is a /%d, so the subnets of %s hold %s usable hosts rather than the %d requested",
+					rules.Name, smallest, report.Info.CIDR(), usable, config.Hosts)
+			case !ipv6 && len(subnets) == 0:
+				c.warnf("%s is a /%d, the smallest subnet %s allows, and leaves %s usable hosts, with no room to grow",
+					report.Info.CIDR(), smallest, rules.Name, usable)
+			case !ipv6:
+				c.warnf("the /%d subnets of %s are the smallest %s allows and leave %s usable hosts each, with no room to grow",
+					smallest, report.Info.CIDR(), rules.Name, usable)
+			}
+		}
+
+		perZone := make([]int, len(config.Zones))
+		for i := range subnets {
+			subnet := &subnets[i]
+			if len(config.Zones) > 0 {
+				perZone[i%len(config.Zones)]++
+				subnet.Computed = append(subnet.Computed, ComputedValue{Name: cloudFieldZone, Value: config.Zones[i%len(config.Zones)]})
+			}
+			subnet.Computed = append(subnet.Computed, ComputedValue{Name: cloudFieldUsable, Value: usable.String()})
+		}
+
+		count := len(subnets)
+		if count == 0 {
+			count = 1
+		}
+		total := new(big.Int).Mul(usable, big.NewInt(int64(count)))
+		c.notef("%s reserves %d addresses in every subnet: %s of %s with %s usable hosts each, %s in total",
+			rules.Name, rules.Reserved, plural(count, fmt.Sprintf("/%d subnet", prefix)), report.Info.CIDR(), usable, total)
+		if len(config.Zones) > 0 {
+			spread := make([]string, len(config.Zones))
+			for i, zone := range config.Zones {
+				spread[i] = fmt.Sprintf("%s %d", zone, perZone[i])
+			}
+			c.notef("subnets per availability zone: %s", strings.Join(spread, ", "))
+			if len(subnets)%len(config.Zones) != 0 {
+				c.warnf("%d subnets of %s do not spread evenly over %d availability zones", len(subnets), report.Info.CIDR(), len(config.Zones))
+			}
+		}
+	}
+	return nil
+}
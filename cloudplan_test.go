@@ -0,0 +1,196 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestZoneList(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+		valid    bool
+	}{
+		{"3", "az1,az2,az3", true},
+		{"us-east-1a,us-east-1b", "us-east-1a,us-east-1b", true},
+		{"0", "", false},
+		{"a,a", "", false},
+		{"a,,b", "", false},
+	}
+
+	for _, tt := range tests {
+		var zones zoneList
+		err := zones.Set(tt.value)
+		if (err == nil) != tt.valid || zones.String() != tt.expected {
+			t.Errorf("%s: expected %q (valid %t), got %q, %v", tt.value, tt.expected, tt.valid, zones.String(), err)
+		}
+	}
+}
+
+func TestProviderRules_CloudUsable(t *testing.T) {
+	aws, _ := LookupProviderRules("aws")
+	if usable := aws.CloudUsable(false, 24); usable.String() != "251" {
+		t.Errorf("expected 251 usable hosts in an AWS /24, got %s", usable)
+	}
+	if usable := aws.CloudUsable(true, 64); usable.String() != "18446744073709551611" {
+		t.Errorf("expected 2^64-5 usable addresses in an AWS /64, got %s", usable)
+	}
+	if usable := aws.CloudUsable(false, 31); usable.Sign() != 0 {
+		t.Errorf("expected no usable hosts in a /31, got %s", usable)
+	}
+}
+
+func TestCLIHandler_Cloud(t *testing.T) {
+	var stderr strings.Builder
+	handler := NewCLIHandler()
+	handler.stderr = &stderr
+	output := filepath.Join(t.TempDir(), "plan.txt")
+
+	// 252 hosts no longer fit a /24 once AWS takes 5 addresses
+	if err := handler.Run([]string{"cidr-calc", "--cloud", "aws", "--azs", "us-east-1a,us-east-1b", "--hosts", "252", "-o", output, "10.0.0.0/21"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	for _, expected := range []string{"Possible /23 Subnets: 4", "10.0.0.0/23        (10.0.0.0 - 10.0.1.255)  az=us-east-1a  usable=507", "10.0.2.0/23        (10.0.2.0 - 10.0.3.255)  az=us-east-1b  usable=507"} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected %q in:\n%s", expected, content)
+		}
+	}
+	for _, expected := range []string{"4 /23 subnets of 10.0.0.0/21 with 507 usable hosts each, 2028 in total", "us-east-1a 2, us-east-1b 2"} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("expected %q in %q", expected, stderr.String())
+		}
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "--cloud", "aws", "--azs", "3", "--split", "20", "--format", "csv", "-o", output, "10.0.0.0/18"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(output)
+	if !strings.HasPrefix(string(content), "Network,CIDR,Network ID,Broadcast,First Usable,Last Usable,Hosts,Classification,az,usable\n") || !strings.Contains(string(content), "10.0.48.0/20,10.0.48.0,10.0.63.255,10.0.48.1,10.0.63.254,4094,Private-Use (RFC 1918),az1,4091") {
+		t.Errorf("unexpected CSV:\n%s", content)
+	}
+
+	// VLSM allocations make room for the reserved addresses too
+	if err := handler.Run([]string{"cidr-calc", "--cloud", "aws", "--vlsm", "12,100", "-o", output, "10.0.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(output)
+	if !strings.Contains(string(content), "12       10.0.0.128/27      10.0.0.129 - 10.0.0.158           27") {
+		t.Errorf("expected a /27 for 12 hosts, got:\n%s", content)
+	}
+
+	// Only the subnets asked for are checked, so the smallest networks pass
+	for _, args := range [][]string{
+		{"--cloud", "aws", "10.0.0.0/28"},
+		{"--cloud", "azure", "10.0.0.0/29"},
+		{"--cloud", "gcp", "10.0.0.0/29"},
+	} {
+		if err := handler.Run(append([]string{"cidr-calc", "-o", output}, args...)); err != nil {
+			t.Errorf("%v: unexpected error: %v", args, err)
+		}
+	}
+	err := handler.Run([]string{"cidr-calc", "--cloud", "aws", "--split", "29", "-o", output, "10.0.0.0/24"})
+	if err == nil || err.Error() != "--cloud aws: subnets of 10.0.0.0/24: a /29 subnet is smaller than the /28 minimum AWS allows" {
+		t.Errorf("expected the error to name --cloud, got %v", err)
+	}
+
+	handler.stderr = io.Discard
+	for _, args := range [][]string{
+		{"--cloud", "ibm", "10.0.0.0/16"},
+		{"--azs", "3", "10.0.0.0/16"},
+		{"--cloud", "aws", "--low-memory", "10.0.0.0/16"},
+		{"--cloud", "aws", "--split", "29", "10.0.0.0/24"},
+		{"--cloud", "aws", "--validate-for", "gcp", "10.0.0.0/16"},
+		{"--cloud", "aws", "--compute", "usable = hosts", "10.0.0.0/16"},
+	} {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestCLIHandler_CloudAzure(t *testing.T) {
+	var stderr strings.Builder
+	handler := NewCLIHandler()
+	handler.stderr = &stderr
+	dir := t.TempDir()
+
+	// 28 hosts need a /26 once Azure takes its 5 addresses
+	output := filepath.Join(dir, "vnet.md")
+	if err := handler.Run([]string{"cidr-calc", "--cloud", "azure", "--azs", "2", "--hosts", "28", "-o", output, "10.1.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	for _, expected := range []string{"| Subnet        | Network ID | Broadcast  | az  | usable |", "| 10.1.0.64/26  | 10.1.0.64  | 10.1.0.127 | az2 | 59     |"} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected %q in:\n%s", expected, content)
+		}
+	}
+	if !strings.Contains(stderr.String(), "Azure reserves 5 addresses in every subnet: 4 /26 subnets of 10.1.0.0/24 with 59 usable hosts each, 236 in total") {
+		t.Errorf("unexpected notes: %q", stderr.String())
+	}
+
+	// /29 subnets are the smallest Azure allows
+	stderr.Reset()
+	output = filepath.Join(dir, "vnet.xml")
+	if err := handler.Run([]string{"cidr-calc", "--cloud", "azure", "--hosts", "2", "-o", output, "10.1.0.0/28"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(output)
+	if !strings.Contains(string(content), `<subnet cidr="10.1.0.8/29" networkId="10.1.0.8" broadcast="10.1.0.15">`+"\n"+`        <field name="usable" value="3"></field>`) {
+		t.Errorf("unexpected XML:\n%s", content)
+	}
+	if !strings.Contains(stderr.String(), "Warning: the /29 subnets of 10.1.0.0/28 are the smallest Azure allows and leave 3 usable hosts each") {
+		t.Errorf("expected a warning about /29 subnets, got %q", stderr.String())
+	}
+
+	// A /29 network is planned as one subnet, with a warning
+	stderr.Reset()
+	output = filepath.Join(dir, "vnet.txt")
+	if err := handler.Run([]string{"cidr-calc", "--cloud", "azure", "-o", output, "10.1.0.0/29"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(output)
+	if !strings.Contains(string(content), "CIDR:           10.1.0.0/29") || strings.Contains(string(content), "usable=") {
+		t.Errorf("expected the report without usable fields, got:\n%s", content)
+	}
+	for _, expected := range []string{
+		"Warning: 10.1.0.0/29 is a /29, the smallest subnet Azure allows, and leaves 3 usable hosts, with no room to grow",
+		"1 /29 subnet of 10.1.0.0/29 with 3 usable hosts each",
+	} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("expected %q in %q", expected, stderr.String())
+		}
+	}
+
+	stderr.Reset()
+	output = filepath.Join(dir, "plan.txt")
+	if err := handler.Run([]string{"cidr-calc", "--cloud", "azure", "--vlsm", "120,2", "-o", output, "10.1.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(output)
+	if !strings.Contains(string(content), "2        10.1.0.128/29      10.1.0.129 - 10.1.0.134           3") {
+		t.Errorf("expected a /29 for 2 hosts, got:\n%s", content)
+	}
+	if !strings.Contains(stderr.String(), "10.1.0.128/29 is a /29, the smallest subnet Azure allows, and leaves 3 usable hosts for the 2 hosts required") {
+		t.Errorf("expected a warning about the /29, got %q", stderr.String())
+	}
+
+	// Smaller requests than the smallest subnet holds get that subnet
+	stderr.Reset()
+	output = filepath.Join(dir, "aws.txt")
+	if err := handler.Run([]string{"cidr-calc", "--cloud", "aws", "--hosts", "3", "-o", output, "10.0.0.0/26"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(output)
+	if !strings.Contains(string(content), "Possible /28 Subnets: 4") || !strings.Contains(stderr.String(), "the smallest AWS subnet is a /28, so the subnets of 10.0.0.0/26 hold 11 usable hosts rather than the 3 requested") {
+		t.Errorf("expected /28 subnets with a warning, got %q and:\n%s", stderr.String(), content)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "--cloud", "azure", "--split", "30", "10.1.0.0/24"}); err == nil {
+		t.Error("expected /30 subnets to be rejected for Azure")
+	}
+}
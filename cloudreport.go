@@ -0,0 +1,214 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CloudInventorySource discovers the networks of one cloud account or region
+type CloudInventorySource interface {
+	Inventory() (*CloudInventory, error)
+}
+
+// stringList collects the values of a repeatable string flag
+type stringList []string
+
+// String returns the collected values
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+// Set appends a value
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// MergeInventories combines inventories from several providers and accounts into one.
+// Network IDs are qualified with their source so findings stay unambiguous.
+func MergeInventories(inventories []*CloudInventory) *CloudInventory {
+	merged := &CloudInventory{Provider: "Cloud"}
+
+	var sources []string
+	for _, inventory := range inventories {
+		source := cloudSourceLabel(inventory)
+		sources = append(sources, source)
+
+		for _, network := range inventory.Networks {
+			network.ID = source + "/" + network.ID
+			merged.Networks = append(merged.Networks, network)
+		}
+	}
+	merged.Region = strings.Join(sources, ", ")
+
+	return merged
+}
+
+// cloudSourceLabel returns a short provider:scope label such as aws:eu-central-1
+func cloudSourceLabel(inventory *CloudInventory) string {
+	return strings.ToLower(inventory.Provider) + ":" + inventory.Region
+}
+
+// cloudAddressBlock is one row of the consolidated address space table
+type cloudAddressBlock struct {
+	info    *NetworkInfo
+	network string
+	subnets int
+	used    string
+}
+
+// addressBlocks lists every IPv4 block in the audit sorted by address. Networks
+// with their own address space contribute their CIDRs with utilisation; networks
+// without (GCP VPCs) contribute their subnets.
+func (f *OutputFormatter) addressBlocks(audit *CloudAudit) []cloudAddressBlock {
+	calculator := NewCIDRCalculator()
+	var blocks []cloudAddressBlock
+
+	for _, network := range audit.Inventory.Networks {
+		label := cloudNetworkLabel(network.ID, network.Name)
+
+		if len(network.CIDRs) == 0 {
+			for _, subnet := range network.Subnets {
+				info, err := calculator.ParseCIDR(subnet.CIDR)
+				if err != nil || info.IsIPv6() {
+					continue
+				}
+				blocks = append(blocks, cloudAddressBlock{info: info, network: label + " " + subnet.ID, used: "-"})
+			}
+			continue
+		}
+
+		for _, cidr := range network.CIDRs {
+			info, err := calculator.ParseCIDR(cidr)
+			if err != nil || info.IsIPv6() {
+				continue
+			}
+
+			count := 0
+			for _, subnet := range network.Subnets {
+				if subnetInfo, err := calculator.ParseCIDR(subnet.CIDR); err == nil && info.Contains(subnetInfo) {
+					count++
+				}
+			}
+
+			blocks = append(blocks, cloudAddressBlock{
+				info:    info,
+				network: label,
+				subnets: count,
+				used:    usedPercentage(info, audit.FreeSpace[freeSpaceKey(network.ID, info.CIDR())], calculator),
+			})
+		}
+	}
+
+	sort.SliceStable(blocks, func(i, j int) bool {
+		a, b := ipv4ToUint32(blocks[i].info.NetworkID), ipv4ToUint32(blocks[j].info.NetworkID)
+		if a != b {
+			return a < b
+		}
+		return blocks[i].info.PrefixLength < blocks[j].info.PrefixLength
+	})
+
+	return blocks
+}
+
+// usedPercentage returns the share of a network's addresses allocated to subnets
+func usedPercentage(info *NetworkInfo, free []string, calculator *CIDRCalculator) string {
+	first, last := ipRange(info)
+	total := last - first + 1
+
+	var freeAddresses uint64
+	for _, cidr := range free {
+		if block, err := calculator.ParseCIDR(cidr); err == nil {
+			start, end := ipRange(block)
+			freeAddresses += end - start + 1
+		}
+	}
+
+	return fmt.Sprintf("%.1f%%", float64(total-freeAddresses)*100/float64(total))
+}
+
+// FormatCloudReport renders the consolidated address space of a merged audit as
+// one table sorted by address, followed by the findings
+func (f *OutputFormatter) FormatCloudReport(audit *CloudAudit) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Cloud Address Space (%s):\n\n", audit.Inventory.Region))
+
+	blocks := f.addressBlocks(audit)
+	if len(blocks) == 0 {
+		output.WriteString("  No networks found\n")
+	} else {
+		output.WriteString(fmt.Sprintf("  %-18s %-7s %-8s %s\n", "CIDR", "Used", "Subnets", "Network"))
+		for _, block := range blocks {
+			subnets := "-"
+			if block.used != "-" {
+				subnets = fmt.Sprintf("%d", block.subnets)
+			}
+			output.WriteString(fmt.Sprintf("  %-18s %-7s %-8s %s\n", block.info.CIDR(), block.used, subnets, block.network))
+		}
+	}
+
+	output.WriteString("\n")
+	output.WriteString(f.FormatFindings(audit.Findings))
+
+	return output.String()
+}
+
+// runCloudReport implements the cloud-report subcommand
+func (c *CLIHandler) runCloudReport(args []string) error {
+	flagSet := flag.NewFlagSet("cloud-report", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var awsSources, azureSources, gcpSources, emit stringList
+	var format, outputFile string
+	flagSet.Var(&awsSources, "aws", "AWS region to include, as REGION or REGION:PROFILE (repeatable)")
+	flagSet.Var(&azureSources, "azure", "Azure subscription to include (repeatable)")
+	flagSet.Var(&gcpSources, "gcp", "GCP project to include (repeatable)")
+	flagSet.StringVar(&format, "format", FormatText, "Report format: text, gh-annotations or any output format")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	flagSet.Var(&emit, "emit", emitFlagUsage)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	emitTargets, err := parseEmitTargets(emit)
+	if err != nil {
+		return err
+	}
+
+	var sources []CloudInventorySource
+	for _, source := range awsSources {
+		region, profile, _ := strings.Cut(source, ":")
+		sources = append(sources, NewAWSInventorySource(region, profile, c.run))
+	}
+	for _, subscription := range azureSources {
+		sources = append(sources, NewAzureInventorySource(subscription, "", "", c.run))
+	}
+	for _, project := range gcpSources {
+		sources = append(sources, NewGCPInventorySource(project, "", c.run))
+	}
+
+	if len(sources) == 0 {
+		return fmt.Errorf("cloud-report requires at least one --aws, --azure or --gcp source")
+	}
+
+	var inventories []*CloudInventory
+	for _, source := range sources {
+		inventory, err := source.Inventory()
+		if err != nil {
+			return err
+		}
+		inventories = append(inventories, inventory)
+	}
+
+	audit := NewCloudAuditor().Audit(MergeInventories(inventories))
+
+	if format == "" || format == FormatText {
+		return c.writeAuditContent(c.formatter.FormatCloudReport(audit), outputFile, emitTargets, audit.Findings)
+	}
+	return c.writeCloudAudit(format, outputFile, emitTargets, audit)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeInventories(t *testing.T) {
+	merged := MergeInventories([]*CloudInventory{
+		{Provider: "AWS", Region: "eu-central-1", Networks: []CloudNetwork{{ID: "vpc-1", CIDRs: []string{"10.0.0.0/16"}}}},
+		{Provider: "Azure", Region: "prod", Networks: []CloudNetwork{{ID: "hub", CIDRs: []string{"10.0.0.0/16"}}}},
+	})
+
+	if merged.Region != "aws:eu-central-1, azure:prod" {
+		t.Errorf("unexpected merged scope: %s", merged.Region)
+	}
+	if len(merged.Networks) != 2 || merged.Networks[0].ID != "aws:eu-central-1/vpc-1" || merged.Networks[1].ID != "azure:prod/hub" {
+		t.Errorf("unexpected merged networks: %+v", merged.Networks)
+	}
+
+	audit := NewCloudAuditor().Audit(merged)
+	if len(audit.Findings) != 1 || audit.Findings[0].Rule != RuleNetworkOverlap {
+		t.Errorf("expected a cross-cloud overlap, got %+v", audit.Findings)
+	}
+}
+
+func TestOutputFormatter_FormatCloudReport(t *testing.T) {
+	merged := MergeInventories([]*CloudInventory{
+		{Provider: "AWS", Region: "prod/eu-central-1", Networks: []CloudNetwork{{
+			ID: "vpc-1", Name: "prod", CIDRs: []string{"10.0.0.0/22"},
+			Subnets: []CloudSubnet{{ID: "subnet-1", CIDR: "10.0.0.0/24"}},
+		}}},
+		{Provider: "AWS", Region: "staging/eu-central-1", Networks: []CloudNetwork{{
+			ID: "vpc-2", CIDRs: []string{"10.0.0.0/22"},
+			Subnets: []CloudSubnet{{ID: "subnet-2", CIDR: "10.0.0.0/23"}},
+		}}},
+		{Provider: "GCP", Region: "p/all regions", Networks: []CloudNetwork{{
+			ID:      "default",
+			Subnets: []CloudSubnet{{ID: "web", CIDR: "172.16.0.0/20"}, {ID: "db", CIDR: "10.200.0.0/24"}},
+		}}},
+	})
+
+	output := NewOutputFormatter().FormatCloudReport(NewCloudAuditor().Audit(merged))
+
+	expected := []string{
+		"Cloud Address Space (aws:prod/eu-central-1, aws:staging/eu-central-1, gcp:p/all regions):",
+		"  CIDR               Used    Subnets  Network\n" +
+			"  10.0.0.0/22        25.0%   1        aws:prod/eu-central-1/vpc-1 (prod)\n" +
+			"  10.0.0.0/22        50.0%   1        aws:staging/eu-central-1/vpc-2\n" +
+			"  10.200.0.0/24      -       -        gcp:p/all regions/default db\n" +
+			"  172.16.0.0/20      -       -        gcp:p/all regions/default web\n",
+		"Summary: 0 errors, 1 warning, 0 notices",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(output, exp) {
+			t.Errorf("expected output to contain %q, got:\n%s", exp, output)
+		}
+	}
+}
+
+func TestCLIHandler_CloudReport(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.run = func(name string, args ...string) ([]byte, error) {
+		command := strings.Join(append([]string{name}, args...), " ")
+		switch {
+		case strings.HasPrefix(command, "aws ec2 describe-vpcs --region eu-central-1 --output json --profile prod"):
+			return []byte(testAWSVPCs), nil
+		case strings.HasPrefix(command, "aws ec2 describe-subnets --region eu-central-1 --output json --profile prod"):
+			return []byte(testAWSSubnets), nil
+		case strings.HasPrefix(command, "az network vnet list --output json --subscription sub"):
+			return []byte(testAzureVNets), nil
+		case strings.HasPrefix(command, "gcloud compute networks subnets list --project p"):
+			return []byte(testGCPSubnets), nil
+		}
+		return nil, fmt.Errorf("unexpected command: %s", command)
+	}
+
+	output := filepath.Join(t.TempDir(), "report.txt")
+	args := []string{"cidr-calc", "cloud-report", "--aws", "eu-central-1:prod", "--azure", "sub", "--gcp", "p", "-o", output}
+	if err := handler.Run(args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	for _, exp := range []string{"aws:prod/eu-central-1/vpc-1 (prod)", "azure:sub/hub", "gcp:p/all regions/prod web"} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected report to contain %q, got:\n%s", exp, content)
+		}
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "cloud-report"}); err == nil || !strings.Contains(err.Error(), "at least one") {
+		t.Errorf("expected missing source error, got %v", err)
+	}
+	if err := handler.Run([]string{"cidr-calc", "cloud-report", "--aws", "us-east-1"}); err == nil {
+		t.Errorf("expected error when a source fails")
+	}
+}
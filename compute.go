@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// subnetVariables are the per-subnet values --compute and --filter expressions can use
+var subnetVariables = []string{"index", "cidr", "network", "broadcast", "first", "last", "prefix", "hosts", "size", "parent", "ipv6"}
+
+// ComputedField is a --compute column: a name and the expression that fills it
+type ComputedField struct {
+	Name string
+	Expr *Expression
+}
+
+// computeList collects repeated --compute "name = expression" flags
+type computeList []ComputedField
+
+// String returns the fields as given
+func (l *computeList) String() string {
+	fields := make([]string, len(*l))
+	for i, field := range *l {
+		fields[i] = field.Name + " = " + field.Expr.String()
+	}
+	return strings.Join(fields, "; ")
+}
+
+// Set parses "name = expression". The expression may use the subnet
+// variables and any field computed by an earlier --compute.
+func (l *computeList) Set(value string) error {
+	name, source, ok := strings.Cut(value, "=")
+	name = strings.TrimSpace(name)
+	if !ok || !isIdentifier(name) {
+		return fmt.Errorf("invalid --compute %q (expected name = expression)", value)
+	}
+
+	variables := append([]string(nil), subnetVariables...)
+	for _, field := range *l {
+		variables = append(variables, field.Name)
+	}
+	for _, existing := range variables {
+		if name == existing {
+			return fmt.Errorf("--compute field %s is already defined", name)
+		}
+	}
+
+	expr, err := ParseExpression(strings.TrimSpace(source), variables)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, ComputedField{Name: name, Expr: expr})
+	return nil
+}
+
+// expressionFlag parses a --filter expression over the subnet variables
+type expressionFlag struct {
+	target **Expression
+}
+
+// String returns the expression as given
+func (f expressionFlag) String() string {
+	if f.target == nil || *f.target == nil {
+		return ""
+	}
+	return (*f.target).String()
+}
+
+// Set compiles the expression
+func (f expressionFlag) Set(value string) error {
+	expr, err := ParseExpression(value, subnetVariables)
+	if err != nil {
+		return err
+	}
+	*f.target = expr
+	return nil
+}
+
+// isIdentifier reports whether name can be used as a variable name
+func isIdentifier(name string) bool {
+	if name == "" || name == "true" || name == "false" {
+		return false
+	}
+	for i, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// subnetValues returns the expression variables describing one subnet of parent
+func (c *CIDRCalculator) subnetValues(parent *NetworkInfo, index int, subnet SubnetInfo) (map[string]interface{}, error) {
+	info, err := c.ParseCIDR(subnet.CIDR)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse subnet %s: %v", subnet.CIDR, err)
+	}
+
+	hostBits := info.MaxPrefix() - info.PrefixLength
+	size := math.Ldexp(1, hostBits)
+	hosts := size
+	if !info.IsIPv6() && hostBits >= 2 {
+		hosts -= 2
+	}
+
+	return map[string]interface{}{
+		"index":     float64(index),
+		"cidr":      info.CIDR(),
+		"network":   info.NetworkID.String(),
+		"broadcast": info.BroadcastAddr.String(),
+		"first":     info.FirstUsableIP.String(),
+		"last":      info.LastUsableIP.String(),
+		"prefix":    float64(info.PrefixLength),
+		"hosts":     hosts,
+		"size":      size,
+		"parent":    parent.CIDR(),
+		"ipv6":      info.IsIPv6(),
+	}, nil
+}
+
+// applyScripts drops the subnets rejected by --filter and fills the --compute
+// fields of the rest. index counts subnets before filtering.
+func (c *CLIHandler) applyScripts(reports []NetworkReport, config *Config) error {
+	if config.Filter == nil && len(config.Compute) == 0 {
+		return nil
+	}
+
+	for r := range reports {
+		report := &reports[r]
+		kept := make([]SubnetInfo, 0, len(report.Subnets))
+
+		for i, subnet := range report.Subnets {
+			vars, err := c.calculator.subnetValues(report.Info, i, subnet)
+			if err != nil {
+				return err
+			}
+
+			if config.Filter != nil {
+				match, err := config.Filter.EvalBool(vars)
+				if err != nil {
+					return fmt.Errorf("--filter on %s: %v", subnet.CIDR, err)
+				}
+				if !match {
+					continue
+				}
+			}
+
+			subnet.Computed = nil
+			for _, field := range config.Compute {
+				value, err := field.Expr.Eval(vars)
+				if err != nil {
+					return fmt.Errorf("--compute %s on %s: %v", field.Name, subnet.CIDR, err)
+				}
+				vars[field.Name] = value
+				subnet.Computed = append(subnet.Computed, ComputedValue{Name: field.Name, Value: formatExprValue(value)})
+			}
+			kept = append(kept, subnet)
+		}
+
+		if config.Filter != nil {
+			if len(report.Subnets) > 0 && len(kept) == 0 {
+				return fmt.Errorf("--filter %q matched no subnets of %s", config.Filter, report.Info.CIDR())
+			}
+			c.notef("--filter kept %d of %d subnets of %s", len(kept), len(report.Subnets), report.Info.CIDR())
+		}
+		report.Subnets = kept
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComputeList_Set(t *testing.T) {
+	var fields computeList
+	for _, value := range []string{"vlan = 100 + index", "name='vlan' + vlan", "big = hosts == 62"} {
+		if err := fields.Set(value); err != nil {
+			t.Fatalf("Set(%q) error = %v", value, err)
+		}
+	}
+	if got := fields.String(); got != "vlan = 100 + index; name = 'vlan' + vlan; big = hosts == 62" {
+		t.Errorf("unexpected fields: %s", got)
+	}
+
+	for _, value := range []string{"100 + index", "vlan = 1", "hosts = 1", "2x = 1", "x = unknown", "x = "} {
+		if err := fields.Set(value); err == nil {
+			t.Errorf("Set(%q) expected error", value)
+		}
+	}
+}
+
+func TestCLIHandler_ComputeAndFilter(t *testing.T) {
+	dir := t.TempDir()
+	handler := NewCLIHandler()
+	var stderr strings.Builder
+	handler.stderr = &stderr
+
+	output := filepath.Join(dir, "plan.txt")
+	args := []string{"cidr-calc", "--split", "26", "--compute", "vlan = 100 + index", "--filter", "index % 2 == 1", "-o", output, "10.0.0.0/24"}
+	if err := handler.Run(args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	expected := "    10.0.0.64/26       (10.0.0.64 - 10.0.0.127)  vlan=101\n" +
+		"    10.0.0.192/26      (10.0.0.192 - 10.0.0.255)  vlan=103\n"
+	if !strings.HasSuffix(string(content), expected) {
+		t.Errorf("expected the odd subnets with their VLANs, got:\n%s", content)
+	}
+	if !strings.Contains(stderr.String(), "Note: --filter kept 2 of 4 subnets of 10.0.0.0/24") {
+		t.Errorf("expected a filter note, got %q", stderr.String())
+	}
+
+	csvOutput := filepath.Join(dir, "plan.csv")
+	args = []string{"cidr-calc", "--split", "64", "--compute", "zone = 'az' + (index + 1)", "-o", csvOutput, "2001:db8::/63"}
+	if err := handler.Run(args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err = os.ReadFile(csvOutput)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], ",Hosts,Classification,zone") || !strings.HasSuffix(lines[2], ",az2") {
+		t.Errorf("expected a zone column, got:\n%s", content)
+	}
+
+	errorCases := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"cidr-calc", "--filter", "hosts > 1000", "10.0.0.0/24"}, wantErr: "matched no subnets of 10.0.0.0/24"},
+		{args: []string{"cidr-calc", "--filter", "index + 1", "10.0.0.0/24"}, wantErr: "expected true or false"},
+		{args: []string{"cidr-calc", "--compute", "x = index / 0", "10.0.0.0/24"}, wantErr: "--compute x on 10.0.0.0/25"},
+		{args: []string{"cidr-calc", "--format", "md", "--compute", "x = 1", "10.0.0.0/24"}, wantErr: "--compute supports text and csv output, not md"},
+		{args: []string{"cidr-calc", "--low-memory", "--filter", "true", "10.0.0.0/24"}, wantErr: "cannot be combined with --low-memory"},
+	}
+	for _, tt := range errorCases {
+		if err := handler.Run(tt.args); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%v: expected error containing %q, got %v", tt.args, tt.wantErr, err)
+		}
+	}
+}
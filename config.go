@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// configDirEnv overrides the directory of the user's configuration files
+const configDirEnv = "CIDR_CALC_CONFIG_DIR"
+
+// defaultConfigDir returns the directory of the user's configuration files:
+// $CIDR_CALC_CONFIG_DIR, or cidr-calc in the user configuration directory
+// ($XDG_CONFIG_HOME or ~/.config on Linux). It is empty when neither is known.
+func defaultConfigDir() string {
+	if dir := os.Getenv(configDirEnv); dir != "" {
+		return dir
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "cidr-calc")
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Relations between two CIDR blocks. Aligned blocks are either nested or
+// disjoint, so two CIDRs never overlap only partially.
+const (
+	RelationEqual    = "equal"
+	RelationContains = "contains"
+	RelationWithin   = "within"
+	RelationDisjoint = "disjoint"
+)
+
+// Exit statuses of the contains and overlaps subcommands, following grep:
+// 0 when the check holds, 1 when it does not and 2 on usage or parse errors
+const (
+	exitCheckFails  = 1
+	exitCheckErrors = 2
+)
+
+// ExitError ends the process with a specific exit status. A nil Err exits
+// without printing an error message.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// Error returns the wrapped error message
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Relation reports how network a relates to network b
+func Relation(a, b *NetworkInfo) string {
+	switch {
+	case a.IsIPv6() != b.IsIPv6():
+		return RelationDisjoint
+	case a.Contains(b) && b.Contains(a):
+		return RelationEqual
+	case a.Contains(b):
+		return RelationContains
+	case b.Contains(a):
+		return RelationWithin
+	default:
+		return RelationDisjoint
+	}
+}
+
+// runContains implements the contains subcommand
+func (c *CLIHandler) runContains(args []string) error {
+	return c.runRelationCheck("contains", args, func(relation string) bool {
+		return relation == RelationEqual || relation == RelationContains
+	})
+}
+
+// runOverlaps implements the overlaps subcommand
+func (c *CLIHandler) runOverlaps(args []string) error {
+	return c.runRelationCheck("overlaps", args, func(relation string) bool {
+		return relation != RelationDisjoint
+	})
+}
+
+// runRelationCheck prints the relation of two CIDRs and exits non-zero
+// when holds rejects it
+func (c *CLIHandler) runRelationCheck(name string, args []string, holds func(relation string) bool) error {
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var quiet bool
+	var outputFile string
+	flagSet.BoolVar(&quiet, "q", false, "Print nothing, only set the exit status")
+	flagSet.BoolVar(&quiet, "quiet", false, "Print nothing, only set the exit status")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept the CIDRs anywhere among the flags
+	cidrs, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return &ExitError{Code: exitCheckErrors, Err: fmt.Errorf("flag parsing error: %v", err)}
+	}
+
+	if len(cidrs) != 2 {
+		return &ExitError{Code: exitCheckErrors, Err: fmt.Errorf("%s requires two CIDRs, got %d", name, len(cidrs))}
+	}
+
+	// A single address stands for its host CIDR
+	networks := make([]*NetworkInfo, len(cidrs))
+	for i, cidr := range cidrs {
+		normalized, err := c.calculator.NormalizeCIDR(cidr)
+		if err != nil {
+			return &ExitError{Code: exitCheckErrors, Err: fmt.Errorf("failed to parse CIDR %s: %v", cidr, err)}
+		}
+		info, err := c.calculator.ParseCIDR(normalized)
+		if err != nil {
+			return &ExitError{Code: exitCheckErrors, Err: fmt.Errorf("failed to parse CIDR %s: %v", cidr, err)}
+		}
+		networks[i] = info
+	}
+
+	relation := Relation(networks[0], networks[1])
+	if !quiet {
+		content := fmt.Sprintf("%s %s %s\n", networks[0].CIDR(), relation, networks[1].CIDR())
+		if err := c.writeOutput(content, outputFile); err != nil {
+			return &ExitError{Code: exitCheckErrors, Err: err}
+		}
+	}
+
+	if !holds(relation) {
+		return &ExitError{Code: exitCheckFails}
+	}
+	return nil
+}
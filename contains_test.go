@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRelation(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"10.0.0.0/8", "10.1.0.0/16", RelationContains},
+		{"10.1.0.0/16", "10.0.0.0/8", RelationWithin},
+		{"10.0.0.5/24", "10.0.0.0/24", RelationEqual},
+		{"10.0.0.0/24", "10.0.1.0/24", RelationDisjoint},
+		{"2001:db8::/32", "2001:db8:1::/48", RelationContains},
+		{"0.0.0.0/0", "::/0", RelationDisjoint},
+	}
+
+	for _, tt := range tests {
+		a, err := calculator.ParseCIDR(tt.a)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", tt.a, err)
+		}
+		b, err := calculator.ParseCIDR(tt.b)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", tt.b, err)
+		}
+		if got := Relation(a, b); got != tt.want {
+			t.Errorf("Relation(%s, %s) = %s, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCLIHandler_RelationChecks(t *testing.T) {
+	dir := t.TempDir()
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantOut  string
+	}{
+		{name: "contains holds", args: []string{"contains", "10.0.0.0/8", "10.1.0.0/16"}, wantCode: 0, wantOut: "10.0.0.0/8 contains 10.1.0.0/16\n"},
+		{name: "contains equal", args: []string{"contains", "10.0.0.0/24", "10.0.0.0/24"}, wantCode: 0, wantOut: "10.0.0.0/24 equal 10.0.0.0/24\n"},
+		{name: "contains fails", args: []string{"contains", "10.1.0.0/16", "10.0.0.0/8"}, wantCode: 1, wantOut: "10.1.0.0/16 within 10.0.0.0/8\n"},
+		{name: "overlaps nested", args: []string{"overlaps", "10.1.0.0/16", "10.0.0.0/8"}, wantCode: 0, wantOut: "10.1.0.0/16 within 10.0.0.0/8\n"},
+		{name: "overlaps disjoint", args: []string{"overlaps", "10.0.0.0/24", "192.168.0.0/24"}, wantCode: 1, wantOut: "10.0.0.0/24 disjoint 192.168.0.0/24\n"},
+		{name: "quiet", args: []string{"overlaps", "-q", "10.0.0.0/24", "10.0.0.0/25"}, wantCode: 0},
+		{name: "one CIDR", args: []string{"overlaps", "10.0.0.0/24"}, wantCode: 2},
+		{name: "invalid CIDR", args: []string{"contains", "10.0.0.0/24", "10.0.0.0/33"}, wantCode: 2},
+		{name: "unknown flag", args: []string{"contains", "--bogus", "10.0.0.0/24", "10.0.0.0/25"}, wantCode: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(dir, tt.name+".txt")
+			args := append([]string{"cidr-calc", tt.args[0], "-o", output}, tt.args[1:]...)
+
+			err := handler.Run(args)
+			code := 0
+			if err != nil {
+				var exitErr *ExitError
+				if !errors.As(err, &exitErr) {
+					t.Fatalf("expected an ExitError, got %v", err)
+				}
+				code = exitErr.Code
+			}
+			if code != tt.wantCode {
+				t.Errorf("expected exit status %d, got %d (%v)", tt.wantCode, code, err)
+			}
+
+			content, _ := os.ReadFile(output)
+			if string(content) != tt.wantOut {
+				t.Errorf("expected output %q, got %q", tt.wantOut, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// crashExitCode is the exit status of a run that panicked, EX_SOFTWARE
+	// of sysexits.h
+	crashExitCode = 70
+
+	// maxCrashInputBytes caps how much of each input file a diagnostic
+	// bundle holds
+	maxCrashInputBytes = 64 << 10
+
+	// issuesURL is where crash reports are filed
+	issuesURL = "https://github.com/marc-poljak/simple-cidr-calculator/issues"
+)
+
+// secretFlags are the flags whose values a diagnostic bundle leaves out: HTTP
+// headers and SNMP communities are redacted, TLS files are not attached
+var secretFlags = map[string]bool{
+	"header":    true,
+	"community": true,
+	"tls-cert":  true,
+	"tls-key":   true,
+}
+
+// CrashReport is the diagnostic bundle of a run that panicked: what was asked,
+// what broke and on which build, so the report can be reproduced
+type CrashReport struct {
+	Time     time.Time
+	Version  string
+	Go       string
+	Platform string
+	Args     []string // with secret values redacted
+	Panic    string
+	Stack    string
+	Inputs   []CrashInput
+}
+
+// CrashInput is a local file named on the command line
+type CrashInput struct {
+	Name      string
+	Content   string
+	Truncated bool // only the first maxCrashInputBytes are kept
+}
+
+// NewCrashReport collects the diagnostic bundle of a panic
+func NewCrashReport(args []string, recovered interface{}, stack []byte) *CrashReport {
+	return &CrashReport{
+		Time:     time.Now().UTC(),
+		Version:  buildVersion(),
+		Go:       runtime.Version(),
+		Platform: runtime.GOOS + "/" + runtime.GOARCH,
+		Args:     redactArgs(args),
+		Panic:    fmt.Sprint(recovered),
+		Stack:    string(stack),
+		Inputs:   crashInputs(args),
+	}
+}
+
+// secretFlag returns the flag name of an argument such as --header or
+// -header=X when it holds a secret, and whether its value is attached
+func secretFlag(arg string) (string, bool, bool) {
+	if !strings.HasPrefix(arg, "-") {
+		return "", false, false
+	}
+	name, _, attached := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	return name, attached, secretFlags[name]
+}
+
+// redactArgs replaces the values of secret flags
+func redactArgs(args []string) []string {
+	redacted := append([]string(nil), args...)
+	for i, arg := range redacted {
+		name, attached, secret := secretFlag(arg)
+		switch {
+		case !secret:
+		case attached:
+			redacted[i] = arg[:strings.Index(arg, name)] + name + "=[redacted]"
+		case i+1 < len(redacted):
+			redacted[i+1] = "[redacted]"
+		}
+	}
+	return redacted
+}
+
+// crashInputs reads the regular files named by the arguments, other than the
+// values of secret flags
+func crashInputs(args []string) []CrashInput {
+	var inputs []CrashInput
+	seen := make(map[string]bool)
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if _, attached, secret := secretFlag(arg); secret {
+			if !attached {
+				i++
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-") {
+			_, arg, _ = strings.Cut(arg, "=")
+		}
+		info, err := os.Stat(arg)
+		if arg == "" || seen[arg] || err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		seen[arg] = true
+
+		file, err := os.Open(arg)
+		if err != nil {
+			continue
+		}
+		content, err := io.ReadAll(io.LimitReader(file, maxCrashInputBytes+1))
+		file.Close()
+		if err != nil {
+			continue
+		}
+		input := CrashInput{Name: arg, Content: string(content)}
+		if len(content) > maxCrashInputBytes {
+			input.Content, input.Truncated = string(content[:maxCrashInputBytes]), true
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs
+}
+
+// String renders the bundle as a text file to attach to an issue
+func (r *CrashReport) String() string {
+	var output strings.Builder
+
+	output.WriteString("simple-cidr-calculator crash report\n\n")
+	output.WriteString(fmt.Sprintf("Time:      %s\n", r.Time.Format(time.RFC3339)))
+	output.WriteString(fmt.Sprintf("Version:   %s\n", r.Version))
+	output.WriteString(fmt.Sprintf("Go:        %s\n", r.Go))
+	output.WriteString(fmt.Sprintf("Platform:  %s\n", r.Platform))
+	output.WriteString(fmt.Sprintf("Arguments: %s\n", strings.Join(quoteArgs(r.Args), " ")))
+	output.WriteString(fmt.Sprintf("Panic:     %s\n", r.Panic))
+
+	output.WriteString("\nStack:\n")
+	output.WriteString(r.Stack)
+
+	for _, input := range r.Inputs {
+		truncated := ""
+		if input.Truncated {
+			truncated = fmt.Sprintf(" (first %d bytes)", maxCrashInputBytes)
+		}
+		output.WriteString(fmt.Sprintf("\nInput %s%s:\n", input.Name, truncated))
+		output.WriteString(input.Content)
+		if !strings.HasSuffix(input.Content, "\n") {
+			output.WriteString("\n")
+		}
+	}
+
+	return output.String()
+}
+
+// quoteArgs quotes the arguments that a shell would split or expand
+func quoteArgs(args []string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$*?[]{}|&;<>()`#~") {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+	return quoted
+}
+
+// reportCrash writes the diagnostic bundle of a panic to a new file in dir and
+// tells the user where it is, returning the exit status of the run. When the
+// bundle cannot be written, the stack is printed instead.
+func reportCrash(w io.Writer, dir string, args []string, recovered interface{}, stack []byte) int {
+	report := NewCrashReport(args, recovered, stack)
+	fmt.Fprintf(w, "Error: simple-cidr-calculator crashed: %s\n", report.Panic)
+	fmt.Fprintf(w, "This is a bug, not a problem with your input.\n")
+
+	file, err := os.CreateTemp(dir, "cidr-calc-crash-*.txt")
+	if err == nil {
+		_, err = file.WriteString(report.String())
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(w, "The diagnostic bundle could not be saved (%v); please include this stack in a report at %s:\n%s",
+			err, issuesURL, report.Stack)
+		return crashExitCode
+	}
+
+	fmt.Fprintf(w, "A diagnostic bundle with the arguments, input files, stack and version was saved to %s.\n", file.Name())
+	fmt.Fprintf(w, "Please check it for anything private and attach it to a report at %s\n", issuesURL)
+	return crashExitCode
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedactArgs(t *testing.T) {
+	args := []string{"cidr-calc", "-f", "https://intranet/plan.txt", "--header", "Authorization: Bearer secret", "snmp-discover", "--community=private", "-tls-key", "key.pem"}
+	expected := []string{"cidr-calc", "-f", "https://intranet/plan.txt", "--header", "[redacted]", "snmp-discover", "--community=[redacted]", "-tls-key", "[redacted]"}
+	if redacted := redactArgs(args); !reflect.DeepEqual(redacted, expected) {
+		t.Errorf("expected %q, got %q", expected, redacted)
+	}
+}
+
+func TestReportCrash(t *testing.T) {
+	dir := t.TempDir()
+	plan := filepath.Join(dir, "plan.txt")
+	key := filepath.Join(dir, "key.pem")
+	large := filepath.Join(dir, "large.txt")
+	for name, content := range map[string]string{
+		plan:  "10.0.0.0/24 # web",
+		key:   "PRIVATE KEY",
+		large: strings.Repeat("10.0.0.0/8\n", maxCrashInputBytes/11+1),
+	} {
+		if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stderr strings.Builder
+	args := []string{"cidr-calc", "lint", plan, "--plan=" + large, "--tls-key", key, "--header", "X-Token: abc"}
+	if code := reportCrash(&stderr, dir, args, "index out of range [3] with length 3", []byte("goroutine 1 [running]:\nmain.main()\n")); code != crashExitCode {
+		t.Errorf("expected exit status %d, got %d", crashExitCode, code)
+	}
+
+	bundles, _ := filepath.Glob(filepath.Join(dir, "cidr-calc-crash-*.txt"))
+	if len(bundles) != 1 {
+		t.Fatalf("expected one bundle, got %v", bundles)
+	}
+	if !strings.HasPrefix(stderr.String(), "Error: simple-cidr-calculator crashed: index out of range [3] with length 3\n") ||
+		!strings.Contains(stderr.String(), "saved to "+bundles[0]+".\n") {
+		t.Errorf("unexpected message:\n%s", stderr.String())
+	}
+
+	content, err := os.ReadFile(bundles[0])
+	if err != nil {
+		t.Fatalf("failed to read bundle: %v", err)
+	}
+	bundle := string(content)
+	for _, exp := range []string{
+		"Arguments: cidr-calc lint " + plan + " --plan=" + large + " --tls-key '[redacted]' --header '[redacted]'\n",
+		"Panic:     index out of range [3] with length 3\n",
+		"\nStack:\ngoroutine 1 [running]:\nmain.main()\n",
+		"\nInput " + plan + ":\n10.0.0.0/24 # web\n",
+		"\nInput " + large + " (first 65536 bytes):\n",
+	} {
+		if !strings.Contains(bundle, exp) {
+			t.Errorf("expected %q in the bundle", exp)
+		}
+	}
+	if strings.Contains(bundle, "PRIVATE KEY") || strings.Contains(bundle, "abc") {
+		t.Errorf("expected secrets to be left out of the bundle:\n%s", bundle)
+	}
+
+	// Without a place to save the bundle the stack is printed
+	stderr.Reset()
+	reportCrash(&stderr, filepath.Join(dir, "missing"), args, "boom", []byte("goroutine 1 [running]:\n"))
+	if !strings.Contains(stderr.String(), "could not be saved") || !strings.HasSuffix(stderr.String(), "goroutine 1 [running]:\n") {
+		t.Errorf("unexpected message:\n%s", stderr.String())
+	}
+}
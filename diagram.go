@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"math/big"
+	"strings"
+)
+
+// Heights of the rows of a network diagram
+const (
+	diagramTitleHeight  = 24
+	diagramParentHeight = 28
+	diagramBlockHeight  = 40
+	diagramNoteHeight   = 18
+	diagramSpacing      = 20
+)
+
+// diagramColors fill the subnet blocks in turn; free space is diagramFree
+var diagramColors = []string{"#a6cee3", "#b2df8a", "#fdbf6f", "#cab2d6", "#fb9a99", "#ffff99"}
+
+// diagramFree fills the space of a parent not taken by a subnet
+const diagramFree = "#ecf0f1"
+
+// diagramBlock is a subnet of a network diagram
+type diagramBlock struct {
+	CIDR        string
+	First, Last *big.Int
+	Name        string
+}
+
+// networkDiagram is a parent network and the subnets drawn inside it
+type networkDiagram struct {
+	Parent *NetworkInfo
+	Blocks []diagramBlock
+	Note   string
+}
+
+// networkDiagrams groups reports into diagrams: subnets carved from a parent
+// by VLSM or a plan are drawn together inside it, and any other network is
+// drawn with its listed subnets
+func (f *OutputFormatter) networkDiagrams(reports []NetworkReport) []*networkDiagram {
+	var diagrams []*networkDiagram
+	byParent := make(map[string]*networkDiagram)
+	calculator := NewCIDRCalculator()
+
+	for _, report := range reports {
+		info := report.Info
+		if info.Derivation != nil {
+			diagram, ok := byParent[info.Derivation.Parent]
+			if !ok {
+				parent, err := calculator.ParseCIDR(info.Derivation.Parent)
+				if err == nil {
+					diagram = &networkDiagram{Parent: parent}
+					byParent[info.Derivation.Parent] = diagram
+					diagrams = append(diagrams, diagram)
+				}
+			}
+			if diagram != nil {
+				diagram.Blocks = append(diagram.Blocks, diagramBlock{
+					CIDR: info.CIDR(), First: addressInt(info.NetworkID), Last: addressInt(info.BroadcastAddr), Name: info.Tags["name"],
+				})
+				continue
+			}
+		}
+
+		diagram := &networkDiagram{Parent: info, Note: shownNote(info.PrefixLength, report.Subnets)}
+		for _, subnet := range report.Subnets {
+			diagram.Blocks = append(diagram.Blocks, diagramBlock{
+				CIDR: subnet.CIDR, First: addressInt(subnet.NetworkID), Last: addressInt(subnet.BroadcastAddr),
+			})
+		}
+		diagrams = append(diagrams, diagram)
+	}
+	return diagrams
+}
+
+// diagramX returns the position of an address on a bar of the parent
+func diagramX(start, size, address *big.Int) float64 {
+	offset := new(big.Float).SetInt(new(big.Int).Sub(address, start))
+	x, _ := offset.Quo(offset, new(big.Float).SetInt(size)).Float64()
+	return x * svgWidth
+}
+
+// diagramFits reports whether a label fits on a block of the width
+func diagramFits(label string, width float64) bool {
+	return float64(len(label))*6.5+8 <= width
+}
+
+// FormatReportsAsSVG draws each network as a block diagram: the parent as a
+// bar across the top and its subnets below it, each as wide as its share of
+// the parent, with free space left light gray
+func (f *OutputFormatter) FormatReportsAsSVG(reports []NetworkReport) string {
+	diagrams := f.networkDiagrams(reports)
+	title := fmt.Sprintf("%d networks", len(diagrams))
+	if len(diagrams) == 1 {
+		title = diagrams[0].Parent.CIDR()
+	}
+
+	var body strings.Builder
+	y := 0
+	for i, diagram := range diagrams {
+		if i > 0 {
+			y += diagramSpacing
+		}
+		y = f.writeDiagram(&body, diagram, y)
+	}
+	if len(f.BatchErrors) > 0 {
+		y += diagramSpacing
+		body.WriteString(fmt.Sprintf(`  <text x="0" y="%d" font-weight="bold">%s</text>`+"\n", y+14, html.EscapeString(f.batchErrorsTitle())))
+		y += diagramTitleHeight
+		for _, failure := range f.BatchErrors {
+			line := fmt.Sprintf("%s line %d: %s: %v", failure.Source, failure.Line, failure.CIDR, failure.Err)
+			body.WriteString(fmt.Sprintf(`  <text x="0" y="%d">%s</text>`+"\n", y+12, html.EscapeString(line)))
+			y += diagramNoteHeight
+		}
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		svgWidth, y, svgWidth, y))
+	output.WriteString(fmt.Sprintf("  <title>CIDR Calculator Diagram: %s</title>\n", html.EscapeString(title)))
+	output.WriteString(body.String())
+	output.WriteString("</svg>\n")
+	return output.String()
+}
+
+// writeDiagram draws one diagram from the top y and returns its bottom
+func (f *OutputFormatter) writeDiagram(output *strings.Builder, diagram *networkDiagram, y int) int {
+	parent := diagram.Parent
+	start, last := addressInt(parent.NetworkID), addressInt(parent.BroadcastAddr)
+	size := new(big.Int).Add(new(big.Int).Sub(last, start), big.NewInt(1))
+
+	heading := parent.CIDR()
+	if name := parent.Tags["name"]; name != "" {
+		heading = name + " " + heading
+	}
+	if len(diagram.Blocks) > 0 {
+		heading += " - " + plural(len(diagram.Blocks), "subnet")
+	}
+	output.WriteString(fmt.Sprintf(`  <text x="0" y="%d" font-size="13" font-weight="bold">%s</text>`+"\n", y+16, html.EscapeString(heading)))
+	y += diagramTitleHeight
+
+	addresses := f.Locale.Integer(size.String())
+	output.WriteString(fmt.Sprintf(`  <g><title>%s (%s - %s, %s addresses)</title><rect x="0" y="%d" width="%d" height="%d" fill="#667eea" stroke="#fff"/>`,
+		parent.CIDR(), parent.NetworkID, parent.BroadcastAddr, addresses, y, svgWidth, diagramParentHeight))
+	output.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle" fill="#fff">%s</text></g>`+"\n",
+		svgWidth/2, y+diagramParentHeight/2+4, html.EscapeString(fmt.Sprintf("%s (%s addresses)", parent.CIDR(), addresses))))
+	y += diagramParentHeight + 4
+
+	// Free space is the background of the row, so gaps need no blocks
+	output.WriteString(fmt.Sprintf(`  <g><title>free</title><rect x="0" y="%d" width="%d" height="%d" fill="%s"/></g>`+"\n",
+		y, svgWidth, diagramBlockHeight, diagramFree))
+	for i, block := range diagram.Blocks {
+		x := diagramX(start, size, block.First)
+		width := diagramX(start, size, new(big.Int).Add(block.Last, big.NewInt(1))) - x
+
+		title := block.CIDR
+		if block.Name != "" {
+			title = block.Name + " " + title
+		}
+		blockSize := new(big.Int).Add(new(big.Int).Sub(block.Last, block.First), big.NewInt(1))
+		title += fmt.Sprintf(" (%s addresses)", f.Locale.Integer(blockSize.String()))
+		output.WriteString(fmt.Sprintf(`  <g><title>%s</title><rect x="%.2f" y="%d" width="%.2f" height="%d" fill="%s" stroke="#fff"/>`,
+			html.EscapeString(title), x, y, width, diagramBlockHeight, diagramColors[i%len(diagramColors)]))
+
+		// Blocks are labeled with their name above their CIDR, with
+		// whichever fits, or left to the tooltip
+		nameFits, cidrFits := block.Name != "" && diagramFits(block.Name, width), diagramFits(block.CIDR, width)
+		switch {
+		case nameFits && cidrFits:
+			output.WriteString(fmt.Sprintf(`<text x="%.2f" y="%d" text-anchor="middle" font-weight="bold">%s</text>`, x+width/2, y+16, html.EscapeString(block.Name)))
+			output.WriteString(fmt.Sprintf(`<text x="%.2f" y="%d" text-anchor="middle">%s</text>`, x+width/2, y+32, block.CIDR))
+		case nameFits:
+			output.WriteString(fmt.Sprintf(`<text x="%.2f" y="%d" text-anchor="middle" font-weight="bold">%s</text>`, x+width/2, y+diagramBlockHeight/2+4, html.EscapeString(block.Name)))
+		case cidrFits:
+			output.WriteString(fmt.Sprintf(`<text x="%.2f" y="%d" text-anchor="middle">%s</text>`, x+width/2, y+diagramBlockHeight/2+4, block.CIDR))
+		}
+		output.WriteString("</g>\n")
+	}
+	y += diagramBlockHeight
+
+	if diagram.Note != "" {
+		output.WriteString(fmt.Sprintf(`  <text x="0" y="%d" fill="#666">%s</text>`+"\n", y+14, html.EscapeString("Note: "+diagram.Note)))
+		y += diagramNoteHeight
+	}
+	return y
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputFormatter_SVGDiagram(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	info := mustParseCIDR(t, calculator, "10.0.0.0/22")
+	subnets, err := calculator.SplitSubnets(info, 24)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := formatter.FormatReportsAsSVG([]NetworkReport{{Info: info, Subnets: subnets}})
+
+	for _, expected := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="960" height="96" viewBox="0 0 960 96"`,
+		"<title>CIDR Calculator Diagram: 10.0.0.0/22</title>",
+		">10.0.0.0/22 - 4 subnets</text>",
+		`<g><title>10.0.1.0/24 (256 addresses)</title><rect x="240.00" y="56" width="240.00" height="40"`,
+		`<text x="840.00" y="80" text-anchor="middle">10.0.3.0/24</text>`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in:\n%s", expected, output)
+		}
+	}
+	if err := xml.Unmarshal([]byte(output), new(struct{})); err != nil {
+		t.Errorf("expected well-formed SVG: %v", err)
+	}
+
+	// Subnets carved from a parent are drawn inside it, with their names
+	web, transit := mustParseCIDR(t, calculator, "10.0.0.0/25"), mustParseCIDR(t, calculator, "10.0.0.192/30")
+	web.Tags, transit.Tags = Tags{"name": "web"}, Tags{"name": "transit"}
+	web.Derivation = &Derivation{Parent: "10.0.0.0/24", Rule: "plan", Requested: "100 hosts"}
+	transit.Derivation = &Derivation{Parent: "10.0.0.0/24", Rule: "plan", Requested: "/30"}
+	output = formatter.FormatReportsAsSVG([]NetworkReport{{Info: web}, {Info: transit}})
+	for _, expected := range []string{
+		"<title>CIDR Calculator Diagram: 10.0.0.0/24</title>",
+		">10.0.0.0/24 - 2 subnets</text>",
+		`<text x="240.00" y="72" text-anchor="middle" font-weight="bold">web</text><text x="240.00" y="88" text-anchor="middle">10.0.0.0/25</text>`,
+		// Too narrow for a label, transit is left to its tooltip
+		`<g><title>transit 10.0.0.192/30 (4 addresses)</title><rect x="720.00" y="56" width="15.00" height="40" fill="#b2df8a" stroke="#fff"/></g>`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in:\n%s", expected, output)
+		}
+	}
+}
+
+func TestCLIHandler_SVGOutput(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+	output := filepath.Join(dir, "diagram.svg")
+
+	if err := handler.Run([]string{"cidr-calc", "--vlsm", "100,50", "-o", output, "10.0.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	if !strings.HasPrefix(string(content), "<svg ") || !strings.Contains(string(content), ">10.0.0.0/24 - 2 subnets</text>") {
+		t.Errorf("expected a diagram of the VLSM plan, got:\n%s", content)
+	}
+
+	input := filepath.Join(dir, "networks.txt")
+	os.WriteFile(input, []byte("10.0.0.0/24 name=lab\n2001:db8::/48\nbogus\n"), 0644)
+	if err := handler.Run([]string{"cidr-calc", "-f", input, "--format", "svg", "-o", output}); err == nil {
+		t.Fatalf("expected the failed entry to be reported")
+	}
+	content, _ = os.ReadFile(output)
+	for _, expected := range []string{">lab 10.0.0.0/24 - 2 subnets</text>", ">2001:db8::/48 - 16 subnets</text>", ">Batch Errors (1 failed entry)</text>"} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected %q in:\n%s", expected, content)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// KindHost marks an address range configured on a host interface
+const KindHost = "host"
+
+// dockerNetwork is a network as returned by docker network inspect
+type dockerNetwork struct {
+	Name   string `json:"Name"`
+	Driver string `json:"Driver"`
+	IPAM   struct {
+		Config []struct {
+			Subnet string `json:"Subnet"`
+		} `json:"Config"`
+	} `json:"IPAM"`
+}
+
+// hostRangeLister returns the address ranges configured on the local host
+type hostRangeLister func() ([]DiscoveredRange, error)
+
+// dockerInterfacePrefixes name the interfaces Docker creates for its own networks
+var dockerInterfacePrefixes = []string{"docker", "br-", "veth"}
+
+// DockerSource lists Docker network subnets through the docker CLI, which talks
+// to the Docker API of the local daemon or of DOCKER_HOST
+type DockerSource struct {
+	run commandRunner
+}
+
+// NewDockerSource creates a new Docker network source
+func NewDockerSource(run commandRunner) *DockerSource {
+	return &DockerSource{run: run}
+}
+
+// Ranges returns the subnets of every Docker network, labelled with the network
+// driver (bridge, overlay, macvlan, ...). Networks without IPAM subnets, such as
+// host and none, are skipped.
+func (d *DockerSource) Ranges() ([]DiscoveredRange, error) {
+	output, err := d.run("docker", "network", "ls", "--quiet", "--no-trunc")
+	if err != nil {
+		return nil, err
+	}
+
+	ids := strings.Fields(string(output))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	output, err = d.run("docker", append([]string{"network", "inspect"}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var networks []dockerNetwork
+	if err := json.Unmarshal(output, &networks); err != nil {
+		return nil, fmt.Errorf("failed to parse docker network inspect output: %v", err)
+	}
+
+	var ranges []DiscoveredRange
+	for _, network := range networks {
+		for _, config := range network.IPAM.Config {
+			if config.Subnet == "" {
+				continue
+			}
+			ranges = append(ranges, DiscoveredRange{Kind: network.Driver, Source: "network/" + network.Name, CIDR: config.Subnet})
+		}
+	}
+
+	return ranges, nil
+}
+
+// hostInterfaceRanges returns the networks of the host's active interfaces,
+// leaving out loopback, link-local addresses and Docker's own bridges
+func hostInterfaceRanges() ([]DiscoveredRange, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network interfaces: %v", err)
+	}
+
+	var ranges []DiscoveredRange
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || isDockerInterface(iface.Name) {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read addresses of %s: %v", iface.Name, err)
+		}
+
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			prefix, _ := ipNet.Mask.Size()
+			network := &net.IPNet{IP: ipNet.IP.Mask(ipNet.Mask), Mask: ipNet.Mask}
+			ranges = append(ranges, DiscoveredRange{
+				Kind:   KindHost,
+				Source: "interface/" + iface.Name,
+				CIDR:   fmt.Sprintf("%s/%d", network.IP.String(), prefix),
+			})
+		}
+	}
+
+	return ranges, nil
+}
+
+// isDockerInterface reports whether an interface was created by Docker
+func isDockerInterface(name string) bool {
+	for _, prefix := range dockerInterfacePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// runDockerAudit implements the docker-audit subcommand
+func (c *CLIHandler) runDockerAudit(args []string) error {
+	flagSet := flag.NewFlagSet("docker-audit", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var planSource, format, outputFile string
+	var emit stringList
+	var skipHost bool
+	flagSet.StringVar(&planSource, "plan", "", "Corporate ranges file, URL or - for stdin")
+	flagSet.BoolVar(&skipHost, "no-host", false, "Do not compare with the host's interface networks")
+	flagSet.StringVar(&format, "format", FormatText, "Findings format: text, gh-annotations")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	flagSet.Var(&emit, "emit", emitFlagUsage)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	emitTargets, err := parseEmitTargets(emit)
+	if err != nil {
+		return err
+	}
+
+	var plan []BatchEntry
+	if planSource != "" {
+		var err error
+		if plan, err = NewBatchReader(defaultFetchTimeout, nil).Read(planSource); err != nil {
+			return err
+		}
+	}
+
+	ranges, err := NewDockerSource(c.run).Ranges()
+	if err != nil {
+		return err
+	}
+
+	var host []DiscoveredRange
+	if !skipHost {
+		if host, err = c.hostRanges(); err != nil {
+			return err
+		}
+	}
+
+	findings := NewRangeChecker().Check(ranges, host, plan)
+
+	var content string
+	switch format {
+	case "", FormatText:
+		content = c.formatter.FormatDiscoveredRanges("Docker Audit", append(ranges, host...), findings)
+	default:
+		if content, err = c.formatter.RenderFindings(format, findings); err != nil {
+			return err
+		}
+	}
+
+	return c.writeAuditContent(content, outputFile, emitTargets, findings)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testDockerNetworks = `[
+  {"Name": "bridge", "Driver": "bridge", "IPAM": {"Config": [{"Subnet": "172.17.0.0/16", "Gateway": "172.17.0.1"}]}},
+  {"Name": "host", "Driver": "host", "IPAM": {"Config": []}},
+  {"Name": "app_default", "Driver": "bridge", "IPAM": {"Config": [{"Subnet": "172.18.0.0/16"}, {"Subnet": "fd00:dead:beef::/64"}]}},
+  {"Name": "ingress", "Driver": "overlay", "IPAM": {"Config": [{"Subnet": "10.0.0.0/24"}]}}
+]`
+
+// fakeDocker answers docker network ls and inspect
+func fakeDocker(inspect string) commandRunner {
+	return func(name string, args ...string) ([]byte, error) {
+		command := strings.Join(append([]string{name}, args...), " ")
+		switch {
+		case command == "docker network ls --quiet --no-trunc":
+			return []byte("aaa\nbbb\nccc\nddd\n"), nil
+		case command == "docker network inspect aaa bbb ccc ddd":
+			return []byte(inspect), nil
+		}
+		return nil, fmt.Errorf("unexpected command: %s", command)
+	}
+}
+
+func TestDockerSource_Ranges(t *testing.T) {
+	ranges, err := NewDockerSource(fakeDocker(testDockerNetworks)).Ranges()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []DiscoveredRange{
+		{Kind: "bridge", Source: "network/bridge", CIDR: "172.17.0.0/16"},
+		{Kind: "bridge", Source: "network/app_default", CIDR: "172.18.0.0/16"},
+		{Kind: "bridge", Source: "network/app_default", CIDR: "fd00:dead:beef::/64"},
+		{Kind: "overlay", Source: "network/ingress", CIDR: "10.0.0.0/24"},
+	}
+	if len(ranges) != len(expected) {
+		t.Fatalf("expected %d ranges, got %d: %+v", len(expected), len(ranges), ranges)
+	}
+	for i, exp := range expected {
+		if ranges[i] != exp {
+			t.Errorf("range %d: expected %+v, got %+v", i, exp, ranges[i])
+		}
+	}
+
+	if _, err := NewDockerSource(fakeDocker("not json")).Ranges(); err == nil {
+		t.Errorf("expected error for invalid inspect output")
+	}
+}
+
+func TestIsDockerInterface(t *testing.T) {
+	tests := map[string]bool{
+		"docker0":         true,
+		"docker_gwbridge": true,
+		"br-3f2a9c":       true,
+		"veth12ab":        true,
+		"eth0":            false,
+		"tun0":            false,
+		"wg0":             false,
+	}
+	for name, expected := range tests {
+		if got := isDockerInterface(name); got != expected {
+			t.Errorf("isDockerInterface(%q) = %v, want %v", name, got, expected)
+		}
+	}
+}
+
+func TestCLIHandler_DockerAudit(t *testing.T) {
+	dir := t.TempDir()
+	plan := filepath.Join(dir, "corporate.txt")
+	if err := os.WriteFile(plan, []byte("# VPN pools\n172.18.0.0/20\n"), 0644); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+
+	handler := NewCLIHandler()
+	handler.run = fakeDocker(testDockerNetworks)
+	handler.hostRanges = func() ([]DiscoveredRange, error) {
+		return []DiscoveredRange{
+			{Kind: KindHost, Source: "interface/eth0", CIDR: "192.168.1.0/24"},
+			{Kind: KindHost, Source: "interface/tun0", CIDR: "172.17.8.0/22"},
+		}, nil
+	}
+
+	output := filepath.Join(dir, "audit.txt")
+	err := handler.Run([]string{"cidr-calc", "docker-audit", "--plan", plan, "-o", output})
+	if err == nil || err.Error() != "audit found 2 errors" {
+		t.Fatalf("expected two errors, got %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	for _, exp := range []string{
+		"Docker Audit:",
+		"host           172.17.8.0/22      interface/tun0",
+		"bridge range 172.17.0.0/16 (network/bridge) overlaps host range 172.17.8.0/22 (interface/tun0) [range-overlap]",
+		"corporate.txt:2",
+		"bridge range 172.18.0.0/16 (network/app_default) overlaps planned 172.18.0.0/20 [plan-conflict]",
+	} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected report to contain %q, got:\n%s", exp, content)
+		}
+	}
+
+	// Without the host comparison and plan only Docker's own networks are checked
+	if err := handler.Run([]string{"cidr-calc", "docker-audit", "--no-host", "-o", output}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	handler.run = fakeCLI("kubectl", "")
+	if err := handler.Run([]string{"cidr-calc", "docker-audit", "--no-host"}); err == nil {
+		t.Errorf("expected error when docker fails")
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Event formats accepted as --emit URL schemes
+const (
+	EmitSyslog = "syslog"
+	EmitCEF    = "cef"
+)
+
+// emitFlagUsage documents the --emit flag shared by the lint and audit commands
+const emitFlagUsage = "Send findings to a syslog://, syslog+tcp://, cef:// or cef+tcp:// HOST[:PORT] (repeatable)"
+
+const (
+	defaultSyslogPort = "514"
+	emitTimeout       = 5 * time.Second
+	emitAppName       = "cidr-calc"
+	cefVendor         = "simple-cidr-calculator"
+	syslogFacility    = 1 // user-level messages
+)
+
+// syslogSeverities maps finding severities to RFC 5424 severity codes
+var syslogSeverities = map[FindingSeverity]int{
+	SeverityError:   3,
+	SeverityWarning: 4,
+	SeverityNotice:  5,
+}
+
+// cefSeverities maps finding severities to the 0-10 CEF severity scale
+var cefSeverities = map[FindingSeverity]int{
+	SeverityError:   8,
+	SeverityWarning: 5,
+	SeverityNotice:  3,
+}
+
+// EmitTarget is a collector that receives findings as events
+type EmitTarget struct {
+	Format  string // EmitSyslog or EmitCEF
+	Network string // udp or tcp
+	Address string // host:port
+}
+
+// String returns the target in its URL form
+func (t EmitTarget) String() string {
+	return fmt.Sprintf("%s+%s://%s", t.Format, t.Network, t.Address)
+}
+
+// ParseEmitTarget parses a URL such as syslog://siem.example.com:514. The scheme
+// picks the event format and an optional +udp or +tcp transport (UDP by default).
+func ParseEmitTarget(raw string) (EmitTarget, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return EmitTarget{}, fmt.Errorf("invalid emit target %s: %v", raw, err)
+	}
+
+	format, transport, _ := strings.Cut(parsed.Scheme, "+")
+	if format != EmitSyslog && format != EmitCEF {
+		return EmitTarget{}, fmt.Errorf("unsupported emit target %s (use syslog:// or cef://)", raw)
+	}
+	if transport == "" {
+		transport = "udp"
+	}
+	if transport != "udp" && transport != "tcp" {
+		return EmitTarget{}, fmt.Errorf("unsupported emit transport %s in %s (use udp or tcp)", transport, raw)
+	}
+	if parsed.Hostname() == "" || (parsed.Path != "" && parsed.Path != "/") {
+		return EmitTarget{}, fmt.Errorf("emit target %s must be %s://HOST[:PORT]", raw, parsed.Scheme)
+	}
+
+	port := parsed.Port()
+	if port == "" {
+		port = defaultSyslogPort
+	}
+
+	return EmitTarget{Format: format, Network: transport, Address: net.JoinHostPort(parsed.Hostname(), port)}, nil
+}
+
+// parseEmitTargets parses every --emit value
+func parseEmitTargets(values []string) ([]EmitTarget, error) {
+	targets := make([]EmitTarget, 0, len(values))
+	for _, value := range values {
+		target, err := ParseEmitTarget(value)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// FindingEmitter sends findings to syslog collectors, one RFC 5424 message per finding
+type FindingEmitter struct {
+	hostname string
+	now      func() time.Time
+}
+
+// NewFindingEmitter creates an emitter that reports the local host name
+func NewFindingEmitter() *FindingEmitter {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &FindingEmitter{hostname: hostname, now: time.Now}
+}
+
+// Emit sends every finding to the target. TCP messages are framed with their
+// length as described in RFC 6587; UDP sends one datagram per finding.
+func (e *FindingEmitter) Emit(target EmitTarget, findings []Finding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+
+	conn, err := net.DialTimeout(target.Network, target.Address, emitTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", target, err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(emitTimeout)); err != nil {
+		return fmt.Errorf("failed to send findings to %s: %v", target, err)
+	}
+
+	for _, finding := range findings {
+		message := e.Message(target.Format, finding)
+		if target.Network == "tcp" {
+			message = fmt.Sprintf("%d %s", len(message), message)
+		}
+		if _, err := conn.Write([]byte(message)); err != nil {
+			return fmt.Errorf("failed to send findings to %s: %v", target, err)
+		}
+	}
+
+	return nil
+}
+
+// Message formats a finding as an RFC 5424 syslog message whose body is either
+// the text finding or, for the cef format, a CEF event
+func (e *FindingEmitter) Message(format string, finding Finding) string {
+	body := strings.TrimSpace(finding.Location()+" "+finding.Message) + " [" + finding.Rule + "]"
+	if format == EmitCEF {
+		body = cefEvent(finding)
+	}
+
+	msgID := finding.Rule
+	if msgID == "" {
+		msgID = "-"
+	}
+
+	priority := syslogFacility*8 + syslogSeverities[finding.Severity]
+	return fmt.Sprintf("<%d>1 %s %s %s - %s - %s", priority, e.now().UTC().Format(time.RFC3339), e.hostname, emitAppName, msgID, body)
+}
+
+// cefEvent formats a finding as an ArcSight Common Event Format record
+func cefEvent(finding Finding) string {
+	var extension []string
+	if finding.CIDR != "" {
+		extension = append(extension, "cs1Label=CIDR", "cs1="+escapeCEFValue(finding.CIDR))
+	}
+	if finding.File != "" {
+		extension = append(extension, "fname="+escapeCEFValue(finding.File))
+	}
+	if finding.Line > 0 {
+		extension = append(extension, "cn1Label=Line", fmt.Sprintf("cn1=%d", finding.Line))
+	}
+
+	return fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		escapeCEFHeader(cefVendor), escapeCEFHeader(emitAppName), escapeCEFHeader(buildVersion()),
+		escapeCEFHeader(finding.Rule), escapeCEFHeader(finding.Message), cefSeverities[finding.Severity],
+		strings.Join(extension, " "))
+}
+
+// escapeCEFHeader escapes a pipe-delimited CEF header field
+func escapeCEFHeader(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", " ", "\r", " ").Replace(value)
+}
+
+// escapeCEFValue escapes a CEF extension value
+func escapeCEFValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, "\n", `\n`, "\r", `\r`).Replace(value)
+}
+
+// buildVersion returns the module version of the running binary
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "unknown"
+}
+
+// emitFindings sends the findings to every --emit target
+func (c *CLIHandler) emitFindings(targets []EmitTarget, findings []Finding) error {
+	emitter := NewFindingEmitter()
+	for _, target := range targets {
+		if err := emitter.Emit(target, findings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
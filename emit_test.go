@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEmitTarget(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    EmitTarget
+		wantErr bool
+	}{
+		{raw: "syslog://siem.example.com", want: EmitTarget{Format: EmitSyslog, Network: "udp", Address: "siem.example.com:514"}},
+		{raw: "syslog+tcp://10.0.0.5:6514", want: EmitTarget{Format: EmitSyslog, Network: "tcp", Address: "10.0.0.5:6514"}},
+		{raw: "cef+udp://[2001:db8::1]:1514", want: EmitTarget{Format: EmitCEF, Network: "udp", Address: "[2001:db8::1]:1514"}},
+		{raw: "https://siem.example.com", wantErr: true},
+		{raw: "syslog+sctp://siem.example.com", wantErr: true},
+		{raw: "syslog:///var/log/audit", wantErr: true},
+		{raw: "siem.example.com:514", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseEmitTarget(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseEmitTarget(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseEmitTarget(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestFindingEmitter_Message(t *testing.T) {
+	emitter := &FindingEmitter{hostname: "ci-runner", now: func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }}
+	finding := Finding{
+		Severity: SeverityError,
+		Rule:     RuleOverlap,
+		Message:  "10.0.0.0/24 overlaps 10.0.0.0/16 at plan.txt:1",
+		File:     "plans/prod=eu.txt",
+		Line:     3,
+		CIDR:     "10.0.0.0/24",
+	}
+
+	syslog := emitter.Message(EmitSyslog, finding)
+	expected := "<11>1 2024-01-02T03:04:05Z ci-runner cidr-calc - overlap - plans/prod=eu.txt:3 10.0.0.0/24 overlaps 10.0.0.0/16 at plan.txt:1 [overlap]"
+	if syslog != expected {
+		t.Errorf("expected syslog message:\n%s\ngot:\n%s", expected, syslog)
+	}
+
+	cef := emitter.Message(EmitCEF, finding)
+	if !strings.HasPrefix(cef, "<11>1 2024-01-02T03:04:05Z ci-runner cidr-calc - overlap - CEF:0|simple-cidr-calculator|cidr-calc|") {
+		t.Errorf("unexpected CEF syslog header: %s", cef)
+	}
+	if !strings.HasSuffix(cef, "|overlap|10.0.0.0/24 overlaps 10.0.0.0/16 at plan.txt:1|8|cs1Label=CIDR cs1=10.0.0.0/24 fname=plans/prod\\=eu.txt cn1Label=Line cn1=3") {
+		t.Errorf("unexpected CEF event: %s", cef)
+	}
+
+	if got := escapeCEFHeader(`a|b\c`); got != `a\|b\\c` {
+		t.Errorf("escapeCEFHeader() = %s", got)
+	}
+}
+
+func TestFindingEmitter_Emit(t *testing.T) {
+	findings := []Finding{
+		{Severity: SeverityWarning, Rule: RuleDuplicate, Message: "first"},
+		{Severity: SeverityNotice, Rule: RuleDuplicate, Message: "second"},
+	}
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on UDP: %v", err)
+	}
+	defer udp.Close()
+
+	emitter := NewFindingEmitter()
+	if err := emitter.Emit(EmitTarget{Format: EmitSyslog, Network: "udp", Address: udp.LocalAddr().String()}, findings); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buffer := make([]byte, 2048)
+	for _, exp := range []string{"<12>1 ", "<13>1 "} {
+		udp.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := udp.ReadFrom(buffer)
+		if err != nil {
+			t.Fatalf("failed to read datagram: %v", err)
+		}
+		if !strings.HasPrefix(string(buffer[:n]), exp) {
+			t.Errorf("expected datagram starting with %q, got %q", exp, buffer[:n])
+		}
+	}
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on TCP: %v", err)
+	}
+	defer tcp.Close()
+
+	emitter.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	message := emitter.Message(EmitCEF, findings[0])
+	// RFC 6587 octet counting puts the message length before the message
+	frame := fmt.Sprintf("%d %s", len(message), message)
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := tcp.Accept()
+		if err != nil {
+			received <- err.Error()
+			return
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		data := make([]byte, len(frame))
+		if _, err := io.ReadFull(conn, data); err != nil {
+			received <- err.Error()
+			return
+		}
+		received <- string(data)
+	}()
+
+	if err := emitter.Emit(EmitTarget{Format: EmitCEF, Network: "tcp", Address: tcp.Addr().String()}, findings[:1]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := <-received; got != frame {
+		t.Errorf("expected frame %q, got %q", frame, got)
+	}
+
+	if err := emitter.Emit(EmitTarget{Format: EmitSyslog, Network: "tcp", Address: "127.0.0.1:1"}, findings); err == nil {
+		t.Errorf("expected error when the collector is unreachable")
+	}
+	if err := emitter.Emit(EmitTarget{Format: EmitSyslog, Network: "tcp", Address: "127.0.0.1:1"}, nil); err != nil {
+		t.Errorf("expected no connection without findings, got %v", err)
+	}
+}
+
+func TestCLIHandler_LintEmit(t *testing.T) {
+	dir := t.TempDir()
+	plan := filepath.Join(dir, "plan.txt")
+	if err := os.WriteFile(plan, []byte("10.0.0.0/16\n10.0.1.0/24\n"), 0644); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on UDP: %v", err)
+	}
+	defer udp.Close()
+
+	handler := NewCLIHandler()
+	output := filepath.Join(dir, "lint.txt")
+	err = handler.Run([]string{"cidr-calc", "lint", "--emit", "cef://" + udp.LocalAddr().String(), "-o", output, plan})
+	if err == nil || err.Error() != "lint found 1 error" {
+		t.Fatalf("expected one lint error, got %v", err)
+	}
+
+	buffer := make([]byte, 2048)
+	udp.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := udp.ReadFrom(buffer)
+	if err != nil {
+		t.Fatalf("failed to read event: %v", err)
+	}
+	if event := string(buffer[:n]); !strings.Contains(event, "|overlap|10.0.1.0/24 overlaps 10.0.0.0/16") {
+		t.Errorf("unexpected event: %s", event)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "lint", "--emit", "http://siem", plan}); err == nil {
+		t.Errorf("expected error for an unsupported emit target")
+	}
+}
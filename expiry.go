@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// expiresTag is the tag that records when an allocation is due to be reclaimed
+const expiresTag = "expires"
+
+// expiryDateLayout is the layout of the expires tag and of --expires dates
+const expiryDateLayout = "2006-01-02"
+
+// parseDays parses a period of days such as 30d or 2w
+func parseDays(value string) (int, error) {
+	if len(value) < 2 {
+		return 0, fmt.Errorf("invalid period %q (use days or weeks, such as 30d or 2w)", value)
+	}
+	count, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("invalid period %q (use days or weeks, such as 30d or 2w)", value)
+	}
+	switch value[len(value)-1] {
+	case 'd':
+		return count, nil
+	case 'w':
+		return count * 7, nil
+	}
+	return 0, fmt.Errorf("invalid period %q (use days or weeks, such as 30d or 2w)", value)
+}
+
+// parseExpiry returns the date an --expires value names: a date such as
+// 2026-12-31, or a period such as 30d or 2w from today
+func parseExpiry(value string, now time.Time) (time.Time, error) {
+	if date, err := time.Parse(expiryDateLayout, value); err == nil {
+		return date, nil
+	}
+	days, err := parseDays(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --expires %q (use a date such as 2026-12-31 or a period such as 30d)", value)
+	}
+	return startOfDay(now).AddDate(0, 0, days), nil
+}
+
+// startOfDay returns midnight UTC of the day of t
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// ExpiringAllocation is an allocation that has expired or is about to
+type ExpiringAllocation struct {
+	CIDR    string
+	Name    string
+	Expires time.Time
+	Days    int // days until it expires; negative once it has
+}
+
+// ExpiryReport lists the allocations of a state that expire within a window
+type ExpiryReport struct {
+	State       string
+	Within      int // days
+	Allocations []ExpiringAllocation
+	Warnings    []string
+}
+
+// expiryReport reads the allocations of a state and lists those that expired
+// or expire within the given number of days of now, soonest first
+func (c *CLIHandler) expiryReport(stateFile string, within int, now time.Time) (*ExpiryReport, error) {
+	allocations, _, err := c.usedRanges(stateFile, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ExpiryReport{State: stateFile, Within: within}
+	today := startOfDay(now)
+	for _, allocation := range allocations {
+		value, ok := allocation.entry.Tags[expiresTag]
+		if !ok {
+			continue
+		}
+		expires, err := time.Parse(expiryDateLayout, value)
+		if err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s line %d: invalid expires date %q", allocation.entry.Source, allocation.entry.Line, value))
+			continue
+		}
+		days := int(expires.Sub(today).Hours() / 24)
+		if days > within {
+			continue
+		}
+		report.Allocations = append(report.Allocations, ExpiringAllocation{
+			CIDR: allocation.info.CIDR(), Name: allocation.entry.Tags["name"], Expires: expires, Days: days,
+		})
+		if days < 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("allocation %s expired %s ago", allocation.info.CIDR(), plural(-days, "day")))
+		}
+	}
+	sort.SliceStable(report.Allocations, func(i, j int) bool {
+		return report.Allocations[i].Days < report.Allocations[j].Days
+	})
+	return report, nil
+}
+
+// describeExpiry says when an allocation expires relative to today
+func describeExpiry(days int) string {
+	switch {
+	case days < 0:
+		return fmt.Sprintf("expired %s ago", plural(-days, "day"))
+	case days == 0:
+		return "expires today"
+	}
+	return fmt.Sprintf("in %s", plural(days, "day"))
+}
+
+// FormatExpiring renders the expiring allocations followed by the warnings
+func (f *OutputFormatter) FormatExpiring(report *ExpiryReport) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Allocations in %s expiring within %s:\n", report.State, plural(report.Within, "day")))
+	if len(report.Allocations) == 0 {
+		output.WriteString("  none\n")
+	} else {
+		output.WriteString(fmt.Sprintf("  %-20s %-16s %-12s %s\n", "CIDR", "Name", "Expires", "When"))
+	}
+	for _, allocation := range report.Allocations {
+		name := allocation.Name
+		if name == "" {
+			name = "-"
+		}
+		output.WriteString(fmt.Sprintf("  %-20s %-16s %-12s %s\n", allocation.CIDR, name, allocation.Expires.Format(expiryDateLayout), describeExpiry(allocation.Days)))
+	}
+
+	if len(report.Warnings) > 0 {
+		output.WriteString("\nWarnings:\n")
+		for _, warning := range report.Warnings {
+			output.WriteString("  " + warning + "\n")
+		}
+	}
+
+	return output.String()
+}
+
+// jsonExpiryReport is the expiring allocations for reclamation jobs
+type jsonExpiryReport struct {
+	State       string                   `json:"state"`
+	WithinDays  int                      `json:"within_days"`
+	Allocations []jsonExpiringAllocation `json:"allocations"`
+	Warnings    []string                 `json:"warnings"`
+}
+
+// jsonExpiringAllocation is one expiring allocation; days is negative once it
+// has expired
+type jsonExpiringAllocation struct {
+	CIDR    string `json:"cidr"`
+	Name    string `json:"name,omitempty"`
+	Expires string `json:"expires"`
+	Days    int    `json:"days"`
+	Expired bool   `json:"expired"`
+}
+
+// FormatExpiringAsJSON renders the expiring allocations as JSON
+func (f *OutputFormatter) FormatExpiringAsJSON(report *ExpiryReport) (string, error) {
+	document := jsonExpiryReport{State: report.State, WithinDays: report.Within, Allocations: []jsonExpiringAllocation{}, Warnings: report.Warnings}
+	if document.Warnings == nil {
+		document.Warnings = []string{}
+	}
+	for _, allocation := range report.Allocations {
+		document.Allocations = append(document.Allocations, jsonExpiringAllocation{
+			CIDR:    allocation.CIDR,
+			Name:    allocation.Name,
+			Expires: allocation.Expires.Format(expiryDateLayout),
+			Days:    allocation.Days,
+			Expired: allocation.Days < 0,
+		})
+	}
+
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %v", err)
+	}
+	return string(content) + "\n", nil
+}
+
+// runIPAMExpiring implements the ipam expiring command
+func (c *CLIHandler) runIPAMExpiring(args []string) error {
+	flagSet := flag.NewFlagSet("ipam expiring", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var stateFile, within, format, outputFile string
+	flagSet.StringVar(&stateFile, "state", "", "Plan file or s3://, gs://, etcd://, consul:// URL of allocations")
+	flagSet.StringVar(&within, "within", "30d", "List allocations expiring within this period, such as 30d or 2w")
+	flagSet.StringVar(&format, "format", FormatText, "Output format: text or json")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if flagSet.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", flagSet.Arg(0))
+	}
+	if stateFile == "" {
+		return fmt.Errorf("ipam expiring requires --state")
+	}
+	days, err := parseDays(within)
+	if err != nil {
+		return fmt.Errorf("invalid --within: %v", err)
+	}
+
+	report, err := c.expiryReport(stateFile, days, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, warning := range report.Warnings {
+		c.warnf("%s", warning)
+	}
+
+	var content string
+	switch format {
+	case FormatText:
+		content = c.formatter.FormatExpiring(report)
+	case FormatJSON:
+		content, err = c.formatter.FormatExpiringAsJSON(report)
+	default:
+		return fmt.Errorf("ipam expiring supports %s and %s output, not %s", FormatText, FormatJSON, format)
+	}
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(content, outputFile)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 16, 15, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{"2026-12-31", "2026-12-31"},
+		{"30d", "2026-11-15"},
+		{"2w", "2026-10-30"},
+		{"0d", "2026-10-16"},
+	}
+	for _, tt := range testCases {
+		expires, err := parseExpiry(tt.value, now)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.value, err)
+			continue
+		}
+		if date := expires.Format(expiryDateLayout); date != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.value, tt.expected, date)
+		}
+	}
+
+	for _, value := range []string{"", "d", "30", "30m", "-1d", "2026-13-01"} {
+		if _, err := parseExpiry(value, now); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
+
+func TestCLIHandler_IPAMExpiring(t *testing.T) {
+	handler := NewCLIHandler()
+	var stderr strings.Builder
+	handler.stderr = &stderr
+	dir := t.TempDir()
+
+	state := filepath.Join(dir, "ipam.txt")
+	output := filepath.Join(dir, "out.txt")
+	if err := handler.Run([]string{"cidr-calc", "ipam", "allocate", "--pool", "10.0.0.0/16", "--prefix", "24", "--state", state, "--name", "lab", "--expires", "2026-11-01", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(state); string(content) != "10.0.0.0/24 expires=2026-11-01 name=lab # allocated from 10.0.0.0/16 (first-fit)\n" {
+		t.Errorf("unexpected state %q", content)
+	}
+	if err := handler.Run([]string{"cidr-calc", "ipam", "allocate", "--pool", "10.0.0.0/16", "--prefix", "24", "--expires", "soon"}); err == nil || !strings.Contains(err.Error(), `invalid --expires "soon"`) {
+		t.Errorf("expected an invalid --expires error, got %v", err)
+	}
+
+	os.WriteFile(state, []byte("10.0.0.0/24 name=lab expires=2026-11-01\n"+
+		"10.0.1.0/24 name=poc expires=2026-10-09\n"+
+		"10.0.2.0/24 name=prod\n"+
+		"10.0.3.0/24 expires=2027-06-30\n"+
+		"10.0.4.0/24 expires=someday\n"), 0644)
+
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	report, err := handler.expiryReport(state, 30, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := handler.formatter.FormatExpiring(report)
+	for _, expected := range []string{
+		"Allocations in " + state + " expiring within 30 days:\n",
+		"  10.0.1.0/24          poc              2026-10-09   expired 7 days ago\n  10.0.0.0/24          lab              2026-11-01   in 16 days\n\n",
+		"line 5: invalid expires date \"someday\"",
+		"  allocation 10.0.1.0/24 expired 7 days ago\n",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("expected %q in:\n%s", expected, content)
+		}
+	}
+	if strings.Contains(content, "10.0.2.0/24") || strings.Contains(content, "10.0.3.0/24") {
+		t.Errorf("expected only allocations expiring within the window, got:\n%s", content)
+	}
+
+	encoded, err := handler.formatter.FormatExpiringAsJSON(report)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var document jsonExpiryReport
+	if err := json.Unmarshal([]byte(encoded), &document); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(document.Allocations) != 2 || !document.Allocations[0].Expired || document.Allocations[0].Days != -7 || document.Allocations[1].Days != 16 {
+		t.Errorf("unexpected document: %+v", document)
+	}
+
+	// Expired allocations are warned about on stderr for scheduled jobs
+	stderr.Reset()
+	if err := handler.Run([]string{"cidr-calc", "ipam", "expiring", "--state", state, "--within", "52w", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "expired") {
+		t.Errorf("expected an expiry warning, got:\n%s", stderr.String())
+	}
+
+	errorCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--within", "30d"}, "ipam expiring requires --state"},
+		{[]string{"--state", state, "--within", "soon"}, "invalid --within: invalid period \"soon\""},
+		{[]string{"--state", state, "--format", "csv"}, "ipam expiring supports text and json output, not csv"},
+	}
+	for _, tt := range errorCases {
+		if err := handler.Run(append([]string{"cidr-calc", "ipam", "expiring"}, tt.args...)); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
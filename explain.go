@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+)
+
+// explanationStep is one step of the worked calculation of a network: a
+// title and its lines, which may be indented to line up binary values
+type explanationStep struct {
+	Title string
+	Lines []string
+}
+
+// explainBits writes a value in binary with a bar between the network and
+// host bits, as the binary breakdown does
+func explainBits(value []byte, prefix int) string {
+	network, host := binaryGroups(value, prefix)
+	switch {
+	case host == "":
+		return network
+	case network == "":
+		return host
+	}
+	return network + " | " + host
+}
+
+// explainOperation lines up a bitwise operation of two values and its result,
+// each with its address form and role
+func explainOperation(operator string, prefix int, operands [3][]byte, roles [3]string) []string {
+	lines := make([]string, 3)
+	for i, symbol := range []string{"", operator, "="} {
+		lines[i] = fmt.Sprintf("  %-4s%s  %s (%s)", symbol, explainBits(operands[i], prefix), net.IP(operands[i]), roles[i])
+	}
+	return lines
+}
+
+// explanationSteps works through how the report of a network is calculated:
+// the masks from the prefix, the network ID and broadcast from bitwise AND
+// and OR, the usable range and the number of subnets
+func (f *OutputFormatter) explanationSteps(info *NetworkInfo, subnets []SubnetInfo) []explanationStep {
+	bits := info.MaxPrefix()
+	hostBits := bits - info.PrefixLength
+	address, networkID, broadcast := info.Address, info.NetworkID, info.BroadcastAddr
+	if address == nil {
+		address = networkID
+	}
+	if !info.IsIPv6() {
+		address, networkID, broadcast = address.To4(), networkID.To4(), broadcast.To4()
+	} else {
+		address, networkID, broadcast = address.To16(), networkID.To16(), broadcast.To16()
+	}
+	mask := []byte(net.CIDRMask(info.PrefixLength, bits))
+	wildcard := make([]byte, len(mask))
+	for i := range mask {
+		wildcard[i] = ^mask[i]
+	}
+
+	maskBits := fmt.Sprintf("/%d sets the first %d of %d bits to 1 and the other %d to 0:", info.PrefixLength, info.PrefixLength, bits, hostBits)
+	switch info.PrefixLength {
+	case 0:
+		maskBits = fmt.Sprintf("/0 sets all %d bits to 0:", bits)
+	case bits:
+		maskBits = fmt.Sprintf("/%d sets all %d bits to 1:", bits, bits)
+	}
+	steps := []explanationStep{{
+		Title: "Subnet mask from the prefix",
+		Lines: []string{
+			maskBits,
+			fmt.Sprintf("  %s  %s", explainBits(mask, info.PrefixLength), net.IP(mask)),
+			"The wildcard mask inverts every bit of the mask (NOT):",
+			fmt.Sprintf("  %s  %s", explainBits(wildcard, info.PrefixLength), net.IP(wildcard)),
+		},
+	}}
+
+	networkStep := explanationStep{
+		Title: "Network ID = address AND mask",
+		Lines: explainOperation("AND", info.PrefixLength, [3][]byte{address, mask, networkID}, [3]string{"address", "mask", "network ID"}),
+	}
+	if address.Equal(networkID) {
+		networkStep.Lines = append(networkStep.Lines, "A bit is 1 only where both are; the host bits were already 0, so the address is the network ID.")
+	} else {
+		networkStep.Lines = append(networkStep.Lines, "A bit is 1 only where both are, which clears the host bits of the address.")
+	}
+	steps = append(steps, networkStep)
+
+	broadcastName, broadcastNote := "broadcast", "A bit is 1 where either is, which sets every host bit: the last address of the network."
+	if info.IsIPv6() {
+		broadcastName, broadcastNote = "last address", "Setting every host bit gives the last address; IPv6 has no broadcast address."
+	}
+	steps = append(steps, explanationStep{
+		Title: strings.ToUpper(broadcastName[:1]) + broadcastName[1:] + " = network ID OR wildcard mask",
+		Lines: append(explainOperation("OR", info.PrefixLength, [3][]byte{networkID, wildcard, broadcast}, [3]string{"network ID", "wildcard mask", broadcastName}), broadcastNote),
+	})
+
+	addresses := f.Locale.Integer(new(big.Int).Lsh(big.NewInt(1), uint(hostBits)).String())
+	verb := "give"
+	if hostBits == 1 {
+		verb = "gives"
+	}
+	size := fmt.Sprintf("%s %s 2^%d = %s", plural(hostBits, "host bit"), verb, hostBits, addressCount(addresses))
+	var usable []string
+	switch {
+	case info.IsIPv6():
+		usable = []string{
+			size + ".",
+			"IPv6 reserves no network or broadcast address, so every one is usable:",
+			fmt.Sprintf("  %s to %s", info.FirstUsableIP, info.LastUsableIP),
+		}
+	case info.PrefixLength == 32:
+		usable = []string{"A /32 has no host bits: it is the single host " + info.FirstUsableIP.String() + "."}
+	case info.PrefixLength == 31:
+		usable = []string{
+			size + ".",
+			"A /31 is a point-to-point link (RFC 3021), where both addresses are usable:",
+			fmt.Sprintf("  %s to %s", info.FirstUsableIP, info.LastUsableIP),
+		}
+	default:
+		usable = []string{
+			size + ".",
+			fmt.Sprintf("The network ID and broadcast are reserved, leaving %s - 2 = %s usable hosts:", addresses, f.Locale.Integer(info.HostCount())),
+			fmt.Sprintf("  %s (network ID + 1) to %s (broadcast - 1)", info.FirstUsableIP, info.LastUsableIP),
+		}
+	}
+	steps = append(steps, explanationStep{Title: "Usable range", Lines: usable})
+
+	subnetStep := explanationStep{Title: "Subnet count"}
+	if info.PrefixLength == bits {
+		subnetStep.Lines = []string{noSubnetsMessage(info.PrefixLength) + "."}
+	} else {
+		prefix := listedPrefix(info, subnets)
+		borrowed := prefix - info.PrefixLength
+		count := f.Locale.Integer(new(big.Int).Lsh(big.NewInt(1), uint(borrowed)).String())
+		each := f.Locale.Integer(new(big.Int).Lsh(big.NewInt(1), uint(bits-prefix)).String())
+		subnetStep.Lines = []string{
+			fmt.Sprintf("Splitting the /%d into /%d subnets borrows %d - %d = %s from the host part:", info.PrefixLength, prefix, prefix, info.PrefixLength, plural(borrowed, "bit")),
+			fmt.Sprintf("  2^%d = %s subnets of 2^%d = %s each", borrowed, count, bits-prefix, addressCount(each)),
+			fmt.Sprintf("Subnet n, counting from 0, starts at the network ID + n x %s.", each),
+		}
+	}
+	return append(steps, subnetStep)
+}
+
+// addressCount returns a formatted count of addresses
+func addressCount(count string) string {
+	if count == "1" {
+		return "1 address"
+	}
+	return count + " addresses"
+}
+
+// FormatExplanation formats the worked calculation for console display
+func (f *OutputFormatter) FormatExplanation(info *NetworkInfo, subnets []SubnetInfo) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Explanation (%s):\n", info.CIDR()))
+	for i, step := range f.explanationSteps(info, subnets) {
+		if i > 0 {
+			output.WriteString("\n")
+		}
+		output.WriteString(fmt.Sprintf("  %d. %s\n", i+1, step.Title))
+		for _, line := range step.Lines {
+			output.WriteString("     " + line + "\n")
+		}
+	}
+
+	return output.String()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputFormatter_Explain(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := &OutputFormatter{Explain: true}
+
+	info := mustParseCIDR(t, calculator, "192.168.1.100/26")
+	text := formatter.FormatComplete(info, calculator.CalculateSubnets(info))
+	for _, expected := range []string{
+		"Explanation (192.168.1.64/26):\n  1. Subnet mask from the prefix\n",
+		"       11111111.11111111.11111111.11 | 000000  255.255.255.192\n",
+		"           11000000.10101000.00000001.01 | 100100  192.168.1.100 (address)\n" +
+			"       AND 11111111.11111111.11111111.11 | 000000  255.255.255.192 (mask)\n" +
+			"       =   11000000.10101000.00000001.01 | 000000  192.168.1.64 (network ID)\n" +
+			"     A bit is 1 only where both are, which clears the host bits of the address.\n",
+		"       =   11000000.10101000.00000001.01 | 111111  192.168.1.127 (broadcast)\n",
+		"leaving 64 - 2 = 62 usable hosts:\n       192.168.1.65 (network ID + 1) to 192.168.1.126 (broadcast - 1)\n",
+		"       2^1 = 2 subnets of 2^5 = 32 addresses each\n",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("expected %q in:\n%s", expected, text)
+		}
+	}
+
+	testCases := []struct {
+		cidr     string
+		subnets  []SubnetInfo
+		expected []string
+	}{
+		{"10.0.0.0/31", nil, []string{"A /31 is a point-to-point link (RFC 3021)", "2^1 = 2 subnets of 2^0 = 1 address each"}},
+		{"10.0.0.9/32", nil, []string{"/32 sets all 32 bits to 1:\n       11111111.11111111.11111111.11111111  255.255.255.255\n", "the single host 10.0.0.9.", "cannot subnet /32 networks"}},
+		{"0.0.0.0/0", nil, []string{"/0 sets all 32 bits to 0:\n       00000000.00000000.00000000.00000000  0.0.0.0\n"}},
+		{"2001:db8::/62", nil, []string{"3. Last address = network ID OR wildcard mask", "2001:db8:0:3:ffff:ffff:ffff:ffff (last address)", "66 host bits give 2^66 = 73786976294838206464 addresses.", "IPv6 reserves no network or broadcast address"}},
+		{"10.0.0.0/24", []SubnetInfo{{CIDR: "10.0.0.0/28"}}, []string{"Splitting the /24 into /28 subnets borrows 28 - 24 = 4 bits", "2^4 = 16 subnets of 2^4 = 16 addresses each"}},
+	}
+	for _, tt := range testCases {
+		explanation := formatter.FormatExplanation(mustParseCIDR(t, calculator, tt.cidr), tt.subnets)
+		for _, expected := range tt.expected {
+			if !strings.Contains(explanation, expected) {
+				t.Errorf("%s: expected %q in:\n%s", tt.cidr, expected, explanation)
+			}
+		}
+	}
+
+	// Networks built without an address explain from their network ID
+	network := mustParseCIDR(t, calculator, "10.0.0.0/24")
+	network.Address = nil
+	if explanation := formatter.FormatExplanation(network, nil); !strings.Contains(explanation, "the address is the network ID") {
+		t.Errorf("expected the network ID as the address, got:\n%s", explanation)
+	}
+
+	if plain := (&OutputFormatter{}).FormatComplete(info, nil); strings.Contains(plain, "Explanation") {
+		t.Error("expected no explanation without Explain")
+	}
+}
+
+func TestCLIHandler_Explain(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	output := filepath.Join(t.TempDir(), "report.txt")
+
+	if err := handler.Run([]string{"cidr-calc", "--explain", "--screen-reader", "-o", output, "10.0.0.0/30"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	for _, expected := range []string{
+		"Section 4 of 4: Explanation, 23 lines.\nStep 1: Subnet mask from the prefix\n",
+		"\nAND 11111111.11111111.11111111.111111 | 00 255.255.255.252 (mask)\n",
+		"Step 5: Subnet count\n",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected %q in:\n%s", expected, content)
+		}
+	}
+
+	errorCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--explain", "--format", "json", "10.0.0.0/24"}, "--explain supports text output, not json"},
+		{[]string{"--explain", "--low-memory", "10.0.0.0/24"}, "--explain cannot be combined with --low-memory"},
+	}
+	for _, tt := range errorCases {
+		if err := handler.Run(append([]string{"cidr-calc"}, tt.args...)); err == nil || err.Error() != tt.expected {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expression is a compiled --compute or --filter expression. Values are
+// numbers (float64), strings or booleans; the language has arithmetic
+// (+ - * / %), comparisons, && || ! and parentheses, and + joins strings.
+type Expression struct {
+	source string
+	root   exprNode
+}
+
+// ParseExpression compiles an expression that may refer to the given variables
+func ParseExpression(source string, variables []string) (*Expression, error) {
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %v", source, err)
+	}
+
+	known := make(map[string]bool, len(variables))
+	for _, name := range variables {
+		known[name] = true
+	}
+
+	parser := &exprParser{tokens: tokens, known: known, variables: variables}
+	root, err := parser.parseOr()
+	if err == nil && parser.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %s", parser.peek())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %v", source, err)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+// String returns the expression source
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression with the given variable values
+func (e *Expression) Eval(vars map[string]interface{}) (interface{}, error) {
+	value, err := e.root.eval(vars)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", e.source, err)
+	}
+	return value, nil
+}
+
+// EvalBool evaluates an expression that must yield true or false
+func (e *Expression) EvalBool(vars map[string]interface{}) (bool, error) {
+	value, err := e.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s: expected true or false, got %s", e.source, formatExprValue(value))
+	}
+	return b, nil
+}
+
+// formatExprValue renders a value for output, printing whole numbers without decimals
+func formatExprValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// exprTypeName names a value's type in error messages
+func exprTypeName(value interface{}) string {
+	switch value.(type) {
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	default:
+		return "string"
+	}
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type exprToken struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+}
+
+// String describes the token in error messages
+func (t exprToken) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// exprOperators lists the operators, two-character ones first
+var exprOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "+", "-", "*", "/", "%", "<", ">", "!", "(", ")"}
+
+// tokenizeExpression splits an expression into tokens
+func tokenizeExpression(source string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			number, err := strconv.ParseFloat(string(runes[start:i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", string(runes[start:i]))
+			}
+			tokens = append(tokens, exprToken{kind: tokenNumber, text: string(runes[start:i]), value: number})
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated string starting at offset %d", i)
+			}
+			text := string(runes[i+1 : end])
+			tokens = append(tokens, exprToken{kind: tokenString, text: text, value: text})
+			i = end + 1
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, text: string(runes[start:i])})
+		default:
+			matched := false
+			for _, op := range exprOperators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, exprToken{kind: tokenOperator, text: op})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+		}
+	}
+
+	return append(tokens, exprToken{kind: tokenEOF}), nil
+}
+
+// exprParser is a recursive descent parser, one method per precedence level
+type exprParser struct {
+	tokens    []exprToken
+	pos       int
+	known     map[string]bool
+	variables []string
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+// accept consumes the next token if it is one of the operators
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	token := p.peek()
+	if token.kind != tokenOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if token.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// binaryLevel parses a left-associative chain of operators over next
+func (p *exprParser) binaryLevel(next func() (exprNode, error), ops ...string) (exprNode, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	return p.binaryLevel(p.parseAnd, "||")
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	return p.binaryLevel(p.parseEquality, "&&")
+}
+
+func (p *exprParser) parseEquality() (exprNode, error) {
+	return p.binaryLevel(p.parseComparison, "==", "!=")
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	return p.binaryLevel(p.parseAdditive, "<", "<=", ">", ">=")
+}
+
+func (p *exprParser) parseAdditive() (exprNode, error) {
+	return p.binaryLevel(p.parseMultiplicative, "+", "-")
+}
+
+func (p *exprParser) parseMultiplicative() (exprNode, error) {
+	return p.binaryLevel(p.parseUnary, "*", "/", "%")
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if op, ok := p.accept("-", "!"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	token := p.peek()
+	switch token.kind {
+	case tokenNumber, tokenString:
+		p.pos++
+		return literalNode{value: token.value}, nil
+	case tokenIdent:
+		p.pos++
+		switch token.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		}
+		if !p.known[token.text] {
+			names := append([]string(nil), p.variables...)
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown variable %q (available: %s)", token.text, strings.Join(names, ", "))
+		}
+		return variableNode{name: token.text}, nil
+	}
+
+	if _, ok := p.accept("("); ok {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("expected \")\", got %s", p.peek())
+		}
+		return inner, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %s", token)
+}
+
+// exprNode is a node of the expression tree
+type exprNode interface {
+	eval(vars map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+type variableNode struct {
+	name string
+}
+
+func (n variableNode) eval(vars map[string]interface{}) (interface{}, error) {
+	value, ok := vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("variable %s is not set", n.name)
+	}
+	return value, nil
+}
+
+type unaryNode struct {
+	op      string
+	operand exprNode
+}
+
+func (n unaryNode) eval(vars map[string]interface{}) (interface{}, error) {
+	value, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if n.op == "-" {
+			return -v, nil
+		}
+	case bool:
+		if n.op == "!" {
+			return !v, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot apply %s to a %s", n.op, exprTypeName(value))
+}
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n binaryNode) eval(vars map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// && and || only evaluate the right side when it decides the result
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("cannot apply %s to a %s", n.op, exprTypeName(left))
+		}
+		if (n.op == "&&" && !l) || (n.op == "||" && l) {
+			return l, nil
+		}
+		right, err := n.right.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("cannot apply %s to a %s", n.op, exprTypeName(right))
+		}
+		return r, nil
+	}
+
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	}
+
+	// + joins strings, also when one side is a number
+	if n.op == "+" {
+		_, leftString := left.(string)
+		_, rightString := right.(string)
+		if leftString || rightString {
+			return formatExprValue(left) + formatExprValue(right), nil
+		}
+	}
+
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			switch n.op {
+			case "<":
+				return l < r, nil
+			case "<=":
+				return l <= r, nil
+			case ">":
+				return l > r, nil
+			case ">=":
+				return l >= r, nil
+			}
+		}
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("cannot apply %s to a %s and a %s", n.op, exprTypeName(left), exprTypeName(right))
+	}
+
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/", "%":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if n.op == "/" {
+			return l / r, nil
+		}
+		return math.Mod(l, r), nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	default: // ">="
+		return l >= r, nil
+	}
+}
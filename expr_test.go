@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpression_Eval(t *testing.T) {
+	vars := map[string]interface{}{"index": 3.0, "hosts": 62.0, "cidr": "10.0.0.192/26", "ipv6": false}
+	variables := []string{"index", "hosts", "cidr", "ipv6"}
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{source: "100 + index", want: "103"},
+		{source: "1 + 2 * 3 - 4 / 2", want: "5"},
+		{source: "(1 + 2) * 3", want: "9"},
+		{source: "index % 2", want: "1"},
+		{source: "-index + 1.5", want: "-1.5"},
+		{source: "hosts > 50 && !ipv6", want: "true"},
+		{source: "hosts < 50 || index == 3", want: "true"},
+		{source: "cidr == '10.0.0.192/26'", want: "true"},
+		{source: `"vlan" + (100 + index)`, want: "vlan103"},
+		{source: "cidr + \" (\" + hosts + \")\"", want: "10.0.0.192/26 (62)"},
+		{source: "'b' > 'a'", want: "true"},
+		{source: "index != 'x'", want: "true"},
+		{source: "false && index / 0 > 1", want: "false"},
+	}
+
+	for _, tt := range tests {
+		expr, err := ParseExpression(tt.source, variables)
+		if err != nil {
+			t.Errorf("ParseExpression(%q) error = %v", tt.source, err)
+			continue
+		}
+		value, err := expr.Eval(vars)
+		if err != nil {
+			t.Errorf("Eval(%q) error = %v", tt.source, err)
+			continue
+		}
+		if got := formatExprValue(value); got != tt.want {
+			t.Errorf("Eval(%q) = %s, want %s", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestExpression_Errors(t *testing.T) {
+	vars := map[string]interface{}{"index": 0.0, "cidr": "10.0.0.0/26"}
+	variables := []string{"index", "cidr"}
+
+	parseErrors := []struct {
+		source  string
+		wantErr string
+	}{
+		{source: "", wantErr: "unexpected end of expression"},
+		{source: "vlan + 1", wantErr: `unknown variable "vlan" (available: cidr, index)`},
+		{source: "(1 + 2", wantErr: `expected ")"`},
+		{source: "1 2", wantErr: `unexpected "2"`},
+		{source: "'open", wantErr: "unterminated string"},
+		{source: "index = 1", wantErr: `unexpected character '='`},
+		{source: "1.2.3", wantErr: "invalid number"},
+	}
+	for _, tt := range parseErrors {
+		_, err := ParseExpression(tt.source, variables)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseExpression(%q) error = %v, want %q", tt.source, err, tt.wantErr)
+		}
+	}
+
+	evalErrors := []struct {
+		source  string
+		wantErr string
+	}{
+		{source: "index / 0", wantErr: "division by zero"},
+		{source: "cidr - 1", wantErr: "cannot apply - to a string and a number"},
+		{source: "!index", wantErr: "cannot apply ! to a number"},
+		{source: "index && true", wantErr: "cannot apply && to a number"},
+	}
+	for _, tt := range evalErrors {
+		expr, err := ParseExpression(tt.source, variables)
+		if err != nil {
+			t.Fatalf("ParseExpression(%q) error = %v", tt.source, err)
+		}
+		if _, err := expr.Eval(vars); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Eval(%q) error = %v, want %q", tt.source, err, tt.wantErr)
+		}
+	}
+
+	expr, _ := ParseExpression("index + 1", variables)
+	if _, err := expr.EvalBool(vars); err == nil || !strings.Contains(err.Error(), "expected true or false, got 1") {
+		t.Errorf("EvalBool() error = %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// favoritesFile is the file of the configuration directory keeping the
+// networks bookmarked in the interactive modes
+const favoritesFile = "favorites"
+
+// Favorite is a bookmarked network and its label
+type Favorite struct {
+	CIDR  string
+	Label string
+}
+
+// String returns the favorite as a line of the favorites file
+func (f Favorite) String() string {
+	return strings.TrimSpace(f.CIDR + " " + f.Label)
+}
+
+// Favorites are the bookmarked networks in the order they were added. The
+// file is a plan-style list, one CIDR and its label per line, so it can be
+// edited by hand or passed to -f.
+type Favorites struct {
+	filename string // empty when there is no configuration directory
+	List     []Favorite
+}
+
+// loadFavorites reads the favorites of the configuration directory; a missing
+// file has none
+func (c *CLIHandler) loadFavorites() (*Favorites, error) {
+	if c.configDir == "" {
+		return &Favorites{}, nil
+	}
+	favorites := &Favorites{filename: filepath.Join(c.configDir, favoritesFile)}
+
+	file, err := os.Open(favorites.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return favorites, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read favorites: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			favorites.List = append(favorites.List, Favorite{CIDR: fields[0], Label: strings.Join(fields[1:], " ")})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read favorites: %v", err)
+	}
+	return favorites, nil
+}
+
+// Add bookmarks a network, or relabels it when it already is one, and
+// returns its position from 1
+func (f *Favorites) Add(cidr, label string) int {
+	for i := range f.List {
+		if f.List[i].CIDR == cidr {
+			f.List[i].Label = label
+			return i + 1
+		}
+	}
+	f.List = append(f.List, Favorite{CIDR: cidr, Label: label})
+	return len(f.List)
+}
+
+// Get returns the favorite at a position from 1
+func (f *Favorites) Get(position int) (Favorite, error) {
+	if position < 1 || position > len(f.List) {
+		if len(f.List) == 0 {
+			return Favorite{}, fmt.Errorf("no favorites yet")
+		}
+		return Favorite{}, fmt.Errorf("no favorite %d; there are %d", position, len(f.List))
+	}
+	return f.List[position-1], nil
+}
+
+// Remove deletes the favorite at a position from 1
+func (f *Favorites) Remove(position int) (Favorite, error) {
+	favorite, err := f.Get(position)
+	if err != nil {
+		return Favorite{}, err
+	}
+	f.List = append(f.List[:position-1], f.List[position:]...)
+	return favorite, nil
+}
+
+// Save writes the favorites file, creating the configuration directory
+func (f *Favorites) Save() error {
+	if f.filename == "" {
+		return fmt.Errorf("no configuration directory to keep favorites in; set %s", configDirEnv)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.filename), 0755); err != nil {
+		return fmt.Errorf("failed to create configuration directory: %v", err)
+	}
+
+	var content strings.Builder
+	content.WriteString("# Favorite networks of simple-cidr-calculator\n")
+	for _, favorite := range f.List {
+		content.WriteString(favorite.String() + "\n")
+	}
+	if err := writeFileAtomic(f.filename, []byte(content.String())); err != nil {
+		return fmt.Errorf("failed to save favorites: %v", err)
+	}
+	return nil
+}
+
+// Lines returns the numbered pick list of the favorites
+func (f *Favorites) Lines() []string {
+	width := 0
+	for _, favorite := range f.List {
+		if len(favorite.CIDR) > width {
+			width = len(favorite.CIDR)
+		}
+	}
+	lines := make([]string, len(f.List))
+	for i, favorite := range f.List {
+		lines[i] = strings.TrimRight(fmt.Sprintf("%2d  %-*s  %s", i+1, width, favorite.CIDR, favorite.Label), " ")
+	}
+	return lines
+}
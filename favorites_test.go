@@ -0,0 +1,152 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFavorites(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.configDir = filepath.Join(t.TempDir(), "cidr-calc")
+
+	favorites, err := handler.loadFavorites()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(favorites.List) != 0 {
+		t.Errorf("expected no favorites without a file, got %v", favorites.List)
+	}
+
+	if position := favorites.Add("10.0.0.0/24", "core switches"); position != 1 {
+		t.Errorf("expected position 1, got %d", position)
+	}
+	favorites.Add("192.168.10.0/24", "")
+	if position := favorites.Add("10.0.0.0/24", "core"); position != 1 {
+		t.Errorf("expected a relabeled favorite to keep position 1, got %d", position)
+	}
+	favorites.Add("172.16.0.0/12", "lab")
+	if removed, err := favorites.Remove(2); err != nil || removed.CIDR != "192.168.10.0/24" {
+		t.Errorf("expected to remove 192.168.10.0/24, got %v, %v", removed, err)
+	}
+	if _, err := favorites.Remove(3); err == nil || err.Error() != "no favorite 3; there are 2" {
+		t.Errorf("expected an out of range error, got %v", err)
+	}
+
+	// Saving creates the configuration directory
+	if err := favorites.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(handler.configDir, favoritesFile))
+	if err != nil {
+		t.Fatalf("failed to read favorites: %v", err)
+	}
+	expected := "# Favorite networks of simple-cidr-calculator\n10.0.0.0/24 core\n172.16.0.0/12 lab\n"
+	if string(content) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, content)
+	}
+
+	favorites, err = handler.loadFavorites()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := favorites.Lines()
+	if len(lines) != 2 || lines[0] != " 1  10.0.0.0/24    core" || lines[1] != " 2  172.16.0.0/12  lab" {
+		t.Errorf("unexpected pick list %q", lines)
+	}
+
+	handler.configDir = ""
+	favorites, _ = handler.loadFavorites()
+	favorites.Add("10.0.0.0/24", "")
+	if err := favorites.Save(); err == nil || !strings.Contains(err.Error(), configDirEnv) {
+		t.Errorf("expected an error without a configuration directory, got %v", err)
+	}
+}
+
+func TestCLIHandler_InteractiveFavorites(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	handler.configDir = t.TempDir()
+
+	var out strings.Builder
+	input := "favs\n10.0.0.0/24\nfav core switches\n_1\nfav\nsplit 26 @1\nunfav 2\n@2\n"
+	if err := handler.runInteractive(&Config{}, strings.NewReader(input), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"No favorites yet",
+		"Saved 10.0.0.0/24 as favorite @1",
+		"Saved 10.0.0.128/25 as favorite @2",
+		"10.0.0.192/26",
+		"Removed favorite 10.0.0.128/25",
+		"Error: no favorite 2; there are 1",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+
+	// A session without CIDRs starts with the pick list
+	out.Reset()
+	if err := handler.runInteractive(&Config{}, strings.NewReader("@1\n"), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Favorites (enter @N to show one):\n 1  10.0.0.0/24  core switches\n") ||
+		!strings.Contains(out.String(), "CIDR:           10.0.0.0/24") {
+		t.Errorf("expected the favorites before the first prompt, got:\n%s", out.String())
+	}
+}
+
+func TestTUIModel_Favorites(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.configDir = t.TempDir()
+	model := newTestTUIModel(t, "10.0.0.0/24")
+	model.favorites, _ = handler.loadFavorites()
+
+	model.Update("f")
+	if model.picking || !strings.Contains(model.message, "no favorites yet") {
+		t.Errorf("expected no favorites list, got message %q", model.message)
+	}
+
+	// Bookmark the selected subnet; q is part of the label, not quit
+	for _, key := range []string{"down", "b", "q", "a", "x", "backspace", "enter"} {
+		if model.Update(key) {
+			t.Fatalf("%s should not quit while typing a label", key)
+		}
+	}
+	if model.message != "saved 10.0.0.128/25 as favorite 1" {
+		t.Errorf("unexpected message %q", model.message)
+	}
+	model.Update("b")
+	model.Update("z")
+	model.Update("esc")
+	if saved, _ := handler.loadFavorites(); len(saved.List) != 1 || saved.List[0] != (Favorite{CIDR: "10.0.0.128/25", Label: "qa"}) {
+		t.Errorf("unexpected favorites %v", saved.List)
+	}
+
+	model.Update("f")
+	screen := model.Render(80, 16)
+	for _, expected := range []string{"── Favorites (1) ", "▸ 1  10.0.0.128/25  qa", tuiFavoritesHelp} {
+		if !strings.Contains(screen, expected) {
+			t.Errorf("expected screen to contain %q, got:\n%s", expected, screen)
+		}
+	}
+
+	// Opening a favorite pushes it, so ← returns
+	model.Update("enter")
+	if model.picking || model.current().info.CIDR() != "10.0.0.128/25" || len(model.views) != 2 {
+		t.Errorf("expected to open 10.0.0.128/25, got %s", model.current().info.CIDR())
+	}
+	model.Update("left")
+	if model.current().info.CIDR() != "10.0.0.0/24" {
+		t.Errorf("expected to return to 10.0.0.0/24, got %s", model.current().info.CIDR())
+	}
+
+	model.Update("f")
+	model.Update("d")
+	if model.picking || len(model.favorites.List) != 0 || model.message != "removed favorite 10.0.0.128/25" {
+		t.Errorf("expected the last favorite to be deleted, got %v (%q)", model.favorites.List, model.message)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FindingSeverity ranks how serious a lint or audit finding is
+type FindingSeverity string
+
+// Finding severities, named after the GitHub workflow commands they map to
+const (
+	SeverityError   FindingSeverity = "error"
+	SeverityWarning FindingSeverity = "warning"
+	SeverityNotice  FindingSeverity = "notice"
+)
+
+// FormatGitHubAnnotations prints findings as GitHub Actions workflow commands
+const FormatGitHubAnnotations = "gh-annotations"
+
+// Finding is a single problem discovered by a lint or audit mode
+type Finding struct {
+	Severity FindingSeverity
+	Rule     string
+	Message  string
+	File     string
+	Line     int
+	CIDR     string
+}
+
+// Location returns the file:line position of the finding, if known
+func (f Finding) Location() string {
+	if f.File == "" {
+		return ""
+	}
+	if f.Line == 0 {
+		return f.File
+	}
+	return fmt.Sprintf("%s:%d", f.File, f.Line)
+}
+
+// countFindings returns the number of findings with the given severity
+func countFindings(findings []Finding, severity FindingSeverity) int {
+	count := 0
+	for _, finding := range findings {
+		if finding.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// RenderFindings formats findings as text or GitHub annotations
+func (f *OutputFormatter) RenderFindings(format string, findings []Finding) (string, error) {
+	switch format {
+	case "", FormatText:
+		return f.FormatFindings(findings), nil
+	case FormatGitHubAnnotations:
+		return f.FormatFindingsAsGitHub(findings), nil
+	default:
+		return "", fmt.Errorf("unsupported findings format: %s (supported: %s, %s)", format, FormatText, FormatGitHubAnnotations)
+	}
+}
+
+// FormatFindings renders findings as an aligned text list with a summary line
+func (f *OutputFormatter) FormatFindings(findings []Finding) string {
+	var output strings.Builder
+
+	output.WriteString("Findings:\n")
+	if len(findings) == 0 {
+		output.WriteString("  No problems found\n")
+		return output.String()
+	}
+
+	for _, finding := range findings {
+		output.WriteString(fmt.Sprintf("  %-8s %-22s %s [%s]\n", finding.Severity, finding.Location(), finding.Message, finding.Rule))
+	}
+
+	output.WriteString(fmt.Sprintf("\nSummary: %s, %s, %s\n",
+		plural(countFindings(findings, SeverityError), "error"), plural(countFindings(findings, SeverityWarning), "warning"), plural(countFindings(findings, SeverityNotice), "notice")))
+
+	return output.String()
+}
+
+// FormatFindingsAsGitHub renders findings as ::error/::warning/::notice workflow commands
+// so they appear inline on pull requests
+func (f *OutputFormatter) FormatFindingsAsGitHub(findings []Finding) string {
+	var output strings.Builder
+
+	for _, finding := range findings {
+		var properties []string
+		if finding.File != "" {
+			properties = append(properties, "file="+escapeGitHubProperty(finding.File))
+			if finding.Line > 0 {
+				properties = append(properties, fmt.Sprintf("line=%d", finding.Line))
+			}
+		}
+		properties = append(properties, "title="+escapeGitHubProperty(finding.Rule))
+
+		output.WriteString(fmt.Sprintf("::%s %s::%s\n", finding.Severity, strings.Join(properties, ","), escapeGitHubData(finding.Message)))
+	}
+
+	return output.String()
+}
+
+// escapeGitHubData escapes a workflow command message
+func escapeGitHubData(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
+
+// escapeGitHubProperty escapes a workflow command property value
+func escapeGitHubProperty(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(value)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutputFormatter_FormatFindings(t *testing.T) {
+	formatter := NewOutputFormatter()
+
+	empty := formatter.FormatFindings(nil)
+	if !strings.Contains(empty, "No problems found") {
+		t.Errorf("expected empty findings message, got %q", empty)
+	}
+
+	findings := []Finding{
+		{Severity: SeverityError, Rule: RuleOverlap, Message: "10.0.5.0/24 overlaps 10.0.0.0/16", File: "prod.txt", Line: 2},
+		{Severity: SeverityWarning, Rule: RuleDuplicate, Message: "10.0.0.0/16 is already listed", File: "prod.txt"},
+	}
+
+	output := formatter.FormatFindings(findings)
+	expected := []string{
+		"error    prod.txt:2",
+		"10.0.5.0/24 overlaps 10.0.0.0/16 [overlap]",
+		"warning  prod.txt ",
+		"Summary: 1 error, 1 warning, 0 notices",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(output, exp) {
+			t.Errorf("expected output to contain %q, got:\n%s", exp, output)
+		}
+	}
+}
+
+func TestOutputFormatter_FormatFindingsAsGitHub(t *testing.T) {
+	formatter := NewOutputFormatter()
+
+	tests := []struct {
+		name    string
+		finding Finding
+		expect  string
+	}{
+		{
+			name:    "file and line",
+			finding: Finding{Severity: SeverityError, Rule: RuleOverlap, Message: "overlaps 10.0.0.0/16", File: "plans/prod.txt", Line: 2},
+			expect:  "::error file=plans/prod.txt,line=2,title=overlap::overlaps 10.0.0.0/16\n",
+		},
+		{
+			name:    "file only",
+			finding: Finding{Severity: SeverityWarning, Rule: RuleDuplicate, Message: "duplicate", File: "prod.txt"},
+			expect:  "::warning file=prod.txt,title=duplicate::duplicate\n",
+		},
+		{
+			name:    "no location",
+			finding: Finding{Severity: SeverityNotice, Rule: "info", Message: "note"},
+			expect:  "::notice title=info::note\n",
+		},
+		{
+			name:    "escaping",
+			finding: Finding{Severity: SeverityError, Rule: "r", Message: "100%\nsure", File: "a,b:c.txt", Line: 1},
+			expect:  "::error file=a%2Cb%3Ac.txt,line=1,title=r::100%25%0Asure\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := formatter.FormatFindingsAsGitHub([]Finding{tt.finding})
+			if output != tt.expect {
+				t.Errorf("expected %q, got %q", tt.expect, output)
+			}
+		})
+	}
+}
+
+func TestOutputFormatter_RenderFindings(t *testing.T) {
+	formatter := NewOutputFormatter()
+
+	for _, format := range []string{"", FormatText, FormatGitHubAnnotations} {
+		if _, err := formatter.RenderFindings(format, nil); err != nil {
+			t.Errorf("format %q: unexpected error: %v", format, err)
+		}
+	}
+
+	if _, err := formatter.RenderFindings(FormatHTML, nil); err == nil {
+		t.Errorf("expected error for unsupported findings format")
+	}
+}
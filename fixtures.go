@@ -0,0 +1,187 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+// fixtureSpace is an address block fixtures are drawn from, with the prefix
+// lengths used in it. Weights are relative frequencies.
+type fixtureSpace struct {
+	network  string
+	weight   int
+	prefixes []fixturePrefix
+}
+
+// fixturePrefix is a prefix length and how often it is picked
+type fixturePrefix struct {
+	length int
+	weight int
+}
+
+// ipv4FixturePrefixes favours the /24s, /16s and small point-to-point and
+// service blocks common in real plans
+var ipv4FixturePrefixes = []fixturePrefix{
+	{16, 6}, {20, 6}, {22, 8}, {23, 6}, {24, 30}, {25, 6}, {26, 8}, {27, 6}, {28, 6}, {29, 4}, {30, 6}, {32, 8},
+}
+
+// ipv6FixturePrefixes favours site /48s and LAN /64s
+var ipv6FixturePrefixes = []fixturePrefix{
+	{48, 20}, {56, 20}, {64, 50}, {127, 5}, {128, 5},
+}
+
+// ipv4FixtureSpaces are mostly RFC 1918 space, with some public space
+var ipv4FixtureSpaces = []fixtureSpace{
+	{network: "10.0.0.0/8", weight: 50, prefixes: ipv4FixturePrefixes},
+	{network: "172.16.0.0/12", weight: 15, prefixes: ipv4FixturePrefixes},
+	{network: "192.168.0.0/16", weight: 20, prefixes: ipv4FixturePrefixes},
+	{network: "0.0.0.0/0", weight: 15, prefixes: ipv4FixturePrefixes},
+}
+
+// ipv6FixtureSpaces are unique local and documentation space
+var ipv6FixtureSpaces = []fixtureSpace{
+	{network: "fd00::/8", weight: 70, prefixes: ipv6FixturePrefixes},
+	{network: "2001:db8::/32", weight: 30, prefixes: ipv6FixturePrefixes},
+}
+
+// fixtureTags are the tag values of generated plan lines
+var fixtureTags = []struct {
+	key    string
+	values []string
+}{
+	{"env", []string{"prod", "staging", "dev", "test"}},
+	{"team", []string{"web", "payments", "data", "platform", "ops"}},
+	{"site", []string{"fra1", "ams2", "nyc3", "sin1"}},
+}
+
+// FixtureOptions are the settings of a fixture run
+type FixtureOptions struct {
+	Count int   // number of CIDRs
+	Seed  int64 // the same seed generates the same fixtures
+	IPv6  int   // percentage of IPv6 networks
+	Plan  bool  // add tags and comments to each line
+}
+
+// pickWeight returns the index of an entry picked in proportion to the weights
+func pickWeight(random *rand.Rand, weights []int) int {
+	total := 0
+	for _, weight := range weights {
+		total += weight
+	}
+	choice := random.Intn(total)
+	for i, weight := range weights {
+		if choice < weight {
+			return i
+		}
+		choice -= weight
+	}
+	return len(weights) - 1
+}
+
+// fixtureNetwork returns a random network of the space at one of its prefix
+// lengths that fits in it
+func (c *CIDRCalculator) fixtureNetwork(random *rand.Rand, space fixtureSpace) (*NetworkInfo, error) {
+	parent, err := c.ParseCIDR(space.network)
+	if err != nil {
+		return nil, err
+	}
+
+	var prefixes []fixturePrefix
+	var weights []int
+	for _, prefix := range space.prefixes {
+		if prefix.length >= parent.PrefixLength {
+			prefixes = append(prefixes, prefix)
+			weights = append(weights, prefix.weight)
+		}
+	}
+	length := prefixes[pickWeight(random, weights)].length
+
+	width := len(addressBytes(parent.NetworkID))
+	address := new(big.Int).SetBytes(addressBytes(parent.NetworkID))
+	offset := new(big.Int).Rand(random, new(big.Int).Lsh(big.NewInt(1), uint(width*8-parent.PrefixLength)))
+	ip := make(net.IP, width)
+	address.Add(address, offset).FillBytes(ip)
+	return c.ParseCIDR(fmt.Sprintf("%s/%d", ip, length))
+}
+
+// GenerateFixtures returns random plan lines. CIDRs are weighted towards RFC
+// 1918 space and common prefix lengths; the same options always give the same
+// lines.
+func (c *CIDRCalculator) GenerateFixtures(options FixtureOptions) ([]string, error) {
+	random := rand.New(rand.NewSource(options.Seed))
+	lines := make([]string, 0, options.Count)
+
+	for i := 0; i < options.Count; i++ {
+		spaces := ipv4FixtureSpaces
+		if random.Intn(100) < options.IPv6 {
+			spaces = ipv6FixtureSpaces
+		}
+		weights := make([]int, len(spaces))
+		for j, space := range spaces {
+			weights[j] = space.weight
+		}
+		network, err := c.fixtureNetwork(random, spaces[pickWeight(random, weights)])
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate a network: %v", err)
+		}
+
+		if !options.Plan {
+			lines = append(lines, network.CIDR())
+			continue
+		}
+		line := fmt.Sprintf("%-20s", network.CIDR())
+		for _, tag := range fixtureTags {
+			line += fmt.Sprintf(" %s=%s", tag.key, tag.values[random.Intn(len(tag.values))])
+		}
+		lines = append(lines, fmt.Sprintf("%s # fixture %d", line, i+1))
+	}
+	return lines, nil
+}
+
+// runGenFixtures implements the gen-fixtures subcommand
+func (c *CLIHandler) runGenFixtures(args []string) error {
+	flagSet := flag.NewFlagSet("gen-fixtures", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	options := FixtureOptions{}
+	var outputFile string
+	flagSet.IntVar(&options.Count, "count", 100, "Number of CIDRs to generate")
+	flagSet.Int64Var(&options.Seed, "seed", 0, "Seed of the random CIDRs (default: time based)")
+	flagSet.IntVar(&options.IPv6, "ipv6", 10, "Percentage of IPv6 networks")
+	flagSet.BoolVar(&options.Plan, "plan", false, "Add tags and comments, as in a plan file")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if flagSet.NArg() > 0 {
+		return fmt.Errorf("gen-fixtures takes no arguments, got %s", strings.Join(flagSet.Args(), " "))
+	}
+	if options.Count < 1 {
+		return fmt.Errorf("--count must be at least 1, got %d", options.Count)
+	}
+	if options.IPv6 < 0 || options.IPv6 > 100 {
+		return fmt.Errorf("--ipv6 must be a percentage between 0 and 100, got %d", options.IPv6)
+	}
+
+	if !flagWasSet(flagSet, "seed") {
+		options.Seed = time.Now().UnixNano()
+	}
+
+	lines, err := c.calculator.GenerateFixtures(options)
+	if err != nil {
+		return err
+	}
+
+	// The header records how to regenerate the file; comments are skipped by -f
+	header := fmt.Sprintf("# gen-fixtures --count %d --seed %d --ipv6 %d", options.Count, options.Seed, options.IPv6)
+	if options.Plan {
+		header += " --plan"
+	}
+	return c.writeOutput(header+"\n"+strings.Join(lines, "\n")+"\n", outputFile)
+}
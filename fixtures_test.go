@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIDRCalculator_GenerateFixtures(t *testing.T) {
+	calculator := NewCIDRCalculator()
+
+	lines, err := calculator.GenerateFixtures(FixtureOptions{Count: 2000, Seed: 42, IPv6: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 2000 {
+		t.Fatalf("expected 2000 lines, got %d", len(lines))
+	}
+
+	private := mustParseCIDR(t, calculator, "10.0.0.0/8")
+	var ipv6, inTen, slash24 int
+	for _, line := range lines {
+		network := mustParseCIDR(t, calculator, line)
+		if network.CIDR() != line {
+			t.Fatalf("expected canonical CIDRs, got %s", line)
+		}
+		switch {
+		case network.IsIPv6():
+			ipv6++
+		case private.Contains(network):
+			inTen++
+		}
+		if network.PrefixLength == 24 {
+			slash24++
+		}
+	}
+	// Loose bounds on the weights: about 10% IPv6, 45% in 10/8, 27% /24s
+	if ipv6 < 100 || ipv6 > 300 || inTen < 700 || slash24 < 350 {
+		t.Errorf("expected weighted fixtures, got %d IPv6, %d in 10/8 and %d /24s of 2000", ipv6, inTen, slash24)
+	}
+
+	again, _ := calculator.GenerateFixtures(FixtureOptions{Count: 2000, Seed: 42, IPv6: 10})
+	if strings.Join(again, "\n") != strings.Join(lines, "\n") {
+		t.Error("expected the same seed to generate the same fixtures")
+	}
+
+	plan, err := calculator.GenerateFixtures(FixtureOptions{Count: 5, Seed: 1, Plan: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, err := parseBatch("fixtures", strings.NewReader(strings.Join(plan, "\n")))
+	if err != nil || len(entries) != 5 {
+		t.Fatalf("expected 5 plan entries, got %v, %v", entries, err)
+	}
+	if entries[4].Tags["env"] == "" || entries[4].Tags["team"] == "" || entries[4].Comment != "fixture 5" {
+		t.Errorf("expected tags and a comment, got %+v", entries[4])
+	}
+}
+
+func TestCLIHandler_GenFixtures(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+
+	output := filepath.Join(t.TempDir(), "fixtures.txt")
+	if err := handler.Run([]string{"cidr-calc", "gen-fixtures", "--count", "25", "--seed", "42", "--ipv6", "0", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	if !strings.HasPrefix(string(content), "# gen-fixtures --count 25 --seed 42 --ipv6 0\n") {
+		t.Errorf("expected a header recording the options, got:\n%s", content)
+	}
+	entries, err := NewBatchReader(defaultFetchTimeout, nil).Read(output)
+	if err != nil || len(entries) != 25 {
+		t.Fatalf("expected the output to read back as 25 entries, got %d, %v", len(entries), err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.CIDR, ":") {
+			t.Errorf("expected no IPv6 networks with --ipv6 0, got %s", entry.CIDR)
+		}
+	}
+
+	for _, args := range [][]string{{"gen-fixtures", "--count", "0"}, {"gen-fixtures", "--ipv6", "101"}, {"gen-fixtures", "extra"}} {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
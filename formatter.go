@@ -3,13 +3,94 @@ package main
 import (
 	"fmt"
 	"html/template"
+	"math/big"
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
+// Supported output format names
+const (
+	FormatText  = "text"
+	FormatHTML  = "html"
+	FormatSlack = "slack"
+	FormatTeams = "teams"
+	FormatOrg   = "org"
+	FormatRST   = "rst"
+	FormatLaTeX = "latex"
+	FormatCSV   = "csv"
+	FormatMD    = "md"
+	FormatXML   = "xml"
+	FormatJSON  = "json"
+	FormatSVG   = "svg"
+)
+
+// SupportedFormats lists every output format accepted by --format
+var SupportedFormats = []string{FormatText, FormatHTML, FormatSlack, FormatTeams, FormatOrg, FormatRST, FormatLaTeX, FormatCSV, FormatMD, FormatXML, FormatJSON, FormatSVG}
+
+// formatExtensions maps file extensions to the output format they imply
+var formatExtensions = map[string]string{
+	".txt":      FormatText,
+	".text":     FormatText,
+	".html":     FormatHTML,
+	".htm":      FormatHTML,
+	".org":      FormatOrg,
+	".rst":      FormatRST,
+	".tex":      FormatLaTeX,
+	".csv":      FormatCSV,
+	".md":       FormatMD,
+	".markdown": FormatMD,
+	".xml":      FormatXML,
+	".json":     FormatJSON,
+	".svg":      FormatSVG,
+}
+
+// FormatForExtension returns the output format implied by a filename's extension,
+// or an empty string when the extension does not identify a single format
+func FormatForExtension(filename string) string {
+	return formatExtensions[strings.ToLower(filepath.Ext(filename))]
+}
+
 // OutputFormatter handles formatting of network information for console output
-type OutputFormatter struct{}
+type OutputFormatter struct {
+	// Binary adds the bit breakdown of the address and masks to text and
+	// HTML reports
+	Binary bool
+	// Numeric adds the addresses as decimal and hex integers to text and
+	// HTML reports
+	Numeric bool
+	// Classful adds the legacy address class and default mask to text and
+	// HTML reports
+	Classful bool
+	// Explain adds the worked calculation of each network to text reports
+	Explain bool
+	// HTMLFonts and HTMLLogo are embedded in HTML reports as data: URIs
+	HTMLFonts []HTMLFont
+	HTMLLogo  string
+	// HTMLTemplate replaces the built-in HTML template when it is set
+	HTMLTemplate *template.Template
+	// ClassRules classify the networks of every report; nil uses the
+	// special-purpose registries alone
+	ClassRules *ClassRules
+	// UsedRanges are drawn as a utilization bar in the HTML report of every
+	// network; nil leaves the bar out
+	UsedRanges *UsedRanges
+	// MinifyHTML drops the indentation and blank lines of HTML reports
+	MinifyHTML bool
+	// Theme restyles HTML reports; empty keeps the light stylesheet
+	Theme string
+	// Locale writes the host and subnet counts of text, HTML and document
+	// reports; the zero value keeps plain numbers
+	Locale Locale
+	// ScreenReader writes text reports as announced sections of
+	// "label: value" lines instead of padded columns
+	ScreenReader bool
+	// BatchErrors are the batch entries left out of the report because they
+	// failed; every format lists them after the networks
+	BatchErrors []BatchError
+}
 
 // NewOutputFormatter creates a new output formatter instance
 func NewOutputFormatter() *OutputFormatter {
@@ -22,29 +103,28 @@ func (f *OutputFormatter) FormatNetworkInfo(info *NetworkInfo) string {
 
 	// Network Information Section
 	output.WriteString("Network Information:\n")
-	output.WriteString(fmt.Sprintf("  %-15s %s\n", "CIDR:", fmt.Sprintf("%s/%d", info.NetworkID.String(), info.PrefixLength)))
-	output.WriteString(fmt.Sprintf("  %-15s %s\n", "Network ID:", info.NetworkID.String()))
-	output.WriteString(fmt.Sprintf("  %-15s %s\n", "Broadcast:", info.BroadcastAddr.String()))
-	output.WriteString(fmt.Sprintf("  %-15s %s\n", "Subnet Mask:", f.formatIPMask(info.SubnetMask)))
-	output.WriteString(fmt.Sprintf("  %-15s %s\n", "Wildcard Mask:", f.formatIPMask(info.WildcardMask)))
+	for _, fact := range f.networkFacts(info) {
+		output.WriteString(fmt.Sprintf("  %-15s %s\n", fact.Label+":", fact.Value))
+	}
 	output.WriteString("\n")
 
 	// Host Information Section
 	output.WriteString("Host Information:\n")
+	for _, fact := range f.hostFacts(info) {
+		output.WriteString(fmt.Sprintf("  %-15s %s\n", fact.Label+":", fact.Value))
+	}
 
-	// Handle edge cases for /31 and /32 networks
-	switch info.PrefixLength {
-	case 32:
-		output.WriteString(fmt.Sprintf("  %-15s %s (single host)\n", "Host Address:", info.FirstUsableIP.String()))
-		output.WriteString(fmt.Sprintf("  %-15s %d\n", "Total Hosts:", info.TotalHosts))
-	case 31:
-		output.WriteString(fmt.Sprintf("  %-15s %s (point-to-point)\n", "First Address:", info.FirstUsableIP.String()))
-		output.WriteString(fmt.Sprintf("  %-15s %s (point-to-point)\n", "Second Address:", info.LastUsableIP.String()))
-		output.WriteString(fmt.Sprintf("  %-15s %d\n", "Total Hosts:", info.TotalHosts))
-	default:
-		output.WriteString(fmt.Sprintf("  %-15s %s\n", "First Usable:", info.FirstUsableIP.String()))
-		output.WriteString(fmt.Sprintf("  %-15s %s\n", "Last Usable:", info.LastUsableIP.String()))
-		output.WriteString(fmt.Sprintf("  %-15s %d\n", "Total Hosts:", info.TotalHosts))
+	if f.Binary {
+		output.WriteString("\n")
+		output.WriteString(f.FormatBinary(info))
+	}
+	if f.Numeric {
+		output.WriteString("\n")
+		output.WriteString(f.FormatNumeric(info))
+	}
+	if f.Classful {
+		output.WriteString("\n")
+		output.WriteString(f.FormatClassful(info))
 	}
 
 	return output.String()
@@ -53,29 +133,41 @@ func (f *OutputFormatter) FormatNetworkInfo(info *NetworkInfo) string {
 // FormatSubnets formats subnet information for console display
 func (f *OutputFormatter) FormatSubnets(subnets []SubnetInfo, originalPrefix int) string {
 	if len(subnets) == 0 {
-		return "Subnet Information:\n  No subnets available (cannot subnet /32 networks)\n"
+		return "Subnet Information:\n  " + noSubnetsMessage(originalPrefix) + "\n"
 	}
 
 	var output strings.Builder
-	nextPrefix := originalPrefix + 1
+	nextPrefix := subnetPrefix(subnets[0])
 
 	// Subnet Information Header
 	output.WriteString("Subnet Information:\n")
-	output.WriteString(fmt.Sprintf("  Possible /%d Subnets: %d\n", nextPrefix, len(subnets)))
+	output.WriteString(fmt.Sprintf("  Possible /%d Subnets: %s\n", nextPrefix, f.Locale.Integer(subnetTotal(originalPrefix, subnets).String())))
 
-	// Add note for limited display if applicable
-	if originalPrefix <= 16 && len(subnets) == 100 {
-		output.WriteString("  (Showing first 100 subnets for performance)\n")
+	// Say how many are listed when the list is partial
+	if note := shownNote(originalPrefix, subnets); note != "" {
+		output.WriteString("  (" + note + ")\n")
 	}
 
 	output.WriteString("\n")
 	output.WriteString("  Subnet List:\n")
 
+	// IPv6 CIDRs are longer than the IPv4 column
+	width := 18
+	for _, subnet := range subnets {
+		if len(subnet.CIDR) >= width {
+			width = len(subnet.CIDR) + 1
+		}
+	}
+
 	// Format each subnet with consistent alignment
 	for _, subnet := range subnets {
 		// Calculate the range for display
 		rangeStr := f.formatSubnetRange(subnet)
-		output.WriteString(fmt.Sprintf("    %-18s %s\n", subnet.CIDR, rangeStr))
+		output.WriteString(fmt.Sprintf("    %-*s %s", width, subnet.CIDR, rangeStr))
+		for _, field := range subnet.Computed {
+			output.WriteString(fmt.Sprintf("  %s=%s", field.Name, field.Value))
+		}
+		output.WriteString("\n")
 	}
 
 	return output.String()
@@ -83,6 +175,10 @@ func (f *OutputFormatter) FormatSubnets(subnets []SubnetInfo, originalPrefix int
 
 // FormatComplete formats both network information and subnets together
 func (f *OutputFormatter) FormatComplete(info *NetworkInfo, subnets []SubnetInfo) string {
+	if f.ScreenReader {
+		return f.FormatScreenReader(info, subnets)
+	}
+
 	var output strings.Builder
 
 	// Add network information
@@ -92,9 +188,264 @@ func (f *OutputFormatter) FormatComplete(info *NetworkInfo, subnets []SubnetInfo
 	// Add subnet information
 	output.WriteString(f.FormatSubnets(subnets, info.PrefixLength))
 
+	if f.Explain {
+		output.WriteString("\n")
+		output.WriteString(f.FormatExplanation(info, subnets))
+	}
+
 	return output.String()
 }
 
+// Render formats network information and subnets in the named output format
+func (f *OutputFormatter) Render(format string, info *NetworkInfo, subnets []SubnetInfo) (string, error) {
+	switch format {
+	case FormatText:
+		return f.FormatComplete(info, subnets), nil
+	case FormatHTML:
+		return f.renderHTML([]NetworkReport{{Info: info, Subnets: subnets}})
+	case FormatSlack:
+		return f.FormatAsSlack(info, subnets)
+	case FormatTeams:
+		return f.FormatAsTeams(info, subnets)
+	case FormatOrg:
+		return f.FormatAsOrg(info, subnets), nil
+	case FormatRST:
+		return f.FormatAsRST(info, subnets), nil
+	case FormatLaTeX:
+		return f.FormatAsLaTeX(info, subnets), nil
+	case FormatCSV:
+		return f.FormatAsCSV(info, subnets)
+	case FormatMD:
+		return f.FormatAsMarkdown(info, subnets), nil
+	case FormatXML:
+		return f.FormatAsXML(info, subnets)
+	case FormatJSON:
+		return f.FormatAsJSON(info, subnets)
+	case FormatSVG:
+		return f.FormatReportsAsSVG([]NetworkReport{{Info: info, Subnets: subnets}}), nil
+	default:
+		return "", fmt.Errorf("unsupported output format: %s (supported: %s)", format, strings.Join(SupportedFormats, ", "))
+	}
+}
+
+// RenderReports formats one or more networks as a single document in the named output format
+func (f *OutputFormatter) RenderReports(format string, reports []NetworkReport) (string, error) {
+	switch format {
+	case FormatHTML:
+		return f.renderHTML(reports)
+	case FormatSlack:
+		return f.FormatReportsAsSlack(reports)
+	case FormatTeams:
+		return f.FormatReportsAsTeams(reports)
+	case FormatCSV:
+		return f.FormatReportsAsCSV(reports)
+	case FormatXML:
+		return f.FormatReportsAsXML(reports)
+	case FormatJSON:
+		return f.FormatReportsAsJSON(reports)
+	case FormatSVG:
+		return f.FormatReportsAsSVG(reports), nil
+	}
+	if len(reports) == 1 && len(f.BatchErrors) == 0 {
+		return f.Render(format, reports[0].Info, reports[0].Subnets)
+	}
+
+	// Document formats stack one complete report per network
+	sections := make([]string, 0, len(reports))
+	for _, report := range reports {
+		section, err := f.Render(format, report.Info, report.Subnets)
+		if err != nil {
+			return "", err
+		}
+		sections = append(sections, section)
+	}
+
+	return strings.Join(sections, "\n") + f.FormatBatchErrors(format), nil
+}
+
+// IsSupportedFormat reports whether the format name is known to Render
+func IsSupportedFormat(format string) bool {
+	for _, supported := range SupportedFormats {
+		if format == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// reportFact is a single label/value pair of the brief network summary
+type reportFact struct {
+	Label string
+	Value string
+}
+
+// briefFacts returns the key network and host details used by compact report formats
+func (f *OutputFormatter) briefFacts(info *NetworkInfo) []reportFact {
+	return append(f.networkFacts(info), f.hostFacts(info)...)
+}
+
+// networkFacts returns the rows of the Network Information section.
+// IPv6 has no broadcast address or dotted masks, so it reports the last address
+// and prefix length instead.
+func (f *OutputFormatter) networkFacts(info *NetworkInfo) []reportFact {
+	var facts []reportFact
+	if info.IsIPv6() {
+		facts = []reportFact{
+			{"CIDR", info.CIDR()},
+			{"Network ID", info.NetworkID.String()},
+			{"Last Address", info.BroadcastAddr.String()},
+			{"Prefix Length", fmt.Sprintf("/%d", info.PrefixLength)},
+		}
+	} else {
+		facts = []reportFact{
+			{"CIDR", fmt.Sprintf("%s/%d", info.NetworkID.String(), info.PrefixLength)},
+			{"Network ID", info.NetworkID.String()},
+			{"Broadcast", info.BroadcastAddr.String()},
+			{"Subnet Mask", f.formatIPMask(info.SubnetMask)},
+			{"Wildcard Mask", f.formatIPMask(info.WildcardMask)},
+		}
+	}
+
+	facts = append(facts, reportFact{"Classification", f.classify(info).String()})
+	if info.Alias != "" {
+		facts = append(facts, reportFact{"Alias", info.Alias})
+	}
+	if len(info.Tags) > 0 {
+		facts = append(facts, reportFact{"Tags", info.Tags.String()})
+	}
+	if info.Derivation != nil {
+		facts = append(facts, reportFact{"Derived From", info.Derivation.String()})
+	}
+	return facts
+}
+
+// hostFacts returns the rows of the Host Information section
+func (f *OutputFormatter) hostFacts(info *NetworkInfo) []reportFact {
+	if info.IsIPv6() {
+		if info.PrefixLength == 128 {
+			return []reportFact{
+				{"Host Address", info.FirstUsableIP.String() + " (single host)"},
+				{"Addresses", f.Locale.Integer(info.HostCount())},
+			}
+		}
+		return []reportFact{
+			{"First Address", info.FirstUsableIP.String()},
+			{"Last Address", info.LastUsableIP.String()},
+			{"Addresses", f.Locale.Integer(info.HostCount())},
+		}
+	}
+
+	var facts []reportFact
+
+	// Handle edge cases for /31 and /32 networks
+	switch info.PrefixLength {
+	case 32:
+		facts = append(facts, reportFact{"Host Address", info.FirstUsableIP.String() + " (single host)"})
+	case 31:
+		facts = append(facts,
+			reportFact{"First Address", info.FirstUsableIP.String() + " (point-to-point)"},
+			reportFact{"Second Address", info.LastUsableIP.String() + " (point-to-point)"})
+	default:
+		facts = append(facts,
+			reportFact{"First Usable", info.FirstUsableIP.String()},
+			reportFact{"Last Usable", info.LastUsableIP.String()})
+	}
+
+	return append(facts, reportFact{"Total Hosts", f.Locale.Integer(info.HostCount())})
+}
+
+// subnetHeaders returns the subnet table column headings for the network's
+// address family, followed by the computed fields of the subnets
+func subnetHeaders(info *NetworkInfo, subnets []SubnetInfo) []string {
+	headers := subnetTableHeaders
+	if info.IsIPv6() {
+		headers = []string{"Subnet", "First Address", "Last Address"}
+	}
+	if len(subnets) == 0 || len(subnets[0].Computed) == 0 {
+		return headers
+	}
+	headers = append([]string(nil), headers...)
+	for _, field := range subnets[0].Computed {
+		headers = append(headers, field.Name)
+	}
+	return headers
+}
+
+// subnetPrefix returns the prefix length of a subnet's CIDR
+func subnetPrefix(subnet SubnetInfo) int {
+	_, ipNet, err := net.ParseCIDR(subnet.CIDR)
+	if err != nil {
+		return 0
+	}
+	prefix, _ := ipNet.Mask.Size()
+	return prefix
+}
+
+// listedPrefix returns the prefix length of the listed subnets, which is the
+// --split target when one was given and the next prefix otherwise
+func listedPrefix(info *NetworkInfo, subnets []SubnetInfo) int {
+	if len(subnets) == 0 {
+		return info.NextPrefix()
+	}
+	return subnetPrefix(subnets[0])
+}
+
+// noSubnetsMessage explains why a network of the given prefix length has no subnets
+func noSubnetsMessage(prefixLength int) string {
+	return fmt.Sprintf("No subnets available (cannot subnet /%d networks)", prefixLength)
+}
+
+// subnetRows returns one CIDR/network/broadcast row per subnet for tabular formats
+func (f *OutputFormatter) subnetRows(subnets []SubnetInfo) [][]string {
+	rows := make([][]string, 0, len(subnets))
+	for _, subnet := range subnets {
+		row := []string{subnet.CIDR, subnet.NetworkID.String(), subnet.BroadcastAddr.String()}
+		for _, field := range subnet.Computed {
+			row = append(row, field.Value)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// factRows converts label/value facts into table rows
+func factRows(facts []reportFact) [][]string {
+	rows := make([][]string, 0, len(facts))
+	for _, fact := range facts {
+		rows = append(rows, []string{fact.Label, fact.Value})
+	}
+	return rows
+}
+
+// subnetTotal returns how many subnets a network of the given prefix length
+// has at the prefix of the listed subnets. The list can hold fewer, after
+// --max-subnets or --filter.
+func subnetTotal(prefixLength int, subnets []SubnetInfo) *big.Int {
+	listed := big.NewInt(int64(len(subnets)))
+	if len(subnets) == 0 {
+		return listed
+	}
+	bits := subnetPrefix(subnets[0]) - prefixLength
+	if bits < 0 {
+		return listed
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits))
+}
+
+// isPartialList reports whether fewer subnets are listed than the network has
+func isPartialList(prefixLength int, subnets []SubnetInfo) bool {
+	return big.NewInt(int64(len(subnets))).Cmp(subnetTotal(prefixLength, subnets)) < 0
+}
+
+// shownNote describes a partial subnet list, e.g. "Showing 100 of 256 subnets",
+// and is empty when every subnet is listed
+func shownNote(prefixLength int, subnets []SubnetInfo) string {
+	if !isPartialList(prefixLength, subnets) {
+		return ""
+	}
+	return fmt.Sprintf("Showing %d of %s subnets", len(subnets), subnetTotal(prefixLength, subnets))
+}
+
 // formatIPMask converts an IP mask to dotted decimal notation
 func (f *OutputFormatter) formatIPMask(mask []byte) string {
 	if len(mask) != 4 {
@@ -127,30 +478,162 @@ func (f *OutputFormatter) FormatUsage() string {
 
 // FormatAsHTML generates HTML formatted output with embedded CSS styling
 func (f *OutputFormatter) FormatAsHTML(info *NetworkInfo, subnets []SubnetInfo) string {
-	tmpl := template.Must(template.New("cidr-report").Parse(htmlTemplate))
+	return f.FormatReportsAsHTML([]NetworkReport{{Info: info, Subnets: subnets}})
+}
+
+// htmlNetworkData is the template data for one network's report sections
+type htmlNetworkData struct {
+	NetworkInfo *NetworkInfo
+	Subnets     []SubnetInfo
+	// SubnetRows are the rows of the sortable subnet table, with a column
+	// per --compute field
+	SubnetRows    []htmlSubnetRow
+	ComputedNames []string
+	HasSubnets    bool
+	NextPrefix    int
+	SubnetCount   int
+	SubnetTotal   string
+	Derivation    *Derivation
+	ShownNote     string
+	ShowHeading   bool
+	ListID        string
+
+	IPv6             bool
+	NetworkFacts     []reportFact
+	HostFacts        []reportFact
+	NoSubnetsMessage string
+
+	BinaryHeading string
+	BinaryRows    []binaryRow
+	NumericRows   []numericRow
+	ClassfulFacts []reportFact
+	Usage         *htmlUsage
+}
+
+// htmlSubnetRow is a row of the HTML subnet table with the keys it sorts by
+type htmlSubnetRow struct {
+	SubnetInfo
+	Addresses string // addresses in the subnet
+	Prefix    int
+	SortKey   string // the network address as fixed-width hex
+}
+
+// htmlSubnetRows returns the rows of the HTML subnet table
+func (f *OutputFormatter) htmlSubnetRows(subnets []SubnetInfo) []htmlSubnetRow {
+	rows := make([]htmlSubnetRow, 0, len(subnets))
+	for _, subnet := range subnets {
+		bits := 128
+		if subnet.NetworkID.To4() != nil {
+			bits = 32
+		}
+		prefix, _ := strconv.Atoi(subnet.CIDR[strings.LastIndex(subnet.CIDR, "/")+1:])
+		rows = append(rows, htmlSubnetRow{
+			SubnetInfo: subnet,
+			Addresses:  f.Locale.Integer(new(big.Int).Lsh(big.NewInt(1), uint(bits-prefix)).String()),
+			Prefix:     prefix,
+			SortKey:    fmt.Sprintf("%0*x", bits/4, addressInt(subnet.NetworkID)),
+		})
+	}
+	return rows
+}
+
+// FormatReportsAsHTML generates a single HTML document with a section per network
+func (f *OutputFormatter) FormatReportsAsHTML(reports []NetworkReport) string {
+	content, err := f.renderHTML(reports)
+	if err != nil {
+		return fmt.Sprintf("Error generating HTML: %v", err)
+	}
+	return content
+}
+
+// renderHTML executes the HTML template, the --template file when one was
+// loaded, with a section per network
+func (f *OutputFormatter) renderHTML(reports []NetworkReport) (string, error) {
+	tmpl := f.HTMLTemplate
+	if tmpl == nil {
+		tmpl = template.Must(template.New("cidr-report").Parse(htmlTemplate))
+	}
+
+	networks := make([]htmlNetworkData, 0, len(reports))
+	for i, report := range reports {
+		listID := "subnetList"
+		if i > 0 {
+			listID = fmt.Sprintf("subnetList-%d", i+1)
+		}
+
+		networks = append(networks, htmlNetworkData{
+			NetworkInfo: report.Info,
+			Subnets:     report.Subnets,
+			SubnetRows:  f.htmlSubnetRows(report.Subnets),
+			HasSubnets:  len(report.Subnets) > 0,
+			NextPrefix:  listedPrefix(report.Info, report.Subnets),
+			SubnetCount: len(report.Subnets),
+			SubnetTotal: f.Locale.Integer(subnetTotal(report.Info.PrefixLength, report.Subnets).String()),
+			Derivation:  report.Derivation,
+			ShownNote:   shownNote(report.Info.PrefixLength, report.Subnets),
+			ShowHeading: len(reports) > 1,
+			ListID:      listID,
+
+			IPv6:             report.Info.IsIPv6(),
+			NetworkFacts:     f.networkFacts(report.Info),
+			HostFacts:        f.hostFacts(report.Info),
+			NoSubnetsMessage: noSubnetsMessage(report.Info.PrefixLength),
+		})
+		if len(report.Subnets) > 0 {
+			for _, field := range report.Subnets[0].Computed {
+				networks[i].ComputedNames = append(networks[i].ComputedNames, field.Name)
+			}
+		}
+		if f.Binary {
+			networks[i].BinaryHeading = binaryHeading(report.Info)
+			networks[i].BinaryRows = f.binaryRows(report.Info)
+		}
+		if f.Numeric {
+			networks[i].NumericRows = f.numericRows(report.Info)
+		}
+		if f.Classful && !report.Info.IsIPv6() {
+			networks[i].ClassfulFacts = f.classfulFacts(report.Info)
+		}
+		if f.UsedRanges != nil {
+			networks[i].Usage = f.htmlUsage(f.UsedRanges.Usage(report.Info))
+		}
+	}
 
 	data := struct {
-		NetworkInfo *NetworkInfo
-		Subnets     []SubnetInfo
-		HasSubnets  bool
-		NextPrefix  int
-		SubnetCount int
-		ShowLimited bool
+		Title       string
+		Networks    []htmlNetworkData
+		ThemeCSS    template.CSS
+		FontCSS     template.CSS
+		Logo        template.URL
+		ErrorsTitle string
+		Errors      []BatchError
 	}{
-		NetworkInfo: info,
-		Subnets:     subnets,
-		HasSubnets:  len(subnets) > 0,
-		NextPrefix:  info.PrefixLength + 1,
-		SubnetCount: len(subnets),
-		ShowLimited: info.PrefixLength <= 16 && len(subnets) == 100,
+		Title:       reportsTitle(reports),
+		Networks:    networks,
+		ThemeCSS:    f.htmlThemeCSS(),
+		FontCSS:     f.htmlFontCSS(),
+		Logo:        template.URL(f.HTMLLogo),
+		ErrorsTitle: f.batchErrorsTitle(),
+		Errors:      f.BatchErrors,
 	}
 
 	var output strings.Builder
 	if err := tmpl.Execute(&output, data); err != nil {
-		return fmt.Sprintf("Error generating HTML: %v", err)
+		return "", fmt.Errorf("failed to generate HTML: %v", err)
 	}
 
-	return output.String()
+	if f.MinifyHTML {
+		return minifyHTML(output.String()), nil
+	}
+	return output.String(), nil
+}
+
+// reportsTitle names a report by its CIDR, or by the number of networks it covers
+func reportsTitle(reports []NetworkReport) string {
+	if len(reports) == 1 {
+		return fmt.Sprintf("%s/%d", reports[0].Info.NetworkID.String(), reports[0].Info.PrefixLength)
+	}
+	return fmt.Sprintf("%d networks", len(reports))
 }
 
 // SaveToFile saves content to a specified file with comprehensive error handling and validation
@@ -160,24 +643,9 @@ func (f *OutputFormatter) SaveToFile(content string, filename string) error {
 		return fmt.Errorf("content cannot be empty")
 	}
 
-	if filename == "" {
-		return fmt.Errorf("filename cannot be empty")
-	}
-
-	// Validate and sanitize file path
-	if err := f.validateFilePath(filename); err != nil {
-		return fmt.Errorf("invalid file path: %v", err)
-	}
-
-	// Create directory if it doesn't exist
-	if err := f.ensureDirectoryExists(filename); err != nil {
-		return fmt.Errorf("failed to create directory: %v", err)
-	}
-
-	// Create file with proper permissions
-	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	file, err := f.CreateFile(filename)
 	if err != nil {
-		return fmt.Errorf("failed to create file %s: %v", filename, err)
+		return err
 	}
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
@@ -205,6 +673,31 @@ func (f *OutputFormatter) SaveToFile(content string, filename string) error {
 	return nil
 }
 
+// CreateFile validates the output path, creates missing directories and
+// opens the file for writing, truncating any previous content
+func (f *OutputFormatter) CreateFile(filename string) (*os.File, error) {
+	if filename == "" {
+		return nil, fmt.Errorf("filename cannot be empty")
+	}
+
+	// Validate and sanitize file path
+	if err := f.validateFilePath(filename); err != nil {
+		return nil, fmt.Errorf("invalid file path: %v", err)
+	}
+
+	// Create directory if it doesn't exist
+	if err := f.ensureDirectoryExists(filename); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	// Create file with proper permissions
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file %s: %v", filename, err)
+	}
+	return file, nil
+}
+
 // SaveTextToFile saves text content to a file with .txt extension validation
 func (f *OutputFormatter) SaveTextToFile(info *NetworkInfo, subnets []SubnetInfo, filename string) error {
 	// Generate text content
@@ -221,7 +714,10 @@ func (f *OutputFormatter) SaveTextToFile(info *NetworkInfo, subnets []SubnetInfo
 // SaveHTMLToFile saves HTML content to a file with .html extension validation
 func (f *OutputFormatter) SaveHTMLToFile(info *NetworkInfo, subnets []SubnetInfo, filename string) error {
 	// Generate HTML content
-	content := f.FormatAsHTML(info, subnets)
+	content, err := f.renderHTML([]NetworkReport{{Info: info, Subnets: subnets}})
+	if err != nil {
+		return err
+	}
 
 	// Validate file extension for HTML output
 	if !f.hasValidHTMLExtension(filename) {
@@ -336,8 +832,234 @@ const htmlTemplate = `<!DOCTYPE html>
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>CIDR Calculator Report - {{.NetworkInfo.NetworkID}}/{{.NetworkInfo.PrefixLength}}</title>
-    <style>
+    <title>CIDR Calculator Report - {{.Title}}</title>
+    <style>{{template "styles" .}}    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            {{if .Logo}}<img class="logo" src="{{.Logo}}" alt="">
+            {{end}}<h1>CIDR Calculator Report</h1>
+            <div class="cidr">{{.Title}}</div>
+        </div>
+        
+        <div class="content">
+            {{range .Networks}}{{template "network" .}}{{end}}{{if .Errors}}{{template "errors" .}}{{end}}
+        </div>
+    </div>
+    
+    <script>{{template "script"}}    </script>
+</body>
+</html>
+{{define "network"}}
+            {{if .ShowHeading}}
+            <div class="network-heading">{{.NetworkInfo.NetworkID}}/{{.NetworkInfo.PrefixLength}}</div>
+            {{end}}
+            <div class="section">
+                <h2>Network Information</h2>
+                <table class="info-table">
+                    {{range .NetworkFacts}}
+                    <tr>
+                        <th>{{.Label}}</th>
+                        <td>{{.Value}}</td>
+                    </tr>
+                    {{end}}
+                </table>
+            </div>
+            
+            <div class="section">
+                <h2>Host Information</h2>
+                <table class="info-table">
+                    {{range .HostFacts}}
+                    <tr>
+                        <th>{{.Label}}</th>
+                        <td>{{.Value}}</td>
+                    </tr>
+                    {{end}}
+                </table>
+                
+                {{if .IPv6}}
+                {{else if eq .NetworkInfo.PrefixLength 32}}
+                    <div class="special-case">
+                        <span class="label">Note:</span> This is a /32 network representing a single host address.
+                    </div>
+                {{else if eq .NetworkInfo.PrefixLength 31}}
+                    <div class="special-case">
+                        <span class="label">Note:</span> This is a /31 network typically used for point-to-point links with no broadcast address.
+                    </div>
+                {{end}}
+            </div>
+            {{if .BinaryRows}}
+            
+            <div class="section">
+                <h2>Binary Breakdown</h2>
+                <p class="binary-legend"><span class="network-bits">{{.BinaryHeading}}</span></p>
+                <table class="info-table binary-table">
+                    {{range .BinaryRows}}
+                    <tr>
+                        <th>{{.Label}}</th>
+                        <td><span class="network-bits">{{.Network}}</span><span class="host-bits">{{.Host}}</span></td>
+                    </tr>
+                    {{end}}
+                </table>
+            </div>
+            {{end}}
+            {{if .NumericRows}}
+            
+            <div class="section">
+                <h2>Numeric Forms</h2>
+                <table class="info-table numeric-table">
+                    <tr>
+                        <th>Address</th>
+                        <th>Decimal</th>
+                        <th>Hex</th>
+                    </tr>
+                    {{range .NumericRows}}
+                    <tr>
+                        <th>{{.Label}}</th>
+                        <td>{{.Decimal}}</td>
+                        <td>{{.Hex}}</td>
+                    </tr>
+                    {{end}}
+                </table>
+            </div>
+            {{end}}
+            {{if .ClassfulFacts}}
+            
+            <div class="section">
+                <h2>Classful Addressing</h2>
+                <table class="info-table">
+                    {{range .ClassfulFacts}}
+                    <tr>
+                        <th>{{.Label}}</th>
+                        <td>{{.Value}}</td>
+                    </tr>
+                    {{end}}
+                </table>
+            </div>
+            {{end}}
+            {{if .Usage}}
+            
+            <div class="section">
+                <h2>Address Space Utilization</h2>
+                <table class="info-table">
+                    <tr>
+                        <th>Allocated</th>
+                        <td>{{.Usage.Used}} of {{.Usage.Total}} addresses ({{.Usage.Percent}})</td>
+                    </tr>
+                    <tr>
+                        <th>Free</th>
+                        <td>{{.Usage.Free}} addresses</td>
+                    </tr>
+                    <tr>
+                        <th>Used Ranges</th>
+                        <td>{{.Usage.Allocations}}</td>
+                    </tr>
+                </table>
+                <div class="usage-bar" role="img" aria-label="{{.Usage.Percent}} of {{.NetworkInfo.CIDR}} allocated">
+                    {{range .Usage.Segments}}
+                    <div class="usage-segment {{if .Used}}used{{else}}free{{end}}" style="width: {{.Width}}%" title="{{.Title}}"></div>
+                    {{end}}
+                </div>
+                <div class="usage-legend">
+                    <span class="usage-key used"></span> Allocated
+                    <span class="usage-key free"></span> Free
+                </div>
+                <div class="subnet-list">
+                    <table class="subnet-table">
+                        <thead>
+                            <tr>
+                                <th>Range</th>
+                                <th>Addresses</th>
+                                <th>Used By</th>
+                            </tr>
+                        </thead>
+                        <tbody>
+                            {{range .Usage.Segments}}
+                            <tr class="subnet-item usage-{{if .Used}}used{{else}}free{{end}}">
+                                <td class="subnet-range">{{.Range}}</td>
+                                <td class="subnet-range">{{.Addresses}}</td>
+                                <td>{{if .Used}}{{.Label}}{{else}}<em>free</em>{{end}}</td>
+                            </tr>
+                            {{end}}
+                        </tbody>
+                    </table>
+                </div>
+            </div>
+            {{end}}
+            
+            <div class="section">
+                <h2>Subnet Information</h2>
+                {{if .HasSubnets}}
+                    <table class="info-table">
+                        <tr>
+                            <th>Possible /{{.NextPrefix}} Subnets</th>
+                            <td>{{.SubnetTotal}}</td>
+                        </tr>
+                        {{if .Derivation}}
+                        <tr>
+                            <th>Derived From</th>
+                            <td>{{.Derivation}}</td>
+                        </tr>
+                        {{end}}
+                    </table>
+                    
+                    {{if .ShownNote}}
+                        <div class="warning">
+                            <strong>Note:</strong> {{.ShownNote}}. The network can be divided into {{.SubnetTotal}} /{{.NextPrefix}} subnets in total.
+                        </div>
+                    {{end}}
+                    
+                    <div class="subnet-controls">
+                        <button class="toggle-btn" data-target="{{.ListID}}" onclick="toggleSubnets('{{.ListID}}')">Toggle Subnet List</button>
+                        <input type="search" class="subnet-search" placeholder="Filter by prefix or octet" aria-label="Filter subnets" oninput="filterSubnets('{{.ListID}}', this.value)">
+                        <span class="subnet-matches" data-target="{{.ListID}}"></span>
+                    </div>
+                    
+                    <div class="subnet-list" id="{{.ListID}}" data-count="{{.SubnetCount}}">
+                        <table class="subnet-table">
+                            <thead>
+                                <tr>
+                                    <th data-sort="network" onclick="sortSubnets('{{.ListID}}', 'network')">Subnet</th>
+                                    <th>Range</th>
+                                    <th data-sort="size" onclick="sortSubnets('{{.ListID}}', 'size')">Addresses</th>{{range .ComputedNames}}
+                                    <th>{{.}}</th>{{end}}
+                                </tr>
+                            </thead>
+                            <tbody>
+                                {{range .SubnetRows}}
+                                <tr class="subnet-item" data-cidr="{{.CIDR}}" data-network="{{.SortKey}}" data-prefix="{{.Prefix}}">
+                                    <td class="subnet-cidr">{{.CIDR}}</td>
+                                    <td class="subnet-range">{{.NetworkID}} - {{.BroadcastAddr}}</td>
+                                    <td class="subnet-range">{{.Addresses}}</td>{{range .Computed}}
+                                    <td class="subnet-range">{{.Value}}</td>{{end}}
+                                </tr>
+                                {{end}}
+                            </tbody>
+                        </table>
+                        <div class="no-matches" hidden>No subnets match the filter</div>
+                    </div>
+                {{else}}
+                    <div class="no-subnets">
+                        {{.NoSubnetsMessage}}
+                    </div>
+                {{end}}
+            </div>
+{{end}}
+{{define "errors"}}
+            <div class="section">
+                <h2>{{.ErrorsTitle}}</h2>
+                <table class="info-table">
+                    {{range .Errors}}
+                    <tr>
+                        <th>{{.Source}} line {{.Line}}</th>
+                        <td>{{.CIDR}}: {{.Err}}</td>
+                    </tr>
+                    {{end}}
+                </table>
+            </div>
+{{end}}
+{{- define "styles"}}
         * {
             margin: 0;
             padding: 0;
@@ -374,6 +1096,13 @@ const htmlTemplate = `<!DOCTYPE html>
             font-weight: 300;
         }
         
+        .header .logo {
+            display: block;
+            max-height: 64px;
+            max-width: 240px;
+            margin: 0 auto 15px;
+        }
+        
         .header .cidr {
             font-size: 1.5em;
             font-family: 'Courier New', monospace;
@@ -391,6 +1120,13 @@ const htmlTemplate = `<!DOCTYPE html>
             margin-bottom: 40px;
         }
         
+        .network-heading {
+            font-size: 1.8em;
+            font-family: 'Courier New', monospace;
+            color: #764ba2;
+            margin-bottom: 20px;
+        }
+        
         .section h2 {
             color: #667eea;
             border-bottom: 2px solid #667eea;
@@ -438,8 +1174,35 @@ const htmlTemplate = `<!DOCTYPE html>
             background: #f8f9fa;
         }
         
+        .binary-table td {
+            font-size: 0.95em;
+            letter-spacing: 0.05em;
+            word-break: break-all;
+        }
+        
+        .binary-legend {
+            margin-bottom: 10px;
+            color: #666;
+        }
+        
+        .network-bits {
+            color: #667eea;
+            font-weight: 600;
+        }
+        
+        .host-bits {
+            color: #e67e22;
+            border-left: 2px solid #764ba2;
+            padding-left: 4px;
+            margin-left: 4px;
+        }
+        
         .subnet-controls {
             margin-bottom: 20px;
+            display: flex;
+            flex-wrap: wrap;
+            align-items: center;
+            gap: 10px;
         }
         
         .toggle-btn {
@@ -457,6 +1220,19 @@ const htmlTemplate = `<!DOCTYPE html>
             background: #5a6fd8;
         }
         
+        .subnet-search {
+            padding: 11px 14px;
+            border: 1px solid #ddd;
+            border-radius: 6px;
+            font-size: 1em;
+            min-width: 240px;
+        }
+        
+        .subnet-matches {
+            color: #666;
+            font-size: 0.9em;
+        }
+        
         .subnet-list {
             max-height: 400px;
             overflow-y: auto;
@@ -465,16 +1241,49 @@ const htmlTemplate = `<!DOCTYPE html>
             background: white;
         }
         
-        .subnet-item {
+        .subnet-table {
+            width: 100%;
+            border-collapse: collapse;
+        }
+        
+        .subnet-table th {
+            position: sticky;
+            top: 0;
+            background: #f8f9fa;
+            color: #495057;
+            font-weight: 600;
+            text-align: left;
+            padding: 12px 20px;
+            border-bottom: 1px solid #ddd;
+        }
+        
+        .subnet-table th[data-sort] {
+            cursor: pointer;
+            user-select: none;
+        }
+        
+        .subnet-table th[data-sort]::after {
+            content: " \2195";
+            opacity: 0.4;
+        }
+        
+        .subnet-table th[aria-sort="ascending"]::after {
+            content: " \2191";
+            opacity: 1;
+        }
+        
+        .subnet-table th[aria-sort="descending"]::after {
+            content: " \2193";
+            opacity: 1;
+        }
+        
+        .subnet-item td {
             padding: 12px 20px;
             border-bottom: 1px solid #eee;
-            display: flex;
-            justify-content: space-between;
-            align-items: center;
             transition: background 0.2s ease;
         }
         
-        .subnet-item:last-child {
+        .subnet-item:last-child td {
             border-bottom: none;
         }
         
@@ -494,6 +1303,59 @@ const htmlTemplate = `<!DOCTYPE html>
             color: #666;
         }
         
+        .no-matches {
+            padding: 12px 20px;
+            color: #666;
+        }
+        
+        .usage-bar {
+            display: flex;
+            height: 32px;
+            margin-bottom: 10px;
+            border: 1px solid #ddd;
+            border-radius: 6px;
+            overflow: hidden;
+            background: #f8f9fa;
+        }
+        
+        .usage-segment.used {
+            min-width: 2px;
+            background: #667eea;
+            border-right: 1px solid white;
+        }
+        
+        .usage-segment.free {
+            background: #f8f9fa;
+        }
+        
+        .usage-legend {
+            margin-bottom: 20px;
+            color: #666;
+            font-size: 0.9em;
+        }
+        
+        .usage-key {
+            display: inline-block;
+            width: 12px;
+            height: 12px;
+            margin: 0 4px 0 12px;
+            vertical-align: middle;
+            border: 1px solid #ddd;
+            border-radius: 2px;
+        }
+        
+        .usage-key:first-child {
+            margin-left: 0;
+        }
+        
+        .usage-key.used {
+            background: #667eea;
+        }
+        
+        .usage-key.free {
+            background: #f8f9fa;
+        }
+        
         .warning {
             background: #fff3cd;
             border: 1px solid #ffeaa7;
@@ -541,7 +1403,15 @@ const htmlTemplate = `<!DOCTYPE html>
                 -webkit-print-color-adjust: exact;
             }
             
-            .toggle-btn {
+            .usage-bar,
+            .usage-key {
+                -webkit-print-color-adjust: exact;
+                print-color-adjust: exact;
+            }
+            
+            .toggle-btn,
+            .subnet-search,
+            .subnet-matches {
                 display: none;
             }
             
@@ -587,137 +1457,25 @@ const htmlTemplate = `<!DOCTYPE html>
                 font-size: 0.9em;
             }
             
-            .subnet-item {
-                flex-direction: column;
-                align-items: flex-start;
-                gap: 5px;
+            .subnet-table th,
+            .subnet-item td {
+                padding: 10px;
+            }
+            
+            .subnet-search {
+                min-width: 0;
+                width: 100%;
             }
             
             .subnet-cidr {
                 min-width: auto;
             }
         }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>CIDR Calculator Report</h1>
-            <div class="cidr">{{.NetworkInfo.NetworkID}}/{{.NetworkInfo.PrefixLength}}</div>
-        </div>
-        
-        <div class="content">
-            <div class="section">
-                <h2>Network Information</h2>
-                <table class="info-table">
-                    <tr>
-                        <th>CIDR</th>
-                        <td>{{.NetworkInfo.NetworkID}}/{{.NetworkInfo.PrefixLength}}</td>
-                    </tr>
-                    <tr>
-                        <th>Network ID</th>
-                        <td>{{.NetworkInfo.NetworkID}}</td>
-                    </tr>
-                    <tr>
-                        <th>Broadcast Address</th>
-                        <td>{{.NetworkInfo.BroadcastAddr}}</td>
-                    </tr>
-                    <tr>
-                        <th>Subnet Mask</th>
-                        <td>{{printf "%d.%d.%d.%d" (index .NetworkInfo.SubnetMask 0) (index .NetworkInfo.SubnetMask 1) (index .NetworkInfo.SubnetMask 2) (index .NetworkInfo.SubnetMask 3)}}</td>
-                    </tr>
-                    <tr>
-                        <th>Wildcard Mask</th>
-                        <td>{{printf "%d.%d.%d.%d" (index .NetworkInfo.WildcardMask 0) (index .NetworkInfo.WildcardMask 1) (index .NetworkInfo.WildcardMask 2) (index .NetworkInfo.WildcardMask 3)}}</td>
-                    </tr>
-                </table>
-            </div>
-            
-            <div class="section">
-                <h2>Host Information</h2>
-                <table class="info-table">
-                    {{if eq .NetworkInfo.PrefixLength 32}}
-                        <tr>
-                            <th>Host Address</th>
-                            <td>{{.NetworkInfo.FirstUsableIP}} <span style="color: #666;">(single host)</span></td>
-                        </tr>
-                    {{else if eq .NetworkInfo.PrefixLength 31}}
-                        <tr>
-                            <th>First Address</th>
-                            <td>{{.NetworkInfo.FirstUsableIP}} <span style="color: #666;">(point-to-point)</span></td>
-                        </tr>
-                        <tr>
-                            <th>Second Address</th>
-                            <td>{{.NetworkInfo.LastUsableIP}} <span style="color: #666;">(point-to-point)</span></td>
-                        </tr>
-                    {{else}}
-                        <tr>
-                            <th>First Usable IP</th>
-                            <td>{{.NetworkInfo.FirstUsableIP}}</td>
-                        </tr>
-                        <tr>
-                            <th>Last Usable IP</th>
-                            <td>{{.NetworkInfo.LastUsableIP}}</td>
-                        </tr>
-                    {{end}}
-                    <tr>
-                        <th>Total Hosts</th>
-                        <td>{{.NetworkInfo.TotalHosts}}</td>
-                    </tr>
-                </table>
-                
-                {{if eq .NetworkInfo.PrefixLength 32}}
-                    <div class="special-case">
-                        <span class="label">Note:</span> This is a /32 network representing a single host address.
-                    </div>
-                {{else if eq .NetworkInfo.PrefixLength 31}}
-                    <div class="special-case">
-                        <span class="label">Note:</span> This is a /31 network typically used for point-to-point links with no broadcast address.
-                    </div>
-                {{end}}
-            </div>
-            
-            <div class="section">
-                <h2>Subnet Information</h2>
-                {{if .HasSubnets}}
-                    <table class="info-table">
-                        <tr>
-                            <th>Possible /{{.NextPrefix}} Subnets</th>
-                            <td>{{.SubnetCount}}</td>
-                        </tr>
-                    </table>
-                    
-                    {{if .ShowLimited}}
-                        <div class="warning">
-                            <strong>Performance Note:</strong> Showing first 100 subnets for performance. The network can be divided into {{.SubnetCount}} total subnets.
-                        </div>
-                    {{end}}
-                    
-                    <div class="subnet-controls">
-                        <button class="toggle-btn" onclick="toggleSubnets()">Toggle Subnet List</button>
-                    </div>
-                    
-                    <div class="subnet-list" id="subnetList">
-                        {{range .Subnets}}
-                            <div class="subnet-item">
-                                <span class="subnet-cidr">{{.CIDR}}</span>
-                                <span class="subnet-range">({{.NetworkID}} - {{.BroadcastAddr}})</span>
-                            </div>
-                        {{end}}
-                    </div>
-                {{else}}
-                    <div class="no-subnets">
-                        No subnets available (cannot subnet /32 networks)
-                    </div>
-                {{end}}
-            </div>
-        </div>
-    </div>
-    
-    <script>
-        function toggleSubnets() {
-            const subnetList = document.getElementById('subnetList');
-            const btn = document.querySelector('.toggle-btn');
+{{.ThemeCSS}}{{.FontCSS}}{{end}}
+{{- define "script"}}
+        function toggleSubnets(listId) {
+            const subnetList = document.getElementById(listId);
+            const btn = document.querySelector('.toggle-btn[data-target="' + listId + '"]');
             
             if (subnetList.style.display === 'none') {
                 subnetList.style.display = 'block';
@@ -728,16 +1486,71 @@ const htmlTemplate = `<!DOCTYPE html>
             }
         }
         
-        // Initially hide subnet list if there are many subnets
-        document.addEventListener('DOMContentLoaded', function() {
-            const subnetList = document.getElementById('subnetList');
-            const subnetCount = {{.SubnetCount}};
+        // Sort a subnet table by network address or by size, smallest
+        // first; sorting by the same column again reverses the order
+        function sortSubnets(listId, key) {
+            const table = document.getElementById(listId).querySelector('.subnet-table');
+            const heading = table.querySelector('th[data-sort="' + key + '"]');
+            const descending = heading.getAttribute('aria-sort') === 'ascending';
+            table.querySelectorAll('th[data-sort]').forEach(function(th) {
+                th.removeAttribute('aria-sort');
+            });
+            heading.setAttribute('aria-sort', descending ? 'descending' : 'ascending');
             
-            if (subnetCount > 20) {
-                subnetList.style.display = 'none';
-                document.querySelector('.toggle-btn').textContent = 'Show Subnet List';
+            const body = table.tBodies[0];
+            const rows = Array.prototype.slice.call(body.rows);
+            rows.sort(function(a, b) {
+                let order = 0;
+                if (key === 'size') {
+                    order = parseInt(b.dataset.prefix, 10) - parseInt(a.dataset.prefix, 10);
+                }
+                if (order === 0 && a.dataset.network !== b.dataset.network) {
+                    order = a.dataset.network < b.dataset.network ? -1 : 1;
+                }
+                return descending ? -order : order;
+            });
+            rows.forEach(function(row) {
+                body.appendChild(row);
+            });
+        }
+        
+        // Show the subnets matching every word of the filter. A number
+        // matches a whole octet or the prefix length; anything else a part
+        // of the subnet, such as "10.0.3." or "/26".
+        function filterSubnets(listId, query) {
+            const subnetList = document.getElementById(listId);
+            const words = query.trim().toLowerCase().split(/\s+/).filter(Boolean);
+            let shown = 0;
+            
+            subnetList.querySelectorAll('.subnet-item').forEach(function(row) {
+                const cidr = row.dataset.cidr;
+                const parts = cidr.split(/[.:\/]/);
+                const match = words.every(function(word) {
+                    return /^[0-9]+$/.test(word) ? parts.indexOf(word) >= 0 : cidr.indexOf(word) >= 0;
+                });
+                row.hidden = !match;
+                if (match) {
+                    shown++;
+                }
+            });
+            
+            subnetList.querySelector('.no-matches').hidden = shown > 0;
+            document.querySelector('.subnet-matches[data-target="' + listId + '"]').textContent =
+                words.length > 0 ? shown + ' of ' + subnetList.dataset.count + ' shown' : '';
+            if (words.length > 0 && subnetList.style.display === 'none') {
+                toggleSubnets(listId);
             }
+        }
+        
+        // Initially hide subnet lists that have many subnets
+        document.addEventListener('DOMContentLoaded', function() {
+            document.querySelectorAll('.subnet-list').forEach(function(subnetList) {
+                const subnetCount = parseInt(subnetList.dataset.count, 10);
+                
+                if (subnetCount > 20) {
+                    subnetList.style.display = 'none';
+                    document.querySelector('.toggle-btn[data-target="' + subnetList.id + '"]').textContent = 'Show Subnet List';
+                }
+            });
         });
-    </script>
-</body>
-</html>`
+{{end}}`
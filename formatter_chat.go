@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// slackText is a Block Kit text object
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackBlock is a Block Kit layout block
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+// slackMessage is the payload accepted by Slack incoming webhooks
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// teamsElement is an Adaptive Card body element
+type teamsElement struct {
+	Type   string      `json:"type"`
+	Text   string      `json:"text,omitempty"`
+	Size   string      `json:"size,omitempty"`
+	Weight string      `json:"weight,omitempty"`
+	Color  string      `json:"color,omitempty"`
+	Wrap   bool        `json:"wrap,omitempty"`
+	Facts  []teamsFact `json:"facts,omitempty"`
+}
+
+// teamsFact is a title/value pair inside an Adaptive Card FactSet
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// teamsCard is an Adaptive Card document
+type teamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+}
+
+// teamsAttachment wraps an Adaptive Card for webhook delivery
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+// teamsMessage is the payload accepted by Teams incoming webhooks
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+// FormatAsSlack generates a Slack Block Kit message summarizing the network
+func (f *OutputFormatter) FormatAsSlack(info *NetworkInfo, subnets []SubnetInfo) (string, error) {
+	return f.FormatReportsAsSlack([]NetworkReport{{Info: info, Subnets: subnets}})
+}
+
+// Slack rejects messages of more than slackMaxBlocks blocks and sections of
+// more than slackMaxFields fields
+const (
+	slackMaxBlocks = 50
+	slackMaxFields = 10
+)
+
+// FormatReportsAsSlack generates one Slack message with a summary block group
+// per network. Networks beyond the block limit are left out with a notice.
+func (f *OutputFormatter) FormatReportsAsSlack(reports []NetworkReport) (string, error) {
+	message := slackMessage{Text: "CIDR Report: " + reportsTitle(reports)}
+
+	var errorBlocks []slackBlock
+	if len(f.BatchErrors) > 0 {
+		lines := make([]string, 0, len(f.BatchErrors))
+		for _, failure := range f.BatchErrors {
+			lines = append(lines, fmt.Sprintf("• %s line %d `%s`: %v", failure.Source, failure.Line, failure.CIDR, failure.Err))
+		}
+		errorBlocks = []slackBlock{
+			{Type: "divider"},
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "*" + f.batchErrorsTitle() + "*\n" + strings.Join(lines, "\n")}},
+		}
+	}
+
+	for i, report := range reports {
+		blocks := f.slackReportBlocks(report)
+		if i > 0 {
+			blocks = append([]slackBlock{{Type: "divider"}}, blocks...)
+		}
+		// Leave room for the batch errors and, unless this is the last
+		// network, the notice of the networks left out
+		needed := len(message.Blocks) + len(blocks) + len(errorBlocks)
+		if i < len(reports)-1 {
+			needed += 2
+		}
+		if needed > slackMaxBlocks {
+			left := len(reports) - i
+			message.Blocks = append(message.Blocks,
+				slackBlock{Type: "divider"},
+				slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: fmt.Sprintf(
+					"%s left out: a Slack message holds at most %d blocks. Use --format json or text for the full report.", plural(left, "more network"), slackMaxBlocks)}}},
+			)
+			break
+		}
+		message.Blocks = append(message.Blocks, blocks...)
+	}
+	message.Blocks = append(message.Blocks, errorBlocks...)
+
+	return f.marshalChatPayload(message)
+}
+
+// slackReportBlocks returns the header, the facts in sections of at most
+// slackMaxFields fields and the subnet summary of a network
+func (f *OutputFormatter) slackReportBlocks(report NetworkReport) []slackBlock {
+	blocks := []slackBlock{{Type: "header", Text: &slackText{Type: "plain_text", Text: f.briefTitle(report.Info)}}}
+
+	facts := f.briefFacts(report.Info)[1:]
+	for start := 0; start < len(facts); start += slackMaxFields {
+		end := start + slackMaxFields
+		if end > len(facts) {
+			end = len(facts)
+		}
+		fields := make([]slackText, 0, end-start)
+		for _, fact := range facts[start:end] {
+			fields = append(fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n`%s`", fact.Label, fact.Value)})
+		}
+		blocks = append(blocks, slackBlock{Type: "section", Fields: fields})
+	}
+
+	return append(blocks, slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: f.briefSubnetSummary(report.Info, report.Subnets)}}})
+}
+
+// FormatAsTeams generates a Microsoft Teams Adaptive Card message summarizing the network
+func (f *OutputFormatter) FormatAsTeams(info *NetworkInfo, subnets []SubnetInfo) (string, error) {
+	return f.FormatReportsAsTeams([]NetworkReport{{Info: info, Subnets: subnets}})
+}
+
+// FormatReportsAsTeams generates one Adaptive Card with a fact set per network
+func (f *OutputFormatter) FormatReportsAsTeams(reports []NetworkReport) (string, error) {
+	var body []teamsElement
+
+	for _, report := range reports {
+		facts := make([]teamsFact, 0, 8)
+		for _, fact := range f.briefFacts(report.Info)[1:] {
+			facts = append(facts, teamsFact{Title: fact.Label, Value: fact.Value})
+		}
+
+		body = append(body,
+			teamsElement{Type: "TextBlock", Text: f.briefTitle(report.Info), Size: "Large", Weight: "Bolder", Wrap: true},
+			teamsElement{Type: "FactSet", Facts: facts},
+			teamsElement{Type: "TextBlock", Text: f.briefSubnetSummary(report.Info, report.Subnets), Wrap: true},
+		)
+	}
+	if len(f.BatchErrors) > 0 {
+		facts := make([]teamsFact, 0, len(f.BatchErrors))
+		for _, failure := range f.BatchErrors {
+			facts = append(facts, teamsFact{Title: fmt.Sprintf("%s line %d", failure.Source, failure.Line), Value: fmt.Sprintf("%s: %v", failure.CIDR, failure.Err)})
+		}
+		body = append(body,
+			teamsElement{Type: "TextBlock", Text: f.batchErrorsTitle(), Size: "Large", Weight: "Bolder", Color: "Attention", Wrap: true},
+			teamsElement{Type: "FactSet", Facts: facts},
+		)
+	}
+
+	message := teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{
+			{
+				ContentType: "application/vnd.microsoft.card.adaptive",
+				Content: teamsCard{
+					Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+					Type:    "AdaptiveCard",
+					Version: "1.4",
+					Body:    body,
+				},
+			},
+		},
+	}
+
+	return f.marshalChatPayload(message)
+}
+
+// briefTitle returns the heading used by chat message formats
+func (f *OutputFormatter) briefTitle(info *NetworkInfo) string {
+	return fmt.Sprintf("CIDR Report: %s/%d", info.NetworkID.String(), info.PrefixLength)
+}
+
+// briefSubnetSummary describes the subnet listing in a single line
+func (f *OutputFormatter) briefSubnetSummary(info *NetworkInfo, subnets []SubnetInfo) string {
+	if len(subnets) == 0 {
+		return noSubnetsMessage(info.PrefixLength)
+	}
+	summary := fmt.Sprintf("Possible /%d subnets: %s", listedPrefix(info, subnets), f.Locale.Integer(subnetTotal(info.PrefixLength, subnets).String()))
+	if isPartialList(info.PrefixLength, subnets) {
+		summary += fmt.Sprintf(" (showing %d)", len(subnets))
+	}
+	for _, field := range subnets[0].Computed {
+		if field.Name == cloudFieldUsable {
+			summary += fmt.Sprintf(", %s usable hosts each", f.Locale.Integer(field.Value))
+		}
+	}
+	return summary
+}
+
+// marshalChatPayload encodes a webhook payload as indented JSON
+func (f *OutputFormatter) marshalChatPayload(payload interface{}) (string, error) {
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode message: %v", err)
+	}
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestOutputFormatter_FormatAsSlack(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	info, err := calculator.ParseCIDR("192.168.1.0/24")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+	subnets := calculator.CalculateSubnets(info)
+
+	output, err := formatter.FormatAsSlack(info, subnets)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var message slackMessage
+	if err := json.Unmarshal([]byte(output), &message); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	if message.Text != "CIDR Report: 192.168.1.0/24" {
+		t.Errorf("unexpected fallback text %q", message.Text)
+	}
+
+	if len(message.Blocks) != 3 {
+		t.Fatalf("expected 3 blocks, got %d", len(message.Blocks))
+	}
+
+	if message.Blocks[0].Type != "header" || message.Blocks[1].Type != "section" || message.Blocks[2].Type != "context" {
+		t.Errorf("unexpected block layout: %s, %s, %s", message.Blocks[0].Type, message.Blocks[1].Type, message.Blocks[2].Type)
+	}
+
+	expected := []string{
+		"*Broadcast*\\n`192.168.1.255`",
+		"*First Usable*\\n`192.168.1.1`",
+		"*Total Hosts*\\n`254`",
+		"Possible /25 subnets: 2",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(output, exp) {
+			t.Errorf("expected output to contain %q", exp)
+		}
+	}
+}
+
+func TestOutputFormatter_SlackLimits(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	// 31 networks need more than the 50 blocks a message may hold
+	var reports []NetworkReport
+	for i := 0; i < 31; i++ {
+		info := mustParseCIDR(t, calculator, fmt.Sprintf("10.%d.0.0/24", i))
+		reports = append(reports, NetworkReport{Info: info, Subnets: calculator.CalculateSubnets(info)})
+	}
+	output, err := formatter.FormatReportsAsSlack(reports)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var message slackMessage
+	if err := json.Unmarshal([]byte(output), &message); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(message.Blocks) > slackMaxBlocks {
+		t.Errorf("expected at most %d blocks, got %d", slackMaxBlocks, len(message.Blocks))
+	}
+	last := message.Blocks[len(message.Blocks)-1]
+	if last.Type != "context" || !strings.HasPrefix(last.Elements[0].Text, "19 more networks left out: a Slack message holds at most 50 blocks.") {
+		t.Errorf("expected a notice of the networks left out, got %+v", last)
+	}
+
+	// Facts beyond the 10 fields of a section go in another section
+	info := mustParseCIDR(t, calculator, "10.0.0.0/24")
+	info.Alias, info.Tags = "web", Tags{"env": "prod"}
+	info.Derivation = &Derivation{Parent: "10.0.0.0/16", Rule: "split", Requested: "/24"}
+	output, _ = formatter.FormatAsSlack(info, nil)
+	message = slackMessage{}
+	if err := json.Unmarshal([]byte(output), &message); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	fields := 0
+	for _, block := range message.Blocks {
+		if len(block.Fields) > slackMaxFields {
+			t.Errorf("expected at most %d fields in a section, got %d", slackMaxFields, len(block.Fields))
+		}
+		fields += len(block.Fields)
+	}
+	if len(message.Blocks) != 4 || fields != 11 {
+		t.Errorf("expected 11 fields in two sections, got %d fields in %d blocks", fields, len(message.Blocks))
+	}
+}
+
+func TestOutputFormatter_FormatAsTeams(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	tests := []struct {
+		name     string
+		cidr     string
+		expected []teamsFact
+		summary  string
+	}{
+		{
+			name:     "standard network",
+			cidr:     "10.0.0.0/30",
+			expected: []teamsFact{{"First Usable", "10.0.0.1"}, {"Last Usable", "10.0.0.2"}, {"Total Hosts", "2"}},
+			summary:  "Possible /31 subnets: 2",
+		},
+		{
+			name:     "single host",
+			cidr:     "10.0.0.1/32",
+			expected: []teamsFact{{"Host Address", "10.0.0.1 (single host)"}, {"Total Hosts", "1"}},
+			summary:  "No subnets available (cannot subnet /32 networks)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := calculator.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("failed to parse CIDR: %v", err)
+			}
+
+			output, err := formatter.FormatAsTeams(info, calculator.CalculateSubnets(info))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var message teamsMessage
+			if err := json.Unmarshal([]byte(output), &message); err != nil {
+				t.Fatalf("output is not valid JSON: %v", err)
+			}
+
+			if len(message.Attachments) != 1 {
+				t.Fatalf("expected 1 attachment, got %d", len(message.Attachments))
+			}
+
+			card := message.Attachments[0].Content
+			if card.Type != "AdaptiveCard" || len(card.Body) != 3 {
+				t.Fatalf("unexpected card structure: %+v", card)
+			}
+
+			facts := card.Body[1].Facts
+			for _, exp := range tt.expected {
+				found := false
+				for _, fact := range facts {
+					if fact == exp {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("expected fact %+v in %+v", exp, facts)
+				}
+			}
+
+			if card.Body[2].Text != tt.summary {
+				t.Errorf("expected summary %q, got %q", tt.summary, card.Body[2].Text)
+			}
+		})
+	}
+}
+
+func TestOutputFormatter_Render(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	info, _ := calculator.ParseCIDR("172.16.0.0/24")
+	subnets := calculator.CalculateSubnets(info)
+
+	for _, format := range SupportedFormats {
+		t.Run(format, func(t *testing.T) {
+			output, err := formatter.Render(format, info, subnets)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(output, "172.16.0.0") {
+				t.Errorf("%s output missing network ID", format)
+			}
+		})
+	}
+
+	if _, err := formatter.Render("pdf", info, subnets); err == nil {
+		t.Errorf("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// csvHeaders are the column headings of the CSV export
+var csvHeaders = []string{"Network", "CIDR", "Network ID", "Broadcast", "First Usable", "Last Usable", "Hosts", "Classification"}
+
+// FormatAsCSV generates a CSV table with a header row and one row per subnet
+func (f *OutputFormatter) FormatAsCSV(info *NetworkInfo, subnets []SubnetInfo) (string, error) {
+	return f.FormatReportsAsCSV([]NetworkReport{{Info: info, Subnets: subnets}})
+}
+
+// FormatReportsAsCSV generates a single CSV table covering the subnets of every report.
+// The Network column identifies the parent network so rows can be filtered per network.
+// Failed batch entries follow as rows with an Error column.
+func (f *OutputFormatter) FormatReportsAsCSV(reports []NetworkReport) (string, error) {
+	var output strings.Builder
+	writer := csv.NewWriter(&output)
+
+	tagged := hasTags(reports)
+	header := append([]string(nil), csvHeaders...)
+	if tagged {
+		header = append(header, "Tags")
+	}
+	header = append(header, computedNames(reports)...)
+	if len(f.BatchErrors) > 0 {
+		header = append(header, "Error")
+	}
+	if err := writer.Write(header); err != nil {
+		return "", fmt.Errorf("failed to write CSV header: %v", err)
+	}
+
+	calculator := NewCIDRCalculator()
+	for _, report := range reports {
+		rows, err := f.csvRows(calculator, report, tagged)
+		if err != nil {
+			return "", err
+		}
+		if len(f.BatchErrors) > 0 {
+			for i := range rows {
+				rows[i] = append(rows[i], "")
+			}
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return "", fmt.Errorf("failed to write CSV rows: %v", err)
+		}
+	}
+	if err := writer.WriteAll(f.csvErrorRows(len(header))); err != nil {
+		return "", fmt.Errorf("failed to write CSV rows: %v", err)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %v", err)
+	}
+
+	return output.String(), nil
+}
+
+// csvRows returns the CSV rows for one report. Networks without subnets (/32)
+// are exported as a single row so they still appear in the table. With tagged
+// set, every row carries the network's tags in a Tags column.
+func (f *OutputFormatter) csvRows(calculator *CIDRCalculator, report NetworkReport, tagged bool) ([][]string, error) {
+	network := report.Info.CIDR()
+	if len(report.Subnets) == 0 {
+		row := f.csvRow(network, report.Info)
+		if tagged {
+			row = append(row, report.Info.Tags.String())
+		}
+		return [][]string{row}, nil
+	}
+
+	rows := make([][]string, 0, len(report.Subnets))
+	for _, subnet := range report.Subnets {
+		info, err := calculator.ParseCIDR(subnet.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse subnet %s: %v", subnet.CIDR, err)
+		}
+		row := f.csvRow(network, info)
+		if tagged {
+			row = append(row, report.Info.Tags.String())
+		}
+		for _, field := range subnet.Computed {
+			row = append(row, field.Value)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// csvErrorRows returns a row per failed batch entry, with the entry as
+// written in the Network column and the failure in the last, Error, column
+func (f *OutputFormatter) csvErrorRows(width int) [][]string {
+	rows := make([][]string, 0, len(f.BatchErrors))
+	for _, failure := range f.BatchErrors {
+		row := make([]string, width)
+		row[0] = failure.CIDR
+		row[width-1] = failure.Error()
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// computedNames returns the --compute field names, which every subnet shares
+func computedNames(reports []NetworkReport) []string {
+	for _, report := range reports {
+		for _, subnet := range report.Subnets {
+			names := make([]string, 0, len(subnet.Computed))
+			for _, field := range subnet.Computed {
+				names = append(names, field.Name)
+			}
+			return names
+		}
+	}
+	return nil
+}
+
+// hasTags reports whether any network carries plan file tags
+func hasTags(reports []NetworkReport) bool {
+	for _, report := range reports {
+		if len(report.Info.Tags) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// csvRow returns the CSV columns describing a single network. IPv6 networks
+// have no broadcast address, so that column is left empty.
+func (f *OutputFormatter) csvRow(parent string, info *NetworkInfo) []string {
+	broadcast := info.BroadcastAddr.String()
+	if info.IsIPv6() {
+		broadcast = ""
+	}
+
+	return []string{
+		parent,
+		info.CIDR(),
+		info.NetworkID.String(),
+		broadcast,
+		info.FirstUsableIP.String(),
+		info.LastUsableIP.String(),
+		info.HostCount(),
+		f.classify(info).String(),
+	}
+}
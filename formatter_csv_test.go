@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestOutputFormatter_FormatAsCSV(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	tests := []struct {
+		cidr       string
+		expectRows [][]string
+	}{
+		{
+			cidr: "192.168.1.0/24",
+			expectRows: [][]string{
+				csvHeaders,
+				{"192.168.1.0/24", "192.168.1.0/25", "192.168.1.0", "192.168.1.127", "192.168.1.1", "192.168.1.126", "126", "Private-Use (RFC 1918)"},
+				{"192.168.1.0/24", "192.168.1.128/25", "192.168.1.128", "192.168.1.255", "192.168.1.129", "192.168.1.254", "126", "Private-Use (RFC 1918)"},
+			},
+		},
+		{
+			cidr: "10.0.0.0/31",
+			expectRows: [][]string{
+				csvHeaders,
+				{"10.0.0.0/31", "10.0.0.0/32", "10.0.0.0", "10.0.0.0", "10.0.0.0", "10.0.0.0", "1", "Private-Use (RFC 1918)"},
+				{"10.0.0.0/31", "10.0.0.1/32", "10.0.0.1", "10.0.0.1", "10.0.0.1", "10.0.0.1", "1", "Private-Use (RFC 1918)"},
+			},
+		},
+		{
+			cidr: "10.0.0.1/32",
+			expectRows: [][]string{
+				csvHeaders,
+				{"10.0.0.1/32", "10.0.0.1/32", "10.0.0.1", "10.0.0.1", "10.0.0.1", "10.0.0.1", "1", "Private-Use (RFC 1918)"},
+			},
+		},
+		{
+			cidr: "2001:db8::/127",
+			expectRows: [][]string{
+				csvHeaders,
+				{"2001:db8::/127", "2001:db8::/128", "2001:db8::", "", "2001:db8::", "2001:db8::", "1", "Documentation (RFC 3849)"},
+				{"2001:db8::/127", "2001:db8::1/128", "2001:db8::1", "", "2001:db8::1", "2001:db8::1", "1", "Documentation (RFC 3849)"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			info, err := calculator.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("failed to parse CIDR: %v", err)
+			}
+
+			output, err := formatter.FormatAsCSV(info, calculator.CalculateSubnets(info))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rows, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+			if err != nil {
+				t.Fatalf("output is not valid CSV: %v", err)
+			}
+
+			if len(rows) != len(tt.expectRows) {
+				t.Fatalf("expected %d rows, got %d:\n%s", len(tt.expectRows), len(rows), output)
+			}
+			for i, expected := range tt.expectRows {
+				if strings.Join(rows[i], ",") != strings.Join(expected, ",") {
+					t.Errorf("row %d: expected %v, got %v", i, expected, rows[i])
+				}
+			}
+		})
+	}
+}
+
+func TestOutputFormatter_FormatReportsAsCSV(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	var reports []NetworkReport
+	for _, cidr := range []string{"10.0.0.0/24", "172.16.0.0/30"} {
+		info, err := calculator.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("failed to parse CIDR: %v", err)
+		}
+		reports = append(reports, NetworkReport{Info: info, Subnets: calculator.CalculateSubnets(info)})
+	}
+
+	output, err := formatter.RenderReports(FormatCSV, reports)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+
+	// One shared header followed by two subnets per network
+	if len(rows) != 5 {
+		t.Fatalf("expected 5 rows, got %d:\n%s", len(rows), output)
+	}
+	if strings.Count(output, "Network,CIDR") != 1 {
+		t.Errorf("expected a single header row, got:\n%s", output)
+	}
+	if rows[3][0] != "172.16.0.0/30" || rows[3][1] != "172.16.0.0/31" {
+		t.Errorf("unexpected row for second network: %v", rows[3])
+	}
+
+	// A Tags column appears once any network is tagged
+	reports[1].Info.Tags = Tags{"env": "lab"}
+	output, err = formatter.RenderReports(FormatCSV, reports)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows, err = csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if rows[0][len(rows[0])-1] != "Tags" || rows[1][len(rows[1])-1] != "" || rows[3][len(rows[3])-1] != "env=lab" {
+		t.Errorf("unexpected Tags column:\n%s", output)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonReport is the JSON document root; it holds one network per report, and
+// the failed entries of a batch run, and follows the layout of the XML export
+type jsonReport struct {
+	Networks []jsonNetwork    `json:"networks"`
+	Errors   []jsonBatchError `json:"errors,omitempty"`
+}
+
+// jsonNetwork describes one network and its subnets. IPv6 networks omit the
+// broadcast address and dotted masks.
+type jsonNetwork struct {
+	CIDR         string            `json:"cidr"`
+	NetworkID    string            `json:"networkId"`
+	Broadcast    string            `json:"broadcast,omitempty"`
+	SubnetMask   string            `json:"subnetMask,omitempty"`
+	WildcardMask string            `json:"wildcardMask,omitempty"`
+	PrefixLength int               `json:"prefixLength"`
+	Class        jsonClass         `json:"classification"`
+	Alias        string            `json:"alias,omitempty"`
+	Derivation   *jsonDerivation   `json:"derivation,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	Hosts        jsonHosts         `json:"hosts"`
+	Subnets      jsonSubnets       `json:"subnets"`
+}
+
+// jsonClass is the special-purpose classification of a network; special is
+// false for public and mixed networks
+type jsonClass struct {
+	Name    string `json:"name"`
+	RFC     string `json:"rfc,omitempty"`
+	Special bool   `json:"special"`
+	Pack    string `json:"pack,omitempty"`
+}
+
+// jsonHosts describes the usable host range of a network. The total is a
+// string because IPv6 counts exceed what JSON numbers hold exactly.
+type jsonHosts struct {
+	FirstUsable string `json:"firstUsable"`
+	LastUsable  string `json:"lastUsable"`
+	Total       string `json:"total"`
+}
+
+// jsonSubnets lists the subnets at the listed prefix length. Count is the
+// number listed and Total the number the network has; limited marks a partial
+// list.
+type jsonSubnets struct {
+	PrefixLength int             `json:"prefixLength,omitempty"`
+	Count        int             `json:"count"`
+	Total        string          `json:"total"`
+	Limited      bool            `json:"limited"`
+	Derivation   *jsonDerivation `json:"derivation,omitempty"`
+	Subnets      []jsonSubnet    `json:"subnets"`
+}
+
+// jsonDerivation records the parent network, rule and requested size a
+// network or its subnets were derived by
+type jsonDerivation struct {
+	Parent    string `json:"parent"`
+	Rule      string `json:"rule"`
+	Requested string `json:"requested"`
+}
+
+// jsonSubnet is a single subnet; computed holds the --compute fields
+type jsonSubnet struct {
+	CIDR      string            `json:"cidr"`
+	NetworkID string            `json:"networkId"`
+	Broadcast string            `json:"broadcast,omitempty"`
+	Computed  map[string]string `json:"computed,omitempty"`
+}
+
+// FormatAsJSON generates a JSON document for a single network
+func (f *OutputFormatter) FormatAsJSON(info *NetworkInfo, subnets []SubnetInfo) (string, error) {
+	return f.FormatReportsAsJSON([]NetworkReport{{Info: info, Subnets: subnets}})
+}
+
+// FormatReportsAsJSON generates one JSON document with an entry in networks per report
+func (f *OutputFormatter) FormatReportsAsJSON(reports []NetworkReport) (string, error) {
+	output, err := json.MarshalIndent(f.jsonDocument(reports), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %v", err)
+	}
+
+	return string(output) + "\n", nil
+}
+
+// jsonDocument builds the JSON model of the reports
+func (f *OutputFormatter) jsonDocument(reports []NetworkReport) jsonReport {
+	document := jsonReport{Networks: make([]jsonNetwork, 0, len(reports))}
+
+	for _, report := range reports {
+		info := report.Info
+		network := jsonNetwork{
+			CIDR:         info.CIDR(),
+			NetworkID:    info.NetworkID.String(),
+			Broadcast:    info.BroadcastAddr.String(),
+			SubnetMask:   f.formatIPMask(info.SubnetMask),
+			WildcardMask: f.formatIPMask(info.WildcardMask),
+			PrefixLength: info.PrefixLength,
+			Class:        jsonClass(f.classify(info)),
+			Hosts: jsonHosts{
+				FirstUsable: info.FirstUsableIP.String(),
+				LastUsable:  info.LastUsableIP.String(),
+				Total:       info.HostCount(),
+			},
+			Subnets: jsonSubnets{
+				Count:   len(report.Subnets),
+				Total:   subnetTotal(info.PrefixLength, report.Subnets).String(),
+				Limited: isPartialList(info.PrefixLength, report.Subnets),
+				Subnets: make([]jsonSubnet, 0, len(report.Subnets)),
+			},
+		}
+		if info.IsIPv6() {
+			network.Broadcast, network.SubnetMask, network.WildcardMask = "", "", ""
+		}
+		network.Alias = info.Alias
+		if info.Derivation != nil {
+			network.Derivation = (*jsonDerivation)(info.Derivation)
+		}
+		if len(info.Tags) > 0 {
+			network.Tags = info.Tags
+		}
+		if report.Derivation != nil {
+			network.Subnets.Derivation = (*jsonDerivation)(report.Derivation)
+		}
+
+		if len(report.Subnets) > 0 {
+			network.Subnets.PrefixLength = listedPrefix(info, report.Subnets)
+		}
+		for _, subnet := range report.Subnets {
+			element := jsonSubnet{CIDR: subnet.CIDR, NetworkID: subnet.NetworkID.String()}
+			if !info.IsIPv6() {
+				element.Broadcast = subnet.BroadcastAddr.String()
+			}
+			if len(subnet.Computed) > 0 {
+				element.Computed = make(map[string]string, len(subnet.Computed))
+				for _, field := range subnet.Computed {
+					element.Computed[field.Name] = field.Value
+				}
+			}
+			network.Subnets.Subnets = append(network.Subnets.Subnets, element)
+		}
+
+		document.Networks = append(document.Networks, network)
+	}
+	for _, failure := range f.BatchErrors {
+		document.Errors = append(document.Errors, jsonBatchError{Source: failure.Source, Line: failure.Line, CIDR: failure.CIDR, Error: failure.Err.Error()})
+	}
+
+	return document
+}
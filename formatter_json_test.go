@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOutputFormatter_FormatReportsAsJSON(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	var reports []NetworkReport
+	for _, cidr := range []string{"10.0.0.0/30", "2001:db8::/64", "10.0.0.1/32"} {
+		info, err := calculator.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("failed to parse CIDR: %v", err)
+		}
+		reports = append(reports, NetworkReport{Info: info, Subnets: calculator.CalculateSubnets(info)})
+	}
+	reports[0].Info.Tags = Tags{"env": "prod"}
+	reports[0].Subnets[1].Computed = []ComputedValue{{Name: "vlan", Value: "101"}}
+
+	output, err := formatter.RenderReports(FormatJSON, reports)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var document jsonReport
+	if err := json.Unmarshal([]byte(output), &document); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(document.Networks) != 3 {
+		t.Fatalf("expected 3 networks, got %d", len(document.Networks))
+	}
+
+	ipv4 := document.Networks[0]
+	expected := jsonSubnets{
+		PrefixLength: 31,
+		Count:        2,
+		Total:        "2",
+		Subnets: []jsonSubnet{
+			{CIDR: "10.0.0.0/31", NetworkID: "10.0.0.0", Broadcast: "10.0.0.1"},
+			{CIDR: "10.0.0.2/31", NetworkID: "10.0.0.2", Broadcast: "10.0.0.3", Computed: map[string]string{"vlan": "101"}},
+		},
+	}
+	if ipv4.SubnetMask != "255.255.255.252" || ipv4.Hosts.Total != "2" || !reflect.DeepEqual(ipv4.Subnets, expected) {
+		t.Errorf("unexpected IPv4 network: %+v", ipv4)
+	}
+	if !reflect.DeepEqual(ipv4.Tags, map[string]string{"env": "prod"}) {
+		t.Errorf("unexpected tags: %v", ipv4.Tags)
+	}
+
+	ipv6 := document.Networks[1]
+	if ipv6.Broadcast != "" || ipv6.SubnetMask != "" || ipv6.Hosts.Total != "18446744073709551616" || ipv6.Subnets.PrefixLength != 68 {
+		t.Errorf("unexpected IPv6 network: %+v", ipv6)
+	}
+
+	// A network without subnets still has an empty list rather than null
+	if !strings.Contains(output, `"count": 0,
+        "total": "0",
+        "limited": false,
+        "subnets": []`) {
+		t.Errorf("expected an empty subnet list for the /32, got:\n%s", output)
+	}
+}
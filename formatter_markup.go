@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// subnetTableHeaders are the column headings for subnet tables in document formats
+var subnetTableHeaders = []string{"Subnet", "Network ID", "Broadcast"}
+
+// FormatAsOrg generates an Emacs Org-mode document with tables for each report section
+func (f *OutputFormatter) FormatAsOrg(info *NetworkInfo, subnets []SubnetInfo) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("* CIDR Report: %s/%d\n\n", info.NetworkID.String(), info.PrefixLength))
+
+	output.WriteString("** Network Information\n\n")
+	output.WriteString(orgTable([]string{"Field", "Value"}, factRows(f.networkFacts(info))))
+	output.WriteString("\n")
+
+	output.WriteString("** Host Information\n\n")
+	output.WriteString(orgTable([]string{"Field", "Value"}, factRows(f.hostFacts(info))))
+	output.WriteString("\n")
+
+	output.WriteString("** Subnet Information\n\n")
+	if len(subnets) == 0 {
+		output.WriteString(noSubnetsMessage(info.PrefixLength) + "\n")
+		return output.String()
+	}
+
+	output.WriteString(fmt.Sprintf("Possible /%d subnets: %s\n", listedPrefix(info, subnets), f.Locale.Integer(subnetTotal(info.PrefixLength, subnets).String())))
+	if note := shownNote(info.PrefixLength, subnets); note != "" {
+		output.WriteString("/" + note + "./\n")
+	}
+	output.WriteString("\n")
+	output.WriteString(orgTable(subnetHeaders(info, subnets), f.subnetRows(subnets)))
+
+	return output.String()
+}
+
+// FormatAsRST generates a reStructuredText document with simple tables for each report section
+func (f *OutputFormatter) FormatAsRST(info *NetworkInfo, subnets []SubnetInfo) string {
+	var output strings.Builder
+
+	output.WriteString(rstHeading(fmt.Sprintf("CIDR Report: %s/%d", info.NetworkID.String(), info.PrefixLength), "="))
+
+	output.WriteString(rstHeading("Network Information", "-"))
+	output.WriteString(rstTable([]string{"Field", "Value"}, factRows(f.networkFacts(info))))
+	output.WriteString("\n")
+
+	output.WriteString(rstHeading("Host Information", "-"))
+	output.WriteString(rstTable([]string{"Field", "Value"}, factRows(f.hostFacts(info))))
+	output.WriteString("\n")
+
+	output.WriteString(rstHeading("Subnet Information", "-"))
+	if len(subnets) == 0 {
+		output.WriteString(noSubnetsMessage(info.PrefixLength) + "\n")
+		return output.String()
+	}
+
+	output.WriteString(fmt.Sprintf("Possible /%d subnets: %s\n\n", listedPrefix(info, subnets), f.Locale.Integer(subnetTotal(info.PrefixLength, subnets).String())))
+	if note := shownNote(info.PrefixLength, subnets); note != "" {
+		output.WriteString(".. note:: " + note + ".\n\n")
+	}
+	output.WriteString(rstTable(subnetHeaders(info, subnets), f.subnetRows(subnets)))
+
+	return output.String()
+}
+
+// FormatAsMarkdown generates a Markdown document with pipe tables for each report section
+func (f *OutputFormatter) FormatAsMarkdown(info *NetworkInfo, subnets []SubnetInfo) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("# CIDR Report: %s/%d\n\n", info.NetworkID.String(), info.PrefixLength))
+
+	output.WriteString("## Network Information\n\n")
+	output.WriteString(markdownTable([]string{"Field", "Value"}, factRows(f.networkFacts(info))))
+	output.WriteString("\n")
+
+	output.WriteString("## Host Information\n\n")
+	output.WriteString(markdownTable([]string{"Field", "Value"}, factRows(f.hostFacts(info))))
+	output.WriteString("\n")
+
+	output.WriteString("## Subnet Information\n\n")
+	if len(subnets) == 0 {
+		output.WriteString(noSubnetsMessage(info.PrefixLength) + "\n")
+		return output.String()
+	}
+
+	output.WriteString(fmt.Sprintf("Possible /%d subnets: %s\n\n", listedPrefix(info, subnets), f.Locale.Integer(subnetTotal(info.PrefixLength, subnets).String())))
+	if note := shownNote(info.PrefixLength, subnets); note != "" {
+		output.WriteString("_" + note + "._\n\n")
+	}
+	output.WriteString(markdownTable(subnetHeaders(info, subnets), f.subnetRows(subnets)))
+
+	return output.String()
+}
+
+// FormatAsLaTeX generates booktabs-style LaTeX tables for inclusion in a document
+func (f *OutputFormatter) FormatAsLaTeX(info *NetworkInfo, subnets []SubnetInfo) string {
+	var output strings.Builder
+	cidr := fmt.Sprintf("%s/%d", info.NetworkID.String(), info.PrefixLength)
+
+	output.WriteString(fmt.Sprintf("%% CIDR Report: %s\n", cidr))
+	output.WriteString("% Requires \\usepackage{booktabs} in the document preamble\n\n")
+
+	output.WriteString(latexTable(fmt.Sprintf("Network information for %s", cidr), []string{"Field", "Value"}, factRows(f.networkFacts(info))))
+	output.WriteString("\n")
+	output.WriteString(latexTable(fmt.Sprintf("Host information for %s", cidr), []string{"Field", "Value"}, factRows(f.hostFacts(info))))
+	output.WriteString("\n")
+
+	if len(subnets) == 0 {
+		output.WriteString("% " + noSubnetsMessage(info.PrefixLength) + "\n")
+		return output.String()
+	}
+
+	caption := fmt.Sprintf("Possible /%d subnets of %s (%s)", listedPrefix(info, subnets), cidr, f.Locale.Integer(subnetTotal(info.PrefixLength, subnets).String()))
+	if isPartialList(info.PrefixLength, subnets) {
+		caption += fmt.Sprintf(", showing %d", len(subnets))
+	}
+	output.WriteString(latexTable(caption, subnetHeaders(info, subnets), f.subnetRows(subnets)))
+
+	return output.String()
+}
+
+// columnWidths returns the widest cell of each column including the header
+func columnWidths(headers []string, rows [][]string) []int {
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = len(header)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	return widths
+}
+
+// orgTable renders an aligned Org-mode table with a header separator
+func orgTable(headers []string, rows [][]string) string {
+	var output strings.Builder
+	widths := columnWidths(headers, rows)
+
+	writeRow := func(cells []string) {
+		output.WriteString("|")
+		for i, cell := range cells {
+			output.WriteString(fmt.Sprintf(" %-*s |", widths[i], cell))
+		}
+		output.WriteString("\n")
+	}
+
+	writeRow(headers)
+	output.WriteString("|")
+	for i, width := range widths {
+		output.WriteString(strings.Repeat("-", width+2))
+		if i < len(widths)-1 {
+			output.WriteString("+")
+		}
+	}
+	output.WriteString("|\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+
+	return output.String()
+}
+
+// rstTable renders a reStructuredText simple table
+func rstTable(headers []string, rows [][]string) string {
+	var output strings.Builder
+	widths := columnWidths(headers, rows)
+
+	border := make([]string, len(widths))
+	for i, width := range widths {
+		border[i] = strings.Repeat("=", width)
+	}
+	borderLine := strings.Join(border, "  ") + "\n"
+
+	writeRow := func(cells []string) {
+		padded := make([]string, len(cells))
+		for i, cell := range cells {
+			padded[i] = fmt.Sprintf("%-*s", widths[i], cell)
+		}
+		output.WriteString(strings.TrimRight(strings.Join(padded, "  "), " ") + "\n")
+	}
+
+	output.WriteString(borderLine)
+	writeRow(headers)
+	output.WriteString(borderLine)
+	for _, row := range rows {
+		writeRow(row)
+	}
+	output.WriteString(borderLine)
+
+	return output.String()
+}
+
+// markdownTable renders an aligned GitHub-flavored Markdown pipe table
+func markdownTable(headers []string, rows [][]string) string {
+	var output strings.Builder
+
+	escaped := make([][]string, len(rows))
+	for i, row := range rows {
+		escaped[i] = make([]string, len(row))
+		for j, cell := range row {
+			escaped[i][j] = strings.ReplaceAll(cell, "|", "\\|")
+		}
+	}
+	widths := columnWidths(headers, escaped)
+
+	writeRow := func(cells []string) {
+		output.WriteString("|")
+		for i, cell := range cells {
+			output.WriteString(fmt.Sprintf(" %-*s |", widths[i], cell))
+		}
+		output.WriteString("\n")
+	}
+
+	writeRow(headers)
+	output.WriteString("|")
+	for _, width := range widths {
+		output.WriteString(strings.Repeat("-", width+2) + "|")
+	}
+	output.WriteString("\n")
+	for _, row := range escaped {
+		writeRow(row)
+	}
+
+	return output.String()
+}
+
+// rstHeading renders a section title underlined with the given adornment character
+func rstHeading(title string, adornment string) string {
+	return title + "\n" + strings.Repeat(adornment, len(title)) + "\n\n"
+}
+
+// latexTable renders a captioned booktabs table with a bold header row
+func latexTable(caption string, headers []string, rows [][]string) string {
+	var output strings.Builder
+
+	output.WriteString("\\begin{table}[htbp]\n")
+	output.WriteString("  \\centering\n")
+	output.WriteString(fmt.Sprintf("  \\caption{%s}\n", latexEscape(caption)))
+	output.WriteString(fmt.Sprintf("  \\begin{tabular}{%s}\n", strings.Repeat("l", len(headers))))
+	output.WriteString("    \\toprule\n")
+
+	boldHeaders := make([]string, len(headers))
+	for i, header := range headers {
+		boldHeaders[i] = fmt.Sprintf("\\textbf{%s}", latexEscape(header))
+	}
+	output.WriteString(fmt.Sprintf("    %s \\\\\n", strings.Join(boldHeaders, " & ")))
+	output.WriteString("    \\midrule\n")
+
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = latexEscape(cell)
+		}
+		output.WriteString(fmt.Sprintf("    %s \\\\\n", strings.Join(cells, " & ")))
+	}
+
+	output.WriteString("    \\bottomrule\n")
+	output.WriteString("  \\end{tabular}\n")
+	output.WriteString("\\end{table}\n")
+
+	return output.String()
+}
+
+// latexReplacer escapes characters with special meaning in LaTeX text
+var latexReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// latexEscape makes arbitrary text safe to embed in a LaTeX table cell
+func latexEscape(text string) string {
+	return latexReplacer.Replace(text)
+}
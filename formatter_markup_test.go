@@ -0,0 +1,238 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutputFormatter_FormatAsOrg(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	tests := []struct {
+		name     string
+		cidr     string
+		expected []string
+	}{
+		{
+			name: "standard /24 network",
+			cidr: "192.168.1.0/24",
+			expected: []string{
+				"* CIDR Report: 192.168.1.0/24",
+				"** Network Information",
+				"| Field          | Value                  |",
+				"|----------------+------------------------|",
+				"| Broadcast      | 192.168.1.255          |",
+				"| Classification | Private-Use (RFC 1918) |",
+				"** Host Information",
+				"| Total Hosts  | 254           |",
+				"Possible /25 subnets: 2",
+				"| 192.168.1.128/25 | 192.168.1.128 | 192.168.1.255 |",
+			},
+		},
+		{
+			name: "/32 network has no subnets",
+			cidr: "10.0.0.1/32",
+			expected: []string{
+				"| Host Address | 10.0.0.1 (single host) |",
+				"No subnets available (cannot subnet /32 networks)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := calculator.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("failed to parse CIDR: %v", err)
+			}
+
+			output := formatter.FormatAsOrg(info, calculator.CalculateSubnets(info))
+			for _, exp := range tt.expected {
+				if !strings.Contains(output, exp) {
+					t.Errorf("expected output to contain %q, got:\n%s", exp, output)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputFormatter_FormatAsRST(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	info, err := calculator.ParseCIDR("172.16.0.0/31")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+
+	output := formatter.FormatAsRST(info, calculator.CalculateSubnets(info))
+
+	expected := []string{
+		"CIDR Report: 172.16.0.0/31\n==========================\n",
+		"Network Information\n-------------------\n",
+		"==============  ======================\nField           Value\n==============  ======================\n",
+		"First Address   172.16.0.0 (point-to-point)",
+		"Possible /32 subnets: 2",
+		"172.16.0.1/32  172.16.0.1  172.16.0.1",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(output, exp) {
+			t.Errorf("expected output to contain %q, got:\n%s", exp, output)
+		}
+	}
+}
+
+func TestRSTTable_TrailingWhitespace(t *testing.T) {
+	table := rstTable([]string{"A", "Long header"}, [][]string{{"value", "x"}})
+
+	for _, line := range strings.Split(strings.TrimSuffix(table, "\n"), "\n") {
+		if strings.HasSuffix(line, " ") {
+			t.Errorf("line has trailing whitespace: %q", line)
+		}
+	}
+}
+
+func TestOutputFormatter_FormatAsMarkdown(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	tests := []struct {
+		cidr     string
+		expected []string
+	}{
+		{
+			cidr: "10.0.0.0/30",
+			expected: []string{
+				"# CIDR Report: 10.0.0.0/30\n",
+				"## Network Information\n\n| Field          | Value                  |\n|----------------|------------------------|\n",
+				"| Subnet Mask    | 255.255.255.252        |",
+				"| First Usable | 10.0.0.1 |",
+				"Possible /31 subnets: 2",
+				"| 10.0.0.2/31 | 10.0.0.2   | 10.0.0.3  |",
+			},
+		},
+		{
+			cidr: "10.0.0.1/32",
+			expected: []string{
+				"| Host Address | 10.0.0.1 (single host) |",
+				"No subnets available (cannot subnet /32 networks)",
+			},
+		},
+		{
+			cidr: "2001:db8:abcd::/48",
+			expected: []string{
+				"| Last Address  | 2001:db8:abcd:ffff:ffff:ffff:ffff:ffff |",
+				"| Prefix Length  | /48",
+				"| Addresses     | 1208925819614629174706176",
+				"Possible /52 subnets: 16",
+				"| Subnet                  | First Address        | Last Address",
+			},
+		},
+		{
+			cidr: "2001:db8::1/128",
+			expected: []string{
+				"| Host Address | 2001:db8::1 (single host) |",
+				"No subnets available (cannot subnet /128 networks)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			info, err := calculator.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("failed to parse CIDR: %v", err)
+			}
+
+			output := formatter.FormatAsMarkdown(info, calculator.CalculateSubnets(info))
+			for _, exp := range tt.expected {
+				if !strings.Contains(output, exp) {
+					t.Errorf("expected output to contain %q, got:\n%s", exp, output)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputFormatter_FormatAsMarkdown_Split(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	info, err := calculator.ParseCIDR("192.168.1.0/24")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+	subnets, err := calculator.SplitSubnets(info, 26)
+	if err != nil {
+		t.Fatalf("failed to split: %v", err)
+	}
+
+	output := formatter.FormatAsMarkdown(info, subnets)
+	for _, exp := range []string{"Possible /26 subnets: 4", "| 192.168.1.192/26 | 192.168.1.192 | 192.168.1.255 |"} {
+		if !strings.Contains(output, exp) {
+			t.Errorf("expected output to contain %q, got:\n%s", exp, output)
+		}
+	}
+}
+
+func TestMarkdownTable_EscapesPipes(t *testing.T) {
+	table := markdownTable([]string{"A"}, [][]string{{"x|y"}})
+	if !strings.Contains(table, "| x\\|y |") {
+		t.Errorf("expected pipe to be escaped, got:\n%s", table)
+	}
+}
+
+func TestOutputFormatter_FormatAsLaTeX(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	info, err := calculator.ParseCIDR("10.0.0.0/30")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+
+	output := formatter.FormatAsLaTeX(info, calculator.CalculateSubnets(info))
+
+	expected := []string{
+		"% Requires \\usepackage{booktabs}",
+		"\\caption{Network information for 10.0.0.0/30}",
+		"\\begin{tabular}{ll}",
+		"\\textbf{Field} & \\textbf{Value} \\\\",
+		"Broadcast & 10.0.0.3 \\\\",
+		"Total Hosts & 2 \\\\",
+		"\\caption{Possible /31 subnets of 10.0.0.0/30 (2)}",
+		"\\begin{tabular}{lll}",
+		"10.0.0.2/31 & 10.0.0.2 & 10.0.0.3 \\\\",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(output, exp) {
+			t.Errorf("expected output to contain %q, got:\n%s", exp, output)
+		}
+	}
+
+	// Each table must be balanced booktabs markup
+	for _, marker := range []string{"\\begin{table}", "\\toprule", "\\midrule", "\\bottomrule", "\\end{table}"} {
+		if count := strings.Count(output, marker); count != 3 {
+			t.Errorf("expected 3 occurrences of %q, got %d", marker, count)
+		}
+	}
+}
+
+func TestLatexEscape(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"192.168.1.0/24", "192.168.1.0/24"},
+		{"50% & more", "50\\% \\& more"},
+		{"vlan_10 #1", "vlan\\_10 \\#1"},
+		{"a\\b", "a\\textbackslash{}b"},
+	}
+
+	for _, tt := range tests {
+		if got := latexEscape(tt.input); got != tt.expected {
+			t.Errorf("latexEscape(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
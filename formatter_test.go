@@ -177,6 +177,36 @@ func TestOutputFormatter_FormatSubnets(t *testing.T) {
 	}
 }
 
+func TestSubnetTotal(t *testing.T) {
+	subnet := func(cidr string) SubnetInfo {
+		return SubnetInfo{CIDR: cidr}
+	}
+
+	tests := []struct {
+		name          string
+		prefixLength  int
+		subnets       []SubnetInfo
+		expectedTotal string
+		expectedNote  string
+	}{
+		{"no subnets", 32, nil, "0", ""},
+		{"complete list", 24, []SubnetInfo{subnet("10.0.0.0/25"), subnet("10.0.0.128/25")}, "2", ""},
+		{"partial list", 8, []SubnetInfo{subnet("10.0.0.0/16"), subnet("10.1.0.0/16")}, "256", "Showing 2 of 256 subnets"},
+		{"IPv6", 32, []SubnetInfo{subnet("2001:db8::/128")}, "79228162514264337593543950336", "Showing 1 of 79228162514264337593543950336 subnets"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if total := subnetTotal(tt.prefixLength, tt.subnets).String(); total != tt.expectedTotal {
+				t.Errorf("expected total %s, got %s", tt.expectedTotal, total)
+			}
+			if note := shownNote(tt.prefixLength, tt.subnets); note != tt.expectedNote {
+				t.Errorf("expected note %q, got %q", tt.expectedNote, note)
+			}
+		})
+	}
+}
+
 func TestOutputFormatter_FormatComplete(t *testing.T) {
 	formatter := NewOutputFormatter()
 
@@ -394,11 +424,11 @@ func TestOutputFormatter_FormatAsHTML(t *testing.T) {
 				"<td>254</td>",
 				"<h2>Subnet Information</h2>",
 				"<td>2</td>",
-				"<span class=\"subnet-cidr\">192.168.1.0/25</span>",
-				"<span class=\"subnet-range\">(192.168.1.0 - 192.168.1.127)</span>",
-				"<span class=\"subnet-cidr\">192.168.1.128/25</span>",
-				"<span class=\"subnet-range\">(192.168.1.128 - 192.168.1.255)</span>",
-				"function toggleSubnets()",
+				"<td class=\"subnet-cidr\">192.168.1.0/25</td>",
+				"<td class=\"subnet-range\">192.168.1.0 - 192.168.1.127</td>",
+				"<td class=\"subnet-cidr\">192.168.1.128/25</td>",
+				"<td class=\"subnet-range\">192.168.1.128 - 192.168.1.255</td>",
+				"function toggleSubnets(listId)",
 			},
 		},
 		{
@@ -417,7 +447,7 @@ func TestOutputFormatter_FormatAsHTML(t *testing.T) {
 			expected: []string{
 				"<title>CIDR Calculator Report - 10.0.0.1/32</title>",
 				"<div class=\"cidr\">10.0.0.1/32</div>",
-				"<td>10.0.0.1 <span style=\"color: #666;\">(single host)</span></td>",
+				"<td>10.0.0.1 (single host)</td>",
 				"<td>1</td>",
 				"This is a /32 network representing a single host address",
 				"<div class=\"no-subnets\">",
@@ -439,8 +469,8 @@ func TestOutputFormatter_FormatAsHTML(t *testing.T) {
 			subnets: []SubnetInfo{},
 			expected: []string{
 				"<title>CIDR Calculator Report - 172.16.0.0/31</title>",
-				"<td>172.16.0.0 <span style=\"color: #666;\">(point-to-point)</span></td>",
-				"<td>172.16.0.1 <span style=\"color: #666;\">(point-to-point)</span></td>",
+				"<td>172.16.0.0 (point-to-point)</td>",
+				"<td>172.16.0.1 (point-to-point)</td>",
 				"<td>2</td>",
 				"This is a /31 network typically used for point-to-point links",
 			},
@@ -505,33 +535,131 @@ func TestOutputFormatter_FormatAsHTML_LargeSubnetList(t *testing.T) {
 		PrefixLength:  8,
 	}
 
-	// Create 100 subnets to simulate the performance limit
+	// List the first 100 of the 256 /16 subnets, as --max-subnets 100 does
 	subnets := make([]SubnetInfo, 100)
 	for i := 0; i < 100; i++ {
 		subnets[i] = SubnetInfo{
 			NetworkID:     net.ParseIP(fmt.Sprintf("10.%d.0.0", i)),
-			CIDR:          fmt.Sprintf("10.%d.0.0/9", i),
+			CIDR:          fmt.Sprintf("10.%d.0.0/16", i),
 			BroadcastAddr: net.ParseIP(fmt.Sprintf("10.%d.255.255", i)),
 		}
 	}
 
 	output := formatter.FormatAsHTML(network, subnets)
 
-	// Should contain performance warning
-	if !strings.Contains(output, "Performance Note") {
-		t.Error("HTML output should contain performance warning for large subnet lists")
+	// Should report the true total next to the number listed
+	if !strings.Contains(output, "<td>256</td>") {
+		t.Error("HTML output should report all 256 possible subnets")
 	}
 
-	if !strings.Contains(output, "Showing first 100 subnets") {
-		t.Error("HTML output should mention showing first 100 subnets")
+	if !strings.Contains(output, "Showing 100 of 256 subnets") {
+		t.Error("HTML output should mention showing 100 of 256 subnets")
 	}
 
 	// Should contain toggle functionality
-	if !strings.Contains(output, "toggleSubnets()") {
+	if !strings.Contains(output, "toggleSubnets('subnetList')") {
 		t.Error("HTML output should contain toggle functionality for large subnet lists")
 	}
 }
 
+func TestOutputFormatter_HTMLSubnetTable(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	info := mustParseCIDR(t, calculator, "10.0.0.0/24")
+	subnets := calculator.CalculateSubnets(info)
+	subnets[0].Computed = []ComputedValue{{Name: "usable", Value: "123"}}
+	subnets[1].Computed = []ComputedValue{{Name: "usable", Value: "123"}}
+	output := formatter.FormatAsHTML(info, subnets)
+	for _, expected := range []string{
+		`<input type="search" class="subnet-search" placeholder="Filter by prefix or octet" aria-label="Filter subnets" oninput="filterSubnets('subnetList', this.value)">`,
+		`<th data-sort="network" onclick="sortSubnets('subnetList', 'network')">Subnet</th>`,
+		`<th data-sort="size" onclick="sortSubnets('subnetList', 'size')">Addresses</th>
+                                    <th>usable</th>`,
+		`<tr class="subnet-item" data-cidr="10.0.0.128/25" data-network="0a000080" data-prefix="25">
+                                    <td class="subnet-cidr">10.0.0.128/25</td>
+                                    <td class="subnet-range">10.0.0.128 - 10.0.0.255</td>
+                                    <td class="subnet-range">128</td>
+                                    <td class="subnet-range">123</td>`,
+		"function sortSubnets(listId, key)",
+		"function filterSubnets(listId, query)",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected the subnet table to contain %q", expected)
+		}
+	}
+
+	// IPv6 addresses sort as 32 hex digits; counts follow the locale
+	formatter.Locale, _ = LookupLocale("de-DE")
+	info = mustParseCIDR(t, calculator, "2001:db8::/47")
+	output = formatter.FormatAsHTML(info, calculator.CalculateSubnets(info))
+	if !strings.Contains(output, `data-cidr="2001:db8:1::/48" data-network="20010db8000100000000000000000000" data-prefix="48"`) ||
+		!strings.Contains(output, `<td class="subnet-range">1.208.925.819.614.629.174.706.176</td>`) {
+		t.Errorf("unexpected IPv6 subnet table:\n%s", output)
+	}
+}
+
+func TestOutputFormatter_FormatReportsAsHTML(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	var reports []NetworkReport
+	for _, cidr := range []string{"10.0.0.0/24", "172.16.0.0/30", "192.168.1.1/32"} {
+		info, err := calculator.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("failed to parse CIDR %s: %v", cidr, err)
+		}
+		reports = append(reports, NetworkReport{Info: info, Subnets: calculator.CalculateSubnets(info)})
+	}
+
+	output := formatter.FormatReportsAsHTML(reports)
+
+	expected := []string{
+		"<title>CIDR Calculator Report - 3 networks</title>",
+		"<div class=\"network-heading\">10.0.0.0/24</div>",
+		"<div class=\"network-heading\">172.16.0.0/30</div>",
+		"<div class=\"network-heading\">192.168.1.1/32</div>",
+		"id=\"subnetList\"",
+		"id=\"subnetList-2\"",
+		"toggleSubnets('subnetList-2')",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(output, exp) {
+			t.Errorf("expected HTML output to contain %q", exp)
+		}
+	}
+
+	if count := strings.Count(output, "<h2>Network Information</h2>"); count != 3 {
+		t.Errorf("expected 3 network sections, got %d", count)
+	}
+
+	if count := strings.Count(output, "</html>"); count != 1 {
+		t.Errorf("expected a single HTML document, got %d closing tags", count)
+	}
+}
+
+func TestOutputFormatter_FormatAsHTML_IPv4Facts(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+	formatter.Locale = locales["de-DE"]
+
+	info := mustParseCIDR(t, calculator, "10.20.0.0/16")
+	info.Alias = "prod"
+	info.Tags = Tags{"env": "prod"}
+	output := formatter.FormatAsHTML(info, nil)
+
+	for _, expected := range []string{
+		"<th>Classification</th>\n                        <td>Private-Use (RFC 1918)</td>",
+		"<th>Alias</th>\n                        <td>prod</td>",
+		"<th>Tags</th>\n                        <td>env=prod</td>",
+		"<th>Total Hosts</th>\n                        <td>65.534</td>",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected HTML output to contain %q", expected)
+		}
+	}
+}
+
 func TestOutputFormatter_SaveToFile(t *testing.T) {
 	formatter := NewOutputFormatter()
 
@@ -1163,8 +1291,9 @@ func TestOutputFormatter_HTMLTemplate_Validation(t *testing.T) {
 
 	// Validate JavaScript functionality
 	jsChecks := []string{
-		"function toggleSubnets()",
-		"document.getElementById('subnetList')",
+		"function toggleSubnets(listId)",
+		"document.getElementById(listId)",
+		"document.querySelectorAll('.subnet-list')",
 		"addEventListener('DOMContentLoaded'",
 	}
 
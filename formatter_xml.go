@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// xmlReport is the <cidrReport> root element; it holds one <network> per report
+// and an <errors> list of the failed entries of a batch run
+type xmlReport struct {
+	XMLName  xml.Name        `xml:"cidrReport"`
+	Networks []xmlNetwork    `xml:"network"`
+	Errors   *xmlBatchErrors `xml:"errors,omitempty"`
+}
+
+// xmlNetwork describes one network and its subnets. IPv6 networks omit the
+// broadcast address and dotted masks.
+type xmlNetwork struct {
+	CIDR         string         `xml:"cidr,attr"`
+	Alias        string         `xml:"alias,attr,omitempty"`
+	NetworkID    string         `xml:"networkId"`
+	Broadcast    string         `xml:"broadcast,omitempty"`
+	SubnetMask   string         `xml:"subnetMask,omitempty"`
+	WildcardMask string         `xml:"wildcardMask,omitempty"`
+	PrefixLength int            `xml:"prefixLength"`
+	Class        xmlClass       `xml:"classification"`
+	Derivation   *xmlDerivation `xml:"derivation,omitempty"`
+	Tags         *xmlTags       `xml:"tags,omitempty"`
+	Hosts        xmlHosts       `xml:"hosts"`
+	Subnets      xmlSubnets     `xml:"subnets"`
+}
+
+// xmlClass is the special-purpose classification of a network
+type xmlClass struct {
+	Name    string `xml:"name,attr"`
+	RFC     string `xml:"rfc,attr,omitempty"`
+	Special bool   `xml:"special,attr"`
+	Pack    string `xml:"pack,attr,omitempty"`
+}
+
+// xmlTags lists the plan file tags of a network
+type xmlTags struct {
+	Tags []xmlTag `xml:"tag"`
+}
+
+// xmlTag is a single key=value tag
+type xmlTag struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// xmlHosts describes the usable host range of a network
+type xmlHosts struct {
+	FirstUsable string `xml:"firstUsable"`
+	LastUsable  string `xml:"lastUsable"`
+	Total       string `xml:"total"`
+}
+
+// xmlSubnets lists the subnets at the next prefix length
+type xmlSubnets struct {
+	PrefixLength int            `xml:"prefixLength,attr,omitempty"`
+	Count        int            `xml:"count,attr"`
+	Total        string         `xml:"total,attr"`
+	Limited      bool           `xml:"limited,attr"`
+	Derivation   *xmlDerivation `xml:"derivation,omitempty"`
+	Subnets      []xmlSubnet    `xml:"subnet"`
+}
+
+// xmlDerivation is a <derivation/> element naming the parent network, rule
+// and requested size a network or its subnets were derived by
+type xmlDerivation struct {
+	Parent    string `xml:"parent,attr"`
+	Rule      string `xml:"rule,attr"`
+	Requested string `xml:"requested,attr"`
+}
+
+// xmlSubnet is a single <subnet/> element, with a <field> per computed field
+type xmlSubnet struct {
+	CIDR      string     `xml:"cidr,attr"`
+	NetworkID string     `xml:"networkId,attr"`
+	Broadcast string     `xml:"broadcast,attr,omitempty"`
+	Fields    []xmlField `xml:"field"`
+}
+
+// xmlField is a computed field of a subnet
+type xmlField struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// FormatAsXML generates an XML document for a single network
+func (f *OutputFormatter) FormatAsXML(info *NetworkInfo, subnets []SubnetInfo) (string, error) {
+	return f.FormatReportsAsXML([]NetworkReport{{Info: info, Subnets: subnets}})
+}
+
+// FormatReportsAsXML generates one XML document with a <network> element per report
+func (f *OutputFormatter) FormatReportsAsXML(reports []NetworkReport) (string, error) {
+	document := xmlReport{Networks: make([]xmlNetwork, 0, len(reports))}
+
+	for _, report := range reports {
+		info := report.Info
+		network := xmlNetwork{
+			CIDR:         info.CIDR(),
+			NetworkID:    info.NetworkID.String(),
+			Broadcast:    info.BroadcastAddr.String(),
+			SubnetMask:   f.formatIPMask(info.SubnetMask),
+			WildcardMask: f.formatIPMask(info.WildcardMask),
+			PrefixLength: info.PrefixLength,
+			Class:        xmlClass(f.classify(info)),
+			Hosts: xmlHosts{
+				FirstUsable: info.FirstUsableIP.String(),
+				LastUsable:  info.LastUsableIP.String(),
+				Total:       info.HostCount(),
+			},
+			Subnets: xmlSubnets{
+				Count:   len(report.Subnets),
+				Total:   subnetTotal(info.PrefixLength, report.Subnets).String(),
+				Limited: isPartialList(info.PrefixLength, report.Subnets),
+			},
+		}
+		if info.IsIPv6() {
+			network.Broadcast, network.SubnetMask, network.WildcardMask = "", "", ""
+		}
+		network.Alias = info.Alias
+		if info.Derivation != nil {
+			network.Derivation = (*xmlDerivation)(info.Derivation)
+		}
+		if report.Derivation != nil {
+			network.Subnets.Derivation = (*xmlDerivation)(report.Derivation)
+		}
+		if len(info.Tags) > 0 {
+			network.Tags = &xmlTags{}
+			for _, key := range info.Tags.Keys() {
+				network.Tags.Tags = append(network.Tags.Tags, xmlTag{Key: key, Value: info.Tags[key]})
+			}
+		}
+
+		if len(report.Subnets) > 0 {
+			network.Subnets.PrefixLength = listedPrefix(info, report.Subnets)
+		}
+		for _, subnet := range report.Subnets {
+			element := xmlSubnet{CIDR: subnet.CIDR, NetworkID: subnet.NetworkID.String()}
+			if !info.IsIPv6() {
+				element.Broadcast = subnet.BroadcastAddr.String()
+			}
+			for _, field := range subnet.Computed {
+				element.Fields = append(element.Fields, xmlField{Name: field.Name, Value: field.Value})
+			}
+			network.Subnets.Subnets = append(network.Subnets.Subnets, element)
+		}
+
+		document.Networks = append(document.Networks, network)
+	}
+	if len(f.BatchErrors) > 0 {
+		document.Errors = &xmlBatchErrors{}
+		for _, failure := range f.BatchErrors {
+			document.Errors.Errors = append(document.Errors.Errors, xmlBatchError{Source: failure.Source, Line: failure.Line, CIDR: failure.CIDR, Message: failure.Err.Error()})
+		}
+	}
+
+	output, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode XML: %v", err)
+	}
+
+	return xml.Header + string(output) + "\n", nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOutputFormatter_FormatAsXML(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	tests := []struct {
+		cidr     string
+		expected []string
+	}{
+		{
+			cidr: "192.168.1.0/24",
+			expected: []string{
+				`<?xml version="1.0" encoding="UTF-8"?>`,
+				`<network cidr="192.168.1.0/24">`,
+				"<subnetMask>255.255.255.0</subnetMask>",
+				"<firstUsable>192.168.1.1</firstUsable>",
+				"<total>254</total>",
+				`<subnets prefixLength="25" count="2" total="2" limited="false">`,
+				`<subnet cidr="192.168.1.128/25" networkId="192.168.1.128" broadcast="192.168.1.255"></subnet>`,
+			},
+		},
+		{
+			cidr: "10.0.0.1/32",
+			expected: []string{
+				"<total>1</total>",
+				`<subnets count="0" total="0" limited="false"></subnets>`,
+			},
+		},
+		{
+			cidr: "2001:db8::/64",
+			expected: []string{
+				`<network cidr="2001:db8::/64">`,
+				"<prefixLength>64</prefixLength>\n    <classification name=\"Documentation\" rfc=\"RFC 3849\" special=\"true\"></classification>\n    <hosts>",
+				"<total>18446744073709551616</total>",
+				`<subnets prefixLength="68" count="16" total="16" limited="false">`,
+				`<subnet cidr="2001:db8::/68" networkId="2001:db8::"></subnet>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			info, err := calculator.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("failed to parse CIDR: %v", err)
+			}
+
+			output, err := formatter.FormatAsXML(info, calculator.CalculateSubnets(info))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, exp := range tt.expected {
+				if !strings.Contains(output, exp) {
+					t.Errorf("expected output to contain %q, got:\n%s", exp, output)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputFormatter_FormatReportsAsXML(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	var reports []NetworkReport
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/30"} {
+		info, err := calculator.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("failed to parse CIDR: %v", err)
+		}
+		reports = append(reports, NetworkReport{Info: info, Subnets: calculator.CalculateSubnets(info)})
+	}
+
+	output, err := formatter.RenderReports(FormatXML, reports)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The document must round-trip through the documented structure
+	var document xmlReport
+	if err := xml.Unmarshal([]byte(output), &document); err != nil {
+		t.Fatalf("output is not valid XML: %v", err)
+	}
+
+	if len(document.Networks) != 2 {
+		t.Fatalf("expected 2 networks, got %d", len(document.Networks))
+	}
+	if document.Networks[0].CIDR != "10.0.0.0/8" || document.Networks[0].Subnets.Count != 2 {
+		t.Errorf("unexpected first network: %+v", document.Networks[0])
+	}
+	if document.Networks[1].Hosts.Total != "2" || len(document.Networks[1].Subnets.Subnets) != 2 {
+		t.Errorf("unexpected second network: %+v", document.Networks[1])
+	}
+	if strings.Contains(output, "<tags>") {
+		t.Errorf("expected no tags element for untagged networks")
+	}
+
+	// Plan file tags become tag elements sorted by key
+	reports[0].Info.Tags = Tags{"team": "net", "env": "prod"}
+	output, err = formatter.RenderReports(FormatXML, reports)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	document = xmlReport{}
+	if err := xml.Unmarshal([]byte(output), &document); err != nil {
+		t.Fatalf("output is not valid XML: %v", err)
+	}
+	expected := []xmlTag{{Key: "env", Value: "prod"}, {Key: "team", Value: "net"}}
+	if document.Networks[0].Tags == nil || !reflect.DeepEqual(document.Networks[0].Tags.Tags, expected) || document.Networks[1].Tags != nil {
+		t.Errorf("unexpected tags: %+v / %+v", document.Networks[0].Tags, document.Networks[1].Tags)
+	}
+}
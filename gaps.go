@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// gapSortOrders are the orders gaps can be listed in
+var gapSortOrders = []string{"address", "size"}
+
+// GapReport is the unallocated space of a parent network
+type GapReport struct {
+	Parent  *NetworkInfo
+	Used    []*NetworkInfo // the allocated networks inside the parent
+	Ignored []*NetworkInfo // allocated networks outside the parent
+	Free    []*NetworkInfo // free aligned blocks
+}
+
+// FreeAddresses returns the number of addresses in all free blocks
+func (r *GapReport) FreeAddresses() uint64 {
+	var free uint64
+	for _, block := range r.Free {
+		free += uint64(1) << uint(32-block.PrefixLength)
+	}
+	return free
+}
+
+// TotalAddresses returns the number of addresses of the parent
+func (r *GapReport) TotalAddresses() uint64 {
+	return uint64(1) << uint(32-r.Parent.PrefixLength)
+}
+
+// Gaps returns the free space of an IPv4 parent as the largest aligned blocks
+// not covered by any allocated network. Blocks are in address order, or
+// largest first when bySize is set.
+func (c *CIDRCalculator) Gaps(parent *NetworkInfo, allocated []*NetworkInfo, bySize bool) (*GapReport, error) {
+	if parent.IsIPv6() {
+		return nil, fmt.Errorf("gap analysis supports IPv4 networks only, got %s", parent.CIDR())
+	}
+
+	report := &GapReport{Parent: parent}
+	for _, network := range allocated {
+		if network.IsIPv6() || !parent.Overlaps(network) {
+			report.Ignored = append(report.Ignored, network)
+			continue
+		}
+		report.Used = append(report.Used, network)
+	}
+
+	for _, block := range c.FreeBlocks(parent, report.Used) {
+		info, err := c.ParseCIDR(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse free block %s: %v", block, err)
+		}
+		report.Free = append(report.Free, info)
+	}
+	if bySize {
+		sort.SliceStable(report.Free, func(i, j int) bool { return report.Free[i].PrefixLength < report.Free[j].PrefixLength })
+	}
+	return report, nil
+}
+
+// FormatGaps renders the free blocks with their address ranges and sizes,
+// followed by the totals
+func (f *OutputFormatter) FormatGaps(report *GapReport) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Free Space in %s:\n", report.Parent.CIDR()))
+	if len(report.Free) == 0 {
+		output.WriteString("  no free space\n")
+	} else {
+		output.WriteString(fmt.Sprintf("  %-18s %-33s %s\n", "Block", "Range", "Addresses"))
+	}
+	for _, block := range report.Free {
+		addressRange := fmt.Sprintf("%s - %s", block.NetworkID, block.BroadcastAddr)
+		output.WriteString(fmt.Sprintf("  %-18s %-33s %d\n", block.CIDR(), addressRange, uint64(1)<<uint(32-block.PrefixLength)))
+	}
+
+	free, total := report.FreeAddresses(), report.TotalAddresses()
+	output.WriteString(fmt.Sprintf("\n  Allocated:      %s\n", plural(len(report.Used), "network")))
+	output.WriteString(fmt.Sprintf("  Free:           %d of %d addresses (%.1f%%) in %s\n", free, total, float64(free)*100/float64(total), plural(len(report.Free), "block")))
+	if len(report.Free) > 0 {
+		largest := report.Free[0]
+		for _, block := range report.Free {
+			if block.PrefixLength < largest.PrefixLength {
+				largest = block
+			}
+		}
+		output.WriteString(fmt.Sprintf("  Largest Block:  %s\n", largest.CIDR()))
+	}
+
+	return output.String()
+}
+
+// FormatGapsAsCSV renders one row per free block
+func (f *OutputFormatter) FormatGapsAsCSV(report *GapReport) (string, error) {
+	var output strings.Builder
+	writer := csv.NewWriter(&output)
+
+	if err := writer.Write([]string{"Parent", "Block", "First", "Last", "Addresses"}); err != nil {
+		return "", fmt.Errorf("failed to write CSV header: %v", err)
+	}
+	for _, block := range report.Free {
+		row := []string{report.Parent.CIDR(), block.CIDR(), block.NetworkID.String(), block.BroadcastAddr.String(), strconv.FormatUint(uint64(1)<<uint(32-block.PrefixLength), 10)}
+		if err := writer.Write(row); err != nil {
+			return "", fmt.Errorf("failed to write CSV rows: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %v", err)
+	}
+	return output.String(), nil
+}
+
+// jsonGapReport is the free space of a parent network for scripts
+type jsonGapReport struct {
+	Parent    string         `json:"parent"`
+	Total     uint64         `json:"total"`
+	Free      uint64         `json:"free"`
+	Allocated int            `json:"allocated"`
+	Blocks    []jsonGapBlock `json:"blocks"`
+}
+
+// jsonGapBlock is one free block
+type jsonGapBlock struct {
+	CIDR      string `json:"cidr"`
+	First     string `json:"first"`
+	Last      string `json:"last"`
+	Addresses uint64 `json:"addresses"`
+}
+
+// FormatGapsAsJSON renders the free space as JSON
+func (f *OutputFormatter) FormatGapsAsJSON(report *GapReport) (string, error) {
+	document := jsonGapReport{
+		Parent:    report.Parent.CIDR(),
+		Total:     report.TotalAddresses(),
+		Free:      report.FreeAddresses(),
+		Allocated: len(report.Used),
+		Blocks:    []jsonGapBlock{},
+	}
+	for _, block := range report.Free {
+		document.Blocks = append(document.Blocks, jsonGapBlock{CIDR: block.CIDR(), First: block.NetworkID.String(), Last: block.BroadcastAddr.String(), Addresses: uint64(1) << uint(32-block.PrefixLength)})
+	}
+
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %v", err)
+	}
+	return string(content) + "\n", nil
+}
+
+// runGaps implements the gaps subcommand
+func (c *CLIHandler) runGaps(args []string) error {
+	flagSet := flag.NewFlagSet("gaps", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var inputFile, order, format, outputFile string
+	var tags tagFilterList
+	flagSet.StringVar(&inputFile, "f", "", "Read allocated CIDRs from file, stdin or URL")
+	flagSet.StringVar(&inputFile, "file", "", "Read allocated CIDRs from file, stdin or URL")
+	flagSet.Var(&tags, "tag", "Only count -f entries with this key=value tag, or with the key (repeatable)")
+	flagSet.StringVar(&order, "sort", "address", "Order of the free blocks: address or size (largest first)")
+	flagSet.StringVar(&format, "format", FormatText, "Output format: text, json or csv")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept CIDRs anywhere among the flags
+	cidrs, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	if len(cidrs) == 0 {
+		return fmt.Errorf("gaps requires a parent CIDR")
+	}
+	if len(tags) > 0 && inputFile == "" {
+		return fmt.Errorf("--tag filters the entries of a -f plan file")
+	}
+	if order != gapSortOrders[0] && order != gapSortOrders[1] {
+		return fmt.Errorf("unsupported --sort %s (available: %s)", order, strings.Join(gapSortOrders, ", "))
+	}
+
+	parent, err := c.calculator.ParseCIDR(cidrs[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse CIDR %s: %v", cidrs[0], err)
+	}
+
+	var allocated []*NetworkInfo
+	for _, cidr := range cidrs[1:] {
+		info, err := c.calculator.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("failed to parse CIDR %s: %v", cidr, err)
+		}
+		allocated = append(allocated, info)
+	}
+	if inputFile != "" {
+		entries, err := NewBatchReader(defaultFetchTimeout, nil).Read(inputFile)
+		if err != nil {
+			return err
+		}
+		if entries, err = filterEntries(entries, tags, inputFile); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			info, err := c.calculator.ParseCIDR(entry.CIDR)
+			if err != nil {
+				return fmt.Errorf("%s line %d: failed to parse CIDR: %v", entry.Source, entry.Line, err)
+			}
+			allocated = append(allocated, info)
+		}
+	}
+
+	report, err := c.calculator.Gaps(parent, allocated, order == "size")
+	if err != nil {
+		return err
+	}
+	for _, network := range report.Ignored {
+		c.warnf("%s is outside %s and was ignored", network.CIDR(), parent.CIDR())
+	}
+
+	var content string
+	switch format {
+	case FormatText:
+		content = c.formatter.FormatGaps(report)
+	case FormatJSON:
+		content, err = c.formatter.FormatGapsAsJSON(report)
+	case FormatCSV:
+		content, err = c.formatter.FormatGapsAsCSV(report)
+	default:
+		return fmt.Errorf("gaps supports %s, %s and %s output, not %s", FormatText, FormatJSON, FormatCSV, format)
+	}
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(content, outputFile)
+}
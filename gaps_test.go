@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIDRCalculator_Gaps(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	parent := mustParseCIDR(t, calculator, "10.0.0.0/16")
+	var allocated []*NetworkInfo
+	for _, cidr := range []string{"10.0.8.0/22", "10.0.0.0/24", "10.0.2.0/23", "10.0.2.0/24", "192.168.0.0/24"} {
+		allocated = append(allocated, mustParseCIDR(t, calculator, cidr))
+	}
+
+	report, err := calculator.Gaps(parent, allocated, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var blocks []string
+	for _, block := range report.Free {
+		blocks = append(blocks, block.CIDR())
+	}
+	expected := "10.0.1.0/24 10.0.4.0/22 10.0.12.0/22 10.0.16.0/20 10.0.32.0/19 10.0.64.0/18 10.0.128.0/17"
+	if strings.Join(blocks, " ") != expected {
+		t.Errorf("expected %s, got %v", expected, blocks)
+	}
+	if len(report.Used) != 4 || len(report.Ignored) != 1 || report.FreeAddresses() != 63744 {
+		t.Errorf("expected 4 used, 1 ignored and 63744 free addresses, got %d, %d and %d", len(report.Used), len(report.Ignored), report.FreeAddresses())
+	}
+
+	bySize, _ := calculator.Gaps(parent, allocated, true)
+	if first, last := bySize.Free[0].CIDR(), bySize.Free[len(bySize.Free)-1].CIDR(); first != "10.0.128.0/17" || last != "10.0.1.0/24" {
+		t.Errorf("expected the largest block first and the smallest last, got %s and %s", first, last)
+	}
+
+	full, _ := calculator.Gaps(mustParseCIDR(t, calculator, "10.0.0.0/24"), []*NetworkInfo{mustParseCIDR(t, calculator, "10.0.0.0/8")}, false)
+	if len(full.Free) != 0 || !strings.Contains(NewOutputFormatter().FormatGaps(full), "no free space") {
+		t.Errorf("expected no free space in a covered parent, got %v", full.Free)
+	}
+
+	half, _ := calculator.Gaps(mustParseCIDR(t, calculator, "10.0.0.0/24"), []*NetworkInfo{mustParseCIDR(t, calculator, "10.0.0.0/25")}, false)
+	if text := NewOutputFormatter().FormatGaps(half); !strings.Contains(text, "Allocated:      1 network\n") || !strings.Contains(text, "(50.0%) in 1 block\n") {
+		t.Errorf("expected singular counts, got:\n%s", text)
+	}
+
+	if _, err := calculator.Gaps(mustParseCIDR(t, calculator, "2001:db8::/48"), nil, false); err == nil {
+		t.Error("expected an error for an IPv6 parent")
+	}
+}
+
+func TestCLIHandler_Gaps(t *testing.T) {
+	handler := NewCLIHandler()
+	var stderr bytes.Buffer
+	handler.stderr = &stderr
+	dir := t.TempDir()
+
+	plan := filepath.Join(dir, "allocated.txt")
+	os.WriteFile(plan, []byte("10.0.0.0/25 env=prod\n10.0.0.128/26 env=dev\n172.16.0.0/24 env=prod\n"), 0644)
+
+	output := filepath.Join(dir, "gaps.json")
+	if err := handler.Run([]string{"cidr-calc", "gaps", "10.0.0.0/24", "-f", plan, "--tag", "env=prod", "--format", "json", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	var document jsonGapReport
+	if err := json.Unmarshal(content, &document); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if document.Free != 128 || len(document.Blocks) != 1 || document.Blocks[0].CIDR != "10.0.0.128/25" || document.Blocks[0].Last != "10.0.0.255" {
+		t.Errorf("expected 10.0.0.128/25 free, got %+v", document)
+	}
+	if !strings.Contains(stderr.String(), "172.16.0.0/24 is outside 10.0.0.0/24 and was ignored") {
+		t.Errorf("expected a warning about the outside network, got %q", stderr.String())
+	}
+
+	output = filepath.Join(dir, "gaps.csv")
+	if err := handler.Run([]string{"cidr-calc", "gaps", "10.0.0.0/24", "10.0.0.0/25", "--sort", "size", "--format", "csv", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); string(content) != "Parent,Block,First,Last,Addresses\n10.0.0.0/24,10.0.0.128/25,10.0.0.128,10.0.0.255,128\n" {
+		t.Errorf("unexpected CSV:\n%s", content)
+	}
+
+	for _, args := range [][]string{{"gaps"}, {"gaps", "10.0.0.0/24", "--sort", "name"}, {"gaps", "10.0.0.0/24", "--format", "xml"}, {"gaps", "10.0.0.0/24", "--tag", "env=prod"}} {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
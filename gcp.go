@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"path"
+)
+
+// gcpSubnetwork is a subnetwork as returned by gcloud compute networks subnets list
+type gcpSubnetwork struct {
+	Name              string `json:"name"`
+	Network           string `json:"network"`
+	Region            string `json:"region"`
+	IPCIDRRange       string `json:"ipCidrRange"`
+	SecondaryIPRanges []struct {
+		RangeName   string `json:"rangeName"`
+		IPCIDRRange string `json:"ipCidrRange"`
+	} `json:"secondaryIpRanges"`
+}
+
+// GCPInventorySource lists VPC subnetworks through the gcloud CLI, using the
+// active gcloud account or application default credentials
+type GCPInventorySource struct {
+	project string
+	region  string
+	run     commandRunner
+}
+
+// NewGCPInventorySource creates an inventory source for a project, optionally
+// limited to one region
+func NewGCPInventorySource(project, region string, run commandRunner) *GCPInventorySource {
+	return &GCPInventorySource{project: project, region: region, run: run}
+}
+
+// Inventory returns every VPC network that has subnetworks. GCP VPC networks
+// have no address space of their own, so only their subnetworks carry CIDRs;
+// secondary ranges are listed as additional subnets.
+func (g *GCPInventorySource) Inventory() (*CloudInventory, error) {
+	args := []string{"compute", "networks", "subnets", "list", "--project", g.project, "--format", "json"}
+	if g.region != "" {
+		args = append(args, "--regions", g.region)
+	}
+
+	output, err := g.run("gcloud", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var subnetworks []gcpSubnetwork
+	if err := json.Unmarshal(output, &subnetworks); err != nil {
+		return nil, fmt.Errorf("failed to parse gcloud subnets list output: %v", err)
+	}
+
+	region := g.region
+	if region == "" {
+		region = "all regions"
+	}
+	inventory := &CloudInventory{Provider: "GCP", Region: g.project + "/" + region}
+	index := make(map[string]int)
+
+	for _, subnetwork := range subnetworks {
+		networkName := path.Base(subnetwork.Network)
+		i, ok := index[networkName]
+		if !ok {
+			i = len(inventory.Networks)
+			index[networkName] = i
+			inventory.Networks = append(inventory.Networks, CloudNetwork{ID: networkName})
+		}
+
+		zone := path.Base(subnetwork.Region)
+		network := &inventory.Networks[i]
+		network.Subnets = append(network.Subnets, CloudSubnet{ID: subnetwork.Name, CIDR: subnetwork.IPCIDRRange, Zone: zone})
+		for _, secondary := range subnetwork.SecondaryIPRanges {
+			network.Subnets = append(network.Subnets, CloudSubnet{
+				ID:   subnetwork.Name + "/" + secondary.RangeName,
+				CIDR: secondary.IPCIDRRange,
+				Zone: zone,
+			})
+		}
+	}
+
+	return inventory, nil
+}
+
+// runGCPAudit implements the gcp-audit subcommand
+func (c *CLIHandler) runGCPAudit(args []string) error {
+	flagSet := flag.NewFlagSet("gcp-audit", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var project, region, format, outputFile string
+	var emit stringList
+	flagSet.StringVar(&project, "project", "", "GCP project to audit")
+	flagSet.StringVar(&region, "region", "", "Only audit subnetworks in this region")
+	flagSet.StringVar(&format, "format", FormatText, "Report format: text, gh-annotations or any output format")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	flagSet.Var(&emit, "emit", emitFlagUsage)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	emitTargets, err := parseEmitTargets(emit)
+	if err != nil {
+		return err
+	}
+
+	if project == "" {
+		return fmt.Errorf("gcp-audit requires --project")
+	}
+
+	inventory, err := NewGCPInventorySource(project, region, c.run).Inventory()
+	if err != nil {
+		return err
+	}
+
+	return c.writeCloudAudit(format, outputFile, emitTargets, NewCloudAuditor().Audit(inventory))
+}
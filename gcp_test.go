@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testGCPSubnets = `[
+  {"name": "web", "network": "https://www.googleapis.com/compute/v1/projects/p/global/networks/prod",
+   "region": "https://www.googleapis.com/compute/v1/projects/p/regions/europe-west1",
+   "ipCidrRange": "10.0.0.0/24",
+   "secondaryIpRanges": [{"rangeName": "pods", "ipCidrRange": "10.4.0.0/14"}]},
+  {"name": "db", "network": "https://www.googleapis.com/compute/v1/projects/p/global/networks/prod",
+   "region": "https://www.googleapis.com/compute/v1/projects/p/regions/europe-west4",
+   "ipCidrRange": "10.0.1.0/24"},
+  {"name": "lab", "network": "https://www.googleapis.com/compute/v1/projects/p/global/networks/lab",
+   "region": "https://www.googleapis.com/compute/v1/projects/p/regions/europe-west1",
+   "ipCidrRange": "10.0.1.0/25"}
+]`
+
+func TestGCPInventorySource_Inventory(t *testing.T) {
+	run := fakeCLI("gcloud compute networks subnets list --project p --format json --regions europe-west1", testGCPSubnets)
+
+	inventory, err := NewGCPInventorySource("p", "europe-west1", run).Inventory()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if inventory.Provider != "GCP" || inventory.Region != "p/europe-west1" || len(inventory.Networks) != 2 {
+		t.Fatalf("unexpected inventory: %+v", inventory)
+	}
+
+	prod := inventory.Networks[0]
+	if prod.ID != "prod" || len(prod.CIDRs) != 0 || len(prod.Subnets) != 3 {
+		t.Fatalf("unexpected network: %+v", prod)
+	}
+	if prod.Subnets[1] != (CloudSubnet{ID: "web/pods", CIDR: "10.4.0.0/14", Zone: "europe-west1"}) {
+		t.Errorf("expected secondary range as subnet, got %+v", prod.Subnets[1])
+	}
+
+	if _, err := NewGCPInventorySource("p", "", fakeCLI("gcloud", "[")).Inventory(); err == nil {
+		t.Errorf("expected error for invalid JSON")
+	}
+}
+
+func TestCLIHandler_GCPAudit(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.run = fakeCLI("gcloud compute networks subnets list --project p", testGCPSubnets)
+
+	output := filepath.Join(t.TempDir(), "audit.txt")
+	if err := handler.Run([]string{"cidr-calc", "gcp-audit", "--project", "p", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	expected := []string{
+		"GCP Audit (p/all regions):",
+		"Network prod:\n",
+		"    10.4.0.0/14        web/pods europe-west1",
+		"10.0.1.0/25 (lab) overlaps 10.0.1.0/24 (prod); they cannot be peered [network-overlap]",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected report to contain %q, got:\n%s", exp, content)
+		}
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "gcp-audit"}); err == nil || !strings.Contains(err.Error(), "requires --project") {
+		t.Errorf("expected missing project error, got %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Names of the ranges of a GCP regional subnet
+const (
+	gcpRangePrimary  = "primary"
+	gcpRangePods     = "pods"
+	gcpRangeServices = "services"
+)
+
+// regionList parses --regions: the comma separated names of the regions to
+// lay out a GCP subnet in
+type regionList []string
+
+// String returns the region names
+func (r *regionList) String() string {
+	return strings.Join(*r, ",")
+}
+
+// Set reads the region names
+func (r *regionList) Set(value string) error {
+	regions, err := parseNameList(value, "region")
+	if err != nil {
+		return err
+	}
+	*r = regions
+	return nil
+}
+
+// GCPRange is the primary range of a regional subnet, or one of its
+// secondary ranges for GKE pods and services
+type GCPRange struct {
+	Region string
+	Name   string
+	Subnet *NetworkInfo
+}
+
+// GCPPlan lays out a subnet per region, with its secondary ranges, inside a
+// parent block
+type GCPPlan struct {
+	Network   *NetworkInfo
+	Ranges    []GCPRange // by region, the primary range first
+	Allocated uint64
+	Free      []string
+	Reserved  int // addresses GCP reserves in every primary range
+}
+
+// AllocateGCPPlan gives every region a primary range of the primary prefix
+// length and, when pods or services is set, secondary ranges of those prefix
+// lengths, all packed into the IPv4 parent block from the start. Like VLSM plans
+// the largest ranges are placed first so every range stays aligned.
+func (c *CIDRCalculator) AllocateGCPPlan(network *NetworkInfo, regions []string, primary, pods, services int) (*GCPPlan, error) {
+	if network.IsIPv6() {
+		return nil, fmt.Errorf("GCP regional plans support IPv4 parent blocks only")
+	}
+
+	type request struct {
+		region, name string
+		prefix       int
+	}
+	var requests []request
+	for _, region := range regions {
+		requests = append(requests, request{region, gcpRangePrimary, primary})
+		if pods != 0 {
+			requests = append(requests, request{region, gcpRangePods, pods})
+		}
+		if services != 0 {
+			requests = append(requests, request{region, gcpRangeServices, services})
+		}
+	}
+
+	order := make([]int, len(requests))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return requests[order[i]].prefix < requests[order[j]].prefix })
+
+	start := uint64(ipv4ToUint32(network.NetworkID))
+	end := uint64(ipv4ToUint32(network.BroadcastAddr))
+	size := end - start + 1
+
+	plan := &GCPPlan{Network: network, Ranges: make([]GCPRange, len(requests))}
+	next := start
+	for _, i := range order {
+		req := requests[i]
+		if req.prefix < network.PrefixLength {
+			return nil, fmt.Errorf("a /%d %s range does not fit in %s", req.prefix, req.name, network.CIDR())
+		}
+
+		blockSize := uint64(1) << uint(32-req.prefix)
+		if next+blockSize-1 > end {
+			return nil, fmt.Errorf("%s cannot fit the ranges of %s: the %s range of %s needs a /%d but only %d of %d addresses remain",
+				network.CIDR(), plural(len(regions), "region"), req.name, req.region, req.prefix, end+1-next, size)
+		}
+
+		subnet, err := c.ParseCIDR(fmt.Sprintf("%s/%d", uint32ToIPv4(uint32(next)), req.prefix))
+		if err != nil {
+			return nil, err
+		}
+		plan.Ranges[i] = GCPRange{Region: req.region, Name: req.name, Subnet: subnet}
+		plan.Allocated += blockSize
+		next += blockSize
+	}
+
+	if next <= end {
+		plan.Free = alignedBlocks(next, end)
+	}
+
+	return plan, nil
+}
+
+// Usable returns the addresses of a range left for hosts, pods or services.
+// GCP reserves addresses in primary ranges only.
+func (p *GCPPlan) Usable(r GCPRange) uint64 {
+	size := uint64(1) << uint(32-r.Subnet.PrefixLength)
+	if r.Name != gcpRangePrimary {
+		return size
+	}
+	if size < uint64(p.Reserved) {
+		return 0
+	}
+	return size - uint64(p.Reserved)
+}
+
+// Size returns the number of addresses in the parent block
+func (p *GCPPlan) Size() uint64 {
+	return uint64(1) << uint(32-p.Network.PrefixLength)
+}
+
+// FormatGCPPlan renders the ranges of every region and the remaining free
+// space as text
+func (f *OutputFormatter) FormatGCPPlan(plan *GCPPlan) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("GCP Plan for %s:\n", plan.Network.CIDR()))
+	output.WriteString(fmt.Sprintf("  %-24s %-9s %-18s %s\n", "Region", "Range", "Subnet", "Usable"))
+	for _, r := range plan.Ranges {
+		output.WriteString(fmt.Sprintf("  %-24s %-9s %-18s %d\n", r.Region, r.Name, r.Subnet.CIDR(), plan.Usable(r)))
+	}
+
+	output.WriteString("\n")
+	output.WriteString(fmt.Sprintf("  %-15s %d of %d addresses (%.1f%%)\n", "Allocated:", plan.Allocated, plan.Size(),
+		float64(plan.Allocated)*100/float64(plan.Size())))
+
+	output.WriteString("\nFree Space:\n")
+	if len(plan.Free) == 0 {
+		output.WriteString("  none\n")
+	}
+	for _, block := range plan.Free {
+		output.WriteString(fmt.Sprintf("  %s\n", block))
+	}
+
+	return output.String()
+}
+
+// FormatGCPPlanAsCSV renders the plan as CSV with the subnet columns of the
+// regular export between the Region and Range columns and a Usable column.
+// Free blocks follow the ranges with empty Region, Range and Usable columns.
+func (f *OutputFormatter) FormatGCPPlanAsCSV(plan *GCPPlan) (string, error) {
+	var output strings.Builder
+	writer := csv.NewWriter(&output)
+
+	header := append(append([]string{"Region", "Range"}, csvHeaders...), "Usable")
+	if err := writer.Write(header); err != nil {
+		return "", fmt.Errorf("failed to write CSV header: %v", err)
+	}
+
+	parent := plan.Network.CIDR()
+	for _, r := range plan.Ranges {
+		row := append(append([]string{r.Region, r.Name}, f.csvRow(parent, r.Subnet)...), fmt.Sprint(plan.Usable(r)))
+		if err := writer.Write(row); err != nil {
+			return "", fmt.Errorf("failed to write CSV rows: %v", err)
+		}
+	}
+
+	calculator := NewCIDRCalculator()
+	for _, block := range plan.Free {
+		info, err := calculator.ParseCIDR(block)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse free block %s: %v", block, err)
+		}
+		if err := writer.Write(append(append([]string{"", ""}, f.csvRow(parent, info)...), "")); err != nil {
+			return "", fmt.Errorf("failed to write CSV rows: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %v", err)
+	}
+
+	return output.String(), nil
+}
+
+// validateRegions checks the GCP regional plan flags of --cloud
+func validateRegions(config *Config) error {
+	gcp := strings.EqualFold(config.Cloud.Name, "gcp")
+	regional := len(config.Regions) > 0 || config.Pods != 0 || config.Services != 0
+	switch {
+	case gcp && len(config.Zones) > 0:
+		return fmt.Errorf("GCP subnets are regional: use --regions rather than --azs")
+	case !regional:
+		return nil
+	case !gcp:
+		return fmt.Errorf("--regions, --pods and --services apply to --cloud gcp only")
+	case len(config.Regions) == 0:
+		return fmt.Errorf("--pods and --services require --regions")
+	case config.Split == 0 && config.Hosts == 0:
+		return fmt.Errorf("--regions needs --hosts or --split to size the primary ranges")
+	case config.InputFile != "":
+		return fmt.Errorf("--regions cannot be combined with -f")
+	case len(config.CIDRs) > 1:
+		return fmt.Errorf("--regions takes a single parent block, got %d", len(config.CIDRs))
+	case config.MaxSubnets > 0 || config.AllSubnets:
+		return fmt.Errorf("--max-subnets and --all do not apply to --regions")
+	}
+
+	for _, secondary := range []struct {
+		flag   string
+		prefix int
+	}{{"--pods", config.Pods}, {"--services", config.Services}} {
+		if secondary.prefix < 0 || secondary.prefix > 32 {
+			return fmt.Errorf("%s must be a prefix length between 1 and 32, got %d", secondary.flag, secondary.prefix)
+		}
+	}
+	return nil
+}
+
+// runGCPPlan lays out a subnet with its secondary ranges for every --regions
+// region inside the network and writes the plan
+func (c *CLIHandler) runGCPPlan(networkInfo *NetworkInfo, config *Config) error {
+	rules := config.Cloud
+	primary := config.Split
+	if config.Hosts != 0 {
+		primary = cloudPrefix(config, false, prefixForHosts(false, cloudHosts(config, false)))
+	}
+	if err := rules.CheckPrefix(false, primary); err != nil {
+		return fmt.Errorf("--validate-for %s: primary ranges: %v", strings.ToLower(rules.Name), err)
+	}
+
+	plan, err := c.calculator.AllocateGCPPlan(networkInfo, config.Regions, primary, config.Pods, config.Services)
+	if err != nil {
+		return err
+	}
+	plan.Reserved = rules.Reserved
+
+	for _, r := range plan.Ranges {
+		if violation, ok := config.Reserved.Violation(r.Subnet); ok {
+			return fmt.Errorf("%s for the %s range of %s %s", r.Subnet.CIDR(), r.Name, r.Region, violation)
+		}
+	}
+	c.notef("%s reserves %d addresses in every primary range and none in secondary ranges; the Usable column leaves them out",
+		rules.Name, rules.Reserved)
+
+	var content string
+	switch format := config.OutputFormat(); format {
+	case FormatText:
+		content = c.formatter.FormatGCPPlan(plan)
+	case FormatCSV:
+		if content, err = c.formatter.FormatGCPPlanAsCSV(plan); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("--regions supports %s and %s output, not %s", FormatText, FormatCSV, format)
+	}
+
+	return c.writeOutput(content, config.OutputFile)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIDRCalculator_AllocateGCPPlan(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	network, err := calculator.ParseCIDR("10.0.0.0/12")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+
+	plan, err := calculator.AllocateGCPPlan(network, []string{"us-central1", "europe-west1"}, 22, 14, 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	plan.Reserved = 4
+
+	expected := []string{
+		"us-central1 primary 10.8.32.0/22 1020",
+		"us-central1 pods 10.0.0.0/14 262144",
+		"us-central1 services 10.8.0.0/20 4096",
+		"europe-west1 primary 10.8.36.0/22 1020",
+		"europe-west1 pods 10.4.0.0/14 262144",
+		"europe-west1 services 10.8.16.0/20 4096",
+	}
+	if len(plan.Ranges) != len(expected) {
+		t.Fatalf("expected %d ranges, got %d", len(expected), len(plan.Ranges))
+	}
+	for i, r := range plan.Ranges {
+		if got := fmt.Sprintf("%s %s %s %d", r.Region, r.Name, r.Subnet.CIDR(), plan.Usable(r)); got != expected[i] {
+			t.Errorf("range %d: expected %q, got %q", i, expected[i], got)
+		}
+	}
+	if plan.Allocated != 534528 || plan.Free[0] != "10.8.40.0/21" {
+		t.Errorf("unexpected allocated space %d and free blocks %v", plan.Allocated, plan.Free)
+	}
+
+	if _, err := calculator.AllocateGCPPlan(network, []string{"a"}, 22, 8, 0); err == nil || err.Error() != "a /8 pods range does not fit in 10.0.0.0/12" {
+		t.Errorf("expected the pods range not to fit, got %v", err)
+	}
+	if _, err := calculator.AllocateGCPPlan(network, []string{"a", "b", "c"}, 13, 0, 0); err == nil || !strings.HasPrefix(err.Error(), "10.0.0.0/12 cannot fit the ranges of 3 regions: the primary range of c needs a /13") {
+		t.Errorf("expected the regions not to fit, got %v", err)
+	}
+}
+
+func TestCLIHandler_CloudGCP(t *testing.T) {
+	var stderr strings.Builder
+	handler := NewCLIHandler()
+	handler.stderr = &stderr
+	dir := t.TempDir()
+
+	// Without --regions GCP plans subnets like the other providers
+	output := filepath.Join(dir, "subnets.txt")
+	if err := handler.Run([]string{"cidr-calc", "--cloud", "gcp", "--hosts", "1000", "-o", output, "10.128.0.0/20"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	if !strings.Contains(string(content), "10.128.4.0/22      (10.128.4.0 - 10.128.7.255)  usable=1020") {
+		t.Errorf("unexpected report:\n%s", content)
+	}
+	if !strings.Contains(stderr.String(), "GCP reserves 4 addresses in every subnet: 4 /22 subnets of 10.128.0.0/20 with 1020 usable hosts each, 4080 in total") {
+		t.Errorf("unexpected notes: %q", stderr.String())
+	}
+
+	output = filepath.Join(dir, "regions.csv")
+	if err := handler.Run([]string{"cidr-calc", "--cloud", "gcp", "--regions", "us-east1,us-west1", "--hosts", "250", "--pods", "16", "-o", output, "10.0.0.0/14"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(output)
+	for _, exp := range []string{
+		"Region,Range,Network,CIDR,Network ID,Broadcast,First Usable,Last Usable,Hosts,Classification,Usable\n",
+		"us-east1,primary,10.0.0.0/14,10.2.0.0/24,10.2.0.0,10.2.0.255,10.2.0.1,10.2.0.254,254,Private-Use (RFC 1918),252\n",
+		"us-west1,pods,10.0.0.0/14,10.1.0.0/16,10.1.0.0,10.1.255.255,10.1.0.1,10.1.255.254,65534,Private-Use (RFC 1918),65536\n",
+		",,10.0.0.0/14,10.2.2.0/23,",
+	} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected %q in:\n%s", exp, content)
+		}
+	}
+
+	// Primary ranges overlapping a reserved range fail the plan
+	reserved := filepath.Join(dir, "reserved.txt")
+	if err := os.WriteFile(reserved, []byte("10.2.0.0/24 # transit\n"), 0644); err != nil {
+		t.Fatalf("failed to write reserved ranges: %v", err)
+	}
+	err := handler.Run([]string{"cidr-calc", "--cloud", "gcp", "--regions", "us-east1", "--hosts", "250", "--pods", "15", "--reserved", reserved, "-o", output, "10.0.0.0/14"})
+	if err == nil || !strings.HasPrefix(err.Error(), "10.2.0.0/24 for the primary range of us-east1 ") {
+		t.Errorf("expected a reserved range violation, got %v", err)
+	}
+
+	handler.stderr = io.Discard
+	for args, exp := range map[string]string{
+		"--regions a --hosts 10 10.0.0.0/16":                           "--regions, --pods and --services require --cloud gcp",
+		"--cloud aws --regions a --hosts 10 10.0.0.0/16":               "--regions, --pods and --services apply to --cloud gcp only",
+		"--cloud gcp --azs 2 --hosts 10 10.0.0.0/16":                   "GCP subnets are regional: use --regions rather than --azs",
+		"--cloud gcp --pods 14 --hosts 10 10.0.0.0/8":                  "--pods and --services require --regions",
+		"--cloud gcp --regions a 10.0.0.0/16":                          "--regions needs --hosts or --split to size the primary ranges",
+		"--cloud gcp --regions a --split 24 --services 33 10.0.0.0/16": "--services must be a prefix length between 1 and 32, got 33",
+		"--cloud gcp --regions a --split 30 10.0.0.0/16":               "--validate-for gcp: primary ranges: a /30 subnet is smaller than the /29 minimum GCP allows",
+		"--cloud gcp --regions a --split 24 --format json 10.0.0.0/16": "--regions supports text and csv output, not json",
+	} {
+		err := handler.Run(append([]string{"cidr-calc"}, strings.Fields(args)...))
+		if err == nil || err.Error() != exp {
+			t.Errorf("%s: expected %q, got %v", args, exp, err)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// GitPlanSource reads plan files from git history
+type GitPlanSource struct {
+	repoDir string
+}
+
+// NewGitPlanSource creates a plan source for the repository at repoDir
+func NewGitPlanSource(repoDir string) *GitPlanSource {
+	return &GitPlanSource{repoDir: repoDir}
+}
+
+// ResolveRange splits a "base..head" or "base...head" range into the two refs to compare.
+// An omitted head means HEAD; the three-dot form compares against the merge base.
+func (g *GitPlanSource) ResolveRange(refRange string) (string, string, error) {
+	separator := ".."
+	if strings.Contains(refRange, "...") {
+		separator = "..."
+	}
+
+	base, head, found := strings.Cut(refRange, separator)
+	if !found || base == "" {
+		return "", "", fmt.Errorf("invalid ref range %q (expected base..head)", refRange)
+	}
+	if head == "" {
+		head = "HEAD"
+	}
+
+	if separator == "..." {
+		mergeBase, err := g.git("merge-base", base, head)
+		if err != nil {
+			return "", "", err
+		}
+		base = strings.TrimSpace(mergeBase)
+	}
+
+	return base, head, nil
+}
+
+// Entries reads every CIDR from the plan files under paths as they exist at ref
+func (g *GitPlanSource) Entries(ref string, paths []string) ([]BatchEntry, error) {
+	args := append([]string{"ls-tree", "-r", "--name-only", ref, "--"}, paths...)
+	listing, err := g.git(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []BatchEntry
+	for _, file := range strings.Split(strings.TrimSpace(listing), "\n") {
+		if file == "" {
+			continue
+		}
+
+		content, err := g.git("show", ref+":"+file)
+		if err != nil {
+			return nil, err
+		}
+
+		fileEntries, err := parseBatch(file, strings.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	return entries, nil
+}
+
+// git runs a git command in the repository and returns its standard output
+func (g *GitPlanSource) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", g.repoDir}, args...)...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed: %s", args[0], message)
+	}
+
+	return stdout.String(), nil
+}
+
+// runGitReport implements the git-report subcommand
+func (c *CLIHandler) runGitReport(args []string) error {
+	flagSet := flag.NewFlagSet("git-report", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var refRange, repoDir, outputFile, format string
+	flagSet.StringVar(&refRange, "ref", "", "Git ref range to compare (base..head)")
+	flagSet.StringVar(&format, "format", FormatText, "Report format: text, gh-annotations")
+	flagSet.StringVar(&repoDir, "repo", ".", "Path to the git repository")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept paths anywhere among the flags
+	paths, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	if refRange == "" {
+		return fmt.Errorf("git-report requires --ref base..head")
+	}
+
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	source := NewGitPlanSource(repoDir)
+
+	base, head, err := source.ResolveRange(refRange)
+	if err != nil {
+		return err
+	}
+
+	baseEntries, err := source.Entries(base, paths)
+	if err != nil {
+		return err
+	}
+
+	headEntries, err := source.Entries(head, paths)
+	if err != nil {
+		return err
+	}
+
+	diff := NewPlanDiffer().Diff(baseEntries, headEntries)
+
+	switch format {
+	case FormatText:
+		return c.writeOutput(c.formatter.FormatPlanDiff(refRange, diff), outputFile)
+	case FormatGitHubAnnotations:
+		// A clean diff has nothing to annotate
+		annotations := c.formatter.FormatFindingsAsGitHub(diff.Findings())
+		if annotations == "" {
+			return nil
+		}
+		return c.writeOutput(annotations, outputFile)
+	default:
+		return fmt.Errorf("unsupported git-report format: %s (supported: %s, %s)", format, FormatText, FormatGitHubAnnotations)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestPlanRepo creates a git repository with a base commit on main and a change on feature
+func newTestPlanRepo(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	run("init", "-q", "-b", "main")
+	write("plans/prod.txt", "10.0.0.0/16\n10.2.0.0/24\n")
+	write("README.md", "not a plan\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "base")
+
+	run("checkout", "-q", "-b", "feature")
+	write("plans/prod.txt", "10.0.0.0/16\n10.3.0.0/24\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "change")
+
+	return dir
+}
+
+func TestGitPlanSource_ResolveRange(t *testing.T) {
+	source := NewGitPlanSource(newTestPlanRepo(t))
+
+	tests := []struct {
+		refRange    string
+		expectBase  string
+		expectHead  string
+		expectError bool
+	}{
+		{refRange: "main..feature", expectBase: "main", expectHead: "feature"},
+		{refRange: "main..", expectBase: "main", expectHead: "HEAD"},
+		{refRange: "feature", expectError: true},
+		{refRange: "..feature", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.refRange, func(t *testing.T) {
+			base, head, err := source.ResolveRange(tt.refRange)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if base != tt.expectBase || head != tt.expectHead {
+				t.Errorf("expected %s..%s, got %s..%s", tt.expectBase, tt.expectHead, base, head)
+			}
+		})
+	}
+
+	// Three-dot ranges compare against the merge base commit
+	base, head, err := source.ResolveRange("main...feature")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(base) != 40 || head != "feature" {
+		t.Errorf("expected merge base commit and feature, got %s..%s", base, head)
+	}
+}
+
+func TestCLIHandler_GitReport(t *testing.T) {
+	repo := newTestPlanRepo(t)
+	handler := NewCLIHandler()
+
+	output := filepath.Join(t.TempDir(), "diff.txt")
+	if err := handler.Run([]string{"cidr-calc", "git-report", "--repo", repo, "--ref", "main..feature", "-o", output, "plans/"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+
+	expected := []string{
+		"Plan Changes (main..feature):",
+		"+ 10.3.0.0/24",
+		"- 10.2.0.0/24",
+		"Summary: 1 added, 1 removed",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected report to contain %q, got:\n%s", exp, content)
+		}
+	}
+
+	annotations := filepath.Join(t.TempDir(), "annotations.txt")
+	if err := handler.Run([]string{"cidr-calc", "git-report", "--repo", repo, "--ref", "main..feature", "--format", "gh-annotations", "-o", annotations, "plans/"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(annotations); !os.IsNotExist(err) {
+		t.Errorf("expected no annotations file for a conflict-free change")
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "git-report", "--repo", repo, "--ref", "main..feature", "--format", "html", "plans/"}); err == nil {
+		t.Errorf("expected error for unsupported format")
+	}
+	// Flags may follow the paths
+	if err := handler.Run([]string{"cidr-calc", "git-report", "--repo", repo, "--ref", "main..feature", "plans/", "--format", "html"}); err == nil {
+		t.Errorf("expected error for unsupported format after the paths")
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "git-report", "--repo", repo, "plans/"}); err == nil {
+		t.Errorf("expected error when --ref is missing")
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "git-report", "--repo", repo, "--ref", "main..no-such-branch"}); err == nil {
+		t.Errorf("expected error for unknown ref")
+	}
+}
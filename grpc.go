@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultGRPCListenAddr is the address the grpc command listens on without --listen
+const defaultGRPCListenAddr = ":9090"
+
+// grpcService is the service name of the RPC paths, from proto/cidrcalc.proto
+const grpcService = "/cidrcalc.v1.CIDRCalculator/"
+
+// gRPC status codes returned by the server
+const (
+	grpcOK              = 0
+	grpcInvalidArgument = 3
+	grpcUnimplemented   = 12
+	grpcInternal        = 13
+)
+
+// grpcRelations maps relations to the values of the Relation enum
+var grpcRelations = map[string]uint64{
+	RelationEqual:    1,
+	RelationContains: 2,
+	RelationWithin:   3,
+	RelationDisjoint: 4,
+}
+
+// grpcError is an RPC failure with its gRPC status code
+type grpcError struct {
+	code    int
+	message string
+}
+
+// Error returns the status message
+func (e *grpcError) Error() string {
+	return e.message
+}
+
+// invalidArgument wraps a calculation error as an INVALID_ARGUMENT status
+func invalidArgument(err error) error {
+	return &grpcError{code: grpcInvalidArgument, message: err.Error()}
+}
+
+// GRPCServer serves the CIDRCalculator service of proto/cidrcalc.proto. It
+// speaks the gRPC wire protocol directly over net/http, which offers HTTP/2
+// on TLS connections.
+type GRPCServer struct {
+	handler *CLIHandler
+	methods map[string]func(request []byte) ([]byte, error)
+}
+
+// NewGRPCServer creates a gRPC server that calculates with the handler
+func NewGRPCServer(handler *CLIHandler) *GRPCServer {
+	s := &GRPCServer{handler: handler}
+	s.methods = map[string]func(request []byte) ([]byte, error){
+		"Calculate": s.calculate,
+		"Split":     s.split,
+		"Aggregate": s.aggregate,
+		"Contains":  s.contains,
+	}
+	return s
+}
+
+// ServeHTTP implements http.Handler for unary gRPC calls
+func (s *GRPCServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "gRPC requests must be POST with content type application/grpc", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+
+	method, ok := s.methods[strings.TrimPrefix(r.URL.Path, grpcService)]
+	if !ok || !strings.HasPrefix(r.URL.Path, grpcService) {
+		s.fail(w, &grpcError{code: grpcUnimplemented, message: fmt.Sprintf("unknown method %s", r.URL.Path)})
+		return
+	}
+
+	request, err := readGRPCMessage(r.Body)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	response, err := method(request)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+
+	// Trailers follow the response message
+	w.Header().Set("Trailer", "Grpc-Status")
+	w.WriteHeader(http.StatusOK)
+	frame := make([]byte, 5, 5+len(response))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(response)))
+	if _, err := w.Write(append(frame, response...)); err != nil {
+		s.handler.warnf("failed to write gRPC response: %v", err)
+	}
+	w.Header().Set("Grpc-Status", strconv.Itoa(grpcOK))
+}
+
+// fail sends a trailers-only response carrying the error status
+func (s *GRPCServer) fail(w http.ResponseWriter, err error) {
+	status, ok := err.(*grpcError)
+	if !ok {
+		status = &grpcError{code: grpcInternal, message: err.Error()}
+	}
+	w.Header().Set("Grpc-Status", strconv.Itoa(status.code))
+	w.Header().Set("Grpc-Message", grpcPercentEncode(status.message))
+	w.WriteHeader(http.StatusOK)
+}
+
+// readGRPCMessage reads the single length-prefixed message of a unary call
+func readGRPCMessage(body io.Reader) ([]byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(body, header); err != nil {
+		return nil, invalidArgument(fmt.Errorf("failed to read request message: %v", err))
+	}
+	if header[0] != 0 {
+		return nil, &grpcError{code: grpcUnimplemented, message: "compressed messages are not supported"}
+	}
+
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxRequestBody {
+		return nil, invalidArgument(fmt.Errorf("request message of %d bytes exceeds the %d byte limit", length, maxRequestBody))
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(body, message); err != nil {
+		return nil, invalidArgument(fmt.Errorf("failed to read request message: %v", err))
+	}
+	return message, nil
+}
+
+// grpcPercentEncode encodes a status message for the grpc-message trailer
+func grpcPercentEncode(message string) string {
+	var output strings.Builder
+	for i := 0; i < len(message); i++ {
+		if b := message[i]; b < ' ' || b > '~' || b == '%' {
+			output.WriteString(fmt.Sprintf("%%%02X", b))
+		} else {
+			output.WriteByte(b)
+		}
+	}
+	return output.String()
+}
+
+// calculate implements the Calculate RPC
+func (s *GRPCServer) calculate(request []byte) ([]byte, error) {
+	fields, err := decodeProto(request)
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	var cidr string
+	for _, field := range fields {
+		if field.Number == 1 && field.Type == protoBytes {
+			cidr = string(field.Bytes)
+		}
+	}
+	return s.network(splitRequest{CIDR: cidr})
+}
+
+// split implements the Split RPC
+func (s *GRPCServer) split(request []byte) ([]byte, error) {
+	fields, err := decodeProto(request)
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	var split splitRequest
+	for _, field := range fields {
+		switch {
+		case field.Number == 1 && field.Type == protoBytes:
+			split.CIDR = string(field.Bytes)
+		case field.Number == 2 && field.Type == protoVarint:
+			split.Prefix = int(int32(field.Varint))
+		case field.Number == 3 && field.Type == protoVarint:
+			split.Parts = int(int32(field.Varint))
+		case field.Number == 4 && field.Type == protoVarint:
+			split.Hosts = int(int32(field.Varint))
+		}
+	}
+	return s.network(split)
+}
+
+// network calculates a split request and encodes the Network message
+func (s *GRPCServer) network(request splitRequest) ([]byte, error) {
+	report, err := s.handler.calculateSplit(request, "prefix", nil, nil)
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	return encodeNetwork(s.handler.formatter.jsonDocument([]NetworkReport{report}).Networks[0]), nil
+}
+
+// encodeNetwork encodes the JSON model of a network as a Network message
+func encodeNetwork(network jsonNetwork) []byte {
+	var hosts, subnets, message protoEncoder
+
+	hosts.String(1, network.Hosts.FirstUsable)
+	hosts.String(2, network.Hosts.LastUsable)
+	hosts.String(3, network.Hosts.Total)
+
+	subnets.Int(1, int64(network.Subnets.PrefixLength))
+	subnets.Int(2, int64(network.Subnets.Count))
+	subnets.Bool(3, network.Subnets.Limited)
+	for _, subnet := range network.Subnets.Subnets {
+		var element protoEncoder
+		element.String(1, subnet.CIDR)
+		element.String(2, subnet.NetworkID)
+		element.String(3, subnet.Broadcast)
+		subnets.Bytes(4, element.buf)
+	}
+	subnets.String(5, network.Subnets.Total)
+
+	message.String(1, network.CIDR)
+	message.String(2, network.NetworkID)
+	message.String(3, network.Broadcast)
+	message.String(4, network.SubnetMask)
+	message.String(5, network.WildcardMask)
+	message.Int(6, int64(network.PrefixLength))
+	message.Bytes(7, hosts.buf)
+	message.Bytes(8, subnets.buf)
+	return message.buf
+}
+
+// aggregate implements the Aggregate RPC
+func (s *GRPCServer) aggregate(request []byte) ([]byte, error) {
+	fields, err := decodeProto(request)
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	var networks []*NetworkInfo
+	for _, field := range fields {
+		if field.Number != 1 || field.Type != protoBytes {
+			continue
+		}
+		info, err := s.handler.calculator.ParseCIDR(string(field.Bytes))
+		if err != nil {
+			return nil, invalidArgument(fmt.Errorf("failed to parse CIDR %s: %v", field.Bytes, err))
+		}
+		networks = append(networks, info)
+	}
+
+	var response protoEncoder
+	for _, network := range s.handler.calculator.Aggregate(networks) {
+		response.String(1, network.CIDR())
+	}
+	return response.buf, nil
+}
+
+// contains implements the Contains RPC
+func (s *GRPCServer) contains(request []byte) ([]byte, error) {
+	fields, err := decodeProto(request)
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	cidrs := make([]string, 2)
+	for _, field := range fields {
+		if (field.Number == 1 || field.Number == 2) && field.Type == protoBytes {
+			cidrs[field.Number-1] = string(field.Bytes)
+		}
+	}
+	networks := make([]*NetworkInfo, 2)
+	for i, cidr := range cidrs {
+		info, err := s.handler.calculator.ParseCIDR(cidr)
+		if err != nil {
+			return nil, invalidArgument(fmt.Errorf("failed to parse CIDR %q: %v", cidr, err))
+		}
+		networks[i] = info
+	}
+
+	relation := Relation(networks[0], networks[1])
+	var response protoEncoder
+	response.Int(1, int64(grpcRelations[relation]))
+	response.Bool(2, relation == RelationEqual || relation == RelationContains)
+	response.Bool(3, relation != RelationDisjoint)
+	return response.buf, nil
+}
+
+// runGRPC implements the grpc subcommand
+func (c *CLIHandler) runGRPC(args []string) error {
+	flagSet := flag.NewFlagSet("grpc", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var listen, certFile, keyFile string
+	flagSet.StringVar(&listen, "listen", defaultGRPCListenAddr, "Address to listen on")
+	flagSet.StringVar(&certFile, "tls-cert", "", "TLS certificate file (PEM)")
+	flagSet.StringVar(&keyFile, "tls-key", "", "TLS private key file (PEM)")
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if flagSet.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", flagSet.Arg(0))
+	}
+
+	// net/http negotiates HTTP/2, which gRPC requires, only over TLS
+	if certFile == "" || keyFile == "" {
+		return fmt.Errorf("grpc requires --tls-cert and --tls-key")
+	}
+
+	server := &http.Server{
+		Addr:              listen,
+		Handler:           NewGRPCServer(c),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	c.notef("serving the gRPC API on %s", listen)
+	if err := server.ListenAndServeTLS(certFile, keyFile); err != nil {
+		return fmt.Errorf("server error: %v", err)
+	}
+	return nil
+}
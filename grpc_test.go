@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// grpcCall sends a unary call and returns the response message and trailers
+func grpcCall(t *testing.T, server *httptest.Server, method string, message []byte) ([]byte, http.Header) {
+	t.Helper()
+
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	request, err := http.NewRequest(http.MethodPost, server.URL+grpcService+method, bytes.NewReader(append(frame, message...)))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/grpc")
+	request.Header.Set("TE", "trailers")
+
+	response, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	defer response.Body.Close()
+	if response.ProtoMajor != 2 {
+		t.Errorf("expected HTTP/2, got %s", response.Proto)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+
+	// Errors arrive as trailers-only responses in the headers
+	status := response.Trailer
+	if response.Header.Get("Grpc-Status") != "" {
+		status = response.Header
+	}
+	if len(body) == 0 {
+		return nil, status
+	}
+	if len(body) < 5 || int(binary.BigEndian.Uint32(body[1:5])) != len(body)-5 {
+		t.Fatalf("invalid response frame: %v", body)
+	}
+	return body[5:], status
+}
+
+// protoStrings returns the string values of a field
+func protoStrings(t *testing.T, message []byte, number int) []string {
+	t.Helper()
+	fields, err := decodeProto(message)
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	var values []string
+	for _, field := range fields {
+		if field.Number == number {
+			values = append(values, string(field.Bytes))
+		}
+	}
+	return values
+}
+
+func TestGRPCServer(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	server := httptest.NewUnstartedServer(NewGRPCServer(handler))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	t.Run("Calculate", func(t *testing.T) {
+		var request protoEncoder
+		request.String(1, "10.0.0.0/24")
+		response, status := grpcCall(t, server, "Calculate", request.buf)
+		if status.Get("Grpc-Status") != "0" {
+			t.Fatalf("unexpected status %q: %s", status.Get("Grpc-Status"), status.Get("Grpc-Message"))
+		}
+		if cidr := protoStrings(t, response, 1); len(cidr) != 1 || cidr[0] != "10.0.0.0/24" {
+			t.Errorf("unexpected cidr %v", cidr)
+		}
+		if mask := protoStrings(t, response, 4); len(mask) != 1 || mask[0] != "255.255.255.0" {
+			t.Errorf("unexpected subnet mask %v", mask)
+		}
+		hosts := protoStrings(t, response, 7)
+		if total := protoStrings(t, []byte(hosts[0]), 3); len(total) != 1 || total[0] != "254" {
+			t.Errorf("unexpected host total %v", total)
+		}
+		subnets := protoStrings(t, []byte(protoStrings(t, response, 8)[0]), 4)
+		if len(subnets) != 2 || protoStrings(t, []byte(subnets[1]), 1)[0] != "10.0.0.128/25" {
+			t.Errorf("unexpected subnets %q", subnets)
+		}
+	})
+
+	t.Run("Split", func(t *testing.T) {
+		var request protoEncoder
+		request.String(1, "10.0.0.0/24")
+		request.Int(3, 3)
+		response, status := grpcCall(t, server, "Split", request.buf)
+		if status.Get("Grpc-Status") != "0" {
+			t.Fatalf("unexpected status %q: %s", status.Get("Grpc-Status"), status.Get("Grpc-Message"))
+		}
+		subnets := protoStrings(t, []byte(protoStrings(t, response, 8)[0]), 4)
+		if len(subnets) != 4 {
+			t.Errorf("expected 4 subnets for 3 parts, got %d", len(subnets))
+		}
+	})
+
+	t.Run("Aggregate", func(t *testing.T) {
+		var request protoEncoder
+		for _, cidr := range []string{"10.0.0.0/25", "10.0.0.128/25", "192.168.0.0/24"} {
+			request.String(1, cidr)
+		}
+		response, _ := grpcCall(t, server, "Aggregate", request.buf)
+		if cidrs := strings.Join(protoStrings(t, response, 1), " "); cidrs != "10.0.0.0/24 192.168.0.0/24" {
+			t.Errorf("unexpected aggregates %q", cidrs)
+		}
+	})
+
+	t.Run("Contains", func(t *testing.T) {
+		var request protoEncoder
+		request.String(1, "10.0.0.0/8")
+		request.String(2, "10.20.0.0/16")
+		response, _ := grpcCall(t, server, "Contains", request.buf)
+		fields, err := decodeProto(response)
+		if err != nil {
+			t.Fatalf("invalid message: %v", err)
+		}
+		expected := []protoField{
+			{Number: 1, Type: protoVarint, Varint: grpcRelations[RelationContains]},
+			{Number: 2, Type: protoVarint, Varint: 1},
+			{Number: 3, Type: protoVarint, Varint: 1},
+		}
+		if !reflect.DeepEqual(fields, expected) {
+			t.Errorf("expected %+v, got %+v", expected, fields)
+		}
+	})
+
+	errorTests := []struct {
+		name     string
+		method   string
+		message  []byte
+		status   string
+		expected string
+	}{
+		{"invalid cidr", "Calculate", []byte{0x0a, 3, '1', '0', '%'}, "3", "failed to parse CIDR: invalid CIDR notation. Expected format: x.x.x.x/y (e.g., 192.168.1.0/24)"},
+		{"missing cidr", "Split", nil, "3", "cidr is required"},
+		{"unknown method", "Delete", nil, "12", "unknown method /cidrcalc.v1.CIDRCalculator/Delete"},
+		{"malformed message", "Contains", []byte{0x0a, 9}, "3", "field 1: invalid length"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, status := grpcCall(t, server, tt.method, tt.message)
+			if response != nil || status.Get("Grpc-Status") != tt.status {
+				t.Errorf("expected status %s without a message, got %q and %v", tt.status, status.Get("Grpc-Status"), response)
+			}
+			// grpc-message is percent-encoded
+			if message := status.Get("Grpc-Message"); !strings.HasPrefix(message, grpcPercentEncode(tt.expected)) {
+				t.Errorf("expected message %q, got %q", grpcPercentEncode(tt.expected), message)
+			}
+		})
+	}
+}
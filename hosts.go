@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"net"
+	"net/netip"
+	"sort"
+)
+
+// AddrSeq is a lazy sequence of addresses. It calls yield with each address
+// in order until yield returns false, like an iter.Seq[netip.Addr].
+type AddrSeq func(yield func(netip.Addr) bool)
+
+// usableBounds returns the first usable address and the number of usable
+// addresses of the network as integers
+func (n *NetworkInfo) usableBounds() (*big.Int, *big.Int) {
+	first := new(big.Int).SetBytes(addressBytes(n.FirstUsableIP))
+	last := new(big.Int).SetBytes(addressBytes(n.LastUsableIP))
+	return first, last.Sub(last, first).Add(last, big.NewInt(1))
+}
+
+// addressBytes returns an IPv4 address in 4 bytes and an IPv6 one in 16
+func addressBytes(ip net.IP) []byte {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip.To16()
+}
+
+// addrFromInt converts an integer back to an address of the network's family
+func (n *NetworkInfo) addrFromInt(value *big.Int) netip.Addr {
+	address := make([]byte, len(addressBytes(n.NetworkID)))
+	value.FillBytes(address)
+	addr, _ := netip.AddrFromSlice(address)
+	return addr
+}
+
+// Hosts returns the usable addresses of the network in order: every address
+// of an IPv6 prefix, /31 and /32, and all but the network and broadcast
+// address of other IPv4 networks. Addresses are generated as they are
+// yielded, so stopping early costs nothing even for a /64.
+func (n *NetworkInfo) Hosts() AddrSeq {
+	return func(yield func(netip.Addr) bool) {
+		first, count := n.usableBounds()
+		addr := n.addrFromInt(first)
+		one := big.NewInt(1)
+		for remaining := count; remaining.Sign() > 0; remaining.Sub(remaining, one) {
+			if !yield(addr) {
+				return
+			}
+			addr = addr.Next()
+		}
+	}
+}
+
+// HostAt returns the usable address at the index, counting from zero at the
+// first usable address
+func (n *NetworkInfo) HostAt(index uint64) (netip.Addr, error) {
+	first, count := n.usableBounds()
+	offset := new(big.Int).SetUint64(index)
+	if offset.Cmp(count) >= 0 {
+		return netip.Addr{}, fmt.Errorf("host index %d is outside %s, which has %s usable addresses", index, n.CIDR(), count)
+	}
+	return n.addrFromInt(first.Add(first, offset)), nil
+}
+
+// SampleHosts returns k distinct usable addresses picked at random, in address
+// order. The same seed picks the same addresses; a network with at most k
+// usable addresses returns all of them.
+func (n *NetworkInfo) SampleHosts(k int, seed int64) ([]netip.Addr, error) {
+	if k < 0 {
+		return nil, fmt.Errorf("sample size must not be negative, got %d", k)
+	}
+
+	first, count := n.usableBounds()
+	if count.Cmp(big.NewInt(int64(k))) <= 0 {
+		var hosts []netip.Addr
+		n.Hosts()(func(addr netip.Addr) bool {
+			hosts = append(hosts, addr)
+			return true
+		})
+		return hosts, nil
+	}
+
+	random := rand.New(rand.NewSource(seed))
+	picked := make(map[string]bool, k)
+	indexes := make([]*big.Int, 0, k)
+	for len(indexes) < k {
+		index := new(big.Int).Rand(random, count)
+		if picked[index.String()] {
+			continue
+		}
+		picked[index.String()] = true
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Cmp(indexes[j]) < 0 })
+
+	hosts := make([]netip.Addr, 0, k)
+	for _, index := range indexes {
+		hosts = append(hosts, n.addrFromInt(index.Add(index, first)))
+	}
+	return hosts, nil
+}
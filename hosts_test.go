@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+// collectHosts returns up to limit addresses of a sequence
+func collectHosts(seq AddrSeq, limit int) []string {
+	var hosts []string
+	seq(func(addr netip.Addr) bool {
+		hosts = append(hosts, addr.String())
+		return len(hosts) < limit
+	})
+	return hosts
+}
+
+func TestNetworkInfo_Hosts(t *testing.T) {
+	calculator := NewCIDRCalculator()
+
+	tests := []struct {
+		cidr     string
+		limit    int
+		expected []string
+	}{
+		{"192.168.1.0/30", 10, []string{"192.168.1.1", "192.168.1.2"}},
+		{"10.0.0.0/31", 10, []string{"10.0.0.0", "10.0.0.1"}},
+		{"10.0.0.7/32", 10, []string{"10.0.0.7"}},
+		{"2001:db8::/127", 10, []string{"2001:db8::", "2001:db8::1"}},
+		// Stopping early works on prefixes far too large to list
+		{"2001:db8::/32", 3, []string{"2001:db8::", "2001:db8::1", "2001:db8::2"}},
+	}
+
+	for _, tt := range tests {
+		got := collectHosts(mustParseCIDR(t, calculator, tt.cidr).Hosts(), tt.limit)
+		if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("%s: expected %v, got %v", tt.cidr, tt.expected, got)
+		}
+	}
+
+	count := 0
+	mustParseCIDR(t, calculator, "10.0.0.0/22").Hosts()(func(netip.Addr) bool {
+		count++
+		return true
+	})
+	if count != 1022 {
+		t.Errorf("expected 1022 hosts in a /22, got %d", count)
+	}
+}
+
+func TestNetworkInfo_HostAt(t *testing.T) {
+	calculator := NewCIDRCalculator()
+
+	info := mustParseCIDR(t, calculator, "192.168.1.0/24")
+	for index, expected := range map[uint64]string{0: "192.168.1.1", 9: "192.168.1.10", 253: "192.168.1.254"} {
+		if addr, err := info.HostAt(index); err != nil || addr.String() != expected {
+			t.Errorf("host %d: expected %s, got %v, %v", index, expected, addr, err)
+		}
+	}
+	if _, err := info.HostAt(254); err == nil || !strings.Contains(err.Error(), "has 254 usable addresses") {
+		t.Errorf("expected an out of range error, got %v", err)
+	}
+
+	if addr, err := mustParseCIDR(t, calculator, "2001:db8::/64").HostAt(1<<64 - 1); err != nil || addr.String() != "2001:db8::ffff:ffff:ffff:ffff" {
+		t.Errorf("expected the last address of the /64, got %v, %v", addr, err)
+	}
+}
+
+func TestNetworkInfo_SampleHosts(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	info := mustParseCIDR(t, calculator, "10.0.0.0/16")
+
+	sample, err := info.SampleHosts(5, 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sample) != 5 {
+		t.Fatalf("expected 5 hosts, got %v", sample)
+	}
+	network := netip.MustParsePrefix("10.0.0.0/16")
+	for i, addr := range sample {
+		if !network.Contains(addr) || addr == network.Addr() || addr.String() == "10.0.255.255" {
+			t.Errorf("%s is not a usable host of the network", addr)
+		}
+		if i > 0 && !sample[i-1].Less(addr) {
+			t.Errorf("expected distinct hosts in address order, got %v", sample)
+		}
+	}
+
+	again, _ := info.SampleHosts(5, 42)
+	if collectAddrs(again) != collectAddrs(sample) {
+		t.Errorf("expected the same seed to pick the same hosts, got %v and %v", sample, again)
+	}
+
+	small, _ := mustParseCIDR(t, calculator, "10.0.0.0/30").SampleHosts(5, 1)
+	if collectAddrs(small) != "10.0.0.1 10.0.0.2" {
+		t.Errorf("expected every host of a small network, got %v", small)
+	}
+	if _, err := info.SampleHosts(-1, 1); err == nil {
+		t.Error("expected an error for a negative sample size")
+	}
+}
+
+// collectAddrs joins addresses for comparison
+func collectAddrs(addrs []netip.Addr) string {
+	values := make([]string, len(addrs))
+	for i, addr := range addrs {
+		values[i] = addr.String()
+	}
+	return strings.Join(values, " ")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// FormatHostsFile renders the hosts of the groups as /etc/hosts entries, a
+// block per subnet headed by a comment. A hostname qualified with the domain
+// is followed by its short name as an alias.
+func (f *OutputFormatter) FormatHostsFile(groups []InventoryGroup, domain string) string {
+	var output strings.Builder
+
+	suffix := ""
+	if domain = strings.Trim(domain, "."); domain != "" {
+		suffix = "." + domain
+	}
+
+	output.WriteString("# Hosts file entries generated by simple-cidr-calculator\n")
+	for _, group := range groups {
+		output.WriteString(fmt.Sprintf("\n# %s\n", strings.TrimSpace(group.Subnet.CIDR()+" "+group.Annotation)))
+		for _, host := range group.Hosts {
+			line := fmt.Sprintf("%s\t%s", host.Address, host.Name)
+			if short := strings.TrimSuffix(host.Name, suffix); suffix != "" && short != host.Name {
+				line += " " + short
+			}
+			output.WriteString(line + "\n")
+		}
+	}
+
+	return output.String()
+}
+
+// runHostsFile implements the hosts-file subcommand
+func (c *CLIHandler) runHostsFile(args []string) error {
+	flagSet := flag.NewFlagSet("hosts-file", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	options := InventoryOptions{Command: "hosts-file"}
+	var inputFile, outputFile string
+	var split int
+	var tags tagFilterList
+	flagSet.StringVar(&inputFile, "f", "", "Read subnets from file, stdin or URL")
+	flagSet.StringVar(&inputFile, "file", "", "Read subnets from file, stdin or URL")
+	flagSet.Var(&tags, "tag", "Only use -f entries with this key=value tag, or with the key (repeatable)")
+	flagSet.IntVar(&split, "split", 0, "Write one block per subnet at this prefix length")
+	flagSet.StringVar(&options.Template, "template", defaultPTRTemplate, "Hostname template with {a} {b} {c} {d} (octets), {ip} (dashed address) and {index} (position in the subnet)")
+	flagSet.StringVar(&options.Domain, "domain", "", "Domain appended to hostnames not ending with a dot")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept CIDRs anywhere among the flags
+	cidrs, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	if len(cidrs) == 0 && inputFile == "" {
+		return fmt.Errorf("hosts-file requires CIDR arguments or -f")
+	}
+	if len(tags) > 0 && inputFile == "" {
+		return fmt.Errorf("--tag filters the entries of a -f plan file")
+	}
+
+	subnets, err := c.inventorySubnets(cidrs, inputFile, tags, split)
+	if err != nil {
+		return err
+	}
+	groups, err := c.calculator.Inventory(subnets, options)
+	if err != nil {
+		return err
+	}
+
+	return c.writeOutput(c.formatter.FormatHostsFile(groups, options.Domain), outputFile)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputFormatter_FormatHostsFile(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	options := InventoryOptions{Template: "host-{index}", Domain: "lab.local."}
+	groups, err := calculator.Inventory([]InventorySubnet{
+		{Network: mustParseCIDR(t, calculator, "192.168.56.0/30"), Name: "web tier"},
+	}, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `# Hosts file entries generated by simple-cidr-calculator
+
+# 192.168.56.0/30 web tier
+192.168.56.1	host-1.lab.local host-1
+192.168.56.2	host-2.lab.local host-2
+`
+	if output := NewOutputFormatter().FormatHostsFile(groups, options.Domain); output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestCLIHandler_HostsFile(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	// A template ending with a dot is used as it is, without an alias
+	output := filepath.Join(dir, "hosts")
+	if err := handler.Run([]string{"cidr-calc", "hosts-file", "10.0.0.0/31", "--template", "{d}.rack{c}.example.", "--domain", "lab.local", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !strings.HasSuffix(string(content), "# 10.0.0.0/31\n10.0.0.0\t0.rack0.example\n10.0.0.1\t1.rack0.example\n") {
+		t.Errorf("unexpected hosts file:\n%s", content)
+	}
+
+	for args, exp := range map[string]string{
+		"hosts-file": "hosts-file requires CIDR arguments or -f",
+		"hosts-file 10.0.0.0/30 10.0.1.0/30 --template x{index}": "--template gives 10.0.0.1 and 10.0.1.1 the same hostname x1",
+		"hosts-file 2001:db8::/126":                              "hosts are generated for IPv4 networks only",
+		"hosts-file 10.0.0.0/8":                                  "hosts-file would list more than 65536 hosts",
+	} {
+		if err := handler.Run(append([]string{"cidr-calc"}, strings.Fields(args)...)); err == nil || !strings.Contains(err.Error(), exp) {
+			t.Errorf("%s: expected %q, got %v", args, exp, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template/parse"
+)
+
+// fontFormats maps font file extensions to their @font-face format and MIME type
+var fontFormats = map[string][2]string{
+	".woff2": {"woff2", "font/woff2"},
+	".woff":  {"woff", "font/woff"},
+	".ttf":   {"truetype", "font/ttf"},
+	".otf":   {"opentype", "font/otf"},
+}
+
+// imageTypes maps image file extensions to their MIME type
+var imageTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+}
+
+// HTMLFont is a font embedded in HTML reports
+type HTMLFont struct {
+	Family string
+	Format string // @font-face format, e.g. woff2
+	URI    string // data: URI of the font file
+}
+
+// dataURI encodes content as a base64 data: URI
+func dataURI(mimeType string, content []byte) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(content)
+}
+
+// LoadHTMLFont reads a font file for embedding. The spec is FILE, whose base
+// name becomes the font family, or FAMILY=FILE.
+func LoadHTMLFont(spec string) (HTMLFont, error) {
+	family, path, named := strings.Cut(spec, "=")
+	if !named {
+		path = spec
+		family = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if family == "" || strings.ContainsAny(family, `'";{}<>\`) {
+		return HTMLFont{}, fmt.Errorf("invalid font family %q for %s", family, path)
+	}
+
+	format, ok := fontFormats[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return HTMLFont{}, fmt.Errorf("unsupported font file %s (use .woff2, .woff, .ttf or .otf)", path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return HTMLFont{}, fmt.Errorf("failed to read font: %v", err)
+	}
+	return HTMLFont{Family: family, Format: format[0], URI: dataURI(format[1], content)}, nil
+}
+
+// LoadHTMLImage reads an image file and returns it as a data: URI
+func LoadHTMLImage(path string) (string, error) {
+	mimeType, ok := imageTypes[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return "", fmt.Errorf("unsupported image file %s (use .png, .jpg, .gif, .svg or .webp)", path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %v", err)
+	}
+	return dataURI(mimeType, content), nil
+}
+
+// LoadHTMLAssets embeds the fonts and the logo in the HTML reports of the
+// formatter, so they render the same without network access
+func (f *OutputFormatter) LoadHTMLAssets(fonts []string, logo string) error {
+	for _, spec := range fonts {
+		font, err := LoadHTMLFont(spec)
+		if err != nil {
+			return err
+		}
+		f.HTMLFonts = append(f.HTMLFonts, font)
+	}
+	if logo != "" {
+		uri, err := LoadHTMLImage(logo)
+		if err != nil {
+			return err
+		}
+		f.HTMLLogo = uri
+	}
+	return nil
+}
+
+// LoadHTMLTemplate reads a Go html/template file that replaces the built-in
+// HTML report. It is parsed over the built-in template, so it can call the
+// "styles", "script", "network" and "errors" templates; a file that only
+// redefines some of them keeps the built-in page around them.
+func (f *OutputFormatter) LoadHTMLTemplate(filename string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read HTML template: %v", err)
+	}
+
+	builtin := template.Must(template.New("cidr-report").Parse(htmlTemplate))
+	custom, err := builtin.New(filepath.Base(filename)).Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("invalid HTML template: %v", err)
+	}
+	if custom.Tree == nil || parse.IsEmptyTree(custom.Tree.Root) {
+		custom = builtin
+	}
+	f.HTMLTemplate = custom
+	return nil
+}
+
+// htmlFontCSS returns the @font-face rules of the embedded fonts and puts
+// them first in the font stack of the report text
+func (f *OutputFormatter) htmlFontCSS() template.CSS {
+	if len(f.HTMLFonts) == 0 {
+		return ""
+	}
+
+	var css strings.Builder
+	families := make([]string, 0, len(f.HTMLFonts))
+	for _, font := range f.HTMLFonts {
+		css.WriteString(fmt.Sprintf("        @font-face {\n            font-family: '%s';\n            src: url(%s) format('%s');\n        }\n", font.Family, font.URI, font.Format))
+		families = append(families, "'"+font.Family+"'")
+	}
+	css.WriteString(fmt.Sprintf("        body {\n            font-family: %s, 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;\n        }\n", strings.Join(families, ", ")))
+	return template.CSS(css.String())
+}
+
+// minifyHTML drops the indentation and blank lines of an HTML report. Line
+// breaks stay, so the inline script keeps working.
+func minifyHTML(content string) string {
+	lines := strings.Split(content, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n") + "\n"
+}
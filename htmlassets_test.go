@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadHTMLFont(t *testing.T) {
+	dir := t.TempDir()
+	font := filepath.Join(dir, "Inter-Regular.woff2")
+	os.WriteFile(font, []byte("wOF2font"), 0644)
+
+	tests := []struct {
+		spec   string
+		family string
+		err    string
+	}{
+		{spec: font, family: "Inter-Regular"},
+		{spec: "Corporate Sans=" + font, family: "Corporate Sans"},
+		{spec: "Bad'Name=" + font, err: "invalid font family"},
+		{spec: filepath.Join(dir, "font.eot"), err: "unsupported font file"},
+		{spec: filepath.Join(dir, "missing.ttf"), err: "failed to read font"},
+	}
+
+	for _, tt := range tests {
+		loaded, err := LoadHTMLFont(tt.spec)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected error %q, got %v", tt.spec, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.spec, err)
+		}
+		if loaded.Family != tt.family || loaded.Format != "woff2" || loaded.URI != "data:font/woff2;base64,"+base64.StdEncoding.EncodeToString([]byte("wOF2font")) {
+			t.Errorf("%s: unexpected font %+v", tt.spec, loaded)
+		}
+	}
+}
+
+func TestOutputFormatter_HTMLAssets(t *testing.T) {
+	dir := t.TempDir()
+	font := filepath.Join(dir, "Inter.ttf")
+	logo := filepath.Join(dir, "logo.png")
+	os.WriteFile(font, []byte("font"), 0644)
+	os.WriteFile(logo, []byte("png"), 0644)
+
+	formatter := NewOutputFormatter()
+	if err := formatter.LoadHTMLAssets([]string{font}, logo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info := mustParseCIDR(t, NewCIDRCalculator(), "10.0.0.0/30")
+	html := formatter.FormatAsHTML(info, nil)
+	for _, expected := range []string{
+		"font-family: 'Inter';",
+		"src: url(data:font/ttf;base64,Zm9udA==) format('truetype');",
+		"font-family: 'Inter', 'Segoe UI'",
+		`<img class="logo" src="data:image/png;base64,cG5n" alt="">`,
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("expected %q in the HTML report", expected)
+		}
+	}
+
+	plain := NewOutputFormatter().FormatAsHTML(info, nil)
+	if strings.Contains(plain, "@font-face") || strings.Contains(plain, "<img") {
+		t.Error("expected no embedded assets without fonts or a logo")
+	}
+
+	formatter = NewOutputFormatter()
+	formatter.MinifyHTML = true
+	minified := formatter.FormatAsHTML(info, nil)
+	if len(minified) >= len(plain) || strings.Contains(minified, "\n ") || strings.Contains(minified, "\n\n") {
+		t.Error("expected the minified report to lose its indentation and blank lines")
+	}
+	if !strings.Contains(minified, "function toggleSubnets(listId) {\n") {
+		t.Error("expected the minified script to keep its line breaks")
+	}
+}
+
+func TestCLIHandler_HTMLAssets(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+	font := filepath.Join(dir, "Inter.woff")
+	os.WriteFile(font, []byte("font"), 0644)
+
+	output := filepath.Join(dir, "report.html")
+	if err := handler.Run([]string{"cidr-calc", "--html-font", font, "--minify", "-o", output, "10.0.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); !strings.Contains(string(content), "\nsrc: url(data:font/woff;base64,Zm9udA==) format('woff');\n") {
+		t.Errorf("expected a minified report with the embedded font, got:\n%s", content)
+	}
+
+	for _, args := range [][]string{
+		{"--minify", "10.0.0.0/24"},
+		{"--format", "json", "--html-font", font, "10.0.0.0/24"},
+		{"--html", "--html-logo", filepath.Join(dir, "logo.bmp"), "10.0.0.0/24"},
+	} {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestOutputFormatter_HTMLTemplate(t *testing.T) {
+	dir := t.TempDir()
+	info := mustParseCIDR(t, NewCIDRCalculator(), "10.0.0.0/30")
+	reports := []NetworkReport{{Info: info}}
+	builtin := NewOutputFormatter().FormatReportsAsHTML(reports)
+
+	page := filepath.Join(dir, "page.html")
+	os.WriteFile(page, []byte(`<html><head><style>{{template "styles" .}}</style></head>`+
+		`<body><h1>ACME {{.Title}}</h1>{{range .Networks}}{{template "network" .}}{{end}}</body></html>`), 0644)
+	formatter := NewOutputFormatter()
+	if err := formatter.LoadHTMLTemplate(page); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := formatter.FormatReportsAsHTML(reports)
+	for _, expected := range []string{"<h1>ACME 10.0.0.0/30</h1>", ".info-table {", "<h2>Network Information</h2>"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in the custom page, got:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "CIDR Calculator Report") {
+		t.Errorf("expected the custom page to replace the built-in one")
+	}
+
+	// A file of definitions only keeps the built-in page around them
+	sections := filepath.Join(dir, "sections.html")
+	os.WriteFile(sections, []byte(`{{define "network"}}<p class="brand">{{.NetworkInfo.NetworkID}}</p>{{end}}`), 0644)
+	formatter = NewOutputFormatter()
+	if err := formatter.LoadHTMLTemplate(sections); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output = formatter.FormatReportsAsHTML(reports)
+	if !strings.Contains(output, `<p class="brand">10.0.0.0</p>`) || !strings.Contains(output, "<h1>CIDR Calculator Report</h1>") ||
+		strings.Contains(output, "Network Information") {
+		t.Errorf("expected the built-in page with the custom network section, got:\n%s", output)
+	}
+
+	// The built-in template is untouched for other formatters
+	if output := NewOutputFormatter().FormatReportsAsHTML(reports); output != builtin {
+		t.Errorf("expected the built-in report to stay the same")
+	}
+}
+
+func TestCLIHandler_HTMLTemplate(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.html")
+	os.WriteFile(page, []byte("<p>{{range .Networks}}{{.NetworkInfo.NetworkID}}/{{.NetworkInfo.PrefixLength}} {{end}}</p>\n"), 0644)
+
+	output := filepath.Join(dir, "report.html")
+	if err := handler.Run([]string{"cidr-calc", "--template", page, "-o", output, "10.0.0.0/24", "10.1.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); string(content) != "<p>10.0.0.0/24 10.1.0.0/24 </p>\n" {
+		t.Errorf("unexpected report %q", content)
+	}
+
+	unknown := filepath.Join(dir, "unknown.html")
+	os.WriteFile(unknown, []byte("{{.Missing}}"), 0644)
+	invalid := filepath.Join(dir, "invalid.html")
+	os.WriteFile(invalid, []byte("{{if}}"), 0644)
+	errorCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--template", page, "10.0.0.0/24"}, "--template applies to html output, not text"},
+		{[]string{"--html", "--template", filepath.Join(dir, "missing.html"), "10.0.0.0/24"}, "failed to read HTML template"},
+		{[]string{"--html", "--template", invalid, "10.0.0.0/24"}, "invalid HTML template: template: invalid.html:1: missing value for if"},
+		{[]string{"--template", unknown, "-o", output, "10.0.0.0/24"}, "failed to generate HTML: template: unknown.html:1:2"},
+	}
+	for _, tt := range errorCases {
+		if err := handler.Run(append([]string{"cidr-calc"}, tt.args...)); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
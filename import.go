@@ -0,0 +1,374 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ImportedAllocation is a spreadsheet row normalized to a plan line
+type ImportedAllocation struct {
+	Row     int
+	Info    *NetworkInfo
+	Name    string
+	Comment string
+	Notes   []string // how the row was normalized, e.g. "host bits cleared"
+}
+
+// Line returns the allocation as a plan line
+func (a ImportedAllocation) Line() string {
+	line := a.Info.CIDR()
+	if a.Name != "" {
+		line += " name=" + a.Name
+	}
+	if a.Comment != "" {
+		line += " # " + a.Comment
+	}
+	return line
+}
+
+// ImportProblem is a row that cannot be imported
+type ImportProblem struct {
+	Row     int
+	Message string
+}
+
+// ImportResult is the outcome of importing the rows of a spreadsheet
+type ImportResult struct {
+	Allocations []ImportedAllocation
+	Existing    []ImportedAllocation // rows already recorded in the state
+	Problems    []ImportProblem
+	HeaderRow   int // the row skipped as a header, or 0
+}
+
+// AllocationImporter turns spreadsheet rows into allocations. Columns are
+// indexes from parseColumn; a negative name or comment column is not read.
+type AllocationImporter struct {
+	calculator    *CIDRCalculator
+	cidrColumn    int
+	nameColumn    int
+	commentColumn int
+}
+
+// NewAllocationImporter creates an importer reading the given columns
+func NewAllocationImporter(cidrColumn, nameColumn, commentColumn int) *AllocationImporter {
+	return &AllocationImporter{
+		calculator:    NewCIDRCalculator(),
+		cidrColumn:    cidrColumn,
+		nameColumn:    nameColumn,
+		commentColumn: commentColumn,
+	}
+}
+
+// Import validates and normalizes the rows. Rows without a CIDR are skipped,
+// and so is a first row whose CIDR cell has no digits, which is taken for a
+// header. A row must not overlap another row or an allocation of existing,
+// except that a row recorded in existing exactly is reported as existing.
+func (i *AllocationImporter) Import(rows []SpreadsheetRow, existing []planNetwork) *ImportResult {
+	result := &ImportResult{}
+
+	for _, row := range rows {
+		cell := row.Cell(i.cidrColumn)
+		if cell == "" {
+			continue
+		}
+		if result.HeaderRow == 0 && len(result.Allocations)+len(result.Existing)+len(result.Problems) == 0 &&
+			!strings.ContainsAny(cell, "0123456789") {
+			result.HeaderRow = row.Number
+			continue
+		}
+
+		allocation, err := i.normalize(row)
+		if err != nil {
+			result.Problems = append(result.Problems, ImportProblem{Row: row.Number, Message: err.Error()})
+			continue
+		}
+
+		if problem, recorded := i.conflict(allocation, result.Allocations, existing); problem != "" {
+			result.Problems = append(result.Problems, ImportProblem{Row: row.Number, Message: problem})
+			continue
+		} else if recorded {
+			result.Existing = append(result.Existing, allocation)
+			continue
+		}
+		result.Allocations = append(result.Allocations, allocation)
+	}
+
+	return result
+}
+
+// conflict describes how an allocation clashes with earlier rows or existing
+// allocations, and reports whether it is already recorded exactly
+func (i *AllocationImporter) conflict(allocation ImportedAllocation, imported []ImportedAllocation, existing []planNetwork) (string, bool) {
+	for _, other := range imported {
+		if other.Info.CIDR() == allocation.Info.CIDR() {
+			return fmt.Sprintf("%s duplicates row %d", allocation.Info.CIDR(), other.Row), false
+		}
+		if other.Info.Overlaps(allocation.Info) {
+			return fmt.Sprintf("%s overlaps %s at row %d", allocation.Info.CIDR(), other.Info.CIDR(), other.Row), false
+		}
+	}
+	for _, other := range existing {
+		if other.info.CIDR() == allocation.Info.CIDR() {
+			return "", true
+		}
+		if other.info.Overlaps(allocation.Info) {
+			return fmt.Sprintf("%s overlaps %s already allocated at %s line %d",
+				allocation.Info.CIDR(), other.info.CIDR(), other.entry.Source, other.entry.Line), false
+		}
+	}
+	return "", false
+}
+
+// normalize parses the cells of a row into an allocation
+func (i *AllocationImporter) normalize(row SpreadsheetRow) (ImportedAllocation, error) {
+	allocation := ImportedAllocation{Row: row.Number}
+
+	cidr, notes, err := normalizeCIDRCell(row.Cell(i.cidrColumn))
+	if err != nil {
+		return allocation, err
+	}
+	info, err := i.calculator.ParseCIDR(cidr)
+	if err != nil {
+		return allocation, fmt.Errorf("invalid CIDR %q: %v", row.Cell(i.cidrColumn), err)
+	}
+	if info.CIDR() != cidr {
+		notes = append(notes, fmt.Sprintf("host bits of %s cleared", cidr))
+	}
+	allocation.Info = info
+
+	if i.nameColumn >= 0 {
+		name := row.Cell(i.nameColumn)
+		allocation.Name = strings.Join(strings.FieldsFunc(name, func(r rune) bool { return unicode.IsSpace(r) || r == '#' }), "-")
+		if allocation.Name != name {
+			notes = append(notes, fmt.Sprintf("name %q written as %s", name, allocation.Name))
+		}
+	}
+	if i.commentColumn >= 0 {
+		allocation.Comment = strings.Join(strings.Fields(row.Cell(i.commentColumn)), " ")
+	}
+
+	allocation.Notes = notes
+	return allocation, nil
+}
+
+// normalizeCIDRCell rewrites the ways spreadsheets tend to record networks,
+// such as "10.0.0.0 255.255.255.0", "10.0.0.0 / 24" or a bare address, as
+// CIDR notation and describes each rewrite
+func normalizeCIDRCell(cell string) (string, []string, error) {
+	var notes []string
+
+	fields := strings.Fields(cell)
+	cidr := strings.Join(fields, "")
+	if len(fields) == 2 && !strings.Contains(cell, "/") {
+		cidr = fields[0] + "/" + fields[1]
+	}
+
+	address, prefix, hasPrefix := strings.Cut(cidr, "/")
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return "", nil, fmt.Errorf("invalid address %q", cell)
+	}
+
+	switch {
+	case !hasPrefix:
+		bits := 128
+		if ip.To4() != nil {
+			bits = 32
+		}
+		cidr = fmt.Sprintf("%s/%d", address, bits)
+		notes = append(notes, fmt.Sprintf("single address written as /%d", bits))
+	case strings.Contains(prefix, "."):
+		mask := net.ParseIP(prefix).To4()
+		if mask == nil || ip.To4() == nil {
+			return "", nil, fmt.Errorf("invalid subnet mask in %q", cell)
+		}
+		ones, bits := net.IPMask(mask).Size()
+		if bits == 0 {
+			return "", nil, fmt.Errorf("non-contiguous subnet mask %s in %q", prefix, cell)
+		}
+		cidr = fmt.Sprintf("%s/%d", address, ones)
+		notes = append(notes, fmt.Sprintf("mask %s written as /%d", prefix, ones))
+	}
+	return cidr, notes, nil
+}
+
+// FormatImport renders the allocations of an import, how rows were
+// normalized and which rows were already recorded
+func (f *OutputFormatter) FormatImport(source, state string, result *ImportResult, dryRun bool) string {
+	var output strings.Builder
+
+	verb := "Imported"
+	if dryRun {
+		verb = "Would import"
+	}
+	output.WriteString(fmt.Sprintf("%s %d allocations from %s into %s\n", verb, len(result.Allocations), source, state))
+	if result.HeaderRow > 0 {
+		output.WriteString(fmt.Sprintf("  Skipped header row %d\n", result.HeaderRow))
+	}
+
+	if len(result.Allocations) > 0 {
+		output.WriteString("\nAllocations:\n")
+		for _, allocation := range result.Allocations {
+			output.WriteString(fmt.Sprintf("  row %-5d %s\n", allocation.Row, allocation.Line()))
+			for _, note := range allocation.Notes {
+				output.WriteString(fmt.Sprintf("            - %s\n", note))
+			}
+		}
+	}
+
+	if len(result.Existing) > 0 {
+		output.WriteString("\nAlready allocated:\n")
+		for _, allocation := range result.Existing {
+			output.WriteString(fmt.Sprintf("  row %-5d %s\n", allocation.Row, allocation.Info.CIDR()))
+		}
+	}
+
+	return output.String()
+}
+
+// importError lists the rows that cannot be imported
+func importError(source string, problems []ImportProblem) error {
+	lines := make([]string, len(problems))
+	for i, problem := range problems {
+		lines[i] = fmt.Sprintf("  row %d: %s", problem.Row, problem.Message)
+	}
+	return fmt.Errorf("%d rows of %s cannot be imported; nothing was imported:\n%s", len(problems), source, strings.Join(lines, "\n"))
+}
+
+// runIPAMImport imports allocations from a spreadsheet into a state, or
+// writes them as a plan file when no state is given
+func (c *CLIHandler) runIPAMImport(args []string) error {
+	flagSet := flag.NewFlagSet("ipam import", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var stateFile, sheet, cidrColumn, nameColumn, commentColumn, outputFile string
+	var dryRun bool
+	flagSet.StringVar(&stateFile, "state", "", "Plan file or s3://, gs://, etcd://, consul:// URL to import into")
+	flagSet.StringVar(&sheet, "sheet", "", "Workbook sheet to read (default: the first)")
+	flagSet.StringVar(&cidrColumn, "column-cidr", "A", "Column holding the CIDRs")
+	flagSet.StringVar(&nameColumn, "column-name", "", "Column recorded as the name=NAME tag")
+	flagSet.StringVar(&commentColumn, "column-comment", "", "Column recorded as the plan line comment")
+	flagSet.BoolVar(&dryRun, "dry-run", false, "Validate and show the import without recording it")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept the spreadsheet anywhere among the flags
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("ipam import takes a single spreadsheet, got %d", len(positional))
+	}
+	source := positional[0]
+
+	columns := make([]int, 3)
+	for i, name := range []string{cidrColumn, nameColumn, commentColumn} {
+		columns[i] = -1
+		if name == "" && i > 0 {
+			continue
+		}
+		column, err := parseColumn(name)
+		if err != nil {
+			return err
+		}
+		columns[i] = column
+	}
+	importer := NewAllocationImporter(columns[0], columns[1], columns[2])
+
+	rows, err := readSpreadsheet(source, sheet)
+	if err != nil {
+		return err
+	}
+
+	// Without a state the rows become a plan file, e.g. a labels file for -f
+	if stateFile == "" {
+		result := importer.Import(rows, nil)
+		if len(result.Problems) > 0 {
+			return importError(source, result.Problems)
+		}
+		var plan strings.Builder
+		for _, allocation := range result.Allocations {
+			plan.WriteString(allocation.Line() + "\n")
+			for _, note := range allocation.Notes {
+				c.notef("row %d: %s", allocation.Row, note)
+			}
+		}
+		return c.writeOutput(plan.String(), outputFile)
+	}
+
+	state, err := c.openState(stateFile)
+	if err != nil {
+		return err
+	}
+
+	// The rows are checked against the state under its lock, so they cannot
+	// overlap an allocation recorded meanwhile
+	var result *ImportResult
+	validate := func(content string) ([]string, error) {
+		entries, err := parseBatch(state.location, strings.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		existing := make([]planNetwork, 0, len(entries))
+		for _, entry := range entries {
+			info, err := c.calculator.ParseCIDR(entry.CIDR)
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: failed to parse CIDR: %v", entry.Source, entry.Line, err)
+			}
+			existing = append(existing, planNetwork{entry: entry, info: info})
+		}
+
+		result = importer.Import(rows, existing)
+		if len(result.Problems) > 0 {
+			return nil, importError(source, result.Problems)
+		}
+		lines := make([]string, len(result.Allocations))
+		for i, allocation := range result.Allocations {
+			lines[i] = allocation.Line()
+		}
+		return lines, nil
+	}
+
+	if dryRun {
+		content, err := state.store.Get(state.key)
+		if err != nil && !isNotExist(err) {
+			return fmt.Errorf("failed to read state file: %v", err)
+		}
+		if _, err := validate(string(content)); err != nil {
+			return err
+		}
+		return c.writeOutput(c.formatter.FormatImport(source, state.location, result, true), outputFile)
+	}
+
+	err = commitIPAM(state, "", func(content string) (string, JournalEntry, error) {
+		lines, err := validate(content)
+		if err != nil {
+			return "", JournalEntry{}, err
+		}
+		if len(lines) == 0 {
+			return "", JournalEntry{}, errNothingToImport
+		}
+		for _, line := range lines {
+			content = addStateLine(content, line)
+		}
+		return content, JournalEntry{Time: time.Now(), Op: OpImport, Line: strings.Join(lines, "\n")}, nil
+	})
+	switch {
+	case err == errNothingToImport:
+		c.notef("every row of %s is already allocated in %s", source, state)
+	case err != nil:
+		return err
+	default:
+		c.notef("recorded %d allocations in %s", len(result.Allocations), state)
+	}
+	return c.writeOutput(c.formatter.FormatImport(source, state.location, result, false), outputFile)
+}
+
+// errNothingToImport leaves the state and journal untouched when every row is
+// already allocated
+var errNothingToImport = fmt.Errorf("nothing to import")
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeCIDRCell(t *testing.T) {
+	tests := []struct {
+		cell     string
+		expected string
+		note     string
+	}{
+		{"10.0.0.0/24", "10.0.0.0/24", ""},
+		{" 10.0.0.0 / 24 ", "10.0.0.0/24", ""},
+		{"10.0.0.0 255.255.255.0", "10.0.0.0/24", "mask 255.255.255.0 written as /24"},
+		{"10.0.0.0/255.255.254.0", "10.0.0.0/23", "mask 255.255.254.0 written as /23"},
+		{"10.0.0.7", "10.0.0.7/32", "single address written as /32"},
+		{"2001:db8::1", "2001:db8::1/128", "single address written as /128"},
+		{"10.0.0.0/255.0.255.0", "", "non-contiguous subnet mask"},
+		{"web-01", "", "invalid address"},
+	}
+
+	for _, tt := range tests {
+		cidr, notes, err := normalizeCIDRCell(tt.cell)
+		if tt.expected == "" {
+			if err == nil || !strings.Contains(err.Error(), tt.note) {
+				t.Errorf("%q: expected error %q, got %v", tt.cell, tt.note, err)
+			}
+			continue
+		}
+		if err != nil || cidr != tt.expected || (tt.note != "" && (len(notes) != 1 || notes[0] != tt.note)) {
+			t.Errorf("%q: expected %s (%q), got %s %v (%v)", tt.cell, tt.expected, tt.note, cidr, notes, err)
+		}
+	}
+}
+
+func TestAllocationImporter_Import(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	existingInfo, _ := calculator.ParseCIDR("10.1.0.0/16")
+	keptInfo, _ := calculator.ParseCIDR("10.2.0.0/24")
+	existing := []planNetwork{
+		{entry: BatchEntry{Source: "ipam.txt", Line: 1, CIDR: "10.1.0.0/16"}, info: existingInfo},
+		{entry: BatchEntry{Source: "ipam.txt", Line: 2, CIDR: "10.2.0.0/24"}, info: keptInfo},
+	}
+	rows := []SpreadsheetRow{
+		{Number: 1, Cells: []string{"Subnet", "Name", "Notes"}},
+		{Number: 2, Cells: []string{"10.0.0.5/24", "Web Tier", "  front   end "}},
+		{Number: 3, Cells: []string{""}},
+		{Number: 4, Cells: []string{"10.0.1.0 255.255.255.0", "db"}},
+		{Number: 5, Cells: []string{"10.0.0.128/25", "dup"}},
+		{Number: 6, Cells: []string{"10.1.5.0/24"}},
+		{Number: 7, Cells: []string{"10.2.0.0/24", "kept"}},
+		{Number: 8, Cells: []string{"n/a"}},
+	}
+
+	result := NewAllocationImporter(0, 1, 2).Import(rows, existing)
+	if result.HeaderRow != 1 {
+		t.Errorf("expected header row 1, got %d", result.HeaderRow)
+	}
+
+	var lines []string
+	for _, allocation := range result.Allocations {
+		lines = append(lines, allocation.Line())
+	}
+	expected := []string{"10.0.0.0/24 name=Web-Tier # front end", "10.0.1.0/24 name=db"}
+	if strings.Join(lines, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected %q, got %q", expected, lines)
+	}
+	if notes := result.Allocations[0].Notes; len(notes) != 2 || notes[0] != "host bits of 10.0.0.5/24 cleared" {
+		t.Errorf("unexpected notes %q", notes)
+	}
+	if len(result.Existing) != 1 || result.Existing[0].Row != 7 {
+		t.Errorf("expected row 7 to be already allocated, got %+v", result.Existing)
+	}
+
+	problems := map[int]string{
+		5: "10.0.0.128/25 overlaps 10.0.0.0/24 at row 2",
+		6: "10.1.5.0/24 overlaps 10.1.0.0/16 already allocated at ipam.txt line 1",
+		8: `invalid address "n/a"`,
+	}
+	if len(result.Problems) != len(problems) {
+		t.Errorf("expected %d problems, got %+v", len(problems), result.Problems)
+	}
+	for _, problem := range result.Problems {
+		if problem.Message != problems[problem.Row] {
+			t.Errorf("row %d: expected %q, got %q", problem.Row, problems[problem.Row], problem.Message)
+		}
+	}
+}
+
+func TestCLIHandler_IPAMImport(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+	state := filepath.Join(dir, "ipam.txt")
+	output := filepath.Join(dir, "out.txt")
+	workbook := filepath.Join(dir, "allocations.xlsx")
+	writeXLSX(t, workbook, []string{"CIDR", "Name", "10.0.0.0/24", "web", "10.0.1.0/24", "db"}, map[string]string{
+		"Allocations": `<row r="1"><c r="B1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>` +
+			`<row r="2"><c r="B2" t="s"><v>2</v></c><c r="C2" t="s"><v>3</v></c></row>` +
+			`<row r="3"><c r="B3" t="s"><v>4</v></c><c r="C3" t="s"><v>5</v></c></row>`,
+	})
+	run := func(args ...string) error {
+		return handler.Run(append([]string{"cidr-calc", "ipam", "import", workbook, "--column-cidr", "B", "--column-name", "C"}, args...))
+	}
+
+	if err := run("-o", filepath.Join(dir, "labels.txt")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	plan := "10.0.0.0/24 name=web\n10.0.1.0/24 name=db\n"
+	if content, _ := os.ReadFile(filepath.Join(dir, "labels.txt")); string(content) != plan {
+		t.Errorf("expected plan %q, got %q", plan, content)
+	}
+
+	if err := run("--state", state, "--dry-run", "-o", output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(state); !os.IsNotExist(err) {
+		t.Errorf("expected --dry-run not to create the state, got %v", err)
+	}
+
+	if err := run("--state", state, "-o", output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(state); string(content) != plan {
+		t.Errorf("expected state %q, got %q", plan, content)
+	}
+	report, _ := os.ReadFile(output)
+	if !strings.Contains(string(report), "Imported 2 allocations from "+workbook) {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+
+	// A second import finds every row allocated and records nothing
+	if err := run("--state", state, "-o", output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	journal, _ := readJournal(localState(state))
+	if len(journal) != 1 || journal[0].Op != OpImport || len(journal[0].Lines()) != 2 {
+		t.Errorf("unexpected journal %+v", journal)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "ipam", "undo", "--state", state}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(state); string(content) != "" {
+		t.Errorf("expected undo to remove the import, got %q", content)
+	}
+
+	os.WriteFile(state, []byte("10.0.0.0/16\n"), 0644)
+	if err := run("--state", state); err == nil || !strings.Contains(err.Error(), "2 rows of "+workbook+" cannot be imported") {
+		t.Errorf("expected an overlap error, got %v", err)
+	}
+}
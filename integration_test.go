@@ -332,12 +332,12 @@ func TestApplicationErrorHandlingWorkflow(t *testing.T) {
 		},
 		{
 			name:        "HTML flag with wrong extension",
-			args:        []string{"cidr-calc", "--html", "-o", tempDir + "/output.txt", "192.168.1.0/24"},
+			args:        []string{"cidr-calc", "--strict-ext", "--html", "-o", tempDir + "/output.txt", "192.168.1.0/24"},
 			expectError: "HTML output requires .html or .htm file extension",
 		},
 		{
 			name:        "HTML extension without HTML flag",
-			args:        []string{"cidr-calc", "-o", tempDir + "/output.html", "192.168.1.0/24"},
+			args:        []string{"cidr-calc", "--strict-ext", "-o", tempDir + "/output.html", "192.168.1.0/24"},
 			expectError: "HTML file extension requires --html flag",
 		},
 	}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// Ansible inventory formats
+const (
+	InventoryINI  = "ini"
+	InventoryYAML = "yaml"
+)
+
+// maxInventoryHosts caps the hosts of an inventory; Ansible is not meant to
+// loop over a /8 and neither is this generator
+const maxInventoryHosts = 1 << 16
+
+// InventoryHost is an inventory entry for one usable address
+type InventoryHost struct {
+	Name    string
+	Address net.IP
+}
+
+// InventoryGroup holds the hosts of one subnet and the variables describing it
+type InventoryGroup struct {
+	Name       string
+	Subnet     *NetworkInfo
+	Annotation string // of the plan file entry, before it became the name
+	Tags       Tags   // plan file tags, written as group variables
+	Hosts      []InventoryHost
+}
+
+// Vars returns the group variables in the order they are written: the
+// subnet, its netmask and prefix length, then the tags sorted by key
+func (g InventoryGroup) Vars() [][2]string {
+	vars := [][2]string{
+		{"subnet_cidr", g.Subnet.CIDR()},
+		{"subnet_netmask", net.IP(g.Subnet.SubnetMask).String()},
+		{"subnet_prefix", fmt.Sprint(g.Subnet.PrefixLength)},
+	}
+	for _, key := range g.Tags.Keys() {
+		vars = append(vars, [2]string{ansibleName(key), g.Tags[key]})
+	}
+	return vars
+}
+
+// InventorySubnet is a subnet to list in an inventory, with the name and tags
+// of the plan file entry it comes from
+type InventorySubnet struct {
+	Network *NetworkInfo
+	Name    string // the entry's annotation; empty names the group after the subnet
+	Tags    Tags
+}
+
+// InventoryOptions name the hosts of an inventory
+type InventoryOptions struct {
+	Template string // hostname template with {a} {b} {c} {d}, {ip} and {index}
+	Domain   string // appended to hostnames not ending with a dot
+	Command  string // the subcommand writing the hosts, named in errors if set
+}
+
+// ansibleName turns a name into a valid Ansible group or variable name:
+// letters, digits and underscores, not starting with a digit
+func ansibleName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "net_" + name
+	}
+	return name
+}
+
+// inventoryHostname fills the template for the usable address at the index
+// of a subnet, counting from 1, and qualifies it with the domain unless the
+// template ends with a dot
+func inventoryHostname(options InventoryOptions, ip net.IP, index int) string {
+	name := fillHostnameTemplate(strings.ReplaceAll(options.Template, "{index}", fmt.Sprint(index)), ip)
+	if strings.HasSuffix(name, ".") {
+		return strings.TrimSuffix(name, ".")
+	}
+	if options.Domain == "" {
+		return name
+	}
+	return name + "." + strings.Trim(options.Domain, ".")
+}
+
+// Inventory groups the usable addresses of every subnet under a host entry
+// named by the template. Groups are named after the plan file annotation of
+// their subnet, or the subnet itself, and a template that gives two addresses
+// the same name is an error, as Ansible would merge them into one host.
+func (c *CIDRCalculator) Inventory(subnets []InventorySubnet, options InventoryOptions) ([]InventoryGroup, error) {
+	var groups []InventoryGroup
+	groupNames := make(map[string]int)
+	hostNames := make(map[string]net.IP)
+	total := uint64(0)
+	deadline := c.startDeadline()
+
+	for _, subnet := range subnets {
+		network := subnet.Network
+		if network.IsIPv6() {
+			return nil, fmt.Errorf("hosts are generated for IPv4 networks only, got %s", network.CIDR())
+		}
+		first := uint64(ipv4ToUint32(network.FirstUsableIP))
+		last := uint64(ipv4ToUint32(network.LastUsableIP))
+		if total += last - first + 1; total > maxInventoryHosts {
+			subject := options.Command
+			if subject == "" {
+				subject = "the subnets"
+			}
+			return nil, fmt.Errorf("%s would list more than %d hosts; use longer prefixes", subject, maxInventoryHosts)
+		}
+
+		name := subnet.Name
+		if name == "" {
+			name = network.CIDR()
+		}
+		name = ansibleName(name)
+		if groupNames[name]++; groupNames[name] > 1 {
+			name = fmt.Sprintf("%s_%d", name, groupNames[name])
+		}
+
+		group := InventoryGroup{Name: name, Subnet: network, Annotation: subnet.Name, Tags: subnet.Tags}
+		var err error
+		network.Hosts()(func(addr netip.Addr) bool {
+			if len(group.Hosts)%deadlineCheckInterval == 0 {
+				if err = c.checkDeadline(deadline); err != nil {
+					return false
+				}
+			}
+			ip := net.IP(addr.AsSlice())
+			host := InventoryHost{Name: inventoryHostname(options, ip, len(group.Hosts)+1), Address: ip}
+			if other, ok := hostNames[host.Name]; ok {
+				err = fmt.Errorf("--template gives %s and %s the same hostname %s; use {ip} or the octets to tell the hosts apart",
+					other, ip, host.Name)
+				return false
+			}
+			hostNames[host.Name] = ip
+			group.Hosts = append(group.Hosts, host)
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+// FormatInventoryINI renders the groups as an INI inventory: a section of
+// hosts and a :vars section per group
+func (f *OutputFormatter) FormatInventoryINI(groups []InventoryGroup) string {
+	var output strings.Builder
+
+	output.WriteString("# Ansible inventory generated by simple-cidr-calculator\n")
+	for _, group := range groups {
+		output.WriteString(fmt.Sprintf("\n[%s]\n", group.Name))
+		for _, host := range group.Hosts {
+			output.WriteString(fmt.Sprintf("%s ansible_host=%s\n", host.Name, host.Address))
+		}
+		output.WriteString(fmt.Sprintf("\n[%s:vars]\n", group.Name))
+		for _, variable := range group.Vars() {
+			output.WriteString(fmt.Sprintf("%s=%s\n", variable[0], variable[1]))
+		}
+	}
+
+	return output.String()
+}
+
+// FormatInventoryYAML renders the groups as a YAML inventory, each group a
+// child of all with its hosts and vars
+func (f *OutputFormatter) FormatInventoryYAML(groups []InventoryGroup) string {
+	var output strings.Builder
+
+	output.WriteString("# Ansible inventory generated by simple-cidr-calculator\n")
+	output.WriteString("all:\n  children:\n")
+	for _, group := range groups {
+		output.WriteString(fmt.Sprintf("    %s:\n      hosts:\n", group.Name))
+		for _, host := range group.Hosts {
+			output.WriteString(fmt.Sprintf("        %s:\n          ansible_host: %s\n", yamlScalar(host.Name), host.Address))
+		}
+		output.WriteString("      vars:\n")
+		for _, variable := range group.Vars() {
+			// The prefix length is the one variable written as a number
+			value := yamlScalar(variable[1])
+			if variable[0] == "subnet_prefix" {
+				value = variable[1]
+			}
+			output.WriteString(fmt.Sprintf("        %s: %s\n", variable[0], value))
+		}
+	}
+
+	return output.String()
+}
+
+// runInventory implements the inventory subcommand
+func (c *CLIHandler) runInventory(args []string) error {
+	flagSet := flag.NewFlagSet("inventory", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	options := InventoryOptions{Command: "inventory"}
+	var inputFile, outputFile, format string
+	var split int
+	var tags tagFilterList
+	flagSet.StringVar(&inputFile, "f", "", "Read subnets from file, stdin or URL")
+	flagSet.StringVar(&inputFile, "file", "", "Read subnets from file, stdin or URL")
+	flagSet.Var(&tags, "tag", "Only use -f entries with this key=value tag, or with the key (repeatable)")
+	flagSet.IntVar(&split, "split", 0, "Write one group per subnet at this prefix length")
+	flagSet.StringVar(&options.Template, "template", defaultPTRTemplate, "Hostname template with {a} {b} {c} {d} (octets), {ip} (dashed address) and {index} (position in the subnet)")
+	flagSet.StringVar(&options.Domain, "domain", "", "Domain appended to hostnames not ending with a dot")
+	flagSet.StringVar(&format, "format", "", "Inventory format: ini or yaml (default from the -o extension, else ini)")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept CIDRs anywhere among the flags
+	cidrs, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	if len(cidrs) == 0 && inputFile == "" {
+		return fmt.Errorf("inventory requires CIDR arguments or -f")
+	}
+	if len(tags) > 0 && inputFile == "" {
+		return fmt.Errorf("--tag filters the entries of a -f plan file")
+	}
+	if format == "" {
+		format = InventoryINI
+		if documentFormat(outputFile) == DocumentYAML {
+			format = InventoryYAML
+		}
+	}
+	format = strings.ToLower(format)
+	if format != InventoryINI && format != InventoryYAML {
+		return fmt.Errorf("unsupported inventory --format %s (available: %s, %s)", format, InventoryINI, InventoryYAML)
+	}
+
+	subnets, err := c.inventorySubnets(cidrs, inputFile, tags, split)
+	if err != nil {
+		return err
+	}
+	groups, err := c.calculator.Inventory(subnets, options)
+	if err != nil {
+		return err
+	}
+
+	content := c.formatter.FormatInventoryINI(groups)
+	if format == InventoryYAML {
+		content = c.formatter.FormatInventoryYAML(groups)
+	}
+	return c.writeOutput(content, outputFile)
+}
+
+// inventorySubnets parses the CIDR arguments and the entries of the -f plan
+// file that match the tags, cut into subnets of the split prefix length when
+// it is set. Split subnets are named after their CIDR and keep the tags of
+// their entry.
+func (c *CLIHandler) inventorySubnets(cidrs []string, inputFile string, tags tagFilterList, split int) ([]InventorySubnet, error) {
+	var subnets []InventorySubnet
+	for _, cidr := range cidrs {
+		info, err := c.calculator.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CIDR %s: %v", cidr, err)
+		}
+		subnets = append(subnets, InventorySubnet{Network: info})
+	}
+	if inputFile != "" {
+		entries, err := NewBatchReader(defaultFetchTimeout, nil).Read(inputFile)
+		if err != nil {
+			return nil, err
+		}
+		if entries, err = filterEntries(entries, tags, inputFile); err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			info, err := c.calculator.ParseCIDR(entry.CIDR)
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: failed to parse CIDR: %v", entry.Source, entry.Line, err)
+			}
+			subnets = append(subnets, InventorySubnet{Network: info, Name: entry.Annotation, Tags: entry.Tags})
+		}
+	}
+
+	if split > 0 {
+		var parts []InventorySubnet
+		for _, subnet := range subnets {
+			pieces, err := c.calculator.SplitNetworks(subnet.Network, split)
+			if err != nil {
+				return nil, err
+			}
+			for i := range pieces {
+				parts = append(parts, InventorySubnet{Network: &pieces[i], Tags: subnet.Tags})
+			}
+		}
+		subnets = parts
+	}
+
+	return subnets, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIDRCalculator_Inventory(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	subnets := []InventorySubnet{
+		{Network: mustParseCIDR(t, calculator, "10.0.1.0/30"), Name: "web tier", Tags: Tags{"env": "prod"}},
+		{Network: mustParseCIDR(t, calculator, "10.0.2.0/31")},
+		{Network: mustParseCIDR(t, calculator, "10.0.3.0/32"), Name: "web tier"},
+	}
+
+	groups, err := calculator.Inventory(subnets, InventoryOptions{Template: "lab-{c}-{index}", Domain: "example.com."})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"web_tier", "net_10_0_2_0_31", "web_tier_2"}
+	if len(groups) != len(expected) {
+		t.Fatalf("expected %d groups, got %d", len(expected), len(groups))
+	}
+	for i, group := range groups {
+		if group.Name != expected[i] {
+			t.Errorf("group %d: expected %s, got %s", i, expected[i], group.Name)
+		}
+	}
+	if hosts := groups[1].Hosts; len(hosts) != 2 || hosts[1].Name != "lab-2-2.example.com" || hosts[1].Address.String() != "10.0.2.1" {
+		t.Errorf("unexpected hosts %v", hosts)
+	}
+
+	if _, err := calculator.Inventory(subnets, InventoryOptions{Template: "lab-{index}"}); err == nil ||
+		err.Error() != "--template gives 10.0.1.1 and 10.0.2.0 the same hostname lab-1; use {ip} or the octets to tell the hosts apart" {
+		t.Errorf("expected a hostname collision, got %v", err)
+	}
+	if _, err := calculator.Inventory([]InventorySubnet{{Network: mustParseCIDR(t, calculator, "10.0.0.0/15")}}, InventoryOptions{Template: defaultPTRTemplate, Command: "inventory"}); err == nil ||
+		err.Error() != "inventory would list more than 65536 hosts; use longer prefixes" {
+		t.Errorf("expected a /15 to exceed the host limit, got %v", err)
+	}
+	if _, err := calculator.Inventory([]InventorySubnet{{Network: mustParseCIDR(t, calculator, "10.0.0.0/15")}}, InventoryOptions{Template: defaultPTRTemplate}); err == nil ||
+		err.Error() != "the subnets would list more than 65536 hosts; use longer prefixes" {
+		t.Errorf("expected the host limit without a command, got %v", err)
+	}
+}
+
+func TestOutputFormatter_FormatInventory(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	groups, err := calculator.Inventory([]InventorySubnet{
+		{Network: mustParseCIDR(t, calculator, "192.168.0.0/30"), Name: "db", Tags: Tags{"env": "prod", "site-id": "001"}},
+	}, InventoryOptions{Template: defaultPTRTemplate})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	formatter := NewOutputFormatter()
+
+	expected := `# Ansible inventory generated by simple-cidr-calculator
+
+[db]
+host-192-168-0-1 ansible_host=192.168.0.1
+host-192-168-0-2 ansible_host=192.168.0.2
+
+[db:vars]
+subnet_cidr=192.168.0.0/30
+subnet_netmask=255.255.255.252
+subnet_prefix=30
+env=prod
+site_id=001
+`
+	if output := formatter.FormatInventoryINI(groups); output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+
+	expected = `# Ansible inventory generated by simple-cidr-calculator
+all:
+  children:
+    db:
+      hosts:
+        host-192-168-0-1:
+          ansible_host: 192.168.0.1
+        host-192-168-0-2:
+          ansible_host: 192.168.0.2
+      vars:
+        subnet_cidr: 192.168.0.0/30
+        subnet_netmask: 255.255.255.252
+        subnet_prefix: 30
+        env: prod
+        site_id: "001"
+`
+	if output := formatter.FormatInventoryYAML(groups); output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestCLIHandler_Inventory(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	plan := filepath.Join(dir, "plan.txt")
+	if err := os.WriteFile(plan, []byte("10.1.0.0/29 lab env=test\n10.2.0.0/29 env=prod\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A .yml output file picks the YAML format
+	output := filepath.Join(dir, "hosts.yml")
+	if err := handler.Run([]string{"cidr-calc", "inventory", "-f", plan, "--tag", "env=test", "--split", "30", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	for _, exp := range []string{"all:\n  children:\n    net_10_1_0_0_30:\n", "    net_10_1_0_4_30:\n", "        host-10-1-0-6:\n", "        env: test\n"} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected %q in:\n%s", exp, content)
+		}
+	}
+	if strings.Contains(string(content), "10.2.0.") {
+		t.Errorf("expected the prod entry to be filtered out:\n%s", content)
+	}
+
+	errorCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"inventory"}, "inventory requires CIDR arguments or -f"},
+		{[]string{"inventory", "10.0.0.0/30", "--tag", "env"}, "--tag filters the entries of a -f plan file"},
+		{[]string{"inventory", "10.0.0.0/30", "--format", "json"}, "unsupported inventory --format json"},
+		{[]string{"inventory", "2001:db8::/126"}, "hosts are generated for IPv4 networks only"},
+	}
+	for _, tt := range errorCases {
+		if err := handler.Run(append([]string{"cidr-calc"}, tt.args...)); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,539 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Allocation strategies for picking a free block of a pool
+const (
+	StrategyFirstFit = "first-fit"
+	StrategyBestFit  = "best-fit"
+	StrategyBuddy    = "buddy"
+)
+
+// AllocationStrategies lists the strategies the allocate command accepts
+var AllocationStrategies = []string{StrategyFirstFit, StrategyBestFit, StrategyBuddy}
+
+// maxAllocateAttempts caps how often allocate chooses again after another
+// process changed the state file
+const maxAllocateAttempts = 3
+
+// AllocationCandidate is a free gap, or for the buddy strategy a free aligned
+// block, that can hold the requested block
+type AllocationCandidate struct {
+	Range  string // "10.0.1.0 - 10.0.2.255", or a CIDR for buddy blocks
+	Size   uint64
+	Chosen bool
+}
+
+// AllocationChoice is the block a strategy picked and why
+type AllocationChoice struct {
+	Strategy   string
+	Block      *NetworkInfo
+	Reason     string
+	Candidates []AllocationCandidate
+}
+
+// ChooseBlock picks a free /prefix block of the IPv4 pool that overlaps none of
+// the used networks:
+//
+//   - first-fit takes the lowest free address, keeping the top of the pool free
+//   - best-fit takes the smallest gap that fits, keeping large gaps whole
+//   - buddy takes the smallest free aligned block that fits and halves it
+//     until it has the requested size, so freed blocks merge with their buddies
+func (c *CIDRCalculator) ChooseBlock(pool *NetworkInfo, used []*NetworkInfo, prefix int, strategy string) (*AllocationChoice, error) {
+	if pool.IsIPv6() {
+		return nil, fmt.Errorf("allocation supports IPv4 pools only")
+	}
+	if prefix < pool.PrefixLength || prefix > 32 {
+		return nil, fmt.Errorf("a /%d block does not fit in %s", prefix, pool.CIDR())
+	}
+
+	size := uint64(1) << uint(32-prefix)
+	choice := &AllocationChoice{Strategy: strategy}
+	chosen := -1
+	var start uint64
+
+	switch strategy {
+	case StrategyFirstFit, StrategyBestFit:
+		for _, gap := range c.freeRanges(pool, used) {
+			aligned := (gap[0] + size - 1) / size * size
+			if aligned+size-1 > gap[1] {
+				continue
+			}
+			candidate := AllocationCandidate{Range: formatAddressRange(gap[0], gap[1]), Size: gap[1] - gap[0] + 1}
+			choice.Candidates = append(choice.Candidates, candidate)
+			if chosen < 0 || (strategy == StrategyBestFit && candidate.Size < choice.Candidates[chosen].Size) {
+				chosen, start = len(choice.Candidates)-1, aligned
+			}
+		}
+	case StrategyBuddy:
+		for _, block := range c.FreeBlocks(pool, used) {
+			info, err := c.ParseCIDR(block)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse free block %s: %v", block, err)
+			}
+			if info.PrefixLength > prefix {
+				continue
+			}
+			candidate := AllocationCandidate{Range: block, Size: uint64(1) << uint(32-info.PrefixLength)}
+			choice.Candidates = append(choice.Candidates, candidate)
+			if chosen < 0 || candidate.Size < choice.Candidates[chosen].Size {
+				chosen, start = len(choice.Candidates)-1, uint64(ipv4ToUint32(info.NetworkID))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown allocation strategy %q (supported: %s)", strategy, strings.Join(AllocationStrategies, ", "))
+	}
+
+	if chosen < 0 {
+		return nil, fmt.Errorf("%s has no free /%d block", pool.CIDR(), prefix)
+	}
+	choice.Candidates[chosen].Chosen = true
+
+	block, err := c.ParseCIDR(fmt.Sprintf("%s/%d", uint32ToIPv4(uint32(start)), prefix))
+	if err != nil {
+		return nil, err
+	}
+	choice.Block = block
+	choice.Reason = explainChoice(choice.Candidates[chosen], len(choice.Candidates), strategy, start, prefix)
+	return choice, nil
+}
+
+// explainChoice says why the strategy picked the candidate
+func explainChoice(candidate AllocationCandidate, candidates int, strategy string, start uint64, prefix int) string {
+	size := uint64(1) << uint(32-prefix)
+	fit := fmt.Sprintf("leaving %d of its %d addresses free", candidate.Size-size, candidate.Size)
+	if candidate.Size == size {
+		fit = "an exact fit"
+	}
+
+	switch {
+	case strategy == StrategyFirstFit:
+		return fmt.Sprintf("the first gap in address order that holds an aligned /%d is %s, %s", prefix, candidate.Range, fit)
+	case strategy == StrategyBestFit && candidates == 1:
+		return fmt.Sprintf("the only gap that holds an aligned /%d is %s, %s", prefix, candidate.Range, fit)
+	case strategy == StrategyBestFit:
+		return fmt.Sprintf("the smallest of %d gaps that hold an aligned /%d is %s, %s", candidates, prefix, candidate.Range, fit)
+	}
+
+	reason := fmt.Sprintf("the smallest of %d free buddy blocks that hold a /%d is %s", candidates, prefix, candidate.Range)
+	if candidates == 1 {
+		reason = fmt.Sprintf("the only free buddy block that holds a /%d is %s", prefix, candidate.Range)
+	}
+	if candidate.Size == size {
+		return reason + ", an exact fit"
+	}
+
+	// Each halving keeps the lower half and leaves the upper half, its buddy, free
+	var buddies []string
+	blockPrefix := 32
+	for uint64(1)<<uint(32-blockPrefix) < candidate.Size {
+		blockPrefix--
+	}
+	for p := blockPrefix + 1; p <= prefix; p++ {
+		buddies = append(buddies, fmt.Sprintf("%s/%d", uint32ToIPv4(uint32(start+uint64(1)<<uint(32-p))), p))
+	}
+	return fmt.Sprintf("%s; halving it down to a /%d leaves the buddies %s free", reason, prefix, strings.Join(buddies, ", "))
+}
+
+// formatAddressRange renders an IPv4 address range as "first - last"
+func formatAddressRange(first, last uint64) string {
+	return fmt.Sprintf("%s - %s", uint32ToIPv4(uint32(first)), uint32ToIPv4(uint32(last)))
+}
+
+// FormatAllocation renders the allocated block, the reason and the candidates
+func (f *OutputFormatter) FormatAllocation(pool *NetworkInfo, choice *AllocationChoice) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Allocated %s from %s (%s)\n", choice.Block.CIDR(), pool.CIDR(), choice.Strategy))
+	output.WriteString(fmt.Sprintf("  Why: %s\n", choice.Reason))
+
+	output.WriteString("\nCandidates:\n")
+	for _, candidate := range choice.Candidates {
+		marker := " "
+		if candidate.Chosen {
+			marker = "*"
+		}
+		output.WriteString(fmt.Sprintf("  %s %-33s %d addresses\n", marker, candidate.Range, candidate.Size))
+	}
+
+	return output.String()
+}
+
+// readIPAMState reads the allocations recorded in a state, which is a plan
+// file, and the version they were read at. A missing state is empty.
+func readIPAMState(state ipamState) ([]BatchEntry, string, error) {
+	content, err := state.store.Get(state.key)
+	if err != nil && !isNotExist(err) {
+		return nil, "", fmt.Errorf("failed to read state file: %v", err)
+	}
+
+	entries, err := parseBatch(state.location, bytes.NewReader(content))
+	if err != nil {
+		return nil, "", err
+	}
+	return entries, stateVersion(content), nil
+}
+
+// usedRanges returns the allocations of the state, if any, followed by the
+// reserved ranges of the policy, and the version of the state
+func (c *CLIHandler) usedRanges(stateFile string, reserved *ReservedPolicy) ([]planNetwork, string, error) {
+	var used []planNetwork
+	var version string
+	if stateFile != "" {
+		state, err := c.openState(stateFile)
+		if err != nil {
+			return nil, "", err
+		}
+		entries, stateVersion, err := readIPAMState(state)
+		if err != nil {
+			return nil, "", err
+		}
+		version = stateVersion
+		for _, entry := range entries {
+			info, err := c.calculator.ParseCIDR(entry.CIDR)
+			if err != nil {
+				return nil, "", fmt.Errorf("%s line %d: failed to parse CIDR: %v", entry.Source, entry.Line, err)
+			}
+			used = append(used, planNetwork{entry: entry, info: info})
+		}
+	}
+	if reserved != nil {
+		used = append(used, reserved.ranges...)
+	}
+	return used, version, nil
+}
+
+// runAllocate implements the allocate subcommand
+func (c *CLIHandler) runAllocate(args []string) error {
+	flagSet := flag.NewFlagSet("allocate", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var poolCIDR, strategy, stateFile, name, tenant, expires, outputFile string
+	var prefix, hosts int
+	var dryRun bool
+	var reserved *ReservedPolicy
+	var quotas quotaFlags
+	flagSet.StringVar(&poolCIDR, "pool", "", "Pool to allocate from")
+	flagSet.IntVar(&prefix, "prefix", 0, "Prefix length of the block to allocate")
+	flagSet.IntVar(&hosts, "hosts", 0, "Allocate the smallest block with this many usable hosts")
+	flagSet.StringVar(&strategy, "strategy", StrategyFirstFit, "Allocation strategy: "+strings.Join(AllocationStrategies, ", "))
+	flagSet.StringVar(&stateFile, "state", "", "Plan file or s3://, gs://, etcd://, consul:// URL of existing allocations; the new block is appended")
+	flagSet.StringVar(&name, "name", "", "Record the allocation with a name=NAME tag")
+	flagSet.StringVar(&tenant, "tenant", "", "Record the allocation for a tenant, with a tenant=TENANT tag (see --by)")
+	quotas.define(flagSet)
+	flagSet.StringVar(&expires, "expires", "", "Record when the allocation is due to be reclaimed: a date such as 2026-12-31 or a period such as 30d")
+	flagSet.BoolVar(&dryRun, "dry-run", false, "Choose a block without recording it")
+	flagSet.Var(&policyFlag{target: &reserved}, "reserved", "File of reserved CIDRs that must not be allocated")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if flagSet.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", flagSet.Arg(0))
+	}
+
+	if poolCIDR == "" {
+		return fmt.Errorf("allocate requires --pool")
+	}
+	pool, err := c.calculator.ParseCIDR(poolCIDR)
+	if err != nil {
+		return fmt.Errorf("failed to parse pool %s: %v", poolCIDR, err)
+	}
+
+	switch {
+	case (prefix == 0) == (hosts == 0):
+		return fmt.Errorf("allocate requires one of --prefix and --hosts")
+	case hosts > 0:
+		prefix = prefixForHosts(false, hosts)
+	}
+
+	if strings.ContainsAny(name, " \t#") {
+		return fmt.Errorf("invalid --name %q: it cannot contain spaces or #", name)
+	}
+	if strings.ContainsAny(tenant, " \t#") {
+		return fmt.Errorf("invalid --tenant %q: it cannot contain spaces or #", tenant)
+	}
+	policy, err := quotas.load()
+	if err != nil {
+		return err
+	}
+	if policy != nil && tenant == "" {
+		return fmt.Errorf("--quotas requires --tenant")
+	}
+	var expiry time.Time
+	if expires != "" {
+		if expiry, err = parseExpiry(expires, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	// The block is chosen from the state as read and recorded only if nobody
+	// changed the state meanwhile; otherwise it is chosen again
+	var choice *AllocationChoice
+	for attempt := 1; ; attempt++ {
+		allocations, version, err := c.usedRanges(stateFile, reserved)
+		if err != nil {
+			return err
+		}
+		used := make([]*NetworkInfo, 0, len(allocations))
+		for _, allocation := range allocations {
+			used = append(used, allocation.info)
+		}
+
+		choice, err = c.calculator.ChooseBlock(pool, used, prefix, strategy)
+		if err != nil {
+			return err
+		}
+		if policy != nil {
+			warning, err := policy.checkQuota(allocations, tenant, choice.Block)
+			if err != nil {
+				return err
+			}
+			if warning != "" {
+				c.warnf("%s", warning)
+			}
+		}
+		if stateFile == "" || dryRun {
+			break
+		}
+
+		// Tags are written sorted, as plan documents write them back
+		tags := Tags{}
+		if name != "" {
+			tags["name"] = name
+		}
+		if tenant != "" {
+			key := defaultQuotaKey
+			if policy != nil {
+				key = policy.Key
+			}
+			tags[key] = tenant
+		}
+		if !expiry.IsZero() {
+			tags[expiresTag] = expiry.Format(expiryDateLayout)
+		}
+		line := choice.Block.CIDR()
+		if len(tags) > 0 {
+			line += " " + tags.String()
+		}
+		// The comment keeps the provenance of the block: the pool, the
+		// strategy and, when it was sized for hosts, how many
+		rule := strategy
+		if hosts > 0 {
+			rule += ", " + plural(hosts, "host")
+		}
+		line += fmt.Sprintf(" # allocated from %s (%s)", pool.CIDR(), rule)
+		state, err := c.openState(stateFile)
+		if err != nil {
+			return err
+		}
+		err = commitIPAM(state, version, func(content string) (string, JournalEntry, error) {
+			return addStateLine(content, line), JournalEntry{Time: time.Now(), Op: OpAllocate, Line: line}, nil
+		})
+		if err == errStateChanged {
+			if attempt == maxAllocateAttempts {
+				return fmt.Errorf("%s kept changing while allocating; try again", stateFile)
+			}
+			c.notef("%s changed while allocating; choosing again", stateFile)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		c.notef("recorded %s in %s", choice.Block.CIDR(), stateFile)
+		break
+	}
+
+	return c.writeOutput(c.formatter.FormatAllocation(pool, choice), outputFile)
+}
+
+// runIPAM implements the ipam subcommand and its commands
+func (c *CLIHandler) runIPAM(args []string) error {
+	commands := map[string]subcommand{
+		"allocate": c.runAllocate,
+		"release":  c.runRelease,
+		"undo":     c.runUndo,
+		"log":      c.runIPAMLog,
+		"import":   c.runIPAMImport,
+		"quota":    c.runIPAMQuota,
+		"expiring": c.runIPAMExpiring,
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("ipam requires a command: allocate, release, undo, log, import, quota or expiring")
+	}
+	run, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown ipam command %q (available: allocate, release, undo, log, import, quota, expiring)", args[0])
+	}
+	return run(args[1:])
+}
+
+// ipamStateFlags parses the flags of an ipam command that works on a state and
+// returns the state and positional arguments
+func (c *CLIHandler) ipamStateFlags(name string, args []string) (ipamState, []string, error) {
+	flagSet := flag.NewFlagSet("ipam "+name, flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var stateFile string
+	flagSet.StringVar(&stateFile, "state", "", "Plan file or s3://, gs://, etcd://, consul:// URL of allocations")
+
+	// Accept positional arguments anywhere among the flags
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return ipamState{}, nil, fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	if stateFile == "" {
+		return ipamState{}, nil, fmt.Errorf("ipam %s requires --state", name)
+	}
+	state, err := c.openState(stateFile)
+	if err != nil {
+		return ipamState{}, nil, err
+	}
+	return state, positional, nil
+}
+
+// runRelease removes an allocation from the state file
+func (c *CLIHandler) runRelease(args []string) error {
+	state, cidrs, err := c.ipamStateFlags("release", args)
+	if err != nil {
+		return err
+	}
+	if len(cidrs) != 1 {
+		return fmt.Errorf("ipam release takes a single CIDR, got %d", len(cidrs))
+	}
+	network, err := c.calculator.ParseCIDR(cidrs[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse CIDR %s: %v", cidrs[0], err)
+	}
+
+	err = commitIPAM(state, "", func(content string) (string, JournalEntry, error) {
+		entries, err := parseBatch(state.location, strings.NewReader(content))
+		if err != nil {
+			return "", JournalEntry{}, err
+		}
+
+		// The journal keeps the whole plan line so undo restores its tags and comment
+		var line string
+		lines := strings.Split(content, "\n")
+		for _, entry := range entries {
+			if info, err := c.calculator.ParseCIDR(entry.CIDR); err == nil && info.CIDR() == network.CIDR() {
+				line = strings.TrimRight(lines[entry.Line-1], "\r")
+			}
+		}
+		if line == "" {
+			return "", JournalEntry{}, fmt.Errorf("%s is not allocated in %s", network.CIDR(), state)
+		}
+
+		updated, _ := removeStateLine(content, line)
+		return updated, JournalEntry{Time: time.Now(), Op: OpRelease, Line: line}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.notef("released %s from %s", network.CIDR(), state)
+	return nil
+}
+
+// runUndo reverts the latest operation of the journal that is not yet undone
+func (c *CLIHandler) runUndo(args []string) error {
+	state, extra, err := c.ipamStateFlags("undo", args)
+	if err != nil {
+		return err
+	}
+	if len(extra) > 0 {
+		return fmt.Errorf("unexpected argument %q", extra[0])
+	}
+
+	// The journal is read under the lock, so concurrent undos revert different operations
+	var target JournalEntry
+	err = commitIPAM(state, "", func(content string) (string, JournalEntry, error) {
+		entries, err := readJournal(state)
+		if err != nil {
+			return "", JournalEntry{}, err
+		}
+		var ok bool
+		if target, ok = lastUndoable(entries); !ok {
+			return "", JournalEntry{}, fmt.Errorf("nothing to undo in %s", journalPath(state.location))
+		}
+
+		entry := JournalEntry{Time: time.Now(), Op: undoPrefix + target.Op, Line: target.Line}
+		switch target.Op {
+		case OpAllocate:
+			updated, ok := removeStateLine(content, target.Line)
+			if !ok {
+				return "", JournalEntry{}, fmt.Errorf("cannot undo %s: %q is no longer in %s", target.Op, target.Line, state)
+			}
+			return updated, entry, nil
+		case OpRelease:
+			return addStateLine(content, target.Line), entry, nil
+		case OpImport:
+			updated := content
+			for _, line := range target.Lines() {
+				var ok bool
+				if updated, ok = removeStateLine(updated, line); !ok {
+					return "", JournalEntry{}, fmt.Errorf("cannot undo %s: %q is no longer in %s", target.Op, line, state)
+				}
+			}
+			return updated, entry, nil
+		default:
+			return "", JournalEntry{}, fmt.Errorf("cannot undo unknown operation %q", target.Op)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	c.notef("undid %s of %s", target.Op, target.Line)
+	return nil
+}
+
+// runIPAMLog prints the journal of a state file
+func (c *CLIHandler) runIPAMLog(args []string) error {
+	state, extra, err := c.ipamStateFlags("log", args)
+	if err != nil {
+		return err
+	}
+	if len(extra) > 0 {
+		return fmt.Errorf("unexpected argument %q", extra[0])
+	}
+
+	entries, err := readJournal(state)
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(c.formatter.FormatJournal(state.location, entries), "")
+}
+
+// FormatJournal renders the operations of an IPAM journal, oldest first
+func (f *OutputFormatter) FormatJournal(stateFile string, entries []JournalEntry) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("IPAM Journal for %s:\n", stateFile))
+	if len(entries) == 0 {
+		output.WriteString("  no operations recorded\n")
+	}
+	for _, entry := range entries {
+		op := entry.Op
+		if undone, ok := entry.Undone(); ok {
+			op = "undo " + undone
+		}
+		// An import shows its first line and how many more it added
+		line := entry.Lines()[0]
+		if more := len(entry.Lines()) - 1; more > 0 {
+			line += fmt.Sprintf(" (+%d more)", more)
+		}
+		output.WriteString(fmt.Sprintf("  %s  %-13s %s\n", entry.Time.UTC().Format("2006-01-02 15:04:05 UTC"), op, line))
+	}
+
+	return output.String()
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIDRCalculator_ChooseBlock(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	pool, _ := calculator.ParseCIDR("10.0.0.0/20")
+
+	var used []*NetworkInfo
+	for _, cidr := range []string{"10.0.0.0/24", "10.0.2.0/24", "10.0.3.0/25", "10.0.4.0/22"} {
+		info, _ := calculator.ParseCIDR(cidr)
+		used = append(used, info)
+	}
+
+	tests := []struct {
+		strategy   string
+		prefix     int
+		expected   string
+		reason     string
+		candidates int
+	}{
+		{StrategyFirstFit, 25, "10.0.1.0/25", "the first gap in address order that holds an aligned /25 is 10.0.1.0 - 10.0.1.255, leaving 128 of its 256 addresses free", 3},
+		{StrategyFirstFit, 23, "10.0.8.0/23", "the first gap in address order that holds an aligned /23 is 10.0.8.0 - 10.0.15.255, leaving 1536 of its 2048 addresses free", 1},
+		{StrategyBestFit, 25, "10.0.3.128/25", "the smallest of 3 gaps that hold an aligned /25 is 10.0.3.128 - 10.0.3.255, an exact fit", 3},
+		{StrategyBestFit, 24, "10.0.1.0/24", "the smallest of 2 gaps that hold an aligned /24 is 10.0.1.0 - 10.0.1.255, an exact fit", 2},
+		{StrategyBestFit, 23, "10.0.8.0/23", "the only gap that holds an aligned /23 is 10.0.8.0 - 10.0.15.255, leaving 1536 of its 2048 addresses free", 1},
+		{StrategyBuddy, 25, "10.0.3.128/25", "the smallest of 3 free buddy blocks that hold a /25 is 10.0.3.128/25, an exact fit", 3},
+		{StrategyBuddy, 26, "10.0.3.128/26", "the smallest of 3 free buddy blocks that hold a /26 is 10.0.3.128/25; halving it down to a /26 leaves the buddies 10.0.3.192/26 free", 3},
+		{StrategyBuddy, 23, "10.0.8.0/23", "the only free buddy block that holds a /23 is 10.0.8.0/21; halving it down to a /23 leaves the buddies 10.0.12.0/22, 10.0.10.0/23 free", 1},
+	}
+
+	for _, tt := range tests {
+		choice, err := calculator.ChooseBlock(pool, used, tt.prefix, tt.strategy)
+		if err != nil {
+			t.Fatalf("%s /%d: unexpected error: %v", tt.strategy, tt.prefix, err)
+		}
+		if choice.Block.CIDR() != tt.expected {
+			t.Errorf("%s /%d: expected %s, got %s", tt.strategy, tt.prefix, tt.expected, choice.Block.CIDR())
+		}
+		if choice.Reason != tt.reason {
+			t.Errorf("%s /%d: expected reason %q, got %q", tt.strategy, tt.prefix, tt.reason, choice.Reason)
+		}
+		if len(choice.Candidates) != tt.candidates {
+			t.Errorf("%s /%d: expected %d candidates, got %+v", tt.strategy, tt.prefix, tt.candidates, choice.Candidates)
+		}
+	}
+
+	errorTests := []struct {
+		strategy string
+		prefix   int
+		expected string
+	}{
+		{StrategyFirstFit, 20, "10.0.0.0/20 has no free /20 block"},
+		{StrategyFirstFit, 16, "a /16 block does not fit in 10.0.0.0/20"},
+		{"worst-fit", 24, `unknown allocation strategy "worst-fit"`},
+	}
+	for _, tt := range errorTests {
+		if _, err := calculator.ChooseBlock(pool, used, tt.prefix, tt.strategy); err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+			t.Errorf("%s /%d: expected error %q, got %v", tt.strategy, tt.prefix, tt.expected, err)
+		}
+	}
+}
+
+func TestCLIHandler_Allocate(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	state := filepath.Join(dir, "ipam.txt")
+	if err := os.WriteFile(state, []byte("10.0.0.0/24 name=core\n10.0.1.0/25"), 0644); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+	reserved := filepath.Join(dir, "reserved.txt")
+	if err := os.WriteFile(reserved, []byte("10.0.1.128/25 # lab\n"), 0644); err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+	output := filepath.Join(dir, "out.txt")
+
+	run := func(args ...string) (string, error) {
+		err := handler.Run(append(append([]string{"cidr-calc"}, args...), "-o", output))
+		content, _ := os.ReadFile(output)
+		return string(content), err
+	}
+
+	content, err := run("allocate", "--pool", "10.0.0.0/22", "--hosts", "100", "--state", state, "--reserved", reserved, "--dry-run")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(content, "Allocated 10.0.2.0/25 from 10.0.0.0/22 (first-fit)\n") {
+		t.Errorf("unexpected output:\n%s", content)
+	}
+
+	if _, err := run("allocate", "--pool", "10.0.0.0/22", "--prefix", "24", "--strategy", "buddy", "--state", state, "--name", "web"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := run("allocate", "--pool", "10.0.0.0/22", "--prefix", "24", "--state", state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	recorded, _ := os.ReadFile(state)
+	expected := "10.0.0.0/24 name=core\n10.0.1.0/25\n" +
+		"10.0.2.0/24 name=web # allocated from 10.0.0.0/22 (buddy)\n" +
+		"10.0.3.0/24 # allocated from 10.0.0.0/22 (first-fit)\n"
+	if string(recorded) != expected {
+		t.Errorf("expected state:\n%s\ngot:\n%s", expected, recorded)
+	}
+
+	errorTests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"allocate", "--prefix", "24"}, "allocate requires --pool"},
+		{[]string{"allocate", "--pool", "10.0.0.0/22"}, "allocate requires one of --prefix and --hosts"},
+		{[]string{"allocate", "--pool", "10.0.0.0/22", "--prefix", "24", "--name", "a b"}, `invalid --name "a b"`},
+		{[]string{"allocate", "--pool", "10.0.0.0/22", "--prefix", "24", "--state", state}, "10.0.0.0/22 has no free /24 block"},
+		{[]string{"allocate", "--pool", "2001:db8::/48", "--prefix", "64"}, "allocation supports IPv4 pools only"},
+	}
+	for _, tt := range errorTests {
+		if _, err := run(tt.args...); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
+
+func TestCLIHandler_IPAM(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+	state := filepath.Join(dir, "ipam.txt")
+	output := filepath.Join(dir, "out.txt")
+
+	run := func(args ...string) error {
+		return handler.Run(append([]string{"cidr-calc", "ipam"}, args...))
+	}
+	expectState := func(step, expected string) {
+		t.Helper()
+		content, _ := os.ReadFile(state)
+		if string(content) != expected {
+			t.Errorf("%s: expected state %q, got %q", step, expected, content)
+		}
+	}
+
+	web := "10.0.0.0/24 name=web # allocated from 10.0.0.0/22 (first-fit)"
+	db := "10.0.1.0/25 # allocated from 10.0.0.0/22 (first-fit)"
+	if err := run("allocate", "--pool", "10.0.0.0/22", "--prefix", "24", "--state", state, "--name", "web", "-o", output); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := handler.Run([]string{"cidr-calc", "allocate", "--pool", "10.0.0.0/22", "--prefix", "25", "--state", state, "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectState("allocate", web+"\n"+db+"\n")
+
+	if err := run("release", "--state", state, "10.0.0.0/24"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectState("release", db+"\n")
+
+	if err := run("undo", "--state", state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectState("undo release", db+"\n"+web+"\n")
+	if err := run("undo", "--state", state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectState("undo allocate", web+"\n")
+
+	entries, err := readJournal(localState(state))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	log := handler.formatter.FormatJournal("ipam.txt", entries)
+	for _, expected := range []string{"IPAM Journal for ipam.txt:\n", "  allocate      " + web, "  undo release  " + web, "  undo allocate " + db} {
+		if !strings.Contains(log, expected) {
+			t.Errorf("expected log to contain %q, got:\n%s", expected, log)
+		}
+	}
+	if empty := handler.formatter.FormatJournal("new.txt", nil); !strings.Contains(empty, "no operations recorded") {
+		t.Errorf("unexpected empty log:\n%s", empty)
+	}
+
+	errorTests := []struct {
+		args     []string
+		expected string
+	}{
+		{nil, "ipam requires a command"},
+		{[]string{"rollback"}, `unknown ipam command "rollback"`},
+		{[]string{"undo"}, "ipam undo requires --state"},
+		{[]string{"release", "--state", state}, "ipam release takes a single CIDR, got 0"},
+		{[]string{"release", "--state", state, "10.0.1.0/25"}, "10.0.1.0/25 is not allocated in"},
+		{[]string{"undo", "--state", filepath.Join(t.TempDir(), "none.txt")}, "nothing to undo in"},
+	}
+	for _, tt := range errorTests {
+		if err := run(tt.args...); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IPAM operations recorded in the journal. Undoing an operation records
+// undoPrefix followed by the operation it reverted. An import records all the
+// plan lines it added, one per line.
+const (
+	OpAllocate = "allocate"
+	OpRelease  = "release"
+	OpImport   = "import"
+	undoPrefix = "undo:"
+)
+
+// JournalEntry is one operation on an IPAM state file: the plan line it added
+// or removed
+type JournalEntry struct {
+	Time time.Time
+	Op   string
+	Line string
+}
+
+// Undone returns the operation an undo entry reverted and whether it is one
+func (e JournalEntry) Undone() (string, bool) {
+	if strings.HasPrefix(e.Op, undoPrefix) {
+		return strings.TrimPrefix(e.Op, undoPrefix), true
+	}
+	return "", false
+}
+
+// Lines returns the plan lines of the entry
+func (e JournalEntry) Lines() []string {
+	return strings.Split(e.Line, "\n")
+}
+
+// String formats the entry as a journal line: time, operation and quoted plan line
+func (e JournalEntry) String() string {
+	return fmt.Sprintf("%s %s %s", e.Time.UTC().Format(time.RFC3339), e.Op, strconv.Quote(e.Line))
+}
+
+// journalPath returns the journal kept next to a state
+func journalPath(stateFile string) string {
+	return stateFile + ".journal"
+}
+
+// readJournal reads the journal of a state; a missing journal is empty
+func readJournal(state ipamState) ([]JournalEntry, error) {
+	content, err := state.store.Get(journalPath(state.key))
+	if isNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %v", err)
+	}
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for number := 1; scanner.Scan(); number++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		entry, err := parseJournalLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", journalPath(state.location), number, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %v", err)
+	}
+	return entries, nil
+}
+
+// parseJournalLine parses a line written by JournalEntry.String
+func parseJournalLine(text string) (JournalEntry, error) {
+	fields := strings.SplitN(text, " ", 3)
+	if len(fields) != 3 {
+		return JournalEntry{}, fmt.Errorf("invalid journal entry %q", text)
+	}
+	recorded, err := time.Parse(time.RFC3339, fields[0])
+	if err != nil {
+		return JournalEntry{}, fmt.Errorf("invalid journal time %q", fields[0])
+	}
+	line, err := strconv.Unquote(fields[2])
+	if err != nil {
+		return JournalEntry{}, fmt.Errorf("invalid journal line %s", fields[2])
+	}
+	return JournalEntry{Time: recorded, Op: fields[1], Line: line}, nil
+}
+
+// appendJournal adds an entry to the journal of a state. Local journals are
+// appended to; other stores rewrite the journal, which the caller's lock keeps
+// consistent.
+func appendJournal(state ipamState, entry JournalEntry) error {
+	if _, ok := state.store.(fileStore); ok {
+		file, err := os.OpenFile(journalPath(state.key), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open journal: %v", err)
+		}
+		if _, err := fmt.Fprintln(file, entry.String()); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write journal: %v", err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to close journal: %v", err)
+		}
+		return nil
+	}
+
+	content, err := state.store.Get(journalPath(state.key))
+	if err != nil && !isNotExist(err) {
+		return fmt.Errorf("failed to open journal: %v", err)
+	}
+	if err := state.store.Put(journalPath(state.key), []byte(addStateLine(string(content), entry.String()))); err != nil {
+		return fmt.Errorf("failed to write journal: %v", err)
+	}
+	return nil
+}
+
+// lastUndoable returns the latest operation that has not been undone. Undo
+// entries cancel the operations before them, newest first.
+func lastUndoable(entries []JournalEntry) (JournalEntry, bool) {
+	var stack []JournalEntry
+	for _, entry := range entries {
+		if _, ok := entry.Undone(); ok {
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+		stack = append(stack, entry)
+	}
+	if len(stack) == 0 {
+		return JournalEntry{}, false
+	}
+	return stack[len(stack)-1], true
+}
+
+// addStateLine appends a plan line to the state content, starting a new line
+// if the content does not end with one
+func addStateLine(content, line string) string {
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + line + "\n"
+}
+
+// removeStateLine removes the last line of the content equal to line
+func removeStateLine(content, line string) (string, bool) {
+	lines := strings.SplitAfter(content, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimRight(lines[i], "\r\n") == line {
+			return strings.Join(append(lines[:i], lines[i+1:]...), ""), true
+		}
+	}
+	return content, false
+}
+
+// writeFileAtomic replaces a file through a temporary file in the same
+// directory, so readers see either the old or the new content. The file keeps
+// its mode; a new file is created with mode 0644.
+func writeFileAtomic(filename string, content []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write temporary file: %v", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %v", err)
+	}
+	if err := os.Chmod(temp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to set file mode: %v", err)
+	}
+	if err := os.Rename(temp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", filename, err)
+	}
+	return nil
+}
+
+// commitIPAM applies an operation to the state and journals the entry change
+// returns with the new content. It holds the lock of the state throughout and
+// fails with errStateChanged if version is set and the state no longer has it.
+// The state is restored when the journal cannot be written, so every change in
+// the store has a journal entry to undo it.
+func commitIPAM(state ipamState, version string, change func(content string) (string, JournalEntry, error)) error {
+	unlock, err := lockState(state)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	original, err := state.store.Get(state.key)
+	if err != nil && !isNotExist(err) {
+		return fmt.Errorf("failed to read state file: %v", err)
+	}
+	existed := err == nil
+	if version != "" && stateVersion(original) != version {
+		return errStateChanged
+	}
+
+	// A plan document state is edited as a plan file of its allocations
+	content, encode, err := stateAsPlan(state.location, original)
+	if err != nil {
+		return err
+	}
+	updated, entry, err := change(content)
+	if err != nil {
+		return err
+	}
+	if updated, err = encode(updated); err != nil {
+		return err
+	}
+	if err := state.store.Put(state.key, []byte(updated)); err != nil {
+		return err
+	}
+
+	if err := appendJournal(state, entry); err != nil {
+		var restoreErr error
+		if existed {
+			restoreErr = state.store.Put(state.key, original)
+		} else {
+			restoreErr = state.store.Delete(state.key)
+		}
+		if restoreErr != nil {
+			return fmt.Errorf("%v (restoring the state failed: %v)", err, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
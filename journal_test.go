@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournalEntry_RoundTrip(t *testing.T) {
+	entry := JournalEntry{
+		Time: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+		Op:   OpRelease,
+		Line: `10.0.0.0/24 name=web # "frontends"`,
+	}
+	text := entry.String()
+	if expected := `2024-03-01T12:30:00Z release "10.0.0.0/24 name=web # \"frontends\""`; text != expected {
+		t.Errorf("expected %q, got %q", expected, text)
+	}
+
+	parsed, err := parseJournalLine(text)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !parsed.Time.Equal(entry.Time) || parsed.Op != entry.Op || parsed.Line != entry.Line {
+		t.Errorf("expected %+v, got %+v", entry, parsed)
+	}
+
+	for _, invalid := range []string{"allocate", "yesterday allocate \"10.0.0.0/24\"", "2024-03-01T12:30:00Z allocate 10.0.0.0/24"} {
+		if _, err := parseJournalLine(invalid); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+}
+
+func TestLastUndoable(t *testing.T) {
+	entry := func(op, line string) JournalEntry {
+		return JournalEntry{Op: op, Line: line}
+	}
+
+	tests := []struct {
+		name     string
+		entries  []JournalEntry
+		expected string
+	}{
+		{"empty", nil, ""},
+		{"latest", []JournalEntry{entry(OpAllocate, "a"), entry(OpRelease, "b")}, "b"},
+		{"after undo", []JournalEntry{entry(OpAllocate, "a"), entry(OpAllocate, "b"), entry(undoPrefix+OpAllocate, "b")}, "a"},
+		{"all undone", []JournalEntry{entry(OpAllocate, "a"), entry(undoPrefix+OpAllocate, "a")}, ""},
+		{"new operation after undo", []JournalEntry{
+			entry(OpAllocate, "a"), entry(OpAllocate, "b"), entry(undoPrefix+OpAllocate, "b"), entry(OpAllocate, "c"),
+			entry(undoPrefix+OpAllocate, "c"),
+		}, "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lastUndoable(tt.entries)
+			if ok != (tt.expected != "") || got.Line != tt.expected {
+				t.Errorf("expected %q, got %q (found %v)", tt.expected, got.Line, ok)
+			}
+		})
+	}
+}
+
+func TestStateLines(t *testing.T) {
+	if got := addStateLine("", "10.0.0.0/24"); got != "10.0.0.0/24\n" {
+		t.Errorf("unexpected content %q", got)
+	}
+	if got := addStateLine("10.0.0.0/24", "10.0.1.0/24"); got != "10.0.0.0/24\n10.0.1.0/24\n" {
+		t.Errorf("unexpected content %q", got)
+	}
+
+	content := "10.0.0.0/24\n# note\n10.0.0.0/24\r\n10.0.1.0/24\n"
+	got, ok := removeStateLine(content, "10.0.0.0/24")
+	if !ok || got != "10.0.0.0/24\n# note\n10.0.1.0/24\n" {
+		t.Errorf("unexpected content %q (removed %v)", got, ok)
+	}
+	if _, ok := removeStateLine(content, "10.0.2.0/24"); ok {
+		t.Error("expected a missing line not to be removed")
+	}
+}
+
+func TestCommitIPAM(t *testing.T) {
+	dir := t.TempDir()
+	state := filepath.Join(dir, "ipam.txt")
+
+	add := func(content string) (string, JournalEntry, error) {
+		return addStateLine(content, "10.0.0.0/24"), JournalEntry{Time: time.Now(), Op: OpAllocate, Line: "10.0.0.0/24"}, nil
+	}
+	if err := commitIPAM(localState(state), "", add); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(state)
+	entries, err := readJournal(localState(state))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(content) != "10.0.0.0/24\n" || len(entries) != 1 || entries[0].Op != OpAllocate {
+		t.Errorf("unexpected state %q and journal %+v", content, entries)
+	}
+
+	// A journal that cannot be appended to rolls the state back
+	if err := os.Remove(journalPath(state)); err != nil {
+		t.Fatalf("failed to remove journal: %v", err)
+	}
+	if err := os.Mkdir(journalPath(state), 0755); err != nil {
+		t.Fatalf("failed to block journal: %v", err)
+	}
+	if err := commitIPAM(localState(state), "", add); err == nil || !strings.Contains(err.Error(), "failed to open journal") {
+		t.Errorf("expected a journal error, got %v", err)
+	}
+	if content, _ := os.ReadFile(state); string(content) != "10.0.0.0/24\n" {
+		t.Errorf("expected the state to be restored, got %q", content)
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Kubernetes range kinds
+const (
+	KindPodCIDR      = "pod"
+	KindServiceCIDR  = "service"
+	KindLoadBalancer = "loadbalancer"
+)
+
+// RuleServiceCIDRUnknown notes that the service range could not be discovered
+const RuleServiceCIDRUnknown = "service-cidr-unknown"
+
+// k8sMetadata holds the object fields used to label discovered ranges
+type k8sMetadata struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// k8sNode is a node as returned by kubectl get nodes
+type k8sNode struct {
+	Metadata k8sMetadata `json:"metadata"`
+	Spec     struct {
+		PodCIDR  string   `json:"podCIDR"`
+		PodCIDRs []string `json:"podCIDRs"`
+	} `json:"spec"`
+}
+
+// k8sServiceCIDR is a networking.k8s.io ServiceCIDR object (Kubernetes 1.29+)
+type k8sServiceCIDR struct {
+	Metadata k8sMetadata `json:"metadata"`
+	Spec     struct {
+		CIDRs []string `json:"cidrs"`
+	} `json:"spec"`
+}
+
+// k8sPod is the subset of a pod needed to read kube-apiserver flags
+type k8sPod struct {
+	Metadata k8sMetadata `json:"metadata"`
+	Spec     struct {
+		Containers []struct {
+			Command []string `json:"command"`
+			Args    []string `json:"args"`
+		} `json:"containers"`
+	} `json:"spec"`
+}
+
+// metalLBPool is a MetalLB IPAddressPool
+type metalLBPool struct {
+	Metadata k8sMetadata `json:"metadata"`
+	Spec     struct {
+		Addresses []string `json:"addresses"`
+	} `json:"spec"`
+}
+
+// ciliumLBPool is a Cilium CiliumLoadBalancerIPPool
+type ciliumLBPool struct {
+	Metadata k8sMetadata `json:"metadata"`
+	Spec     struct {
+		Blocks []struct {
+			CIDR  string `json:"cidr"`
+			Start string `json:"start"`
+			Stop  string `json:"stop"`
+		} `json:"blocks"`
+	} `json:"spec"`
+}
+
+// KubernetesSource discovers the address ranges of a cluster through kubectl
+type KubernetesSource struct {
+	kubeconfig string
+	context    string
+	run        commandRunner
+}
+
+// NewKubernetesSource creates a source for the cluster selected by the kubeconfig
+// and context; empty values use kubectl's defaults
+func NewKubernetesSource(kubeconfig, context string, run commandRunner) *KubernetesSource {
+	return &KubernetesSource{kubeconfig: kubeconfig, context: context, run: run}
+}
+
+// Ranges returns the node pod CIDRs, the service CIDRs and the LoadBalancer pools
+// of the cluster. serviceFound is false when the service range is not visible,
+// as on managed clusters that hide the API server.
+func (k *KubernetesSource) Ranges() (ranges []DiscoveredRange, serviceFound bool, err error) {
+	var nodes struct {
+		Items []k8sNode `json:"items"`
+	}
+	if err := k.get(&nodes, "nodes"); err != nil {
+		return nil, false, err
+	}
+	for _, node := range nodes.Items {
+		cidrs := node.Spec.PodCIDRs
+		if len(cidrs) == 0 && node.Spec.PodCIDR != "" {
+			cidrs = []string{node.Spec.PodCIDR}
+		}
+		for _, cidr := range cidrs {
+			ranges = append(ranges, DiscoveredRange{Kind: KindPodCIDR, Source: "node/" + node.Metadata.Name, CIDR: cidr})
+		}
+	}
+
+	services := k.serviceRanges()
+	ranges = append(ranges, services...)
+
+	pools, err := k.loadBalancerRanges()
+	if err != nil {
+		return nil, false, err
+	}
+	ranges = append(ranges, pools...)
+
+	return ranges, len(services) > 0, nil
+}
+
+// serviceRanges reads ServiceCIDR objects, falling back to the kube-apiserver
+// --service-cluster-ip-range flag on clusters that predate them
+func (k *KubernetesSource) serviceRanges() []DiscoveredRange {
+	var ranges []DiscoveredRange
+
+	var serviceCIDRs struct {
+		Items []k8sServiceCIDR `json:"items"`
+	}
+	if err := k.get(&serviceCIDRs, "servicecidrs"); err == nil {
+		for _, item := range serviceCIDRs.Items {
+			for _, cidr := range item.Spec.CIDRs {
+				ranges = append(ranges, DiscoveredRange{Kind: KindServiceCIDR, Source: "servicecidr/" + item.Metadata.Name, CIDR: cidr})
+			}
+		}
+		if len(ranges) > 0 {
+			return ranges
+		}
+	}
+
+	var pods struct {
+		Items []k8sPod `json:"items"`
+	}
+	if err := k.get(&pods, "pods", "--namespace", "kube-system", "--selector", "component=kube-apiserver"); err != nil {
+		return nil
+	}
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			for _, arg := range append(container.Command, container.Args...) {
+				if !strings.HasPrefix(arg, "--service-cluster-ip-range=") {
+					continue
+				}
+				value := strings.TrimPrefix(arg, "--service-cluster-ip-range=")
+				for _, cidr := range strings.Split(value, ",") {
+					ranges = append(ranges, DiscoveredRange{Kind: KindServiceCIDR, Source: "pod/" + pod.Metadata.Name, CIDR: cidr})
+				}
+			}
+		}
+		// Every API server replica carries the same flag
+		if len(ranges) > 0 {
+			break
+		}
+	}
+
+	return ranges
+}
+
+// loadBalancerRanges reads MetalLB and Cilium LoadBalancer IP pools. Clusters
+// without either controller have no such resources, which is not an error.
+func (k *KubernetesSource) loadBalancerRanges() ([]DiscoveredRange, error) {
+	var ranges []DiscoveredRange
+
+	var metalLB struct {
+		Items []metalLBPool `json:"items"`
+	}
+	if err := k.get(&metalLB, "ipaddresspools.metallb.io", "--all-namespaces"); err == nil {
+		for _, pool := range metalLB.Items {
+			source := "ipaddresspool/" + pool.Metadata.Namespace + "/" + pool.Metadata.Name
+			for _, address := range pool.Spec.Addresses {
+				cidrs, err := rangeToCIDRs(address)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %v", source, err)
+				}
+				for _, cidr := range cidrs {
+					ranges = append(ranges, DiscoveredRange{Kind: KindLoadBalancer, Source: source, CIDR: cidr})
+				}
+			}
+		}
+	}
+
+	var cilium struct {
+		Items []ciliumLBPool `json:"items"`
+	}
+	if err := k.get(&cilium, "ciliumloadbalancerippools"); err == nil {
+		for _, pool := range cilium.Items {
+			source := "ciliumloadbalancerippool/" + pool.Metadata.Name
+			for _, block := range pool.Spec.Blocks {
+				value := block.CIDR
+				if value == "" {
+					value = block.Start + "-" + block.Stop
+				}
+				cidrs, err := rangeToCIDRs(value)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %v", source, err)
+				}
+				for _, cidr := range cidrs {
+					ranges = append(ranges, DiscoveredRange{Kind: KindLoadBalancer, Source: source, CIDR: cidr})
+				}
+			}
+		}
+	}
+
+	return ranges, nil
+}
+
+// get runs kubectl get for a resource and decodes the JSON list into target
+func (k *KubernetesSource) get(target interface{}, resource string, extra ...string) error {
+	args := []string{"get", resource, "--output", "json"}
+	args = append(args, extra...)
+	if k.kubeconfig != "" {
+		args = append(args, "--kubeconfig", k.kubeconfig)
+	}
+	if k.context != "" {
+		args = append(args, "--context", k.context)
+	}
+
+	output, err := k.run("kubectl", args...)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(output, target); err != nil {
+		return fmt.Errorf("failed to parse kubectl get %s output: %v", resource, err)
+	}
+	return nil
+}
+
+// clusterLabel names the cluster in report headings
+func (k *KubernetesSource) clusterLabel() string {
+	switch {
+	case k.context != "":
+		return k.context
+	case k.kubeconfig != "":
+		return k.kubeconfig
+	default:
+		return "current context"
+	}
+}
+
+// runK8sAudit implements the k8s-audit subcommand
+func (c *CLIHandler) runK8sAudit(args []string) error {
+	flagSet := flag.NewFlagSet("k8s-audit", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var kubeconfig, context, planSource, format, outputFile string
+	var emit stringList
+	flagSet.StringVar(&kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	flagSet.StringVar(&context, "context", "", "Kubeconfig context to audit")
+	flagSet.StringVar(&planSource, "plan", "", "Corporate plan file, URL or - for stdin")
+	flagSet.StringVar(&format, "format", FormatText, "Findings format: text, gh-annotations")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	flagSet.Var(&emit, "emit", emitFlagUsage)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	emitTargets, err := parseEmitTargets(emit)
+	if err != nil {
+		return err
+	}
+
+	if planSource == "" {
+		return fmt.Errorf("k8s-audit requires --plan")
+	}
+
+	plan, err := NewBatchReader(defaultFetchTimeout, nil).Read(planSource)
+	if err != nil {
+		return err
+	}
+
+	source := NewKubernetesSource(kubeconfig, context, c.run)
+	ranges, serviceFound, err := source.Ranges()
+	if err != nil {
+		return err
+	}
+
+	findings := NewRangeChecker().Check(ranges, nil, plan)
+	if !serviceFound {
+		findings = append(findings, Finding{
+			Severity: SeverityNotice,
+			Rule:     RuleServiceCIDRUnknown,
+			Message:  "service CIDR not found; the cluster has no ServiceCIDR objects and its API server is not visible",
+		})
+	}
+
+	var content string
+	switch format {
+	case "", FormatText:
+		content = c.formatter.FormatDiscoveredRanges(fmt.Sprintf("Kubernetes Audit (%s)", source.clusterLabel()), ranges, findings)
+	default:
+		if content, err = c.formatter.RenderFindings(format, findings); err != nil {
+			return err
+		}
+	}
+
+	return c.writeAuditContent(content, outputFile, emitTargets, findings)
+}
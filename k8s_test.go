@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testK8sNodes = `{"items": [
+  {"metadata": {"name": "worker-1"}, "spec": {"podCIDR": "10.244.0.0/24", "podCIDRs": ["10.244.0.0/24", "fd00:10:244::/64"]}},
+  {"metadata": {"name": "worker-2"}, "spec": {"podCIDR": "10.244.1.0/24"}},
+  {"metadata": {"name": "pending"}, "spec": {}}
+]}`
+
+const testK8sAPIServer = `{"items": [
+  {"metadata": {"name": "kube-apiserver-cp-1"}, "spec": {"containers": [{"command": ["kube-apiserver", "--secure-port=6443", "--service-cluster-ip-range=10.96.0.0/12"]}]}}
+]}`
+
+const testMetalLBPools = `{"items": [
+  {"metadata": {"name": "lan", "namespace": "metallb-system"}, "spec": {"addresses": ["192.168.10.0/24", "192.168.20.0-192.168.20.127"]}}
+]}`
+
+// fakeKubectl answers kubectl get commands from a map of resource to JSON output;
+// resources that are not listed fail like a missing CRD would
+func fakeKubectl(outputs map[string]string) commandRunner {
+	return func(name string, args ...string) ([]byte, error) {
+		if name == "kubectl" && len(args) > 1 {
+			if output, ok := outputs[args[1]]; ok {
+				return []byte(output), nil
+			}
+		}
+		return nil, fmt.Errorf("error: the server doesn't have a resource type %q", strings.Join(args, " "))
+	}
+}
+
+func TestKubernetesSource_Ranges(t *testing.T) {
+	var commands []string
+	fake := fakeKubectl(map[string]string{
+		"nodes":                     testK8sNodes,
+		"pods":                      testK8sAPIServer,
+		"ipaddresspools.metallb.io": testMetalLBPools,
+	})
+	run := func(name string, args ...string) ([]byte, error) {
+		commands = append(commands, strings.Join(append([]string{name}, args...), " "))
+		return fake(name, args...)
+	}
+
+	ranges, serviceFound, err := NewKubernetesSource("/tmp/kubeconfig", "prod", run).Ranges()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !serviceFound {
+		t.Errorf("expected service range to be found through the API server flags")
+	}
+
+	expected := []DiscoveredRange{
+		{Kind: KindPodCIDR, Source: "node/worker-1", CIDR: "10.244.0.0/24"},
+		{Kind: KindPodCIDR, Source: "node/worker-1", CIDR: "fd00:10:244::/64"},
+		{Kind: KindPodCIDR, Source: "node/worker-2", CIDR: "10.244.1.0/24"},
+		{Kind: KindServiceCIDR, Source: "pod/kube-apiserver-cp-1", CIDR: "10.96.0.0/12"},
+		{Kind: KindLoadBalancer, Source: "ipaddresspool/metallb-system/lan", CIDR: "192.168.10.0/24"},
+		{Kind: KindLoadBalancer, Source: "ipaddresspool/metallb-system/lan", CIDR: "192.168.20.0/25"},
+	}
+	if len(ranges) != len(expected) {
+		t.Fatalf("expected %d ranges, got %d: %+v", len(expected), len(ranges), ranges)
+	}
+	for i, exp := range expected {
+		if ranges[i] != exp {
+			t.Errorf("range %d: expected %+v, got %+v", i, exp, ranges[i])
+		}
+	}
+
+	if commands[0] != "kubectl get nodes --output json --kubeconfig /tmp/kubeconfig --context prod" {
+		t.Errorf("unexpected kubectl command: %s", commands[0])
+	}
+}
+
+func TestKubernetesSource_ServiceCIDRObjects(t *testing.T) {
+	run := fakeKubectl(map[string]string{
+		"nodes":        `{"items": []}`,
+		"servicecidrs": `{"items": [{"metadata": {"name": "kubernetes"}, "spec": {"cidrs": ["10.96.0.0/16", "fd00:10:96::/112"]}}]}`,
+	})
+
+	ranges, serviceFound, err := NewKubernetesSource("", "", run).Ranges()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !serviceFound || len(ranges) != 2 || ranges[0].Source != "servicecidr/kubernetes" || ranges[1].CIDR != "fd00:10:96::/112" {
+		t.Errorf("unexpected ranges: %+v", ranges)
+	}
+}
+
+func TestCLIHandler_K8sAudit(t *testing.T) {
+	dir := t.TempDir()
+	plan := filepath.Join(dir, "plan.txt")
+	if err := os.WriteFile(plan, []byte("10.0.0.0/16\n192.168.20.64/26\n"), 0644); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+
+	handler := NewCLIHandler()
+	handler.run = fakeKubectl(map[string]string{
+		"nodes":                     testK8sNodes,
+		"pods":                      testK8sAPIServer,
+		"ipaddresspools.metallb.io": testMetalLBPools,
+	})
+
+	output := filepath.Join(dir, "audit.txt")
+	err := handler.Run([]string{"cidr-calc", "k8s-audit", "--context", "prod", "--plan", plan, "-o", output})
+	if err == nil || err.Error() != "audit found 1 error" {
+		t.Fatalf("expected one error, got %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	for _, exp := range []string{
+		"Kubernetes Audit (prod):",
+		"service        10.96.0.0/12       pod/kube-apiserver-cp-1",
+		"loadbalancer range 192.168.20.0/25 (ipaddresspool/metallb-system/lan) overlaps planned 192.168.20.64/26 [plan-conflict]",
+	} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected report to contain %q, got:\n%s", exp, content)
+		}
+	}
+
+	// Managed clusters hide the API server, which only earns a notice
+	handler.run = fakeKubectl(map[string]string{"nodes": testK8sNodes})
+	annotations := filepath.Join(dir, "annotations.txt")
+	if err := handler.Run([]string{"cidr-calc", "k8s-audit", "--plan", plan, "--format", "gh-annotations", "-o", annotations}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, err := os.ReadFile(annotations); err != nil || !strings.Contains(string(content), "::notice") || !strings.Contains(string(content), "service-cidr-unknown") {
+		t.Errorf("expected a service CIDR notice, got %q (%v)", content, err)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "k8s-audit"}); err == nil || err.Error() != "k8s-audit requires --plan" {
+		t.Errorf("expected missing plan error, got %v", err)
+	}
+
+	handler.run = fakeKubectl(nil)
+	if err := handler.Run([]string{"cidr-calc", "k8s-audit", "--plan", plan}); err == nil {
+		t.Errorf("expected error when kubectl fails")
+	}
+}
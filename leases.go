@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// iscLeaseTimeLayout is the "starts 4 2024/01/11 10:00:00" timestamp used by ISC dhcpd
+const iscLeaseTimeLayout = "2006/01/02 15:04:05"
+
+// keaStateDefault is the Kea memfile state of a lease that is in use
+const keaStateDefault = "0"
+
+// Lease is a single DHCP lease. A zero Ends means the lease never expires.
+type Lease struct {
+	IP     net.IP
+	Starts time.Time
+	Ends   time.Time
+	Active bool
+}
+
+// ActiveAt reports whether the lease holds its address at the given time
+func (l Lease) ActiveAt(now time.Time) bool {
+	if !l.Active || (!l.Starts.IsZero() && l.Starts.After(now)) {
+		return false
+	}
+	return l.Ends.IsZero() || l.Ends.After(now)
+}
+
+// ReadLeases loads an ISC dhcpd.leases file or a Kea memfile lease CSV
+func ReadLeases(path string) ([]Lease, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lease file %s: %v", path, err)
+	}
+
+	return ParseLeases(data)
+}
+
+// ParseLeases detects the lease file format and parses it. Both formats append
+// a new record on every renewal, so only the last record of each address is kept.
+func ParseLeases(data []byte) ([]Lease, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("address,")) {
+		return parseKeaLeases(data)
+	}
+	return parseISCLeases(data)
+}
+
+// parseISCLeases parses the lease declarations of an ISC dhcpd.leases file
+func parseISCLeases(data []byte) ([]Lease, error) {
+	var leases []Lease
+	index := make(map[string]int)
+
+	var current *Lease
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(scanner.Text()), ";"))
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if current == nil {
+			if fields[0] == "lease" && len(fields) == 3 && fields[2] == "{" {
+				ip := net.ParseIP(fields[1]).To4()
+				if ip == nil {
+					return nil, fmt.Errorf("line %d: invalid lease address %q", line, fields[1])
+				}
+				current = &Lease{IP: ip}
+			}
+			continue
+		}
+
+		var err error
+		switch {
+		case fields[0] == "}":
+			if i, ok := index[current.IP.String()]; ok {
+				leases[i] = *current
+			} else {
+				index[current.IP.String()] = len(leases)
+				leases = append(leases, *current)
+			}
+			current = nil
+		case fields[0] == "starts":
+			current.Starts, err = parseISCLeaseTime(fields[1:])
+		case fields[0] == "ends":
+			current.Ends, err = parseISCLeaseTime(fields[1:])
+		case len(fields) == 3 && fields[0] == "binding" && fields[1] == "state":
+			current.Active = fields[2] == "active"
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read leases: %v", err)
+	}
+	if current != nil {
+		return nil, fmt.Errorf("unterminated lease for %s", current.IP)
+	}
+
+	return leases, nil
+}
+
+// parseISCLeaseTime parses "W YYYY/MM/DD HH:MM:SS" (UTC), "epoch N" or "never"
+func parseISCLeaseTime(fields []string) (time.Time, error) {
+	switch {
+	case len(fields) == 1 && fields[0] == "never":
+		return time.Time{}, nil
+	case len(fields) == 2 && fields[0] == "epoch":
+		seconds, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid lease time: %v", err)
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	case len(fields) == 3:
+		t, err := time.Parse(iscLeaseTimeLayout, fields[1]+" "+fields[2])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid lease time: %v", err)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid lease time: %s", strings.Join(fields, " "))
+}
+
+// parseKeaLeases parses a Kea DHCPv4 memfile (kea-leases4.csv)
+func parseKeaLeases(data []byte) ([]Lease, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Kea lease header: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range []string{"address", "valid_lifetime", "expire", "state"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("Kea lease file has no %s column", name)
+		}
+	}
+
+	var leases []Lease
+	index := make(map[string]int)
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if len(record) < len(header) {
+			return nil, fmt.Errorf("line %d: expected %d fields, got %d", line, len(header), len(record))
+		}
+
+		ip := net.ParseIP(record[columns["address"]]).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("line %d: invalid lease address %q", line, record[columns["address"]])
+		}
+		lifetime, err := strconv.ParseInt(record[columns["valid_lifetime"]], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid valid_lifetime: %v", line, err)
+		}
+		expire, err := strconv.ParseInt(record[columns["expire"]], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expire: %v", line, err)
+		}
+
+		lease := Lease{
+			IP:     ip,
+			Starts: time.Unix(expire-lifetime, 0).UTC(),
+			Ends:   time.Unix(expire, 0).UTC(),
+			Active: record[columns["state"]] == keaStateDefault,
+		}
+
+		if i, ok := index[ip.String()]; ok {
+			leases[i] = lease
+		} else {
+			index[ip.String()] = len(leases)
+			leases = append(leases, lease)
+		}
+	}
+
+	return leases, nil
+}
+
+// countActiveLeases returns how many usable addresses of the network hold an active lease
+func countActiveLeases(leases []Lease, network *NetworkInfo, now time.Time) uint64 {
+	var used uint64
+	for _, lease := range leases {
+		if lease.ActiveAt(now) && isUsableAddress(network, lease.IP) {
+			used++
+		}
+	}
+	return used
+}
+
+// isUsableAddress reports whether ip is a usable host address of the IPv4 network
+func isUsableAddress(network *NetworkInfo, ip net.IP) bool {
+	if !network.Network.Contains(ip) {
+		return false
+	}
+	value := ipv4ToUint32(ip)
+	return value >= ipv4ToUint32(network.FirstUsableIP) && value <= ipv4ToUint32(network.LastUsableIP)
+}
+
+// runLeases implements the leases subcommand
+func (c *CLIHandler) runLeases(args []string) error {
+	flagSet := flag.NewFlagSet("leases", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var cidr, historyFile, format, outputFile string
+	var emit stringList
+	var locale Locale
+	thresholds := UtilizationThresholds{}
+	flagSet.StringVar(&cidr, "network", "", "DHCP scope to analyse, in CIDR notation")
+	flagSet.StringVar(&historyFile, "history", "", "CSV file that records each run and feeds the forecast")
+	flagSet.Float64Var(&thresholds.WarnPercent, "warn", defaultWarnPercent, "Warn at this utilization percentage")
+	flagSet.Float64Var(&thresholds.CriticalPercent, "critical", defaultCriticalPercent, "Fail at this utilization percentage")
+	flagSet.IntVar(&thresholds.HorizonDays, "horizon", defaultHorizonDays, "Warn when the forecast runs out within this many days")
+	flagSet.StringVar(&format, "format", FormatText, "Findings format: text, gh-annotations")
+	flagSet.Var(localeFlag{&locale}, "locale", "Write dates and numbers for this locale, e.g. de-DE or ja-JP")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	flagSet.Var(&emit, "emit", emitFlagUsage)
+
+	// Accept the lease file anywhere among the flags
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	emitTargets, err := parseEmitTargets(emit)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		return fmt.Errorf("leases requires a single lease file, got %d", len(positional))
+	}
+	if cidr == "" {
+		return fmt.Errorf("leases requires --network")
+	}
+
+	network, err := c.calculator.ParseCIDR(cidr)
+	if err != nil {
+		return fmt.Errorf("failed to parse CIDR: %v", err)
+	}
+	if network.IsIPv6() {
+		return fmt.Errorf("leases supports IPv4 scopes only")
+	}
+
+	leaseFile := positional[0]
+	leases, err := ReadLeases(leaseFile)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	utilization := NewUtilization(network, countActiveLeases(leases, network, now))
+
+	var samples []UtilizationSample
+	if historyFile != "" {
+		if samples, err = ReadUtilizationHistory(historyFile, network.CIDR()); err != nil {
+			return err
+		}
+		sample := UtilizationSample{Time: now, CIDR: network.CIDR(), Used: utilization.Used, Total: utilization.Total}
+		if err := AppendUtilizationSample(historyFile, sample); err != nil {
+			return err
+		}
+		samples = append(samples, sample)
+	}
+
+	forecast, hasForecast := ForecastExhaustion(samples, utilization.Total)
+	findings := UtilizationFindings(utilization, forecast, hasForecast, thresholds, leaseFile, now, locale)
+
+	var content string
+	switch format {
+	case "", FormatText:
+		c.formatter.Locale = locale
+		content = c.formatter.FormatUtilization(fmt.Sprintf("DHCP Lease Utilization (%s)", leaseFile), utilization, forecast, hasForecast, findings, now)
+	default:
+		if content, err = c.formatter.RenderFindings(format, findings); err != nil {
+			return err
+		}
+	}
+
+	return c.writeAuditContent(content, outputFile, emitTargets, findings)
+}
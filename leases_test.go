@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testISCLeases = `# The format of this file is documented in the dhcpd.leases(5) manual page.
+authoring-byte-order little-endian;
+
+lease 10.1.0.10 {
+  starts 4 2024/01/11 10:00:00;
+  ends 4 2024/01/11 22:00:00;
+  binding state free;
+}
+lease 10.1.0.10 {
+  starts 4 2024/01/11 22:00:00;
+  ends never;
+  binding state active;
+  hardware ethernet 00:11:22:33:44:55;
+  client-hostname "printer";
+}
+lease 10.1.0.11 {
+  starts epoch 1704967200;
+  ends 5 2099/01/01 00:00:00;
+  binding state active;
+}
+lease 10.1.0.12 {
+  starts 4 2024/01/11 10:00:00;
+  ends 4 2024/01/11 22:00:00;
+  binding state active;
+}
+lease 10.9.0.1 {
+  ends never;
+  binding state active;
+}
+`
+
+const testKeaLeases = `address,hwaddr,client_id,valid_lifetime,expire,subnet_id,fqdn_fwd,fqdn_rev,hostname,state,user_context,pool_id
+10.1.0.20,00:11:22:33:44:66,,3600,1704967200,1,0,0,host-a,0,,0
+10.1.0.20,00:11:22:33:44:66,,3600,4102444800,1,0,0,host-a,0,,0
+10.1.0.21,00:11:22:33:44:77,,3600,4102444800,1,0,0,host-b,1,,0
+10.1.0.22,00:11:22:33:44:88,,3600,4102444800,1,0,0,host-c,0,,0
+`
+
+func TestParseLeases_ISC(t *testing.T) {
+	leases, err := ParseLeases([]byte(testISCLeases))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(leases) != 4 {
+		t.Fatalf("expected 4 leases, got %d: %+v", len(leases), leases)
+	}
+
+	// The later record for 10.1.0.10 replaces the earlier one
+	if !leases[0].Active || !leases[0].Ends.IsZero() {
+		t.Errorf("expected the last record for 10.1.0.10 to win, got %+v", leases[0])
+	}
+	if leases[1].Starts != time.Unix(1704967200, 0).UTC() {
+		t.Errorf("expected epoch start time, got %v", leases[1].Starts)
+	}
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	network, err := NewCIDRCalculator().ParseCIDR("10.1.0.0/22")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+	// 10.1.0.12 has expired and 10.9.0.1 is outside the scope
+	if used := countActiveLeases(leases, network, now); used != 2 {
+		t.Errorf("expected 2 active leases, got %d", used)
+	}
+
+	errorCases := map[string]string{
+		"invalid address": "lease 10.1.0.999 {\n}\n",
+		"invalid time":    "lease 10.1.0.1 {\n  ends 4 yesterday;\n}\n",
+		"unterminated":    "lease 10.1.0.1 {\n  binding state active;\n",
+	}
+	for name, data := range errorCases {
+		if _, err := ParseLeases([]byte(data)); err == nil {
+			t.Errorf("%s: expected error but got none", name)
+		}
+	}
+}
+
+func TestParseLeases_Kea(t *testing.T) {
+	leases, err := ParseLeases([]byte(testKeaLeases))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(leases) != 3 {
+		t.Fatalf("expected 3 leases, got %d: %+v", len(leases), leases)
+	}
+	if leases[0].Ends != time.Unix(4102444800, 0).UTC() || leases[0].Starts != time.Unix(4102444800-3600, 0).UTC() {
+		t.Errorf("expected the renewed lease for 10.1.0.20, got %+v", leases[0])
+	}
+	if leases[1].Active {
+		t.Errorf("expected declined lease to be inactive")
+	}
+
+	if _, err := ParseLeases([]byte("address,hwaddr\n10.1.0.1,aa\n")); err == nil {
+		t.Errorf("expected error for missing Kea columns")
+	}
+}
+
+func TestCLIHandler_Leases(t *testing.T) {
+	dir := t.TempDir()
+	leaseFile := filepath.Join(dir, "dhcpd.leases")
+	if err := os.WriteFile(leaseFile, []byte(testISCLeases), 0644); err != nil {
+		t.Fatalf("failed to write leases: %v", err)
+	}
+
+	handler := NewCLIHandler()
+	output := filepath.Join(dir, "report.txt")
+	history := filepath.Join(dir, "history.csv")
+
+	if err := handler.Run([]string{"cidr-calc", "leases", leaseFile, "--network", "10.1.0.0/22", "--history", history, "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	for _, exp := range []string{"DHCP Lease Utilization (" + leaseFile + "):", "Used:           2 of 1022 (0.2%)", "not enough history (1 samples)", "No problems found"} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected report to contain %q, got:\n%s", exp, content)
+		}
+	}
+
+	if samples, err := ReadUtilizationHistory(history, "10.1.0.0/22"); err != nil || len(samples) != 1 {
+		t.Errorf("expected one recorded sample, got %v (%v)", samples, err)
+	}
+
+	// --locale writes the numbers of the report for the reader
+	if err := handler.Run([]string{"cidr-calc", "leases", leaseFile, "--network", "10.1.0.0/22", "--locale", "de-DE", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); !strings.Contains(string(content), "Used:           2 of 1.022 (0,2%)") {
+		t.Errorf("expected German number formats, got:\n%s", content)
+	}
+
+	// Two active leases fill a /31 scope
+	err = handler.Run([]string{"cidr-calc", "leases", "--network", "10.1.0.10/31", leaseFile, "-o", output})
+	if err == nil || err.Error() != "audit found 1 error" {
+		t.Errorf("expected a critical utilization error, got %v", err)
+	}
+
+	errorCases := [][]string{
+		{"cidr-calc", "leases", leaseFile},
+		{"cidr-calc", "leases", "--network", "10.1.0.0/22"},
+		{"cidr-calc", "leases", "--network", "2001:db8::/64", leaseFile},
+		{"cidr-calc", "leases", "--network", "10.1.0.0/22", filepath.Join(dir, "missing.leases")},
+	}
+	for _, args := range errorCases {
+		if err := handler.Run(args); err == nil {
+			t.Errorf("%v: expected error but got none", args)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+)
+
+// deadlineCheckInterval is how many subnets or hosts are generated between
+// two looks at the clock
+const deadlineCheckInterval = 4096
+
+// Limits bound the work of a calculator, so that a mistyped request fails
+// fast with a clear message instead of grinding the machine
+type Limits struct {
+	Subnets int           // most subnets a split may list; 0 keeps maxSplitSubnets
+	Timeout time.Duration // how long one calculation may take; 0 for no limit
+}
+
+// SetLimits applies the limits to the calculations that follow. The timeout
+// counts from the start of each calculation, so a long-running server gives
+// every request the same time.
+func (c *CIDRCalculator) SetLimits(limits Limits) {
+	c.limits = limits
+}
+
+// startDeadline returns when a calculation starting now has to give up, or
+// the zero time when the calculator has no timeout
+func (c *CIDRCalculator) startDeadline() time.Time {
+	if c.limits.Timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(c.limits.Timeout)
+}
+
+// splitLimit returns the most subnets a split may list
+func (c *CIDRCalculator) splitLimit() int {
+	if c.limits.Subnets > 0 {
+		return c.limits.Subnets
+	}
+	return maxSplitSubnets
+}
+
+// checkDeadline fails once a calculation has run past the deadline it got
+// from startDeadline. Long loops call it every deadlineCheckInterval steps.
+func (c *CIDRCalculator) checkDeadline(deadline time.Time) error {
+	if deadline.IsZero() || time.Now().Before(deadline) {
+		return nil
+	}
+	return fmt.Errorf("gave up after the %s --timeout; use a longer prefix or --max-subnets to ask for less", c.limits.Timeout)
+}
+
+// parseGuards reads --timeout and --max-subnets given before a subcommand
+// name, e.g. "cidr-calc --timeout 30s inventory 10.0.0.0/8 --split 30", sets
+// them as the limits of the calculator and returns the arguments without them.
+// Arguments that do not name a subcommand after the guards are returned as
+// they are, for the calculator flags to parse.
+func (c *CLIHandler) parseGuards(args []string) ([]string, error) {
+	flagSet := flag.NewFlagSet("cidr-calc", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+
+	var limits Limits
+	flagSet.DurationVar(&limits.Timeout, "timeout", 0, "")
+	flagSet.IntVar(&limits.Subnets, "max-subnets", 0, "")
+	if len(args) < 2 || flagSet.Parse(args[1:]) != nil || flagSet.NFlag() == 0 || flagSet.NArg() == 0 {
+		return args, nil
+	}
+	if _, ok := c.subcommands()[flagSet.Arg(0)]; !ok {
+		return args, nil
+	}
+	if err := limits.validate(); err != nil {
+		return nil, err
+	}
+
+	c.calculator.SetLimits(limits)
+	return append([]string{args[0]}, flagSet.Args()...), nil
+}
+
+// validate rejects negative limits
+func (l Limits) validate() error {
+	if l.Subnets < 0 {
+		return fmt.Errorf("--max-subnets must be at least 1, got %d", l.Subnets)
+	}
+	if l.Timeout < 0 {
+		return fmt.Errorf("--timeout must be positive, got %s", l.Timeout)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCIDRCalculator_SetLimits(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	network := mustParseCIDR(t, calculator, "10.0.0.0/16")
+
+	calculator.SetLimits(Limits{Subnets: 16})
+	if _, err := calculator.SplitSubnets(network, 20); err != nil {
+		t.Errorf("expected 16 subnets to be allowed, got %v", err)
+	}
+	if _, err := calculator.SplitNetworks(network, 21); err == nil || err.Error() != "splitting 10.0.0.0/16 into /21 subnets would list 32 subnets (limit 16)" {
+		t.Errorf("expected the subnet limit, got %v", err)
+	}
+
+	calculator.SetLimits(Limits{Timeout: time.Nanosecond})
+	err := calculator.EachSubnet(network, 24, 256, func(SubnetInfo) error { return nil })
+	if err == nil || !strings.HasPrefix(err.Error(), "gave up after the 1ns --timeout") {
+		t.Errorf("expected the timeout to stop the split, got %v", err)
+	}
+
+	// The timeout counts from the start of each split, not from SetLimits
+	calculator.SetLimits(Limits{Timeout: 50 * time.Millisecond})
+	time.Sleep(60 * time.Millisecond)
+	if err := calculator.EachSubnet(network, 24, 256, func(SubnetInfo) error { return nil }); err != nil {
+		t.Errorf("expected a later split to get the whole timeout, got %v", err)
+	}
+
+	// Without limits the defaults apply
+	calculator.SetLimits(Limits{})
+	if _, err := calculator.SplitSubnets(mustParseCIDR(t, calculator, "10.0.0.0/8"), 25); err == nil || !strings.Contains(err.Error(), "(limit 65536)") {
+		t.Errorf("expected the default subnet limit, got %v", err)
+	}
+}
+
+func TestCLIHandler_Guards(t *testing.T) {
+	tests := []struct {
+		args     string
+		expected string
+	}{
+		{"--max-subnets 100 inventory 10.0.0.0/16 --split 24", "splitting 10.0.0.0/16 into /24 subnets would list 256 subnets (limit 100)"},
+		{"--timeout 1ns --max-subnets 100000 acl 10.0.0.0/8 --split 24", "gave up after the 1ns --timeout"},
+		{"--timeout 1ns --split 24 10.0.0.0/8", "gave up after the 1ns --timeout"},
+		{"--timeout 1ns 10.0.0.0/8", "gave up after the 1ns --timeout"},
+		{"--timeout -1s acl 10.0.0.0/8", "--timeout must be positive, got -1s"},
+		{"--max-subnets -1 acl 10.0.0.0/8", "--max-subnets must be at least 1, got -1"},
+	}
+	for _, tt := range tests {
+		handler := NewCLIHandler()
+		handler.stderr = io.Discard
+		err := handler.Run(append([]string{"cidr-calc"}, strings.Fields(tt.args)...))
+		if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+			t.Errorf("%s: expected %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+
+	// Guards before a subcommand are not passed on to it
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	output := t.TempDir() + "/acl.txt"
+	if err := handler.Run([]string{"cidr-calc", "--timeout", "1m", "acl", "10.0.0.0/24", "-o", output}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
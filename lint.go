@@ -0,0 +1,183 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+)
+
+// Lint rule names
+const (
+	RuleInvalidCIDR = "invalid-cidr"
+	RuleHostBitsSet = "host-bits-set"
+	RuleDuplicate   = "duplicate"
+	RuleOverlap     = "overlap"
+	RuleProvider    = "provider-prefix"
+)
+
+// PlanLinter checks plan entries for common addressing mistakes
+type PlanLinter struct {
+	calculator *CIDRCalculator
+	provider   *ProviderRules  // when set, entries must be subnets the provider can create
+	reserved   *ReservedPolicy // when set, entries must not overlap its ranges
+}
+
+// NewPlanLinter creates a new plan linter
+func NewPlanLinter() *PlanLinter {
+	return &PlanLinter{calculator: NewCIDRCalculator()}
+}
+
+// Lint returns findings for invalid, non-canonical, duplicate and overlapping entries
+func (l *PlanLinter) Lint(entries []BatchEntry) []Finding {
+	var findings []Finding
+	var networks []planNetwork
+	seen := make(map[string]BatchEntry)
+
+	for _, entry := range entries {
+		info, err := l.calculator.ParseCIDR(entry.CIDR)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Rule:     RuleInvalidCIDR,
+				Message:  err.Error(),
+				File:     entry.Source,
+				Line:     entry.Line,
+				CIDR:     entry.CIDR,
+			})
+			continue
+		}
+
+		if ip, _, err := net.ParseCIDR(entry.CIDR); err == nil && !ip.Equal(info.NetworkID) {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Rule:     RuleHostBitsSet,
+				Message:  fmt.Sprintf("%s has host bits set; the network is %s", entry.CIDR, info.CIDR()),
+				File:     entry.Source,
+				Line:     entry.Line,
+				CIDR:     entry.CIDR,
+			})
+		}
+
+		if l.provider != nil {
+			if err := l.provider.CheckPrefix(info.IsIPv6(), info.PrefixLength); err != nil {
+				findings = append(findings, Finding{
+					Severity: SeverityError,
+					Rule:     RuleProvider,
+					Message:  fmt.Sprintf("%s: %v", info.CIDR(), err),
+					File:     entry.Source,
+					Line:     entry.Line,
+					CIDR:     entry.CIDR,
+				})
+			}
+		}
+
+		if violation, ok := l.reserved.Violation(info); ok {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Rule:     RuleReserved,
+				Message:  fmt.Sprintf("%s %s", info.CIDR(), violation),
+				File:     entry.Source,
+				Line:     entry.Line,
+				CIDR:     entry.CIDR,
+			})
+		}
+
+		if first, ok := seen[info.CIDR()]; ok {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Rule:     RuleDuplicate,
+				Message:  fmt.Sprintf("%s is already listed at %s:%d", info.CIDR(), first.Source, first.Line),
+				File:     entry.Source,
+				Line:     entry.Line,
+				CIDR:     entry.CIDR,
+			})
+			continue
+		}
+		seen[info.CIDR()] = entry
+
+		for _, other := range networks {
+			if info.Overlaps(other.info) {
+				findings = append(findings, Finding{
+					Severity: SeverityError,
+					Rule:     RuleOverlap,
+					Message:  fmt.Sprintf("%s overlaps %s at %s:%d", info.CIDR(), other.info.CIDR(), other.entry.Source, other.entry.Line),
+					File:     entry.Source,
+					Line:     entry.Line,
+					CIDR:     entry.CIDR,
+				})
+			}
+		}
+
+		networks = append(networks, planNetwork{entry: entry, info: info})
+	}
+
+	return findings
+}
+
+// runLint implements the lint subcommand
+func (c *CLIHandler) runLint(args []string) error {
+	flagSet := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var format, outputFile string
+	var emit stringList
+	linter := NewPlanLinter()
+	flagSet.StringVar(&format, "format", FormatText, "Findings format: text, gh-annotations")
+	flagSet.Var(&policyFlag{target: &linter.reserved}, "reserved", "File of reserved CIDRs that entries must not overlap")
+	flagSet.Var(providerFlag{&linter.provider}, "validate-for", "Report entries this cloud provider cannot create: aws, azure, gcp, oci")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	flagSet.Var(&emit, "emit", emitFlagUsage)
+
+	// Accept plan files anywhere among the flags
+	sources, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	emitTargets, err := parseEmitTargets(emit)
+	if err != nil {
+		return err
+	}
+
+	if len(sources) == 0 {
+		return fmt.Errorf("lint requires at least one plan file")
+	}
+
+	reader := NewBatchReader(defaultFetchTimeout, nil)
+
+	var entries []BatchEntry
+	for _, source := range sources {
+		sourceEntries, err := reader.Read(source)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, sourceEntries...)
+	}
+
+	findings := linter.Lint(entries)
+
+	if err := c.emitFindings(emitTargets, findings); err != nil {
+		return err
+	}
+
+	content, err := c.formatter.RenderFindings(format, findings)
+	if err != nil {
+		return err
+	}
+
+	// Clean plans produce no GitHub annotations at all
+	if content == "" {
+		return nil
+	}
+
+	if err := c.writeOutput(content, outputFile); err != nil {
+		return err
+	}
+
+	if errors := countFindings(findings, SeverityError); errors > 0 {
+		return fmt.Errorf("lint found %s", plural(errors, "error"))
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanLinter_Lint(t *testing.T) {
+	entries := []BatchEntry{
+		{Source: "prod.txt", Line: 1, CIDR: "10.0.0.0/16"},
+		{Source: "prod.txt", Line: 2, CIDR: "10.0.5.0/24"},
+		{Source: "prod.txt", Line: 3, CIDR: "10.0.0.0/16"},
+		{Source: "prod.txt", Line: 4, CIDR: "192.168.1.7/24"},
+		{Source: "prod.txt", Line: 5, CIDR: "bogus"},
+		{Source: "prod.txt", Line: 6, CIDR: "172.16.0.0/12"},
+	}
+
+	findings := NewPlanLinter().Lint(entries)
+
+	expected := []struct {
+		rule     string
+		severity FindingSeverity
+		line     int
+	}{
+		{RuleOverlap, SeverityError, 2},
+		{RuleDuplicate, SeverityWarning, 3},
+		{RuleHostBitsSet, SeverityWarning, 4},
+		{RuleInvalidCIDR, SeverityError, 5},
+	}
+
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for i, exp := range expected {
+		got := findings[i]
+		if got.Rule != exp.rule || got.Severity != exp.severity || got.Line != exp.line || got.File != "prod.txt" {
+			t.Errorf("finding %d: expected %s/%s at line %d, got %+v", i, exp.severity, exp.rule, exp.line, got)
+		}
+	}
+}
+
+func TestPlanLinter_Provider(t *testing.T) {
+	entries := []BatchEntry{
+		{Source: "aws.txt", Line: 1, CIDR: "10.0.0.0/16"},
+		{Source: "aws.txt", Line: 2, CIDR: "10.1.0.0/29"},
+		{Source: "aws.txt", Line: 3, CIDR: "10.16.0.0/12"},
+		{Source: "aws.txt", Line: 4, CIDR: "2001:db8::/64"},
+	}
+
+	linter := NewPlanLinter()
+	rules, err := LookupProviderRules("aws")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	linter.provider = rules
+
+	findings := linter.Lint(entries)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d: %+v", len(findings), findings)
+	}
+	for i, line := range []int{2, 3} {
+		if findings[i].Rule != RuleProvider || findings[i].Severity != SeverityError || findings[i].Line != line {
+			t.Errorf("finding %d: expected a provider-prefix error at line %d, got %+v", i, line, findings[i])
+		}
+	}
+}
+
+func TestPlanLinter_Reserved(t *testing.T) {
+	linter := NewPlanLinter()
+	policy, err := NewReservedPolicy([]BatchEntry{{Source: "reserved.txt", Line: 1, CIDR: "10.255.0.0/16", Comment: "future expansion"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	linter.reserved = policy
+
+	findings := linter.Lint([]BatchEntry{
+		{Source: "prod.txt", Line: 1, CIDR: "10.1.0.0/16"},
+		{Source: "prod.txt", Line: 2, CIDR: "10.255.8.0/24"},
+	})
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d: %+v", len(findings), findings)
+	}
+	expected := "10.255.8.0/24 overlaps reserved 10.255.0.0/16 at reserved.txt:1 (future expansion)"
+	if findings[0].Rule != RuleReserved || findings[0].Line != 2 || findings[0].Message != expected {
+		t.Errorf("unexpected finding: %+v", findings[0])
+	}
+}
+
+func TestCLIHandler_Lint(t *testing.T) {
+	dir := t.TempDir()
+	clean := filepath.Join(dir, "clean.txt")
+	broken := filepath.Join(dir, "broken.txt")
+	if err := os.WriteFile(clean, []byte("10.0.0.0/16\n10.1.0.0/16\n"), 0644); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+	if err := os.WriteFile(broken, []byte("10.0.0.0/16\n10.0.5.0/24\n"), 0644); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+
+	output := filepath.Join(dir, "clean.out")
+	if err := handler.Run([]string{"cidr-calc", "lint", "-o", output, clean}); err != nil {
+		t.Fatalf("unexpected error for clean plan: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !strings.Contains(string(content), "No problems found") {
+		t.Errorf("expected clean report, got:\n%s", content)
+	}
+
+	output = filepath.Join(dir, "broken.out")
+	if err := handler.Run([]string{"cidr-calc", "lint", "--format", "gh-annotations", "-o", output, broken}); err == nil {
+		t.Errorf("expected error for overlapping plan")
+	}
+	content, err = os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	expected := "::error file=" + escapeGitHubProperty(broken) + ",line=2,title=overlap::"
+	if !strings.HasPrefix(string(content), expected) {
+		t.Errorf("expected annotation %q, got:\n%s", expected, content)
+	}
+
+	// Findings in a YAML document point at the line of the range
+	document := filepath.Join(dir, "plan.yaml")
+	content = []byte("version: 2\npools: []\nallocations:\n  - cidr: 10.0.0.0/16\n    comment: core\n  - cidr: 10.0.5.0/24\n")
+	if err := os.WriteFile(document, content, 0644); err != nil {
+		t.Fatalf("failed to write document: %v", err)
+	}
+	if err := handler.Run([]string{"cidr-calc", "lint", "--format", "gh-annotations", "-o", output, document}); err == nil {
+		t.Errorf("expected error for overlapping document")
+	}
+	content, _ = os.ReadFile(output)
+	expected = "::error file=" + escapeGitHubProperty(document) + ",line=6,title=overlap::"
+	if !strings.HasPrefix(string(content), expected) {
+		t.Errorf("expected annotation %q, got:\n%s", expected, content)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "lint"}); err == nil {
+		t.Errorf("expected error when no plan files are given")
+	}
+	if err := handler.Run([]string{"cidr-calc", "lint", "--format", "html", clean}); err == nil {
+		t.Errorf("expected error for unsupported format")
+	}
+
+	// Flags may follow the plan files
+	output = filepath.Join(dir, "after.out")
+	handler.Run([]string{"cidr-calc", "lint", broken, "--format", "gh-annotations", "-o", output})
+	if content, _ := os.ReadFile(output); !strings.HasPrefix(string(content), "::error file=") {
+		t.Errorf("expected annotations with the flags after the plan file, got:\n%s", content)
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxLoadTestRPS bounds --rps to rates a single client can pace
+const maxLoadTestRPS = 10000
+
+// loadTestPercentiles are the latency percentiles of a load test report
+var loadTestPercentiles = []float64{50, 90, 99}
+
+// LoadTestOptions are the settings of a load test against a serve instance
+type LoadTestOptions struct {
+	URL         string        // base URL of the server, e.g. http://host:8080
+	RPS         int           // requests started per second
+	Duration    time.Duration // how long to keep sending
+	Concurrency int           // requests in flight at most; further ticks are skipped
+	Timeout     time.Duration // timeout of one request
+	Seed        int64         // seed of the requested networks
+}
+
+// loadTestRequest is one API call of the mix sent by a load test
+type loadTestRequest struct {
+	endpoint string
+	method   string
+	path     string
+	body     string
+}
+
+// LoadTestEndpoint is the outcome of the requests to one endpoint
+type LoadTestEndpoint struct {
+	Name        string
+	Requests    int
+	Failures    int
+	Percentiles []time.Duration // in the order of loadTestPercentiles
+	Max         time.Duration
+
+	latencies []time.Duration
+}
+
+// LoadTestReport is the outcome of a load test
+type LoadTestReport struct {
+	URL       string
+	Target    int // requested rate
+	Elapsed   time.Duration
+	Sent      int
+	Skipped   int // ticks without a free request slot
+	Endpoints []*LoadTestEndpoint
+	Statuses  map[string]int // "200", "400", ... and "error" for transport failures
+}
+
+// loadTestRequests returns the request mix for one network: a report, a
+// report split into parts, and a POST /v1/split into parts
+func loadTestRequests(random *rand.Rand, cidr string) []loadTestRequest {
+	network := "/v1/networks/" + url.PathEscape(cidr)
+	return []loadTestRequest{
+		{endpoint: "GET /v1/networks", method: http.MethodGet, path: network},
+		{endpoint: "GET /v1/networks?parts", method: http.MethodGet, path: fmt.Sprintf("%s?parts=%d", network, 2+random.Intn(3))},
+		{endpoint: "POST /v1/split", method: http.MethodPost, path: "/v1/split", body: fmt.Sprintf(`{"cidr": %q, "parts": %d}`, cidr, 2+random.Intn(7))},
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// LoadTest sends the request mix at a steady rate for the duration and
+// measures the latency of every endpoint. Requests that fail, or answer with
+// anything but 200, count as failures.
+func (c *CLIHandler) LoadTest(options LoadTestOptions) (*LoadTestReport, error) {
+	generated, err := c.calculator.GenerateFixtures(FixtureOptions{Count: 1000, Seed: options.Seed, IPv6: 10})
+	if err != nil {
+		return nil, err
+	}
+	// Keep networks that can be split into the up to 8 parts requested
+	var fixtures []string
+	for _, cidr := range generated {
+		if network, err := c.calculator.ParseCIDR(cidr); err == nil && network.MaxPrefix()-network.PrefixLength >= 3 {
+			fixtures = append(fixtures, cidr)
+		}
+	}
+	random := rand.New(rand.NewSource(options.Seed))
+	client := &http.Client{Timeout: options.Timeout}
+	base := strings.TrimRight(options.URL, "/")
+
+	report := &LoadTestReport{URL: options.URL, Target: options.RPS, Statuses: make(map[string]int)}
+	endpoints := make(map[string]*LoadTestEndpoint)
+	for _, request := range loadTestRequests(random, fixtures[0]) {
+		endpoint := &LoadTestEndpoint{Name: request.endpoint}
+		endpoints[request.endpoint] = endpoint
+		report.Endpoints = append(report.Endpoints, endpoint)
+	}
+
+	var mutex sync.Mutex
+	var inFlight sync.WaitGroup
+	slots := make(chan struct{}, options.Concurrency)
+
+	send := func(request loadTestRequest) {
+		defer func() { <-slots; inFlight.Done() }()
+
+		started := time.Now()
+		status := "error"
+		httpRequest, err := http.NewRequest(request.method, base+request.path, strings.NewReader(request.body))
+		if err == nil {
+			var response *http.Response
+			if response, err = client.Do(httpRequest); err == nil {
+				io.Copy(io.Discard, response.Body)
+				response.Body.Close()
+				status = fmt.Sprint(response.StatusCode)
+			}
+		}
+		latency := time.Since(started)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		endpoint := endpoints[request.endpoint]
+		endpoint.Requests++
+		endpoint.latencies = append(endpoint.latencies, latency)
+		if status != "200" {
+			endpoint.Failures++
+		}
+		report.Statuses[status]++
+	}
+
+	ticker := time.NewTicker(time.Second / time.Duration(options.RPS))
+	defer ticker.Stop()
+	started := time.Now()
+	deadline := time.After(options.Duration)
+	for running := true; running; {
+		select {
+		case <-deadline:
+			running = false
+		case <-ticker.C:
+			select {
+			case slots <- struct{}{}:
+			default:
+				report.Skipped++
+				continue
+			}
+			mix := loadTestRequests(random, fixtures[random.Intn(len(fixtures))])
+			inFlight.Add(1)
+			report.Sent++
+			go send(mix[random.Intn(len(mix))])
+		}
+	}
+	inFlight.Wait()
+	report.Elapsed = time.Since(started)
+
+	for _, endpoint := range report.Endpoints {
+		sort.Slice(endpoint.latencies, func(i, j int) bool { return endpoint.latencies[i] < endpoint.latencies[j] })
+		for _, p := range loadTestPercentiles {
+			endpoint.Percentiles = append(endpoint.Percentiles, percentile(endpoint.latencies, p))
+		}
+		if n := len(endpoint.latencies); n > 0 {
+			endpoint.Max = endpoint.latencies[n-1]
+		}
+	}
+	return report, nil
+}
+
+// roundLatency rounds a latency for display
+func roundLatency(latency time.Duration) time.Duration {
+	return latency.Round(10 * time.Microsecond)
+}
+
+// FormatLoadTest renders the latency percentiles of every endpoint and the
+// status codes seen
+func (f *OutputFormatter) FormatLoadTest(report *LoadTestReport) string {
+	var output strings.Builder
+
+	achieved := float64(report.Sent) / report.Elapsed.Seconds()
+	output.WriteString(fmt.Sprintf("Load Test of %s:\n", report.URL))
+	output.WriteString(fmt.Sprintf("  Requests:  %d in %s (%.1f/s of %d/s requested)\n", report.Sent, report.Elapsed.Round(time.Millisecond), achieved, report.Target))
+	if report.Skipped > 0 {
+		output.WriteString(fmt.Sprintf("  Skipped:   %d, all request slots were busy; raise --concurrency or expect saturation\n", report.Skipped))
+	}
+
+	output.WriteString(fmt.Sprintf("\n  %-24s %-9s %-9s", "Endpoint", "Requests", "Failures"))
+	for _, p := range loadTestPercentiles {
+		output.WriteString(fmt.Sprintf(" %-10s", fmt.Sprintf("p%g", p)))
+	}
+	output.WriteString(" Max\n")
+	for _, endpoint := range report.Endpoints {
+		output.WriteString(fmt.Sprintf("  %-24s %-9d %-9d", endpoint.Name, endpoint.Requests, endpoint.Failures))
+		for _, latency := range endpoint.Percentiles {
+			output.WriteString(fmt.Sprintf(" %-10s", roundLatency(latency)))
+		}
+		output.WriteString(fmt.Sprintf(" %s\n", roundLatency(endpoint.Max)))
+	}
+
+	statuses := make([]string, 0, len(report.Statuses))
+	for status := range report.Statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	output.WriteString("\nStatus Codes:\n")
+	for _, status := range statuses {
+		output.WriteString(fmt.Sprintf("  %-6s %d\n", status, report.Statuses[status]))
+	}
+
+	return output.String()
+}
+
+// jsonLoadTestReport is the load test outcome for dashboards and CI
+type jsonLoadTestReport struct {
+	URL         string                 `json:"url"`
+	TargetRPS   int                    `json:"targetRps"`
+	AchievedRPS float64                `json:"achievedRps"`
+	ElapsedMS   float64                `json:"elapsedMs"`
+	Sent        int                    `json:"sent"`
+	Skipped     int                    `json:"skipped"`
+	Endpoints   []jsonLoadTestEndpoint `json:"endpoints"`
+	Statuses    map[string]int         `json:"statuses"`
+}
+
+// jsonLoadTestEndpoint has the latencies of one endpoint in milliseconds,
+// keyed by percentile such as "p99"
+type jsonLoadTestEndpoint struct {
+	Name      string             `json:"name"`
+	Requests  int                `json:"requests"`
+	Failures  int                `json:"failures"`
+	LatencyMS map[string]float64 `json:"latencyMs"`
+}
+
+// FormatLoadTestAsJSON renders the load test outcome as JSON
+func (f *OutputFormatter) FormatLoadTestAsJSON(report *LoadTestReport) (string, error) {
+	milliseconds := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+
+	document := jsonLoadTestReport{
+		URL:         report.URL,
+		TargetRPS:   report.Target,
+		AchievedRPS: math.Round(float64(report.Sent)/report.Elapsed.Seconds()*10) / 10,
+		ElapsedMS:   milliseconds(report.Elapsed),
+		Sent:        report.Sent,
+		Skipped:     report.Skipped,
+		Statuses:    report.Statuses,
+	}
+	for _, endpoint := range report.Endpoints {
+		latencies := map[string]float64{"max": milliseconds(endpoint.Max)}
+		for i, p := range loadTestPercentiles {
+			latencies[fmt.Sprintf("p%g", p)] = milliseconds(endpoint.Percentiles[i])
+		}
+		document.Endpoints = append(document.Endpoints, jsonLoadTestEndpoint{Name: endpoint.Name, Requests: endpoint.Requests, Failures: endpoint.Failures, LatencyMS: latencies})
+	}
+
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %v", err)
+	}
+	return string(content) + "\n", nil
+}
+
+// runLoadTest implements the loadtest subcommand
+func (c *CLIHandler) runLoadTest(args []string) error {
+	flagSet := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	options := LoadTestOptions{}
+	var format, outputFile string
+	flagSet.IntVar(&options.RPS, "rps", 50, "Requests to start per second")
+	flagSet.DurationVar(&options.Duration, "duration", 30*time.Second, "How long to send requests, e.g. 2m")
+	flagSet.IntVar(&options.Concurrency, "concurrency", 100, "Requests in flight at most")
+	flagSet.DurationVar(&options.Timeout, "timeout", 10*time.Second, "Timeout of one request")
+	flagSet.Int64Var(&options.Seed, "seed", 1, "Seed of the requested networks")
+	flagSet.StringVar(&format, "format", FormatText, "Output format: text or json")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept the URL anywhere among the flags
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	if len(positional) != 1 || !isURL(positional[0]) {
+		return fmt.Errorf("loadtest takes the http(s) URL of a serve instance")
+	}
+	options.URL = positional[0]
+	if format != FormatText && format != FormatJSON {
+		return fmt.Errorf("loadtest supports %s and %s output, not %s", FormatText, FormatJSON, format)
+	}
+	if options.RPS < 1 || options.RPS > maxLoadTestRPS {
+		return fmt.Errorf("--rps must be between 1 and %d, got %d", maxLoadTestRPS, options.RPS)
+	}
+	if options.Concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1, got %d", options.Concurrency)
+	}
+	if options.Duration <= 0 || options.Timeout <= 0 {
+		return fmt.Errorf("--duration and --timeout must be positive")
+	}
+
+	c.notef("sending %d requests per second to %s for %s", options.RPS, options.URL, options.Duration)
+	report, err := c.LoadTest(options)
+	if err != nil {
+		return err
+	}
+
+	var content string
+	if format == FormatJSON {
+		if content, err = c.formatter.FormatLoadTestAsJSON(report); err != nil {
+			return err
+		}
+	} else {
+		content = c.formatter.FormatLoadTest(report)
+	}
+	if err := c.writeOutput(content, outputFile); err != nil {
+		return err
+	}
+	if report.Sent > 0 && report.Statuses["200"] == 0 {
+		return fmt.Errorf("none of the %d requests to %s succeeded", report.Sent, options.URL)
+	}
+	return nil
+}
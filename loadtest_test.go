@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	for p, expected := range map[float64]time.Duration{50: 50 * time.Millisecond, 90: 90 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond} {
+		if got := percentile(latencies, p); got != expected {
+			t.Errorf("p%g: expected %s, got %s", p, expected, got)
+		}
+	}
+	if got := percentile(nil, 99); got != 0 {
+		t.Errorf("expected 0 without latencies, got %s", got)
+	}
+}
+
+func TestCLIHandler_LoadTest(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	server := httptest.NewServer(NewAPIServer(handler, "", nil))
+	defer server.Close()
+
+	report, err := handler.LoadTest(LoadTestOptions{URL: server.URL, RPS: 200, Duration: 300 * time.Millisecond, Concurrency: 20, Timeout: time.Second, Seed: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Sent < 20 || report.Statuses["200"] != report.Sent {
+		t.Fatalf("expected every request to succeed, sent %d with statuses %v", report.Sent, report.Statuses)
+	}
+	total := 0
+	for _, endpoint := range report.Endpoints {
+		total += endpoint.Requests
+		if endpoint.Requests > 0 && (endpoint.Percentiles[0] <= 0 || endpoint.Percentiles[2] > endpoint.Max) {
+			t.Errorf("%s: unexpected latencies %v, max %s", endpoint.Name, endpoint.Percentiles, endpoint.Max)
+		}
+	}
+	if total != report.Sent {
+		t.Errorf("expected the endpoints to account for all %d requests, got %d", report.Sent, total)
+	}
+
+	text := NewOutputFormatter().FormatLoadTest(report)
+	for _, expected := range []string{"Load Test of " + server.URL, "of 200/s requested", "POST /v1/split", "p99", "Status Codes:"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("expected %q in:\n%s", expected, text)
+		}
+	}
+
+	output := filepath.Join(t.TempDir(), "load.json")
+	if err := handler.Run([]string{"cidr-calc", "loadtest", server.URL, "--rps", "100", "--duration", "100ms", "--format", "json", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	var document jsonLoadTestReport
+	if err := json.Unmarshal(content, &document); err != nil || document.TargetRPS != 100 || len(document.Endpoints) != 3 {
+		t.Errorf("expected a JSON report of three endpoints, got %v:\n%s", err, content)
+	}
+
+	// A server that is down fails every request
+	down := httptest.NewServer(nil)
+	down.Close()
+	if err := handler.Run([]string{"cidr-calc", "loadtest", down.URL, "--rps", "50", "--duration", "50ms", "-o", filepath.Join(t.TempDir(), "down.txt")}); err == nil || !strings.Contains(err.Error(), "succeeded") {
+		t.Errorf("expected an error when no request succeeds, got %v", err)
+	}
+
+	for _, args := range [][]string{{"loadtest"}, {"loadtest", "localhost:8080"}, {"loadtest", server.URL, "--rps", "0"}, {"loadtest", server.URL, "--format", "csv"}} {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
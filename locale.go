@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale is how dates and numbers are written in the reports people read.
+// The zero value keeps the plain ISO dates and ungrouped numbers used by
+// default.
+type Locale struct {
+	Name       string
+	DateLayout string // time layout of dates
+	Group      string // thousands separator; a no-break space where a space is used
+	Decimal    string // decimal separator
+}
+
+// locales are the supported locales by name
+var locales = map[string]Locale{
+	"en-US": {Name: "en-US", DateLayout: "01/02/2006", Group: ",", Decimal: "."},
+	"en-GB": {Name: "en-GB", DateLayout: "02/01/2006", Group: ",", Decimal: "."},
+	"de-DE": {Name: "de-DE", DateLayout: "02.01.2006", Group: ".", Decimal: ","},
+	"de-CH": {Name: "de-CH", DateLayout: "02.01.2006", Group: "'", Decimal: "."},
+	"fr-FR": {Name: "fr-FR", DateLayout: "02/01/2006", Group: "\u202f", Decimal: ","},
+	"es-ES": {Name: "es-ES", DateLayout: "02/01/2006", Group: ".", Decimal: ","},
+	"it-IT": {Name: "it-IT", DateLayout: "02/01/2006", Group: ".", Decimal: ","},
+	"nl-NL": {Name: "nl-NL", DateLayout: "02-01-2006", Group: ".", Decimal: ","},
+	"pl-PL": {Name: "pl-PL", DateLayout: "02.01.2006", Group: "\u00a0", Decimal: ","},
+	"sv-SE": {Name: "sv-SE", DateLayout: "2006-01-02", Group: "\u00a0", Decimal: ","},
+	"ja-JP": {Name: "ja-JP", DateLayout: "2006/01/02", Group: ",", Decimal: "."},
+	"zh-CN": {Name: "zh-CN", DateLayout: "2006/01/02", Group: ",", Decimal: "."},
+}
+
+// localeLanguages picks the locale for a bare language code
+var localeLanguages = map[string]string{
+	"en": "en-US",
+	"de": "de-DE",
+	"fr": "fr-FR",
+	"es": "es-ES",
+	"it": "it-IT",
+	"nl": "nl-NL",
+	"pl": "pl-PL",
+	"sv": "sv-SE",
+	"ja": "ja-JP",
+	"zh": "zh-CN",
+}
+
+// LookupLocale finds a locale by name. Names are matched like LANG values,
+// so de_DE.UTF-8 is de-DE and a bare language such as ja picks its main
+// country. C, POSIX and iso keep the default formats.
+func LookupLocale(name string) (Locale, error) {
+	normalized := name
+	if i := strings.IndexAny(normalized, ".@"); i >= 0 {
+		normalized = normalized[:i]
+	}
+	normalized = strings.ReplaceAll(normalized, "_", "-")
+
+	switch strings.ToLower(normalized) {
+	case "", "c", "posix", "iso":
+		return Locale{}, nil
+	}
+	language, country, _ := strings.Cut(normalized, "-")
+	language = strings.ToLower(language)
+	if country == "" {
+		if full, ok := localeLanguages[language]; ok {
+			return locales[full], nil
+		}
+	}
+	if locale, ok := locales[language+"-"+strings.ToUpper(country)]; ok {
+		return locale, nil
+	}
+	return Locale{}, fmt.Errorf("unsupported locale %s (supported: %s)", name, strings.Join(LocaleNames(), ", "))
+}
+
+// LocaleNames returns the names of the supported locales, sorted
+func LocaleNames() []string {
+	names := make([]string, 0, len(locales)+1)
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append(names, "iso")
+}
+
+// Date writes a date in the locale
+func (l Locale) Date(t time.Time) string {
+	if l.DateLayout == "" {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(l.DateLayout)
+}
+
+// Integer groups the digits of a decimal integer, which may be too large
+// for any integer type, such as the address count of an IPv6 network
+func (l Locale) Integer(digits string) string {
+	sign := ""
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[:1], digits[1:]
+	}
+	if l.Group == "" || len(digits) <= 3 {
+		return sign + digits
+	}
+
+	var grouped strings.Builder
+	grouped.WriteString(sign)
+	lead := len(digits) % 3
+	if lead == 0 {
+		lead = 3
+	}
+	grouped.WriteString(digits[:lead])
+	for i := lead; i < len(digits); i += 3 {
+		grouped.WriteString(l.Group)
+		grouped.WriteString(digits[i : i+3])
+	}
+	return grouped.String()
+}
+
+// Uint writes a count in the locale
+func (l Locale) Uint(value uint64) string {
+	return l.Integer(strconv.FormatUint(value, 10))
+}
+
+// Number writes value with the given number of decimals in the locale. With
+// signed set, positive values get a + like the %+f verb.
+func (l Locale) Number(value float64, decimals int, signed bool) string {
+	text := strconv.FormatFloat(value, 'f', decimals, 64)
+	if signed && !strings.HasPrefix(text, "-") {
+		text = "+" + text
+	}
+	whole, fraction, hasFraction := strings.Cut(text, ".")
+	whole = l.Integer(whole)
+	if !hasFraction {
+		return whole
+	}
+	decimal := l.Decimal
+	if decimal == "" {
+		decimal = "."
+	}
+	return whole + decimal + fraction
+}
+
+// localeFlag parses --locale into a locale
+type localeFlag struct {
+	target *Locale
+}
+
+// String returns the locale name
+func (f localeFlag) String() string {
+	if f.target == nil {
+		return ""
+	}
+	return f.target.Name
+}
+
+// Set looks up the locale
+func (f localeFlag) Set(value string) error {
+	locale, err := LookupLocale(value)
+	if err != nil {
+		return err
+	}
+	*f.target = locale
+	return nil
+}
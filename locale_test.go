@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLookupLocale(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		valid    bool
+	}{
+		{"de-DE", "de-DE", true},
+		{"de_DE.UTF-8", "de-DE", true},
+		{"ja", "ja-JP", true},
+		{"en_gb", "en-GB", true},
+		{"C", "", true},
+		{"iso", "", true},
+		{"xx-YY", "", false},
+		{"de-AT", "", false},
+	}
+
+	for _, tt := range tests {
+		locale, err := LookupLocale(tt.name)
+		if (err == nil) != tt.valid || locale.Name != tt.expected {
+			t.Errorf("%s: expected %q (valid %t), got %q, %v", tt.name, tt.expected, tt.valid, locale.Name, err)
+		}
+	}
+}
+
+func TestLocale_Format(t *testing.T) {
+	date := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		locale  Locale
+		date    string
+		integer string
+		number  string
+	}{
+		{Locale{}, "2024-03-02", "16777214", "+1234.5"},
+		{locales["en-US"], "03/02/2024", "16,777,214", "+1,234.5"},
+		{locales["de-DE"], "02.03.2024", "16.777.214", "+1.234,5"},
+		{locales["fr-FR"], "02/03/2024", "16\u202f777\u202f214", "+1\u202f234,5"},
+		{locales["ja-JP"], "2024/03/02", "16,777,214", "+1,234.5"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.locale.Date(date); got != tt.date {
+			t.Errorf("%s: expected date %s, got %s", tt.locale.Name, tt.date, got)
+		}
+		if got := tt.locale.Integer("16777214"); got != tt.integer {
+			t.Errorf("%s: expected integer %s, got %s", tt.locale.Name, tt.integer, got)
+		}
+		if got := tt.locale.Number(1234.54, 1, true); got != tt.number {
+			t.Errorf("%s: expected number %s, got %s", tt.locale.Name, tt.number, got)
+		}
+	}
+
+	german := locales["de-DE"]
+	if got := german.Number(-7, 1, true); got != "-7,0" {
+		t.Errorf("expected -7,0, got %s", got)
+	}
+	if got := german.Integer("340282366920938463463374607431768211456"); !strings.HasPrefix(got, "340.282.366.") {
+		t.Errorf("expected a grouped IPv6 address count, got %s", got)
+	}
+}
+
+func TestCLIHandler_Locale(t *testing.T) {
+	handler := NewCLIHandler()
+	output := filepath.Join(t.TempDir(), "report.txt")
+	if err := handler.Run([]string{"cidr-calc", "--locale", "de-DE", "--split", "12", "-o", output, "10.0.0.0/8"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	if !strings.Contains(string(content), "Total Hosts:    16.777.214") {
+		t.Errorf("expected a grouped host count, got:\n%s", content)
+	}
+
+	for _, args := range [][]string{{"--locale", "xx", "10.0.0.0/8"}, {"--locale", "de-DE", "--format", "csv", "10.0.0.0/8"}} {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
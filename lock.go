@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// stateLockTimeout is how long a change waits for another process to release
+// the lock of a state file
+var stateLockTimeout = 10 * time.Second
+
+// stateLockRetry is how often a waiting change tries to take the lock
+const stateLockRetry = 50 * time.Millisecond
+
+// errStateChanged reports that a state file changed after it was read
+var errStateChanged = fmt.Errorf("state file changed since it was read")
+
+// lockPath returns the lock kept next to a state
+func lockPath(stateFile string) string {
+	return stateFile + ".lock"
+}
+
+// lockState takes the advisory lock of a state by creating its lock object,
+// waiting up to stateLockTimeout for another holder. The lock records the
+// holder so a lock left behind by a crashed process can be identified and
+// removed by hand.
+func lockState(state ipamState) (func(), error) {
+	key := lockPath(state.key)
+	host, _ := os.Hostname()
+	deadline := time.Now().Add(stateLockTimeout)
+
+	for {
+		holder := fmt.Sprintf("pid %d on %s since %s\n", os.Getpid(), host, time.Now().UTC().Format(time.RFC3339))
+		err := state.store.Create(key, []byte(holder))
+		if err == nil {
+			return func() { state.store.Delete(key) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock state file: %v", err)
+		}
+
+		if time.Now().After(deadline) {
+			holder, _ := state.store.Get(key)
+			return nil, fmt.Errorf("%s is locked by %s; remove %s if that process is gone",
+				state, strings.TrimSpace(string(holder)), lockPath(state.location))
+		}
+		time.Sleep(stateLockRetry)
+	}
+}
+
+// stateVersion identifies the content of a state file; a missing file has the
+// version of an empty one
+func stateVersion(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockState(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "ipam.txt")
+
+	unlock, err := lockState(localState(state))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	holder, _ := os.ReadFile(lockPath(state))
+	if !strings.HasPrefix(string(holder), "pid ") {
+		t.Errorf("expected the lock file to name its holder, got %q", holder)
+	}
+
+	defer func(timeout time.Duration) { stateLockTimeout = timeout }(stateLockTimeout)
+	stateLockTimeout = 100 * time.Millisecond
+	if _, err := lockState(localState(state)); err == nil || !strings.Contains(err.Error(), "is locked by pid ") {
+		t.Errorf("expected a lock timeout, got %v", err)
+	}
+
+	unlock()
+	if _, err := os.Stat(lockPath(state)); !os.IsNotExist(err) {
+		t.Errorf("expected the lock file to be removed, got %v", err)
+	}
+	unlock, err = lockState(localState(state))
+	if err != nil {
+		t.Fatalf("unexpected error after unlock: %v", err)
+	}
+	unlock()
+}
+
+func TestCommitIPAM_Version(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "ipam.txt")
+	if err := os.WriteFile(state, []byte("10.0.0.0/24\n"), 0644); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+	add := func(content string) (string, JournalEntry, error) {
+		return addStateLine(content, "10.0.1.0/24"), JournalEntry{Time: time.Now(), Op: OpAllocate, Line: "10.0.1.0/24"}, nil
+	}
+
+	if err := commitIPAM(localState(state), stateVersion([]byte("")), add); err != errStateChanged {
+		t.Errorf("expected errStateChanged, got %v", err)
+	}
+	if content, _ := os.ReadFile(state); string(content) != "10.0.0.0/24\n" {
+		t.Errorf("expected the state to be unchanged, got %q", content)
+	}
+
+	if err := commitIPAM(localState(state), stateVersion([]byte("10.0.0.0/24\n")), add); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCLIHandler_AllocateConcurrently(t *testing.T) {
+	dir := t.TempDir()
+	state := filepath.Join(dir, "ipam.txt")
+
+	const workers = 8
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			handler := NewCLIHandler()
+			handler.stderr = io.Discard
+			output := filepath.Join(dir, "out"+string(rune('a'+i))+".txt")
+			errs[i] = handler.Run([]string{"cidr-calc", "allocate", "--pool", "10.0.0.0/20", "--prefix", "24", "--state", state, "-o", output})
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !strings.Contains(err.Error(), "kept changing while allocating"):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	entries, _, err := readIPAMState(localState(state))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != succeeded {
+		t.Errorf("expected %d allocations in the state, got %d", succeeded, len(entries))
+	}
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if seen[entry.CIDR] {
+			t.Errorf("%s was allocated twice", entry.CIDR)
+		}
+		seen[entry.CIDR] = true
+	}
+
+	journal, err := readJournal(localState(state))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(journal) != succeeded {
+		t.Errorf("expected %d journal entries, got %d", succeeded, len(journal))
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+const (
+	// maxStreamedSplitSubnets caps splits under --low-memory, where subnets
+	// are written as they are generated instead of being held in memory
+	maxStreamedSplitSubnets = 1 << 24
+
+	// lowMemoryLimit is the soft heap limit under --low-memory; the garbage
+	// collector runs more often as the heap approaches it
+	lowMemoryLimit = 64 << 20
+)
+
+// applyLowMemoryProfile limits the Go runtime to one OS thread running Go
+// code and a small soft memory limit, for hosts with little RAM
+func applyLowMemoryProfile() {
+	runtime.GOMAXPROCS(1)
+	debug.SetMemoryLimit(lowMemoryLimit)
+}
+
+// StreamsOutput reports whether the report is written subnet by subnet
+// rather than rendered in memory first
+func (c *Config) StreamsOutput() bool {
+	if !c.LowMemory || c.StrictExt {
+		return false
+	}
+	format := c.OutputFormat()
+	return format == FormatText || format == FormatCSV
+}
+
+// streamedReport is a network whose subnets are generated while writing.
+// A zero Prefix lists the subnets at the next prefix like the regular report,
+// and a non-zero Limit writes only the first Limit subnets.
+type streamedReport struct {
+	Info   *NetworkInfo
+	Prefix int
+	Limit  int
+}
+
+// counts returns how many subnets the network has at the split prefix and how
+// many of them are written
+func (r streamedReport) counts() (*big.Int, int) {
+	total := new(big.Int).Lsh(big.NewInt(1), uint(r.Prefix-r.Info.PrefixLength))
+	if r.Limit > 0 && (!total.IsInt64() || total.Int64() > int64(r.Limit)) {
+		return total, r.Limit
+	}
+	return total, int(total.Int64())
+}
+
+// nextSubnets lists the subnets at the next prefix, up to the limit
+func (r streamedReport) nextSubnets(calculator *CIDRCalculator) []SubnetInfo {
+	subnets := calculator.CalculateSubnets(r.Info)
+	if r.Limit > 0 && len(subnets) > r.Limit {
+		subnets = subnets[:r.Limit]
+	}
+	return subnets
+}
+
+// newStreamedReport resolves the split prefix of a network for streaming,
+// which allows far larger splits than the in-memory report
+func (c *CLIHandler) newStreamedReport(networkInfo *NetworkInfo, config *Config) (streamedReport, error) {
+	prefix, err := c.splitPrefix(networkInfo, config, maxStreamedSplitSubnets)
+	if err != nil {
+		return streamedReport{}, err
+	}
+
+	if err := c.checkProvider(networkInfo, prefix, config); err != nil {
+		return streamedReport{}, err
+	}
+	return streamedReport{Info: networkInfo, Prefix: prefix, Limit: config.MaxSubnets}, nil
+}
+
+// streamReports writes the text or CSV report of every network to the output
+// file or stdout, generating subnets one at a time. The output is identical to
+// the regular report.
+func (c *CLIHandler) streamReports(reports []streamedReport, config *Config) (err error) {
+	var out io.Writer = os.Stdout
+	if config.WritesToFile() {
+		file, err := c.formatter.CreateFile(config.OutputFile)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := file.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("failed to close file %s: %v", config.OutputFile, closeErr)
+			}
+		}()
+		out = file
+	}
+
+	writer := bufio.NewWriter(out)
+	if config.OutputFormat() == FormatCSV {
+		err = c.streamCSV(writer, reports)
+	} else {
+		err = c.streamText(writer, reports)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write output: %v", err)
+	}
+	return nil
+}
+
+// streamText writes the text report of every network, separated by blank lines
+func (c *CLIHandler) streamText(w *bufio.Writer, reports []streamedReport) error {
+	for i, report := range reports {
+		if i > 0 {
+			w.WriteString("\n")
+		}
+		w.WriteString(c.formatter.FormatNetworkInfo(report.Info))
+		w.WriteString("\n")
+
+		if report.Prefix == 0 {
+			w.WriteString(c.formatter.FormatSubnets(report.nextSubnets(c.calculator), report.Info.PrefixLength))
+			continue
+		}
+
+		total, count := report.counts()
+		w.WriteString("Subnet Information:\n")
+		w.WriteString(fmt.Sprintf("  Possible /%d Subnets: %s\n", report.Prefix, total))
+		if big.NewInt(int64(count)).Cmp(total) < 0 {
+			w.WriteString(fmt.Sprintf("  (Showing %d of %s subnets)\n", count, total))
+		}
+		w.WriteString("\n")
+		w.WriteString("  Subnet List:\n")
+
+		// A first pass finds the column width, as IPv6 CIDRs vary in length
+		width := 18
+		c.calculator.EachSubnet(report.Info, report.Prefix, count, func(subnet SubnetInfo) error {
+			if len(subnet.CIDR) >= width {
+				width = len(subnet.CIDR) + 1
+			}
+			return nil
+		})
+
+		err := c.calculator.EachSubnet(report.Info, report.Prefix, count, func(subnet SubnetInfo) error {
+			if _, err := fmt.Fprintf(w, "    %-*s %s\n", width, subnet.CIDR, c.formatter.formatSubnetRange(subnet)); err != nil {
+				return fmt.Errorf("failed to write output: %v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	w.WriteString(c.formatter.FormatBatchErrors(FormatText))
+	return nil
+}
+
+// streamCSV writes one CSV table covering the subnets of every network
+func (c *CLIHandler) streamCSV(w *bufio.Writer, reports []streamedReport) error {
+	tagged := false
+	for _, report := range reports {
+		tagged = tagged || len(report.Info.Tags) > 0
+	}
+
+	writer := csv.NewWriter(w)
+	header := append([]string(nil), csvHeaders...)
+	if tagged {
+		header = append(header, "Tags")
+	}
+	failed := len(c.formatter.BatchErrors) > 0
+	if failed {
+		header = append(header, "Error")
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %v", err)
+	}
+
+	for _, report := range reports {
+		if report.Prefix == 0 {
+			rows, err := c.formatter.csvRows(c.calculator, NetworkReport{Info: report.Info, Subnets: report.nextSubnets(c.calculator)}, tagged)
+			if err != nil {
+				return err
+			}
+			if failed {
+				for i := range rows {
+					rows[i] = append(rows[i], "")
+				}
+			}
+			if err := writer.WriteAll(rows); err != nil {
+				return fmt.Errorf("failed to write CSV rows: %v", err)
+			}
+			continue
+		}
+
+		network := report.Info.CIDR()
+		_, count := report.counts()
+		err := c.calculator.EachSubnet(report.Info, report.Prefix, count, func(subnet SubnetInfo) error {
+			info, err := c.calculator.ParseCIDR(subnet.CIDR)
+			if err != nil {
+				return fmt.Errorf("failed to parse subnet %s: %v", subnet.CIDR, err)
+			}
+			row := c.formatter.csvRow(network, info)
+			if tagged {
+				row = append(row, report.Info.Tags.String())
+			}
+			if failed {
+				row = append(row, "")
+			}
+			if err := writer.Write(row); err != nil {
+				return fmt.Errorf("failed to write CSV rows: %v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := writer.WriteAll(c.formatter.csvErrorRows(len(header))); err != nil {
+		return fmt.Errorf("failed to write CSV rows: %v", err)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+// restoreRuntimeLimits undoes the process-wide settings of --low-memory after a test
+func restoreRuntimeLimits(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	t.Cleanup(func() {
+		runtime.GOMAXPROCS(procs)
+		debug.SetMemoryLimit(math.MaxInt64)
+	})
+}
+
+func TestCLIHandler_LowMemoryMatchesRegularOutput(t *testing.T) {
+	restoreRuntimeLimits(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "cidrs.txt")
+	if err := os.WriteFile(input, []byte("10.0.0.0/24\n2001:db8::/60\n"), 0644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "text split", args: []string{"--split", "27", "10.0.0.0/24"}},
+		{name: "text default subnets", args: []string{"10.0.0.0/16"}},
+		{name: "text IPv6 hosts", args: []string{"--parts", "200", "2001:db8::/48"}},
+		{name: "csv split", args: []string{"--format", "csv", "--split", "28", "192.168.0.0/24"}},
+		{name: "csv batch", args: []string{"--format", "csv", "--parts", "8", "-f", input}},
+		{name: "text batch", args: []string{"--parts", "4", "-f", input}},
+	}
+
+	handler := NewCLIHandler()
+	handler.stderr = &strings.Builder{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regular := filepath.Join(dir, "regular.out")
+			streamed := filepath.Join(dir, "streamed.out")
+
+			args := append([]string{"cidr-calc", "--format", "text", "-o", regular}, tt.args...)
+			if err := handler.Run(args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			args = append([]string{"cidr-calc", "--low-memory", "--format", "text", "-o", streamed}, tt.args...)
+			if err := handler.Run(args); err != nil {
+				t.Fatalf("unexpected error with --low-memory: %v", err)
+			}
+
+			expected, _ := os.ReadFile(regular)
+			got, _ := os.ReadFile(streamed)
+			if len(expected) == 0 || string(got) != string(expected) {
+				t.Errorf("streamed output differs from regular output:\n%s\nwant:\n%s", got, expected)
+			}
+		})
+	}
+}
+
+func TestCLIHandler_LowMemory(t *testing.T) {
+	restoreRuntimeLimits(t)
+	dir := t.TempDir()
+	handler := NewCLIHandler()
+	var stderr strings.Builder
+	handler.stderr = &stderr
+
+	// Splits beyond the in-memory limit are streamed
+	output := filepath.Join(dir, "large.csv")
+	if err := handler.Run([]string{"cidr-calc", "--low-memory", "--split", "26", "-o", output, "10.0.0.0/8"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if lines := strings.Count(string(content), "\n"); lines != 262145 {
+		t.Errorf("expected a header and 262144 rows, got %d lines", lines)
+	}
+	if !strings.HasSuffix(string(content), "10.0.0.0/8,10.255.255.192/26,10.255.255.192,10.255.255.255,10.255.255.193,10.255.255.254,62,Private-Use (RFC 1918)\n") {
+		t.Errorf("unexpected last row in %s", output)
+	}
+	if runtime.GOMAXPROCS(0) != 1 {
+		t.Errorf("expected --low-memory to cap GOMAXPROCS at 1")
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "--split", "26", "-o", output, "10.0.0.0/8"}); err == nil {
+		t.Errorf("expected the in-memory split limit without --low-memory")
+	}
+
+	// --max-subnets writes only the first subnets but reports the total
+	limited := filepath.Join(dir, "limited.txt")
+	if err := handler.Run([]string{"cidr-calc", "--low-memory", "--split", "32", "--max-subnets", "2", "-o", limited, "10.0.0.0/8"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err = os.ReadFile(limited)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !strings.Contains(string(content), "  Possible /32 Subnets: 16777216\n  (Showing 2 of 16777216 subnets)\n") || strings.Contains(string(content), "10.0.0.2/32") {
+		t.Errorf("unexpected limited report:\n%s", content)
+	}
+
+	// Formats built in memory are still produced, with a note
+	if err := handler.Run([]string{"cidr-calc", "--low-memory", "-o", filepath.Join(dir, "report.md"), "10.0.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "Note: --low-memory streams text and csv output only; md output is built in memory") {
+		t.Errorf("expected a note for markdown output, got %q", stderr.String())
+	}
+
+	// Batch entries are checked before any output is written
+	input := filepath.Join(dir, "cidrs.txt")
+	if err := os.WriteFile(input, []byte("10.0.0.0/24\n10.0.0.0/33\n"), 0644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+	partial := filepath.Join(dir, "partial.txt")
+	err = handler.Run([]string{"cidr-calc", "--low-memory", "--fail-fast", "-f", input, "-o", partial})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error naming line 2, got %v", err)
+	}
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Errorf("expected no output file after a bad batch entry")
+	}
+
+	// Without --fail-fast the bad entry is listed after the streamed report
+	err = handler.Run([]string{"cidr-calc", "--low-memory", "-f", input, "-o", partial})
+	if err == nil || !strings.Contains(err.Error(), "(1 of 2 batch entries failed)") {
+		t.Errorf("expected a summary of the failed entries, got %v", err)
+	}
+	content, err = os.ReadFile(partial)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !strings.Contains(string(content), "10.0.0.0/25") || !strings.Contains(string(content), "\nBatch Errors (1 failed entry):\n  "+input+" line 2: 10.0.0.0/33: failed to parse CIDR") {
+		t.Errorf("unexpected partial report:\n%s", content)
+	}
+}
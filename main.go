@@ -1,24 +1,132 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math/big"
+	"net/http"
 	"os"
+	"runtime/debug"
 	"strings"
+	"time"
 )
 
+// stdoutFilename is the -o value that sends output to standard output
+const stdoutFilename = "-"
+
 // Config holds command-line configuration options
 type Config struct {
-	CIDR       string
-	OutputFile string
-	HTMLOutput bool
-	ShowHelp   bool
+	CIDR         string   // the first of CIDRs
+	CIDRs        []string // every CIDR argument, followed by the CIDRs of Range
+	Range        string   // an IPv4 address range "first-last" to report as CIDRs
+	InputFile    string
+	FetchTimeout time.Duration
+	Headers      http.Header
+	OutputFile   string
+	HTMLOutput   bool
+	Format       string
+	Split        int
+	Parts        int
+	Hosts        int
+	VLSM         []int
+	MaxSubnets   int  // list at most this many subnets; 0 lists every one
+	AllSubnets   bool // list every subnet, beyond the in-memory split limit
+	StrictExt    bool
+	LowMemory    bool
+	Compute      []ComputedField
+	Filter       *Expression
+	Binary       bool // add the bit breakdown to text and HTML reports
+	Numeric      bool // add decimal and hex addresses to text and HTML reports
+	Classful     bool // add the legacy class and default mask to text and HTML reports
+	Explain      bool // add the worked calculation to text reports
+	Tags         tagFilterList
+	Provider     *ProviderRules
+	Interactive  bool
+	TUI          bool
+	Reserved     *ReservedPolicy
+	ShowHelp     bool
+	OTLPEndpoint string
+	OTLPHeaders  http.Header
+
+	// Assets embedded in HTML reports, whether to minify them, their theme
+	// and the template file replacing the built-in page
+	HTMLFonts stringList
+	HTMLLogo  string
+	Minify    bool
+	Theme     string
+	Template  string
+	// Used is the file of used subnets and hosts drawn as a utilization bar
+	Used string
+
+	// Locale writes the host and subnet counts of the reports people read
+	Locale Locale
+	// ScreenReader writes text reports for screen readers
+	ScreenReader bool
+
+	// ClassRules are rule pack files classifying ranges on top of the
+	// special-purpose registries
+	ClassRules stringList
+
+	// Exclude holds the networks taken out of scanner target lists
+	Exclude cidrList
+
+	// Timeout is --timeout when it is given: besides -f fetches it bounds
+	// the calculation, as the time limit of the calculator
+	Timeout time.Duration
+
+	// Cloud plans the subnets for a provider, spread over Zones
+	Cloud *ProviderRules
+	Zones zoneList
+	// Regions lays out a --cloud gcp subnet per region, with secondary
+	// ranges of the Pods and Services prefix lengths when they are set
+	Regions  regionList
+	Pods     int
+	Services int
+
+	// FailFast stops a batch at the first failed entry instead of listing
+	// the failures in the report of the others
+	FailFast bool
+
+	// Normalize writes the plan file given as the argument, or with -f, with
+	// canonical entries and without duplicates instead of a report
+	Normalize bool
+	// Write rewrites the --normalize plan files in place
+	Write bool
+}
+
+// WritesToFile reports whether output goes to a file rather than standard output
+func (c *Config) WritesToFile() bool {
+	return c.OutputFile != "" && c.OutputFile != stdoutFilename
+}
+
+// RequestsSplit reports whether --split, --parts or --hosts asked for the
+// subnets, rather than the next prefix listing them by default
+func (c *Config) RequestsSplit() bool {
+	return c.Split != 0 || c.Parts != 0 || c.Hosts != 0
+}
+
+// OutputFormat returns the effective output format for the configuration
+func (c *Config) OutputFormat() string {
+	if c.Format != "" {
+		return c.Format
+	}
+	if c.HTMLOutput {
+		return FormatHTML
+	}
+	return FormatText
 }
 
 // CLIHandler manages command-line interface operations
 type CLIHandler struct {
 	calculator *CIDRCalculator
 	formatter  *OutputFormatter
+	stderr     io.Writer
+	run        commandRunner
+	hostRanges hostRangeLister
+	statsFile  string // usage statistics file; empty keeps none
+	configDir  string // directory of the user's configuration files
 }
 
 // NewCLIHandler creates a new CLI handler instance
@@ -26,11 +134,92 @@ func NewCLIHandler() *CLIHandler {
 	return &CLIHandler{
 		calculator: NewCIDRCalculator(),
 		formatter:  NewOutputFormatter(),
+		stderr:     os.Stderr,
+		run:        runCommand,
+		hostRanges: hostInterfaceRanges,
+		statsFile:  os.Getenv(statsFileEnv),
+		configDir:  defaultConfigDir(),
+	}
+}
+
+// warnf reports a non-fatal problem without interrupting the run
+func (c *CLIHandler) warnf(format string, args ...interface{}) {
+	fmt.Fprintf(c.stderr, "Warning: "+format+"\n", args...)
+}
+
+// notef adds context to a report on stderr, keeping the report itself unchanged
+func (c *CLIHandler) notef(format string, args ...interface{}) {
+	fmt.Fprintf(c.stderr, "Note: "+format+"\n", args...)
+}
+
+// subcommand runs a named mode with its own flags
+type subcommand func(args []string) error
+
+// subcommands returns the named modes available as the first argument
+func (c *CLIHandler) subcommands() map[string]subcommand {
+	return map[string]subcommand{
+		"git-report":    c.runGitReport,
+		"lint":          c.runLint,
+		"score":         c.runScore,
+		"tf-check":      c.runTFCheck,
+		"aws-audit":     c.runAWSAudit,
+		"azure-audit":   c.runAzureAudit,
+		"gcp-audit":     c.runGCPAudit,
+		"k8s-audit":     c.runK8sAudit,
+		"docker-audit":  c.runDockerAudit,
+		"leases":        c.runLeases,
+		"neighbors":     c.runNeighbors,
+		"snmp-discover": c.runSNMPDiscover,
+		"cloud-report":  c.runCloudReport,
+		"aggregate":     c.runAggregate,
+		"sort":          c.runSort,
+		"contains":      c.runContains,
+		"overlaps":      c.runOverlaps,
+		"allocate":      c.runAllocate,
+		"ipam":          c.runIPAM,
+		"serve":         c.runServe,
+		"loadtest":      c.runLoadTest,
+		"buddy-tree":    c.runBuddyTree,
+		"grpc":          c.runGRPC,
+		"partition":     c.runPartition,
+		"ptr-zone":      c.runPTRZone,
+		"inventory":     c.runInventory,
+		"hosts-file":    c.runHostsFile,
+		"plan":          c.runPlan,
+		"acl":           c.runACL,
+		"next":          c.runAdjacent("next", 1),
+		"prev":          c.runAdjacent("prev", -1),
+		"supernet":      c.runSupernet,
+		"gaps":          c.runGaps,
+		"tf-subnets":    c.runTerraformSubnets,
+		"verify":        c.runVerify,
+		"gen-fixtures":  c.runGenFixtures,
+		"stats":         c.runStats,
 	}
 }
 
 // Run executes the CLI application with provided arguments
 func (c *CLIHandler) Run(args []string) error {
+	if err := c.loadAliases(); err != nil {
+		return err
+	}
+
+	// Dispatch subcommands before parsing the calculator flags, applying the
+	// --timeout and --max-subnets guards given before their name
+	args, err := c.parseGuards(args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		if run, ok := c.subcommands()[args[1]]; ok {
+			err := run(args[2:])
+			if err == nil && args[1] != "stats" {
+				c.recordUsage(args[1], subcommandFormat(args[2:]))
+			}
+			return err
+		}
+	}
+
 	// Parse command-line flags
 	config, err := c.parseFlags(args)
 	if err != nil {
@@ -43,28 +232,359 @@ func (c *CLIHandler) Run(args []string) error {
 		return nil
 	}
 
+	// Rule packs classify the networks of every report, interactive ones too
+	c.formatter.ClassRules = nil
+	if len(config.ClassRules) > 0 {
+		if c.formatter.ClassRules, err = LoadClassRules(config.ClassRules); err != nil {
+			return err
+		}
+	}
+
+	// Interactive sessions have no output format to count
+	format := ""
+	if config.Interactive {
+		err = c.runInteractive(config, os.Stdin, os.Stdout)
+	} else if config.TUI {
+		err = c.runTUI(config)
+	} else {
+		format = config.OutputFormat()
+		err = c.runReports(config)
+	}
+	if err == nil {
+		c.recordUsage(calculatorCommand(config), format)
+	}
+	return err
+}
+
+// runReports writes the reports of a calculator run
+func (c *CLIHandler) runReports(config *Config) error {
+	c.calculator.SetLimits(Limits{Timeout: config.Timeout})
+	c.formatter.Binary = config.Binary
+	c.formatter.Numeric = config.Numeric
+	c.formatter.Classful = config.Classful
+	c.formatter.Explain = config.Explain
+	c.formatter.MinifyHTML = config.Minify
+	c.formatter.Theme = config.Theme
+	c.formatter.Locale = config.Locale
+	c.formatter.ScreenReader = config.ScreenReader
+	c.formatter.HTMLTemplate = nil
+	if config.Template != "" {
+		if err := c.formatter.LoadHTMLTemplate(config.Template); err != nil {
+			return err
+		}
+	}
+	if err := c.formatter.LoadHTMLAssets(config.HTMLFonts, config.HTMLLogo); err != nil {
+		return err
+	}
+	c.formatter.UsedRanges = nil
+	if config.Used != "" {
+		used, err := LoadUsedRanges(config.Used)
+		if err != nil {
+			return err
+		}
+		c.formatter.UsedRanges = used
+	}
+
+	if config.LowMemory {
+		applyLowMemoryProfile()
+		if !config.StreamsOutput() {
+			c.notef("--low-memory streams text and csv output only; %s output is built in memory", config.OutputFormat())
+		}
+	}
+
+	// Export traces and metrics of the run when an OTLP endpoint is set;
+	// a collector that is down must not fail the calculation
+	telemetry := NewTelemetry(config.OTLPEndpoint, config.OTLPHeaders)
+	err := c.runCalculator(config, telemetry)
+	if exportErr := telemetry.Export(); exportErr != nil {
+		c.warnf("%v", exportErr)
+	}
+	return err
+}
+
+// runCalculator calculates the CIDR arguments or, in batch mode, every CIDR of -f
+func (c *CLIHandler) runCalculator(config *Config, telemetry *Telemetry) error {
+	if config.Normalize {
+		return c.runNormalize(config)
+	}
+
+	// Batch mode reads the CIDR list from a file, stdin or URL
+	if config.InputFile != "" {
+		return c.runBatch(config, telemetry)
+	}
+
 	// Validate CIDR input
 	if config.CIDR == "" {
 		c.showUsage()
 		return fmt.Errorf("CIDR notation is required")
 	}
 
-	// Parse and calculate network information
-	networkInfo, err := c.calculator.ParseCIDR(config.CIDR)
-	if err != nil {
-		return fmt.Errorf("failed to parse CIDR: %v", err)
+	// A VLSM plan replaces the regular report
+	if len(config.VLSM) > 0 {
+		networkInfo, err := c.calculator.ParseCIDR(config.CIDR)
+		if err != nil {
+			return fmt.Errorf("failed to parse CIDR: %v", err)
+		}
+		return c.runVLSM(networkInfo, config)
+	}
+
+	// So does a GCP regional plan
+	if len(config.Regions) > 0 {
+		networkInfo, err := c.calculator.ParseCIDR(config.CIDR)
+		if err != nil {
+			return fmt.Errorf("failed to parse CIDR: %v", err)
+		}
+		return c.runGCPPlan(networkInfo, config)
 	}
 
-	// Calculate subnets
-	subnets := c.calculator.CalculateSubnets(networkInfo)
+	// Under --low-memory subnets are written as they are generated
+	if config.StreamsOutput() {
+		reports := make([]streamedReport, 0, len(config.CIDRs))
+		for _, cidr := range config.CIDRs {
+			networkInfo, err := c.calculator.ParseCIDR(cidr)
+			if err != nil {
+				return argumentError(config, cidr, fmt.Errorf("failed to parse CIDR: %v", err))
+			}
+			report, err := c.newStreamedReport(networkInfo, config)
+			if err != nil {
+				return argumentError(config, cidr, err)
+			}
+			reports = append(reports, report)
+		}
+		return c.streamReports(reports, config)
+	}
+
+	// Parse each network and calculate its subnets; several CIDRs give one
+	// combined report with a section per network
+	reports := make([]NetworkReport, 0, len(config.CIDRs))
+	for _, cidr := range config.CIDRs {
+		report, err := c.calculate(cidr, config, telemetry, nil)
+		if err != nil {
+			return argumentError(config, cidr, err)
+		}
+		reports = append(reports, report)
+	}
 
 	// Handle output based on configuration
-	return c.handleOutput(networkInfo, subnets, config)
+	return c.handleOutput(reports, config)
+}
+
+// argumentError names the CIDR argument an error belongs to when several were given
+func argumentError(config *Config, cidr string, err error) error {
+	if len(config.CIDRs) > 1 {
+		return fmt.Errorf("%s: %v", cidr, err)
+	}
+	return err
+}
+
+// runBatch calculates every CIDR listed in the input source and renders one combined report
+func (c *CLIHandler) runBatch(config *Config, telemetry *Telemetry) (err error) {
+	span := telemetry.StartSpan("batch", nil)
+	span.SetAttribute("batch.source", config.InputFile)
+	defer func() { span.End(err) }()
+
+	reader := NewBatchReader(config.FetchTimeout, config.Headers)
+
+	entries, err := reader.Read(config.InputFile)
+	if err != nil {
+		return err
+	}
+	span.SetAttribute("batch.cidrs", len(entries))
+
+	if len(config.Tags) > 0 {
+		total := len(entries)
+		if entries, err = filterEntries(entries, config.Tags, config.InputFile); err != nil {
+			return err
+		}
+		c.notef("--tag kept %d of %d CIDRs", len(entries), total)
+	}
+
+	if err := c.checkReserved(entries, config.Reserved); err != nil {
+		return err
+	}
+
+	if config.StreamsOutput() {
+		return c.streamBatch(entries, config)
+	}
+
+	// Failed entries are listed in the report of the others, unless
+	// --fail-fast stops at the first
+	started := time.Now()
+	reports := make([]NetworkReport, 0, len(entries))
+	var failures []BatchError
+	for _, entry := range entries {
+		report, err := c.calculate(entry.CIDR, config, telemetry, span)
+		if err != nil {
+			failure := BatchError{Source: entry.Source, Line: entry.Line, CIDR: entry.CIDR, Err: err}
+			if config.FailFast {
+				return failure
+			}
+			failures = append(failures, failure)
+			continue
+		}
+		report.Info.Tags = entry.Tags
+		reports = append(reports, report)
+	}
+	telemetry.RecordBatch(len(reports), time.Since(started))
+	span.SetAttribute("batch.failed", len(failures))
+
+	return c.reportBatch(failures, len(entries), func() error {
+		return c.handleOutput(reports, config)
+	})
+}
+
+// streamBatch checks every batch entry before streaming the combined report,
+// so the failed entries are known before any output is written
+func (c *CLIHandler) streamBatch(entries []BatchEntry, config *Config) error {
+	reports := make([]streamedReport, 0, len(entries))
+	var failures []BatchError
+	for _, entry := range entries {
+		report, err := c.streamedEntry(entry, config)
+		if err != nil {
+			failure := BatchError{Source: entry.Source, Line: entry.Line, CIDR: entry.CIDR, Err: err}
+			if config.FailFast {
+				return failure
+			}
+			failures = append(failures, failure)
+			continue
+		}
+		reports = append(reports, report)
+	}
+
+	return c.reportBatch(failures, len(entries), func() error {
+		return c.streamReports(reports, config)
+	})
+}
+
+// streamedEntry parses a batch entry and prepares its streamed report
+func (c *CLIHandler) streamedEntry(entry BatchEntry, config *Config) (streamedReport, error) {
+	networkInfo, err := c.calculator.ParseCIDR(entry.CIDR)
+	if err != nil {
+		return streamedReport{}, fmt.Errorf("failed to parse CIDR: %v", err)
+	}
+	networkInfo.Tags = entry.Tags
+	return c.newStreamedReport(networkInfo, config)
+}
+
+// calculate parses a CIDR and lists its subnets, tracing the calculation as a
+// child of parent
+func (c *CLIHandler) calculate(cidr string, config *Config, telemetry *Telemetry, parent *Span) (report NetworkReport, err error) {
+	span := telemetry.StartSpan("calculate", parent)
+	span.SetAttribute("cidr", cidr)
+	started := time.Now()
+	defer func() {
+		telemetry.RecordCalculation(time.Since(started), err)
+		span.End(err)
+	}()
+
+	networkInfo, err := c.calculator.ParseCIDR(cidr)
+	if err != nil {
+		return NetworkReport{}, fmt.Errorf("failed to parse CIDR: %v", err)
+	}
+
+	subnets, err := c.subnets(networkInfo, config)
+	if err != nil {
+		return NetworkReport{}, err
+	}
+	span.SetAttribute("subnets", len(subnets))
+
+	return NetworkReport{Info: networkInfo, Subnets: subnets, Derivation: splitDerivation(networkInfo, config)}, nil
+}
+
+// subnets lists the subnets of a network at the --split prefix, at the prefix
+// that yields --parts subnets or --hosts usable hosts per subnet, or at the
+// next prefix when none was requested. --max-subnets keeps only the first ones.
+func (c *CLIHandler) subnets(networkInfo *NetworkInfo, config *Config) ([]SubnetInfo, error) {
+	prefix, err := c.splitPrefix(networkInfo, config, maxSplitSubnets)
+	if err != nil {
+		return nil, err
+	}
+	if prefix == 0 {
+		if networkInfo.PrefixLength >= networkInfo.MaxPrefix() {
+			return []SubnetInfo{}, nil
+		}
+		prefix = networkInfo.NextPrefix()
+	}
+
+	// splitPrefix has checked the count against the limit that applies, and
+	// the next prefix lists at most 16 subnets
+	total, err := c.calculator.SubnetTotal(networkInfo, prefix)
+	if err != nil {
+		return nil, err
+	}
+	count := config.MaxSubnets
+	if count == 0 || (total.IsInt64() && total.Int64() < int64(count)) {
+		count = int(total.Int64())
+	}
+	return c.calculator.enumerateSubnets(networkInfo, prefix, count)
+}
+
+// splitPrefix returns the prefix length requested by --split, --parts or
+// --hosts, checked against the subnet limit, or 0 when none was requested.
+// --all raises the limit to that of --low-memory; --max-subnets removes it, as
+// only the first subnets are generated.
+func (c *CLIHandler) splitPrefix(networkInfo *NetworkInfo, config *Config, limit int) (int, error) {
+	var prefix int
+	var err error
+	switch {
+	case config.Split != 0:
+		prefix = config.Split
+	case config.Parts != 0:
+		prefix, err = c.calculator.PartsPrefix(networkInfo, config.Parts)
+	case config.Hosts != 0:
+		prefix, err = c.calculator.HostsPrefix(networkInfo, cloudHosts(config, networkInfo.IsIPv6()))
+		prefix = cloudPrefix(config, networkInfo.IsIPv6(), prefix)
+	default:
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	total, err := c.calculator.SubnetTotal(networkInfo, prefix)
+	if err != nil {
+		return 0, err
+	}
+	if config.MaxSubnets == 0 {
+		if config.AllSubnets && limit < maxStreamedSplitSubnets {
+			limit = maxStreamedSplitSubnets
+		}
+		if _, err := c.calculator.SplitCount(networkInfo, prefix, limit); err != nil {
+			return 0, &SplitLimitError{Err: err, All: !config.AllSubnets && total.Cmp(big.NewInt(maxStreamedSplitSubnets)) <= 0}
+		}
+	}
+
+	switch {
+	case config.Parts != 0 && total.Cmp(big.NewInt(int64(config.Parts))) > 0:
+		c.notef("%s splits into %s /%d subnets for %d parts; %s remain unused", networkInfo.CIDR(), total, prefix, config.Parts,
+			new(big.Int).Sub(total, big.NewInt(int64(config.Parts))))
+	case config.Hosts != 0 && config.Cloud == nil:
+		if usable := usableHosts(networkInfo.IsIPv6(), networkInfo.MaxPrefix()-prefix); usable > uint64(config.Hosts) {
+			c.notef("each /%d subnet has %d usable hosts for %d requested", prefix, usable, config.Hosts)
+		}
+	}
+	return prefix, nil
+}
+
+// SplitLimitError reports a split that would list more subnets than the limit.
+// Its message suggests the CLI flags that list them anyway; the API names its
+// own request fields instead.
+type SplitLimitError struct {
+	Err error
+	All bool // --all raises the limit enough to list them all
+}
+
+// Error returns the limit error with the flags that get around it
+func (e *SplitLimitError) Error() string {
+	if e.All {
+		return fmt.Sprintf("%v; use --max-subnets N to list the first N, or --all to list them all", e.Err)
+	}
+	return fmt.Sprintf("%v; use --max-subnets N to list the first N", e.Err)
 }
 
 // parseFlags parses command-line arguments and returns configuration
 func (c *CLIHandler) parseFlags(args []string) (*Config, error) {
-	config := &Config{}
+	config := &Config{Headers: http.Header{}}
 
 	// Create custom flag set to avoid conflicts with testing
 	flagSet := flag.NewFlagSet("cidr-calc", flag.ContinueOnError)
@@ -74,14 +594,59 @@ func (c *CLIHandler) parseFlags(args []string) (*Config, error) {
 	flagSet.SetOutput(&helpOutput)
 
 	// Define flags
+	flagSet.StringVar(&config.InputFile, "f", "", "Read CIDRs from file, stdin or URL")
+	flagSet.StringVar(&config.InputFile, "file", "", "Read CIDRs from file, stdin or URL")
+	flagSet.StringVar(&config.Range, "range", "", "Report the fewest CIDRs covering an IPv4 address range FIRST-LAST")
+	flagSet.DurationVar(&config.FetchTimeout, "timeout", defaultFetchTimeout, "Stop fetching -f URLs, and each calculation when given, after this long")
+	flagSet.Var(headerList(config.Headers), "header", "HTTP header for fetching -f URLs")
 	flagSet.StringVar(&config.OutputFile, "o", "", "Save output to file")
 	flagSet.StringVar(&config.OutputFile, "output", "", "Save output to file")
 	flagSet.BoolVar(&config.HTMLOutput, "h", false, "Generate HTML formatted output")
 	flagSet.BoolVar(&config.HTMLOutput, "html", false, "Generate HTML formatted output")
+	flagSet.StringVar(&config.Format, "format", "", "Output format")
+	flagSet.IntVar(&config.Split, "split", 0, "List every subnet at this prefix length")
+	flagSet.IntVar(&config.Parts, "parts", 0, "Divide the network into at least this many equal subnets")
+	flagSet.IntVar(&config.Hosts, "hosts", 0, "Split the network into the smallest subnets with this many usable hosts")
+	flagSet.Var((*hostList)(&config.VLSM), "vlsm", "Allocate a subnet for each comma separated host count")
+	flagSet.Var(&config.Exclude, "exclude", "Leave this CIDR or address out of --format targets and masscan (repeatable)")
+	flagSet.IntVar(&config.MaxSubnets, "max-subnets", 0, "List at most this many subnets; the total is still reported")
+	flagSet.BoolVar(&config.AllSubnets, "all", false, "List every subnet, lifting the 65536 subnet limit of splits")
+	flagSet.BoolVar(&config.StrictExt, "strict-ext", false, "Require output file extensions to match the format")
+	flagSet.Var((*computeList)(&config.Compute), "compute", "Add a per-subnet field: name = expression (repeatable)")
+	flagSet.Var(expressionFlag{&config.Filter}, "filter", "Only list subnets for which the expression is true")
+	flagSet.Var(providerFlag{&config.Provider}, "validate-for", "Reject subnets this cloud provider cannot create: aws, azure, gcp, oci")
+	flagSet.Var(cloudFlag{&config.Cloud}, "cloud", "Plan the subnets for this cloud provider: aws, azure, gcp")
+	flagSet.Var(&config.Zones, "azs", "Spread --cloud subnets over this many availability zones, or over the named zones")
+	flagSet.Var(&config.Regions, "regions", "Lay out a --cloud gcp subnet in each of the comma separated regions")
+	flagSet.IntVar(&config.Pods, "pods", 0, "Give every --regions subnet a secondary range for pods of this prefix length")
+	flagSet.IntVar(&config.Services, "services", 0, "Give every --regions subnet a secondary range for services of this prefix length")
+	flagSet.Var(&policyFlag{target: &config.Reserved}, "reserved", "File of reserved CIDRs that plans and allocations must not overlap")
+	flagSet.BoolVar(&config.Binary, "binary", false, "Show the address and masks in binary, split at the prefix length")
+	flagSet.BoolVar(&config.Numeric, "numeric", false, "Show the addresses as decimal and hex integers")
+	flagSet.BoolVar(&config.Classful, "classful", false, "Show the legacy address class, default mask and classful boundary")
+	flagSet.BoolVar(&config.Explain, "explain", false, "Walk through the calculation step by step: masks, AND/OR in binary, usable range and subnet count")
+	flagSet.Var(&config.ClassRules, "class-rules", "Classify ranges with a YAML or JSON rule pack file (repeatable)")
+	flagSet.Var(&config.HTMLFonts, "html-font", "Embed a font file in HTML output, as FILE or FAMILY=FILE (repeatable)")
+	flagSet.StringVar(&config.HTMLLogo, "html-logo", "", "Embed an image in the header of HTML output")
+	flagSet.BoolVar(&config.Minify, "minify", false, "Drop the indentation and blank lines of HTML output")
+	flagSet.StringVar(&config.Theme, "theme", "", "Color theme of HTML output: light, dark, high-contrast, neutral or auto")
+	flagSet.StringVar(&config.Template, "template", "", "Render HTML output with this Go html/template file")
+	flagSet.StringVar(&config.Used, "used", "", "Draw the allocated and free space of the used subnets or hosts in this file in HTML output")
+	flagSet.Var(localeFlag{&config.Locale}, "locale", "Write host and subnet counts for this locale, e.g. de-DE or ja-JP")
+	flagSet.BoolVar(&config.ScreenReader, "screen-reader", false, "Write text output as announced sections of label: value lines")
+	flagSet.Var(&config.Tags, "tag", "Only list -f entries with this key=value tag, or with the key (repeatable)")
+	flagSet.BoolVar(&config.Normalize, "normalize", false, "Write the plan file with canonical CIDRs and without duplicates")
+	flagSet.BoolVar(&config.Write, "write", false, "Rewrite the --normalize plan files in place")
+	flagSet.BoolVar(&config.FailFast, "fail-fast", false, "Stop a -f batch at the first failed entry instead of listing failures in the report")
+	flagSet.BoolVar(&config.LowMemory, "low-memory", false, "Stream text and CSV output and limit memory use")
+	flagSet.StringVar(&config.OTLPEndpoint, "otlp-endpoint", os.Getenv(otlpEndpointEnv), "Export traces and metrics to this OTLP/HTTP endpoint")
+	flagSet.BoolVar(&config.Interactive, "i", false, "Keep a prompt open for successive commands")
+	flagSet.BoolVar(&config.Interactive, "interactive", false, "Keep a prompt open for successive commands")
+	flagSet.BoolVar(&config.TUI, "tui", false, "Browse the network in a full-screen terminal UI")
 	flagSet.BoolVar(&config.ShowHelp, "help", false, "Show help message")
 
-	// Parse flags
-	err := flagSet.Parse(args[1:]) // Skip program name
+	// Parse flags, accepting CIDR arguments anywhere among them
+	cidrs, err := parseInterspersed(flagSet, args[1:]) // Skip program name
 	if err != nil {
 		if err == flag.ErrHelp {
 			config.ShowHelp = true
@@ -89,11 +654,125 @@ func (c *CLIHandler) parseFlags(args []string) (*Config, error) {
 		}
 		return nil, fmt.Errorf("flag parsing error: %v", err)
 	}
+	config.CIDRs = cidrs
+	// A --timeout given on the command line also bounds the calculation, and
+	// a --split, --parts or --hosts given must ask for a split, as their zero
+	// value means none was requested
+	flagSet.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "timeout":
+			config.Timeout = config.FetchTimeout
+		case "split":
+			if config.Split < 1 {
+				err = fmt.Errorf("--split must be a prefix length of at least 1, got %d", config.Split)
+			}
+		case "parts":
+			if config.Parts < 2 {
+				err = fmt.Errorf("--parts must be at least 2, got %d", config.Parts)
+			}
+		case "hosts":
+			if config.Hosts < 1 {
+				err = fmt.Errorf("--hosts must be at least 1, got %d", config.Hosts)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := (Limits{Timeout: config.Timeout}).validate(); err != nil {
+		return nil, err
+	}
+	if config.Range != "" {
+		if config.InputFile != "" {
+			return nil, fmt.Errorf("--range cannot be combined with -f")
+		}
+		if !strings.Contains(config.Range, "-") {
+			return nil, fmt.Errorf("--range must be FIRST-LAST, e.g. 10.0.0.5-10.0.3.200, got %s", config.Range)
+		}
+		cidrs, err := rangeToCIDRs(config.Range)
+		if err != nil {
+			return nil, err
+		}
+		c.notef("%s is covered by %d CIDRs: %s", config.Range, len(cidrs), strings.Join(cidrs, ", "))
+		config.CIDRs = append(config.CIDRs, cidrs...)
+	}
+	if len(config.CIDRs) > 0 {
+		config.CIDR = config.CIDRs[0]
+	}
+
+	if config.InputFile != "" && config.CIDR != "" {
+		return nil, fmt.Errorf("a CIDR argument cannot be combined with -f")
+	}
+	if config.Interactive && (config.InputFile != "" || config.OutputFile != "") {
+		return nil, fmt.Errorf("--interactive cannot be combined with -f or -o")
+	}
+	if config.TUI {
+		if config.Interactive || config.InputFile != "" || config.OutputFile != "" {
+			return nil, fmt.Errorf("--tui cannot be combined with --interactive, -f or -o")
+		}
+		if len(config.CIDRs) != 1 {
+			return nil, fmt.Errorf("--tui takes a single CIDR, got %d", len(config.CIDRs))
+		}
+	}
+	if len(config.Tags) > 0 && config.InputFile == "" {
+		return nil, fmt.Errorf("--tag filters the entries of a -f plan file")
+	}
+	if config.FailFast && config.InputFile == "" {
+		return nil, fmt.Errorf("--fail-fast applies to -f batch runs")
+	}
+	if config.Write && !config.Normalize {
+		return nil, fmt.Errorf("--write rewrites the --normalize plan files in place")
+	}
+	if config.Normalize {
+		if count := len(config.CIDRs); count+len(config.InputFile) == 0 || (count > 1 && !config.Write) {
+			return nil, fmt.Errorf("--normalize takes one plan file, got %d; use --write to normalize several in place", count)
+		}
+		if config.Write && config.OutputFile != "" {
+			return nil, fmt.Errorf("--write rewrites the plan files in place and takes no -o")
+		}
+		if config.Format != "" || config.HTMLOutput {
+			return nil, fmt.Errorf("--normalize writes a plan file, not a report, and takes no --format")
+		}
+	}
+
+	splitModes := 0
+	for _, value := range []int{config.Split, config.Parts, config.Hosts, len(config.VLSM)} {
+		if value != 0 {
+			splitModes++
+		}
+	}
+	if splitModes > 1 {
+		return nil, fmt.Errorf("only one of --split, --parts, --hosts and --vlsm can be used")
+	}
+
+	if config.MaxSubnets < 0 {
+		return nil, fmt.Errorf("--max-subnets must be at least 1, got %d", config.MaxSubnets)
+	}
+	if config.MaxSubnets > 0 && config.AllSubnets {
+		return nil, fmt.Errorf("--max-subnets cannot be combined with --all")
+	}
+	if (config.MaxSubnets > 0 || config.AllSubnets) && len(config.VLSM) > 0 {
+		return nil, fmt.Errorf("--max-subnets and --all do not apply to --vlsm")
+	}
+
+	if len(config.VLSM) > 0 && config.InputFile != "" {
+		return nil, fmt.Errorf("--vlsm cannot be combined with -f")
+	}
+	if len(config.VLSM) > 0 && len(config.CIDRs) > 1 {
+		return nil, fmt.Errorf("--vlsm takes a single CIDR, got %d", len(config.CIDRs))
+	}
+
+	if config.OTLPEndpoint != "" {
+		headers, err := ParseOTLPHeaders(os.Getenv(otlpHeadersEnv))
+		if err != nil {
+			return nil, err
+		}
+		config.OTLPHeaders = headers
+	}
 
-	// Get remaining arguments (should be CIDR)
-	remaining := flagSet.Args()
-	if len(remaining) > 0 {
-		config.CIDR = remaining[0]
+	// Infer the format from the output file extension unless one was requested
+	if config.WritesToFile() && config.Format == "" && !config.HTMLOutput && !config.StrictExt {
+		config.Format = FormatForExtension(config.OutputFile)
 	}
 
 	// Validate flag combinations
@@ -104,49 +783,168 @@ func (c *CLIHandler) parseFlags(args []string) (*Config, error) {
 	return config, nil
 }
 
+// parseInterspersed parses the flags in args, accepting positional arguments
+// anywhere among them, and returns the positional arguments in order
+func parseInterspersed(flagSet *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flagSet.Parse(args); err != nil {
+			return nil, err
+		}
+		if flagSet.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flagSet.Arg(0))
+		args = flagSet.Args()[1:]
+	}
+}
+
 // validateConfig validates the configuration for consistency
 func (c *CLIHandler) validateConfig(config *Config) error {
-	// If HTML output is requested, ensure output file has proper extension
-	if config.HTMLOutput && config.OutputFile != "" {
-		if !strings.HasSuffix(strings.ToLower(config.OutputFile), ".html") &&
-			!strings.HasSuffix(strings.ToLower(config.OutputFile), ".htm") {
-			return fmt.Errorf("HTML output requires .html or .htm file extension")
+	// Ensure the requested format exists and agrees with --html
+	if config.Format != "" {
+		if !IsSupportedFormat(config.Format) && !isTargetFormat(config.Format) {
+			return fmt.Errorf("unsupported output format: %s (supported: %s, %s, %s)", config.Format, strings.Join(SupportedFormats, ", "), FormatTargets, FormatMasscan)
+		}
+		if config.HTMLOutput && config.Format != FormatHTML {
+			return fmt.Errorf("--html cannot be combined with --format %s", config.Format)
+		}
+	}
+
+	if len(config.Exclude) > 0 && !isTargetFormat(config.OutputFormat()) {
+		return fmt.Errorf("--exclude applies to --format %s and %s", FormatTargets, FormatMasscan)
+	}
+
+	// Computed fields need a column, which only text and CSV have
+	if len(config.Compute) > 0 {
+		if format := config.OutputFormat(); format != FormatText && format != FormatCSV {
+			return fmt.Errorf("--compute supports text and csv output, not %s", format)
+		}
+	}
+	// The extra report sections have no place in the other formats
+	for _, section := range []struct {
+		flag string
+		set  bool
+	}{{"--binary", config.Binary}, {"--numeric", config.Numeric}, {"--classful", config.Classful}} {
+		if format := config.OutputFormat(); section.set && format != FormatText && format != FormatHTML {
+			return fmt.Errorf("%s supports text and html output, not %s", section.flag, format)
+		}
+	}
+	for _, option := range []struct {
+		flag string
+		set  bool
+	}{{"--html-font", len(config.HTMLFonts) > 0}, {"--html-logo", config.HTMLLogo != ""}, {"--minify", config.Minify}, {"--theme", config.Theme != ""}, {"--template", config.Template != ""}, {"--used", config.Used != ""}} {
+		if format := config.OutputFormat(); option.set && format != FormatHTML {
+			return fmt.Errorf("%s applies to html output, not %s", option.flag, format)
 		}
 	}
+	if config.Theme != "" && !IsHTMLTheme(config.Theme) {
+		return fmt.Errorf("unknown --theme %s (available: %s)", config.Theme, strings.Join(HTMLThemes(), ", "))
+	}
+	// Machine-readable formats keep plain numbers
+	if format := config.OutputFormat(); config.Locale.Name != "" && (format == FormatCSV || format == FormatJSON || format == FormatXML) {
+		return fmt.Errorf("--locale applies to the formats people read, not %s", format)
+	}
+	if format := config.OutputFormat(); config.ScreenReader && format != FormatText {
+		return fmt.Errorf("--screen-reader supports text output, not %s", format)
+	}
+	if format := config.OutputFormat(); config.Explain && format != FormatText {
+		return fmt.Errorf("--explain supports text output, not %s", format)
+	}
+	if config.ScreenReader && config.LowMemory {
+		return fmt.Errorf("--screen-reader cannot be combined with --low-memory")
+	}
+	if config.Explain && config.LowMemory {
+		return fmt.Errorf("--explain cannot be combined with --low-memory")
+	}
+	if (len(config.Compute) > 0 || config.Filter != nil) && config.LowMemory {
+		return fmt.Errorf("--compute and --filter cannot be combined with --low-memory")
+	}
+	if err := validateCloud(config); err != nil {
+		return err
+	}
 
-	// If output file is specified without HTML flag, ensure it's not HTML extension
-	if !config.HTMLOutput && config.OutputFile != "" {
+	if !config.WritesToFile() {
+		return nil
+	}
+
+	// Under --strict-ext keep the original hard extension requirements
+	if config.StrictExt {
 		ext := strings.ToLower(config.OutputFile)
-		if strings.HasSuffix(ext, ".html") || strings.HasSuffix(ext, ".htm") {
+		isHTMLExt := strings.HasSuffix(ext, ".html") || strings.HasSuffix(ext, ".htm")
+
+		if config.OutputFormat() == FormatHTML && !isHTMLExt {
+			return fmt.Errorf("HTML output requires .html or .htm file extension")
+		}
+		if config.OutputFormat() != FormatHTML && isHTMLExt {
 			return fmt.Errorf("HTML file extension requires --html flag")
 		}
 	}
 
+	// An explicit format wins over the extension; mention the mismatch
+	// Target lists are plain text
+	if implied := FormatForExtension(config.OutputFile); implied != "" && implied != config.OutputFormat() &&
+		!(implied == FormatText && isTargetFormat(config.OutputFormat())) {
+		if config.StrictExt {
+			return fmt.Errorf("%s output does not match %s file extension of %s", config.OutputFormat(), implied, config.OutputFile)
+		}
+		c.warnf("writing %s output to %s, whose extension suggests %s", config.OutputFormat(), config.OutputFile, implied)
+	}
+
 	return nil
 }
 
 // handleOutput processes and outputs the results based on configuration
-func (c *CLIHandler) handleOutput(networkInfo *NetworkInfo, subnets []SubnetInfo, config *Config) error {
-	if config.OutputFile != "" {
-		// Save to file
-		if config.HTMLOutput {
-			return c.formatter.SaveHTMLToFile(networkInfo, subnets, config.OutputFile)
-		} else {
-			return c.formatter.SaveTextToFile(networkInfo, subnets, config.OutputFile)
+func (c *CLIHandler) handleOutput(reports []NetworkReport, config *Config) error {
+	if err := c.applyScripts(reports, config); err != nil {
+		return err
+	}
+
+	if config.Provider != nil {
+		for _, report := range reports {
+			prefix, err := c.reportPrefix(report)
+			if err != nil {
+				return err
+			}
+			if err := c.checkProvider(report.Info, prefix, config); err != nil {
+				return err
+			}
 		}
-	} else {
-		// Output to console
-		if config.HTMLOutput {
-			// HTML output to console
-			htmlContent := c.formatter.FormatAsHTML(networkInfo, subnets)
-			fmt.Print(htmlContent)
-		} else {
-			// Text output to console
-			textContent := c.formatter.FormatComplete(networkInfo, subnets)
-			fmt.Print(textContent)
+	}
+	if err := c.applyCloud(reports, config); err != nil {
+		return err
+	}
+
+	format := config.OutputFormat()
+	if isTargetFormat(format) {
+		return c.writeTargets(reports, config)
+	}
+
+	if config.WritesToFile() && config.StrictExt && len(reports) == 1 && len(c.formatter.BatchErrors) == 0 {
+		// Text and HTML files keep their extension-checked save paths
+		switch format {
+		case FormatHTML:
+			return c.formatter.SaveHTMLToFile(reports[0].Info, reports[0].Subnets, config.OutputFile)
+		case FormatText:
+			return c.formatter.SaveTextToFile(reports[0].Info, reports[0].Subnets, config.OutputFile)
 		}
 	}
 
+	content, err := c.formatter.RenderReports(format, reports)
+	if err != nil {
+		return err
+	}
+
+	return c.writeOutput(content, config.OutputFile)
+}
+
+// writeOutput saves content to the output file, or prints it when no file (or "-") is given
+func (c *CLIHandler) writeOutput(content string, outputFile string) error {
+	if outputFile != "" && outputFile != stdoutFilename {
+		return c.formatter.SaveToFile(content, outputFile)
+	}
+
+	fmt.Print(content)
 	return nil
 }
 
@@ -155,20 +953,242 @@ func (c *CLIHandler) showUsage() {
 	fmt.Print(`CIDR Calculator - Network Subnet Information Tool
 
 Usage:
-  cidr-calc [OPTIONS] <CIDR>
+  cidr-calc [OPTIONS] <CIDR>...
+  cidr-calc [OPTIONS] -f <FILE|URL|->
+  cidr-calc <COMMAND> [COMMAND OPTIONS]
 
 Arguments:
-  CIDR                 Network in CIDR notation (e.g., 192.168.1.0/24)
+  CIDR                 IPv4 or IPv6 network in CIDR notation (e.g., 192.168.1.0/24,
+                       2001:db8::/48); several CIDRs give one combined report
+
+Commands:
+  git-report --ref BASE..HEAD [PATH...]
+                       Report CIDR changes in plan files between two git refs
+  lint [--format text|gh-annotations] [--validate-for PROVIDER] [--reserved FILE] FILE...
+                       Check plan files for invalid, duplicate and overlapping CIDRs
+  score PLAN [--pools FILE] [--headroom PCT] [--min N] [--format text|json]
+                       Score a plan against alignment, summarizability, growth
+                       headroom, RFC 1918 usage and fragmentation, with suggestions
+  tf-check STATE --plan FILE [--reserved FILE]
+                       Check Terraform state CIDRs against the approved plan
+  tf-subnets BASE NEWBITS...|CIDR... [--format text|json]
+                       Print the subnets of Terraform's cidrsubnets(BASE, NEWBITS...),
+                       or the cidrsubnet newbits and netnum of each CIDR
+  aws-audit --region REGION [--profile NAME]
+                       Audit deployed VPC and subnet CIDRs via the AWS CLI
+  azure-audit [--subscription ID] [--resource-group RG] [--location LOC]
+                       Audit deployed VNet and subnet CIDRs via the Azure CLI
+  gcp-audit --project PROJECT [--region REGION]
+                       Audit deployed VPC subnetworks via the gcloud CLI
+  cloud-report [--aws REGION[:PROFILE]] [--azure SUB] [--gcp PROJECT]
+                       Consolidated address space and overlaps across clouds
+  k8s-audit --plan FILE [--kubeconfig PATH] [--context NAME]
+                       Check cluster pod, service and LoadBalancer ranges against a plan
+  docker-audit [--plan FILE] [--no-host]
+                       Check Docker network subnets against host networks and a plan
+  leases FILE --network CIDR [--history FILE] [--locale LOCALE]
+                       DHCP scope utilization and exhaustion forecast from a lease file
+  neighbors FILE|- --network CIDR [--history FILE] [--heatmap] [--locale LOCALE]
+                       Utilization of a subnet from "arp -an" or "ip neigh" output
+  snmp-discover [--community NAME] [--version 1|2c] ROUTER...
+                       List interface and routed networks of routers as a plan file
+  aggregate [-f SOURCE [--tag K=V]] [CIDR...] | aggregate --write -f FILE
+                       Collapse CIDRs into the fewest covering supernets
+  sort [--write] FILE...
+                       Order the entries of plan files by address, keeping comments
+  acl [-f SOURCE [--tag K=V]] [CIDR...] [--split N] [--action permit|deny]
+      [--protocol P] [--destination CIDR|any] [--port N] [--name NAME]
+                       Write Cisco ACL entries matching the networks with
+                       wildcard masks
+  next|prev CIDR [--step N] [--format FORMAT]
+                       Print the adjacent network of the same size, or the one
+                       N networks away
+  gaps PARENT [-f SOURCE [--tag K=V]] [USED...] [--sort address|size]
+                       List the unallocated blocks of PARENT with their sizes
+  supernet CIDR... [--prefix N] [--format FORMAT]
+                       Print the enclosing network of each CIDR at prefix N
+                       (default: one bit shorter)
+  verify [--iterations N] [--seed N]
+                       Check calculator invariants against N random networks
+                       and report any counterexample
+  gen-fixtures [--count N] [--seed N] [--ipv6 PERCENT] [--plan]
+                       Generate realistic random CIDRs or plan lines for load
+                       tests; the same seed gives the same output
+  contains|overlaps [-q] CIDR_A CIDR_B
+                       Print how two CIDRs relate; exit 0 if the check holds,
+                       1 if not, 2 on errors
+  allocate --pool CIDR --prefix N|--hosts N [--strategy S] [--state FILE] [--name NAME]
+                       Pick a free block of the pool with first-fit, best-fit or
+                       buddy and append it to the state file
+  ipam allocate|release|undo|log --state FILE [...]
+                       Allocate or release blocks with a journal of every
+                       change; undo reverts the latest one. --state is a file
+                       or an s3://, gs://, etcd:// or consul:// URL
+  ipam import SHEET [--state STATE] [--column-cidr COL] [--column-name COL]
+                       Import allocations from an .xlsx, CSV or TSV spreadsheet
+                       into the state, or print them as a plan file
+  ipam quota --state STATE --quotas FILE [--by TAG] [--warn PCT] [--format text|json]
+                       Report each tenant's addresses against its quota and
+                       warn about tenants near or over it
+  ipam expiring --state STATE [--within 30d] [--format text|json]
+                       List allocations recorded with allocate --expires that
+                       expired or expire within the period
+  plan export [PLAN...] [--pools FILE] [--reserved FILE] [--quotas FILE] [--format yaml|json]
+                       Combine plan, pool, reserved and quota files into one
+                       YAML or JSON plan document
+  plan import DOCUMENT [--section allocations|pools|reserved|quotas]
+                       Write a section of a plan document as a plan file
+  plan build REQUEST [--format text|plan|FORMAT]
+                       Place the named subnets of a YAML or JSON request in its
+                       network and report the addressing plan
+  plan migrate DOCUMENT... [--dry-run-migrate]
+                       Upgrade plan documents written by older releases to the
+                       current version, keeping each original as a .bak file
+  plan relabel DOCUMENT... --match GLOB|--tag KEY=VALUE [--set KEY=VALUE] [--unset KEY] [--rename OLD=NEW]
+                       Bulk edit the labels of the matching ranges of plan
+                       documents in place; --dry-run lists the changes
+  buddy-tree --pool CIDR [--state FILE] [--reserved FILE] [--format text|html|svg]
+                       Draw the buddy tree of a pool to show fragmentation and
+                       blocks that would merge if released
+  partition POOL --tenants N|--names A,B [--min /P] [--headroom PCT] [--growth N]
+                       Divide a pool among tenants into equal, aligned
+                       super-blocks; --format json writes an assignment map
+  ptr-zone CIDR --domain DOMAIN [--template T] [--ns NS] [--dir DIR]
+                       Write in-addr.arpa zone skeletons with a PTR record for
+                       every usable address
+  inventory CIDR...|-f FILE [--template T] [--domain D] [--format ini|yaml]
+                       Write an Ansible inventory with a host per usable address,
+                       grouped per subnet
+  hosts-file CIDR...|-f FILE [--template T] [--domain D]
+                       Write /etc/hosts entries for every usable address
+  serve [--listen ADDR] [--otlp-endpoint URL] [--state STATE --quotas FILE]
+                       Serve GET /v1/networks/{cidr} and POST /v1/split as JSON
+                       (default address :8080), and GET /v1/quotas with --quotas
+  loadtest URL [--rps N] [--duration D] [--concurrency N] [--format text|json]
+                       Exercise a serve instance at a steady rate and report
+                       latency percentiles per endpoint
+  grpc --tls-cert FILE --tls-key FILE [--listen ADDR]
+                       Serve the gRPC API of proto/cidrcalc.proto over TLS
+                       (default address :9090)
+  stats [--format text|json] [--reset]
+                       Show how often each command and format was used, from
+                       the local file named by CIDR_CALC_STATS_FILE (opt-in)
+
+  lint, tf-check and the audit commands accept --emit syslog://HOST[:PORT] or
+  cef://HOST[:PORT] (add +tcp for TCP) to send findings to a SIEM
+
+  --timeout DURATION and --max-subnets N before a command name stop the command
+  after DURATION and cap the subnets its splits may list at N (default 65536)
+
+  Aliases defined as NAME=CIDR lines in ~/.config/cidr-calc/aliases (or
+  $CIDR_CALC_CONFIG_DIR/aliases) can be used wherever a CIDR is expected
 
 Options:
-  -o, --output FILE    Save output to specified file
+  -f, --file SOURCE    Read CIDRs (one per line, # comments) from a file,
+                       an http(s) URL, or "-" for stdin
+  --range FIRST-LAST   Report the fewest CIDRs that cover exactly the IPv4
+                       address range, e.g. 10.0.0.5-10.0.3.200
+  --timeout DURATION   Timeout for fetching -f URLs (default 30s); when given,
+                       each calculation also stops after DURATION
+  --header "K: V"      HTTP header for fetching -f URLs (repeatable)
+  -o, --output FILE    Save output to specified file ("-" for stdout)
   -h, --html          Generate HTML formatted output
+  --format FORMAT     Output format: text, html, slack, teams, org, rst, latex, csv, md,
+                      xml, json, svg; targets or masscan print just the CIDRs for
+                      nmap -iL or masscan
+                      (inferred from the output file extension when omitted)
+  --split PREFIX      List every subnet at this prefix length instead of the
+                      next one (e.g. --split 28 on a /24 lists sixteen /28s)
+  --parts N           Divide the network into at least N equal subnets, using
+                      the smallest prefix that yields that many
+  --hosts N           Split the network into the smallest subnets that each
+                      have at least N usable hosts
+  --max-subnets N     List only the first N subnets; the report still gives the
+                      total, and splits of any size are allowed
+  --all               List every subnet, allowing splits of up to 16777216
+                      subnets instead of 65536
+  --vlsm N,N,...      Allocate the smallest subnet for each host count and
+                      report the plan and free space (text or csv), or a
+                      report per allocation in the other formats
+  --exclude CIDR      Leave a network or address out of --format targets and
+                      masscan (repeatable, or comma-separated)
+  --strict-ext        Fail when the output file extension does not match the format
+  --compute "NAME = EXPR"
+                      Add a per-subnet field to text and csv output (repeatable)
+  --filter EXPR       Only list subnets for which the expression is true
+  --binary            Show the network ID, subnet mask and wildcard mask in
+                      binary, split into network and host bits (text or html)
+  --numeric           Show the network ID, broadcast and usable range as
+                      decimal and hex integers (text or html)
+  --classful          Show the legacy class (A-E), default classful mask and
+                      whether the network crosses classful boundaries (text or html)
+  --explain           Walk through the calculation step by step: mask derivation,
+                      AND/OR in binary, usable range and subnet count (text)
+  --class-rules FILE  Classify ranges with a YAML or JSON rule pack on top of
+                      the special-purpose registries (repeatable)
+  --html-font [FAMILY=]FILE
+                      Embed a .woff2, .woff, .ttf or .otf font in HTML output
+                      and use it for the report text (repeatable)
+  --html-logo FILE    Embed an image in the header of HTML output
+  --minify            Drop the indentation and blank lines of HTML output
+  --theme NAME        Color theme of HTML output: light (default), dark,
+                      high-contrast, neutral, or auto to follow the reader's
+                      light or dark system setting
+  --template FILE     Render HTML output with a Go html/template file, which
+                      can use or redefine the sections of the built-in page
+  --used FILE         Draw the allocated and free space of each network in HTML
+                      output, from a plan file of used subnets or hosts
+  --locale LOCALE     Group host and subnet counts for a locale such as de-DE,
+                      fr-FR or ja-JP (not csv, json or xml)
+  --screen-reader     Write text output as announced sections of "label: value"
+                      lines, without column alignment
+  --tag KEY=VALUE     Only list -f entries tagged KEY=VALUE, or with any value
+                      of KEY when given without "=" (repeatable; all must match)
+  --normalize FILE    Write the plan file with canonical CIDRs: lowercase, host
+                      bits cleared, shorthand such as 10/8 expanded, duplicates
+                      removed (-o FILE FILE rewrites it)
+  --write             With --normalize, rewrite each plan file given in place
+  --fail-fast         Stop a -f batch at the first failed entry; by default failed
+                      entries are listed in the report of the others
+  --reserved FILE     Fail when a -f plan entry or --vlsm allocation overlaps a
+                      range in FILE (one CIDR per line, # comment as the reason)
+  --validate-for PROVIDER
+                      Fail when the --split, --parts or --hosts subnets, or
+                      the network without them, are outside the sizes the
+                      provider allows: aws, azure, gcp, oci
+  --cloud PROVIDER    Plan the subnets for a cloud: --hosts and --vlsm leave room
+                      for its reserved addresses and each subnet shows its
+                      usable hosts (implies --validate-for): aws, azure, gcp
+  --azs N|ZONE,...    Spread --cloud subnets round-robin over N availability
+                      zones, or over the named zones
+  --regions REGION,...
+                      With --cloud gcp, lay out a subnet per region sized by
+                      --hosts or --split instead of the report
+  --pods N            Give every --regions subnet a /N secondary range for pods
+  --services N        Give every --regions subnet a /N secondary range for services
+  --low-memory        Stream text and csv output subnet by subnet and keep memory
+                      use small, allowing splits of up to 16777216 subnets
+  -i, --interactive   Keep a prompt open to enter CIDRs, splits and containment
+                      checks one after another, and to bookmark favorites
+  --tui               Browse the network in a full-screen terminal UI, with
+                      bookmarked favorites a key away
+  --otlp-endpoint URL Export traces and metrics over OTLP/HTTP, for example
+                      http://localhost:4318 (default $OTEL_EXPORTER_OTLP_ENDPOINT)
   --help              Show this help message
 
 Examples:
   cidr-calc 192.168.1.0/24
+  cidr-calc 2001:db8:abcd::/48
+  cidr-calc --split 28 192.168.1.0/24
+  cidr-calc --parts 6 10.0.0.0/24
+  cidr-calc --hosts 500 10.0.0.0/20
+  cidr-calc --vlsm 100,50,20,2,2 192.168.10.0/24
   cidr-calc -o report.txt 172.16.0.0/16
   cidr-calc --html -o network.html 10.0.0.0/8
+  cidr-calc -o network.html 10.0.0.0/8
+  cidr-calc --format slack 10.20.0.0/22
+  cidr-calc -f https://intranet/plans/prod-cidrs.txt --header "Authorization: Bearer $TOKEN"
+  cidr-calc --html -o - 10.0.0.0/8 | gzip > network.html.gz
   cidr-calc --help
 
 Description:
@@ -179,10 +1199,26 @@ Description:
 }
 
 func main() {
+	// A panic leaves a diagnostic bundle to attach to a bug report instead
+	// of a bare stack trace
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			os.Exit(reportCrash(os.Stderr, os.TempDir(), os.Args, recovered, debug.Stack()))
+		}
+	}()
+
 	handler := NewCLIHandler()
 
 	if err := handler.Run(os.Args); err != nil {
+		code := 1
+		var exitErr *ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.Code
+			if exitErr.Err == nil {
+				os.Exit(code)
+			}
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(code)
 	}
 }
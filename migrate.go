@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// planMigration upgrades a decoded plan document from one version to the next
+type planMigration struct {
+	from        int
+	description string
+	apply       func(document map[string]interface{}) error
+}
+
+// planMigrations upgrade the documents of earlier releases, oldest first.
+// Raising planDocumentVersion adds the migration from the version before, so
+// a document of any release can still be read.
+var planMigrations = []planMigration{
+	{
+		from:        1,
+		description: "rename policies.quotaTag to policies.tenantTag",
+		apply: func(document map[string]interface{}) error {
+			policies, ok := document["policies"].(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			if tag, ok := policies["quotaTag"]; ok {
+				delete(policies, "quotaTag")
+				policies["tenantTag"] = tag
+			}
+			return nil
+		},
+	},
+}
+
+// migratePlanDocument decodes a plan document and migrates it from its version
+// to the current one. It returns the document and a description of each
+// migration applied; a current document has none.
+func migratePlanDocument(source string, content []byte) (*PlanDocument, []string, error) {
+	value, err := decodePlanValue(source, content)
+	if err != nil {
+		return nil, nil, err
+	}
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: the document must be a mapping", source)
+	}
+
+	versionText, _ := fields["version"].(string)
+	version, err := strconv.Atoi(versionText)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: version must be a number, got %q", source, versionText)
+	}
+	if version > planDocumentVersion {
+		return nil, nil, fmt.Errorf("%s: plan document version %d is newer than the %d this release reads; upgrade simple-cidr-calculator", source, version, planDocumentVersion)
+	}
+	if version < 1 {
+		return nil, nil, fmt.Errorf("%s: unsupported plan document version %d", source, version)
+	}
+
+	var steps []string
+	for _, migration := range planMigrations {
+		if migration.from < version {
+			continue
+		}
+		if err := migration.apply(fields); err != nil {
+			return nil, nil, fmt.Errorf("%s: failed to migrate from version %d: %v", source, migration.from, err)
+		}
+		fields["version"] = strconv.Itoa(migration.from + 1)
+		steps = append(steps, fmt.Sprintf("version %d to %d: %s", migration.from, migration.from+1, migration.description))
+	}
+
+	document := &PlanDocument{}
+	if err := document.fromValue(source, fields); err != nil {
+		return nil, nil, err
+	}
+	if err := document.validate(source); err != nil {
+		return nil, nil, err
+	}
+	return document, steps, nil
+}
+
+// runPlanMigrate rewrites plan documents in the current version, keeping the
+// original of each next to it
+func (c *CLIHandler) runPlanMigrate(args []string) error {
+	flagSet := flag.NewFlagSet("plan migrate", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var dryRun bool
+	var outputFile string
+	flagSet.BoolVar(&dryRun, "dry-run-migrate", false, "Print the migrations and the migrated documents without writing them")
+	flagSet.StringVar(&outputFile, "o", "", "Save the --dry-run-migrate preview to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save the --dry-run-migrate preview to file")
+
+	// Accept the documents anywhere among the flags
+	sources, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("plan migrate requires a plan document")
+	}
+
+	var preview strings.Builder
+	for _, source := range sources {
+		content, err := readSource(source)
+		if err != nil {
+			return err
+		}
+		if !isPlanDocument(content) {
+			return fmt.Errorf("%s is not a plan document; it should start with version: or {", source)
+		}
+		document, steps, err := migratePlanDocument(source, content)
+		if err != nil {
+			return err
+		}
+		if len(steps) == 0 {
+			c.notef("%s is already at version %d", source, planDocumentVersion)
+			continue
+		}
+		migrated, err := document.Encode(planDocumentFormat(source, content))
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			preview.WriteString(fmt.Sprintf("Migrations of %s:\n", source))
+			for _, step := range steps {
+				preview.WriteString("  " + step + "\n")
+			}
+			preview.WriteString("\n" + migrated + "\n")
+			continue
+		}
+
+		// The original stays readable by the release that wrote it
+		backup := fmt.Sprintf("%s.v%d.bak", source, planDocumentVersion-len(steps))
+		if err := writeFileAtomic(backup, content); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(source, []byte(migrated)); err != nil {
+			return err
+		}
+		c.notef("migrated %s to version %d; the original is in %s", source, planDocumentVersion, backup)
+	}
+
+	if dryRun && preview.Len() > 0 {
+		return c.writeOutput(strings.TrimSuffix(preview.String(), "\n"), outputFile)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testPlanDocumentV1 is a document written by the first plan document release
+const testPlanDocumentV1 = `version: 1
+allocations:
+  - cidr: 10.0.0.0/24
+    labels:
+      team: payments
+policies:
+  quotas:
+    - tenant: payments
+      quota: 4096
+  quotaTag: team
+`
+
+func TestMigratePlanDocument(t *testing.T) {
+	for name, content := range map[string]string{
+		"yaml": testPlanDocumentV1,
+		"json": `{"version": 1, "allocations": [{"cidr": "10.0.0.0/24"}], "policies": {"quotaTag": "team"}}`,
+	} {
+		document, steps, err := migratePlanDocument("plan", []byte(content))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if len(steps) != 1 || !strings.HasPrefix(steps[0], "version 1 to 2: rename policies.quotaTag") {
+			t.Errorf("%s: unexpected steps %q", name, steps)
+		}
+		if document.Version != planDocumentVersion || document.Policies.TenantTag != "team" {
+			t.Errorf("%s: expected a current document with tenant tag team, got %+v", name, document)
+		}
+	}
+
+	current, err := testPlanDocument.Encode(DocumentYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, steps, err := migratePlanDocument("plan.yaml", []byte(current)); err != nil || len(steps) != 0 {
+		t.Errorf("expected a current document to need no migration, got %q, %v", steps, err)
+	}
+}
+
+func TestPlanMigrate_Command(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+	plan := filepath.Join(dir, "plan.yaml")
+	if err := os.WriteFile(plan, []byte(testPlanDocumentV1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A dry run prints the migration and leaves the file alone
+	preview := filepath.Join(dir, "preview.txt")
+	if err := handler.Run([]string{"cidr-calc", "plan", "migrate", plan, "--dry-run-migrate", "-o", preview}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(preview); !strings.Contains(string(content), "version 1 to 2") || !strings.Contains(string(content), "tenantTag: team") {
+		t.Errorf("expected the migration preview, got:\n%s", content)
+	}
+	if content, _ := os.ReadFile(plan); string(content) != testPlanDocumentV1 {
+		t.Errorf("expected --dry-run-migrate to leave the document unchanged, got:\n%s", content)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "plan", "migrate", plan}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backup, err := os.ReadFile(plan + ".v1.bak"); err != nil || string(backup) != testPlanDocumentV1 {
+		t.Errorf("expected the original in plan.yaml.v1.bak, got %q, %v", backup, err)
+	}
+	content, _ := os.ReadFile(plan)
+	if !strings.HasPrefix(string(content), "version: 2\n") || !strings.Contains(string(content), "tenantTag: team") {
+		t.Errorf("expected a version 2 document, got:\n%s", content)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"math/big"
 	"net"
 	"strings"
 )
@@ -9,14 +10,18 @@ import (
 // NetworkInfo represents comprehensive information about a network
 type NetworkInfo struct {
 	Network       net.IPNet
+	Address       net.IP // the address the network was given with, host bits and all
 	NetworkID     net.IP
-	BroadcastAddr net.IP
+	BroadcastAddr net.IP // for IPv6, the last address of the prefix
 	SubnetMask    net.IPMask
 	WildcardMask  net.IPMask
 	FirstUsableIP net.IP
 	LastUsableIP  net.IP
-	TotalHosts    uint32
+	TotalHosts    uint32 // IPv4 only; see HostCount
 	PrefixLength  int
+	Tags          Tags        // labels from the plan file line, if any
+	Alias         string      // the alias name the network was given as, if any
+	Derivation    *Derivation // how the network was carved from a parent, if it was
 }
 
 // SubnetInfo represents information about a subnet
@@ -24,6 +29,20 @@ type SubnetInfo struct {
 	NetworkID     net.IP
 	CIDR          string
 	BroadcastAddr net.IP
+	Computed      []ComputedValue // --compute fields, in flag order
+}
+
+// ComputedValue is the value of a --compute field for one subnet
+type ComputedValue struct {
+	Name  string
+	Value string
+}
+
+// NetworkReport pairs a parsed network with its calculated subnets
+type NetworkReport struct {
+	Info       *NetworkInfo
+	Subnets    []SubnetInfo
+	Derivation *Derivation // how the subnets were derived, when requested
 }
 
 // ValidateCIDR validates CIDR notation format
@@ -46,6 +65,57 @@ func ValidateCIDR(cidr string) error {
 	return nil
 }
 
+// IsIPv6 reports whether the network is an IPv6 prefix
+func (n *NetworkInfo) IsIPv6() bool {
+	return n.NetworkID.To4() == nil
+}
+
+// MaxPrefix returns the longest prefix of the address family: 32 or 128
+func (n *NetworkInfo) MaxPrefix() int {
+	if n.IsIPv6() {
+		return 128
+	}
+	return 32
+}
+
+// NextPrefix returns the prefix length subnets are split at. IPv4 networks are
+// halved; IPv6 networks are split at the next nibble (hex digit) boundary.
+func (n *NetworkInfo) NextPrefix() int {
+	if !n.IsIPv6() {
+		return n.PrefixLength + 1
+	}
+
+	next := (n.PrefixLength/4 + 1) * 4
+	if next > 128 {
+		next = 128
+	}
+	return next
+}
+
+// HostCount returns the number of usable hosts as a decimal string.
+// For IPv6 this is the number of addresses in the prefix, which can exceed uint32.
+func (n *NetworkInfo) HostCount() string {
+	if !n.IsIPv6() {
+		return fmt.Sprintf("%d", n.TotalHosts)
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(128-n.PrefixLength)).String()
+}
+
+// CIDR returns the network in canonical CIDR notation
+func (n *NetworkInfo) CIDR() string {
+	return fmt.Sprintf("%s/%d", n.NetworkID.String(), n.PrefixLength)
+}
+
+// Contains reports whether other lies entirely within this network
+func (n *NetworkInfo) Contains(other *NetworkInfo) bool {
+	return n.PrefixLength <= other.PrefixLength && n.Network.Contains(other.NetworkID)
+}
+
+// Overlaps reports whether the two networks share any address
+func (n *NetworkInfo) Overlaps(other *NetworkInfo) bool {
+	return n.Contains(other) || other.Contains(n)
+}
+
 // ValidateNetworkInfo validates NetworkInfo struct fields
 func (n *NetworkInfo) Validate() error {
 	if n.NetworkID == nil {
@@ -60,8 +130,8 @@ func (n *NetworkInfo) Validate() error {
 		return fmt.Errorf("subnet mask cannot be nil")
 	}
 
-	if n.PrefixLength < 0 || n.PrefixLength > 32 {
-		return fmt.Errorf("prefix length must be between 0 and 32")
+	if n.PrefixLength < 0 || n.PrefixLength > n.MaxPrefix() {
+		return fmt.Errorf("prefix length must be between 0 and %d", n.MaxPrefix())
 	}
 
 	return nil
@@ -88,3 +158,56 @@ func (s *SubnetInfo) Validate() error {
 
 	return nil
 }
+
+// String returns the network in canonical CIDR notation, or an empty string
+// for the zero value
+func (n NetworkInfo) String() string {
+	if n.NetworkID == nil {
+		return ""
+	}
+	return n.CIDR()
+}
+
+// MarshalText encodes the network as its canonical CIDR, so networks can be
+// written to YAML, JSON and logs as plain strings
+func (n NetworkInfo) MarshalText() ([]byte, error) {
+	return []byte(n.String()), nil
+}
+
+// UnmarshalText parses a CIDR into a fully populated network, so a
+// *NetworkInfo can be used with flag.TextVar and configuration decoders.
+// Host bits are cleared: "192.168.1.5/24" becomes 192.168.1.0/24.
+func (n *NetworkInfo) UnmarshalText(text []byte) error {
+	info, err := NewCIDRCalculator().ParseCIDR(string(text))
+	if err != nil {
+		return err
+	}
+	*n = *info
+	return nil
+}
+
+// String returns the subnet in canonical CIDR notation
+func (s SubnetInfo) String() string {
+	return s.CIDR
+}
+
+// MarshalText encodes the subnet as its canonical CIDR
+func (s SubnetInfo) MarshalText() ([]byte, error) {
+	return []byte(s.CIDR), nil
+}
+
+// UnmarshalText parses a CIDR into a subnet with its network ID and broadcast
+// address; computed fields are left empty
+func (s *SubnetInfo) UnmarshalText(text []byte) error {
+	calculator := NewCIDRCalculator()
+	info, err := calculator.ParseCIDR(string(text))
+	if err != nil {
+		return err
+	}
+	*s = SubnetInfo{
+		NetworkID:     info.NetworkID,
+		CIDR:          info.CIDR(),
+		BroadcastAddr: calculator.calculateSubnetBroadcast(info.NetworkID, info.PrefixLength),
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
+	"fmt"
 	"net"
 	"testing"
 )
@@ -46,6 +49,11 @@ func TestValidateCIDR(t *testing.T) {
 			cidr:    "192.168.1.0/33",
 			wantErr: true,
 		},
+		{
+			name:    "valid IPv6 CIDR",
+			cidr:    "2001:db8::/32",
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -205,3 +213,91 @@ func TestSubnetInfo_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestNetworkInfo_AddressFamily(t *testing.T) {
+	calc := NewCIDRCalculator()
+
+	tests := []struct {
+		cidr       string
+		ipv6       bool
+		nextPrefix int
+		hostCount  string
+	}{
+		{"192.168.1.0/24", false, 25, "254"},
+		{"10.0.0.0/31", false, 32, "2"},
+		{"2001:db8::/32", true, 36, "79228162514264337593543950336"},
+		{"2001:db8::/50", true, 52, "302231454903657293676544"},
+		{"2001:db8::/126", true, 128, "4"},
+		{"2001:db8::1/128", true, 128, "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			info, err := calc.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("ParseCIDR() error = %v", err)
+			}
+			if info.IsIPv6() != tt.ipv6 {
+				t.Errorf("IsIPv6() = %v, want %v", info.IsIPv6(), tt.ipv6)
+			}
+			if got := info.NextPrefix(); got != tt.nextPrefix {
+				t.Errorf("NextPrefix() = %d, want %d", got, tt.nextPrefix)
+			}
+			if got := info.HostCount(); got != tt.hostCount {
+				t.Errorf("HostCount() = %s, want %s", got, tt.hostCount)
+			}
+		})
+	}
+}
+
+func TestNetworkInfo_Text(t *testing.T) {
+	var info NetworkInfo
+	if info.String() != "" {
+		t.Errorf("expected the zero value to print as an empty string, got %q", info.String())
+	}
+	if err := info.UnmarshalText([]byte("192.168.1.5/24")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.String() != "192.168.1.0/24" || info.TotalHosts != 254 || info.LastUsableIP.String() != "192.168.1.254" {
+		t.Errorf("expected a fully populated 192.168.1.0/24, got %+v", info)
+	}
+	if text, err := info.MarshalText(); err != nil || string(text) != "192.168.1.0/24" {
+		t.Errorf("expected 192.168.1.0/24, got %q, %v", text, err)
+	}
+	if err := info.UnmarshalText([]byte("10.0.0.0/33")); err == nil {
+		t.Error("expected an error for an invalid CIDR")
+	}
+
+	// The interfaces let networks be flags and JSON values directly
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	var pool NetworkInfo
+	flagSet.TextVar(&pool, "pool", &NetworkInfo{}, "pool")
+	if err := flagSet.Parse([]string{"--pool", "2001:db8::1/48"}); err != nil || pool.String() != "2001:db8::/48" {
+		t.Errorf("expected --pool 2001:db8::/48, got %q, %v", pool.String(), err)
+	}
+
+	encoded, err := json.Marshal(map[string]NetworkInfo{"pool": pool})
+	if err != nil || string(encoded) != `{"pool":"2001:db8::/48"}` {
+		t.Errorf("unexpected JSON %s, %v", encoded, err)
+	}
+	var decoded struct{ Pool *NetworkInfo }
+	if err := json.Unmarshal([]byte(`{"Pool": "10.1.0.0/16"}`), &decoded); err != nil || fmt.Sprint(decoded.Pool) != "10.1.0.0/16" {
+		t.Errorf("expected to decode 10.1.0.0/16, got %v, %v", decoded.Pool, err)
+	}
+}
+
+func TestSubnetInfo_Text(t *testing.T) {
+	var subnet SubnetInfo
+	if err := subnet.UnmarshalText([]byte("10.0.0.70/26")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if subnet.String() != "10.0.0.64/26" || subnet.NetworkID.String() != "10.0.0.64" || subnet.BroadcastAddr.String() != "10.0.0.127" {
+		t.Errorf("unexpected subnet %+v", subnet)
+	}
+	if text, err := subnet.MarshalText(); err != nil || string(text) != "10.0.0.64/26" {
+		t.Errorf("expected 10.0.0.64/26, got %q, %v", text, err)
+	}
+	if err := subnet.UnmarshalText([]byte("not a cidr")); err == nil {
+		t.Error("expected an error for an invalid CIDR")
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// Address map layout: cells per row, and the most cells drawn before
+// each cell starts to cover a block of addresses
+const (
+	heatmapRowWidth = 64
+	maxHeatmapCells = 1024
+)
+
+// heatmapLevels are the cell characters for blocks that are 0%, under 25%,
+// under 50%, under 75% and at least 75% observed
+const heatmapLevels = ".:+*#"
+
+// unreachableNeighborStates are ip neigh states without a confirmed link-layer address
+var unreachableNeighborStates = map[string]bool{
+	"FAILED":     true,
+	"INCOMPLETE": true,
+}
+
+// Neighbor is an entry of an ARP or NDP neighbor table
+type Neighbor struct {
+	IP  net.IP
+	MAC string
+}
+
+// ParseNeighbors reads the output of "arp -an" (Linux, BSD and macOS), Windows
+// "arp -a" or "ip neigh". Entries without a link-layer address, such as
+// incomplete or failed resolutions, are skipped, as are lines in no known format.
+func ParseNeighbors(r io.Reader) ([]Neighbor, error) {
+	var neighbors []Neighbor
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		neighbor, ok := parseNeighborLine(strings.Fields(scanner.Text()))
+		if !ok || seen[neighbor.IP.String()] {
+			continue
+		}
+		seen[neighbor.IP.String()] = true
+		neighbors = append(neighbors, neighbor)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read neighbor table: %v", err)
+	}
+
+	return neighbors, nil
+}
+
+// parseNeighborLine recognises a single neighbor table line
+func parseNeighborLine(fields []string) (Neighbor, bool) {
+	// arp -an: ? (192.168.1.1) at 00:11:22:33:44:55 [ether] on eth0
+	if len(fields) >= 4 && fields[2] == "at" && strings.HasPrefix(fields[1], "(") {
+		ip := parseNeighborIP(strings.Trim(fields[1], "()"))
+		if ip == nil || !isLinkLayerAddress(fields[3]) {
+			return Neighbor{}, false
+		}
+		return Neighbor{IP: ip, MAC: fields[3]}, true
+	}
+
+	if len(fields) < 2 {
+		return Neighbor{}, false
+	}
+	ip := parseNeighborIP(fields[0])
+	if ip == nil {
+		return Neighbor{}, false
+	}
+
+	// Windows arp -a: 192.168.1.1  00-11-22-33-44-55  dynamic
+	if len(fields) == 3 && isLinkLayerAddress(fields[1]) {
+		return Neighbor{IP: ip, MAC: fields[1]}, true
+	}
+
+	// ip neigh: 192.168.1.1 dev eth0 lladdr 00:11:22:33:44:55 REACHABLE
+	if unreachableNeighborStates[fields[len(fields)-1]] {
+		return Neighbor{}, false
+	}
+	for i := 1; i < len(fields)-1; i++ {
+		if fields[i] == "lladdr" && isLinkLayerAddress(fields[i+1]) {
+			return Neighbor{IP: ip, MAC: fields[i+1]}, true
+		}
+	}
+
+	return Neighbor{}, false
+}
+
+// parseNeighborIP parses an address, dropping an IPv6 zone such as %en0
+func parseNeighborIP(value string) net.IP {
+	value, _, _ = strings.Cut(value, "%")
+	ip := net.ParseIP(value)
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
+
+// isLinkLayerAddress reports whether value looks like a colon or dash separated MAC address
+func isLinkLayerAddress(value string) bool {
+	parts := strings.FieldsFunc(value, func(r rune) bool { return r == ':' || r == '-' })
+	if len(parts) < 6 {
+		return false
+	}
+	for _, part := range parts {
+		if len(part) == 0 || len(part) > 2 || strings.Trim(part, "0123456789abcdefABCDEF") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// observedAddresses returns the usable addresses of the IPv4 network that have a neighbor entry
+func observedAddresses(neighbors []Neighbor, network *NetworkInfo) map[uint32]bool {
+	observed := make(map[uint32]bool)
+	for _, neighbor := range neighbors {
+		if neighbor.IP.To4() != nil && isUsableAddress(network, neighbor.IP) {
+			observed[ipv4ToUint32(neighbor.IP)] = true
+		}
+	}
+	return observed
+}
+
+// FormatHeatmap draws the IPv4 network as rows of cells marking observed
+// addresses. Networks with more than maxHeatmapCells addresses are drawn with
+// each cell covering an equal block, shaded by how much of it was observed.
+func (f *OutputFormatter) FormatHeatmap(network *NetworkInfo, observed map[uint32]bool) string {
+	var output strings.Builder
+
+	start := uint64(ipv4ToUint32(network.NetworkID))
+	size := uint64(1) << uint(32-network.PrefixLength)
+	cellSize := uint64(1)
+	for size/cellSize > maxHeatmapCells {
+		cellSize *= 2
+	}
+
+	if cellSize == 1 {
+		output.WriteString("Address Map (# observed, . free, - reserved):\n")
+	} else {
+		output.WriteString(fmt.Sprintf("Address Map (each cell is %d addresses: . none, : <25%%, + <50%%, * <75%%, # 75%%+ observed):\n", cellSize))
+	}
+
+	cells := size / cellSize
+	for row := uint64(0); row < cells; row += heatmapRowWidth {
+		rowStart := start + row*cellSize
+		output.WriteString(fmt.Sprintf("  %-15s ", uint32ToIPv4(uint32(rowStart))))
+
+		for cell := row; cell < cells && cell < row+heatmapRowWidth; cell++ {
+			cellStart := start + cell*cellSize
+			if cellSize == 1 {
+				address := uint32ToIPv4(uint32(cellStart))
+				switch {
+				case !isUsableAddress(network, address):
+					output.WriteByte('-')
+				case observed[uint32(cellStart)]:
+					output.WriteByte('#')
+				default:
+					output.WriteByte('.')
+				}
+				continue
+			}
+
+			var count uint64
+			for address := cellStart; address < cellStart+cellSize; address++ {
+				if observed[uint32(address)] {
+					count++
+				}
+			}
+			level := 0
+			if count > 0 {
+				level = 1 + int(count*4/cellSize)
+				if level >= len(heatmapLevels) {
+					level = len(heatmapLevels) - 1
+				}
+			}
+			output.WriteByte(heatmapLevels[level])
+		}
+		output.WriteString("\n")
+	}
+
+	return output.String()
+}
+
+// readNeighborTable loads a neighbor table from a file or "-" for standard input
+func readNeighborTable(source string) ([]Neighbor, error) {
+	if source == stdinFilename {
+		return ParseNeighbors(os.Stdin)
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open neighbor table: %v", err)
+	}
+	defer file.Close()
+
+	return ParseNeighbors(file)
+}
+
+// runNeighbors implements the neighbors subcommand
+func (c *CLIHandler) runNeighbors(args []string) error {
+	flagSet := flag.NewFlagSet("neighbors", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var cidr, historyFile, format, outputFile string
+	var emit stringList
+	var heatmap bool
+	var locale Locale
+	thresholds := UtilizationThresholds{}
+	flagSet.StringVar(&cidr, "network", "", "Subnet to analyse, in CIDR notation")
+	flagSet.StringVar(&historyFile, "history", "", "CSV file that records each run and feeds the forecast")
+	flagSet.BoolVar(&heatmap, "heatmap", false, "Draw a map of observed and free addresses")
+	flagSet.Float64Var(&thresholds.WarnPercent, "warn", defaultWarnPercent, "Warn at this utilization percentage")
+	flagSet.Float64Var(&thresholds.CriticalPercent, "critical", defaultCriticalPercent, "Fail at this utilization percentage")
+	flagSet.IntVar(&thresholds.HorizonDays, "horizon", defaultHorizonDays, "Warn when the forecast runs out within this many days")
+	flagSet.StringVar(&format, "format", FormatText, "Findings format: text, gh-annotations")
+	flagSet.Var(localeFlag{&locale}, "locale", "Write dates and numbers for this locale, e.g. de-DE or ja-JP")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	flagSet.Var(&emit, "emit", emitFlagUsage)
+
+	// Accept the table source anywhere among the flags
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	emitTargets, err := parseEmitTargets(emit)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		return fmt.Errorf("neighbors requires a single neighbor table file or \"-\" for stdin, got %d", len(positional))
+	}
+	if cidr == "" {
+		return fmt.Errorf("neighbors requires --network")
+	}
+
+	network, err := c.calculator.ParseCIDR(cidr)
+	if err != nil {
+		return fmt.Errorf("failed to parse CIDR: %v", err)
+	}
+	if network.IsIPv6() {
+		return fmt.Errorf("neighbors supports IPv4 networks only")
+	}
+
+	source := positional[0]
+	neighbors, err := readNeighborTable(source)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	observed := observedAddresses(neighbors, network)
+	utilization := NewUtilization(network, uint64(len(observed)))
+
+	var samples []UtilizationSample
+	if historyFile != "" {
+		if samples, err = ReadUtilizationHistory(historyFile, network.CIDR()); err != nil {
+			return err
+		}
+		sample := UtilizationSample{Time: now, CIDR: network.CIDR(), Used: utilization.Used, Total: utilization.Total}
+		if err := AppendUtilizationSample(historyFile, sample); err != nil {
+			return err
+		}
+		samples = append(samples, sample)
+	}
+
+	forecast, hasForecast := ForecastExhaustion(samples, utilization.Total)
+	findings := UtilizationFindings(utilization, forecast, hasForecast, thresholds, source, now, locale)
+
+	var content string
+	switch format {
+	case "", FormatText:
+		c.formatter.Locale = locale
+		content = c.formatter.FormatUtilization(fmt.Sprintf("Observed Neighbors (%s)", source), utilization, forecast, hasForecast, findings, now)
+		if heatmap {
+			content += "\n" + c.formatter.FormatHeatmap(network, observed)
+		}
+	default:
+		if content, err = c.formatter.RenderFindings(format, findings); err != nil {
+			return err
+		}
+	}
+
+	return c.writeAuditContent(content, outputFile, emitTargets, findings)
+}
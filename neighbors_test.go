@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testNeighborTable = `? (192.168.1.1) at 00:11:22:33:44:55 [ether] on eth0
+? (192.168.1.5) at <incomplete> on eth0
+router.lan (192.168.1.2) at 0:11:22:33:44:56 on en0 ifscope [ethernet]
+192.168.1.3 dev eth0 lladdr 00:11:22:33:44:57 STALE
+192.168.1.4 dev eth0  FAILED
+192.168.1.1 dev eth0 lladdr 00:11:22:33:44:55 REACHABLE
+fe80::1 dev eth0 lladdr 00:11:22:33:44:58 router REACHABLE
+
+Interface: 192.168.1.100 --- 0x4
+  Internet Address      Physical Address      Type
+  192.168.1.6           00-11-22-33-44-59     dynamic
+  192.168.1.255         ff-ff-ff-ff-ff-ff     static
+  10.0.0.1              00-11-22-33-44-60     dynamic
+`
+
+func TestParseNeighbors(t *testing.T) {
+	neighbors, err := ParseNeighbors(strings.NewReader(testNeighborTable))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, neighbor := range neighbors {
+		got = append(got, neighbor.IP.String())
+	}
+	expected := "192.168.1.1,192.168.1.2,192.168.1.3,fe80::1,192.168.1.6,192.168.1.255,10.0.0.1"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("expected neighbors %s, got %s", expected, strings.Join(got, ","))
+	}
+
+	network, err := NewCIDRCalculator().ParseCIDR("192.168.1.0/24")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+	// The broadcast address, the IPv6 neighbor and 10.0.0.1 are not usable addresses of the /24
+	if observed := observedAddresses(neighbors, network); len(observed) != 4 {
+		t.Errorf("expected 4 observed addresses, got %d", len(observed))
+	}
+}
+
+func TestIsLinkLayerAddress(t *testing.T) {
+	tests := map[string]bool{
+		"00:11:22:33:44:55": true,
+		"0:11:22:3:44:5":    true,
+		"00-11-22-33-44-55": true,
+		"<incomplete>":      false,
+		"(incomplete)":      false,
+		"dev":               false,
+		"00:11:22:33:44":    false,
+		"00:11:22:33:44:zz": false,
+	}
+	for value, expected := range tests {
+		if got := isLinkLayerAddress(value); got != expected {
+			t.Errorf("isLinkLayerAddress(%q) = %v, want %v", value, got, expected)
+		}
+	}
+}
+
+func TestOutputFormatter_FormatHeatmap(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+
+	small, err := calculator.ParseCIDR("192.168.1.0/29")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+	observed := map[uint32]bool{ipv4ToUint32(small.FirstUsableIP): true}
+	if got := formatter.FormatHeatmap(small, observed); !strings.Contains(got, "  192.168.1.0     -#.....-\n") {
+		t.Errorf("unexpected address map:\n%s", got)
+	}
+
+	large, err := calculator.ParseCIDR("10.0.0.0/20")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+	observed = make(map[uint32]bool)
+	for i := uint32(1); i <= 3; i++ {
+		observed[ipv4ToUint32(large.NetworkID)+i] = true
+	}
+	got := formatter.FormatHeatmap(large, observed)
+	if !strings.Contains(got, "each cell is 4 addresses") || !strings.Contains(got, "  10.0.0.0        #...") {
+		t.Errorf("unexpected address map:\n%s", got)
+	}
+	if rows := strings.Count(got, "\n"); rows != 17 {
+		t.Errorf("expected a header and 16 rows, got %d lines", rows)
+	}
+}
+
+func TestCLIHandler_Neighbors(t *testing.T) {
+	dir := t.TempDir()
+	table := filepath.Join(dir, "arp.txt")
+	if err := os.WriteFile(table, []byte(testNeighborTable), 0644); err != nil {
+		t.Fatalf("failed to write neighbor table: %v", err)
+	}
+
+	handler := NewCLIHandler()
+	output := filepath.Join(dir, "report.txt")
+
+	if err := handler.Run([]string{"cidr-calc", "neighbors", "--network", "192.168.1.0/24", table, "--heatmap", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	for _, exp := range []string{"Observed Neighbors (" + table + "):", "Used:           4 of 254 (1.6%)", "Address Map", "  192.168.1.0     -###..#.", "No problems found"} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected report to contain %q, got:\n%s", exp, content)
+		}
+	}
+
+	// Two observed addresses fill a /30
+	err = handler.Run([]string{"cidr-calc", "neighbors", table, "--network", "192.168.1.0/30", "-o", output})
+	if err == nil || err.Error() != "audit found 1 error" {
+		t.Errorf("expected a critical utilization error, got %v", err)
+	}
+
+	errorCases := [][]string{
+		{"cidr-calc", "neighbors", table},
+		{"cidr-calc", "neighbors", "--network", "192.168.1.0/24"},
+		{"cidr-calc", "neighbors", "--network", "fe80::/64", table},
+		{"cidr-calc", "neighbors", "--network", "192.168.1.0/24", filepath.Join(dir, "missing.txt")},
+	}
+	for _, args := range errorCases {
+		if err := handler.Run(args); err == nil {
+			t.Errorf("%v: expected error but got none", args)
+		}
+	}
+}
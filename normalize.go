@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// NormalizeCIDR returns the canonical form of a plan entry: lowercase, with the
+// host bits cleared and IPv6 addresses compressed. It expands the shorthand
+// people write in plan files: abbreviated IPv4 networks such as 10/8 or
+// 172.16/12, dotted masks such as 10.0.0.0/255.255.0.0, and bare addresses,
+// which become a /32 or /128.
+func (c *CIDRCalculator) NormalizeCIDR(entry string) (string, error) {
+	entry, _ = c.expandAlias(strings.TrimSpace(entry))
+	cidr := strings.ToLower(entry)
+	address, prefix, hasPrefix := strings.Cut(cidr, "/")
+	ipv6 := strings.Contains(address, ":")
+
+	if !ipv6 && hasPrefix {
+		if octets := strings.Split(address, "."); len(octets) < 4 {
+			address += strings.Repeat(".0", 4-len(octets))
+		}
+		if strings.Contains(prefix, ".") {
+			mask := net.ParseIP(prefix).To4()
+			if mask == nil {
+				return "", fmt.Errorf("invalid subnet mask %s", prefix)
+			}
+			ones, bits := net.IPMask(mask).Size()
+			if bits == 0 {
+				return "", fmt.Errorf("subnet mask %s is not contiguous", prefix)
+			}
+			prefix = strconv.Itoa(ones)
+		}
+	}
+	if !hasPrefix {
+		prefix = "32"
+		if ipv6 {
+			prefix = "128"
+		}
+	}
+	if length, err := strconv.Atoi(prefix); err == nil {
+		prefix = strconv.Itoa(length)
+	}
+
+	info, err := c.ParseCIDR(address + "/" + prefix)
+	if err != nil {
+		return "", err
+	}
+	return info.CIDR(), nil
+}
+
+// NormalizedPlan is a plan file rewritten with canonical entries
+type NormalizedPlan struct {
+	Content    string
+	Entries    int
+	Changed    int // entries written differently
+	Duplicates []PlanDuplicate
+}
+
+// PlanDuplicate is an entry dropped as a duplicate of an earlier one
+type PlanDuplicate struct {
+	Source string // the file, and the section of a document
+	Line   int    // line of a plan file, position in a document section
+	First  int    // line or position of the entry it repeats
+	CIDR   string
+}
+
+// String describes where the duplicate was and what it repeats
+func (d PlanDuplicate) String() string {
+	unit := "line"
+	if strings.Contains(d.Source, " ") {
+		unit = "range"
+	}
+	return fmt.Sprintf("%s at %s %s %d, a duplicate of %s %d", d.CIDR, d.Source, unit, d.Line, unit, d.First)
+}
+
+// NormalizePlanFile rewrites every entry of a plan file in canonical form and
+// drops the entries that repeat an earlier one. Only the CIDR of a line is
+// rewritten; its tags, other words and comment, comment lines, !include
+// directives and blank lines are kept as they are. A repeated entry with an annotation or comment
+// of its own is kept as a comment line, so that its context is not lost. It is
+// an error when any entry is not a network.
+func (c *CIDRCalculator) NormalizePlanFile(source string, content []byte) (*NormalizedPlan, error) {
+	plan := &NormalizedPlan{}
+	var failures []error
+	first := make(map[string]int)
+	contexts := make(map[string]string)
+
+	lines := strings.SplitAfter(string(content), "\n")
+	var output strings.Builder
+	for i, line := range lines {
+		text, _, _ := strings.Cut(line, "#")
+		fields := strings.Fields(text)
+		if _, include := parseInclude(fields); len(fields) == 0 || include {
+			output.WriteString(line)
+			continue
+		}
+
+		plan.Entries++
+		cidr, err := c.NormalizeCIDR(fields[0])
+		if err != nil {
+			failures = append(failures, BatchError{Source: source, Line: i + 1, CIDR: fields[0], Err: err})
+			continue
+		}
+		context := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0]))
+		if earlier, ok := first[cidr]; ok {
+			plan.Duplicates = append(plan.Duplicates, PlanDuplicate{Source: source, Line: i + 1, First: earlier, CIDR: cidr})
+			if context != "" && context != contexts[cidr] {
+				output.WriteString(fmt.Sprintf("# duplicate of line %d: %s\n", earlier, strings.TrimSpace(line)))
+			}
+			continue
+		}
+		first[cidr] = i + 1
+		contexts[cidr] = context
+
+		if cidr != fields[0] {
+			plan.Changed++
+			start := strings.Index(line, fields[0])
+			line = line[:start] + cidr + line[start+len(fields[0]):]
+		}
+		output.WriteString(line)
+	}
+
+	switch len(failures) {
+	case 0:
+	case 1:
+		return nil, failures[0]
+	default:
+		return nil, fmt.Errorf("%v (and %d more entries that are not networks)", failures[0], len(failures)-1)
+	}
+	plan.Content = output.String()
+	return plan, nil
+}
+
+// NormalizePlanDocument rewrites the ranges of every section of a plan
+// document in canonical form, dropping the ranges a section repeats. The
+// comment of the range kept names a dropped range with a context of its own.
+func (c *CIDRCalculator) NormalizePlanDocument(source string, document *PlanDocument) (*NormalizedPlan, error) {
+	plan := &NormalizedPlan{}
+	for _, section := range []string{SectionPools, SectionAllocations, SectionReserved} {
+		ranges, err := document.Section(section)
+		if err != nil {
+			return nil, err
+		}
+
+		first := make(map[string]int)
+		index := make(map[string]int)
+		contexts := make(map[string]string)
+		kept := ranges[:0]
+		for i, planRange := range ranges {
+			plan.Entries++
+			cidr, err := c.NormalizeCIDR(planRange.CIDR)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s %d: %v", source, section, i+1, err)
+			}
+			if position, ok := first[cidr]; ok {
+				plan.Duplicates = append(plan.Duplicates, PlanDuplicate{Source: source + " " + section, Line: i + 1, First: position, CIDR: cidr})
+				if context := planRangeContext(planRange); context != "" && context != contexts[cidr] {
+					original := &kept[index[cidr]]
+					planRange.CIDR = cidr
+					original.Comment = strings.TrimPrefix(original.Comment+"; ", "; ") + "duplicate " + planRange.Line()
+				}
+				continue
+			}
+			first[cidr] = i + 1
+			index[cidr] = len(kept)
+			contexts[cidr] = planRangeContext(planRange)
+			if cidr != planRange.CIDR {
+				plan.Changed++
+				planRange.CIDR = cidr
+			}
+			kept = append(kept, planRange)
+		}
+
+		switch section {
+		case SectionPools:
+			document.Pools = kept
+		case SectionAllocations:
+			document.Allocations = kept
+		case SectionReserved:
+			document.Policies.Reserved = kept
+		}
+	}
+	return plan, nil
+}
+
+// normalizeSource reads a plan file or document and returns it in canonical
+// form; documents are encoded in the format of location
+func (c *CLIHandler) normalizeSource(source, location string, content []byte) (*NormalizedPlan, error) {
+	if !isPlanDocument(content) {
+		return c.calculator.NormalizePlanFile(source, content)
+	}
+
+	document, err := parsePlanDocument(source, content)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := c.calculator.NormalizePlanDocument(source, document)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Content, err = document.Encode(planDocumentFormat(location, content)); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// planRangeContext returns the annotation, labels and comment of a range
+func planRangeContext(planRange PlanRange) string {
+	return strings.TrimSpace(strings.TrimPrefix(planRange.Line(), planRange.CIDR))
+}
+
+// runNormalize writes the canonical form of the --normalize plan file or
+// document, noting what changed. Under --write every plan file given is
+// rewritten in place instead.
+func (c *CLIHandler) runNormalize(config *Config) error {
+	if config.Write {
+		return c.normalizeInPlace(config)
+	}
+
+	source := config.InputFile
+	if source == "" {
+		source = config.CIDR
+	}
+	content, err := readSource(source)
+	if err != nil {
+		return err
+	}
+	location := source
+	if config.WritesToFile() {
+		location = config.OutputFile
+	}
+	plan, err := c.normalizeSource(source, location, content)
+	if err != nil {
+		return err
+	}
+
+	for _, duplicate := range plan.Duplicates {
+		c.notef("removed %s", duplicate)
+	}
+	c.notef("normalized %d of %d entries and removed %s", plan.Changed, plan.Entries, plural(len(plan.Duplicates), "duplicate"))
+	return c.writeOutput(plan.Content, config.OutputFile)
+}
+
+// normalizeInPlace rewrites every --normalize plan file in canonical form,
+// leaving the files already in it untouched
+func (c *CLIHandler) normalizeInPlace(config *Config) error {
+	sources := config.CIDRs
+	if config.InputFile != "" {
+		sources = append([]string{config.InputFile}, sources...)
+	}
+
+	for _, source := range sources {
+		if source == "-" || isURL(source) {
+			return fmt.Errorf("--write rewrites local plan files, not %s", source)
+		}
+		content, err := readSource(source)
+		if err != nil {
+			return err
+		}
+		plan, err := c.normalizeSource(source, source, content)
+		if err != nil {
+			return err
+		}
+		rewritten, err := rewriteInPlace(source, content, plan.Content)
+		if err != nil {
+			return err
+		}
+		if !rewritten {
+			continue
+		}
+		for _, duplicate := range plan.Duplicates {
+			c.notef("removed %s", duplicate)
+		}
+		c.notef("rewrote %s: normalized %d of %d entries and removed %s", source, plan.Changed, plan.Entries, plural(len(plan.Duplicates), "duplicate"))
+	}
+	return nil
+}
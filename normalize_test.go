@@ -0,0 +1,153 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIDRCalculator_NormalizeCIDR(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	tests := map[string]string{
+		"10.0.0.0/8":                "10.0.0.0/8",
+		"10/8":                      "10.0.0.0/8",
+		"172.16/12":                 "172.16.0.0/12",
+		"192.168.1/24":              "192.168.1.0/24",
+		"192.168.1.77/24":           "192.168.1.0/24",
+		"10.0.0.0/255.255.0.0":      "10.0.0.0/16",
+		"10.0.0.0/024":              "10.0.0.0/24",
+		"10.1.2.3":                  "10.1.2.3/32",
+		"2001:DB8:0:0::1/32":        "2001:db8::/32",
+		"2001:db8::1":               "2001:db8::1/128",
+		" 2001:0db8:0000::/48 ":     "2001:db8::/48",
+		"10.0.0.0/255.0.255.0":      "",
+		"10.0.0.0/33":               "",
+		"10.0.0.300/24":             "",
+		"not-a-network":             "",
+		"2001:db8::/255.255.255.0":  "",
+		"10.0.0.0/255.255.255.256":  "",
+		"192.168.001.0/24":          "",
+		"10.0.0.0/8/8":              "",
+		"10.0.0.0.0/8":              "",
+		"gg::/16":                   "",
+		"10.0.0.0/-1":               "",
+		"10.0.0.0/":                 "",
+		"/8":                        "",
+		"":                          "",
+		"10..0/8":                   "",
+		"10.0.0.0/255.255.255.255 ": "10.0.0.0/32",
+	}
+	for input, expected := range tests {
+		got, err := calculator.NormalizeCIDR(input)
+		if expected == "" {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %s", input, got)
+			}
+			continue
+		}
+		if err != nil || got != expected {
+			t.Errorf("%q: expected %s, got %s (%v)", input, expected, got, err)
+		}
+	}
+}
+
+func TestCLIHandler_Normalize(t *testing.T) {
+	var stderr strings.Builder
+	handler := NewCLIHandler()
+	handler.stderr = &stderr
+	dir := t.TempDir()
+
+	plan := filepath.Join(dir, "plan.txt")
+	content := "# prod\n10/8    env=prod  # main\n\n2001:DB8::1/32\n10.0.0.0/8 dup\n10.1.2.3"
+	if err := os.WriteFile(plan, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+	if err := handler.Run([]string{"cidr-calc", "--normalize", "-o", plan, plan}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	normalized, _ := os.ReadFile(plan)
+	expected := "# prod\n10.0.0.0/8    env=prod  # main\n\n2001:db8::/32\n# duplicate of line 2: 10.0.0.0/8 dup\n10.1.2.3/32"
+	if string(normalized) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, normalized)
+	}
+	for _, note := range []string{"removed 10.0.0.0/8 at " + plan + " line 5, a duplicate of line 2", "normalized 3 of 4 entries and removed 1 duplicate\n"} {
+		if !strings.Contains(stderr.String(), note) {
+			t.Errorf("expected %q in %q", note, stderr.String())
+		}
+	}
+
+	// Normalizing again changes nothing
+	stderr.Reset()
+	if err := handler.Run([]string{"cidr-calc", "--normalize", "-o", plan, "-f", plan}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again, _ := os.ReadFile(plan); string(again) != expected || !strings.Contains(stderr.String(), "normalized 0 of 3 entries and removed 0 duplicates") {
+		t.Errorf("expected no changes, got %q and:\n%s", stderr.String(), again)
+	}
+
+	// Documents keep their format
+	document := filepath.Join(dir, "plan.yaml")
+	yaml := "version: 1\npools:\n  - cidr: 10/8\nallocations:\n  - cidr: 10.1.0.7/16\n    labels:\n      env: prod\n  - cidr: 10.1.0.0/16\n"
+	if err := os.WriteFile(document, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write document: %v", err)
+	}
+	output := filepath.Join(dir, "normalized.yaml")
+	if err := handler.Run([]string{"cidr-calc", "--normalize", "-o", output, document}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	normalized, _ = os.ReadFile(output)
+	if !strings.Contains(string(normalized), "cidr: 10.0.0.0/8") || strings.Count(string(normalized), "10.1.0.0/16") != 1 || !strings.Contains(stderr.String(), "allocations range 2, a duplicate of range 1") {
+		t.Errorf("unexpected document %q:\n%s", stderr.String(), normalized)
+	}
+
+	// Nothing is written when an entry is not a network
+	if err := os.WriteFile(plan, []byte("10.0.0.0/8\n10.0.0.0/33\n"), 0644); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+	err := handler.Run([]string{"cidr-calc", "--normalize", "-o", plan, plan})
+	if err == nil || !strings.HasPrefix(err.Error(), plan+" line 2: ") {
+		t.Errorf("expected an error naming line 2, got %v", err)
+	}
+	if unchanged, _ := os.ReadFile(plan); string(unchanged) != "10.0.0.0/8\n10.0.0.0/33\n" {
+		t.Errorf("expected the plan to be left alone, got:\n%s", unchanged)
+	}
+
+	for _, args := range [][]string{
+		{"--normalize"},
+		{"--normalize", "a.txt", "b.txt"},
+		{"--normalize", "--format", "json", plan},
+	} {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestCIDRCalculator_NormalizePlanDocument_KeepsContext(t *testing.T) {
+	document := &PlanDocument{Version: planDocumentVersion, Allocations: []PlanRange{
+		{CIDR: "10.0.0.0/24", Annotation: "web"},
+		{CIDR: "10.0.0.7/24", Annotation: "legacy vlan 12", Comment: "old"},
+		{CIDR: "10.0.0.0/24", Annotation: "web"},
+	}}
+	plan, err := NewCIDRCalculator().NormalizePlanDocument("plan.yaml", document)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Duplicates) != 2 || len(document.Allocations) != 1 {
+		t.Fatalf("expected one range and two duplicates, got %+v", document.Allocations)
+	}
+	if comment := document.Allocations[0].Comment; comment != "duplicate 10.0.0.0/24 legacy vlan 12 # old" {
+		t.Errorf("unexpected comment %q", comment)
+	}
+}
+
+func TestCIDRCalculator_NormalizePlanFile_Include(t *testing.T) {
+	plan, err := NewCIDRCalculator().NormalizePlanFile("plan.txt", []byte("10/8\n!include sites/*.txt # sites\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.Content != "10.0.0.0/8\n!include sites/*.txt # sites\n" || plan.Entries != 1 {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+}
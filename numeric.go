@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+)
+
+// numericRow is an address written as an unsigned integer in decimal and hex
+type numericRow struct {
+	Label   string
+	Decimal string
+	Hex     string
+}
+
+// numericForms returns an IPv4 address as a 32-bit integer, or an IPv6
+// address as a 128-bit one, in decimal and in zero-padded hex
+func numericForms(ip net.IP) (string, string) {
+	address := []byte(ip.To4())
+	if address == nil {
+		address = []byte(ip.To16())
+	}
+	value := new(big.Int).SetBytes(address)
+	return value.String(), fmt.Sprintf("0x%0*x", len(address)*2, value)
+}
+
+// numericRows returns the network ID, broadcast or last address and usable
+// range as integers
+func (f *OutputFormatter) numericRows(info *NetworkInfo) []numericRow {
+	lastLabel := "Broadcast"
+	if info.IsIPv6() {
+		lastLabel = "Last Address"
+	}
+
+	rows := make([]numericRow, 0, 4)
+	for _, address := range []struct {
+		label string
+		ip    net.IP
+	}{{"Network ID", info.NetworkID}, {lastLabel, info.BroadcastAddr}, {"First Usable", info.FirstUsableIP}, {"Last Usable", info.LastUsableIP}} {
+		decimal, hex := numericForms(address.ip)
+		rows = append(rows, numericRow{Label: address.label, Decimal: decimal, Hex: hex})
+	}
+	return rows
+}
+
+// FormatNumeric formats the integer forms of the addresses for console display
+func (f *OutputFormatter) FormatNumeric(info *NetworkInfo) string {
+	var output strings.Builder
+
+	rows := f.numericRows(info)
+	width := 0
+	for _, row := range rows {
+		if len(row.Decimal) > width {
+			width = len(row.Decimal)
+		}
+	}
+
+	output.WriteString("Numeric Forms (decimal, hex):\n")
+	for _, row := range rows {
+		output.WriteString(fmt.Sprintf("  %-15s %-*s  %s\n", row.Label+":", width, row.Decimal, row.Hex))
+	}
+
+	return output.String()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestNumericForms(t *testing.T) {
+	tests := []struct {
+		ip      string
+		decimal string
+		hex     string
+	}{
+		{"192.168.1.0", "3232235776", "0xc0a80100"},
+		{"0.0.0.1", "1", "0x00000001"},
+		{"255.255.255.255", "4294967295", "0xffffffff"},
+		{"2001:db8::1", "42540766411282592856903984951653826561", "0x20010db8000000000000000000000001"},
+	}
+
+	for _, tt := range tests {
+		decimal, hex := numericForms(net.ParseIP(tt.ip))
+		if decimal != tt.decimal || hex != tt.hex {
+			t.Errorf("%s: expected %s %s, got %s %s", tt.ip, tt.decimal, tt.hex, decimal, hex)
+		}
+	}
+}
+
+func TestOutputFormatter_Numeric(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := &OutputFormatter{Numeric: true}
+
+	info := mustParseCIDR(t, calculator, "10.0.0.0/30")
+	text := formatter.FormatNetworkInfo(info)
+	for _, expected := range []string{
+		"Numeric Forms (decimal, hex):\n",
+		"  Network ID:     167772160  0x0a000000\n",
+		"  Broadcast:      167772163  0x0a000003\n",
+		"  Last Usable:    167772162  0x0a000002\n",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("expected %q in:\n%s", expected, text)
+		}
+	}
+
+	html := formatter.FormatAsHTML(info, nil)
+	if !strings.Contains(html, "<h2>Numeric Forms</h2>") || !strings.Contains(html, "<td>0x0a000001</td>") {
+		t.Error("expected the numeric forms in the HTML report")
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TenantPartition is the super-block of one tenant: the whole block is kept
+// for the tenant, and the assigned block at its start is handed out now. The
+// rest of the super-block is room for the tenant to grow into.
+type TenantPartition struct {
+	Tenant     string
+	SuperBlock *NetworkInfo
+	Assigned   *NetworkInfo
+}
+
+// PoolPartition divides an IPv4 pool among tenants
+type PoolPartition struct {
+	Pool     *NetworkInfo
+	Tenants  []TenantPartition
+	Headroom []string // free blocks of the pool outside every super-block
+	Growth   int      // how often each tenant can double within its super-block
+}
+
+// PartitionRules are the headroom rules of a partition. Min is the longest
+// prefix a tenant may be assigned; HeadroomPercent of the pool stays outside
+// every super-block for future tenants; Growth doublings of every assigned
+// block fit in its super-block.
+type PartitionRules struct {
+	Min             int
+	HeadroomPercent int
+	Growth          int
+}
+
+// String describes the rules, e.g. "a /18 each, 2 growth steps and 10% headroom"
+func (r PartitionRules) String() string {
+	var parts []string
+	if r.Min > 0 {
+		parts = append(parts, fmt.Sprintf("a /%d each", r.Min))
+	}
+	if r.Growth > 0 {
+		parts = append(parts, fmt.Sprintf("%d growth steps", r.Growth))
+	}
+	if r.HeadroomPercent > 0 {
+		parts = append(parts, fmt.Sprintf("%d%% headroom", r.HeadroomPercent))
+	}
+	if len(parts) == 0 {
+		return "no headroom rules"
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
+
+// PartitionPool gives every tenant an equal, aligned super-block of the pool,
+// the largest size that leaves the headroom free. Because the super-blocks are
+// aligned and disjoint, tenants never overlap however they grow inside them.
+func (c *CIDRCalculator) PartitionPool(pool *NetworkInfo, tenants []string, rules PartitionRules) (*PoolPartition, error) {
+	if pool.IsIPv6() {
+		return nil, fmt.Errorf("partitioning supports IPv4 pools only")
+	}
+	if len(tenants) == 0 {
+		return nil, fmt.Errorf("no tenants to partition %s among", pool.CIDR())
+	}
+	if rules.HeadroomPercent < 0 || rules.HeadroomPercent >= 100 {
+		return nil, fmt.Errorf("headroom must be between 0 and 99 percent, got %d", rules.HeadroomPercent)
+	}
+
+	// The budget is the share of the pool tenants may take
+	total := uint64(1) << uint(32-pool.PrefixLength)
+	budget := total * uint64(100-rules.HeadroomPercent) / 100
+
+	superPrefix := pool.PrefixLength
+	for superPrefix <= 32 && uint64(len(tenants))<<uint(32-superPrefix) > budget {
+		superPrefix++
+	}
+	assignedPrefix := superPrefix + rules.Growth
+	if superPrefix > 32 || assignedPrefix > 32 || (rules.Min > 0 && assignedPrefix > rules.Min) {
+		minPrefix := rules.Min
+		if minPrefix == 0 {
+			minPrefix = 32
+		}
+		needed := uint64(len(tenants)) << uint(32-minPrefix+rules.Growth)
+		return nil, fmt.Errorf("%s cannot hold %d tenants with %s: that needs %d addresses, %d are available",
+			pool.CIDR(), len(tenants), rules, needed, budget)
+	}
+
+	partition := &PoolPartition{Pool: pool, Growth: rules.Growth}
+	start := uint64(ipv4ToUint32(pool.NetworkID))
+	superSize := uint64(1) << uint(32-superPrefix)
+	used := make([]*NetworkInfo, 0, len(tenants))
+	for i, tenant := range tenants {
+		address := uint32ToIPv4(uint32(start + uint64(i)*superSize))
+		superBlock, err := c.ParseCIDR(fmt.Sprintf("%s/%d", address, superPrefix))
+		if err != nil {
+			return nil, err
+		}
+		assigned, err := c.ParseCIDR(fmt.Sprintf("%s/%d", address, assignedPrefix))
+		if err != nil {
+			return nil, err
+		}
+		partition.Tenants = append(partition.Tenants, TenantPartition{Tenant: tenant, SuperBlock: superBlock, Assigned: assigned})
+		used = append(used, superBlock)
+	}
+	partition.Headroom = c.FreeBlocks(pool, used)
+
+	return partition, nil
+}
+
+// tenantNames returns the names given with --names, or tenant-01 to
+// tenant-NN for --tenants
+func tenantNames(count int, names string) ([]string, error) {
+	if names != "" {
+		list := strings.Split(names, ",")
+		seen := make(map[string]bool)
+		for i, name := range list {
+			list[i] = strings.TrimSpace(name)
+			if list[i] == "" || strings.ContainsAny(list[i], " \t#") {
+				return nil, fmt.Errorf("invalid tenant name %q", name)
+			}
+			if seen[list[i]] {
+				return nil, fmt.Errorf("tenant %s is listed twice", list[i])
+			}
+			seen[list[i]] = true
+		}
+		if count > 0 && count != len(list) {
+			return nil, fmt.Errorf("--tenants %d does not match the %d --names", count, len(list))
+		}
+		return list, nil
+	}
+
+	if count <= 0 {
+		return nil, fmt.Errorf("partition requires --tenants or --names")
+	}
+	width := len(strconv.Itoa(count))
+	if width < 2 {
+		width = 2
+	}
+	list := make([]string, count)
+	for i := range list {
+		list[i] = fmt.Sprintf("tenant-%0*d", width, i+1)
+	}
+	return list, nil
+}
+
+// FormatPartition renders the super-blocks, assigned blocks and headroom as text
+func (f *OutputFormatter) FormatPartition(partition *PoolPartition) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Partition of %s among %d tenants:\n", partition.Pool.CIDR(), len(partition.Tenants)))
+	output.WriteString(fmt.Sprintf("  %-16s %-18s %-18s %s\n", "Tenant", "Super-block", "Assigned", "Addresses"))
+	for _, tenant := range partition.Tenants {
+		output.WriteString(fmt.Sprintf("  %-16s %-18s %-18s %d\n", tenant.Tenant, tenant.SuperBlock.CIDR(),
+			tenant.Assigned.CIDR(), uint64(1)<<uint(32-tenant.Assigned.PrefixLength)))
+	}
+
+	if partition.Growth > 0 {
+		output.WriteString(fmt.Sprintf("\n  Each tenant can grow %d times by doubling within its super-block.\n", partition.Growth))
+	}
+
+	output.WriteString("\nHeadroom:\n")
+	if len(partition.Headroom) == 0 {
+		output.WriteString("  none\n")
+	}
+	for _, block := range partition.Headroom {
+		output.WriteString(fmt.Sprintf("  %s\n", block))
+	}
+
+	return output.String()
+}
+
+// jsonPartition is the assignment map for provisioning tools
+type jsonPartition struct {
+	Pool     string            `json:"pool"`
+	Tenants  []jsonTenantBlock `json:"tenants"`
+	Headroom []string          `json:"headroom"`
+}
+
+// jsonTenantBlock is the assignment of one tenant
+type jsonTenantBlock struct {
+	Tenant     string `json:"tenant"`
+	SuperBlock string `json:"superBlock"`
+	Assigned   string `json:"assigned"`
+}
+
+// FormatPartitionAsJSON renders the partition as an assignment map
+func (f *OutputFormatter) FormatPartitionAsJSON(partition *PoolPartition) (string, error) {
+	document := jsonPartition{Pool: partition.Pool.CIDR(), Headroom: partition.Headroom}
+	if document.Headroom == nil {
+		document.Headroom = []string{}
+	}
+	for _, tenant := range partition.Tenants {
+		document.Tenants = append(document.Tenants, jsonTenantBlock{
+			Tenant:     tenant.Tenant,
+			SuperBlock: tenant.SuperBlock.CIDR(),
+			Assigned:   tenant.Assigned.CIDR(),
+		})
+	}
+
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %v", err)
+	}
+	return string(content) + "\n", nil
+}
+
+// FormatPartitionAsCSV renders one row per tenant
+func (f *OutputFormatter) FormatPartitionAsCSV(partition *PoolPartition) (string, error) {
+	var output strings.Builder
+	writer := csv.NewWriter(&output)
+
+	if err := writer.Write([]string{"Tenant", "Super-block", "Assigned"}); err != nil {
+		return "", fmt.Errorf("failed to write CSV header: %v", err)
+	}
+	for _, tenant := range partition.Tenants {
+		if err := writer.Write([]string{tenant.Tenant, tenant.SuperBlock.CIDR(), tenant.Assigned.CIDR()}); err != nil {
+			return "", fmt.Errorf("failed to write CSV rows: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %v", err)
+	}
+	return output.String(), nil
+}
+
+// runPartition implements the partition subcommand
+func (c *CLIHandler) runPartition(args []string) error {
+	flagSet := flag.NewFlagSet("partition", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var names, minPrefix, format, outputFile string
+	var count int
+	var rules PartitionRules
+	flagSet.IntVar(&count, "tenants", 0, "Number of tenants, named tenant-01 and up")
+	flagSet.StringVar(&names, "names", "", "Comma-separated tenant names")
+	flagSet.StringVar(&minPrefix, "min", "", "Smallest block a tenant may be assigned, e.g. /18")
+	flagSet.IntVar(&rules.HeadroomPercent, "headroom", 0, "Percent of the pool kept free for future tenants")
+	flagSet.IntVar(&rules.Growth, "growth", 0, "Doublings each tenant can grow by within its super-block")
+	flagSet.StringVar(&format, "format", FormatText, "Output format: text, json or csv")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept the pool anywhere among the flags
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("partition takes a single pool CIDR, got %d", len(positional))
+	}
+
+	pool, err := c.calculator.ParseCIDR(positional[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse pool %s: %v", positional[0], err)
+	}
+	tenants, err := tenantNames(count, names)
+	if err != nil {
+		return err
+	}
+	if minPrefix != "" {
+		if rules.Min, err = strconv.Atoi(strings.TrimPrefix(minPrefix, "/")); err != nil || rules.Min < pool.PrefixLength || rules.Min > 32 {
+			return fmt.Errorf("invalid --min %s: expected a prefix length between /%d and /32", minPrefix, pool.PrefixLength)
+		}
+	}
+	if rules.Growth < 0 || rules.Growth > 32 {
+		return fmt.Errorf("--growth must be between 0 and 32, got %d", rules.Growth)
+	}
+
+	partition, err := c.calculator.PartitionPool(pool, tenants, rules)
+	if err != nil {
+		return err
+	}
+
+	var content string
+	switch format {
+	case FormatText:
+		content = c.formatter.FormatPartition(partition)
+	case FormatJSON:
+		content, err = c.formatter.FormatPartitionAsJSON(partition)
+	case FormatCSV:
+		content, err = c.formatter.FormatPartitionAsCSV(partition)
+	default:
+		return fmt.Errorf("partition supports %s, %s and %s output, not %s", FormatText, FormatJSON, FormatCSV, format)
+	}
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(content, outputFile)
+}
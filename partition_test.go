@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIDRCalculator_PartitionPool(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	pool, _ := calculator.ParseCIDR("10.0.0.0/12")
+
+	tests := []struct {
+		name       string
+		tenants    int
+		rules      PartitionRules
+		superBlock string // of the last tenant
+		assigned   string
+		headroom   []string
+		err        string
+	}{
+		{"equal split", 30, PartitionRules{Min: 18}, "10.14.128.0/17", "10.14.128.0/17", []string{"10.15.0.0/16"}, ""},
+		{"growth", 4, PartitionRules{Growth: 2}, "10.12.0.0/14", "10.12.0.0/16", nil, ""},
+		{"headroom", 3, PartitionRules{HeadroomPercent: 25}, "10.8.0.0/14", "10.8.0.0/14", []string{"10.12.0.0/14"}, ""},
+		{"too small", 30, PartitionRules{Min: 18, Growth: 2}, "", "", nil, "10.0.0.0/12 cannot hold 30 tenants with a /18 each and 2 growth steps: that needs 1966080 addresses"},
+		{"bad headroom", 2, PartitionRules{HeadroomPercent: 100}, "", "", nil, "headroom must be between 0 and 99 percent"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, _ := tenantNames(tt.tenants, "")
+			partition, err := calculator.PartitionPool(pool, names, tt.rules)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			last := partition.Tenants[len(partition.Tenants)-1]
+			if last.SuperBlock.CIDR() != tt.superBlock || last.Assigned.CIDR() != tt.assigned {
+				t.Errorf("expected %s assigned %s, got %s assigned %s", tt.superBlock, tt.assigned, last.SuperBlock.CIDR(), last.Assigned.CIDR())
+			}
+			if strings.Join(partition.Headroom, ",") != strings.Join(tt.headroom, ",") {
+				t.Errorf("expected headroom %v, got %v", tt.headroom, partition.Headroom)
+			}
+			for i, tenant := range partition.Tenants {
+				for _, other := range partition.Tenants[i+1:] {
+					if tenant.SuperBlock.Overlaps(other.SuperBlock) {
+						t.Errorf("%s overlaps %s", tenant.SuperBlock.CIDR(), other.SuperBlock.CIDR())
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTenantNames(t *testing.T) {
+	names, err := tenantNames(0, "red, green,blue")
+	if err != nil || strings.Join(names, ",") != "red,green,blue" {
+		t.Errorf("unexpected names %v (%v)", names, err)
+	}
+	if names, _ := tenantNames(120, ""); names[0] != "tenant-001" || names[119] != "tenant-120" {
+		t.Errorf("unexpected generated names %s..%s", names[0], names[119])
+	}
+	for _, tt := range []struct {
+		count int
+		names string
+		err   string
+	}{
+		{0, "", "requires --tenants or --names"},
+		{0, "a,a", "tenant a is listed twice"},
+		{3, "a,b", "--tenants 3 does not match the 2 --names"},
+	} {
+		if _, err := tenantNames(tt.count, tt.names); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%d %q: expected error %q, got %v", tt.count, tt.names, tt.err, err)
+		}
+	}
+}
+
+func TestCLIHandler_Partition(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	output := filepath.Join(t.TempDir(), "tenants.json")
+
+	if err := handler.Run([]string{"cidr-calc", "partition", "10.0.0.0/16", "--names", "red,blue", "--growth", "1", "--format", "json", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	var document jsonPartition
+	if err := json.Unmarshal(content, &document); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, content)
+	}
+	if len(document.Tenants) != 2 || document.Tenants[1] != (jsonTenantBlock{Tenant: "blue", SuperBlock: "10.0.128.0/17", Assigned: "10.0.128.0/18"}) {
+		t.Errorf("unexpected assignment map %+v", document)
+	}
+
+	pool, _ := handler.calculator.ParseCIDR("10.0.0.0/16")
+	text := handler.formatter.FormatPartition(&PoolPartition{Pool: pool})
+	if !strings.Contains(text, "Headroom:\n  none\n") {
+		t.Errorf("unexpected text:\n%s", text)
+	}
+
+	for _, tt := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--tenants", "2"}, "partition takes a single pool CIDR, got 0"},
+		{[]string{"10.0.0.0/16", "--tenants", "2", "--min", "/8"}, "invalid --min /8"},
+		{[]string{"10.0.0.0/16", "--tenants", "2", "--format", "xml"}, "partition supports text, json and csv output, not xml"},
+	} {
+		if err := handler.Run(append([]string{"cidr-calc", "partition"}, tt.args...)); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.err, err)
+		}
+	}
+}
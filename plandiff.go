@@ -0,0 +1,324 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PlanChangeKind classifies a difference between two versions of a plan
+type PlanChangeKind string
+
+// Kinds of plan changes reported by the diff engine
+const (
+	ChangeAdded   PlanChangeKind = "added"
+	ChangeRemoved PlanChangeKind = "removed"
+	ChangeResized PlanChangeKind = "resized"
+	ChangeMoved   PlanChangeKind = "moved"
+	// An entry whose annotation, tags or comment changed
+	ChangeAnnotated PlanChangeKind = "annotated"
+)
+
+// PlanChange is a single semantic difference between two plans
+type PlanChange struct {
+	Kind         PlanChangeKind
+	CIDR         string
+	PreviousCIDR string
+	File         string
+	PreviousFile string
+	Line         int
+
+	// Context is the annotation, tags and comment of the entry, the ones it
+	// had before when they changed
+	Context         string
+	PreviousContext string
+}
+
+// PlanConflict records an added network that overlaps another network of the new plan
+type PlanConflict struct {
+	CIDR        string
+	File        string
+	Line        int
+	Overlapping string
+	OtherFile   string
+	OtherLine   int
+}
+
+// PlanDiff is the semantic difference between a base and a head plan
+type PlanDiff struct {
+	Changes   []PlanChange
+	Conflicts []PlanConflict
+	Invalid   []BatchEntry
+}
+
+// planNetwork is a parsed plan entry
+type planNetwork struct {
+	entry BatchEntry
+	info  *NetworkInfo
+}
+
+// PlanDiffer compares plan entries by network rather than by text
+type PlanDiffer struct {
+	calculator *CIDRCalculator
+}
+
+// NewPlanDiffer creates a new plan diff engine
+func NewPlanDiffer() *PlanDiffer {
+	return &PlanDiffer{calculator: NewCIDRCalculator()}
+}
+
+// Diff compares two plans and reports added, removed, resized and moved networks,
+// and networks whose annotation, tags or comment changed, along with overlaps
+// introduced by the head plan. Every change carries the context of its entry.
+func (d *PlanDiffer) Diff(base, head []BatchEntry) *PlanDiff {
+	diff := &PlanDiff{}
+
+	baseNetworks, _ := d.parseEntries(base)
+	headNetworks, invalid := d.parseEntries(head)
+	diff.Invalid = invalid
+
+	baseByCIDR := indexPlanNetworks(baseNetworks)
+	headByCIDR := indexPlanNetworks(headNetworks)
+
+	var added, removed []planNetwork
+	for _, network := range headNetworks {
+		previous, ok := baseByCIDR[network.info.CIDR()]
+		if !ok {
+			added = append(added, network)
+			continue
+		}
+		context, previousContext := network.entry.Context(), previous.entry.Context()
+		if context == previousContext {
+			previousContext = ""
+		}
+		switch {
+		case previous.entry.Source != network.entry.Source:
+			diff.Changes = append(diff.Changes, PlanChange{
+				Kind:            ChangeMoved,
+				CIDR:            network.info.CIDR(),
+				File:            network.entry.Source,
+				PreviousFile:    previous.entry.Source,
+				Line:            network.entry.Line,
+				Context:         context,
+				PreviousContext: previousContext,
+			})
+		case context != previous.entry.Context():
+			diff.Changes = append(diff.Changes, PlanChange{
+				Kind:            ChangeAnnotated,
+				CIDR:            network.info.CIDR(),
+				File:            network.entry.Source,
+				Line:            network.entry.Line,
+				Context:         context,
+				PreviousContext: previousContext,
+			})
+		}
+	}
+	for _, network := range baseNetworks {
+		if _, ok := headByCIDR[network.info.CIDR()]; !ok {
+			removed = append(removed, network)
+		}
+	}
+
+	// A removal and an addition of overlapping blocks in the same file is a resize
+	matched := make(map[int]bool)
+	for _, addition := range added {
+		change := PlanChange{Kind: ChangeAdded, CIDR: addition.info.CIDR(), File: addition.entry.Source, Line: addition.entry.Line, Context: addition.entry.Context()}
+
+		for i, removal := range removed {
+			if !matched[i] && removal.entry.Source == addition.entry.Source && removal.info.Overlaps(addition.info) {
+				matched[i] = true
+				change.Kind = ChangeResized
+				change.PreviousCIDR = removal.info.CIDR()
+				if previous := removal.entry.Context(); previous != change.Context {
+					change.PreviousContext = previous
+				}
+				break
+			}
+		}
+
+		diff.Changes = append(diff.Changes, change)
+	}
+	for i, removal := range removed {
+		if !matched[i] {
+			diff.Changes = append(diff.Changes, PlanChange{Kind: ChangeRemoved, CIDR: removal.info.CIDR(), File: removal.entry.Source, Line: removal.entry.Line, Context: removal.entry.Context()})
+		}
+	}
+
+	// Newly added or resized networks must not collide with the rest of the head plan
+	reported := make(map[[2]string]bool)
+	for _, change := range diff.Changes {
+		if change.Kind != ChangeAdded && change.Kind != ChangeResized {
+			continue
+		}
+		network := headByCIDR[change.CIDR]
+		for _, other := range headNetworks {
+			if other.entry.Source == network.entry.Source && other.entry.Line == network.entry.Line || other.info.CIDR() == network.info.CIDR() {
+				continue
+			}
+			pair := [2]string{network.info.CIDR(), other.info.CIDR()}
+			if pair[0] > pair[1] {
+				pair[0], pair[1] = pair[1], pair[0]
+			}
+			if network.info.Overlaps(other.info) && !reported[pair] {
+				reported[pair] = true
+				diff.Conflicts = append(diff.Conflicts, PlanConflict{
+					CIDR:        change.CIDR,
+					File:        network.entry.Source,
+					Line:        network.entry.Line,
+					Overlapping: other.info.CIDR(),
+					OtherFile:   other.entry.Source,
+					OtherLine:   other.entry.Line,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(diff.Changes, func(i, j int) bool {
+		if diff.Changes[i].File != diff.Changes[j].File {
+			return diff.Changes[i].File < diff.Changes[j].File
+		}
+		return diff.Changes[i].Line < diff.Changes[j].Line
+	})
+
+	return diff
+}
+
+// parseEntries parses plan entries, separating invalid CIDRs
+func (d *PlanDiffer) parseEntries(entries []BatchEntry) ([]planNetwork, []BatchEntry) {
+	networks := make([]planNetwork, 0, len(entries))
+	var invalid []BatchEntry
+
+	for _, entry := range entries {
+		info, err := d.calculator.ParseCIDR(entry.CIDR)
+		if err != nil {
+			invalid = append(invalid, entry)
+			continue
+		}
+		networks = append(networks, planNetwork{entry: entry, info: info})
+	}
+
+	return networks, invalid
+}
+
+// indexPlanNetworks maps canonical CIDRs to their first occurrence
+func indexPlanNetworks(networks []planNetwork) map[string]planNetwork {
+	index := make(map[string]planNetwork, len(networks))
+	for _, network := range networks {
+		if _, exists := index[network.info.CIDR()]; !exists {
+			index[network.info.CIDR()] = network
+		}
+	}
+	return index
+}
+
+// IsEmpty reports whether the diff found nothing to report
+func (p *PlanDiff) IsEmpty() bool {
+	return len(p.Changes) == 0 && len(p.Conflicts) == 0 && len(p.Invalid) == 0
+}
+
+// Count returns the number of changes of the given kind
+func (p *PlanDiff) Count(kind PlanChangeKind) int {
+	count := 0
+	for _, change := range p.Changes {
+		if change.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// Findings converts conflicts and invalid entries of the head plan into findings
+func (p *PlanDiff) Findings() []Finding {
+	var findings []Finding
+
+	for _, conflict := range p.Conflicts {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Rule:     RuleOverlap,
+			Message:  fmt.Sprintf("%s overlaps %s at %s:%d", conflict.CIDR, conflict.Overlapping, conflict.OtherFile, conflict.OtherLine),
+			File:     conflict.File,
+			Line:     conflict.Line,
+			CIDR:     conflict.CIDR,
+		})
+	}
+
+	for _, entry := range p.Invalid {
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Rule:     RuleInvalidCIDR,
+			Message:  fmt.Sprintf("%s is not a valid IPv4 CIDR", entry.CIDR),
+			File:     entry.Source,
+			Line:     entry.Line,
+			CIDR:     entry.CIDR,
+		})
+	}
+
+	return findings
+}
+
+// FormatPlanDiff renders a plan diff as a plain text report
+func (f *OutputFormatter) FormatPlanDiff(title string, diff *PlanDiff) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Plan Changes (%s):\n", title))
+	if diff.IsEmpty() {
+		output.WriteString("  No CIDR changes\n")
+		return output.String()
+	}
+
+	for _, change := range diff.Changes {
+		marker, subject := "", change.CIDR
+		var details []string
+		switch change.Kind {
+		case ChangeAdded:
+			marker = "+"
+		case ChangeRemoved:
+			marker = "-"
+		case ChangeResized:
+			marker, subject = "~", change.PreviousCIDR+" -> "+change.CIDR
+			details = append(details, "resized")
+		case ChangeMoved:
+			marker = ">"
+			details = append(details, "moved from "+change.PreviousFile)
+		case ChangeAnnotated:
+			marker = "*"
+		}
+		switch {
+		case change.PreviousContext != "":
+			details = append(details, "was: "+change.PreviousContext)
+		case change.Kind == ChangeAnnotated:
+			details = append(details, "annotated")
+		}
+
+		// The context of the entry tells reviewers what the network is for
+		line := fmt.Sprintf("  %s %-34s %s:%d", marker, subject, change.File, change.Line)
+		if change.Context != "" {
+			line += "  " + change.Context
+		}
+		if len(details) > 0 {
+			line += " (" + strings.Join(details, ", ") + ")"
+		}
+		output.WriteString(line + "\n")
+	}
+
+	if len(diff.Conflicts) > 0 {
+		output.WriteString("\nConflicts:\n")
+		for _, conflict := range diff.Conflicts {
+			output.WriteString(fmt.Sprintf("  ! %s (%s:%d) overlaps %s (%s:%d)\n",
+				conflict.CIDR, conflict.File, conflict.Line, conflict.Overlapping, conflict.OtherFile, conflict.OtherLine))
+		}
+	}
+
+	if len(diff.Invalid) > 0 {
+		output.WriteString("\nInvalid Entries:\n")
+		for _, entry := range diff.Invalid {
+			output.WriteString(fmt.Sprintf("  ? %-34s %s:%d\n", entry.CIDR, entry.Source, entry.Line))
+		}
+	}
+
+	output.WriteString(fmt.Sprintf("\nSummary: %d added, %d removed, %d resized, %d moved, %d annotated, %d conflicts, %d invalid\n",
+		diff.Count(ChangeAdded), diff.Count(ChangeRemoved), diff.Count(ChangeResized), diff.Count(ChangeMoved),
+		diff.Count(ChangeAnnotated), len(diff.Conflicts), len(diff.Invalid)))
+
+	return output.String()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanDiffer_Diff(t *testing.T) {
+	base := []BatchEntry{
+		{Source: "prod.txt", Line: 1, CIDR: "10.0.0.0/16"},
+		{Source: "prod.txt", Line: 2, CIDR: "10.1.0.0/24"},
+		{Source: "prod.txt", Line: 3, CIDR: "10.2.0.0/24"},
+		{Source: "lab.txt", Line: 1, CIDR: "172.16.0.0/22"},
+	}
+	head := []BatchEntry{
+		{Source: "prod.txt", Line: 1, CIDR: "10.0.0.0/16"},
+		{Source: "prod.txt", Line: 2, CIDR: "10.1.0.0/23"},
+		{Source: "prod.txt", Line: 3, CIDR: "10.3.0.0/24"},
+		{Source: "prod.txt", Line: 4, CIDR: "172.16.0.0/22"},
+		{Source: "prod.txt", Line: 5, CIDR: "10.0.5.0/24"},
+		{Source: "prod.txt", Line: 6, CIDR: "10.9.0.0/99"},
+	}
+
+	diff := NewPlanDiffer().Diff(base, head)
+
+	expected := []PlanChange{
+		{Kind: ChangeResized, CIDR: "10.1.0.0/23", PreviousCIDR: "10.1.0.0/24", File: "prod.txt", Line: 2},
+		{Kind: ChangeAdded, CIDR: "10.3.0.0/24", File: "prod.txt", Line: 3},
+		{Kind: ChangeRemoved, CIDR: "10.2.0.0/24", File: "prod.txt", Line: 3},
+		{Kind: ChangeMoved, CIDR: "172.16.0.0/22", File: "prod.txt", PreviousFile: "lab.txt", Line: 4},
+		{Kind: ChangeAdded, CIDR: "10.0.5.0/24", File: "prod.txt", Line: 5},
+	}
+
+	if len(diff.Changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d: %+v", len(expected), len(diff.Changes), diff.Changes)
+	}
+	for i, exp := range expected {
+		if diff.Changes[i] != exp {
+			t.Errorf("change %d: expected %+v, got %+v", i, exp, diff.Changes[i])
+		}
+	}
+
+	if len(diff.Conflicts) != 1 || diff.Conflicts[0].CIDR != "10.0.5.0/24" || diff.Conflicts[0].Overlapping != "10.0.0.0/16" {
+		t.Errorf("unexpected conflicts: %+v", diff.Conflicts)
+	}
+
+	if len(diff.Invalid) != 1 || diff.Invalid[0].Line != 6 {
+		t.Errorf("unexpected invalid entries: %+v", diff.Invalid)
+	}
+}
+
+func TestPlanDiffer_Diff_HostBitsAreNormalized(t *testing.T) {
+	base := []BatchEntry{{Source: "a.txt", Line: 1, CIDR: "192.168.1.0/24"}}
+	head := []BatchEntry{{Source: "a.txt", Line: 1, CIDR: "192.168.1.77/24"}}
+
+	diff := NewPlanDiffer().Diff(base, head)
+	if !diff.IsEmpty() {
+		t.Errorf("expected no changes for equivalent CIDRs, got %+v", diff.Changes)
+	}
+}
+
+func TestPlanDiffer_Diff_ConflictsReportedOnce(t *testing.T) {
+	head := []BatchEntry{
+		{Source: "a.txt", Line: 1, CIDR: "10.0.0.0/24"},
+		{Source: "a.txt", Line: 2, CIDR: "10.0.0.0/25"},
+	}
+
+	diff := NewPlanDiffer().Diff(nil, head)
+	if len(diff.Conflicts) != 1 {
+		t.Errorf("expected a single conflict for one overlapping pair, got %+v", diff.Conflicts)
+	}
+}
+
+func TestPlanDiff_Findings(t *testing.T) {
+	diff := &PlanDiff{
+		Conflicts: []PlanConflict{
+			{CIDR: "10.3.0.0/24", File: "prod.txt", Line: 3, Overlapping: "10.0.0.0/8", OtherFile: "core.txt", OtherLine: 1},
+		},
+		Invalid: []BatchEntry{{Source: "prod.txt", Line: 6, CIDR: "10.9.0.0/99"}},
+	}
+
+	findings := diff.Findings()
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if findings[0].Rule != RuleOverlap || findings[0].Line != 3 || !strings.Contains(findings[0].Message, "core.txt:1") {
+		t.Errorf("unexpected conflict finding: %+v", findings[0])
+	}
+	if findings[1].Rule != RuleInvalidCIDR || findings[1].Line != 6 || findings[1].Severity != SeverityError {
+		t.Errorf("unexpected invalid finding: %+v", findings[1])
+	}
+}
+
+func TestOutputFormatter_FormatPlanDiff(t *testing.T) {
+	formatter := NewOutputFormatter()
+
+	empty := formatter.FormatPlanDiff("main..feature", &PlanDiff{})
+	if !strings.Contains(empty, "No CIDR changes") {
+		t.Errorf("expected empty diff message, got %q", empty)
+	}
+
+	diff := &PlanDiff{
+		Changes: []PlanChange{
+			{Kind: ChangeAdded, CIDR: "10.3.0.0/24", File: "prod.txt", Line: 3},
+			{Kind: ChangeResized, CIDR: "10.1.0.0/23", PreviousCIDR: "10.1.0.0/24", File: "prod.txt", Line: 2},
+		},
+		Conflicts: []PlanConflict{
+			{CIDR: "10.3.0.0/24", File: "prod.txt", Line: 3, Overlapping: "10.0.0.0/8", OtherFile: "core.txt", OtherLine: 1},
+		},
+	}
+
+	output := formatter.FormatPlanDiff("main..feature", diff)
+	expected := []string{
+		"Plan Changes (main..feature):",
+		"  + 10.3.0.0/24",
+		"  ~ 10.1.0.0/24 -> 10.1.0.0/23",
+		"prod.txt:2 (resized)",
+		"  ! 10.3.0.0/24 (prod.txt:3) overlaps 10.0.0.0/8 (core.txt:1)",
+		"Summary: 1 added, 0 removed, 1 resized, 0 moved, 0 annotated, 1 conflicts, 0 invalid",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(output, exp) {
+			t.Errorf("expected output to contain %q, got:\n%s", exp, output)
+		}
+	}
+}
+
+func TestPlanDiffer_Diff_Context(t *testing.T) {
+	base := []BatchEntry{
+		{Source: "prod.txt", Line: 1, CIDR: "10.0.0.0/24", Annotation: "web vlan 100"},
+		{Source: "prod.txt", Line: 2, CIDR: "10.0.1.0/24", Annotation: "db", Comment: "primary"},
+		{Source: "prod.txt", Line: 3, CIDR: "10.0.2.0/24", Tags: Tags{"env": "prod"}},
+	}
+	head := []BatchEntry{
+		{Source: "prod.txt", Line: 1, CIDR: "10.0.0.0/24", Annotation: "web vlan 110"},
+		{Source: "prod.txt", Line: 2, CIDR: "10.0.1.0/24", Annotation: "db", Comment: "primary"},
+		{Source: "prod.txt", Line: 3, CIDR: "10.0.2.0/23", Tags: Tags{"env": "prod"}},
+		{Source: "prod.txt", Line: 4, CIDR: "10.0.8.0/24", Annotation: "mgmt", Comment: "new"},
+	}
+
+	diff := NewPlanDiffer().Diff(base, head)
+	expected := []PlanChange{
+		{Kind: ChangeAnnotated, CIDR: "10.0.0.0/24", File: "prod.txt", Line: 1, Context: "web vlan 110", PreviousContext: "web vlan 100"},
+		{Kind: ChangeResized, CIDR: "10.0.2.0/23", PreviousCIDR: "10.0.2.0/24", File: "prod.txt", Line: 3, Context: "env=prod"},
+		{Kind: ChangeAdded, CIDR: "10.0.8.0/24", File: "prod.txt", Line: 4, Context: "mgmt # new"},
+	}
+	if !reflect.DeepEqual(diff.Changes, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, diff.Changes)
+	}
+
+	output := NewOutputFormatter().FormatPlanDiff("main..feature", diff)
+	for _, exp := range []string{
+		"  * 10.0.0.0/24                        prod.txt:1  web vlan 110 (was: web vlan 100)\n",
+		"  + 10.0.8.0/24                        prod.txt:4  mgmt # new\n",
+		"Summary: 1 added, 0 removed, 1 resized, 0 moved, 1 annotated, 0 conflicts, 0 invalid",
+	} {
+		if !strings.Contains(output, exp) {
+			t.Errorf("expected output to contain %q, got:\n%s", exp, output)
+		}
+	}
+}
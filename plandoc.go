@@ -0,0 +1,753 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// planDocumentVersion is the version of the plan document format written;
+// older documents are migrated when they are read, see planMigrations
+const planDocumentVersion = 2
+
+// Plan document formats
+const (
+	DocumentYAML = "yaml"
+	DocumentJSON = "json"
+)
+
+// Sections of a plan document that are read as plan file entries
+const (
+	SectionPools       = "pools"
+	SectionAllocations = "allocations"
+	SectionReserved    = "reserved"
+)
+
+// PlanDocument is the canonical file form of an address plan: its pools, the
+// allocations made from them, and the policies that apply. It is written as
+// YAML or JSON and converts losslessly to and from plan files, so every mode
+// reads it in place of a plan file; they see its allocations.
+type PlanDocument struct {
+	Version     int          `json:"version"`
+	Pools       []PlanRange  `json:"pools"`
+	Allocations []PlanRange  `json:"allocations"`
+	Policies    PlanPolicies `json:"policies"`
+}
+
+// PlanRange is a CIDR with the annotation, labels and comment of its plan file line
+type PlanRange struct {
+	CIDR       string `json:"cidr"`
+	Annotation string `json:"annotation,omitempty"`
+	Labels     Tags   `json:"labels,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+}
+
+// PlanPolicies are the rules a plan is checked against: the ranges that must
+// not be allocated and the address quota of each tenant
+type PlanPolicies struct {
+	Reserved  []PlanRange `json:"reserved"`
+	Quotas    []PlanQuota `json:"quotas"`
+	TenantTag string      `json:"tenantTag,omitempty"` // default "tenant"
+}
+
+// PlanQuota is the address quota of one tenant
+type PlanQuota struct {
+	Tenant  string `json:"tenant"`
+	Quota   uint64 `json:"quota"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// planRangeFromEntry converts a plan file entry
+func planRangeFromEntry(entry BatchEntry) PlanRange {
+	return PlanRange{CIDR: entry.CIDR, Annotation: entry.Annotation, Labels: entry.Tags, Comment: entry.Comment}
+}
+
+// Line returns the range as a plan file line: the CIDR, the annotation, the
+// labels sorted by key and the comment
+func (r PlanRange) Line() string {
+	line := r.CIDR
+	if r.Annotation != "" {
+		line += " " + r.Annotation
+	}
+	if len(r.Labels) > 0 {
+		line += " " + r.Labels.String()
+	}
+	if r.Comment != "" {
+		line += " # " + r.Comment
+	}
+	return line
+}
+
+// Section returns the ranges of a section
+func (d *PlanDocument) Section(section string) ([]PlanRange, error) {
+	switch section {
+	case SectionPools:
+		return d.Pools, nil
+	case SectionAllocations:
+		return d.Allocations, nil
+	case SectionReserved:
+		return d.Policies.Reserved, nil
+	}
+	return nil, fmt.Errorf("unknown plan document section %q (available: %s, %s, %s)", section, SectionPools, SectionAllocations, SectionReserved)
+}
+
+// Entries returns the ranges of a section as plan file entries, with Line the
+// position of the range in its section; see documentLines for the lines of a
+// YAML document.
+func (d *PlanDocument) Entries(source, section string) ([]BatchEntry, error) {
+	ranges, err := d.Section(section)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]BatchEntry, len(ranges))
+	for i, planRange := range ranges {
+		entries[i] = BatchEntry{Source: source, Line: i + 1, CIDR: planRange.CIDR, Tags: planRange.Labels, Comment: planRange.Comment, Annotation: planRange.Annotation}
+	}
+	return entries, nil
+}
+
+// sectionPaths are the paths of the sections in a decoded plan document
+var sectionPaths = map[string]string{
+	SectionPools:       "pools",
+	SectionAllocations: "allocations",
+	SectionReserved:    "policies.reserved",
+}
+
+// documentLines points the entries of a section of a YAML document at the
+// line each range starts on. JSON documents keep the positions.
+func documentLines(source string, content []byte, section string, entries []BatchEntry) {
+	if bytes.HasPrefix(bytes.TrimSpace(bytes.TrimPrefix(content, []byte("\ufeff"))), []byte("{")) {
+		return
+	}
+	_, positions, err := parseYAMLPositions(source, string(content))
+	if err != nil {
+		return
+	}
+	for i := range entries {
+		if line, ok := positions[yamlPath(sectionPaths[section], strconv.Itoa(i))]; ok {
+			entries[i].Line = line
+		}
+	}
+}
+
+// PlanText returns the ranges of a section as a plan file
+func (d *PlanDocument) PlanText(section string) (string, error) {
+	ranges, err := d.Section(section)
+	if err != nil {
+		return "", err
+	}
+	var output strings.Builder
+	for _, planRange := range ranges {
+		output.WriteString(planRange.Line() + "\n")
+	}
+	return output.String(), nil
+}
+
+// QuotaText returns the quotas as a quota file
+func (d *PlanDocument) QuotaText() string {
+	var output strings.Builder
+	for _, quota := range d.Policies.Quotas {
+		line := fmt.Sprintf("%s %d", quota.Tenant, quota.Quota)
+		if quota.Comment != "" {
+			line += " # " + quota.Comment
+		}
+		output.WriteString(line + "\n")
+	}
+	return output.String()
+}
+
+// QuotaPolicy returns the quotas as a policy grouping allocations by the
+// tenant tag of the document
+func (d *PlanDocument) QuotaPolicy() *QuotaPolicy {
+	policy := &QuotaPolicy{Key: d.Policies.TenantTag, Warn: defaultQuotaWarn, quotas: make(map[string]uint64), comments: make(map[string]string)}
+	if policy.Key == "" {
+		policy.Key = defaultQuotaKey
+	}
+	for _, quota := range d.Policies.Quotas {
+		policy.quotas[quota.Tenant] = quota.Quota
+		policy.comments[quota.Tenant] = quota.Comment
+		policy.tenants = append(policy.tenants, quota.Tenant)
+	}
+	return policy
+}
+
+// validate checks the document for what plan files cannot hold
+func (d *PlanDocument) validate(source string) error {
+	if d.Version != planDocumentVersion {
+		return fmt.Errorf("%s: unsupported plan document version %d (supported: %d)", source, d.Version, planDocumentVersion)
+	}
+	for _, section := range []string{SectionPools, SectionAllocations, SectionReserved} {
+		ranges, _ := d.Section(section)
+		for i, planRange := range ranges {
+			if planRange.CIDR == "" || strings.ContainsAny(planRange.CIDR, " \t#") {
+				return fmt.Errorf("%s: %s %d has an invalid cidr %q", source, section, i+1, planRange.CIDR)
+			}
+			for key, value := range planRange.Labels {
+				if key == "" || strings.ContainsAny(key, " \t#=") || strings.ContainsAny(value, " \t#") {
+					return fmt.Errorf("%s: %s %d has an invalid label %q: labels cannot contain spaces or #", source, section, i+1, key+"="+value)
+				}
+			}
+			if strings.Contains(planRange.Comment, "\n") {
+				return fmt.Errorf("%s: %s %d has a comment of several lines", source, section, i+1)
+			}
+			if strings.ContainsAny(planRange.Annotation, "#=\n") {
+				return fmt.Errorf("%s: %s %d has an invalid annotation %q: annotations cannot contain #, = or line breaks", source, section, i+1, planRange.Annotation)
+			}
+		}
+	}
+	tenants := make(map[string]bool)
+	for i, quota := range d.Policies.Quotas {
+		if quota.Tenant == "" || strings.ContainsAny(quota.Tenant, " \t#") || quota.Quota == 0 {
+			return fmt.Errorf("%s: quota %d needs a tenant without spaces and a quota of at least 1", source, i+1)
+		}
+		if tenants[quota.Tenant] {
+			return fmt.Errorf("%s: tenant %s already has a quota", source, quota.Tenant)
+		}
+		tenants[quota.Tenant] = true
+	}
+	return nil
+}
+
+// isPlanDocument reports whether content is a plan document rather than a
+// plan file, whose lines start with a CIDR
+func isPlanDocument(content []byte) bool {
+	for _, line := range strings.Split(string(bytes.TrimPrefix(content, []byte("\ufeff"))), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") || line == "---" {
+			return true
+		}
+		key, _, ok := strings.Cut(line, ":")
+		return ok && key == "version"
+	}
+	return false
+}
+
+// documentFormat returns the plan document format a filename's extension
+// calls for, or "" for plan files
+func documentFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return DocumentYAML
+	case ".json":
+		return DocumentJSON
+	}
+	return ""
+}
+
+// planDocumentFormat returns the format a document is written back in: the
+// one its filename calls for, else the one it is written in
+func planDocumentFormat(location string, content []byte) string {
+	if format := documentFormat(location); format != "" {
+		return format
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(bytes.TrimPrefix(content, []byte("\ufeff"))), []byte("{")) {
+		return DocumentJSON
+	}
+	return DocumentYAML
+}
+
+// parsePlanDocument decodes a YAML or JSON plan document, migrating it to
+// the current version
+func parsePlanDocument(source string, content []byte) (*PlanDocument, error) {
+	document, _, err := migratePlanDocument(source, content)
+	return document, err
+}
+
+// decodePlanValue decodes a YAML or JSON document to mappings, sequences and
+// scalar strings. JSON numbers and booleans become their text, as in YAML.
+func decodePlanValue(source string, content []byte) (interface{}, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(content, []byte("\ufeff")))
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		return parseYAML(source, string(content))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", source, err)
+	}
+	var text func(value interface{}) interface{}
+	text = func(value interface{}) interface{} {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			for key, item := range value {
+				value[key] = text(item)
+			}
+		case []interface{}:
+			for i, item := range value {
+				value[i] = text(item)
+			}
+		case json.Number:
+			return value.String()
+		case bool:
+			return strconv.FormatBool(value)
+		}
+		return value
+	}
+	return text(value), nil
+}
+
+// yamlFields checks that a decoded value is a mapping with only known keys
+func yamlFields(source, what string, value interface{}, known ...string) (map[string]interface{}, error) {
+	if value == nil {
+		return map[string]interface{}{}, nil
+	}
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: %s must be a mapping", source, what)
+	}
+	for key := range fields {
+		found := false
+		for _, name := range known {
+			found = found || key == name
+		}
+		if !found {
+			return nil, fmt.Errorf("%s: unknown field %q in %s", source, key, what)
+		}
+	}
+	return fields, nil
+}
+
+// yamlString returns a scalar field, or "" when it is missing
+func yamlString(source, what string, value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s: %s must be a scalar", source, what)
+	}
+	return text, nil
+}
+
+// yamlItems returns the items of a sequence field
+func yamlItems(source, what string, value interface{}) ([]interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: %s must be a sequence", source, what)
+	}
+	return items, nil
+}
+
+// yamlRanges decodes a sequence of ranges
+func yamlRanges(source, section string, value interface{}) ([]PlanRange, error) {
+	items, err := yamlItems(source, section, value)
+	if err != nil {
+		return nil, err
+	}
+	ranges := make([]PlanRange, 0, len(items))
+	for i, item := range items {
+		what := fmt.Sprintf("%s %d", section, i+1)
+		fields, err := yamlFields(source, what, item, "cidr", "annotation", "labels", "comment")
+		if err != nil {
+			return nil, err
+		}
+		var planRange PlanRange
+		if planRange.CIDR, err = yamlString(source, what+" cidr", fields["cidr"]); err != nil {
+			return nil, err
+		}
+		if planRange.Annotation, err = yamlString(source, what+" annotation", fields["annotation"]); err != nil {
+			return nil, err
+		}
+		if planRange.Comment, err = yamlString(source, what+" comment", fields["comment"]); err != nil {
+			return nil, err
+		}
+		labels, ok := fields["labels"].(map[string]interface{})
+		if fields["labels"] != nil && !ok {
+			return nil, fmt.Errorf("%s: %s labels must be a mapping", source, what)
+		}
+		for key, value := range labels {
+			text, err := yamlString(source, what+" label "+key, value)
+			if err != nil {
+				return nil, err
+			}
+			if planRange.Labels == nil {
+				planRange.Labels = make(Tags)
+			}
+			planRange.Labels[key] = text
+		}
+		ranges = append(ranges, planRange)
+	}
+	return ranges, nil
+}
+
+// fromValue fills the document from its decoded value
+func (d *PlanDocument) fromValue(source string, value interface{}) error {
+	fields, err := yamlFields(source, "the document", value, "version", "pools", "allocations", "policies")
+	if err != nil {
+		return err
+	}
+
+	version, err := yamlString(source, "version", fields["version"])
+	if err != nil {
+		return err
+	}
+	if d.Version, err = strconv.Atoi(version); err != nil {
+		return fmt.Errorf("%s: version must be a number, got %q", source, version)
+	}
+	if d.Pools, err = yamlRanges(source, SectionPools, fields["pools"]); err != nil {
+		return err
+	}
+	if d.Allocations, err = yamlRanges(source, SectionAllocations, fields["allocations"]); err != nil {
+		return err
+	}
+
+	policies, err := yamlFields(source, "policies", fields["policies"], "reserved", "quotas", "tenantTag")
+	if err != nil {
+		return err
+	}
+	if d.Policies.Reserved, err = yamlRanges(source, SectionReserved, policies["reserved"]); err != nil {
+		return err
+	}
+	if d.Policies.TenantTag, err = yamlString(source, "tenantTag", policies["tenantTag"]); err != nil {
+		return err
+	}
+	quotas, err := yamlItems(source, "quotas", policies["quotas"])
+	if err != nil {
+		return err
+	}
+	for i, item := range quotas {
+		what := fmt.Sprintf("quota %d", i+1)
+		quotaFields, err := yamlFields(source, what, item, "tenant", "quota", "comment")
+		if err != nil {
+			return err
+		}
+		var quota PlanQuota
+		if quota.Tenant, err = yamlString(source, what+" tenant", quotaFields["tenant"]); err != nil {
+			return err
+		}
+		if quota.Comment, err = yamlString(source, what+" comment", quotaFields["comment"]); err != nil {
+			return err
+		}
+		count, err := yamlString(source, what+" quota", quotaFields["quota"])
+		if err != nil {
+			return err
+		}
+		if quota.Quota, err = parseQuota(count); err != nil {
+			return fmt.Errorf("%s: %s: %v", source, what, err)
+		}
+		d.Policies.Quotas = append(d.Policies.Quotas, quota)
+	}
+	return nil
+}
+
+// Encode writes the document in a format: YAML or JSON
+func (d *PlanDocument) Encode(format string) (string, error) {
+	switch format {
+	case DocumentJSON:
+		document := *d
+		// Empty sections are written as [] so the document shows every section
+		for _, section := range []*[]PlanRange{&document.Pools, &document.Allocations, &document.Policies.Reserved} {
+			if *section == nil {
+				*section = []PlanRange{}
+			}
+		}
+		if document.Policies.Quotas == nil {
+			document.Policies.Quotas = []PlanQuota{}
+		}
+		content, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode JSON: %v", err)
+		}
+		return string(content) + "\n", nil
+	case DocumentYAML:
+		return d.encodeYAML(), nil
+	}
+	return "", fmt.Errorf("unsupported plan document format %q (supported: %s, %s)", format, DocumentYAML, DocumentJSON)
+}
+
+// encodeYAML writes the document as block-style YAML
+func (d *PlanDocument) encodeYAML() string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("version: %d\n", d.Version))
+
+	writeRanges := func(indent, name string, ranges []PlanRange) {
+		if len(ranges) == 0 {
+			output.WriteString(indent + name + ": []\n")
+			return
+		}
+		output.WriteString(indent + name + ":\n")
+		for _, planRange := range ranges {
+			output.WriteString(indent + "  - cidr: " + yamlScalar(planRange.CIDR) + "\n")
+			if planRange.Annotation != "" {
+				output.WriteString(indent + "    annotation: " + yamlScalar(planRange.Annotation) + "\n")
+			}
+			if len(planRange.Labels) > 0 {
+				output.WriteString(indent + "    labels:\n")
+				for _, key := range planRange.Labels.Keys() {
+					output.WriteString(indent + "      " + yamlScalar(key) + ": " + yamlScalar(planRange.Labels[key]) + "\n")
+				}
+			}
+			if planRange.Comment != "" {
+				output.WriteString(indent + "    comment: " + yamlScalar(planRange.Comment) + "\n")
+			}
+		}
+	}
+
+	writeRanges("", SectionPools, d.Pools)
+	writeRanges("", SectionAllocations, d.Allocations)
+	output.WriteString("policies:\n")
+	writeRanges("  ", SectionReserved, d.Policies.Reserved)
+	if len(d.Policies.Quotas) == 0 {
+		output.WriteString("  quotas: []\n")
+	} else {
+		output.WriteString("  quotas:\n")
+		for _, quota := range d.Policies.Quotas {
+			output.WriteString("    - tenant: " + yamlScalar(quota.Tenant) + "\n")
+			output.WriteString(fmt.Sprintf("      quota: %d\n", quota.Quota))
+			if quota.Comment != "" {
+				output.WriteString("      comment: " + yamlScalar(quota.Comment) + "\n")
+			}
+		}
+	}
+	if d.Policies.TenantTag != "" {
+		output.WriteString("  tenantTag: " + yamlScalar(d.Policies.TenantTag) + "\n")
+	}
+	return output.String()
+}
+
+// withAllocations returns a copy of the document whose allocations are the
+// entries of a plan file
+func (d *PlanDocument) withAllocations(source, content string) (*PlanDocument, error) {
+	entries, err := parseBatch(source, strings.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	updated := *d
+	updated.Allocations = make([]PlanRange, len(entries))
+	for i, entry := range entries {
+		updated.Allocations[i] = planRangeFromEntry(entry)
+	}
+	return &updated, nil
+}
+
+// stateAsPlan converts the content of a plan document state to a plan file of
+// its allocations, which IPAM operations edit line by line. A missing state
+// becomes an empty document. The returned function converts the edited plan
+// file back into the document.
+func stateAsPlan(location string, content []byte) (string, func(string) (string, error), error) {
+	if documentFormat(location) == "" && !isPlanDocument(content) {
+		return string(content), func(plan string) (string, error) { return plan, nil }, nil
+	}
+	format := planDocumentFormat(location, content)
+
+	document := &PlanDocument{Version: planDocumentVersion}
+	if len(bytes.TrimSpace(content)) > 0 {
+		var err error
+		if document, err = parsePlanDocument(location, content); err != nil {
+			return "", nil, err
+		}
+	}
+	plan, _ := document.PlanText(SectionAllocations)
+	return plan, func(plan string) (string, error) {
+		updated, err := document.withAllocations(location, plan)
+		if err != nil {
+			return "", err
+		}
+		return updated.Encode(format)
+	}, nil
+}
+
+// readPlanDocument reads a document, or wraps the entries of a plan file as
+// the given section of a new document
+func readPlanDocument(source, section string) (*PlanDocument, error) {
+	content, err := readSource(source)
+	if err != nil {
+		return nil, err
+	}
+	if isPlanDocument(content) {
+		return parsePlanDocument(source, content)
+	}
+
+	entries, err := parseBatch(source, bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	document := &PlanDocument{Version: planDocumentVersion}
+	ranges := make([]PlanRange, len(entries))
+	for i, entry := range entries {
+		ranges[i] = planRangeFromEntry(entry)
+	}
+	switch section {
+	case SectionPools:
+		document.Pools = ranges
+	case SectionReserved:
+		document.Policies.Reserved = ranges
+	default:
+		document.Allocations = ranges
+	}
+	return document, nil
+}
+
+// readSource reads a file, or standard input for "-"
+func readSource(source string) ([]byte, error) {
+	if source == "-" {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read standard input: %v", err)
+		}
+		return content, nil
+	}
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", source, err)
+	}
+	return content, nil
+}
+
+// merge adds the pools, allocations and policies of another document
+func (d *PlanDocument) merge(other *PlanDocument) error {
+	d.Pools = append(d.Pools, other.Pools...)
+	d.Allocations = append(d.Allocations, other.Allocations...)
+	d.Policies.Reserved = append(d.Policies.Reserved, other.Policies.Reserved...)
+	d.Policies.Quotas = append(d.Policies.Quotas, other.Policies.Quotas...)
+	if other.Policies.TenantTag != "" {
+		if d.Policies.TenantTag != "" && d.Policies.TenantTag != other.Policies.TenantTag {
+			return fmt.Errorf("conflicting tenant tags %q and %q", d.Policies.TenantTag, other.Policies.TenantTag)
+		}
+		d.Policies.TenantTag = other.Policies.TenantTag
+	}
+	return nil
+}
+
+// runPlan implements the plan subcommand and its commands
+func (c *CLIHandler) runPlan(args []string) error {
+	commands := map[string]subcommand{
+		"build":   c.runPlanBuild,
+		"export":  c.runPlanExport,
+		"import":  c.runPlanImport,
+		"migrate": c.runPlanMigrate,
+		"relabel": c.runPlanRelabel,
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("plan requires a command: build, export, import, migrate or relabel")
+	}
+	run, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown plan command %q (available: build, export, import, migrate, relabel)", args[0])
+	}
+	return run(args[1:])
+}
+
+// runPlanExport combines plan files, policy files and documents into one
+// plan document
+func (c *CLIHandler) runPlanExport(args []string) error {
+	flagSet := flag.NewFlagSet("plan export", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var pools, reserved stringList
+	var quotaFile, tenantTag, format, outputFile string
+	flagSet.Var(&pools, "pools", "Plan file of pools (repeatable)")
+	flagSet.Var(&reserved, "reserved", "Plan file of reserved ranges (repeatable)")
+	flagSet.StringVar(&quotaFile, "quotas", "", "Quota file of the tenants")
+	flagSet.StringVar(&tenantTag, "by", "", "Tag naming the tenant of an allocation, if not tenant")
+	flagSet.StringVar(&format, "format", "", "Document format: yaml or json (default from the -o extension, else yaml)")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept the allocation files anywhere among the flags
+	sources, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	if format == "" {
+		format = documentFormat(outputFile)
+	}
+	if format == "" {
+		format = DocumentYAML
+	}
+
+	document := &PlanDocument{Version: planDocumentVersion, Policies: PlanPolicies{TenantTag: tenantTag}}
+	for _, group := range []struct {
+		section string
+		sources []string
+	}{{SectionAllocations, sources}, {SectionPools, pools}, {SectionReserved, reserved}} {
+		for _, source := range group.sources {
+			part, err := readPlanDocument(source, group.section)
+			if err != nil {
+				return err
+			}
+			if err := document.merge(part); err != nil {
+				return err
+			}
+		}
+	}
+	if quotaFile != "" {
+		content, err := readSource(quotaFile)
+		if err != nil {
+			return err
+		}
+		policy, err := parseQuotas(quotaFile, bytes.NewReader(content))
+		if err != nil {
+			return err
+		}
+		for _, tenant := range policy.tenants {
+			document.Policies.Quotas = append(document.Policies.Quotas, PlanQuota{Tenant: tenant, Quota: policy.quotas[tenant], Comment: policy.comments[tenant]})
+		}
+	}
+	if err := document.validate("the plan"); err != nil {
+		return err
+	}
+
+	content, err := document.Encode(format)
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(content, outputFile)
+}
+
+// runPlanImport writes a section of a plan document as a plan file, or its
+// quotas as a quota file
+func (c *CLIHandler) runPlanImport(args []string) error {
+	flagSet := flag.NewFlagSet("plan import", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var section, outputFile string
+	flagSet.StringVar(&section, "section", SectionAllocations, "Section to write: allocations, pools, reserved or quotas")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept the document anywhere among the flags
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("plan import takes a single plan document, got %d", len(positional))
+	}
+
+	content, err := readSource(positional[0])
+	if err != nil {
+		return err
+	}
+	if !isPlanDocument(content) {
+		return fmt.Errorf("%s is not a plan document; it should start with version: or {", positional[0])
+	}
+	document, err := parsePlanDocument(positional[0], content)
+	if err != nil {
+		return err
+	}
+
+	if section == "quotas" {
+		return c.writeOutput(document.QuotaText(), outputFile)
+	}
+	plan, err := document.PlanText(section)
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(plan, outputFile)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testPlanDocument has every section of a plan document
+var testPlanDocument = &PlanDocument{
+	Version:     planDocumentVersion,
+	Pools:       []PlanRange{{CIDR: "10.0.0.0/16", Comment: "main"}},
+	Allocations: []PlanRange{{CIDR: "10.0.0.0/24", Labels: Tags{"name": "web", "vlan": "100"}, Comment: "web: tier 1"}, {CIDR: "2001:db8::/48", Annotation: "lab vlan 300"}},
+	Policies: PlanPolicies{
+		Reserved:  []PlanRange{{CIDR: "10.0.255.0/24", Comment: "future"}},
+		Quotas:    []PlanQuota{{Tenant: "payments", Quota: 4096, Comment: "cost center 4711"}},
+		TenantTag: "team",
+	},
+}
+
+func TestPlanDocument_RoundTrip(t *testing.T) {
+	for _, format := range []string{DocumentYAML, DocumentJSON} {
+		content, err := testPlanDocument.Encode(format)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		if !isPlanDocument([]byte(content)) {
+			t.Errorf("%s: not detected as a plan document:\n%s", format, content)
+		}
+		document, err := parsePlanDocument("plan."+format, []byte(content))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v\n%s", format, err, content)
+		}
+		if !reflect.DeepEqual(document, testPlanDocument) {
+			t.Errorf("%s: expected %+v, got %+v", format, testPlanDocument, document)
+		}
+	}
+
+	// The allocations read like the lines of a plan file
+	plan, _ := testPlanDocument.PlanText(SectionAllocations)
+	if plan != "10.0.0.0/24 name=web vlan=100 # web: tier 1\n2001:db8::/48 lab vlan 300\n" {
+		t.Errorf("unexpected plan text:\n%s", plan)
+	}
+	updated, err := testPlanDocument.withAllocations("plan.yaml", plan)
+	if err != nil || !reflect.DeepEqual(updated, testPlanDocument) {
+		t.Errorf("plan text did not convert back: %+v (%v)", updated, err)
+	}
+
+	for _, plan := range []string{"10.0.0.0/24 name=web\n", "# version: 2\n10.0.0.0/8\n"} {
+		if isPlanDocument([]byte(plan)) {
+			t.Errorf("%q: a plan file was taken for a document", plan)
+		}
+	}
+}
+
+func TestParsePlanDocument_Errors(t *testing.T) {
+	errorTests := map[string]string{
+		"version: 3\n":               "plan document version 3 is newer than the 2 this release reads",
+		"version: 0\n":               "unsupported plan document version 0",
+		"version: one\n":             `version must be a number, got "one"`,
+		"version: 1\nnetworks: []\n": `unknown field "networks" in the document`,
+		"version: 1\nallocations:\n  - cidr: 10.0.0.0/8\n    owner: me\n":                `unknown field "owner" in allocations 1`,
+		"version: 1\nallocations:\n  - labels:\n      a: b\n":                            `allocations 1 has an invalid cidr ""`,
+		"version: 1\nallocations:\n  - cidr: 10.0.0.0/8\n    labels:\n      team: a b\n": "labels cannot contain spaces or #",
+		"version: 1\npolicies:\n  quotas:\n    - tenant: a\n      quota: x\n":            "quota 1: invalid quota x",
+		`{"version": 1, "pool": []}`:                                                     `unknown field "pool"`,
+		"version: 1\nallocations:\n  - cidr: 10.0.0.0/8\n    annotation: vlan=3\n":       "annotations cannot contain #, = or line breaks",
+	}
+	for content, expected := range errorTests {
+		if _, err := parsePlanDocument("plan.yaml", []byte(content)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%q: expected error %q, got %v", content, expected, err)
+		}
+	}
+}
+
+func TestPlanDocument_Modes(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+	output := filepath.Join(dir, "out.txt")
+
+	// Export plan, pool, reserved and quota files into one document
+	files := map[string]string{
+		"plan.txt":     "10.0.0.0/24 name=web # web tier\n10.0.1.0/24 team=payments\n",
+		"pools.txt":    "10.0.0.0/16 # main\n",
+		"reserved.txt": "10.0.255.0/24 # future\n",
+		"quotas.txt":   "payments /20 # cost center\n",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+	document := filepath.Join(dir, "plan.yaml")
+	if err := handler.Run([]string{"cidr-calc", "plan", "export", filepath.Join(dir, "plan.txt"), "--pools", filepath.Join(dir, "pools.txt"),
+		"--reserved", filepath.Join(dir, "reserved.txt"), "--quotas", filepath.Join(dir, "quotas.txt"), "--by", "team", "-o", document}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// IPAM edits the document in place, honoring its reserved ranges and quotas
+	for i := 0; i < 2; i++ {
+		if err := handler.Run([]string{"cidr-calc", "ipam", "allocate", "--pool", "10.0.0.0/16", "--prefix", "24", "--state", document,
+			"--tenant", "payments", "--quotas", document, "--reserved", document, "-o", output}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := handler.Run([]string{"cidr-calc", "ipam", "undo", "--state", document}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(document)
+	parsed, err := parsePlanDocument(document, content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, content)
+	}
+	if len(parsed.Allocations) != 3 || parsed.Allocations[2].Line() != "10.0.2.0/24 team=payments # allocated from 10.0.0.0/16 (first-fit)" ||
+		len(parsed.Pools) != 1 || parsed.Policies.TenantTag != "team" || parsed.Policies.Quotas[0].Comment != "cost center" {
+		t.Errorf("unexpected document:\n%s", content)
+	}
+
+	// Plan file readers see the allocations
+	if err := handler.Run([]string{"cidr-calc", "lint", "-o", output, document}); err != nil {
+		t.Errorf("unexpected lint error: %v", err)
+	}
+	if err := handler.Run([]string{"cidr-calc", "plan", "import", document, "--section", "reserved", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reserved, _ := os.ReadFile(output); string(reserved) != files["reserved.txt"] {
+		t.Errorf("unexpected reserved section %q", reserved)
+	}
+
+	errorTests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"plan"}, "plan requires a command"},
+		{[]string{"plan", "import", filepath.Join(dir, "plan.txt")}, "is not a plan document"},
+		{[]string{"plan", "import", document, "--section", "subnets"}, `unknown plan document section "subnets"`},
+		{[]string{"plan", "export", "--format", "toml", filepath.Join(dir, "plan.txt")}, `unsupported plan document format "toml"`},
+	}
+	for _, tt := range errorTests {
+		if err := handler.Run(append([]string{"cidr-calc"}, tt.args...)); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+)
+
+// ReservedPolicy holds ranges that must never be allocated, read from a plan
+// file where a trailing comment gives the reason:
+//
+//	10.255.0.0/16   # future expansion
+type ReservedPolicy struct {
+	ranges []planNetwork
+}
+
+// NewReservedPolicy builds a policy from the entries of a reserved-range file
+func NewReservedPolicy(entries []BatchEntry) (*ReservedPolicy, error) {
+	calculator := NewCIDRCalculator()
+	policy := &ReservedPolicy{}
+	for _, entry := range entries {
+		info, err := calculator.ParseCIDR(entry.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid reserved range: %v", entry.Source, entry.Line, err)
+		}
+		policy.ranges = append(policy.ranges, planNetwork{entry: entry, info: info})
+	}
+	return policy, nil
+}
+
+// LoadReservedPolicy reads a reserved-range file from a path, "-" or a URL; a
+// plan document gives its reserved policy
+func LoadReservedPolicy(source string) (*ReservedPolicy, error) {
+	entries, err := NewBatchReader(defaultFetchTimeout, nil).ReadSection(source, SectionReserved)
+	if err != nil {
+		return nil, err
+	}
+	return NewReservedPolicy(entries)
+}
+
+// Violation describes the first reserved range the network overlaps, e.g.
+// "overlaps reserved 10.255.0.0/16 at reserved.txt:1 (future expansion)".
+// A nil policy reserves nothing.
+func (p *ReservedPolicy) Violation(info *NetworkInfo) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	for _, reserved := range p.ranges {
+		if info.Overlaps(reserved.info) {
+			return "overlaps " + describeReserved(reserved), true
+		}
+	}
+	return "", false
+}
+
+// describeReserved names a reserved range, where it is declared and why
+func describeReserved(reserved planNetwork) string {
+	description := fmt.Sprintf("reserved %s at %s:%d", reserved.info.CIDR(), reserved.entry.Source, reserved.entry.Line)
+	if reserved.entry.Comment != "" {
+		description += " (" + reserved.entry.Comment + ")"
+	}
+	return description
+}
+
+// policyFlag loads a --reserved policy file when the flag is parsed
+type policyFlag struct {
+	target **ReservedPolicy
+	source string
+}
+
+// String returns the policy file name
+func (f *policyFlag) String() string {
+	return f.source
+}
+
+// Set reads the policy file
+func (f *policyFlag) Set(value string) error {
+	policy, err := LoadReservedPolicy(value)
+	if err != nil {
+		return err
+	}
+	f.source = value
+	*f.target = policy
+	return nil
+}
+
+// checkReserved rejects plan entries that overlap a reserved range, naming the
+// first violation and how many there are
+func (c *CLIHandler) checkReserved(entries []BatchEntry, policy *ReservedPolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	var first string
+	violations := 0
+	for _, entry := range entries {
+		info, err := c.calculator.ParseCIDR(entry.CIDR)
+		if err != nil {
+			continue // reported when the entry is calculated
+		}
+		if violation, ok := policy.Violation(info); ok {
+			if violations == 0 {
+				first = fmt.Sprintf("%s line %d: %s %s", entry.Source, entry.Line, info.CIDR(), violation)
+			}
+			violations++
+		}
+	}
+
+	switch violations {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%s", first)
+	default:
+		return fmt.Errorf("%s (and %d more reserved-range violations)", first, violations-1)
+	}
+}
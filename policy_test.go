@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReservedPolicy_Violation(t *testing.T) {
+	policy, err := NewReservedPolicy([]BatchEntry{
+		{Source: "reserved.txt", Line: 1, CIDR: "10.255.0.0/16", Comment: "future expansion"},
+		{Source: "reserved.txt", Line: 2, CIDR: "192.168.0.0/24"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		cidr     string
+		expected string
+	}{
+		{"10.255.1.0/24", "overlaps reserved 10.255.0.0/16 at reserved.txt:1 (future expansion)"},
+		{"10.0.0.0/8", "overlaps reserved 10.255.0.0/16 at reserved.txt:1 (future expansion)"},
+		{"192.168.0.128/25", "overlaps reserved 192.168.0.0/24 at reserved.txt:2"},
+		{"10.254.0.0/16", ""},
+		{"2001:db8::/32", ""},
+	}
+
+	calculator := NewCIDRCalculator()
+	for _, tt := range tests {
+		info, err := calculator.ParseCIDR(tt.cidr)
+		if err != nil {
+			t.Fatalf("failed to parse CIDR: %v", err)
+		}
+		violation, ok := policy.Violation(info)
+		if ok != (tt.expected != "") || violation != tt.expected {
+			t.Errorf("%s: expected %q, got %q (%v)", tt.cidr, tt.expected, violation, ok)
+		}
+	}
+
+	// Without a policy nothing is reserved
+	info, _ := calculator.ParseCIDR("10.255.0.0/24")
+	if _, ok := (*ReservedPolicy)(nil).Violation(info); ok {
+		t.Errorf("expected no violation without a policy")
+	}
+
+	if _, err := NewReservedPolicy([]BatchEntry{{Source: "reserved.txt", Line: 3, CIDR: "10.0.0.0/33"}}); err == nil ||
+		!strings.HasPrefix(err.Error(), "reserved.txt line 3: invalid reserved range") {
+		t.Errorf("expected an error naming the bad line, got %v", err)
+	}
+}
+
+func TestCLIHandler_Reserved(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	reserved := filepath.Join(dir, "reserved.txt")
+	if err := os.WriteFile(reserved, []byte("10.255.0.0/16  # future expansion\n10.0.0.128/26\n"), 0644); err != nil {
+		t.Fatalf("failed to write policy: %v", err)
+	}
+	plan := filepath.Join(dir, "plan.txt")
+	if err := os.WriteFile(plan, []byte("10.1.0.0/16\n10.255.4.0/24\n10.255.5.0/24\n"), 0644); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+	clean := filepath.Join(dir, "clean.txt")
+	if err := os.WriteFile(clean, []byte("10.1.0.0/16\n10.254.0.0/16\n"), 0644); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+	output := filepath.Join(dir, "out.txt")
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectedErr string
+	}{
+		{
+			name:        "plan violations",
+			args:        []string{"-f", plan},
+			expectedErr: "plan.txt line 2: 10.255.4.0/24 overlaps reserved 10.255.0.0/16 at " + reserved + ":1 (future expansion) (and 1 more reserved-range violations)",
+		},
+		{
+			name: "plan clear of reserved ranges",
+			args: []string{"-f", clean},
+		},
+		{
+			name:        "vlsm allocation",
+			args:        []string{"--vlsm", "50,50,10", "10.0.0.0/24"},
+			expectedErr: "10.0.0.128/28 for 10 hosts overlaps reserved 10.0.0.128/26 at " + reserved + ":2",
+		},
+		{
+			name: "vlsm clear of reserved ranges",
+			args: []string{"--vlsm", "50,50", "10.0.0.0/24"},
+		},
+		{
+			name:        "missing policy file",
+			args:        []string{"--reserved", filepath.Join(dir, "missing.txt"), "10.0.0.0/24"},
+			expectedErr: "failed to open input file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"cidr-calc", "--reserved", reserved, "-o", output}, tt.args...)
+			err := handler.Run(args)
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("expected error containing %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
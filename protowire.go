@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Protocol buffer wire types used by the gRPC messages
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// protoEncoder builds a protocol buffer message. Zero values are left out, as
+// proto3 does for fields without presence.
+type protoEncoder struct {
+	buf []byte
+}
+
+// tag appends the key of a field
+func (e *protoEncoder) tag(field, wireType int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(field)<<3|uint64(wireType))
+}
+
+// String appends a string field
+func (e *protoEncoder) String(field int, value string) {
+	if value == "" {
+		return
+	}
+	e.Bytes(field, []byte(value))
+}
+
+// Bytes appends a length-delimited field, such as an embedded message. Empty
+// values are kept, so a repeated message with only default fields still counts.
+func (e *protoEncoder) Bytes(field int, value []byte) {
+	e.tag(field, protoBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(value)))
+	e.buf = append(e.buf, value...)
+}
+
+// Int appends an int32 or int64 field; negative values take ten bytes
+func (e *protoEncoder) Int(field int, value int64) {
+	if value == 0 {
+		return
+	}
+	e.tag(field, protoVarint)
+	e.buf = binary.AppendUvarint(e.buf, uint64(value))
+}
+
+// Bool appends a bool field
+func (e *protoEncoder) Bool(field int, value bool) {
+	if value {
+		e.Int(field, 1)
+	}
+}
+
+// protoField is one decoded field of a message. Varint holds varint values and
+// Bytes length-delimited ones; fixed-width fields are skipped.
+type protoField struct {
+	Number int
+	Type   int
+	Varint uint64
+	Bytes  []byte
+}
+
+// decodeProto splits a message into its fields in wire order
+func decodeProto(data []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid field key")
+		}
+		data = data[n:]
+
+		field := protoField{Number: int(key >> 3), Type: int(key & 7)}
+		if field.Number == 0 {
+			return nil, fmt.Errorf("invalid field number 0")
+		}
+
+		switch field.Type {
+		case protoVarint:
+			if field.Varint, n = binary.Uvarint(data); n <= 0 {
+				return nil, fmt.Errorf("field %d: invalid varint", field.Number)
+			}
+			data = data[n:]
+		case protoBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return nil, fmt.Errorf("field %d: invalid length", field.Number)
+			}
+			field.Bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case protoFixed64, protoFixed32:
+			size := 8
+			if field.Type == protoFixed32 {
+				size = 4
+			}
+			if len(data) < size {
+				return nil, fmt.Errorf("field %d: truncated value", field.Number)
+			}
+			data = data[size:]
+		default:
+			return nil, fmt.Errorf("field %d: unsupported wire type %d", field.Number, field.Type)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
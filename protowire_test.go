@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProtoEncoder_RoundTrip(t *testing.T) {
+	var nested, message protoEncoder
+	nested.String(1, "inner")
+	message.String(1, "10.0.0.0/24")
+	message.String(2, "")
+	message.Int(3, 300)
+	message.Int(4, -1)
+	message.Bool(5, true)
+	message.Bool(6, false)
+	message.Bytes(7, nested.buf)
+	message.Bytes(8, nil)
+
+	fields, err := decodeProto(message.buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Zero values are left out; empty embedded messages are kept
+	expected := []protoField{
+		{Number: 1, Type: protoBytes, Bytes: []byte("10.0.0.0/24")},
+		{Number: 3, Type: protoVarint, Varint: 300},
+		{Number: 4, Type: protoVarint, Varint: 1<<64 - 1},
+		{Number: 5, Type: protoVarint, Varint: 1},
+		{Number: 7, Type: protoBytes, Bytes: []byte{0x0a, 5, 'i', 'n', 'n', 'e', 'r'}},
+		{Number: 8, Type: protoBytes, Bytes: []byte{}},
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("expected %+v, got %+v", expected, fields)
+	}
+	if int32(fields[2].Varint) != -1 {
+		t.Errorf("expected -1 to survive as int32, got %d", int32(fields[2].Varint))
+	}
+}
+
+func TestDecodeProto(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected int // number of fields, or -1 for an error
+	}{
+		{"empty", nil, 0},
+		{"fixed fields are skipped", []byte{0x09, 1, 2, 3, 4, 5, 6, 7, 8, 0x15, 1, 2, 3, 4, 0x18, 1}, 3},
+		{"truncated length", []byte{0x0a, 5, 'a'}, -1},
+		{"truncated varint", []byte{0x08, 0x80}, -1},
+		{"field number 0", []byte{0x00, 1}, -1},
+		{"group wire type", []byte{0x0b}, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := decodeProto(tt.data)
+			if tt.expected < 0 {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", fields)
+				}
+				return
+			}
+			if err != nil || len(fields) != tt.expected {
+				t.Errorf("expected %d fields, got %+v (%v)", tt.expected, fields, err)
+			}
+		})
+	}
+}
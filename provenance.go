@@ -0,0 +1,43 @@
+package main
+
+import "fmt"
+
+// Derivation records how subnets were derived from a parent network, so a
+// report can be traced back to the request that created them
+type Derivation struct {
+	Parent    string // CIDR of the network the subnets were carved from
+	Rule      string // split, parts, hosts, vlsm or plan
+	Requested string // what was asked for, such as "/26", "4 parts" or "50 hosts"
+}
+
+// String describes the derivation, such as "10.0.0.0/24 by hosts (50 hosts requested)"
+func (d *Derivation) String() string {
+	return fmt.Sprintf("%s by %s (%s requested)", d.Parent, d.Rule, d.Requested)
+}
+
+// splitDerivation returns how --split, --parts or --hosts derive the subnets
+// of a network, or nil when the next prefix is listed
+func splitDerivation(networkInfo *NetworkInfo, config *Config) *Derivation {
+	derivation := &Derivation{Parent: networkInfo.CIDR()}
+	switch {
+	case config.Split != 0:
+		derivation.Rule, derivation.Requested = "split", fmt.Sprintf("/%d", config.Split)
+	case config.Parts != 0:
+		derivation.Rule, derivation.Requested = "parts", plural(config.Parts, "part")
+	case config.Hosts != 0:
+		derivation.Rule, derivation.Requested = "hosts", plural(config.Hosts, "host")
+	default:
+		return nil
+	}
+	return derivation
+}
+
+// hostsDerivation returns the derivation of a subnet sized for a host count,
+// or for a prefix length when hosts is 0
+func hostsDerivation(parent *NetworkInfo, rule string, hosts, prefix int) *Derivation {
+	requested := fmt.Sprintf("/%d", prefix)
+	if hosts > 0 {
+		requested = plural(hosts, "host")
+	}
+	return &Derivation{Parent: parent.CIDR(), Rule: rule, Requested: requested}
+}
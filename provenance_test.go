@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitDerivation(t *testing.T) {
+	info := mustParseCIDR(t, NewCIDRCalculator(), "10.0.0.0/24")
+
+	testCases := []struct {
+		config   Config
+		expected string
+	}{
+		{Config{Split: 26}, "10.0.0.0/24 by split (/26 requested)"},
+		{Config{Parts: 3}, "10.0.0.0/24 by parts (3 parts requested)"},
+		{Config{Hosts: 1}, "10.0.0.0/24 by hosts (1 host requested)"},
+	}
+	for _, tt := range testCases {
+		if derivation := splitDerivation(info, &tt.config); derivation == nil || derivation.String() != tt.expected {
+			t.Errorf("expected %q, got %v", tt.expected, derivation)
+		}
+	}
+	if derivation := splitDerivation(info, &Config{}); derivation != nil {
+		t.Errorf("expected no derivation for the next prefix, got %v", derivation)
+	}
+}
+
+func TestCLIHandler_Provenance(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	run := func(args ...string) string {
+		t.Helper()
+		output := filepath.Join(dir, "report")
+		if err := handler.Run(append(append([]string{"cidr-calc"}, args...), "-o", output)); err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+		content, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("failed to read output: %v", err)
+		}
+		return string(content)
+	}
+	expectContent := func(content string, expected ...string) {
+		t.Helper()
+		for _, exp := range expected {
+			if !strings.Contains(content, exp) {
+				t.Errorf("expected %q in:\n%s", exp, content)
+			}
+		}
+	}
+
+	expectContent(run("--hosts", "50", "--format", "json", "10.0.0.0/24"),
+		"\"limited\": false,\n        \"derivation\": {\n          \"parent\": \"10.0.0.0/24\",\n          \"rule\": \"hosts\",\n          \"requested\": \"50 hosts\"\n        },")
+	expectContent(run("--parts", "4", "--format", "xml", "10.0.0.0/24"),
+		`<derivation parent="10.0.0.0/24" rule="parts" requested="4 parts"></derivation>`)
+	expectContent(run("--split", "26", "--html", "10.0.0.0/24"),
+		"<th>Derived From</th>\n                            <td>10.0.0.0/24 by split (/26 requested)</td>")
+	if content := run("--format", "json", "10.0.0.0/24"); strings.Contains(content, "derivation") {
+		t.Errorf("expected no derivation without a split, got:\n%s", content)
+	}
+
+	// Every VLSM allocation records the host count it was sized for
+	expectContent(run("--vlsm", "50,10", "--format", "md", "10.0.0.0/24"),
+		"| Derived From   | 10.0.0.0/24 by vlsm (50 hosts requested)",
+		"| Derived From   | 10.0.0.0/24 by vlsm (10 hosts requested)")
+
+	request := filepath.Join(dir, "request.yaml")
+	if err := os.WriteFile(request, []byte("network: 10.20.0.0/22\nsubnets:\n  - name: web\n    hosts: 200\n  - name: transit\n    prefix: 30\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectContent(run("plan", "build", request, "--format", "json"),
+		"\"derivation\": {\n        \"parent\": \"10.20.0.0/22\",\n        \"rule\": \"plan\",\n        \"requested\": \"200 hosts\"\n      },",
+		"\"requested\": \"/30\"")
+
+	// Allocations sized for hosts keep the count in the state file comment
+	state := filepath.Join(dir, "ipam.txt")
+	run("ipam", "allocate", "--pool", "10.0.0.0/22", "--hosts", "100", "--state", state, "--name", "web")
+	if content, _ := os.ReadFile(state); string(content) != "10.0.0.0/25 name=web # allocated from 10.0.0.0/22 (first-fit, 100 hosts)\n" {
+		t.Errorf("unexpected state %q", content)
+	}
+}
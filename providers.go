@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProviderRules are the subnet sizes a cloud provider accepts. Min is the
+// shortest (largest) prefix, Max the longest (smallest).
+type ProviderRules struct {
+	Name     string
+	IPv4Min  int
+	IPv4Max  int
+	IPv6Min  int
+	IPv6Max  int
+	Reserved int // addresses the provider reserves in every IPv4 subnet
+}
+
+// providerRules are the subnet limits of the providers --validate-for knows
+var providerRules = map[string]ProviderRules{
+	"aws":   {Name: "AWS", IPv4Min: 16, IPv4Max: 28, IPv6Min: 44, IPv6Max: 64, Reserved: 5},
+	"azure": {Name: "Azure", IPv4Min: 2, IPv4Max: 29, IPv6Min: 64, IPv6Max: 64, Reserved: 5},
+	"gcp":   {Name: "GCP", IPv4Min: 8, IPv4Max: 29, IPv6Min: 64, IPv6Max: 64, Reserved: 4},
+	"oci":   {Name: "OCI", IPv4Min: 16, IPv4Max: 30, IPv6Min: 64, IPv6Max: 64, Reserved: 3},
+}
+
+// LookupProviderRules returns the rules of a provider by name, e.g. "aws"
+func LookupProviderRules(name string) (*ProviderRules, error) {
+	rules, ok := providerRules[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(providerRules))
+		for known := range providerRules {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown provider %q (supported: %s)", name, strings.Join(names, ", "))
+	}
+	return &rules, nil
+}
+
+// CheckPrefix returns an error when the provider cannot create a subnet with this prefix length
+func (r *ProviderRules) CheckPrefix(ipv6 bool, prefix int) error {
+	min, max := r.IPv4Min, r.IPv4Max
+	if ipv6 {
+		min, max = r.IPv6Min, r.IPv6Max
+	}
+
+	switch {
+	case prefix < min:
+		return fmt.Errorf("a /%d subnet is larger than the /%d maximum %s allows", prefix, min, r.Name)
+	case prefix > max:
+		return fmt.Errorf("a /%d subnet is smaller than the /%d minimum %s allows", prefix, max, r.Name)
+	}
+	return nil
+}
+
+// UsableHosts returns the hosts left in an IPv4 subnet once the provider's
+// reserved addresses are taken out
+func (r *ProviderRules) UsableHosts(prefix int) int {
+	usable := (1 << uint(32-prefix)) - r.Reserved
+	if usable < 0 {
+		return 0
+	}
+	return usable
+}
+
+// providerFlag parses --validate-for into provider rules
+type providerFlag struct {
+	target **ProviderRules
+}
+
+// String returns the provider name
+func (f providerFlag) String() string {
+	if f.target == nil || *f.target == nil {
+		return ""
+	}
+	return strings.ToLower((*f.target).Name)
+}
+
+// Set looks up the provider
+func (f providerFlag) Set(value string) error {
+	rules, err := LookupProviderRules(value)
+	if err != nil {
+		return err
+	}
+	*f.target = rules
+	return nil
+}
+
+// checkProvider rejects a network whose subnets the --validate-for provider
+// cannot create, and warns when --hosts subnets lose the requested hosts to the
+// addresses the provider reserves. prefix is the prefix of the listed subnets,
+// which are only checked when --split, --parts or --hosts asked for them;
+// otherwise the network itself is.
+func (c *CLIHandler) checkProvider(info *NetworkInfo, prefix int, config *Config) error {
+	rules := config.Provider
+	if rules == nil {
+		return nil
+	}
+
+	flagName := "--validate-for"
+	if config.Cloud != nil {
+		flagName = "--cloud"
+	}
+	if !config.RequestsSplit() {
+		prefix = info.PrefixLength
+	}
+	if err := rules.CheckPrefix(info.IsIPv6(), prefix); err != nil {
+		if prefix == info.PrefixLength {
+			return fmt.Errorf("%s %s: %s: %v", flagName, strings.ToLower(rules.Name), info.CIDR(), err)
+		}
+		return fmt.Errorf("%s %s: subnets of %s: %v", flagName, strings.ToLower(rules.Name), info.CIDR(), err)
+	}
+
+	if config.Hosts > 0 && !info.IsIPv6() {
+		if usable := rules.UsableHosts(prefix); usable < config.Hosts {
+			c.warnf("%s reserves %d addresses in every subnet, so the /%d subnets of %s hold %d hosts, fewer than the %d requested",
+				rules.Name, rules.Reserved, prefix, info.CIDR(), usable, config.Hosts)
+		}
+	}
+	return nil
+}
+
+// reportPrefix returns the prefix of the listed subnets, or of the network
+// itself when it has none
+func (c *CLIHandler) reportPrefix(report NetworkReport) (int, error) {
+	if len(report.Subnets) == 0 {
+		return report.Info.PrefixLength, nil
+	}
+	subnet, err := c.calculator.ParseCIDR(report.Subnets[0].CIDR)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse subnet %s: %v", report.Subnets[0].CIDR, err)
+	}
+	return subnet.PrefixLength, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLookupProviderRules(t *testing.T) {
+	rules, err := LookupProviderRules("AWS")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rules.Name != "AWS" || rules.IPv4Min != 16 || rules.IPv4Max != 28 {
+		t.Errorf("unexpected AWS rules: %+v", rules)
+	}
+
+	_, err = LookupProviderRules("ibm")
+	if err == nil || !strings.Contains(err.Error(), "supported: aws, azure, gcp, oci") {
+		t.Errorf("expected an error listing the providers, got %v", err)
+	}
+}
+
+func TestProviderRules_CheckPrefix(t *testing.T) {
+	tests := []struct {
+		provider    string
+		ipv6        bool
+		prefix      int
+		expectedErr string
+	}{
+		{"aws", false, 16, ""},
+		{"aws", false, 28, ""},
+		{"aws", false, 15, "a /15 subnet is larger than the /16 maximum AWS allows"},
+		{"aws", false, 29, "a /29 subnet is smaller than the /28 minimum AWS allows"},
+		{"aws", true, 56, ""},
+		{"aws", true, 40, "larger than the /44 maximum"},
+		{"azure", false, 8, ""},
+		{"azure", false, 30, "smaller than the /29 minimum Azure allows"},
+		{"azure", true, 48, "larger than the /64 maximum Azure allows"},
+		{"gcp", false, 29, ""},
+		{"oci", false, 30, ""},
+	}
+
+	for _, tt := range tests {
+		rules, err := LookupProviderRules(tt.provider)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err = rules.CheckPrefix(tt.ipv6, tt.prefix)
+		if tt.expectedErr == "" {
+			if err != nil {
+				t.Errorf("%s /%d: unexpected error: %v", tt.provider, tt.prefix, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+			t.Errorf("%s /%d: expected error containing %q, got %v", tt.provider, tt.prefix, tt.expectedErr, err)
+		}
+	}
+}
+
+func TestProviderRules_UsableHosts(t *testing.T) {
+	rules, _ := LookupProviderRules("aws")
+	if usable := rules.UsableHosts(28); usable != 11 {
+		t.Errorf("expected 11 usable hosts in an AWS /28, got %d", usable)
+	}
+	if usable := rules.UsableHosts(32); usable != 0 {
+		t.Errorf("expected no usable hosts in a /32, got %d", usable)
+	}
+}
+
+func TestCLIHandler_ValidateFor(t *testing.T) {
+	var stderr strings.Builder
+	handler := NewCLIHandler()
+	handler.stderr = &stderr
+	output := t.TempDir() + "/plan.txt"
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectedErr string
+		warning     string
+	}{
+		{"valid split", []string{"--split", "28", "10.0.0.0/24"}, "", ""},
+		{"too small", []string{"--split", "29", "10.0.0.0/24"}, "subnets of 10.0.0.0/24: a /29 subnet is smaller", ""},
+		{"too large", []string{"10.0.0.0/8"}, "--validate-for aws: 10.0.0.0/8: a /8 subnet is larger than the /16 maximum", ""},
+		{"smallest network", []string{"10.0.0.0/28"}, "", ""},
+		{"no subnets", []string{"10.0.0.1/32"}, "--validate-for aws: 10.0.0.1/32:", ""},
+		{"streamed", []string{"--low-memory", "--split", "29", "10.0.0.0/16"}, "a /29 subnet is smaller", ""},
+		{"reserved hosts", []string{"--hosts", "12", "10.0.0.0/24"}, "", "so the /28 subnets of 10.0.0.0/24 hold 11 hosts"},
+		{"vlsm allocation", []string{"--vlsm", "2", "10.0.0.0/24"}, "10.0.0.0/31 for 2 hosts: a /31 subnet is smaller", ""},
+		{"vlsm reserved hosts", []string{"--vlsm", "12,100", "10.0.0.0/24"}, "", "so 10.0.0.128/28 holds 11 hosts, fewer than the 12 required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stderr.Reset()
+			args := append([]string{"cidr-calc", "--validate-for", "aws", "-o", output}, tt.args...)
+			err := handler.Run(args)
+
+			if tt.expectedErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tt.expectedErr)) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedErr, err)
+			}
+			if tt.warning != "" && !strings.Contains(stderr.String(), tt.warning) {
+				t.Errorf("expected warning containing %q, got %q", tt.warning, stderr.String())
+			}
+		})
+	}
+
+	handler.stderr = io.Discard
+	if err := handler.Run([]string{"cidr-calc", "--validate-for", "ibm", "10.0.0.0/24"}); err == nil {
+		t.Errorf("expected an error for an unknown provider")
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxPTRRecords caps the records of a PTR skeleton; larger networks are
+// generated per smaller CIDR
+const maxPTRRecords = 1 << 20
+
+// defaultPTRTemplate names each address after its dashed form
+const defaultPTRTemplate = "host-{ip}"
+
+// PTRZone is a reverse zone holding the PTR records of a block of addresses
+type PTRZone struct {
+	Name    string       // e.g. "2.0.192.in-addr.arpa" or "0/26.2.0.192.in-addr.arpa"
+	Block   *NetworkInfo // the addresses the zone is authoritative for
+	Records []PTRRecord
+	// Delegation holds the CNAMEs the parent /24 zone needs to point into a
+	// classless (RFC 2317) zone; it is empty for octet-aligned zones
+	Delegation []PTRRecord
+}
+
+// PTRRecord is one record of a reverse zone; Owner is relative to the zone
+type PTRRecord struct {
+	Owner  string
+	Target string
+}
+
+// PTRZoneOptions are the SOA and naming settings of generated zones
+type PTRZoneOptions struct {
+	Template    string   // hostname template with {a} {b} {c} {d} and {ip}
+	Domain      string   // appended to hostnames not ending with a dot
+	NameServers []string // NS records; default ns1.<domain>
+	TTL         int
+	Serial      string
+}
+
+// reverseName returns the in-addr.arpa name of the first octets of an address
+func reverseName(ip net.IP, octets int) string {
+	ip4 := ip.To4()
+	labels := make([]string, 0, octets+1)
+	for i := octets - 1; i >= 0; i-- {
+		labels = append(labels, fmt.Sprintf("%d", ip4[i]))
+	}
+	return strings.Join(append(labels, "in-addr.arpa"), ".")
+}
+
+// fillHostnameTemplate replaces {a} {b} {c} {d} with the octets of an IPv4
+// address and {ip} with its dashed form
+func fillHostnameTemplate(template string, ip net.IP) string {
+	ip4 := ip.To4()
+	return strings.NewReplacer(
+		"{a}", fmt.Sprint(ip4[0]), "{b}", fmt.Sprint(ip4[1]),
+		"{c}", fmt.Sprint(ip4[2]), "{d}", fmt.Sprint(ip4[3]),
+		"{ip}", strings.ReplaceAll(ip4.String(), ".", "-"),
+	).Replace(template)
+}
+
+// expandHostname fills the template for an address and qualifies it with the
+// domain unless the template ends with a dot
+func expandHostname(template, domain string, ip net.IP) string {
+	name := fillHostnameTemplate(template, ip)
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "." + strings.TrimSuffix(domain, ".") + "."
+}
+
+// PTRZones cuts an IPv4 network into reverse zones and fills them with a PTR
+// record for every usable address. Zones are cut at octet boundaries: a /16
+// is one zone, a /20 is sixteen /24 zones, and a network longer than /24 gets
+// a classless RFC 2317 zone named after its first address and prefix.
+func (c *CIDRCalculator) PTRZones(network *NetworkInfo, options PTRZoneOptions) ([]PTRZone, error) {
+	if network.IsIPv6() {
+		return nil, fmt.Errorf("PTR zones support IPv4 networks only")
+	}
+	first, last := ipRange(network)
+	if last-first+1 > maxPTRRecords {
+		return nil, fmt.Errorf("%s has %d addresses, more than the %d a PTR skeleton is generated for; use a longer prefix",
+			network.CIDR(), last-first+1, maxPTRRecords)
+	}
+
+	// Usable addresses leave out the network and broadcast address of the network
+	firstUsable := uint64(ipv4ToUint32(network.FirstUsableIP))
+	lastUsable := uint64(ipv4ToUint32(network.LastUsableIP))
+
+	zonePrefix := (network.PrefixLength + 7) / 8 * 8
+	classless := network.PrefixLength > 24
+	if classless {
+		zonePrefix = network.PrefixLength
+	}
+	if zonePrefix == 0 {
+		zonePrefix = 8
+	}
+	zoneSize := uint64(1) << uint(32-zonePrefix)
+
+	var zones []PTRZone
+	for start := first; start <= last; start += zoneSize {
+		block, err := c.ParseCIDR(fmt.Sprintf("%s/%d", uint32ToIPv4(uint32(start)), zonePrefix))
+		if err != nil {
+			return nil, err
+		}
+		zone := PTRZone{Block: block}
+
+		octets := zonePrefix / 8
+		if classless {
+			octets = 3
+			zone.Name = fmt.Sprintf("%d/%d.%s", start&0xff, zonePrefix, reverseName(block.NetworkID, 3))
+		} else {
+			zone.Name = reverseName(block.NetworkID, octets)
+		}
+
+		for address := start; address < start+zoneSize; address++ {
+			if address < firstUsable || address > lastUsable {
+				continue
+			}
+			ip := uint32ToIPv4(uint32(address))
+			// The owner is the address octets below the zone cut, most specific first
+			var labels []string
+			for i := 3; i >= octets; i-- {
+				labels = append(labels, fmt.Sprint(ip[i]))
+			}
+			owner := strings.Join(labels, ".")
+			zone.Records = append(zone.Records, PTRRecord{Owner: owner, Target: expandHostname(options.Template, options.Domain, ip)})
+			if classless {
+				zone.Delegation = append(zone.Delegation, PTRRecord{Owner: owner, Target: owner + "." + zone.Name + "."})
+			}
+		}
+		zones = append(zones, zone)
+	}
+
+	return zones, nil
+}
+
+// FormatPTRZone renders a zone file: SOA, NS records and the PTR records. A
+// classless zone ends with the CNAMEs to add to its parent zone as comments.
+func (f *OutputFormatter) FormatPTRZone(zone PTRZone, options PTRZoneOptions) string {
+	var output strings.Builder
+
+	domain := strings.TrimSuffix(options.Domain, ".")
+	nameServers := options.NameServers
+	if len(nameServers) == 0 {
+		nameServers = []string{"ns1." + domain + "."}
+	}
+
+	output.WriteString(fmt.Sprintf("; Reverse zone for %s\n", zone.Block.CIDR()))
+	output.WriteString(fmt.Sprintf("$ORIGIN %s.\n", zone.Name))
+	output.WriteString(fmt.Sprintf("$TTL %d\n", options.TTL))
+	output.WriteString(fmt.Sprintf("@\tIN\tSOA\t%s hostmaster.%s. (\n", nameServers[0], domain))
+	output.WriteString(fmt.Sprintf("\t\t\t%s\t; serial\n", options.Serial))
+	output.WriteString("\t\t\t3600\t\t; refresh\n")
+	output.WriteString("\t\t\t900\t\t; retry\n")
+	output.WriteString("\t\t\t1209600\t\t; expire\n")
+	output.WriteString(fmt.Sprintf("\t\t\t%d )\t\t; negative caching TTL\n", options.TTL))
+	for _, server := range nameServers {
+		output.WriteString(fmt.Sprintf("@\tIN\tNS\t%s\n", server))
+	}
+	output.WriteString("\n")
+
+	for _, record := range zone.Records {
+		output.WriteString(fmt.Sprintf("%s\tIN\tPTR\t%s\n", record.Owner, record.Target))
+	}
+
+	if len(zone.Delegation) > 0 {
+		parent := strings.SplitN(zone.Name, ".", 2)[1]
+		output.WriteString(fmt.Sprintf("\n; Add to the parent zone %s to delegate these addresses (RFC 2317):\n", parent))
+		output.WriteString(fmt.Sprintf(";   %s\tIN\tNS\t%s\n", strings.SplitN(zone.Name, ".", 2)[0], nameServers[0]))
+		for _, record := range zone.Delegation {
+			output.WriteString(fmt.Sprintf(";   %s\tIN\tCNAME\t%s\n", record.Owner, record.Target))
+		}
+	}
+
+	return output.String()
+}
+
+// zoneFilename returns the file a zone is written to in --dir; the slash of a
+// classless zone name becomes a dash
+func zoneFilename(zone PTRZone) string {
+	return strings.ReplaceAll(zone.Name, "/", "-") + ".zone"
+}
+
+// runPTRZone implements the ptr-zone subcommand
+func (c *CLIHandler) runPTRZone(args []string) error {
+	flagSet := flag.NewFlagSet("ptr-zone", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	options := PTRZoneOptions{}
+	var nameServers stringList
+	var dir, outputFile string
+	flagSet.StringVar(&options.Template, "template", defaultPTRTemplate, "Hostname template with {a} {b} {c} {d} (octets) and {ip} (dashed address)")
+	flagSet.StringVar(&options.Domain, "domain", "", "Domain appended to relative hostnames and used for the SOA")
+	flagSet.Var(&nameServers, "ns", "Name server of the zone (repeatable; default ns1.DOMAIN)")
+	flagSet.IntVar(&options.TTL, "ttl", 3600, "Default TTL of the records")
+	flagSet.StringVar(&options.Serial, "serial", time.Now().UTC().Format("20060102")+"01", "SOA serial")
+	flagSet.StringVar(&dir, "dir", "", "Write one <zone>.zone file per zone into this directory")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept the CIDR anywhere among the flags
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("ptr-zone takes a single CIDR, got %d", len(positional))
+	}
+	if options.Domain == "" {
+		return fmt.Errorf("ptr-zone requires --domain")
+	}
+	for _, server := range nameServers {
+		options.NameServers = append(options.NameServers, strings.TrimSuffix(server, ".")+".")
+	}
+
+	network, err := c.calculator.ParseCIDR(positional[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse CIDR %s: %v", positional[0], err)
+	}
+	zones, err := c.calculator.PTRZones(network, options)
+	if err != nil {
+		return err
+	}
+
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %v", dir, err)
+		}
+		for _, zone := range zones {
+			path := filepath.Join(dir, zoneFilename(zone))
+			if err := os.WriteFile(path, []byte(c.formatter.FormatPTRZone(zone, options)), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %v", path, err)
+			}
+		}
+		c.notef("wrote %d zone files to %s", len(zones), dir)
+		return nil
+	}
+
+	if len(zones) > 1 {
+		c.notef("%s spans %d zones; use --dir to write one file per zone", network.CIDR(), len(zones))
+	}
+	rendered := make([]string, len(zones))
+	for i, zone := range zones {
+		rendered[i] = c.formatter.FormatPTRZone(zone, options)
+	}
+	return c.writeOutput(strings.Join(rendered, "\n"), outputFile)
+}
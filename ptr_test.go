@@ -0,0 +1,113 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIDRCalculator_PTRZones(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	options := PTRZoneOptions{Template: defaultPTRTemplate, Domain: "example.com", TTL: 3600, Serial: "2026101601"}
+
+	tests := []struct {
+		cidr    string
+		zones   []string
+		records int    // in the first zone
+		first   string // first record of the first zone
+	}{
+		{"10.1.0.0/16", []string{"1.10.in-addr.arpa"}, 65534, "1.0 host-10-1-0-1.example.com."},
+		{"10.1.0.0/22", []string{"0.1.10.in-addr.arpa", "1.1.10.in-addr.arpa", "2.1.10.in-addr.arpa", "3.1.10.in-addr.arpa"}, 255, "1 host-10-1-0-1.example.com."},
+		{"192.0.2.0/24", []string{"2.0.192.in-addr.arpa"}, 254, "1 host-192-0-2-1.example.com."},
+		{"192.0.2.64/26", []string{"64/26.2.0.192.in-addr.arpa"}, 62, "65 host-192-0-2-65.example.com."},
+	}
+
+	for _, tt := range tests {
+		zones, err := calculator.PTRZones(mustParseCIDR(t, calculator, tt.cidr), options)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.cidr, err)
+		}
+		var names []string
+		for _, zone := range zones {
+			names = append(names, zone.Name)
+		}
+		if strings.Join(names, " ") != strings.Join(tt.zones, " ") {
+			t.Errorf("%s: expected zones %v, got %v", tt.cidr, tt.zones, names)
+		}
+		first := zones[0].Records[0]
+		if len(zones[0].Records) != tt.records || first.Owner+" "+first.Target != tt.first {
+			t.Errorf("%s: expected %d records starting with %q, got %d starting with %q",
+				tt.cidr, tt.records, tt.first, len(zones[0].Records), first.Owner+" "+first.Target)
+		}
+	}
+
+	if _, err := calculator.PTRZones(mustParseCIDR(t, calculator, "10.0.0.0/8"), options); err == nil || !strings.Contains(err.Error(), "use a longer prefix") {
+		t.Errorf("expected a size error, got %v", err)
+	}
+	if _, err := calculator.PTRZones(mustParseCIDR(t, calculator, "2001:db8::/120"), options); err == nil {
+		t.Error("expected IPv6 to be rejected")
+	}
+}
+
+func mustParseCIDR(t *testing.T, calculator *CIDRCalculator, cidr string) *NetworkInfo {
+	t.Helper()
+	info, err := calculator.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", cidr, err)
+	}
+	return info
+}
+
+func TestExpandHostname(t *testing.T) {
+	ip := mustParseCIDR(t, NewCIDRCalculator(), "10.1.2.3/32").NetworkID
+	tests := map[string]string{
+		"host-{ip}":           "host-10-1-2-3.example.com.",
+		"{d}.{c}.rack":        "3.2.rack.example.com.",
+		"gw-{a}-{b}.corp.io.": "gw-10-1.corp.io.",
+	}
+	for template, expected := range tests {
+		if name := expandHostname(template, "example.com.", ip); name != expected {
+			t.Errorf("%s: expected %s, got %s", template, expected, name)
+		}
+	}
+}
+
+func TestCLIHandler_PTRZone(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+	output := filepath.Join(dir, "zone.txt")
+
+	if err := handler.Run([]string{"cidr-calc", "ptr-zone", "192.0.2.128/30", "--domain", "example.com", "--ns", "ns1.example.net", "--ns", "ns2.example.net.", "--serial", "7", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	for _, expected := range []string{
+		"$ORIGIN 128/30.2.0.192.in-addr.arpa.\n",
+		"@\tIN\tSOA\tns1.example.net. hostmaster.example.com. (\n\t\t\t7\t; serial\n",
+		"@\tIN\tNS\tns2.example.net.\n",
+		"129\tIN\tPTR\thost-192-0-2-129.example.com.\n",
+		"; Add to the parent zone 2.0.192.in-addr.arpa to delegate these addresses (RFC 2317):\n",
+		";   130\tIN\tCNAME\t130.128/30.2.0.192.in-addr.arpa.\n",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected zone to contain %q, got:\n%s", expected, content)
+		}
+	}
+
+	zones := filepath.Join(dir, "zones")
+	if err := handler.Run([]string{"cidr-calc", "ptr-zone", "10.1.0.0/23", "--domain", "example.com", "--dir", zones}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range []string{"0.1.10.in-addr.arpa.zone", "1.1.10.in-addr.arpa.zone"} {
+		if _, err := os.Stat(filepath.Join(zones, name)); err != nil {
+			t.Errorf("expected %s to be written: %v", name, err)
+		}
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "ptr-zone", "10.1.0.0/24"}); err == nil || !strings.Contains(err.Error(), "requires --domain") {
+		t.Errorf("expected a --domain error, got %v", err)
+	}
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultQuotaKey is the tag that names the tenant of an allocation
+const defaultQuotaKey = "tenant"
+
+// defaultQuotaWarn is the percent of a quota at which a tenant is warned about
+const defaultQuotaWarn = 80
+
+// QuotaPolicy holds the address quota of each tenant, read from a file of
+// tenant and quota lines. A quota is an address count or a prefix length
+// standing for the addresses of one such block:
+//
+//	payments  /20    # cost center 4711
+//	search    8192
+//
+// Allocations belong to the tenant named by their Key tag, e.g. tenant=search;
+// only IPv4 allocations count against a quota.
+type QuotaPolicy struct {
+	Key      string // tag naming the tenant of an allocation
+	Warn     int    // percent of a quota at which a tenant is warned about
+	quotas   map[string]uint64
+	comments map[string]string
+	tenants  []string // in file order
+}
+
+// parseQuotas reads a quota file
+func parseQuotas(source string, reader io.Reader) (*QuotaPolicy, error) {
+	policy := &QuotaPolicy{Key: defaultQuotaKey, Warn: defaultQuotaWarn, quotas: make(map[string]uint64), comments: make(map[string]string)}
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line, comment, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s line %d: expected a tenant and a quota, got %q", source, lineNumber, strings.TrimSpace(line))
+		}
+
+		tenant := fields[0]
+		if _, ok := policy.quotas[tenant]; ok {
+			return nil, fmt.Errorf("%s line %d: tenant %s already has a quota", source, lineNumber, tenant)
+		}
+		quota, err := parseQuota(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", source, lineNumber, err)
+		}
+		policy.quotas[tenant] = quota
+		policy.comments[tenant] = strings.TrimSpace(comment)
+		policy.tenants = append(policy.tenants, tenant)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", source, err)
+	}
+	return policy, nil
+}
+
+// parseQuota parses an address count such as 4096 or a prefix such as /20
+func parseQuota(value string) (uint64, error) {
+	if strings.HasPrefix(value, "/") {
+		prefix, err := strconv.Atoi(value[1:])
+		if err != nil || prefix < 0 || prefix > 32 {
+			return 0, fmt.Errorf("invalid quota %s: expected a prefix length between /0 and /32", value)
+		}
+		return uint64(1) << uint(32-prefix), nil
+	}
+	quota, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || quota == 0 {
+		return 0, fmt.Errorf("invalid quota %s: expected an address count or a prefix length such as /20", value)
+	}
+	return quota, nil
+}
+
+// LoadQuotaPolicy reads a quota file; allocations are grouped by the key tag
+// and tenants are warned about at warn percent of their quota
+func LoadQuotaPolicy(filename, key string, warn int) (*QuotaPolicy, error) {
+	if key == "" || strings.ContainsAny(key, " \t#=") {
+		return nil, fmt.Errorf("invalid tenant tag %q", key)
+	}
+	if warn < 1 || warn > 100 {
+		return nil, fmt.Errorf("the warning threshold must be between 1 and 100 percent, got %d", warn)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quota file: %v", err)
+	}
+
+	// A plan document gives its quotas and, unless --by is set, its quota tag
+	var policy *QuotaPolicy
+	if isPlanDocument(content) {
+		document, err := parsePlanDocument(filename, content)
+		if err != nil {
+			return nil, err
+		}
+		policy = document.QuotaPolicy()
+		if key == defaultQuotaKey {
+			key = policy.Key
+		}
+	} else if policy, err = parseQuotas(filename, bytes.NewReader(content)); err != nil {
+		return nil, err
+	}
+	policy.Key = key
+	policy.Warn = warn
+	return policy, nil
+}
+
+// TenantUsage is the address consumption of one tenant. Quota is 0 when the
+// tenant has allocations but no quota.
+type TenantUsage struct {
+	Tenant      string
+	Allocations int
+	Used        uint64
+	Quota       uint64
+}
+
+// Percent returns the share of the quota in use, rounded down
+func (u TenantUsage) Percent() uint64 {
+	if u.Quota == 0 {
+		return 0
+	}
+	return u.Used * 100 / u.Quota
+}
+
+// Usage adds up the allocations of every tenant: tenants with a quota in file
+// order, then tenants without one by name. Allocations without the tenant tag
+// are not counted.
+func (p *QuotaPolicy) Usage(allocations []planNetwork) []TenantUsage {
+	usage := make(map[string]*TenantUsage)
+	for _, tenant := range p.tenants {
+		usage[tenant] = &TenantUsage{Tenant: tenant, Quota: p.quotas[tenant]}
+	}
+
+	var unlisted []string
+	for _, allocation := range allocations {
+		tenant := allocation.entry.Tags[p.Key]
+		if tenant == "" || allocation.info.IsIPv6() {
+			continue
+		}
+		if usage[tenant] == nil {
+			usage[tenant] = &TenantUsage{Tenant: tenant}
+			unlisted = append(unlisted, tenant)
+		}
+		usage[tenant].Allocations++
+		usage[tenant].Used += uint64(1) << uint(32-allocation.info.PrefixLength)
+	}
+	sort.Strings(unlisted)
+
+	result := make([]TenantUsage, 0, len(usage))
+	for _, tenant := range append(append([]string{}, p.tenants...), unlisted...) {
+		result = append(result, *usage[tenant])
+	}
+	return result
+}
+
+// Warning describes a tenant at or over its warning threshold, or with
+// allocations but no quota, e.g. "tenant payments at 87% of quota (3584 of
+// 4096 addresses)"; it is empty for tenants within their quota
+func (p *QuotaPolicy) Warning(usage TenantUsage) string {
+	switch {
+	case usage.Quota == 0:
+		return fmt.Sprintf("tenant %s has %d addresses but no quota", usage.Tenant, usage.Used)
+	case usage.Used > usage.Quota:
+		return fmt.Sprintf("tenant %s over quota at %d%% (%d of %d addresses)", usage.Tenant, usage.Percent(), usage.Used, usage.Quota)
+	case usage.Percent() >= uint64(p.Warn):
+		return fmt.Sprintf("tenant %s at %d%% of quota (%d of %d addresses)", usage.Tenant, usage.Percent(), usage.Used, usage.Quota)
+	}
+	return ""
+}
+
+// QuotaReport is the quota usage of the tenants of an IPAM state
+type QuotaReport struct {
+	State    string
+	Key      string
+	Tenants  []TenantUsage
+	Warnings []string
+}
+
+// quotaReport reads the allocations of a state and measures them against the policy
+func (c *CLIHandler) quotaReport(stateFile string, policy *QuotaPolicy) (*QuotaReport, error) {
+	allocations, _, err := c.usedRanges(stateFile, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &QuotaReport{State: stateFile, Key: policy.Key, Tenants: policy.Usage(allocations)}
+	for _, usage := range report.Tenants {
+		if warning := policy.Warning(usage); warning != "" {
+			report.Warnings = append(report.Warnings, warning)
+		}
+	}
+	return report, nil
+}
+
+// checkQuota rejects an allocation that would take a tenant over its quota and
+// returns the warning, if any, for the tenant after the allocation
+func (p *QuotaPolicy) checkQuota(allocations []planNetwork, tenant string, block *NetworkInfo) (string, error) {
+	entry := BatchEntry{CIDR: block.CIDR(), Tags: Tags{p.Key: tenant}}
+	for _, usage := range p.Usage(append(append([]planNetwork{}, allocations...), planNetwork{entry: entry, info: block})) {
+		if usage.Tenant != tenant {
+			continue
+		}
+		if usage.Quota == 0 {
+			return "", fmt.Errorf("tenant %s has no quota", tenant)
+		}
+		if usage.Used > usage.Quota {
+			return "", fmt.Errorf("allocating %s would take tenant %s to %d of its %d address quota", block.CIDR(), tenant, usage.Used, usage.Quota)
+		}
+		return p.Warning(usage), nil
+	}
+	return "", nil
+}
+
+// FormatQuotas renders the quota usage of every tenant followed by the warnings
+func (f *OutputFormatter) FormatQuotas(report *QuotaReport) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Quota Usage for %s (by %s):\n", report.State, report.Key))
+	if len(report.Tenants) == 0 {
+		output.WriteString("  no quotas or tenant allocations\n")
+	} else {
+		output.WriteString(fmt.Sprintf("  %-16s %-12s %-10s %-10s %s\n", "Tenant", "Allocations", "Used", "Quota", "Use"))
+	}
+	for _, usage := range report.Tenants {
+		quota, percent := "-", "-"
+		if usage.Quota > 0 {
+			quota = fmt.Sprint(usage.Quota)
+			percent = fmt.Sprintf("%d%%", usage.Percent())
+		}
+		output.WriteString(fmt.Sprintf("  %-16s %-12d %-10d %-10s %s\n", usage.Tenant, usage.Allocations, usage.Used, quota, percent))
+	}
+
+	if len(report.Warnings) > 0 {
+		output.WriteString("\nWarnings:\n")
+		for _, warning := range report.Warnings {
+			output.WriteString("  " + warning + "\n")
+		}
+	}
+
+	return output.String()
+}
+
+// jsonQuotaReport is the quota usage for chargeback tools and the API
+type jsonQuotaReport struct {
+	State    string            `json:"state"`
+	Key      string            `json:"key"`
+	Tenants  []jsonTenantQuota `json:"tenants"`
+	Warnings []string          `json:"warnings"`
+}
+
+// jsonTenantQuota is the usage of one tenant; quota and percent are omitted
+// for tenants without a quota
+type jsonTenantQuota struct {
+	Tenant      string  `json:"tenant"`
+	Allocations int     `json:"allocations"`
+	Used        uint64  `json:"used"`
+	Quota       *uint64 `json:"quota,omitempty"`
+	Percent     *uint64 `json:"percent,omitempty"`
+}
+
+// jsonQuotas converts a quota report to its JSON model
+func jsonQuotas(report *QuotaReport) jsonQuotaReport {
+	document := jsonQuotaReport{State: report.State, Key: report.Key, Tenants: []jsonTenantQuota{}, Warnings: report.Warnings}
+	if document.Warnings == nil {
+		document.Warnings = []string{}
+	}
+	for _, usage := range report.Tenants {
+		tenant := jsonTenantQuota{Tenant: usage.Tenant, Allocations: usage.Allocations, Used: usage.Used}
+		if usage.Quota > 0 {
+			quota, percent := usage.Quota, usage.Percent()
+			tenant.Quota, tenant.Percent = &quota, &percent
+		}
+		document.Tenants = append(document.Tenants, tenant)
+	}
+	return document
+}
+
+// FormatQuotasAsJSON renders the quota usage as JSON
+func (f *OutputFormatter) FormatQuotasAsJSON(report *QuotaReport) (string, error) {
+	content, err := json.MarshalIndent(jsonQuotas(report), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %v", err)
+	}
+	return string(content) + "\n", nil
+}
+
+// quotaFlags defines the flags that load a quota policy
+type quotaFlags struct {
+	file string
+	key  string
+	warn int
+}
+
+// define adds --quotas, --by and --warn to a flag set
+func (q *quotaFlags) define(flagSet *flag.FlagSet) {
+	flagSet.StringVar(&q.file, "quotas", "", "File of tenant quotas: TENANT COUNT or TENANT /PREFIX per line")
+	flagSet.StringVar(&q.key, "by", defaultQuotaKey, "Tag naming the tenant of an allocation")
+	flagSet.IntVar(&q.warn, "warn", defaultQuotaWarn, "Warn about tenants at this percent of their quota")
+}
+
+// load reads the quota file, or returns nil without --quotas
+func (q *quotaFlags) load() (*QuotaPolicy, error) {
+	if q.file == "" {
+		return nil, nil
+	}
+	return LoadQuotaPolicy(q.file, q.key, q.warn)
+}
+
+// runIPAMQuota implements the ipam quota command
+func (c *CLIHandler) runIPAMQuota(args []string) error {
+	flagSet := flag.NewFlagSet("ipam quota", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var quotas quotaFlags
+	var stateFile, format, outputFile string
+	quotas.define(flagSet)
+	flagSet.StringVar(&stateFile, "state", "", "Plan file or s3://, gs://, etcd://, consul:// URL of allocations")
+	flagSet.StringVar(&format, "format", FormatText, "Output format: text or json")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if flagSet.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", flagSet.Arg(0))
+	}
+	if stateFile == "" || quotas.file == "" {
+		return fmt.Errorf("ipam quota requires --state and --quotas")
+	}
+
+	policy, err := quotas.load()
+	if err != nil {
+		return err
+	}
+	report, err := c.quotaReport(stateFile, policy)
+	if err != nil {
+		return err
+	}
+	for _, warning := range report.Warnings {
+		c.warnf("%s", warning)
+	}
+
+	var content string
+	switch format {
+	case FormatText:
+		content = c.formatter.FormatQuotas(report)
+	case FormatJSON:
+		content, err = c.formatter.FormatQuotasAsJSON(report)
+	default:
+		return fmt.Errorf("ipam quota supports %s and %s output, not %s", FormatText, FormatJSON, format)
+	}
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(content, outputFile)
+}
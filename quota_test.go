@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseQuotas(t *testing.T) {
+	policy, err := parseQuotas("quotas.txt", strings.NewReader("# tenant quota\npayments /20 # cost center 4711\n\nsearch 8192\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy.quotas["payments"] != 4096 || policy.quotas["search"] != 8192 || strings.Join(policy.tenants, ",") != "payments,search" {
+		t.Errorf("unexpected policy %+v", policy)
+	}
+
+	errorTests := map[string]string{
+		"payments":               "quotas.txt line 1: expected a tenant and a quota",
+		"payments /33":           "invalid quota /33",
+		"payments 0":             "invalid quota 0",
+		"payments 1\npayments 2": "quotas.txt line 2: tenant payments already has a quota",
+	}
+	for content, expected := range errorTests {
+		if _, err := parseQuotas("quotas.txt", strings.NewReader(content)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%q: expected error %q, got %v", content, expected, err)
+		}
+	}
+}
+
+func TestQuotaPolicy_Usage(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	policy, _ := parseQuotas("quotas.txt", strings.NewReader("payments /20\nsearch 8192\n"))
+
+	var allocations []planNetwork
+	for _, line := range []string{"10.0.0.0/21 tenant=payments", "10.0.8.0/22 tenant=payments", "10.1.0.0/24 tenant=lab", "10.2.0.0/24", "2001:db8::/64 tenant=search"} {
+		entries, _ := parseBatch("ipam.txt", strings.NewReader(line))
+		info, _ := calculator.ParseCIDR(entries[0].CIDR)
+		allocations = append(allocations, planNetwork{entry: entries[0], info: info})
+	}
+
+	usage := policy.Usage(allocations)
+	expected := []TenantUsage{
+		{Tenant: "payments", Allocations: 2, Used: 3072, Quota: 4096},
+		{Tenant: "search", Quota: 8192},
+		{Tenant: "lab", Allocations: 1, Used: 256},
+	}
+	if len(usage) != len(expected) {
+		t.Fatalf("expected %+v, got %+v", expected, usage)
+	}
+	for i := range expected {
+		if usage[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], usage[i])
+		}
+	}
+
+	warnings := []string{"tenant payments at 75% of quota (3072 of 4096 addresses)", "", "tenant lab has 256 addresses but no quota"}
+	policy.Warn = 75
+	for i, warning := range warnings {
+		if got := policy.Warning(usage[i]); got != warning {
+			t.Errorf("expected warning %q, got %q", warning, got)
+		}
+	}
+
+	block, _ := calculator.ParseCIDR("10.0.12.0/22")
+	if warning, err := policy.checkQuota(allocations, "payments", block); err != nil || warning != "tenant payments at 100% of quota (4096 of 4096 addresses)" {
+		t.Errorf("unexpected quota check %q (%v)", warning, err)
+	}
+	block, _ = calculator.ParseCIDR("10.0.16.0/21")
+	if _, err := policy.checkQuota(allocations, "payments", block); err == nil || !strings.Contains(err.Error(), "would take tenant payments to 5120 of its 4096 address quota") {
+		t.Errorf("expected an over-quota error, got %v", err)
+	}
+	if _, err := policy.checkQuota(allocations, "lab", block); err == nil || !strings.Contains(err.Error(), "tenant lab has no quota") {
+		t.Errorf("expected a missing quota error, got %v", err)
+	}
+}
+
+func TestCLIHandler_IPAMQuota(t *testing.T) {
+	handler := NewCLIHandler()
+	var stderr strings.Builder
+	handler.stderr = &stderr
+	dir := t.TempDir()
+
+	state := filepath.Join(dir, "ipam.txt")
+	quotas := filepath.Join(dir, "quotas.txt")
+	output := filepath.Join(dir, "out.txt")
+	os.WriteFile(quotas, []byte("payments /20\n"), 0644)
+
+	for _, prefix := range []string{"21", "22", "23"} {
+		if err := handler.Run([]string{"cidr-calc", "ipam", "allocate", "--pool", "10.0.0.0/16", "--prefix", prefix, "--state", state, "--tenant", "payments", "--quotas", quotas, "-o", output}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if !strings.Contains(stderr.String(), "Warning: tenant payments at 87% of quota (3584 of 4096 addresses)") {
+		t.Errorf("expected a quota warning, got:\n%s", stderr.String())
+	}
+	err := handler.Run([]string{"cidr-calc", "ipam", "allocate", "--pool", "10.0.0.0/16", "--prefix", "22", "--state", state, "--tenant", "payments", "--quotas", quotas})
+	if err == nil || !strings.Contains(err.Error(), "would take tenant payments to 4608 of its 4096 address quota") {
+		t.Errorf("expected an over-quota error, got %v", err)
+	}
+	if err := handler.Run([]string{"cidr-calc", "ipam", "allocate", "--pool", "10.0.0.0/16", "--prefix", "22", "--quotas", quotas}); err == nil || !strings.Contains(err.Error(), "--quotas requires --tenant") {
+		t.Errorf("expected a missing tenant error, got %v", err)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "ipam", "quota", "--state", state, "--quotas", quotas, "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	if !strings.Contains(string(content), "  payments         3            3584       4096       87%\n") ||
+		!strings.Contains(string(content), "Warnings:\n  tenant payments at 87% of quota") {
+		t.Errorf("unexpected report:\n%s", content)
+	}
+
+	// The API serves the same report, read from the state on every request
+	api := NewAPIServer(handler, "", nil)
+	policy, _ := LoadQuotaPolicy(quotas, defaultQuotaKey, defaultQuotaWarn)
+	api.ServeQuotas(state, policy)
+	handler.stderr = io.Discard
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/v1/quotas")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer response.Body.Close()
+	var document jsonQuotaReport
+	if err := json.NewDecoder(response.Body).Decode(&document); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(document.Tenants) != 1 || document.Tenants[0].Used != 3584 || *document.Tenants[0].Percent != 87 || len(document.Warnings) != 1 {
+		t.Errorf("unexpected document: %+v", document)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Discovered range rule names
+const (
+	RulePlanConflict = "plan-conflict"
+	RuleRangeOverlap = "range-overlap"
+)
+
+// DiscoveredRange is an address range found in use on a live system, such as a
+// node's pod CIDR or a Docker network subnet
+type DiscoveredRange struct {
+	Kind   string
+	Source string
+	CIDR   string
+}
+
+// RangeChecker compares discovered ranges with each other and with a plan
+type RangeChecker struct {
+	calculator *CIDRCalculator
+}
+
+// NewRangeChecker creates a new range checker instance
+func NewRangeChecker() *RangeChecker {
+	return &RangeChecker{calculator: NewCIDRCalculator()}
+}
+
+// Check reports discovered ranges that overlap each other, any fixed range, or
+// any CIDR in the plan. Fixed ranges, such as host interfaces, are not compared
+// with each other. Plan conflicts are located at the plan entry so they annotate
+// the plan file.
+func (r *RangeChecker) Check(ranges, fixed []DiscoveredRange, plan []BatchEntry) []Finding {
+	var findings []Finding
+
+	var planned []planNetwork
+	for _, entry := range plan {
+		info, err := r.calculator.ParseCIDR(entry.CIDR)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Rule:     RuleInvalidCIDR,
+				Message:  err.Error(),
+				File:     entry.Source,
+				Line:     entry.Line,
+				CIDR:     entry.CIDR,
+			})
+			continue
+		}
+		planned = append(planned, planNetwork{entry: entry, info: info})
+	}
+
+	var fixedNetworks []*NetworkInfo
+	var fixedRanges []DiscoveredRange
+	for _, existing := range fixed {
+		if info, err := r.calculator.ParseCIDR(existing.CIDR); err == nil {
+			fixedNetworks = append(fixedNetworks, info)
+			fixedRanges = append(fixedRanges, existing)
+		}
+	}
+
+	var parsed []*NetworkInfo
+	for i, discovered := range ranges {
+		info, err := r.calculator.ParseCIDR(discovered.CIDR)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Rule:     RuleInvalidCIDR,
+				Message:  fmt.Sprintf("%s range %q (%s): %v", discovered.Kind, discovered.CIDR, discovered.Source, err),
+				CIDR:     discovered.CIDR,
+			})
+			parsed = append(parsed, nil)
+			continue
+		}
+		parsed = append(parsed, info)
+
+		for j, other := range parsed[:i] {
+			if other == nil || ranges[j].Source == discovered.Source {
+				continue
+			}
+			findings = append(findings, r.overlapFindings(discovered, info, ranges[j], other)...)
+		}
+
+		for j, other := range fixedNetworks {
+			findings = append(findings, r.overlapFindings(discovered, info, fixedRanges[j], other)...)
+		}
+
+		for _, network := range planned {
+			if !info.Overlaps(network.info) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Rule:     RulePlanConflict,
+				Message:  fmt.Sprintf("%s range %s (%s) overlaps planned %s", discovered.Kind, info.CIDR(), discovered.Source, network.info.CIDR()),
+				File:     network.entry.Source,
+				Line:     network.entry.Line,
+				CIDR:     discovered.CIDR,
+			})
+		}
+	}
+
+	return findings
+}
+
+// overlapFindings returns a range-overlap finding when the two ranges share addresses
+func (r *RangeChecker) overlapFindings(discovered DiscoveredRange, info *NetworkInfo, other DiscoveredRange, otherInfo *NetworkInfo) []Finding {
+	if !info.Overlaps(otherInfo) {
+		return nil
+	}
+	return []Finding{{
+		Severity: SeverityError,
+		Rule:     RuleRangeOverlap,
+		Message: fmt.Sprintf("%s range %s (%s) overlaps %s range %s (%s)",
+			discovered.Kind, info.CIDR(), discovered.Source, other.Kind, otherInfo.CIDR(), other.Source),
+		CIDR: discovered.CIDR,
+	}}
+}
+
+// rangeToCIDRs converts an IPv4 address range written as "first-last" into the
+// CIDR blocks that cover it exactly; plain CIDRs are returned unchanged
+func rangeToCIDRs(value string) ([]string, error) {
+	first, last, ok := strings.Cut(value, "-")
+	if !ok {
+		return []string{strings.TrimSpace(value)}, nil
+	}
+
+	start := net.ParseIP(strings.TrimSpace(first)).To4()
+	end := net.ParseIP(strings.TrimSpace(last)).To4()
+	if start == nil || end == nil {
+		return nil, fmt.Errorf("invalid IPv4 address range: %s", value)
+	}
+	if ipv4ToUint32(start) > ipv4ToUint32(end) {
+		return nil, fmt.Errorf("address range ends before it starts: %s", value)
+	}
+
+	return alignedBlocks(uint64(ipv4ToUint32(start)), uint64(ipv4ToUint32(end))), nil
+}
+
+// FormatDiscoveredRanges renders discovered ranges as a table followed by the findings
+func (f *OutputFormatter) FormatDiscoveredRanges(title string, ranges []DiscoveredRange, findings []Finding) string {
+	var output strings.Builder
+
+	output.WriteString(title + ":\n")
+	if len(ranges) == 0 {
+		output.WriteString("  No ranges found\n")
+	} else {
+		output.WriteString(fmt.Sprintf("  %-14s %-18s %s\n", "Kind", "CIDR", "Source"))
+		for _, discovered := range ranges {
+			output.WriteString(fmt.Sprintf("  %-14s %-18s %s\n", discovered.Kind, discovered.CIDR, discovered.Source))
+		}
+	}
+
+	output.WriteString("\n")
+	output.WriteString(f.FormatFindings(findings))
+
+	return output.String()
+}
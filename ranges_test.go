@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRangeChecker_Check(t *testing.T) {
+	ranges := []DiscoveredRange{
+		{Kind: KindPodCIDR, Source: "node/a", CIDR: "10.244.0.0/24"},
+		{Kind: KindPodCIDR, Source: "node/b", CIDR: "10.244.0.0/25"},
+		{Kind: KindServiceCIDR, Source: "servicecidr/kubernetes", CIDR: "10.96.0.0/12"},
+		{Kind: KindLoadBalancer, Source: "ipaddresspool/metallb-system/lan", CIDR: "not-a-cidr"},
+	}
+	plan := []BatchEntry{
+		{Source: "plan.txt", Line: 1, CIDR: "10.100.0.0/16"},
+		{Source: "plan.txt", Line: 2, CIDR: "192.168.0.0/16"},
+	}
+
+	findings := NewRangeChecker().Check(ranges, nil, plan)
+
+	expected := []struct {
+		rule     string
+		location string
+	}{
+		{RuleRangeOverlap, ""},
+		{RulePlanConflict, "plan.txt:1"},
+		{RuleInvalidCIDR, ""},
+	}
+
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for i, exp := range expected {
+		if findings[i].Rule != exp.rule || findings[i].Location() != exp.location || findings[i].Severity != SeverityError {
+			t.Errorf("finding %d: expected error %s at %q, got %+v", i, exp.rule, exp.location, findings[i])
+		}
+	}
+	if !strings.Contains(findings[1].Message, "service range 10.96.0.0/12 (servicecidr/kubernetes) overlaps planned 10.100.0.0/16") {
+		t.Errorf("unexpected plan conflict message: %q", findings[1].Message)
+	}
+}
+
+func TestRangeToCIDRs(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []string
+		wantErr  bool
+	}{
+		{value: "192.168.10.0/24", expected: []string{"192.168.10.0/24"}},
+		{value: "192.168.10.0-192.168.10.255", expected: []string{"192.168.10.0/24"}},
+		{value: "192.168.10.10 - 192.168.10.20", expected: []string{"192.168.10.10/31", "192.168.10.12/30", "192.168.10.16/30", "192.168.10.20/32"}},
+		{value: "192.168.10.20-192.168.10.10", wantErr: true},
+		{value: "fd00::1-fd00::ff", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := rangeToCIDRs(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rangeToCIDRs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestOutputFormatter_FormatDiscoveredRanges(t *testing.T) {
+	formatter := NewOutputFormatter()
+
+	output := formatter.FormatDiscoveredRanges("Test Audit", []DiscoveredRange{{Kind: KindPodCIDR, Source: "node/a", CIDR: "10.244.0.0/24"}}, nil)
+	for _, exp := range []string{"Test Audit:\n", "  pod            10.244.0.0/24      node/a\n", "No problems found"} {
+		if !strings.Contains(output, exp) {
+			t.Errorf("expected output to contain %q, got:\n%s", exp, output)
+		}
+	}
+
+	if output := formatter.FormatDiscoveredRanges("Empty", nil, nil); !strings.Contains(output, "No ranges found") {
+		t.Errorf("expected empty message, got:\n%s", output)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Relabel is a bulk edit of the labels of the ranges a pattern and tag
+// conditions select
+type Relabel struct {
+	Match  string // glob matched against the name label, annotation or CIDR
+	Tags   tagFilterList
+	Set    Tags
+	Unset  []string
+	Rename Tags // old label key to new
+}
+
+// RelabelChange is the edit made to one range
+type RelabelChange struct {
+	CIDR    string
+	Name    string
+	Changes []string // such as "set owner=netops" or "rename team to owner"
+}
+
+// globPattern compiles a shell glob, where * matches any text and ? one
+// character, anchored at both ends
+func globPattern(glob string) (*regexp.Regexp, error) {
+	pattern := regexp.QuoteMeta(glob)
+	pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+	pattern = strings.ReplaceAll(pattern, `\?`, ".")
+	return regexp.Compile("^" + pattern + "$")
+}
+
+// Selects reports whether the relabel applies to a range
+func (r *Relabel) Selects(planRange PlanRange, match *regexp.Regexp) bool {
+	if match != nil && !match.MatchString(planRange.Labels["name"]) && !match.MatchString(planRange.Annotation) && !match.MatchString(planRange.CIDR) {
+		return false
+	}
+	return r.Tags.Matches(planRange.Labels)
+}
+
+// Apply edits the labels of the selected ranges in place: keys are renamed
+// first, then unset, then set. It returns the number of ranges selected and
+// the changes; ranges whose labels end up unchanged are not listed.
+func (r *Relabel) Apply(ranges []PlanRange) (int, []RelabelChange, error) {
+	var match *regexp.Regexp
+	if r.Match != "" {
+		var err error
+		if match, err = globPattern(r.Match); err != nil {
+			return 0, nil, fmt.Errorf("invalid --match %q: %v", r.Match, err)
+		}
+	}
+
+	selected := 0
+	var changes []RelabelChange
+	for i := range ranges {
+		planRange := &ranges[i]
+		if !r.Selects(*planRange, match) {
+			continue
+		}
+		selected++
+		labels := Tags{}
+		for key, value := range planRange.Labels {
+			labels[key] = value
+		}
+
+		var edits []string
+		for _, old := range r.Rename.Keys() {
+			value, ok := labels[old]
+			if !ok {
+				continue
+			}
+			if _, taken := labels[r.Rename[old]]; taken {
+				return 0, nil, fmt.Errorf("cannot rename label %s of %s to %s: it already has one", old, planRange.CIDR, r.Rename[old])
+			}
+			delete(labels, old)
+			labels[r.Rename[old]] = value
+			edits = append(edits, fmt.Sprintf("rename %s to %s", old, r.Rename[old]))
+		}
+		for _, key := range r.Unset {
+			if _, ok := labels[key]; ok {
+				delete(labels, key)
+				edits = append(edits, "unset "+key)
+			}
+		}
+		for _, key := range r.Set.Keys() {
+			if value, ok := labels[key]; ok && value == r.Set[key] {
+				continue
+			}
+			labels[key] = r.Set[key]
+			edits = append(edits, fmt.Sprintf("set %s=%s", key, r.Set[key]))
+		}
+		if len(edits) == 0 {
+			continue
+		}
+
+		if len(labels) == 0 {
+			labels = nil
+		}
+		planRange.Labels = labels
+		name := labels["name"]
+		if name == "" {
+			name = planRange.Annotation
+		}
+		changes = append(changes, RelabelChange{CIDR: planRange.CIDR, Name: name, Changes: edits})
+	}
+	return selected, changes, nil
+}
+
+// FormatRelabel lists the edits of a relabel of a document section
+func (f *OutputFormatter) FormatRelabel(source, section string, selected int, changes []RelabelChange) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Relabel of %s (%s):\n", source, section))
+	if len(changes) == 0 {
+		output.WriteString(fmt.Sprintf("  no changes to the %s selected\n", plural(selected, "range")))
+		return output.String()
+	}
+	for _, change := range changes {
+		name := change.Name
+		if name == "" {
+			name = "-"
+		}
+		output.WriteString(fmt.Sprintf("  %-20s %-16s %s\n", change.CIDR, name, strings.Join(change.Changes, ", ")))
+	}
+	return output.String()
+}
+
+// labelList collects repeated KEY=VALUE flags into labels
+type labelList struct {
+	flag   string
+	labels *Tags
+}
+
+// String returns the labels in plan file syntax
+func (l labelList) String() string {
+	if l.labels == nil {
+		return ""
+	}
+	return l.labels.String()
+}
+
+// Set parses KEY=VALUE
+func (l labelList) Set(value string) error {
+	key, labelValue, ok := strings.Cut(value, "=")
+	if !ok || key == "" || strings.ContainsAny(key, " \t#") || strings.ContainsAny(labelValue, " \t#") {
+		return fmt.Errorf("invalid %s %q (expected KEY=VALUE without spaces or #)", l.flag, value)
+	}
+	if *l.labels == nil {
+		*l.labels = Tags{}
+	}
+	(*l.labels)[key] = labelValue
+	return nil
+}
+
+// runPlanRelabel bulk edits the labels of plan document ranges in place
+func (c *CLIHandler) runPlanRelabel(args []string) error {
+	flagSet := flag.NewFlagSet("plan relabel", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var relabel Relabel
+	var unset stringList
+	var rename Tags
+	var section, outputFile string
+	var dryRun bool
+	flagSet.StringVar(&relabel.Match, "match", "", "Select ranges whose name label, annotation or CIDR matches this glob, such as 'vlan-*'")
+	flagSet.Var(&relabel.Tags, "tag", "Select ranges with this key=value label, or with the key (repeatable)")
+	flagSet.Var(labelList{"--set", &relabel.Set}, "set", "Set this KEY=VALUE label on the selected ranges (repeatable)")
+	flagSet.Var(&unset, "unset", "Remove this label from the selected ranges (repeatable)")
+	flagSet.Var(labelList{"--rename", &rename}, "rename", "Rename the label OLD to NEW on the selected ranges, as OLD=NEW (repeatable)")
+	flagSet.StringVar(&section, "section", SectionAllocations, "Section to edit: allocations, pools or reserved")
+	flagSet.BoolVar(&dryRun, "dry-run", false, "List the changes without writing the documents")
+	flagSet.StringVar(&outputFile, "o", "", "Save the list of changes to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save the list of changes to file")
+
+	// Accept the documents anywhere among the flags
+	sources, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("plan relabel requires a plan document")
+	}
+	if relabel.Match == "" && len(relabel.Tags) == 0 {
+		return fmt.Errorf("plan relabel requires --match or --tag to select ranges")
+	}
+	relabel.Unset, relabel.Rename = unset, rename
+	if len(relabel.Set) == 0 && len(relabel.Unset) == 0 && len(relabel.Rename) == 0 {
+		return fmt.Errorf("plan relabel requires --set, --unset or --rename")
+	}
+
+	// Every document is relabeled before any is written, so an invalid one
+	// leaves them all as they were
+	type relabeledDocument struct {
+		source, content, note string
+	}
+	var relabeled []relabeledDocument
+	var report strings.Builder
+	for _, source := range sources {
+		if source == "-" {
+			return fmt.Errorf("plan relabel edits documents in place and cannot read standard input")
+		}
+		content, err := readSource(source)
+		if err != nil {
+			return err
+		}
+		if !isPlanDocument(content) {
+			return fmt.Errorf("%s is not a plan document; it should start with version: or {", source)
+		}
+		document, err := parsePlanDocument(source, content)
+		if err != nil {
+			return err
+		}
+		ranges, err := document.Section(section)
+		if err != nil {
+			return err
+		}
+
+		selected, changes, err := relabel.Apply(ranges)
+		if err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+		if report.Len() > 0 {
+			report.WriteString("\n")
+		}
+		report.WriteString(c.formatter.FormatRelabel(source, section, selected, changes))
+		if dryRun || len(changes) == 0 {
+			continue
+		}
+
+		if err := document.validate(source); err != nil {
+			return err
+		}
+		encoded, err := document.Encode(planDocumentFormat(source, content))
+		if err != nil {
+			return err
+		}
+		relabeled = append(relabeled, relabeledDocument{source, encoded, fmt.Sprintf("relabeled %d of the %d %s of %s", len(changes), len(ranges), section, source)})
+	}
+
+	for _, document := range relabeled {
+		if err := writeFileAtomic(document.source, []byte(document.content)); err != nil {
+			return err
+		}
+		c.notef("%s", document.note)
+	}
+	return c.writeOutput(report.String(), outputFile)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRelabelDocument is a plan document of VLAN allocations and an uplink
+const testRelabelDocument = `version: 2
+allocations:
+  - cidr: 10.0.10.0/24
+    labels:
+      name: vlan-10
+      team: ops
+  - cidr: 10.0.20.0/24
+    labels:
+      name: vlan-20
+      owner: netops
+  - cidr: 10.0.30.0/24
+    annotation: core uplink
+    labels:
+      team: ops
+`
+
+func TestRelabel_Apply(t *testing.T) {
+	document, err := parsePlanDocument("plan.yaml", []byte(testRelabelDocument))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	relabel := &Relabel{Match: "vlan-*", Set: Tags{"owner": "netops"}, Rename: Tags{"team": "squad"}}
+	selected, changes, err := relabel.Apply(document.Allocations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if selected != 2 || len(changes) != 1 || strings.Join(changes[0].Changes, ", ") != "rename team to squad, set owner=netops" {
+		t.Errorf("expected vlan-20 to be selected but unchanged, got %d selected and %+v", selected, changes)
+	}
+	if labels := document.Allocations[0].Labels.String(); labels != "name=vlan-10 owner=netops squad=ops" {
+		t.Errorf("unexpected labels %s", labels)
+	}
+
+	// The annotation, the CIDR and the tags select ranges too
+	testCases := []struct {
+		relabel  Relabel
+		expected []string
+	}{
+		{Relabel{Match: "core *"}, []string{"10.0.30.0/24"}},
+		{Relabel{Match: "10.0.?0.0/24"}, []string{"10.0.10.0/24", "10.0.20.0/24", "10.0.30.0/24"}},
+		{Relabel{Tags: tagFilterList{{Key: "team", Value: "ops"}}}, []string{"10.0.30.0/24"}},
+		{Relabel{Match: "vlan-*", Tags: tagFilterList{{Key: "owner", AnyValue: true}}}, []string{"10.0.10.0/24", "10.0.20.0/24"}},
+	}
+	for _, tt := range testCases {
+		tt.relabel.Set = Tags{"reviewed": "yes"}
+		ranges := append([]PlanRange(nil), document.Allocations...)
+		_, changes, err := tt.relabel.Apply(ranges)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var cidrs []string
+		for _, change := range changes {
+			cidrs = append(cidrs, change.CIDR)
+		}
+		if strings.Join(cidrs, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("%+v: expected %v, got %v", tt.relabel, tt.expected, cidrs)
+		}
+	}
+
+	unset := &Relabel{Match: "*", Unset: []string{"team", "owner", "squad", "name", "reviewed"}}
+	if _, _, err := unset.Apply(document.Allocations); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if document.Allocations[2].Labels != nil {
+		t.Errorf("expected no labels left, got %v", document.Allocations[2].Labels)
+	}
+
+	clash := &Relabel{Match: "*", Rename: Tags{"a": "b"}}
+	if _, _, err := clash.Apply([]PlanRange{{CIDR: "10.0.0.0/24", Labels: Tags{"a": "1", "b": "2"}}}); err == nil || err.Error() != "cannot rename label a of 10.0.0.0/24 to b: it already has one" {
+		t.Errorf("expected a rename clash, got %v", err)
+	}
+}
+
+func TestPlanRelabel_Command(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+	plan := filepath.Join(dir, "plan.yaml")
+	output := filepath.Join(dir, "changes.txt")
+	if err := os.WriteFile(plan, []byte(testRelabelDocument), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"cidr-calc", "plan", "relabel", plan, "--match", "vlan-*", "--set", "owner=netops", "--unset", "team", "-o", output}
+	if err := handler.Run(append(args, "--dry-run")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); string(content) != "Relabel of "+plan+" (allocations):\n  10.0.10.0/24         vlan-10          unset team, set owner=netops\n" {
+		t.Errorf("unexpected changes:\n%s", content)
+	}
+	if content, _ := os.ReadFile(plan); string(content) != testRelabelDocument {
+		t.Errorf("expected --dry-run to leave the document unchanged, got:\n%s", content)
+	}
+
+	if err := handler.Run(args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(plan)
+	if !strings.Contains(string(content), "  - cidr: 10.0.10.0/24\n    labels:\n      name: vlan-10\n      owner: netops\n  - cidr: 10.0.20.0/24") ||
+		!strings.Contains(string(content), "annotation: core uplink\n    labels:\n      team: ops\n") {
+		t.Errorf("unexpected document:\n%s", content)
+	}
+
+	// A second run finds nothing left to change
+	if err := handler.Run(args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if changes, _ := os.ReadFile(output); !strings.Contains(string(changes), "no changes to the 2 ranges selected") {
+		t.Errorf("expected no changes, got:\n%s", changes)
+	}
+
+	// An invalid document leaves every document unchanged
+	invalid := filepath.Join(dir, "invalid.yaml")
+	os.WriteFile(invalid, []byte("10.0.0.0/24 name=vlan-1\n"), 0644)
+	before, _ := os.ReadFile(plan)
+	if err := handler.Run([]string{"cidr-calc", "plan", "relabel", plan, invalid, "--match", "vlan-*", "--set", "site=ams"}); err == nil || !strings.Contains(err.Error(), "is not a plan document") {
+		t.Errorf("expected a plan document error, got %v", err)
+	}
+	if after, _ := os.ReadFile(plan); string(after) != string(before) {
+		t.Errorf("expected the first document to be left unchanged")
+	}
+
+	errorCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--match", "vlan-*", "--set", "a=b"}, "plan relabel requires a plan document"},
+		{[]string{plan, "--set", "a=b"}, "plan relabel requires --match or --tag to select ranges"},
+		{[]string{plan, "--match", "vlan-*"}, "plan relabel requires --set, --unset or --rename"},
+		{[]string{plan, "--match", "vlan-*", "--set", "a b"}, `invalid --set "a b"`},
+		{[]string{plan, "--match", "vlan-*", "--set", "a=b", "--section", "quotas"}, `unknown plan document section "quotas"`},
+	}
+	for _, tt := range errorCases {
+		if err := handler.Run(append([]string{"cidr-calc", "plan", "relabel"}, tt.args...)); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// replPrompt is printed before every interactive command
+const replPrompt = "cidr> "
+
+// replHelp lists the interactive commands
+const replHelp = `Commands:
+  CIDR                   Show the network and its subnets
+  split PREFIX [CIDR]    List every subnet at this prefix length
+  parts N [CIDR]         Divide the network into at least N equal subnets
+  hosts N [CIDR]         Split into the smallest subnets with N usable hosts
+  contains CIDR_A CIDR_B Show how two CIDRs relate
+  overlaps CIDR_A CIDR_B Show how two CIDRs relate
+  fav [LABEL]            Bookmark the last network shown with a label
+  favs                   List the favorites
+  unfav N                Remove favorite N
+  last                   Print the last result again
+  help                   Show this help
+  quit, exit             Leave (or press Ctrl-D)
+
+  _ stands for the last network shown and _N for its subnet N (from 0),
+  and @N for favorite N (from 1); split, parts and hosts use the last
+  network when CIDR is omitted.
+`
+
+// replSession is the state of an interactive session
+type replSession struct {
+	handler   *CLIHandler
+	out       io.Writer
+	last      *NetworkReport // the last network shown, recalled as _
+	output    string         // the last result, printed again by "last"
+	favorites *Favorites     // bookmarked networks, recalled as @N
+}
+
+// runInteractive reads commands from in until quit or end of input, printing
+// results and errors to out. CIDR arguments are shown before the first prompt,
+// or the favorites to pick from when there are none.
+func (c *CLIHandler) runInteractive(config *Config, in io.Reader, out io.Writer) error {
+	favorites, err := c.loadFavorites()
+	if err != nil {
+		return err
+	}
+	session := &replSession{handler: c, out: out, favorites: favorites}
+	for _, cidr := range config.CIDRs {
+		session.run(cidr)
+	}
+	if len(config.CIDRs) == 0 && len(favorites.List) > 0 {
+		session.run("favs")
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, replPrompt)
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			break
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "quit" || line == "exit" {
+			return nil
+		}
+		if line != "" {
+			session.run(line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read input: %v", err)
+	}
+	return nil
+}
+
+// run executes one command line; errors are printed and the session goes on
+func (s *replSession) run(line string) {
+	if err := s.execute(strings.Fields(line)); err != nil {
+		fmt.Fprintf(s.out, "Error: %v\n", err)
+	}
+}
+
+// execute runs a command given as fields
+func (s *replSession) execute(fields []string) error {
+	switch command := fields[0]; command {
+	case "help":
+		fmt.Fprint(s.out, replHelp)
+		return nil
+
+	case "last":
+		if s.output == "" {
+			return fmt.Errorf("no result yet")
+		}
+		fmt.Fprint(s.out, s.output)
+		return nil
+
+	case "split", "parts", "hosts":
+		if len(fields) < 2 || len(fields) > 3 {
+			return fmt.Errorf("usage: %s N [CIDR]", command)
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid %s value %q", command, fields[1])
+		}
+		target := "_"
+		if len(fields) == 3 {
+			target = fields[2]
+		}
+
+		config := &Config{}
+		switch command {
+		case "split":
+			config.Split = n
+		case "parts":
+			config.Parts = n
+		default:
+			config.Hosts = n
+		}
+		return s.show(target, config)
+
+	case "contains", "overlaps":
+		if len(fields) != 3 {
+			return fmt.Errorf("usage: %s CIDR_A CIDR_B", command)
+		}
+		var networks [2]*NetworkInfo
+		for i, arg := range fields[1:] {
+			cidr, err := s.resolve(arg)
+			if err != nil {
+				return err
+			}
+			if networks[i], err = s.handler.calculator.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("failed to parse CIDR %s: %v", cidr, err)
+			}
+		}
+		s.print(fmt.Sprintf("%s %s %s\n", networks[0].CIDR(), Relation(networks[0], networks[1]), networks[1].CIDR()))
+		return nil
+
+	case "fav":
+		if s.last == nil {
+			return fmt.Errorf("no network shown yet; enter a CIDR first")
+		}
+		cidr := s.last.Info.CIDR()
+		position := s.favorites.Add(cidr, strings.Join(fields[1:], " "))
+		if err := s.favorites.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(s.out, "Saved %s as favorite @%d\n", cidr, position)
+		return nil
+
+	case "favs":
+		if len(s.favorites.List) == 0 {
+			fmt.Fprintln(s.out, "No favorites yet; show a network and type fav LABEL to bookmark it")
+			return nil
+		}
+		s.print("Favorites (enter @N to show one):\n" + strings.Join(s.favorites.Lines(), "\n") + "\n")
+		return nil
+
+	case "unfav":
+		if len(fields) != 2 {
+			return fmt.Errorf("usage: unfav N")
+		}
+		position, err := strconv.Atoi(strings.TrimPrefix(fields[1], "@"))
+		if err != nil {
+			return fmt.Errorf("invalid favorite %q", fields[1])
+		}
+		favorite, err := s.favorites.Remove(position)
+		if err != nil {
+			return err
+		}
+		if err := s.favorites.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(s.out, "Removed favorite %s\n", favorite)
+		return nil
+	}
+
+	if len(fields) != 1 {
+		return fmt.Errorf("unknown command %q (type help for the list)", fields[0])
+	}
+	return s.show(fields[0], &Config{})
+}
+
+// show calculates a network with the split options of config and prints its report
+func (s *replSession) show(arg string, config *Config) error {
+	cidr, err := s.resolve(arg)
+	if err != nil {
+		return err
+	}
+
+	report, err := s.handler.calculate(cidr, config, nil, nil)
+	if err != nil {
+		return err
+	}
+	content, err := s.handler.formatter.RenderReports(FormatText, []NetworkReport{report})
+	if err != nil {
+		return err
+	}
+
+	s.last = &report
+	s.print(content)
+	return nil
+}
+
+// print writes a result and keeps it for "last"
+func (s *replSession) print(content string) {
+	s.output = content
+	fmt.Fprint(s.out, content)
+}
+
+// resolve replaces _ with the last network, _N with its subnet N and @N
+// with favorite N
+func (s *replSession) resolve(arg string) (string, error) {
+	if strings.HasPrefix(arg, "@") {
+		position, err := strconv.Atoi(arg[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid favorite %q (use @N)", arg)
+		}
+		favorite, err := s.favorites.Get(position)
+		if err != nil {
+			return "", err
+		}
+		return favorite.CIDR, nil
+	}
+	if !strings.HasPrefix(arg, "_") {
+		return arg, nil
+	}
+	if s.last == nil {
+		return "", fmt.Errorf("no network shown yet; enter a CIDR first")
+	}
+	if arg == "_" {
+		return s.last.Info.CIDR(), nil
+	}
+
+	index, err := strconv.Atoi(arg[1:])
+	if err != nil || index < 0 {
+		return "", fmt.Errorf("invalid reference %q (use _ or _N)", arg)
+	}
+	if index >= len(s.last.Subnets) {
+		return "", fmt.Errorf("%s has %d listed subnets, no subnet %d", s.last.Info.CIDR(), len(s.last.Subnets), index)
+	}
+	return s.last.Subnets[index].CIDR, nil
+}
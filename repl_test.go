@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCLIHandler_runInteractive(t *testing.T) {
+	tests := []struct {
+		name     string
+		cidrs    []string
+		input    string
+		expected []string
+		absent   []string
+	}{
+		{
+			name:     "show a network",
+			input:    "192.168.1.0/24\n",
+			expected: []string{"cidr> Network Information:", "CIDR:           192.168.1.0/24", "192.168.1.128/25"},
+		},
+		{
+			name:     "split the last network",
+			cidrs:    []string{"10.0.0.0/24"},
+			input:    "split 26\n",
+			expected: []string{"Possible /26 Subnets: 4", "10.0.0.192/26"},
+		},
+		{
+			name:     "recall a subnet",
+			input:    "10.0.0.0/24\nsplit 26\n_2\n",
+			expected: []string{"CIDR:           10.0.0.128/26", "10.0.0.160/27"},
+		},
+		{
+			name:     "hosts and parts",
+			input:    "parts 3 10.0.0.0/24\nhosts 100 _\n",
+			expected: []string{"Possible /26 Subnets: 4", "Possible /25 Subnets: 2"},
+		},
+		{
+			name:     "containment",
+			input:    "10.1.0.0/16\ncontains 10.0.0.0/8 _\noverlaps _ 192.168.0.0/16\n",
+			expected: []string{"10.0.0.0/8 contains 10.1.0.0/16\n", "10.1.0.0/16 disjoint 192.168.0.0/16\n"},
+		},
+		{
+			name:     "last result",
+			input:    "contains 10.0.0.0/8 10.1.0.0/16\nlast\n",
+			expected: []string{"10.1.0.0/16\ncidr> 10.0.0.0/8 contains 10.1.0.0/16\n"},
+		},
+		{
+			name:  "errors keep the session open",
+			input: "bogus\nsplit 26\n_\nlast\nfoo bar\nsplit x\n10.0.0.0/24\n_7\n",
+			expected: []string{
+				"Error: failed to parse CIDR",
+				"Error: no network shown yet; enter a CIDR first",
+				"Error: no result yet",
+				"Error: unknown command \"foo\"",
+				"Error: invalid split value \"x\"",
+				"Error: 10.0.0.0/24 has 2 listed subnets, no subnet 7",
+			},
+		},
+		{
+			name:     "quit",
+			input:    "quit\n10.0.0.0/24\n",
+			absent:   []string{"Network Information"},
+			expected: []string{"cidr> "},
+		},
+		{
+			name:     "help",
+			input:    "help\n",
+			expected: []string{"split PREFIX [CIDR]", "_ stands for the last network"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewCLIHandler()
+			handler.stderr = io.Discard
+
+			var out strings.Builder
+			if err := handler.runInteractive(&Config{CIDRs: tt.cidrs}, strings.NewReader(tt.input), &out); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(out.String(), absent) {
+					t.Errorf("expected output not to contain %q, got:\n%s", absent, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCLIHandler_InteractiveFlags(t *testing.T) {
+	handler := NewCLIHandler()
+
+	config, err := handler.parseFlags([]string{"cidr-calc", "--interactive", "10.0.0.0/24"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !config.Interactive || config.CIDR != "10.0.0.0/24" {
+		t.Errorf("unexpected config: %+v", config)
+	}
+
+	if _, err := handler.parseFlags([]string{"cidr-calc", "-i", "-f", "plan.txt"}); err == nil {
+		t.Errorf("expected an error for --interactive with -f")
+	}
+}
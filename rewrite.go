@@ -0,0 +1,332 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// planEntryLines is an entry line of a plan file with the comment lines
+// directly above it, which move with the entry when the file is rewritten
+type planEntryLines struct {
+	Comments []string
+	Text     string // the line as written, with its newline
+	Line     int
+	CIDR     string // the CIDR as written
+}
+
+// annotation returns what follows the CIDR on the line: tags, words and comment
+func (e planEntryLines) annotation() string {
+	start := strings.Index(e.Text, e.CIDR) + len(e.CIDR)
+	return strings.TrimRight(e.Text[start:], "\r\n")
+}
+
+// planParagraph is a run of plan file lines ending in blank lines. Comment
+// lines above its first entry head the paragraph and stay in place.
+type planParagraph struct {
+	Heading  []string
+	Entries  []planEntryLines
+	Trailing []string // comment lines below the last entry, then the blank lines
+}
+
+// planLayout is a plan file split into paragraphs, so that its entries can be
+// reordered, merged or removed without losing the comments around them
+type planLayout []planParagraph
+
+// parsePlanLayout splits a plan file into paragraphs. A missing newline at the
+// end of the file is added, as the last line may not stay last.
+func parsePlanLayout(content string) planLayout {
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	var layout planLayout
+	var paragraph planParagraph
+	var pending []string
+	closed := false
+	flush := func() {
+		if len(paragraph.Entries) == 0 {
+			paragraph.Heading = append(paragraph.Heading, pending...)
+		} else {
+			paragraph.Trailing = append(paragraph.Trailing, pending...)
+		}
+		pending = nil
+	}
+
+	for i, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			paragraph.Trailing = append(paragraph.Trailing, line)
+			closed = true
+			continue
+		}
+		if closed {
+			layout = append(layout, paragraph)
+			paragraph, closed = planParagraph{}, false
+		}
+
+		// !include directives stay in place like comment lines
+		text, _, _ := strings.Cut(line, "#")
+		fields := strings.Fields(text)
+		if _, include := parseInclude(fields); len(fields) == 0 || include {
+			pending = append(pending, line)
+			continue
+		}
+		if len(paragraph.Entries) == 0 {
+			flush()
+		}
+		paragraph.Entries = append(paragraph.Entries, planEntryLines{Comments: pending, Text: line, Line: i + 1, CIDR: fields[0]})
+		pending = nil
+	}
+	flush()
+	if len(paragraph.Heading)+len(paragraph.Entries)+len(paragraph.Trailing) > 0 {
+		layout = append(layout, paragraph)
+	}
+	return layout
+}
+
+// String writes the plan file back
+func (l planLayout) String() string {
+	var output strings.Builder
+	for _, paragraph := range l {
+		for _, line := range paragraph.Heading {
+			output.WriteString(line)
+		}
+		for _, entry := range paragraph.Entries {
+			for _, line := range entry.Comments {
+				output.WriteString(line)
+			}
+			output.WriteString(entry.Text)
+		}
+		for _, line := range paragraph.Trailing {
+			output.WriteString(line)
+		}
+	}
+	return output.String()
+}
+
+// networks parses the CIDR of every entry, failing on the first that is not a network
+func (l planLayout) networks(calculator *CIDRCalculator, source string) (map[int]*NetworkInfo, error) {
+	networks := make(map[int]*NetworkInfo)
+	for _, paragraph := range l {
+		for _, entry := range paragraph.Entries {
+			info, err := calculator.ParseCIDR(entry.CIDR)
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: failed to parse CIDR: %v", source, entry.Line, err)
+			}
+			networks[entry.Line] = info
+		}
+	}
+	return networks, nil
+}
+
+// SortPlanFile orders the entries of every paragraph of a plan file by
+// address, IPv4 before IPv6. Paragraphs, the comment lines heading them and the
+// annotations of the entries stay as they are; comment lines directly above an
+// entry move with it.
+func (c *CIDRCalculator) SortPlanFile(source string, content []byte) (string, error) {
+	layout := parsePlanLayout(string(content))
+	networks, err := layout.networks(c, source)
+	if err != nil {
+		return "", err
+	}
+
+	for _, paragraph := range layout {
+		entries := paragraph.Entries
+		sort.SliceStable(entries, func(i, j int) bool {
+			return networkLess(networks[entries[i].Line], networks[entries[j].Line])
+		})
+	}
+	return layout.String(), nil
+}
+
+// PlanMerge is a group of plan file entries aggregated into one
+type PlanMerge struct {
+	Source string
+	Lines  []int
+	CIDR   string
+}
+
+// String names the aggregate and the lines it replaces
+func (m PlanMerge) String() string {
+	lines := make([]string, len(m.Lines))
+	for i, line := range m.Lines {
+		lines[i] = fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%s from lines %s and %s of %s", m.CIDR, strings.Join(lines[:len(lines)-1], ", "), lines[len(lines)-1], m.Source)
+}
+
+// AggregatePlanFile rewrites a plan file with the fewest entries covering the
+// same addresses. An aggregate takes the place of the first entry it covers and
+// the others are removed, their comment lines kept above it. It keeps the
+// annotation of the entries when they share one; otherwise each entry it
+// replaces is kept as a comment line, so that no annotation is lost.
+func (c *CIDRCalculator) AggregatePlanFile(source string, content []byte) (string, []PlanMerge, error) {
+	layout := parsePlanLayout(string(content))
+	networks, err := layout.networks(c, source)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var all []*NetworkInfo
+	var members []*planEntryLines
+	for p := range layout {
+		for e := range layout[p].Entries {
+			entry := &layout[p].Entries[e]
+			all = append(all, networks[entry.Line])
+			members = append(members, entry)
+		}
+	}
+
+	var merges []PlanMerge
+	removed := make(map[int]bool)
+	for _, aggregate := range c.Aggregate(all) {
+		var covered []*planEntryLines
+		for i, network := range all {
+			if aggregate.Contains(network) {
+				covered = append(covered, members[i])
+			}
+		}
+		if len(covered) == 1 {
+			continue
+		}
+
+		merge := PlanMerge{Source: source, CIDR: aggregate.CIDR()}
+		first := covered[0]
+		shared := true
+		for _, entry := range covered {
+			merge.Lines = append(merge.Lines, entry.Line)
+			shared = shared && strings.TrimSpace(entry.annotation()) == strings.TrimSpace(first.annotation())
+		}
+
+		var comments []string
+		for _, entry := range covered {
+			comments = append(comments, entry.Comments...)
+		}
+		text := aggregate.CIDR() + "\n"
+		if shared {
+			text = aggregate.CIDR() + first.annotation() + "\n"
+		} else {
+			for _, entry := range covered {
+				comments = append(comments, "# merged "+strings.TrimSpace(entry.Text)+"\n")
+			}
+		}
+		for _, entry := range covered[1:] {
+			removed[entry.Line] = true
+		}
+		first.Comments, first.Text, first.CIDR = comments, text, aggregate.CIDR()
+		merges = append(merges, merge)
+	}
+
+	for p := range layout {
+		kept := layout[p].Entries[:0]
+		for _, entry := range layout[p].Entries {
+			if !removed[entry.Line] {
+				kept = append(kept, entry)
+			}
+		}
+		layout[p].Entries = kept
+	}
+	return layout.String(), merges, nil
+}
+
+// readPlanFileToWrite reads a plan file that --write may replace: a local
+// plain plan file, as plan documents are not made of lines
+func readPlanFileToWrite(source string) ([]byte, error) {
+	if source == "-" || isURL(source) {
+		return nil, fmt.Errorf("--write rewrites local plan files, not %s", source)
+	}
+	content, err := readSource(source)
+	if err != nil {
+		return nil, err
+	}
+	if isPlanDocument(content) {
+		return nil, fmt.Errorf("%s is a plan document; only plan files of one CIDR per line can be rewritten", source)
+	}
+	return content, nil
+}
+
+// rewriteInPlace replaces a plan file with its rewritten content through a
+// temporary file and a rename, following a symlink to the file it points to.
+// A file whose content does not change is left untouched, so running it again
+// changes nothing. It reports whether the file was rewritten.
+func rewriteInPlace(filename string, original []byte, content string) (bool, error) {
+	if content == string(original) {
+		return false, nil
+	}
+	target, err := filepath.EvalSymlinks(filename)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve %s: %v", filename, err)
+	}
+	if err := writeFileAtomic(target, []byte(content)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// runSort implements the sort subcommand
+func (c *CLIHandler) runSort(args []string) error {
+	flagSet := flag.NewFlagSet("sort", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var write bool
+	var outputFile string
+	flagSet.BoolVar(&write, "w", false, "Rewrite the plan files in place")
+	flagSet.BoolVar(&write, "write", false, "Rewrite the plan files in place")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept the plan files anywhere among the flags
+	sources, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	switch {
+	case len(sources) == 0:
+		return fmt.Errorf("sort requires a plan file")
+	case write && outputFile != "":
+		return fmt.Errorf("--write rewrites the plan files in place and takes no -o")
+	case !write && len(sources) > 1:
+		return fmt.Errorf("sort writes one plan file, got %d; use --write to sort several in place", len(sources))
+	}
+
+	if !write {
+		content, err := readSource(sources[0])
+		if err != nil {
+			return err
+		}
+		if isPlanDocument(content) {
+			return fmt.Errorf("%s is a plan document; only plan files of one CIDR per line can be sorted", sources[0])
+		}
+		sorted, err := c.calculator.SortPlanFile(sources[0], content)
+		if err != nil {
+			return err
+		}
+		return c.writeOutput(sorted, outputFile)
+	}
+
+	for _, source := range sources {
+		content, err := readPlanFileToWrite(source)
+		if err != nil {
+			return err
+		}
+		sorted, err := c.calculator.SortPlanFile(source, content)
+		if err != nil {
+			return err
+		}
+		rewritten, err := rewriteInPlace(source, content, sorted)
+		if err != nil {
+			return err
+		}
+		if rewritten {
+			c.notef("sorted %s", source)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testUnsortedPlan = `# Production networks
+10.0.1.0/24   env=prod  # web
+# database tier
+10.0.0.0/24   env=prod  # db
+10.0.0.128/25 env=prod  # db replicas
+
+# Staging
+10.1.1.0/24   env=staging
+2001:db8::/48
+10.1.0.0/24   env=staging
+# end of staging`
+
+func TestParsePlanLayout(t *testing.T) {
+	layout := parsePlanLayout(testUnsortedPlan)
+	if len(layout) != 2 {
+		t.Fatalf("expected 2 paragraphs, got %d", len(layout))
+	}
+	if heading := layout[0].Heading; len(heading) != 1 || heading[0] != "# Production networks\n" {
+		t.Errorf("unexpected heading %q", heading)
+	}
+	if entry := layout[0].Entries[1]; entry.Line != 4 || entry.CIDR != "10.0.0.0/24" || len(entry.Comments) != 1 || entry.annotation() != "   env=prod  # db" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if trailing := layout[1].Trailing; len(trailing) != 1 || trailing[0] != "# end of staging\n" {
+		t.Errorf("unexpected trailing lines %q", trailing)
+	}
+	if content := layout.String(); content != testUnsortedPlan+"\n" {
+		t.Errorf("expected the plan back with a final newline, got:\n%s", content)
+	}
+}
+
+func TestCIDRCalculator_SortPlanFile(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	sorted, err := calculator.SortPlanFile("plan.txt", []byte(testUnsortedPlan))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `# Production networks
+# database tier
+10.0.0.0/24   env=prod  # db
+10.0.0.128/25 env=prod  # db replicas
+10.0.1.0/24   env=prod  # web
+
+# Staging
+10.1.0.0/24   env=staging
+10.1.1.0/24   env=staging
+2001:db8::/48
+# end of staging
+`
+	if sorted != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sorted)
+	}
+
+	// An !include directive stays in place like a comment line
+	sorted, err = calculator.SortPlanFile("plan.txt", []byte("10.0.1.0/24\n!include sites/*.txt\n10.0.0.0/24\n"))
+	if err != nil || sorted != "!include sites/*.txt\n10.0.0.0/24\n10.0.1.0/24\n" {
+		t.Errorf("unexpected sorted plan %q (%v)", sorted, err)
+	}
+
+	if _, err := calculator.SortPlanFile("plan.txt", []byte("10.0.0.0/24\n10.0.0.0/33\n")); err == nil || !strings.HasPrefix(err.Error(), "plan.txt line 2: failed to parse CIDR") {
+		t.Errorf("expected an error for line 2, got %v", err)
+	}
+}
+
+func TestCIDRCalculator_AggregatePlanFile(t *testing.T) {
+	aggregated, merges, err := NewCIDRCalculator().AggregatePlanFile("plan.txt", []byte(testUnsortedPlan))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `# Production networks
+# database tier
+# merged 10.0.1.0/24   env=prod  # web
+# merged 10.0.0.0/24   env=prod  # db
+# merged 10.0.0.128/25 env=prod  # db replicas
+10.0.0.0/23
+
+# Staging
+10.1.0.0/23   env=staging
+2001:db8::/48
+# end of staging
+`
+	if aggregated != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, aggregated)
+	}
+	if len(merges) != 2 || merges[0].String() != "10.0.0.0/23 from lines 2, 4 and 5 of plan.txt" || merges[1].String() != "10.1.0.0/23 from lines 8 and 10 of plan.txt" {
+		t.Errorf("unexpected merges %v", merges)
+	}
+}
+
+func TestCLIHandler_Write(t *testing.T) {
+	var stderr strings.Builder
+	handler := NewCLIHandler()
+	handler.stderr = &stderr
+	dir := t.TempDir()
+
+	plan := filepath.Join(dir, "plan.txt")
+	if err := os.WriteFile(plan, []byte(testUnsortedPlan), 0600); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink(plan, link); err != nil {
+		t.Fatalf("failed to link plan: %v", err)
+	}
+
+	// Rewriting through the link replaces the plan and keeps its mode
+	if err := handler.Run([]string{"cidr-calc", "sort", "--write", link}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sorted, _ := os.ReadFile(plan)
+	if !strings.HasPrefix(string(sorted), "# Production networks\n# database tier\n10.0.0.0/24") {
+		t.Errorf("expected the plan to be sorted, got:\n%s", sorted)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("expected %s to stay a symlink", link)
+	}
+	if info, err := os.Stat(plan); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected the plan to keep mode 0600, got %v", info.Mode())
+	}
+
+	// A second run finds nothing to do
+	stderr.Reset()
+	if err := handler.Run([]string{"cidr-calc", "sort", "-w", plan}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again, _ := os.ReadFile(plan); string(again) != string(sorted) || stderr.Len() != 0 {
+		t.Errorf("expected no change, got %q and:\n%s", stderr.String(), again)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "aggregate", "--write", "-f", plan}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	aggregated, _ := os.ReadFile(plan)
+	if !strings.Contains(string(aggregated), "\n10.0.0.0/23\n") || !strings.Contains(stderr.String(), "Note: merged 10.1.0.0/23 from lines 8 and 9 of "+plan) {
+		t.Errorf("unexpected aggregation %q:\n%s", stderr.String(), aggregated)
+	}
+
+	// --normalize --write takes several files and leaves tidy ones alone
+	shorthand := filepath.Join(dir, "shorthand.txt")
+	if err := os.WriteFile(shorthand, []byte("10/8 # main\n10.0.0.0/8\n"), 0644); err != nil {
+		t.Fatalf("failed to write plan: %v", err)
+	}
+	stderr.Reset()
+	if err := handler.Run([]string{"cidr-calc", "--normalize", "--write", plan, shorthand}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if normalized, _ := os.ReadFile(shorthand); string(normalized) != "10.0.0.0/8 # main\n" {
+		t.Errorf("unexpected normalized plan %q", normalized)
+	}
+	if !strings.HasSuffix(stderr.String(), "Note: rewrote "+shorthand+": normalized 1 of 2 entries and removed 1 duplicate\n") || strings.Contains(stderr.String(), "rewrote "+plan) {
+		t.Errorf("unexpected notes %q", stderr.String())
+	}
+
+	for _, args := range [][]string{
+		{"--write", plan},
+		{"--normalize", "--write", "-o", plan, plan},
+		{"--normalize", "--write", "-"},
+		{"sort"},
+		{"sort", plan, shorthand},
+		{"sort", "--write", "-o", plan, plan},
+		{"aggregate", "--write", "10.0.0.0/24"},
+		{"aggregate", "--write", "-f", plan, "--format", "json"},
+	} {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Score criteria, named as the rules of their findings
+const (
+	CriterionAlignment       = "alignment"
+	CriterionSummarizability = "summarizability"
+	CriterionHeadroom        = "headroom"
+	CriterionRFC1918         = "rfc1918"
+	CriterionFragmentation   = "fragmentation"
+)
+
+// scoreWeights are the shares of the criteria in the score, in percent
+var scoreWeights = []struct {
+	criterion string
+	weight    int
+}{
+	{CriterionAlignment, 20},
+	{CriterionSummarizability, 25},
+	{CriterionHeadroom, 20},
+	{CriterionRFC1918, 15},
+	{CriterionFragmentation, 20},
+}
+
+// ScoreCriterion is how well a plan meets one best practice, from 0 to 100.
+// A criterion that does not apply, such as headroom without pools, is left
+// out of the score.
+type ScoreCriterion struct {
+	Name    string
+	Weight  int
+	Score   int
+	Applies bool
+	Summary string
+}
+
+// ScoreFinding is a finding of a criterion with what to do about it
+type ScoreFinding struct {
+	Finding
+	Suggestion string
+}
+
+// PlanScore is the weighted best-practice score of an address plan
+type PlanScore struct {
+	Source   string
+	Score    int
+	Criteria []ScoreCriterion
+	Findings []ScoreFinding
+}
+
+// PlanScorer rates the allocations of a plan against the pools they are
+// carved from
+type PlanScorer struct {
+	calculator *CIDRCalculator
+	headroom   float64 // percent of each pool to keep free for growth
+}
+
+// NewPlanScorer creates a scorer asking for the given headroom in percent
+func NewPlanScorer(headroom float64) *PlanScorer {
+	return &PlanScorer{calculator: NewCIDRCalculator(), headroom: headroom}
+}
+
+// scoreInput is a plan parsed for scoring
+type scoreInput struct {
+	allocations []planNetwork
+	pools       []planNetwork
+}
+
+// finding adds a finding about a plan entry
+func (s *PlanScore) finding(severity FindingSeverity, rule string, entry BatchEntry, message, suggestion string) {
+	s.Findings = append(s.Findings, ScoreFinding{
+		Finding:    Finding{Severity: severity, Rule: rule, Message: message, File: entry.Source, Line: entry.Line, CIDR: entry.CIDR},
+		Suggestion: suggestion,
+	})
+}
+
+// percentScore turns a share of count in total into a score
+func percentScore(count, total int) int {
+	return int(math.Round(float64(count) * 100 / float64(total)))
+}
+
+// Score rates the allocations against alignment, summarizability, growth
+// headroom, RFC 1918 usage and fragmentation and weighs the criteria that
+// apply into one score
+func (p *PlanScorer) Score(source string, allocations, pools []BatchEntry) (*PlanScore, error) {
+	if len(allocations) == 0 {
+		return nil, fmt.Errorf("%s has no allocations to score", source)
+	}
+	var input scoreInput
+	for _, group := range []struct {
+		entries []BatchEntry
+		target  *[]planNetwork
+	}{
+		{allocations, &input.allocations},
+		{pools, &input.pools},
+	} {
+		for _, entry := range group.entries {
+			info, err := p.calculator.ParseCIDR(entry.CIDR)
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: failed to parse CIDR: %v", entry.Source, entry.Line, err)
+			}
+			*group.target = append(*group.target, planNetwork{entry: entry, info: info})
+		}
+	}
+
+	score := &PlanScore{Source: source}
+	criteria := map[string]ScoreCriterion{
+		CriterionAlignment:       p.alignment(score, input),
+		CriterionSummarizability: p.summarizability(score, input),
+		CriterionHeadroom:        p.headroomScore(score, input),
+		CriterionRFC1918:         p.rfc1918(score, input),
+		CriterionFragmentation:   p.fragmentation(score, input),
+	}
+
+	weighted, weights := 0, 0
+	for _, weight := range scoreWeights {
+		criterion := criteria[weight.criterion]
+		criterion.Name, criterion.Weight = weight.criterion, weight.weight
+		score.Criteria = append(score.Criteria, criterion)
+		if criterion.Applies {
+			weighted += criterion.Score * criterion.Weight
+			weights += criterion.Weight
+		}
+	}
+	score.Score = int(math.Round(float64(weighted) / float64(weights)))
+	return score, nil
+}
+
+// alignment checks that allocations are written as their network address and
+// that IPv6 prefixes fall on nibble boundaries, where reverse DNS zones and
+// addresses split cleanly
+func (p *PlanScorer) alignment(score *PlanScore, input scoreInput) ScoreCriterion {
+	aligned := 0
+	for _, allocation := range input.allocations {
+		info, entry := allocation.info, allocation.entry
+		written := strings.SplitN(entry.CIDR, "/", 2)[0]
+		switch {
+		case written != info.NetworkID.String():
+			score.finding(SeverityWarning, CriterionAlignment, entry,
+				fmt.Sprintf("%s has host bits set; the network is %s", entry.CIDR, info.CIDR()),
+				fmt.Sprintf("write it as %s", info.CIDR()))
+		case info.IsIPv6() && info.PrefixLength%4 != 0:
+			shorter := info.PrefixLength / 4 * 4
+			score.finding(SeverityNotice, CriterionAlignment, entry,
+				fmt.Sprintf("%s is not on a nibble boundary", info.CIDR()),
+				fmt.Sprintf("use a /%d or /%d so reverse DNS zones and addresses split on hex digits", shorter, shorter+4))
+		default:
+			aligned++
+		}
+	}
+	return ScoreCriterion{
+		Score:   percentScore(aligned, len(input.allocations)),
+		Applies: true,
+		Summary: fmt.Sprintf("%d of %s aligned", aligned, plural(len(input.allocations), "allocation")),
+	}
+}
+
+// summarizability checks that allocations can be advertised as few routes:
+// inside the pools when there are pools, or else merged into supernets
+func (p *PlanScorer) summarizability(score *PlanScore, input scoreInput) ScoreCriterion {
+	total := len(input.allocations)
+	if len(input.pools) > 0 {
+		inside := 0
+		for _, allocation := range input.allocations {
+			contained := false
+			for _, pool := range input.pools {
+				if pool.info.Contains(allocation.info) {
+					contained = true
+					break
+				}
+			}
+			if contained {
+				inside++
+				continue
+			}
+			score.finding(SeverityWarning, CriterionSummarizability, allocation.entry,
+				fmt.Sprintf("%s is outside every pool and needs a route of its own", allocation.info.CIDR()),
+				"move it into a pool, or add a pool covering it")
+		}
+		return ScoreCriterion{
+			Score:   percentScore(inside, total),
+			Applies: true,
+			Summary: fmt.Sprintf("%d of %s inside a pool", inside, plural(total, "allocation")),
+		}
+	}
+
+	networks := make([]*NetworkInfo, total)
+	for i, allocation := range input.allocations {
+		networks[i] = allocation.info
+	}
+	routes := len(p.calculator.Aggregate(networks))
+	criterion := ScoreCriterion{Score: 100, Applies: true, Summary: fmt.Sprintf("%s in %s", plural(total, "allocation"), plural(routes, "summary route"))}
+	if routes > 1 {
+		criterion.Score = percentScore(total-routes, total-1)
+		score.finding(SeverityNotice, CriterionSummarizability, BatchEntry{Source: score.Source},
+			criterion.Summary,
+			"carve allocations from contiguous blocks, and list those blocks as pools of a plan document")
+	}
+	return criterion
+}
+
+// poolUsage lays the allocations over each pool
+func poolUsage(input scoreInput) []*AddressUsage {
+	used := &UsedRanges{ranges: input.allocations}
+	usages := make([]*AddressUsage, len(input.pools))
+	for i, pool := range input.pools {
+		usages[i] = used.Usage(pool.info)
+	}
+	return usages
+}
+
+// headroomScore checks that every pool keeps the headroom free for growth;
+// a pool scores in proportion to the share of the headroom it has left
+func (p *PlanScorer) headroomScore(score *PlanScore, input scoreInput) ScoreCriterion {
+	if len(input.pools) == 0 {
+		return ScoreCriterion{Summary: "no pools to measure"}
+	}
+	total, short := 0.0, 0
+	for i, usage := range poolUsage(input) {
+		free := 100 - usage.Percent(usage.Used)
+		if free >= p.headroom {
+			total += 100
+			continue
+		}
+		total += free * 100 / p.headroom
+		short++
+
+		pool := input.pools[i].info
+		suggestion := "add a pool"
+		if wider, err := pool.Supernet(pool.PrefixLength - 1); err == nil {
+			suggestion = fmt.Sprintf("widen it to %s or add a pool", wider.CIDR())
+		}
+		score.finding(SeverityWarning, CriterionHeadroom, input.pools[i].entry,
+			fmt.Sprintf("pool %s is %.1f%% allocated, leaving %.1f%% for growth", pool.CIDR(), 100-free, free),
+			fmt.Sprintf("keep at least %g%% of each pool free: %s", p.headroom, suggestion))
+	}
+	return ScoreCriterion{
+		Score:   int(math.Round(total / float64(len(input.pools)))),
+		Applies: true,
+		Summary: fmt.Sprintf("%d of %s with %g%% free", len(input.pools)-short, plural(len(input.pools), "pool"), p.headroom),
+	}
+}
+
+// rfc1918 checks that IPv4 allocations use private address space, and notes
+// those in 192.168.0.0/16, which collides with home networks over VPNs
+func (p *PlanScorer) rfc1918(score *PlanScore, input scoreInput) ScoreCriterion {
+	ipv4, private := 0, 0
+	for _, allocation := range input.allocations {
+		info := allocation.info
+		if info.IsIPv6() {
+			continue
+		}
+		ipv4++
+		class := info.Classify()
+		if class.RFC != "RFC 1918" {
+			score.finding(SeverityWarning, CriterionRFC1918, allocation.entry,
+				fmt.Sprintf("%s is %s, not private address space", info.CIDR(), class),
+				"use 10.0.0.0/8, 172.16.0.0/12 or 192.168.0.0/16 for internal networks")
+			continue
+		}
+		private++
+		if info.NetworkID.To4()[0] == 192 {
+			score.finding(SeverityNotice, CriterionRFC1918, allocation.entry,
+				fmt.Sprintf("%s is in 192.168.0.0/16, which home networks use too", info.CIDR()),
+				"prefer 10.0.0.0/8 or 172.16.0.0/12 for networks reached over VPNs")
+		}
+	}
+	if ipv4 == 0 {
+		return ScoreCriterion{Summary: "no IPv4 allocations"}
+	}
+	return ScoreCriterion{
+		Score:   percentScore(private, ipv4),
+		Applies: true,
+		Summary: fmt.Sprintf("%d of %s private", private, plural(ipv4, "IPv4 allocation")),
+	}
+}
+
+// largestAlignedBlock returns the prefix length of the largest aligned block
+// between two addresses of a family
+func largestAlignedBlock(first, last *big.Int, maxPrefix int) int {
+	for prefix := 0; prefix < maxPrefix; prefix++ {
+		size := new(big.Int).Lsh(big.NewInt(1), uint(maxPrefix-prefix))
+		start := new(big.Int).Add(first, new(big.Int).Sub(size, big.NewInt(1)))
+		start.Div(start, size).Mul(start, size)
+		if end := new(big.Int).Add(start, size); end.Sub(end, big.NewInt(1)).Cmp(last) <= 0 {
+			return prefix
+		}
+	}
+	return maxPrefix
+}
+
+// fragmentation checks that the free space of each pool stays in large
+// blocks: a pool scores the size of its largest free block against the
+// largest its free addresses would allow if allocations were packed together
+func (p *PlanScorer) fragmentation(score *PlanScore, input scoreInput) ScoreCriterion {
+	if len(input.pools) == 0 {
+		return ScoreCriterion{Summary: "no pools to measure"}
+	}
+	total, fragmented := 0.0, 0
+	for i, usage := range poolUsage(input) {
+		pool := input.pools[i].info
+		free := new(big.Int).Sub(usage.Total, usage.Used)
+		if free.Sign() == 0 {
+			total += 100
+			continue
+		}
+
+		largest, blocks := pool.MaxPrefix(), 0
+		for _, segment := range usage.Segments {
+			if segment.Used {
+				continue
+			}
+			blocks++
+			if prefix := largestAlignedBlock(segment.First, segment.Last, pool.MaxPrefix()); prefix < largest {
+				largest = prefix
+			}
+		}
+		possible := pool.MaxPrefix() - (free.BitLen() - 1)
+		if largest <= possible {
+			total += 100
+			continue
+		}
+		total += 100 / math.Pow(2, float64(largest-possible))
+		fragmented++
+		score.finding(SeverityWarning, CriterionFragmentation, input.pools[i].entry,
+			fmt.Sprintf("the free space of pool %s is split into %s; the largest is a /%d where a /%d would fit", pool.CIDR(), plural(blocks, "block"), largest, possible),
+			"allocate new subnets next to existing ones so free space stays contiguous")
+	}
+	return ScoreCriterion{
+		Score:   int(math.Round(total / float64(len(input.pools)))),
+		Applies: true,
+		Summary: fmt.Sprintf("%d of %s with contiguous free space", len(input.pools)-fragmented, plural(len(input.pools), "pool")),
+	}
+}
+
+// FormatScore renders the score, the criteria and the findings with their
+// suggestions
+func (f *OutputFormatter) FormatScore(score *PlanScore) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("Plan score for %s: %d/100\n", score.Source, score.Score))
+	output.WriteString("\nCriteria:\n")
+	for _, criterion := range score.Criteria {
+		value := "-"
+		if criterion.Applies {
+			value = fmt.Sprint(criterion.Score)
+		}
+		output.WriteString(fmt.Sprintf("  %-16s %3d%%  %3s  %s\n", criterion.Name, criterion.Weight, value, criterion.Summary))
+	}
+
+	output.WriteString("\nFindings:\n")
+	if len(score.Findings) == 0 {
+		output.WriteString("  No problems found\n")
+		return output.String()
+	}
+	for _, finding := range score.Findings {
+		output.WriteString(fmt.Sprintf("  %-8s %-22s %s [%s]\n", finding.Severity, finding.Location(), finding.Message, finding.Rule))
+		output.WriteString(fmt.Sprintf("  %-8s %-22s suggestion: %s\n", "", "", finding.Suggestion))
+	}
+	return output.String()
+}
+
+// jsonPlanScore is the score for quality gates in pipelines
+type jsonPlanScore struct {
+	Source   string               `json:"source"`
+	Score    int                  `json:"score"`
+	Criteria []jsonScoreCriterion `json:"criteria"`
+	Findings []jsonScoreFinding   `json:"findings"`
+}
+
+// jsonScoreCriterion is a criterion; Score is null when it does not apply
+type jsonScoreCriterion struct {
+	Name    string `json:"name"`
+	Weight  int    `json:"weight"`
+	Score   *int   `json:"score"`
+	Summary string `json:"summary"`
+}
+
+// jsonScoreFinding is a finding with its suggestion
+type jsonScoreFinding struct {
+	Severity   FindingSeverity `json:"severity"`
+	Criterion  string          `json:"criterion"`
+	Location   string          `json:"location,omitempty"`
+	CIDR       string          `json:"cidr,omitempty"`
+	Message    string          `json:"message"`
+	Suggestion string          `json:"suggestion"`
+}
+
+// FormatScoreAsJSON renders the score as a JSON document
+func (f *OutputFormatter) FormatScoreAsJSON(score *PlanScore) (string, error) {
+	document := jsonPlanScore{Source: score.Source, Score: score.Score, Findings: []jsonScoreFinding{}}
+	for _, criterion := range score.Criteria {
+		entry := jsonScoreCriterion{Name: criterion.Name, Weight: criterion.Weight, Summary: criterion.Summary}
+		if criterion.Applies {
+			value := criterion.Score
+			entry.Score = &value
+		}
+		document.Criteria = append(document.Criteria, entry)
+	}
+	for _, finding := range score.Findings {
+		document.Findings = append(document.Findings, jsonScoreFinding{
+			Severity:   finding.Severity,
+			Criterion:  finding.Rule,
+			Location:   finding.Location(),
+			CIDR:       finding.CIDR,
+			Message:    finding.Message,
+			Suggestion: finding.Suggestion,
+		})
+	}
+
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %v", err)
+	}
+	return string(content) + "\n", nil
+}
+
+// runScore implements the score subcommand
+func (c *CLIHandler) runScore(args []string) error {
+	flagSet := flag.NewFlagSet("score", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var poolsFile, format, outputFile string
+	var headroom float64
+	var minimum int
+	flagSet.StringVar(&poolsFile, "pools", "", "Plan file of the pools the allocations are carved from (default: the pools of a plan document)")
+	flagSet.Float64Var(&headroom, "headroom", 25, "Percent of each pool to keep free for growth")
+	flagSet.IntVar(&minimum, "min", 0, "Fail when the score is below this, as a quality gate")
+	flagSet.StringVar(&format, "format", FormatText, "Output format: text or json")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept the plan anywhere among the flags
+	sources, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if len(sources) != 1 {
+		return fmt.Errorf("score requires one plan file or plan document")
+	}
+	if format != FormatText && format != FormatJSON {
+		return fmt.Errorf("score supports %s and %s output, not %s", FormatText, FormatJSON, format)
+	}
+	if headroom <= 0 || headroom >= 100 {
+		return fmt.Errorf("--headroom must be between 0 and 100, got %g", headroom)
+	}
+	if minimum < 0 || minimum > 100 {
+		return fmt.Errorf("--min must be between 0 and 100, got %d", minimum)
+	}
+
+	source := sources[0]
+	content, err := readSource(source)
+	if err != nil {
+		return err
+	}
+	allocations, err := parseBatch(source, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	var pools []BatchEntry
+	switch {
+	case poolsFile != "":
+		if pools, err = NewBatchReader(defaultFetchTimeout, nil).ReadSection(poolsFile, SectionPools); err != nil {
+			return err
+		}
+	case isPlanDocument(content):
+		if pools, err = parsePlanSection(source, bytes.NewReader(content), SectionPools); err != nil {
+			return err
+		}
+	}
+
+	score, err := NewPlanScorer(headroom).Score(source, allocations, pools)
+	if err != nil {
+		return err
+	}
+
+	var output string
+	if format == FormatJSON {
+		if output, err = c.formatter.FormatScoreAsJSON(score); err != nil {
+			return err
+		}
+	} else {
+		output = c.formatter.FormatScore(score)
+	}
+	if err := c.writeOutput(output, outputFile); err != nil {
+		return err
+	}
+
+	if score.Score < minimum {
+		return fmt.Errorf("plan scored %d, below the minimum of %d", score.Score, minimum)
+	}
+	return nil
+}
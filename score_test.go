@@ -0,0 +1,156 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testScoreDocument has a full pool, a fragmented one and allocations that
+// are misaligned, outside every pool or public
+const testScoreDocument = `version: 2
+pools:
+  - cidr: 10.0.0.0/22
+  - cidr: 10.1.0.0/24
+allocations:
+  - cidr: 10.0.1.0/24
+  - cidr: 10.0.2.0/24
+  - cidr: 10.1.0.0/25
+  - cidr: 10.1.0.128/26
+  - cidr: 10.9.0.5/24
+  - cidr: 8.8.8.0/24
+  - cidr: 2001:db8::/62
+`
+
+func TestPlanScorer_Score(t *testing.T) {
+	allocations, err := parsePlanSection("plan.yaml", strings.NewReader(testScoreDocument), SectionAllocations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pools, err := parsePlanSection("plan.yaml", strings.NewReader(testScoreDocument), SectionPools)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	score, err := NewPlanScorer(30).Score("plan.yaml", allocations, pools)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]int{
+		CriterionAlignment:       71,
+		CriterionSummarizability: 57,
+		CriterionHeadroom:        92,
+		CriterionRFC1918:         83,
+		CriterionFragmentation:   75,
+	}
+	for _, criterion := range score.Criteria {
+		if !criterion.Applies || criterion.Score != expected[criterion.Name] {
+			t.Errorf("%s: expected %d, got %d (%s)", criterion.Name, expected[criterion.Name], criterion.Score, criterion.Summary)
+		}
+	}
+	// (71*20 + 57*25 + 92*20 + 83*15 + 75*20) / 100
+	if score.Score != 74 {
+		t.Errorf("expected a score of 74, got %d", score.Score)
+	}
+
+	var findings []string
+	for _, finding := range score.Findings {
+		findings = append(findings, finding.Location()+" "+finding.Rule+": "+finding.Message)
+	}
+	for _, expected := range []string{
+		"plan.yaml:10 alignment: 10.9.0.5/24 has host bits set; the network is 10.9.0.0/24",
+		"plan.yaml:12 alignment: 2001:db8::/62 is not on a nibble boundary",
+		"plan.yaml:11 summarizability: 8.8.8.0/24 is outside every pool and needs a route of its own",
+		"plan.yaml:4 headroom: pool 10.1.0.0/24 is 75.0% allocated, leaving 25.0% for growth",
+		"plan.yaml:11 rfc1918: 8.8.8.0/24 is Public, not private address space",
+		"plan.yaml:3 fragmentation: the free space of pool 10.0.0.0/22 is split into 2 blocks; the largest is a /24 where a /23 would fit",
+	} {
+		if !strings.Contains(strings.Join(findings, "\n"), expected) {
+			t.Errorf("expected finding %q in:\n%s", expected, strings.Join(findings, "\n"))
+		}
+	}
+	if len(findings) != 8 {
+		t.Errorf("expected 8 findings, got %d", len(findings))
+	}
+
+	// Without pools, allocations are scored by the routes they summarize
+	// into, and the pool criteria are left out
+	score, err = NewPlanScorer(25).Score("plan.txt", []BatchEntry{{CIDR: "10.0.0.0/24"}, {CIDR: "10.0.1.0/24"}, {CIDR: "10.0.3.0/24"}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if score.Score != 79 || score.Criteria[1].Summary != "3 allocations in 2 summary routes" || score.Criteria[2].Applies || score.Criteria[4].Applies {
+		t.Errorf("unexpected score %d: %+v", score.Score, score.Criteria)
+	}
+}
+
+func TestLargestAlignedBlock(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	testCases := []struct {
+		first, last string
+		expected    int
+	}{
+		{"10.0.0.0/24", "10.0.0.0/24", 24},
+		{"10.0.0.64/26", "10.0.1.0/26", 25},
+		{"10.0.0.255/32", "10.0.1.0/32", 32},
+		{"2001:db8::/48", "2001:db8::/48", 48},
+	}
+	for _, tt := range testCases {
+		first, last := mustParseCIDR(t, calculator, tt.first), mustParseCIDR(t, calculator, tt.last)
+		got := largestAlignedBlock(addressInt(first.NetworkID), addressInt(last.BroadcastAddr), first.MaxPrefix())
+		if got != tt.expected {
+			t.Errorf("%s to %s: expected /%d, got /%d", tt.first, tt.last, tt.expected, got)
+		}
+	}
+}
+
+func TestCLIHandler_Score(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+	plan := filepath.Join(dir, "plan.yaml")
+	output := filepath.Join(dir, "score.json")
+	if err := os.WriteFile(plan, []byte(testScoreDocument), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "score", plan, "--format", "json", "-o", output, "--min", "70"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	for _, expected := range []string{`"score": 76,`, `"name": "headroom",`, `"suggestion": "write it as 10.9.0.0/24"`} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected %q in:\n%s", expected, content)
+		}
+	}
+
+	// A plan file takes its pools from --pools
+	planFile, pools := filepath.Join(dir, "plan.txt"), filepath.Join(dir, "pools.txt")
+	os.WriteFile(planFile, []byte("# prod\n10.0.0.0/24\n10.0.0.0/23\n"), 0644)
+	os.WriteFile(pools, []byte("10.0.0.0/23\n"), 0644)
+	if err := handler.Run([]string{"cidr-calc", "score", planFile, "--pools", pools, "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(output)
+	if !strings.Contains(string(content), "headroom          20%    0  0 of 1 pool with 25% free") ||
+		!strings.Contains(string(content), "pools.txt:1 pool 10.0.0.0/23 is 100.0% allocated") {
+		t.Errorf("unexpected score:\n%s", content)
+	}
+
+	errorCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{plan, "--min", "90"}, "plan scored 76, below the minimum of 90"},
+		{[]string{}, "score requires one plan file or plan document"},
+		{[]string{plan, "--format", "csv"}, "score supports text and json output, not csv"},
+		{[]string{plan, "--headroom", "100"}, "--headroom must be between 0 and 100, got 100"},
+	}
+	for _, tt := range errorCases {
+		if err := handler.Run(append([]string{"cidr-calc", "score", "-o", output}, tt.args...)); err == nil || err.Error() != tt.expected {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// screenReaderSection is a titled group of "label: value" lines
+type screenReaderSection struct {
+	Title string
+	Lines []string
+}
+
+// factLines writes report facts as "label: value" lines
+func factLines(facts []reportFact) []string {
+	lines := make([]string, 0, len(facts))
+	for _, fact := range facts {
+		lines = append(lines, fact.Label+": "+fact.Value)
+	}
+	return lines
+}
+
+// FormatScreenReader formats the report for screen readers: no padding or
+// columns, one "label: value" line per fact, and every section announced
+// with its size and closed explicitly, so it reads well line by line
+func (f *OutputFormatter) FormatScreenReader(info *NetworkInfo, subnets []SubnetInfo) string {
+	sections := []screenReaderSection{
+		{Title: "Network Information", Lines: factLines(f.networkFacts(info))},
+		{Title: "Host Information", Lines: factLines(f.hostFacts(info))},
+	}
+
+	if f.Binary {
+		lines := []string{"Split: " + binaryHeading(info)}
+		for _, row := range f.binaryRows(info) {
+			var parts []string
+			if row.Network != "" {
+				parts = append(parts, "network bits "+row.Network)
+			}
+			if row.Host != "" {
+				parts = append(parts, "host bits "+row.Host)
+			}
+			lines = append(lines, row.Label+": "+strings.Join(parts, ", "))
+		}
+		sections = append(sections, screenReaderSection{Title: "Binary Breakdown", Lines: lines})
+	}
+	if f.Numeric {
+		var lines []string
+		for _, row := range f.numericRows(info) {
+			lines = append(lines, fmt.Sprintf("%s: decimal %s, hex %s", row.Label, row.Decimal, row.Hex))
+		}
+		sections = append(sections, screenReaderSection{Title: "Numeric Forms", Lines: lines})
+	}
+	if f.Classful {
+		lines := []string{"IPv6 has no address classes"}
+		if !info.IsIPv6() {
+			lines = factLines(f.classfulFacts(info))
+		}
+		sections = append(sections, screenReaderSection{Title: "Classful Addressing", Lines: lines})
+	}
+
+	subnetSection := screenReaderSection{Title: "Subnet Information"}
+	if len(subnets) == 0 {
+		subnetSection.Lines = []string{noSubnetsMessage(info.PrefixLength)}
+	} else {
+		total := f.Locale.Integer(subnetTotal(info.PrefixLength, subnets).String())
+		subnetSection.Lines = append(subnetSection.Lines, fmt.Sprintf("Possible /%d subnets: %s", subnetPrefix(subnets[0]), total))
+		if note := shownNote(info.PrefixLength, subnets); note != "" {
+			subnetSection.Lines = append(subnetSection.Lines, note)
+		}
+		for i, subnet := range subnets {
+			line := fmt.Sprintf("Subnet %d of %d: %s, from %s to %s", i+1, len(subnets), subnet.CIDR, subnet.NetworkID, subnet.BroadcastAddr)
+			for _, field := range subnet.Computed {
+				line += fmt.Sprintf(", %s: %s", field.Name, field.Value)
+			}
+			subnetSection.Lines = append(subnetSection.Lines, line)
+		}
+	}
+	sections = append(sections, subnetSection)
+	if f.Explain {
+		var lines []string
+		for i, step := range f.explanationSteps(info, subnets) {
+			lines = append(lines, fmt.Sprintf("Step %d: %s", i+1, step.Title))
+			for _, line := range step.Lines {
+				lines = append(lines, strings.Join(strings.Fields(line), " "))
+			}
+		}
+		sections = append(sections, screenReaderSection{Title: "Explanation", Lines: lines})
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Report for %s, %d sections.\n", info.CIDR(), len(sections)))
+	for i, section := range sections {
+		output.WriteString(fmt.Sprintf("\nSection %d of %d: %s, %s.\n", i+1, len(sections), section.Title, plural(len(section.Lines), "line")))
+		for _, line := range section.Lines {
+			output.WriteString(line + "\n")
+		}
+		output.WriteString(fmt.Sprintf("End of %s.\n", section.Title))
+	}
+	output.WriteString(fmt.Sprintf("\nEnd of report for %s.\n", info.CIDR()))
+
+	return output.String()
+}
+
+// plural returns the count with the noun, adding an s unless there is one
+func plural(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
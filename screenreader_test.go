@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputFormatter_FormatScreenReader(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	info := mustParseCIDR(t, calculator, "192.168.1.0/30")
+	formatter := NewOutputFormatter()
+	formatter.Binary = true
+	formatter.ScreenReader = true
+
+	expected := `Report for 192.168.1.0/30, 4 sections.
+
+Section 1 of 4: Network Information, 6 lines.
+CIDR: 192.168.1.0/30
+Network ID: 192.168.1.0
+Broadcast: 192.168.1.3
+Subnet Mask: 255.255.255.252
+Wildcard Mask: 0.0.0.3
+Classification: Private-Use (RFC 1918)
+End of Network Information.
+
+Section 2 of 4: Host Information, 3 lines.
+First Usable: 192.168.1.1
+Last Usable: 192.168.1.2
+Total Hosts: 2
+End of Host Information.
+
+Section 3 of 4: Binary Breakdown, 4 lines.
+Split: 30 network bits, 2 host bits
+Network ID: network bits 11000000.10101000.00000001.000000, host bits 00
+Subnet Mask: network bits 11111111.11111111.11111111.111111, host bits 00
+Wildcard Mask: network bits 00000000.00000000.00000000.000000, host bits 11
+End of Binary Breakdown.
+
+Section 4 of 4: Subnet Information, 3 lines.
+Possible /31 subnets: 2
+Subnet 1 of 2: 192.168.1.0/31, from 192.168.1.0 to 192.168.1.1
+Subnet 2 of 2: 192.168.1.2/31, from 192.168.1.2 to 192.168.1.3
+End of Subnet Information.
+
+End of report for 192.168.1.0/30.
+`
+	if got := formatter.FormatComplete(info, calculator.CalculateSubnets(info)); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	// Nothing is padded to a column
+	single := mustParseCIDR(t, calculator, "10.0.0.1/32")
+	formatter = NewOutputFormatter()
+	formatter.ScreenReader = true
+	got := formatter.FormatComplete(single, nil)
+	if strings.Contains(got, "  ") || !strings.Contains(got, "Section 3 of 3: Subnet Information, 1 line.\nNo subnets available") {
+		t.Errorf("unexpected report:\n%s", got)
+	}
+}
+
+func TestCLIHandler_ScreenReader(t *testing.T) {
+	handler := NewCLIHandler()
+	output := filepath.Join(t.TempDir(), "report.txt")
+	if err := handler.Run([]string{"cidr-calc", "--screen-reader", "--split", "26", "-o", output, "10.0.0.0/24", "10.1.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	for _, expected := range []string{"Report for 10.0.0.0/24, 3 sections.", "Subnet 4 of 4: 10.1.0.192/26, from 10.1.0.192 to 10.1.0.255", "End of report for 10.1.0.0/24."} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected %q in:\n%s", expected, content)
+		}
+	}
+
+	for _, args := range [][]string{{"--screen-reader", "--format", "html", "10.0.0.0/8"}, {"--screen-reader", "--low-memory", "10.0.0.0/8"}} {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultListenAddr is the address the serve command listens on without --listen
+const defaultListenAddr = ":8080"
+
+// maxRequestBody caps the size of a POST body
+const maxRequestBody = 1 << 20
+
+// APIServer serves the calculator over HTTP. Responses use the JSON model of
+// --format json; every request is traced like one CLI run, and the metrics of
+// all requests add up in one recorder.
+type APIServer struct {
+	handler   *CLIHandler
+	telemetry *Telemetry
+	mux       *http.ServeMux
+
+	// quotaState and quotas back GET /v1/quotas; see ServeQuotas
+	quotaState string
+	quotas     *QuotaPolicy
+}
+
+// splitRequest is the body of POST /v1/split. At most one of prefix, parts and
+// hosts may be set; without any the network is split at the next prefix.
+type splitRequest struct {
+	CIDR   string `json:"cidr"`
+	Prefix int    `json:"prefix"`
+	Parts  int    `json:"parts"`
+	Hosts  int    `json:"hosts"`
+}
+
+// apiError is the body of every error response
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// NewAPIServer creates a server that calculates with the handler and records
+// a trace per request, and the metrics of the server, for the OTLP endpoint,
+// if any. ExportTelemetry sends them.
+func NewAPIServer(handler *CLIHandler, otlpEndpoint string, otlpHeaders http.Header) *APIServer {
+	s := &APIServer{
+		handler:   handler,
+		telemetry: NewTelemetry(otlpEndpoint, otlpHeaders),
+		mux:       http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/v1/networks/", s.handleNetwork)
+	s.mux.HandleFunc("/v1/split", s.handleSplit)
+	return s
+}
+
+// ServeQuotas serves the tenant quota usage of an IPAM state at GET /v1/quotas.
+// The state is read on every request, so the usage is always current.
+func (s *APIServer) ServeQuotas(stateFile string, policy *QuotaPolicy) {
+	s.quotaState = stateFile
+	s.quotas = policy
+	s.mux.HandleFunc("/v1/quotas", s.handleQuotas)
+}
+
+// ExportTelemetry exports the recorded traces and metrics every interval
+// until the returned stop function is called. Requests never wait for the
+// collector.
+func (s *APIServer) ExportTelemetry(interval time.Duration) (stop func()) {
+	return s.telemetry.ExportEvery(interval, func(err error) {
+		s.handler.warnf("%v", err)
+	})
+}
+
+// ServeHTTP implements http.Handler
+func (s *APIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleNetwork serves GET /v1/networks/{cidr}. The CIDR keeps its slash, as in
+// /v1/networks/10.0.0.0/16, or is escaped as 10.0.0.0%2F16. The split, parts
+// and hosts query parameters select the subnets like the CLI flags.
+func (s *APIServer) handleNetwork(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.fail(w, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed; use GET", r.Method))
+		return
+	}
+
+	request := splitRequest{CIDR: strings.TrimPrefix(r.URL.Path, "/v1/networks/")}
+	query := r.URL.Query()
+	for name, target := range map[string]*int{"split": &request.Prefix, "parts": &request.Parts, "hosts": &request.Hosts} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			s.fail(w, http.StatusBadRequest, fmt.Errorf("invalid %s %q", name, value))
+			return
+		}
+		*target = number
+	}
+
+	s.respond(w, "GET /v1/networks", "split", request)
+}
+
+// handleSplit serves POST /v1/split with a splitRequest body
+func (s *APIServer) handleSplit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.fail(w, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed; use POST", r.Method))
+		return
+	}
+
+	var request splitRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		s.fail(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return
+	}
+
+	s.respond(w, "POST /v1/split", "prefix", request)
+}
+
+// handleQuotas serves GET /v1/quotas with the quota report in the JSON model
+// of ipam quota --format json
+func (s *APIServer) handleQuotas(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.fail(w, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed; use GET", r.Method))
+		return
+	}
+
+	report, err := s.handler.quotaReport(s.quotaState, s.quotas)
+	if err != nil {
+		s.fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := writeJSON(w, http.StatusOK, jsonQuotas(report)); err != nil {
+		s.handler.warnf("%v", err)
+	}
+}
+
+// respond calculates the requested network and writes its JSON report. The
+// route names the prefix length prefixField in errors.
+func (s *APIServer) respond(w http.ResponseWriter, route, prefixField string, request splitRequest) {
+	span := s.telemetry.StartTrace("request")
+	span.SetAttribute("http.route", route)
+
+	status := http.StatusOK
+	report, err := s.handler.calculateSplit(request, prefixField, s.telemetry, span)
+	if err != nil {
+		status = http.StatusBadRequest
+		s.fail(w, status, err)
+	} else if writeErr := writeJSON(w, status, s.handler.formatter.jsonDocument([]NetworkReport{report})); writeErr != nil {
+		s.handler.warnf("%v", writeErr)
+	}
+	span.SetAttribute("http.status_code", status)
+	span.End(err)
+}
+
+// calculateSplit parses the requested network and lists its subnets. Errors
+// name the request fields, with the prefix length as prefixField, rather than
+// the CLI flags.
+func (c *CLIHandler) calculateSplit(request splitRequest, prefixField string, telemetry *Telemetry, span *Span) (NetworkReport, error) {
+	if request.CIDR == "" {
+		return NetworkReport{}, fmt.Errorf("cidr is required")
+	}
+
+	modes := 0
+	for _, value := range []int{request.Prefix, request.Parts, request.Hosts} {
+		if value != 0 {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return NetworkReport{}, fmt.Errorf("only one of %s, parts and hosts can be used", prefixField)
+	}
+
+	config := &Config{Split: request.Prefix, Parts: request.Parts, Hosts: request.Hosts}
+	report, err := c.calculate(request.CIDR, config, telemetry, span)
+	var limitErr *SplitLimitError
+	if errors.As(err, &limitErr) {
+		switch {
+		case request.Parts != 0:
+			return NetworkReport{}, fmt.Errorf("%v; lower parts to list fewer subnets", limitErr.Err)
+		case request.Hosts != 0:
+			return NetworkReport{}, fmt.Errorf("%v; raise hosts to list fewer subnets", limitErr.Err)
+		}
+		return NetworkReport{}, fmt.Errorf("%v; lower %s to list fewer subnets", limitErr.Err, prefixField)
+	}
+	return report, err
+}
+
+// fail writes an error response
+func (s *APIServer) fail(w http.ResponseWriter, status int, err error) {
+	if writeErr := writeJSON(w, status, apiError{Error: err.Error()}); writeErr != nil {
+		s.handler.warnf("%v", writeErr)
+	}
+}
+
+// writeJSON writes value as the JSON response body
+func writeJSON(w http.ResponseWriter, status int, value interface{}) error {
+	body, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(append(body, '\n')); err != nil {
+		return fmt.Errorf("failed to write response: %v", err)
+	}
+	return nil
+}
+
+// runServe implements the serve subcommand
+func (c *CLIHandler) runServe(args []string) error {
+	flagSet := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var listen, otlpEndpoint, stateFile string
+	var quotas quotaFlags
+	flagSet.StringVar(&listen, "listen", defaultListenAddr, "Address to listen on")
+	flagSet.StringVar(&otlpEndpoint, "otlp-endpoint", os.Getenv(otlpEndpointEnv), "Export a trace per request to this OTLP/HTTP endpoint")
+	flagSet.StringVar(&stateFile, "state", "", "IPAM state whose tenant quota usage is served at /v1/quotas (with --quotas)")
+	quotas.define(flagSet)
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if flagSet.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", flagSet.Arg(0))
+	}
+
+	var otlpHeaders http.Header
+	if otlpEndpoint != "" {
+		headers, err := ParseOTLPHeaders(os.Getenv(otlpHeadersEnv))
+		if err != nil {
+			return err
+		}
+		otlpHeaders = headers
+	}
+
+	api := NewAPIServer(c, otlpEndpoint, otlpHeaders)
+	if (stateFile == "") != (quotas.file == "") {
+		return fmt.Errorf("--state and --quotas must be given together")
+	}
+	if stateFile != "" {
+		policy, err := quotas.load()
+		if err != nil {
+			return err
+		}
+		api.ServeQuotas(stateFile, policy)
+	}
+
+	server := &http.Server{
+		Addr:              listen,
+		Handler:           api,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	stopExport := api.ExportTelemetry(telemetryExportInterval)
+	defer stopExport()
+
+	c.notef("serving the calculator API on %s", listen)
+	if err := server.ListenAndServe(); err != nil {
+		return fmt.Errorf("server error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAPIServer(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	server := httptest.NewServer(NewAPIServer(handler, "", nil))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		status   int
+		cidr     string
+		subnets  int
+		expected string // error message prefix
+	}{
+		{name: "network", method: http.MethodGet, path: "/v1/networks/10.0.0.0/24", status: 200, cidr: "10.0.0.0/24", subnets: 2},
+		{name: "escaped slash", method: http.MethodGet, path: "/v1/networks/10.0.0.0%2F24?split=26", status: 200, cidr: "10.0.0.0/24", subnets: 4},
+		{name: "ipv6", method: http.MethodGet, path: "/v1/networks/2001:db8::/48?parts=3", status: 200, cidr: "2001:db8::/48", subnets: 4},
+		{name: "split", method: http.MethodPost, path: "/v1/split", body: `{"cidr": "192.168.0.0/16", "hosts": 500}`, status: 200, cidr: "192.168.0.0/16", subnets: 128},
+		{name: "split prefix", method: http.MethodPost, path: "/v1/split", body: `{"cidr": "10.0.0.0/24", "prefix": 28}`, status: 200, cidr: "10.0.0.0/24", subnets: 16},
+		{name: "invalid cidr", method: http.MethodGet, path: "/v1/networks/10.0.0.0", status: 400, expected: "failed to parse CIDR"},
+		{name: "invalid query", method: http.MethodGet, path: "/v1/networks/10.0.0.0/24?split=x", status: 400, expected: `invalid split "x"`},
+		{name: "too many subnets", method: http.MethodGet, path: "/v1/networks/10.0.0.0/8?split=30", status: 400,
+			expected: "splitting 10.0.0.0/8 into /30 subnets would list 4194304 subnets (limit 65536); lower split to list fewer subnets"},
+		{name: "too many parts", method: http.MethodPost, path: "/v1/split", body: `{"cidr": "10.0.0.0/8", "parts": 100000}`, status: 400,
+			expected: "splitting 10.0.0.0/8 into /25 subnets would list 131072 subnets (limit 65536); lower parts to list fewer subnets"},
+		{name: "too few hosts", method: http.MethodPost, path: "/v1/split", body: `{"cidr": "10.0.0.0/8", "hosts": 2}`, status: 400,
+			expected: "splitting 10.0.0.0/8 into /31 subnets would list 8388608 subnets (limit 65536); raise hosts to list fewer subnets"},
+		{name: "several query modes", method: http.MethodGet, path: "/v1/networks/10.0.0.0/24?split=28&parts=2", status: 400, expected: "only one of split, parts and hosts"},
+		{name: "several modes", method: http.MethodPost, path: "/v1/split", body: `{"cidr": "10.0.0.0/24", "prefix": 28, "parts": 2}`, status: 400, expected: "only one of prefix, parts and hosts"},
+		{name: "missing cidr", method: http.MethodPost, path: "/v1/split", body: `{"prefix": 28}`, status: 400, expected: "cidr is required"},
+		{name: "unknown field", method: http.MethodPost, path: "/v1/split", body: `{"cidr": "10.0.0.0/24", "size": 2}`, status: 400, expected: "invalid request body"},
+		{name: "wrong method", method: http.MethodGet, path: "/v1/split", status: 405, expected: "GET is not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer response.Body.Close()
+
+			if response.StatusCode != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, response.StatusCode)
+			}
+			if contentType := response.Header.Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("unexpected content type %q", contentType)
+			}
+
+			if tt.expected != "" {
+				var body apiError
+				if err := json.NewDecoder(response.Body).Decode(&body); err != nil || !strings.HasPrefix(body.Error, tt.expected) {
+					t.Errorf("expected error %q, got %q (%v)", tt.expected, body.Error, err)
+				}
+				return
+			}
+
+			var document jsonReport
+			if err := json.NewDecoder(response.Body).Decode(&document); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if len(document.Networks) != 1 || document.Networks[0].CIDR != tt.cidr || len(document.Networks[0].Subnets.Subnets) != tt.subnets {
+				t.Errorf("unexpected document: %+v", document)
+			}
+		})
+	}
+}
+
+func TestAPIServer_Timeout(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	server := httptest.NewServer(NewAPIServer(handler, "", nil))
+	defer server.Close()
+
+	split := func(body string) (int, string) {
+		response, err := http.Post(server.URL+"/v1/split", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer response.Body.Close()
+		var failure apiError
+		json.NewDecoder(response.Body).Decode(&failure)
+		return response.StatusCode, failure.Error
+	}
+
+	// As "cidr-calc --timeout 50ms serve" sets it, the timeout bounds each
+	// request rather than the life of the server
+	handler.calculator.SetLimits(Limits{Timeout: 50 * time.Millisecond})
+	time.Sleep(60 * time.Millisecond)
+	if status, message := split(`{"cidr": "10.0.0.0/16", "prefix": 24}`); status != http.StatusOK {
+		t.Errorf("expected a request after the timeout has elapsed to succeed, got %d: %s", status, message)
+	}
+
+	handler.calculator.SetLimits(Limits{Timeout: time.Nanosecond})
+	if status, message := split(`{"cidr": "10.0.0.0/16", "prefix": 24}`); status != http.StatusBadRequest || !strings.HasPrefix(message, "gave up after the 1ns --timeout") {
+		t.Errorf("expected the timeout to fail the request, got %d: %s", status, message)
+	}
+}
+
+func TestAPIServer_Telemetry(t *testing.T) {
+	var mu sync.Mutex
+	var traces []otlpTraces
+	var metrics []otlpMetrics
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/v1/traces":
+			var payload otlpTraces
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				t.Errorf("invalid trace payload: %v", err)
+			}
+			traces = append(traces, payload)
+		case "/v1/metrics":
+			var payload otlpMetrics
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				t.Errorf("invalid metrics payload: %v", err)
+			}
+			metrics = append(metrics, payload)
+		}
+	}))
+	defer collector.Close()
+
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	api := NewAPIServer(handler, collector.URL, nil)
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	get := func(paths ...string) {
+		for _, path := range paths {
+			response, err := http.Get(server.URL + path)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			response.Body.Close()
+		}
+	}
+	calculations := func(export otlpMetrics) map[string]string {
+		counts := make(map[string]string)
+		for _, metric := range export.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+			if metric.Name == "cidr_calc.calculations" {
+				for _, point := range metric.Sum.DataPoints {
+					counts[*point.Attributes[0].Value.StringValue] = point.AsInt
+				}
+			}
+		}
+		return counts
+	}
+
+	// Requests only record; nothing reaches the collector until an export
+	get("/v1/networks/10.0.0.0/24", "/v1/networks/bad")
+	mu.Lock()
+	if len(traces)+len(metrics) != 0 {
+		t.Errorf("expected no export during requests, got %d traces and %d metrics", len(traces), len(metrics))
+	}
+	mu.Unlock()
+
+	// Stopping the background export sends what was recorded so far
+	stop := api.ExportTelemetry(time.Hour)
+	stop()
+	get("/v1/networks/10.0.1.0/24")
+	if err := api.telemetry.Export(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(traces) != 2 || len(metrics) != 2 {
+		t.Fatalf("expected 2 exports of traces and metrics, got %d and %d", len(traces), len(metrics))
+	}
+
+	// Every request is its own trace of a request span and its calculate child
+	spans := traces[0].ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 4 {
+		t.Fatalf("expected the spans of both requests in one export, got %+v", spans)
+	}
+	first, second := spans[:2], spans[2:]
+	if first[0].Name != "calculate" || first[1].Name != "request" || first[0].ParentSpanID != first[1].SpanID || first[0].TraceID != first[1].TraceID {
+		t.Errorf("unexpected spans: %+v", first)
+	}
+	if first[0].TraceID == second[0].TraceID {
+		t.Errorf("expected a trace per request")
+	}
+	if second[1].Status == nil || second[1].Status.Code != otlpStatusError {
+		t.Errorf("expected the failed request span to be marked as an error: %+v", second[1])
+	}
+	if len(traces[1].ResourceSpans[0].ScopeSpans[0].Spans) != 2 {
+		t.Errorf("expected only the spans of the later request in the second export")
+	}
+
+	// The counters of the server keep adding up across exports
+	if counts := calculations(metrics[0]); counts["ok"] != "1" || counts["error"] != "1" {
+		t.Errorf("expected 1 ok and 1 failed calculation, got %v", counts)
+	}
+	if counts := calculations(metrics[1]); counts["ok"] != "2" || counts["error"] != "1" {
+		t.Errorf("expected the counts to accumulate, got %v", counts)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// MIB-II columns walked by snmp-discover. Both tables are indexed by an IPv4
+// address: the interface address for ipAddrTable, the destination for ipRouteTable.
+const (
+	oidIPAdEntNetMask = "1.3.6.1.2.1.4.20.1.3"
+	oidIPRouteMask    = "1.3.6.1.2.1.4.21.1.11"
+)
+
+// Kinds of networks found over SNMP
+const (
+	KindInterface = "interface"
+	KindRoute     = "route"
+)
+
+// supportedSNMPVersions are the community-based SNMP versions snmp-discover can use
+var supportedSNMPVersions = map[string]bool{"1": true, "2c": true}
+
+// SNMPSource discovers the networks configured on routers through the net-snmp
+// snmpwalk CLI
+type SNMPSource struct {
+	community string
+	version   string
+	run       commandRunner
+}
+
+// NewSNMPSource creates a source that walks routers with the given community and version
+func NewSNMPSource(community, version string, run commandRunner) *SNMPSource {
+	return &SNMPSource{community: community, version: version, run: run}
+}
+
+// Ranges returns the networks of a router's interface addresses and routing
+// table. The default route and loopback networks are left out.
+func (s *SNMPSource) Ranges(router string) ([]DiscoveredRange, error) {
+	var ranges []DiscoveredRange
+
+	for _, table := range []struct {
+		kind string
+		oid  string
+	}{
+		{kind: KindInterface, oid: oidIPAdEntNetMask},
+		{kind: KindRoute, oid: oidIPRouteMask},
+	} {
+		masks, err := s.walk(router, table.oid)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range masks {
+			cidr, err := maskedNetwork(entry.index, entry.value)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s %s: %v", router, table.kind, entry.index, err)
+			}
+			if cidr == "0.0.0.0/0" || entry.index.IsLoopback() {
+				continue
+			}
+			ranges = append(ranges, DiscoveredRange{Kind: table.kind, Source: router, CIDR: cidr})
+		}
+	}
+
+	return ranges, nil
+}
+
+// snmpEntry is one row of a walked column: the address index and its value
+type snmpEntry struct {
+	index net.IP
+	value string
+}
+
+// walk runs snmpwalk for one column with numeric OIDs
+func (s *SNMPSource) walk(router, oid string) ([]snmpEntry, error) {
+	output, err := s.run("snmpwalk", "-v", s.version, "-c", s.community, "-On", router, oid)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSNMPWalk(string(output), oid), nil
+}
+
+// parseSNMPWalk reads snmpwalk lines such as
+// ".1.3.6.1.2.1.4.20.1.3.10.0.0.1 = IpAddress: 255.255.255.0", keeping the
+// rows of the column whose index is an IPv4 address. Messages such as
+// "No Such Object available" do not match and are skipped.
+func parseSNMPWalk(output, oid string) []snmpEntry {
+	var entries []snmpEntry
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		index := strings.TrimPrefix(fields[0], ".")
+		if !strings.HasPrefix(index, oid+".") {
+			continue
+		}
+		ip := net.ParseIP(strings.TrimPrefix(index, oid+".")).To4()
+		if ip == nil {
+			continue
+		}
+
+		entries = append(entries, snmpEntry{index: ip, value: strings.Trim(fields[len(fields)-1], `"`)})
+	}
+
+	return entries
+}
+
+// maskedNetwork returns the CIDR of the network containing ip under a dotted netmask
+func maskedNetwork(ip net.IP, mask string) (string, error) {
+	maskIP := net.ParseIP(mask).To4()
+	if maskIP == nil {
+		return "", fmt.Errorf("invalid netmask %q", mask)
+	}
+
+	ipMask := net.IPMask(maskIP)
+	ones, bits := ipMask.Size()
+	if bits == 0 {
+		return "", fmt.Errorf("non-contiguous netmask %s", mask)
+	}
+
+	return fmt.Sprintf("%s/%d", ip.Mask(ipMask), ones), nil
+}
+
+// discoveredNetwork is a distinct network with every place it was seen
+type discoveredNetwork struct {
+	info    *NetworkInfo
+	sources []string
+}
+
+// groupDiscoveredRanges merges ranges with the same CIDR and sorts them by address
+func groupDiscoveredRanges(ranges []DiscoveredRange, calculator *CIDRCalculator) ([]discoveredNetwork, error) {
+	var networks []discoveredNetwork
+	index := make(map[string]int)
+
+	for _, discovered := range ranges {
+		source := discovered.Source + " " + discovered.Kind
+		if i, ok := index[discovered.CIDR]; ok {
+			networks[i].sources = append(networks[i].sources, source)
+			continue
+		}
+
+		info, err := calculator.ParseCIDR(discovered.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s %s: %v", discovered.Source, discovered.Kind, discovered.CIDR, err)
+		}
+		index[discovered.CIDR] = len(networks)
+		networks = append(networks, discoveredNetwork{info: info, sources: []string{source}})
+	}
+
+	sort.SliceStable(networks, func(i, j int) bool {
+		a, b := ipv4ToUint32(networks[i].info.NetworkID), ipv4ToUint32(networks[j].info.NetworkID)
+		if a != b {
+			return a < b
+		}
+		return networks[i].info.PrefixLength < networks[j].info.PrefixLength
+	})
+
+	return networks, nil
+}
+
+// FormatDiscoveredPlan renders discovered networks as a plan file, one CIDR per
+// line with a comment naming where it was found, ready for -f, --plan or lint
+func (f *OutputFormatter) FormatDiscoveredPlan(title string, networks []discoveredNetwork) string {
+	var output strings.Builder
+
+	output.WriteString("# " + title + "\n")
+	for _, network := range networks {
+		output.WriteString(fmt.Sprintf("%-18s # %s\n", network.info.CIDR(), strings.Join(network.sources, ", ")))
+	}
+
+	return output.String()
+}
+
+// runSNMPDiscover implements the snmp-discover subcommand
+func (c *CLIHandler) runSNMPDiscover(args []string) error {
+	flagSet := flag.NewFlagSet("snmp-discover", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var community, version, format, outputFile string
+	flagSet.StringVar(&community, "community", "public", "SNMP community string")
+	flagSet.StringVar(&version, "version", "2c", "SNMP version: 1 or 2c")
+	flagSet.StringVar(&format, "format", FormatText, "Output format: text (a plan file) or any output format")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept routers anywhere among the flags
+	routers, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	if len(routers) == 0 {
+		return fmt.Errorf("snmp-discover requires at least one router")
+	}
+	if !supportedSNMPVersions[version] {
+		return fmt.Errorf("unsupported SNMP version: %s (supported: 1, 2c)", version)
+	}
+	if !IsSupportedFormat(format) {
+		return fmt.Errorf("unsupported output format: %s (supported: %s)", format, strings.Join(SupportedFormats, ", "))
+	}
+
+	source := NewSNMPSource(community, version, c.run)
+	var ranges []DiscoveredRange
+	for _, router := range routers {
+		found, err := source.Ranges(router)
+		if err != nil {
+			return err
+		}
+		ranges = append(ranges, found...)
+	}
+
+	networks, err := groupDiscoveredRanges(ranges, c.calculator)
+	if err != nil {
+		return err
+	}
+	if len(networks) == 0 {
+		return fmt.Errorf("no networks found on %s", strings.Join(routers, ", "))
+	}
+
+	if format == FormatText {
+		title := fmt.Sprintf("Networks discovered over SNMP on %s", strings.Join(routers, ", "))
+		return c.writeOutput(c.formatter.FormatDiscoveredPlan(title, networks), outputFile)
+	}
+
+	var reports []NetworkReport
+	for _, network := range networks {
+		reports = append(reports, NetworkReport{Info: network.info, Subnets: c.calculator.CalculateSubnets(network.info)})
+	}
+	content, err := c.formatter.RenderReports(format, reports)
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(content, outputFile)
+}
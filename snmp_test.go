@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSNMP answers snmpwalk for the ipAddrTable and ipRouteTable masks of each router
+func fakeSNMP(walks map[string]string) commandRunner {
+	return func(name string, args ...string) ([]byte, error) {
+		command := strings.Join(append([]string{name}, args...), " ")
+		if output, ok := walks[command]; ok {
+			return []byte(output), nil
+		}
+		return nil, fmt.Errorf("unexpected command: %s", command)
+	}
+}
+
+var testSNMPWalks = map[string]string{
+	"snmpwalk -v 2c -c public -On router1 " + oidIPAdEntNetMask: `.1.3.6.1.2.1.4.20.1.3.10.0.0.1 = IpAddress: 255.255.255.252
+.1.3.6.1.2.1.4.20.1.3.10.1.0.1 = IpAddress: 255.255.0.0
+.1.3.6.1.2.1.4.20.1.3.127.0.0.1 = IpAddress: 255.0.0.0
+`,
+	"snmpwalk -v 2c -c public -On router1 " + oidIPRouteMask: `.1.3.6.1.2.1.4.21.1.11.0.0.0.0 = IpAddress: 0.0.0.0
+.1.3.6.1.2.1.4.21.1.11.10.0.0.0 = IpAddress: 255.255.255.252
+.1.3.6.1.2.1.4.21.1.11.10.1.0.0 = IpAddress: 255.255.0.0
+.1.3.6.1.2.1.4.21.1.11.10.2.0.0 = IpAddress: 255.255.0.0
+`,
+	"snmpwalk -v 2c -c public -On router2 " + oidIPAdEntNetMask: `.1.3.6.1.2.1.4.20.1.3.10.0.0.2 = IpAddress: 255.255.255.252
+.1.3.6.1.2.1.4.20.1.3.10.2.0.1 = IpAddress: 255.255.0.0
+`,
+	"snmpwalk -v 2c -c public -On router2 " + oidIPRouteMask: `.1.3.6.1.2.1.4.21.1.11 = No Such Object available on this agent at this OID
+`,
+}
+
+func TestSNMPSource_Ranges(t *testing.T) {
+	ranges, err := NewSNMPSource("public", "2c", fakeSNMP(testSNMPWalks)).Ranges("router1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []DiscoveredRange{
+		{Kind: KindInterface, Source: "router1", CIDR: "10.0.0.0/30"},
+		{Kind: KindInterface, Source: "router1", CIDR: "10.1.0.0/16"},
+		{Kind: KindRoute, Source: "router1", CIDR: "10.0.0.0/30"},
+		{Kind: KindRoute, Source: "router1", CIDR: "10.1.0.0/16"},
+		{Kind: KindRoute, Source: "router1", CIDR: "10.2.0.0/16"},
+	}
+	if len(ranges) != len(expected) {
+		t.Fatalf("expected %d ranges, got %d: %+v", len(expected), len(ranges), ranges)
+	}
+	for i, exp := range expected {
+		if ranges[i] != exp {
+			t.Errorf("range %d: expected %+v, got %+v", i, exp, ranges[i])
+		}
+	}
+
+	if _, err := NewSNMPSource("private", "2c", fakeSNMP(testSNMPWalks)).Ranges("router1"); err == nil {
+		t.Errorf("expected error when snmpwalk fails")
+	}
+}
+
+func TestMaskedNetwork(t *testing.T) {
+	tests := []struct {
+		ip      string
+		mask    string
+		want    string
+		wantErr bool
+	}{
+		{ip: "10.1.2.3", mask: "255.255.0.0", want: "10.1.0.0/16"},
+		{ip: "192.168.1.1", mask: "255.255.255.255", want: "192.168.1.1/32"},
+		{ip: "10.0.0.1", mask: "255.0.255.0", wantErr: true},
+		{ip: "10.0.0.1", mask: "Gauge32", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := maskedNetwork(parseNeighborIP(tt.ip), tt.mask)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("maskedNetwork(%s, %s) error = %v, wantErr %v", tt.ip, tt.mask, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("maskedNetwork(%s, %s) = %s, want %s", tt.ip, tt.mask, got, tt.want)
+		}
+	}
+}
+
+func TestCLIHandler_SNMPDiscover(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "discovered.txt")
+
+	handler := NewCLIHandler()
+	handler.run = fakeSNMP(testSNMPWalks)
+
+	if err := handler.Run([]string{"cidr-calc", "snmp-discover", "router1", "-o", output, "router2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	expected := `# Networks discovered over SNMP on router1, router2
+10.0.0.0/30        # router1 interface, router1 route, router2 interface
+10.1.0.0/16        # router1 interface, router1 route
+10.2.0.0/16        # router1 route, router2 interface
+`
+	if string(content) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, content)
+	}
+
+	// The discovered plan feeds straight into -f
+	entries, err := NewBatchReader(defaultFetchTimeout, nil).Read(output)
+	if err != nil || len(entries) != 3 || entries[2].CIDR != "10.2.0.0/16" {
+		t.Errorf("expected the plan to read back as 3 CIDRs, got %v (%v)", entries, err)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "snmp-discover", "--format", "csv", "-o", output, "router1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err = os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	if !strings.Contains(string(content), "10.2.0.0/16") {
+		t.Errorf("expected CSV report to include 10.2.0.0/16, got:\n%s", content)
+	}
+
+	errorCases := [][]string{
+		{"cidr-calc", "snmp-discover"},
+		{"cidr-calc", "snmp-discover", "--version", "3", "router1"},
+		{"cidr-calc", "snmp-discover", "--format", "pdf", "router1"},
+		{"cidr-calc", "snmp-discover", "router3"},
+	}
+	for _, args := range errorCases {
+		if err := handler.Run(args); err == nil {
+			t.Errorf("%v: expected error but got none", args)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SpreadsheetRow is a row of a spreadsheet with its 1-based row number; cells
+// are indexed by column, so cell 0 is column A
+type SpreadsheetRow struct {
+	Number int
+	Cells  []string
+}
+
+// Cell returns the text of a column, or "" beyond the last cell
+func (r SpreadsheetRow) Cell(column int) string {
+	if column < len(r.Cells) {
+		return strings.TrimSpace(r.Cells[column])
+	}
+	return ""
+}
+
+// parseColumn converts a column letter such as "B" or "AA" to its index
+func parseColumn(name string) (int, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "" {
+		return 0, fmt.Errorf("empty column")
+	}
+	column := 0
+	for _, letter := range name {
+		if letter < 'A' || letter > 'Z' {
+			return 0, fmt.Errorf("invalid column %q (expected a letter such as B)", name)
+		}
+		column = column*26 + int(letter-'A'+1)
+	}
+	return column - 1, nil
+}
+
+// readSpreadsheet reads the rows of an .xlsx workbook sheet, or of a CSV or
+// TSV file. An empty sheet name reads the first sheet of a workbook.
+func readSpreadsheet(filename, sheet string) ([]SpreadsheetRow, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx", ".xlsm":
+		return readXLSX(filename, sheet)
+	case ".csv", ".tsv", ".txt":
+		if sheet != "" {
+			return nil, fmt.Errorf("--sheet applies to .xlsx workbooks only")
+		}
+		return readDelimited(filename)
+	}
+	return nil, fmt.Errorf("unsupported spreadsheet %s (supported: .xlsx, .csv, .tsv; save .xls and .ods files as one of these)", filename)
+}
+
+// readDelimited reads a CSV file, or a TSV file when its first line has tabs
+func readDelimited(filename string) ([]SpreadsheetRow, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spreadsheet: %v", err)
+	}
+
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(content), "\ufeff")))
+	firstLine, _, _ := strings.Cut(string(content), "\n")
+	if strings.Contains(firstLine, "\t") {
+		reader.Comma = '\t'
+	}
+	reader.FieldsPerRecord = -1
+
+	var rows []SpreadsheetRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", filename, err)
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, SpreadsheetRow{Number: line, Cells: record})
+	}
+	return rows, nil
+}
+
+// xlsxWorkbook is xl/workbook.xml
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxRelationships is xl/_rels/workbook.xml.rels
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a string item: plain text or rich-text runs
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String joins the text of the item
+func (t xlsxText) String() string {
+	text := t.Text
+	for _, run := range t.Runs {
+		text += run.Text
+	}
+	return text
+}
+
+// xlsxSharedStrings is xl/sharedStrings.xml
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+// xlsxSheet is a worksheet part
+type xlsxSheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX reads the cell text of a workbook sheet. Only the cached values of
+// formulas are read, so the workbook must have been saved by a spreadsheet
+// application.
+func readXLSX(filename, sheetName string) ([]SpreadsheetRow, error) {
+	archive, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook %s: %v", filename, err)
+	}
+	defer archive.Close()
+
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(&archive.Reader, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var relationships xlsxRelationships
+	if err := decodeZipXML(&archive.Reader, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, err
+	}
+	var shared xlsxSharedStrings
+	if err := decodeZipXML(&archive.Reader, "xl/sharedStrings.xml", &shared); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	// Resolve the sheet name to its part through the workbook relationships
+	var names []string
+	var target string
+	for i, sheet := range workbook.Sheets {
+		names = append(names, sheet.Name)
+		if (sheetName == "" && i == 0) || sheet.Name == sheetName {
+			for _, relationship := range relationships.Relationships {
+				if relationship.ID == sheet.ID {
+					target = relationship.Target
+				}
+			}
+		}
+	}
+	if target == "" {
+		return nil, fmt.Errorf("workbook %s has no sheet %q (sheets: %s)", filename, sheetName, strings.Join(names, ", "))
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	var sheet xlsxSheet
+	if err := decodeZipXML(&archive.Reader, target, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([]SpreadsheetRow, 0, len(sheet.Rows))
+	for i, sheetRow := range sheet.Rows {
+		row := SpreadsheetRow{Number: sheetRow.Number}
+		if row.Number == 0 {
+			row.Number = i + 1
+		}
+		for j, cell := range sheetRow.Cells {
+			column := j
+			if cell.Ref != "" {
+				if column, err = parseColumn(strings.TrimRight(cell.Ref, "0123456789")); err != nil {
+					return nil, fmt.Errorf("%s: invalid cell reference %q", filename, cell.Ref)
+				}
+			}
+			for len(row.Cells) <= column {
+				row.Cells = append(row.Cells, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, fmt.Errorf("%s: cell %s refers to a missing shared string", filename, cell.Ref)
+				}
+				row.Cells[column] = shared.Items[index].String()
+			case "inlineStr":
+				row.Cells[column] = cell.Inline.String()
+			default:
+				row.Cells[column] = cell.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// decodeZipXML decodes an XML part of a zip archive; a missing part fails with
+// an error matching os.ErrNotExist
+func decodeZipXML(archive *zip.Reader, name string, target interface{}) error {
+	part, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("workbook has no %s: %w", name, err)
+	}
+	defer part.Close()
+
+	if err := xml.NewDecoder(part).Decode(target); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", name, err)
+	}
+	return nil
+}
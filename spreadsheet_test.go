@@ -0,0 +1,107 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeXLSX writes a minimal workbook with one sheet per entry of sheets,
+// whose XML is the sheetData content, and the given shared strings
+func writeXLSX(t *testing.T, path string, shared []string, sheets map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create workbook: %v", err)
+	}
+	defer file.Close()
+	archive := zip.NewWriter(file)
+
+	parts := map[string]string{}
+	var workbook, relationships strings.Builder
+	workbook.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	relationships.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, name := range []string{"Allocations", "Retired"} {
+		data, ok := sheets[name]
+		if !ok {
+			continue
+		}
+		id := string(rune('1' + i))
+		workbook.WriteString(`<sheet name="` + name + `" sheetId="` + id + `" r:id="rId` + id + `"/>`)
+		relationships.WriteString(`<Relationship Id="rId` + id + `" Target="worksheets/sheet` + id + `.xml"/>`)
+		parts["xl/worksheets/sheet"+id+".xml"] = `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + data + `</sheetData></worksheet>`
+	}
+	parts["xl/workbook.xml"] = workbook.String() + `</sheets></workbook>`
+	parts["xl/_rels/workbook.xml.rels"] = relationships.String() + `</Relationships>`
+
+	var sst strings.Builder
+	sst.WriteString(`<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	for _, text := range shared {
+		sst.WriteString(`<si><t>` + text + `</t></si>`)
+	}
+	parts["xl/sharedStrings.xml"] = sst.String() + `</sst>`
+
+	for name, content := range parts {
+		writer, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		writer.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("failed to write workbook: %v", err)
+	}
+}
+
+func TestReadSpreadsheet(t *testing.T) {
+	dir := t.TempDir()
+
+	workbook := filepath.Join(dir, "ipam.xlsx")
+	writeXLSX(t, workbook, []string{"Network", "10.0.0.0/24", "web"}, map[string]string{
+		"Allocations": `<row r="1"><c r="A1" t="s"><v>0</v></c></row>` +
+			`<row r="3"><c r="B3" t="s"><v>1</v></c><c r="C3" t="s"><v>2</v></c></row>`,
+		"Retired": `<row r="1"><c r="A1" t="inlineStr"><is><t>10.9.0.0/16</t></is></c><c r="B1"><v>42</v></c></row>`,
+	})
+
+	rows, err := readSpreadsheet(workbook, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []SpreadsheetRow{{Number: 1, Cells: []string{"Network"}}, {Number: 3, Cells: []string{"", "10.0.0.0/24", "web"}}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %+v, got %+v", expected, rows)
+	}
+
+	rows, err = readSpreadsheet(workbook, "Retired")
+	if err != nil || len(rows) != 1 || rows[0].Cell(0) != "10.9.0.0/16" || rows[0].Cell(1) != "42" || rows[0].Cell(5) != "" {
+		t.Errorf("unexpected rows %+v (%v)", rows, err)
+	}
+	if _, err := readSpreadsheet(workbook, "Plan"); err == nil || !strings.Contains(err.Error(), "sheets: Allocations, Retired") {
+		t.Errorf("expected a missing sheet error, got %v", err)
+	}
+
+	tsv := filepath.Join(dir, "ipam.tsv")
+	os.WriteFile(tsv, []byte("cidr\tname\n10.0.0.0/24\tweb tier\n"), 0644)
+	rows, err = readSpreadsheet(tsv, "")
+	if err != nil || len(rows) != 2 || rows[1].Number != 2 || rows[1].Cell(1) != "web tier" {
+		t.Errorf("unexpected rows %+v (%v)", rows, err)
+	}
+
+	if _, err := readSpreadsheet(filepath.Join(dir, "ipam.ods"), ""); err == nil || !strings.Contains(err.Error(), "unsupported spreadsheet") {
+		t.Errorf("expected an unsupported format error, got %v", err)
+	}
+}
+
+func TestParseColumn(t *testing.T) {
+	for name, expected := range map[string]int{"A": 0, "c": 2, "Z": 25, "AA": 26, "AB": 27} {
+		if column, err := parseColumn(name); err != nil || column != expected {
+			t.Errorf("%s: expected %d, got %d (%v)", name, expected, column, err)
+		}
+	}
+	if _, err := parseColumn("2"); err == nil {
+		t.Error("expected an error for a numeric column")
+	}
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// stateStoreTimeout bounds each request to an etcd or Consul state backend
+const stateStoreTimeout = 10 * time.Second
+
+// StateStore keeps the objects of an IPAM state: the state itself, its
+// journal and its lock. Get fails with an error matching os.ErrNotExist for a
+// missing object, and Create fails with one matching os.ErrExist for an object
+// that is already there, so a lock can be taken by creating it.
+type StateStore interface {
+	Get(key string) ([]byte, error)
+	Create(key string, content []byte) error
+	Put(key string, content []byte) error
+	Delete(key string) error
+}
+
+// ipamState is the location of a state in its store; the journal and lock are
+// stored next to it under derived keys
+type ipamState struct {
+	store    StateStore
+	key      string
+	location string // the --state value, used in messages
+}
+
+// String returns the location of the state as given on the command line
+func (s ipamState) String() string {
+	return s.location
+}
+
+// openState returns the state at a --state location: a local file, or an
+// s3://, gs://, etcd:// or consul:// URL. etcd and Consul are reached over HTTP;
+// use etcd+https:// or consul+https:// for TLS.
+func (c *CLIHandler) openState(location string) (ipamState, error) {
+	scheme, rest, ok := strings.Cut(location, "://")
+	if !ok {
+		return localState(location), nil
+	}
+
+	authority, key, _ := strings.Cut(rest, "/")
+	if authority == "" || key == "" {
+		return ipamState{}, fmt.Errorf("invalid state location %s: expected %s://HOST-OR-BUCKET/KEY", location, scheme)
+	}
+
+	state := ipamState{key: key, location: location}
+	switch scheme {
+	case "s3":
+		state.store = &s3Store{bucket: authority, run: c.run}
+	case "gs":
+		state.store = &gcsStore{bucket: authority, run: c.run}
+	case "etcd", "etcd+https":
+		state.store = newEtcdStore(httpEndpoint(scheme, authority))
+	case "consul", "consul+https":
+		state.store = newConsulStore(httpEndpoint(scheme, authority), os.Getenv("CONSUL_HTTP_TOKEN"))
+	default:
+		return ipamState{}, fmt.Errorf("unsupported state location %s (supported: a file, s3://, gs://, etcd://, consul://)", location)
+	}
+	return state, nil
+}
+
+// localState returns the state kept in a local file
+func localState(path string) ipamState {
+	return ipamState{store: fileStore{}, key: path, location: path}
+}
+
+// httpEndpoint returns the base URL of an etcd or Consul API
+func httpEndpoint(scheme, host string) string {
+	if strings.HasSuffix(scheme, "+https") {
+		return "https://" + host
+	}
+	return "http://" + host
+}
+
+// fileStore keeps state objects as files; keys are paths
+type fileStore struct{}
+
+func (fileStore) Get(key string) ([]byte, error) {
+	return os.ReadFile(key)
+}
+
+func (fileStore) Create(key string, content []byte) error {
+	file, err := os.OpenFile(key, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (fileStore) Put(key string, content []byte) error {
+	return writeFileAtomic(key, content)
+}
+
+func (fileStore) Delete(key string) error {
+	return os.Remove(key)
+}
+
+// s3Store keeps state objects in an S3 bucket through the AWS CLI, which picks
+// up the standard credential chain. Create relies on conditional writes
+// (If-None-Match), which S3 supports since 2024.
+type s3Store struct {
+	bucket string
+	run    commandRunner
+}
+
+func (s *s3Store) Get(key string) ([]byte, error) {
+	return withTempFile(nil, func(path string) error {
+		_, err := s.run("aws", "s3api", "get-object", "--bucket", s.bucket, "--key", key, path)
+		return cliStoreError(err, "s3://"+s.bucket+"/"+key)
+	})
+}
+
+func (s *s3Store) Create(key string, content []byte) error {
+	_, err := withTempFile(content, func(path string) error {
+		_, err := s.run("aws", "s3api", "put-object", "--bucket", s.bucket, "--key", key, "--body", path, "--if-none-match", "*")
+		return cliStoreError(err, "s3://"+s.bucket+"/"+key)
+	})
+	return err
+}
+
+func (s *s3Store) Put(key string, content []byte) error {
+	_, err := withTempFile(content, func(path string) error {
+		_, err := s.run("aws", "s3api", "put-object", "--bucket", s.bucket, "--key", key, "--body", path)
+		return cliStoreError(err, "s3://"+s.bucket+"/"+key)
+	})
+	return err
+}
+
+func (s *s3Store) Delete(key string) error {
+	_, err := s.run("aws", "s3api", "delete-object", "--bucket", s.bucket, "--key", key)
+	return cliStoreError(err, "s3://"+s.bucket+"/"+key)
+}
+
+// gcsStore keeps state objects in a Cloud Storage bucket through the gcloud CLI
+type gcsStore struct {
+	bucket string
+	run    commandRunner
+}
+
+func (g *gcsStore) url(key string) string {
+	return "gs://" + g.bucket + "/" + key
+}
+
+func (g *gcsStore) Get(key string) ([]byte, error) {
+	output, err := g.run("gcloud", "storage", "cat", g.url(key))
+	if err != nil {
+		return nil, cliStoreError(err, g.url(key))
+	}
+	return output, nil
+}
+
+func (g *gcsStore) Create(key string, content []byte) error {
+	_, err := withTempFile(content, func(path string) error {
+		_, err := g.run("gcloud", "storage", "cp", "--if-generation-match=0", path, g.url(key))
+		return cliStoreError(err, g.url(key))
+	})
+	return err
+}
+
+func (g *gcsStore) Put(key string, content []byte) error {
+	_, err := withTempFile(content, func(path string) error {
+		_, err := g.run("gcloud", "storage", "cp", path, g.url(key))
+		return cliStoreError(err, g.url(key))
+	})
+	return err
+}
+
+func (g *gcsStore) Delete(key string) error {
+	_, err := g.run("gcloud", "storage", "rm", g.url(key))
+	return cliStoreError(err, g.url(key))
+}
+
+// withTempFile writes content to a temporary file, runs use with its path and
+// returns what use left in the file
+func withTempFile(content []byte, use func(path string) error) ([]byte, error) {
+	temp, err := os.CreateTemp("", "cidr-calc-state-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(temp.Name())
+
+	_, err = temp.Write(content)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to write temporary file: %v", err)
+	}
+
+	if err := use(temp.Name()); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(temp.Name())
+}
+
+// cliStoreError maps the errors of the cloud CLIs for missing objects and
+// failed preconditions to os.ErrNotExist and os.ErrExist. Only the error
+// codes of the providers count, as object names and other messages can hold
+// the same numbers as HTTP statuses.
+func cliStoreError(err error, object string) error {
+	if err == nil {
+		return nil
+	}
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "NoSuchKey"), strings.Contains(message, "(404)"),
+		strings.Contains(message, "No URLs matched"), strings.Contains(message, "matched no objects"):
+		return fmt.Errorf("%s: %w", object, os.ErrNotExist)
+	case strings.Contains(message, "(PreconditionFailed)"), strings.Contains(message, "conditionNotMet"),
+		strings.Contains(message, "pre-conditions you specified did not hold"):
+		return fmt.Errorf("%s: %w", object, os.ErrExist)
+	}
+	return err
+}
+
+// etcdStore keeps state objects as keys of an etcd cluster through its v3
+// JSON gateway
+type etcdStore struct {
+	endpoint string
+	client   *http.Client
+}
+
+func newEtcdStore(endpoint string) *etcdStore {
+	return &etcdStore{endpoint: endpoint, client: &http.Client{Timeout: stateStoreTimeout}}
+}
+
+// call posts a request to an etcd v3 API method and decodes the response
+func (e *etcdStore) call(method string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Post(e.endpoint+"/v3/"+method, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("etcd %s failed: %v", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("etcd %s failed: %s: %s", method, resp.Status, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("failed to parse etcd %s response: %v", method, err)
+	}
+	return nil
+}
+
+func (e *etcdStore) Get(key string) ([]byte, error) {
+	var response struct {
+		Kvs []struct {
+			Value []byte `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := e.call("kv/range", map[string]interface{}{"key": []byte(key)}, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Kvs) == 0 {
+		return nil, fmt.Errorf("etcd key %s: %w", key, os.ErrNotExist)
+	}
+	return response.Kvs[0].Value, nil
+}
+
+func (e *etcdStore) Create(key string, content []byte) error {
+	// The put only succeeds while the key has never been created
+	request := map[string]interface{}{
+		"compare": []map[string]interface{}{{"key": []byte(key), "target": "CREATE", "create_revision": "0"}},
+		"success": []map[string]interface{}{{"request_put": map[string]interface{}{"key": []byte(key), "value": content}}},
+	}
+	var response struct {
+		Succeeded bool `json:"succeeded"`
+	}
+	if err := e.call("kv/txn", request, &response); err != nil {
+		return err
+	}
+	if !response.Succeeded {
+		return fmt.Errorf("etcd key %s: %w", key, os.ErrExist)
+	}
+	return nil
+}
+
+func (e *etcdStore) Put(key string, content []byte) error {
+	var response struct{}
+	return e.call("kv/put", map[string]interface{}{"key": []byte(key), "value": content}, &response)
+}
+
+func (e *etcdStore) Delete(key string) error {
+	var response struct{}
+	return e.call("kv/deleterange", map[string]interface{}{"key": []byte(key)}, &response)
+}
+
+// consulStore keeps state objects in the KV store of a Consul agent; the token
+// comes from CONSUL_HTTP_TOKEN like for the consul CLI
+type consulStore struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+func newConsulStore(endpoint, token string) *consulStore {
+	return &consulStore{endpoint: endpoint, token: token, client: &http.Client{Timeout: stateStoreTimeout}}
+}
+
+// do sends a request for a KV key and returns the response body and status
+func (c *consulStore) do(method, key string, query url.Values, body []byte) ([]byte, int, error) {
+	target := c.endpoint + "/v1/kv/" + strings.TrimPrefix(key, "/")
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid Consul key %s: %v", key, err)
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("consul %s %s failed: %v", method, key, err)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read Consul response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return nil, 0, fmt.Errorf("consul %s %s failed: %s: %s", method, key, resp.Status, strings.TrimSpace(string(content)))
+	}
+	return content, resp.StatusCode, nil
+}
+
+func (c *consulStore) Get(key string) ([]byte, error) {
+	content, status, err := c.do(http.MethodGet, key, url.Values{"raw": {""}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, fmt.Errorf("consul key %s: %w", key, os.ErrNotExist)
+	}
+	return content, nil
+}
+
+// put writes a key and reports whether Consul accepted the write
+func (c *consulStore) put(key string, query url.Values, content []byte) (bool, error) {
+	response, status, err := c.do(http.MethodPut, key, query, content)
+	if err != nil {
+		return false, err
+	}
+	if status == http.StatusNotFound {
+		return false, fmt.Errorf("consul PUT %s failed: 404 Not Found", key)
+	}
+	return strings.TrimSpace(string(response)) == "true", nil
+}
+
+func (c *consulStore) Create(key string, content []byte) error {
+	// A check-and-set index of 0 only writes a key that does not exist
+	written, err := c.put(key, url.Values{"cas": {"0"}}, content)
+	if err != nil {
+		return err
+	}
+	if !written {
+		return fmt.Errorf("consul key %s: %w", key, os.ErrExist)
+	}
+	return nil
+}
+
+func (c *consulStore) Put(key string, content []byte) error {
+	written, err := c.put(key, nil, content)
+	if err != nil {
+		return err
+	}
+	if !written {
+		return fmt.Errorf("consul rejected the write of %s", key)
+	}
+	return nil
+}
+
+func (c *consulStore) Delete(key string) error {
+	_, _, err := c.do(http.MethodDelete, key, nil, nil)
+	return err
+}
+
+// isNotExist reports whether a store error is about a missing object
+func isNotExist(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
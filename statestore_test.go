@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeConsul serves the Consul KV endpoints used by consulStore from memory
+func fakeConsul(t *testing.T) (*httptest.Server, map[string]string) {
+	var mu sync.Mutex
+	kv := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+		value, exists := kv[key]
+		switch r.Method {
+		case http.MethodGet:
+			if !exists {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, value)
+		case http.MethodPut:
+			if r.URL.Query().Get("cas") == "0" && exists {
+				io.WriteString(w, "false")
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			kv[key] = string(body)
+			io.WriteString(w, "true")
+		case http.MethodDelete:
+			delete(kv, key)
+			io.WriteString(w, "true")
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, kv
+}
+
+func TestCLIHandler_openState(t *testing.T) {
+	handler := NewCLIHandler()
+
+	tests := []struct {
+		location string
+		key      string
+		expected string // store type, or an error
+	}{
+		{"ipam.txt", "ipam.txt", "main.fileStore"},
+		{"s3://team-bucket/ipam/prod.txt", "ipam/prod.txt", "*main.s3Store"},
+		{"gs://team-bucket/prod.txt", "prod.txt", "*main.gcsStore"},
+		{"etcd://10.0.0.5:2379/ipam/prod", "ipam/prod", "*main.etcdStore"},
+		{"consul+https://consul.internal:8501/ipam/prod", "ipam/prod", "*main.consulStore"},
+		{"s3://team-bucket", "", "invalid state location"},
+		{"ftp://host/ipam.txt", "", "unsupported state location"},
+	}
+
+	for _, tt := range tests {
+		state, err := handler.openState(tt.location)
+		if err != nil {
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("%s: expected %q, got error %v", tt.location, tt.expected, err)
+			}
+			continue
+		}
+		if kind := fmt.Sprintf("%T", state.store); kind != tt.expected || state.key != tt.key {
+			t.Errorf("%s: expected %s with key %q, got %s with key %q", tt.location, tt.expected, tt.key, kind, state.key)
+		}
+	}
+
+	state, _ := handler.openState("consul+https://consul.internal:8501/ipam/prod")
+	if endpoint := state.store.(*consulStore).endpoint; endpoint != "https://consul.internal:8501" {
+		t.Errorf("unexpected Consul endpoint %s", endpoint)
+	}
+}
+
+func TestConsulStore(t *testing.T) {
+	server, _ := fakeConsul(t)
+	store := newConsulStore(server.URL, "")
+
+	if _, err := store.Get("ipam/prod"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing key, got %v", err)
+	}
+	if err := store.Create("ipam/prod.lock", []byte("pid 1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Create("ipam/prod.lock", []byte("pid 2")); !errors.Is(err, os.ErrExist) {
+		t.Errorf("expected an existing key, got %v", err)
+	}
+	if err := store.Put("ipam/prod", []byte("10.0.0.0/24\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, err := store.Get("ipam/prod"); err != nil || string(content) != "10.0.0.0/24\n" {
+		t.Errorf("unexpected content %q (%v)", content, err)
+	}
+	if err := store.Delete("ipam/prod.lock"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Get("ipam/prod.lock"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the lock to be deleted, got %v", err)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	objects := map[string]string{"ipam.txt": "10.0.0.0/24\n"}
+	var calls []string
+	run := func(name string, args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args[:2], " "))
+		key := args[5]
+		switch args[1] {
+		case "get-object":
+			content, ok := objects[key]
+			if !ok {
+				return nil, fmt.Errorf("aws failed: An error occurred (NoSuchKey) when calling the GetObject operation")
+			}
+			return nil, os.WriteFile(args[6], []byte(content), 0644)
+		case "put-object":
+			if len(args) > 8 && args[8] == "--if-none-match" {
+				if _, ok := objects[key]; ok {
+					return nil, fmt.Errorf("aws failed: An error occurred (PreconditionFailed) when calling the PutObject operation")
+				}
+			}
+			body, _ := os.ReadFile(args[7])
+			objects[key] = string(body)
+		}
+		return nil, nil
+	}
+	store := &s3Store{bucket: "team-bucket", run: run}
+
+	if content, err := store.Get("ipam.txt"); err != nil || string(content) != "10.0.0.0/24\n" {
+		t.Errorf("unexpected content %q (%v)", content, err)
+	}
+	if _, err := store.Get("other.txt"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing object, got %v", err)
+	}
+	if err := store.Create("ipam.txt", []byte("")); !errors.Is(err, os.ErrExist) {
+		t.Errorf("expected an existing object, got %v", err)
+	}
+	if err := store.Put("ipam.txt.journal", []byte("entry\n")); err != nil || objects["ipam.txt.journal"] != "entry\n" {
+		t.Errorf("unexpected journal %q (%v)", objects["ipam.txt.journal"], err)
+	}
+	if calls[0] != "s3api get-object" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+}
+
+func TestCLIStoreError(t *testing.T) {
+	testCases := []struct {
+		message  string
+		expected error
+	}{
+		{"aws failed: An error occurred (PreconditionFailed) when calling the PutObject operation", os.ErrExist},
+		{"gcloud failed: ERROR: HTTPError 412: At least one of the pre-conditions you specified did not hold.", os.ErrExist},
+		{`gcloud failed: {"code": 412, "errors": [{"reason": "conditionNotMet"}]}`, os.ErrExist},
+		{"aws failed: An error occurred (NoSuchKey) when calling the GetObject operation", os.ErrNotExist},
+		{"gcloud failed: ERROR: The following URLs matched no objects or files", os.ErrNotExist},
+		// Numbers in object names and other failures are not conflicts
+		{"aws failed: An error occurred (AccessDenied) when calling the PutObject operation on ipam/412.txt", nil},
+		{"gcloud failed: ERROR: HTTPError 403: 4120 bytes over quota", nil},
+	}
+	for _, tt := range testCases {
+		err := cliStoreError(errors.New(tt.message), "s3://bucket/key")
+		if tt.expected == nil {
+			if errors.Is(err, os.ErrExist) || errors.Is(err, os.ErrNotExist) {
+				t.Errorf("%s: expected the error as it is, got %v", tt.message, err)
+			}
+		} else if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.message, tt.expected, err)
+		}
+	}
+}
+
+func TestCLIHandler_IPAMRemoteState(t *testing.T) {
+	server, kv := fakeConsul(t)
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	state := "consul://" + strings.TrimPrefix(server.URL, "http://") + "/ipam/prod.txt"
+	output := filepath.Join(t.TempDir(), "out.txt")
+
+	for _, prefix := range []string{"24", "25"} {
+		if err := handler.Run([]string{"cidr-calc", "ipam", "allocate", "--pool", "10.0.0.0/22", "--prefix", prefix, "--state", state, "-o", output}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := handler.Run([]string{"cidr-calc", "ipam", "release", "--state", state, "10.0.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := "10.0.1.0/25 # allocated from 10.0.0.0/22 (first-fit)\n"; kv["ipam/prod.txt"] != expected {
+		t.Errorf("expected state %q, got %q", expected, kv["ipam/prod.txt"])
+	}
+	if _, locked := kv["ipam/prod.txt.lock"]; locked {
+		t.Error("expected the lock to be released")
+	}
+
+	stateRef, _ := handler.openState(state)
+	entries, err := readJournal(stateRef)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 || entries[2].Op != OpRelease {
+		t.Errorf("unexpected journal %+v", entries)
+	}
+}
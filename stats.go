@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// statsFileEnv names the local usage statistics file. Statistics are kept only
+// when it is set, and they never leave the machine.
+const statsFileEnv = "CIDR_CALC_STATS_FILE"
+
+// UsageStats counts the commands and output formats of successful runs. Only
+// names are counted: no CIDRs, file names or other arguments are kept.
+type UsageStats struct {
+	Since    time.Time      `json:"since"`
+	Runs     int            `json:"runs"`
+	Commands map[string]int `json:"commands"`
+	Formats  map[string]int `json:"formats"`
+}
+
+// loadUsageStats reads a statistics file; a missing file has no runs
+func loadUsageStats(filename string) (*UsageStats, error) {
+	stats := &UsageStats{Commands: make(map[string]int), Formats: make(map[string]int)}
+	content, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage statistics: %v", err)
+	}
+	if err := json.Unmarshal(content, stats); err != nil {
+		return nil, fmt.Errorf("failed to parse usage statistics %s: %v", filename, err)
+	}
+	if stats.Commands == nil {
+		stats.Commands = make(map[string]int)
+	}
+	if stats.Formats == nil {
+		stats.Formats = make(map[string]int)
+	}
+	return stats, nil
+}
+
+// save replaces the statistics file
+func (s *UsageStats) save(filename string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode usage statistics: %v", err)
+	}
+	return writeFileAtomic(filename, append(content, '\n'))
+}
+
+// Record counts a run of the command, and its format unless empty
+func (s *UsageStats) Record(command, format string, now time.Time) {
+	if s.Since.IsZero() {
+		s.Since = now.UTC().Truncate(time.Second)
+	}
+	s.Runs++
+	s.Commands[command]++
+	if format != "" {
+		s.Formats[format]++
+	}
+}
+
+// recordUsage counts a successful run in the statistics file, when one is
+// set. Statistics are a convenience: failing to keep them only warns.
+func (c *CLIHandler) recordUsage(command, format string) {
+	if c.statsFile == "" {
+		return
+	}
+	stats, err := loadUsageStats(c.statsFile)
+	if err == nil {
+		stats.Record(command, format, time.Now())
+		err = stats.save(c.statsFile)
+	}
+	if err != nil {
+		c.warnf("usage statistics not recorded: %v", err)
+	}
+}
+
+// calculatorCommand names the mode of a calculator run for the statistics
+func calculatorCommand(config *Config) string {
+	switch {
+	case config.Interactive:
+		return "interactive"
+	case config.TUI:
+		return "tui"
+	case config.Normalize:
+		return "normalize"
+	case len(config.VLSM) > 0:
+		return "vlsm"
+	case config.InputFile != "":
+		return "batch"
+	}
+	return "calculate"
+}
+
+// subcommandFormat returns the --format given to a subcommand, if any
+func subcommandFormat(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, attached := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "format" {
+			continue
+		}
+		if attached {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// FormatUsageStats writes the counts from most to least used
+func (f *OutputFormatter) FormatUsageStats(stats *UsageStats, filename string) string {
+	var output strings.Builder
+
+	if stats.Since.IsZero() {
+		output.WriteString("Usage statistics: no runs recorded yet\n")
+	} else {
+		output.WriteString(fmt.Sprintf("Usage statistics since %s: %s\n", stats.Since.Format("2006-01-02"), plural(stats.Runs, "run")))
+	}
+	output.WriteString(fmt.Sprintf("File: %s\n", filename))
+
+	for _, section := range []struct {
+		title  string
+		counts map[string]int
+	}{
+		{"Commands", stats.Commands},
+		{"Formats", stats.Formats},
+	} {
+		if len(section.counts) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(section.counts))
+		width := 0
+		for name := range section.counts {
+			names = append(names, name)
+			if len(name) > width {
+				width = len(name)
+			}
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if section.counts[names[i]] != section.counts[names[j]] {
+				return section.counts[names[i]] > section.counts[names[j]]
+			}
+			return names[i] < names[j]
+		})
+
+		output.WriteString(fmt.Sprintf("\n%s:\n", section.title))
+		for _, name := range names {
+			output.WriteString(fmt.Sprintf("  %-*s  %d\n", width, name, section.counts[name]))
+		}
+	}
+
+	return output.String()
+}
+
+// runStats implements the stats subcommand
+func (c *CLIHandler) runStats(args []string) error {
+	flagSet := flag.NewFlagSet("stats", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var format, outputFile string
+	var reset bool
+	flagSet.StringVar(&format, "format", FormatText, "Output format: text or json")
+	flagSet.BoolVar(&reset, "reset", false, "Clear the statistics")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if flagSet.NArg() > 0 {
+		return fmt.Errorf("stats takes no arguments, got %s", flagSet.Arg(0))
+	}
+	if format != FormatText && format != FormatJSON {
+		return fmt.Errorf("stats supports %s and %s output, not %s", FormatText, FormatJSON, format)
+	}
+	if c.statsFile == "" {
+		return fmt.Errorf("usage statistics are off; set %s to a file path to keep them", statsFileEnv)
+	}
+
+	if reset {
+		if err := os.Remove(c.statsFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to reset usage statistics: %v", err)
+		}
+		c.notef("usage statistics cleared")
+		return nil
+	}
+
+	stats, err := loadUsageStats(c.statsFile)
+	if err != nil {
+		return err
+	}
+	if format == FormatJSON {
+		content, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode usage statistics: %v", err)
+		}
+		return c.writeOutput(string(content)+"\n", outputFile)
+	}
+	return c.writeOutput(c.formatter.FormatUsageStats(stats, c.statsFile), outputFile)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUsageStats_Record(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "stats.json")
+
+	stats, err := loadUsageStats(filename)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Runs != 0 || !stats.Since.IsZero() {
+		t.Errorf("expected a missing file to have no runs, got %+v", stats)
+	}
+
+	first := time.Date(2026, 3, 1, 12, 0, 0, 500, time.UTC)
+	stats.Record("calculate", FormatJSON, first)
+	stats.Record("inventory", "", first.Add(time.Hour))
+	if err := stats.save(filename); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stats, err = loadUsageStats(filename)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Runs != 2 || stats.Commands["calculate"] != 1 || stats.Commands["inventory"] != 1 || len(stats.Formats) != 1 {
+		t.Errorf("unexpected statistics %+v", stats)
+	}
+	if !stats.Since.Equal(first.Truncate(time.Second)) {
+		t.Errorf("expected the first run to start the statistics, got %s", stats.Since)
+	}
+
+	if err := os.WriteFile(filename, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadUsageStats(filename); err == nil {
+		t.Error("expected an error for a corrupt file")
+	}
+}
+
+func TestSubcommandFormat(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"10.0.0.0/24", "--format", "yaml"}, "yaml"},
+		{[]string{"-format=json", "10.0.0.0/24"}, "json"},
+		{[]string{"--formats", "x", "10.0.0.0/24"}, ""},
+		{[]string{"--", "--format", "json"}, ""},
+		{[]string{"--format"}, ""},
+	}
+	for _, tt := range testCases {
+		if format := subcommandFormat(tt.args); format != tt.expected {
+			t.Errorf("%v: expected %q, got %q", tt.args, tt.expected, format)
+		}
+	}
+}
+
+func TestOutputFormatter_FormatUsageStats(t *testing.T) {
+	stats := &UsageStats{
+		Since:    time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Runs:     4,
+		Commands: map[string]int{"supernet": 1, "calculate": 2, "inventory": 1},
+		Formats:  map[string]int{"text": 2},
+	}
+
+	expected := `Usage statistics since 2026-03-01: 4 runs
+File: stats.json
+
+Commands:
+  calculate  2
+  inventory  1
+  supernet   1
+
+Formats:
+  text  2
+`
+	if output := NewOutputFormatter().FormatUsageStats(stats, "stats.json"); output != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestCLIHandler_Stats(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	// Statistics are off unless a file is named
+	handler.statsFile = ""
+	if err := handler.Run([]string{"cidr-calc", "--format", "json", "-o", filepath.Join(dir, "report.json"), "10.0.0.0/30"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := handler.Run([]string{"cidr-calc", "stats"}); err == nil || !strings.Contains(err.Error(), "usage statistics are off") {
+		t.Errorf("expected statistics to be off, got %v", err)
+	}
+
+	handler.statsFile = filepath.Join(dir, "stats.json")
+	runs := [][]string{
+		{"--format", "json", "-o", filepath.Join(dir, "report.json"), "10.0.0.0/30"},
+		{"supernet", "10.0.0.0/24", "10.0.1.0/24", "-o", filepath.Join(dir, "supernet.txt")},
+		{"inventory", "10.0.0.0/30", "--format", "yaml", "-o", filepath.Join(dir, "hosts.yml")},
+	}
+	for _, args := range runs {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+	}
+	// Failed runs and the stats command itself are not counted
+	handler.Run([]string{"cidr-calc", "bogus"})
+	handler.Run([]string{"cidr-calc", "stats", "-o", filepath.Join(dir, "before.txt")})
+
+	output := filepath.Join(dir, "stats.txt")
+	if err := handler.Run([]string{"cidr-calc", "stats", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	for _, exp := range []string{": 3 runs\n", "  calculate  1\n", "  inventory  1\n", "  supernet   1\n", "  json  1\n", "  yaml  1\n"} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected %q in:\n%s", exp, content)
+		}
+	}
+	if saved, err := os.ReadFile(handler.statsFile); err != nil || bytes.Contains(saved, []byte("10.0.0.0")) || bytes.Contains(saved, []byte(dir)) {
+		t.Errorf("expected no arguments in the statistics file, got %v:\n%s", err, saved)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "stats", "--reset"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(handler.statsFile); !os.IsNotExist(err) {
+		t.Errorf("expected --reset to remove the file, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Supernet returns the network of the given prefix length that contains this
+// one: the /20 around 10.1.13.0/24 is 10.1.0.0/20. The prefix must not be
+// longer than the network's own.
+func (n *NetworkInfo) Supernet(prefix int) (*NetworkInfo, error) {
+	if prefix < 0 || prefix > n.PrefixLength {
+		return nil, fmt.Errorf("no /%d supernet of %s: prefix must be between /0 and /%d", prefix, n.CIDR(), n.PrefixLength)
+	}
+	return NewCIDRCalculator().ParseCIDR(fmt.Sprintf("%s/%d", n.NetworkID, prefix))
+}
+
+// runSupernet implements the supernet subcommand
+func (c *CLIHandler) runSupernet(args []string) error {
+	flagSet := flag.NewFlagSet("supernet", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var prefix int
+	var format, outputFile string
+	flagSet.IntVar(&prefix, "prefix", -1, "Prefix length of the supernet (default: one bit shorter)")
+	flagSet.IntVar(&prefix, "p", -1, "Prefix length of the supernet (default: one bit shorter)")
+	flagSet.StringVar(&format, "format", FormatText, "Output format: text (the CIDR) or any output format")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept CIDRs anywhere among the flags
+	cidrs, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	if !IsSupportedFormat(format) {
+		return fmt.Errorf("unsupported output format: %s (supported: %s)", format, strings.Join(SupportedFormats, ", "))
+	}
+	if len(cidrs) == 0 {
+		return fmt.Errorf("supernet requires at least one CIDR")
+	}
+
+	var text strings.Builder
+	var reports []NetworkReport
+	for _, cidr := range cidrs {
+		network, err := c.calculator.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("failed to parse CIDR %s: %v", cidr, err)
+		}
+		length := prefix
+		if length < 0 {
+			if network.PrefixLength == 0 {
+				return fmt.Errorf("%s has no supernet", network.CIDR())
+			}
+			length = network.PrefixLength - 1
+		}
+		supernet, err := network.Supernet(length)
+		if err != nil {
+			return err
+		}
+		text.WriteString(supernet.CIDR() + "\n")
+		reports = append(reports, NetworkReport{Info: supernet, Subnets: c.calculator.CalculateSubnets(supernet)})
+	}
+
+	if format == FormatText {
+		return c.writeOutput(text.String(), outputFile)
+	}
+	content, err := c.formatter.RenderReports(format, reports)
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(content, outputFile)
+}
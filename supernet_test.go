@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNetworkInfo_Supernet(t *testing.T) {
+	calculator := NewCIDRCalculator()
+
+	tests := []struct {
+		cidr     string
+		prefix   int
+		expected string
+		err      string
+	}{
+		{cidr: "10.1.13.0/24", prefix: 20, expected: "10.1.0.0/20"},
+		{cidr: "10.1.13.0/24", prefix: 24, expected: "10.1.13.0/24"},
+		{cidr: "10.1.13.0/24", prefix: 0, expected: "0.0.0.0/0"},
+		{cidr: "192.168.1.77/32", prefix: 26, expected: "192.168.1.64/26"},
+		{cidr: "2001:db8:abcd:12::/64", prefix: 48, expected: "2001:db8:abcd::/48"},
+		{cidr: "10.1.13.0/24", prefix: 25, err: "no /25 supernet of 10.1.13.0/24: prefix must be between /0 and /24"},
+	}
+
+	for _, tt := range tests {
+		supernet, err := mustParseCIDR(t, calculator, tt.cidr).Supernet(tt.prefix)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s /%d: expected error %q, got %v", tt.cidr, tt.prefix, tt.err, err)
+			}
+			continue
+		}
+		if err != nil || supernet.CIDR() != tt.expected {
+			t.Errorf("%s /%d: expected %s, got %v, %v", tt.cidr, tt.prefix, tt.expected, supernet, err)
+		}
+	}
+}
+
+func TestCLIHandler_Supernet(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	output := filepath.Join(dir, "supernet.txt")
+	if err := handler.Run([]string{"cidr-calc", "supernet", "10.1.13.0/24", "--prefix", "20", "10.2.5.0/24", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); string(content) != "10.1.0.0/20\n10.2.0.0/20\n" {
+		t.Errorf("expected the /20s of both networks, got %q", content)
+	}
+
+	output = filepath.Join(dir, "parent.json")
+	if err := handler.Run([]string{"cidr-calc", "supernet", "10.1.13.0/24", "--format", "json", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); !strings.Contains(string(content), `"cidr": "10.1.12.0/23"`) {
+		t.Errorf("expected a JSON report of the parent 10.1.12.0/23, got:\n%s", content)
+	}
+
+	for args, expected := range map[string]string{
+		"supernet":                         "supernet requires at least one CIDR",
+		"supernet 10.0.0.0/16 --prefix 24": "no /24 supernet of 10.0.0.0/16: prefix must be between /0 and /16",
+		"supernet 0.0.0.0/0":               "0.0.0.0/0 has no supernet",
+		"supernet ::/0":                    "::/0 has no supernet",
+	} {
+		if err := handler.Run(append([]string{"cidr-calc"}, strings.Fields(args)...)); err == nil || err.Error() != expected {
+			t.Errorf("%s: expected error %q, got %v", args, expected, err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tags are key=value labels written after the CIDR on a plan file line,
+// e.g. "10.20.0.0/16 env=prod team=payments"
+type Tags map[string]string
+
+// Keys returns the tag keys in sorted order
+func (t Tags) Keys() []string {
+	keys := make([]string, 0, len(t))
+	for key := range t {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String returns the tags sorted by key, in plan file syntax
+func (t Tags) String() string {
+	keys := t.Keys()
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + t[key]
+	}
+	return strings.Join(pairs, " ")
+}
+
+// parseTags collects the key=value fields of a plan line; other fields are ignored
+func parseTags(fields []string) Tags {
+	var tags Tags
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			continue
+		}
+		if tags == nil {
+			tags = make(Tags)
+		}
+		tags[key] = value
+	}
+	return tags
+}
+
+// parseAnnotation joins the fields of a plan line that are not key=value
+// tags, such as a name or "vlan 120", by single spaces
+func parseAnnotation(fields []string) string {
+	var words []string
+	for _, field := range fields {
+		if key, _, ok := strings.Cut(field, "="); ok && key != "" {
+			continue
+		}
+		words = append(words, field)
+	}
+	return strings.Join(words, " ")
+}
+
+// tagFilter is a --tag condition: a key that must be present, with a given
+// value unless AnyValue is set
+type tagFilter struct {
+	Key      string
+	Value    string
+	AnyValue bool
+}
+
+// String returns the condition as given on the command line
+func (f tagFilter) String() string {
+	if f.AnyValue {
+		return f.Key
+	}
+	return f.Key + "=" + f.Value
+}
+
+// tagFilterList collects repeated --tag flags; an entry must match all of them
+type tagFilterList []tagFilter
+
+// String returns the conditions as given
+func (l *tagFilterList) String() string {
+	conditions := make([]string, len(*l))
+	for i, filter := range *l {
+		conditions[i] = filter.String()
+	}
+	return strings.Join(conditions, ",")
+}
+
+// Set parses "key=value", or "key" to match any value of the key
+func (l *tagFilterList) Set(value string) error {
+	key, tagValue, hasValue := strings.Cut(value, "=")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("invalid --tag %q (expected key=value or key)", value)
+	}
+	*l = append(*l, tagFilter{Key: key, Value: strings.TrimSpace(tagValue), AnyValue: !hasValue})
+	return nil
+}
+
+// Matches reports whether the tags satisfy every condition
+func (l tagFilterList) Matches(tags Tags) bool {
+	for _, filter := range l {
+		value, ok := tags[filter.Key]
+		if !ok || (!filter.AnyValue && value != filter.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterEntries keeps the batch entries whose tags match every --tag condition
+func filterEntries(entries []BatchEntry, filters tagFilterList, source string) ([]BatchEntry, error) {
+	if len(filters) == 0 {
+		return entries, nil
+	}
+
+	var matched []BatchEntry
+	for _, entry := range entries {
+		if filters.Matches(entry.Tags) {
+			matched = append(matched, entry)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no CIDRs in %s match --tag %s", source, filters.String())
+	}
+	return matched, nil
+}
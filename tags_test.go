@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   []string
+		expected Tags
+	}{
+		{"no fields", nil, nil},
+		{"plain words are ignored", []string{"vlan-10"}, nil},
+		{"key value pairs", []string{"env=prod", "team=payments"}, Tags{"env": "prod", "team": "payments"}},
+		{"empty value", []string{"owner="}, Tags{"owner": ""}},
+		{"missing key", []string{"=prod", "env=prod"}, Tags{"env": "prod"}},
+		{"later value wins", []string{"env=dev", "env=prod"}, Tags{"env": "prod"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tags := parseTags(tt.fields); !reflect.DeepEqual(tags, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, tags)
+			}
+		})
+	}
+}
+
+func TestTags_String(t *testing.T) {
+	tags := Tags{"team": "payments", "env": "prod", "zone": "a"}
+	if got := tags.String(); got != "env=prod team=payments zone=a" {
+		t.Errorf("expected tags sorted by key, got %q", got)
+	}
+	if got := Tags(nil).String(); got != "" {
+		t.Errorf("expected empty string, got %q", got)
+	}
+}
+
+func TestTagFilterList(t *testing.T) {
+	var filters tagFilterList
+	for _, value := range []string{"env=prod", "team"} {
+		if err := filters.Set(value); err != nil {
+			t.Fatalf("unexpected error for %q: %v", value, err)
+		}
+	}
+	if err := filters.Set("=prod"); err == nil {
+		t.Errorf("expected an error for a missing key")
+	}
+	if filters.String() != "env=prod,team" {
+		t.Errorf("unexpected conditions: %s", filters.String())
+	}
+
+	tests := []struct {
+		tags     Tags
+		expected bool
+	}{
+		{Tags{"env": "prod", "team": "payments"}, true},
+		{Tags{"env": "prod", "team": ""}, true},
+		{Tags{"env": "prod"}, false},
+		{Tags{"env": "staging", "team": "payments"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := filters.Matches(tt.tags); got != tt.expected {
+			t.Errorf("Matches(%v): expected %v, got %v", tt.tags, tt.expected, got)
+		}
+	}
+}
+
+func TestFilterEntries(t *testing.T) {
+	entries := []BatchEntry{
+		{Source: "plan.txt", Line: 1, CIDR: "10.10.0.0/16", Tags: Tags{"env": "prod"}},
+		{Source: "plan.txt", Line: 2, CIDR: "10.20.0.0/16", Tags: Tags{"env": "staging"}},
+		{Source: "plan.txt", Line: 3, CIDR: "10.30.0.0/16"},
+	}
+
+	kept, err := filterEntries(entries, nil, "plan.txt")
+	if err != nil || len(kept) != 3 {
+		t.Errorf("expected no filtering without conditions, got %v (%v)", kept, err)
+	}
+
+	kept, err = filterEntries(entries, tagFilterList{{Key: "env", Value: "prod"}}, "plan.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(kept) != 1 || kept[0].CIDR != "10.10.0.0/16" {
+		t.Errorf("unexpected entries: %+v", kept)
+	}
+
+	_, err = filterEntries(entries, tagFilterList{{Key: "env", Value: "dev"}}, "plan.txt")
+	if err == nil || !strings.Contains(err.Error(), "no CIDRs in plan.txt match --tag env=dev") {
+		t.Errorf("expected a no-match error, got %v", err)
+	}
+}
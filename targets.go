@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scanner target list formats: one CIDR per line for nmap -iL, and one
+// comma separated line for masscan
+const (
+	FormatTargets = "targets"
+	FormatMasscan = "masscan"
+)
+
+// isTargetFormat reports whether the format writes a scanner target list
+// rather than a report
+func isTargetFormat(format string) bool {
+	return format == FormatTargets || format == FormatMasscan
+}
+
+// cidrList collects repeated --exclude flags; each takes a CIDR, an address
+// or a comma separated list of them
+type cidrList []string
+
+// String returns the collected values
+func (l *cidrList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set adds the CIDRs of a flag value
+func (l *cidrList) Set(value string) error {
+	for _, cidr := range strings.Split(value, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr != "" {
+			*l = append(*l, cidr)
+		}
+	}
+	return nil
+}
+
+// Exclude returns the fewest CIDRs that cover the addresses of each network
+// outside every excluded network, network by network in the order given. A
+// network partly covered by an exclusion is halved until each half is either
+// excluded or clear of all exclusions.
+func (c *CIDRCalculator) Exclude(networks, excluded []*NetworkInfo) ([]*NetworkInfo, error) {
+	var remaining []*NetworkInfo
+	deadline := c.startDeadline()
+	for _, network := range networks {
+		kept, err := c.exclude(network, excluded, deadline)
+		if err != nil {
+			return nil, err
+		}
+		remaining = append(remaining, kept...)
+	}
+	return remaining, nil
+}
+
+// exclude returns the parts of one network outside the excluded networks,
+// giving up at the deadline of the exclusion
+func (c *CIDRCalculator) exclude(network *NetworkInfo, excluded []*NetworkInfo, deadline time.Time) ([]*NetworkInfo, error) {
+	overlapped := false
+	for _, exclusion := range excluded {
+		if exclusion.IsIPv6() != network.IsIPv6() {
+			continue
+		}
+		if exclusion.Contains(network) {
+			return nil, nil
+		}
+		overlapped = overlapped || network.Contains(exclusion)
+	}
+	if !overlapped {
+		return []*NetworkInfo{network}, nil
+	}
+	if err := c.checkDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	halves, err := c.SplitNetworks(network, network.PrefixLength+1)
+	if err != nil {
+		return nil, err
+	}
+	var kept []*NetworkInfo
+	for i := range halves {
+		parts, err := c.exclude(&halves[i], excluded, deadline)
+		if err != nil {
+			return nil, err
+		}
+		kept = append(kept, parts...)
+	}
+	return kept, nil
+}
+
+// FormatTargets writes the networks as a scanner target list: one CIDR per
+// line, or one comma separated line for masscan
+func (f *OutputFormatter) FormatTargets(networks []*NetworkInfo, format string) string {
+	cidrs := make([]string, len(networks))
+	for i, network := range networks {
+		cidrs[i] = network.CIDR()
+	}
+	if len(cidrs) == 0 {
+		return ""
+	}
+	if format == FormatMasscan {
+		return strings.Join(cidrs, ",") + "\n"
+	}
+	return strings.Join(cidrs, "\n") + "\n"
+}
+
+// writeTargets writes the networks of the reports, or their subnets when a
+// split was requested, as a scanner target list with the --exclude networks
+// taken out
+func (c *CLIHandler) writeTargets(reports []NetworkReport, config *Config) error {
+	split := config.Split != 0 || config.Parts != 0 || config.Hosts != 0
+
+	var networks []*NetworkInfo
+	for _, report := range reports {
+		if !split {
+			networks = append(networks, report.Info)
+			continue
+		}
+		for _, subnet := range report.Subnets {
+			info, err := c.calculator.ParseCIDR(subnet.CIDR)
+			if err != nil {
+				return fmt.Errorf("failed to parse subnet %s: %v", subnet.CIDR, err)
+			}
+			networks = append(networks, info)
+		}
+	}
+
+	var excluded []*NetworkInfo
+	for _, cidr := range config.Exclude {
+		normalized, err := c.calculator.NormalizeCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("failed to parse --exclude %s: %v", cidr, err)
+		}
+		info, err := c.calculator.ParseCIDR(normalized)
+		if err != nil {
+			return fmt.Errorf("failed to parse --exclude %s: %v", cidr, err)
+		}
+		excluded = append(excluded, info)
+	}
+
+	targets, err := c.calculator.Exclude(networks, excluded)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("--exclude leaves no addresses to scan")
+	}
+	if len(excluded) > 0 {
+		c.notef("%s left after excluding %s", plural(len(targets), "target CIDR"), plural(len(excluded), "network"))
+	}
+	return c.writeOutput(c.formatter.FormatTargets(targets, config.OutputFormat()), config.OutputFile)
+}
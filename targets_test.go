@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIDRCalculator_Exclude(t *testing.T) {
+	calculator := NewCIDRCalculator()
+
+	testCases := []struct {
+		name     string
+		networks []string
+		excluded []string
+		expected []string
+	}{
+		{"no exclusions", []string{"10.0.0.0/24"}, nil, []string{"10.0.0.0/24"}},
+		{"disjoint", []string{"10.0.0.0/24"}, []string{"10.1.0.0/16"}, []string{"10.0.0.0/24"}},
+		{"covering", []string{"10.0.0.0/24"}, []string{"10.0.0.0/8"}, nil},
+		{"first address", []string{"10.0.0.0/30"}, []string{"10.0.0.0/32"}, []string{"10.0.0.1/32", "10.0.0.2/31"}},
+		{"middle half", []string{"10.0.0.0/24"}, []string{"10.0.0.64/26", "10.0.0.128/26"}, []string{"10.0.0.0/26", "10.0.0.192/26"}},
+		{"per network", []string{"10.0.0.0/25", "10.0.1.0/25"}, []string{"10.0.1.0/26"}, []string{"10.0.0.0/25", "10.0.1.64/26"}},
+		{"families kept apart", []string{"2001:db8::/48"}, []string{"10.0.0.0/8", "2001:db8::/50"}, []string{"2001:db8:0:4000::/50", "2001:db8:0:8000::/49"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var networks, excluded []*NetworkInfo
+			for _, cidr := range tt.networks {
+				networks = append(networks, mustParseCIDR(t, calculator, cidr))
+			}
+			for _, cidr := range tt.excluded {
+				excluded = append(excluded, mustParseCIDR(t, calculator, cidr))
+			}
+
+			remaining, err := calculator.Exclude(networks, excluded)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var cidrs []string
+			for _, network := range remaining {
+				cidrs = append(cidrs, network.CIDR())
+			}
+			if strings.Join(cidrs, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("expected %v, got %v", tt.expected, cidrs)
+			}
+		})
+	}
+}
+
+func TestOutputFormatter_FormatTargets(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	formatter := NewOutputFormatter()
+	networks := []*NetworkInfo{
+		mustParseCIDR(t, calculator, "10.0.0.0/24"),
+		mustParseCIDR(t, calculator, "192.168.1.5/32"),
+	}
+
+	if output := formatter.FormatTargets(networks, FormatTargets); output != "10.0.0.0/24\n192.168.1.5/32\n" {
+		t.Errorf("unexpected targets output %q", output)
+	}
+	if output := formatter.FormatTargets(networks, FormatMasscan); output != "10.0.0.0/24,192.168.1.5/32\n" {
+		t.Errorf("unexpected masscan output %q", output)
+	}
+}
+
+func TestCLIHandler_Targets(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"networks", []string{"--format", "targets", "10.0.0.0/24", "172.16.0.0/30"}, "10.0.0.0/24\n172.16.0.0/30\n"},
+		{"split with exclusions", []string{"--format", "masscan", "--split", "26", "10.0.0.0/24", "--exclude", "10.0.0.64/26,10.0.0.200"},
+			"10.0.0.0/26,10.0.0.128/26,10.0.0.192/29,10.0.0.201/32,10.0.0.202/31,10.0.0.204/30,10.0.0.208/28,10.0.0.224/27\n"},
+		{"repeated exclusions", []string{"--format", "targets", "10.0.0.0/30", "--exclude", "10.0.0.0", "--exclude", "10.0.0.3"}, "10.0.0.1/32\n10.0.0.2/32\n"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// A .txt output file does not warn about the target formats
+			output := filepath.Join(dir, tt.name+".txt")
+			args := append([]string{"cidr-calc", "--strict-ext", "-o", output}, tt.args...)
+			if err := handler.Run(args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			content, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatalf("failed to read output: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, content)
+			}
+		})
+	}
+
+	errorCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"10.0.0.0/24", "--exclude", "10.0.0.1"}, "--exclude applies to --format targets and masscan"},
+		{[]string{"--format", "targets", "10.0.0.0/24", "--exclude", "10.0.0.0/8"}, "--exclude leaves no addresses to scan"},
+		{[]string{"--format", "targets", "10.0.0.0/24", "--exclude", "bogus"}, "failed to parse --exclude bogus"},
+	}
+	for _, tt := range errorCases {
+		if err := handler.Run(append([]string{"cidr-calc"}, tt.args...)); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
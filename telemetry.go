@@ -0,0 +1,514 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Standard OpenTelemetry environment variables
+const (
+	otlpEndpointEnv = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	otlpHeadersEnv  = "OTEL_EXPORTER_OTLP_HEADERS"
+	otelServiceEnv  = "OTEL_SERVICE_NAME"
+)
+
+const (
+	defaultServiceName = "cidr-calc"
+	telemetryScope     = "github.com/marc-poljak/simple-cidr-calculator"
+	otlpTimeout        = 10 * time.Second
+
+	// telemetryExportInterval is how often a server exports what its
+	// requests recorded
+	telemetryExportInterval = 10 * time.Second
+)
+
+// OTLP enum values used in the JSON encoding
+const (
+	otlpSpanKindInternal      = 1
+	otlpStatusError           = 2
+	otlpTemporalityCumulative = 2
+)
+
+// calculationLatencyBounds are the histogram bucket bounds, in milliseconds,
+// for the time taken to calculate one CIDR
+var calculationLatencyBounds = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000}
+
+// Telemetry records traces and metrics of a run, or of every request of a
+// server, and exports them to an OpenTelemetry collector over OTLP/HTTP with
+// JSON encoding. It is safe for concurrent use. A nil Telemetry records
+// nothing, so callers need not check whether export is enabled.
+type Telemetry struct {
+	endpoint    string
+	headers     http.Header
+	serviceName string
+	client      *http.Client
+	now         func() time.Time
+
+	start   time.Time
+	traceID string
+
+	mu    sync.Mutex // guards the spans and metrics below
+	spans []otlpSpan
+
+	calculations map[string]int64 // by outcome
+	latencyCount int64
+	latencySum   float64
+	latencyBins  []int64
+	throughput   float64
+	hasBatch     bool
+}
+
+// NewTelemetry creates a recorder that exports to the OTLP/HTTP base endpoint,
+// for example http://localhost:4318, or returns nil when endpoint is empty
+func NewTelemetry(endpoint string, headers http.Header) *Telemetry {
+	if endpoint == "" {
+		return nil
+	}
+
+	serviceName := os.Getenv(otelServiceEnv)
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	return &Telemetry{
+		endpoint:     strings.TrimRight(endpoint, "/"),
+		headers:      headers,
+		serviceName:  serviceName,
+		client:       &http.Client{Timeout: otlpTimeout},
+		now:          time.Now,
+		start:        time.Now(),
+		traceID:      randomHex(16),
+		calculations: make(map[string]int64),
+		latencyBins:  make([]int64, len(calculationLatencyBounds)+1),
+	}
+}
+
+// ParseOTLPHeaders parses the comma separated key=value list of
+// OTEL_EXPORTER_OTLP_HEADERS, whose values may be percent-encoded
+func ParseOTLPHeaders(value string) (http.Header, error) {
+	headers := http.Header{}
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid %s entry %q (expected key=value)", otlpHeadersEnv, pair)
+		}
+		if unescaped, err := url.PathUnescape(strings.TrimSpace(val)); err == nil {
+			val = unescaped
+		}
+		headers.Add(key, strings.TrimSpace(val))
+	}
+	return headers, nil
+}
+
+// Span is an operation in the run's trace. Methods on a nil Span do nothing.
+type Span struct {
+	telemetry *Telemetry
+	span      otlpSpan
+}
+
+// StartSpan starts a span, as a child of parent unless parent is nil, in
+// which case it belongs to the trace of the run
+func (t *Telemetry) StartSpan(name string, parent *Span) *Span {
+	if t == nil {
+		return nil
+	}
+
+	span := otlpSpan{
+		TraceID:   t.traceID,
+		SpanID:    randomHex(8),
+		Name:      name,
+		Kind:      otlpSpanKindInternal,
+		StartTime: unixNano(t.now()),
+	}
+	if parent != nil {
+		span.TraceID = parent.span.TraceID
+		span.ParentSpanID = parent.span.SpanID
+	}
+	return &Span{telemetry: t, span: span}
+}
+
+// StartTrace starts the root span of a new trace, such as one per request of
+// a server
+func (t *Telemetry) StartTrace(name string) *Span {
+	span := t.StartSpan(name, nil)
+	if span != nil {
+		span.span.TraceID = randomHex(16)
+	}
+	return span
+}
+
+// SetAttribute records a string, integer or boolean attribute on the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.span.Attributes = append(s.span.Attributes, otlpAttribute(key, value))
+}
+
+// End finishes the span, marking it failed when err is not nil
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.span.EndTime = unixNano(s.telemetry.now())
+	if err != nil {
+		s.span.Status = &otlpStatus{Code: otlpStatusError, Message: err.Error()}
+	}
+	s.telemetry.mu.Lock()
+	s.telemetry.spans = append(s.telemetry.spans, s.span)
+	s.telemetry.mu.Unlock()
+}
+
+// RecordCalculation counts one calculated CIDR and its latency
+func (t *Telemetry) RecordCalculation(elapsed time.Duration, err error) {
+	if t == nil {
+		return
+	}
+
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calculations[outcome]++
+
+	millis := float64(elapsed) / float64(time.Millisecond)
+	t.latencyCount++
+	t.latencySum += millis
+	bin := len(calculationLatencyBounds)
+	for i, bound := range calculationLatencyBounds {
+		if millis <= bound {
+			bin = i
+			break
+		}
+	}
+	t.latencyBins[bin]++
+}
+
+// RecordBatch records the throughput of a batch of CIDRs
+func (t *Telemetry) RecordBatch(cidrs int, elapsed time.Duration) {
+	if t == nil || elapsed <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.throughput = float64(cidrs) / elapsed.Seconds()
+	t.hasBatch = true
+}
+
+// Export sends the spans ended since the last export to /v1/traces and the
+// metrics to /v1/metrics. The metrics are cumulative: every export reports
+// the totals since the recorder was created.
+func (t *Telemetry) Export() error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	metrics := t.metrics()
+	t.mu.Unlock()
+
+	resource := otlpResource{Attributes: []otlpKeyValue{otlpAttribute("service.name", t.serviceName)}}
+	scope := otlpScope{Name: telemetryScope, Version: buildVersion()}
+
+	if len(spans) > 0 {
+		traces := otlpTraces{ResourceSpans: []otlpResourceSpans{{
+			Resource:   resource,
+			ScopeSpans: []otlpScopeSpans{{Scope: scope, Spans: spans}},
+		}}}
+		if err := t.post("/v1/traces", traces); err != nil {
+			return err
+		}
+	}
+
+	if len(metrics) == 0 {
+		return nil
+	}
+	return t.post("/v1/metrics", otlpMetrics{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     resource,
+		ScopeMetrics: []otlpScopeMetrics{{Scope: scope, Metrics: metrics}},
+	}}})
+}
+
+// ExportEvery exports from the background every interval until the returned
+// stop function is called, which exports once more. Failed exports are passed
+// to report, so that a collector that is down never holds up the caller.
+func (t *Telemetry) ExportEvery(interval time.Duration, report func(error)) (stop func()) {
+	if t == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+			if err := t.Export(); err != nil {
+				report(err)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-exited
+			if err := t.Export(); err != nil {
+				report(err)
+			}
+		})
+	}
+}
+
+// metrics builds the OTLP metrics from the recorded calculations and batches.
+// The caller holds t.mu.
+func (t *Telemetry) metrics() []otlpMetric {
+	start, now := unixNano(t.start), unixNano(t.now())
+	var metrics []otlpMetric
+
+	if t.latencyCount > 0 {
+		bins := make([]string, len(t.latencyBins))
+		for i, count := range t.latencyBins {
+			bins[i] = strconv.FormatInt(count, 10)
+		}
+		metrics = append(metrics, otlpMetric{
+			Name:        "cidr_calc.calculation.duration",
+			Description: "Time taken to calculate one CIDR",
+			Unit:        "ms",
+			Histogram: &otlpHistogram{
+				AggregationTemporality: otlpTemporalityCumulative,
+				DataPoints: []otlpHistogramPoint{{
+					StartTime:      start,
+					Time:           now,
+					Count:          strconv.FormatInt(t.latencyCount, 10),
+					Sum:            t.latencySum,
+					BucketCounts:   bins,
+					ExplicitBounds: calculationLatencyBounds,
+				}},
+			},
+		})
+
+		var points []otlpNumberPoint
+		for _, outcome := range []string{"ok", "error"} {
+			if count, ok := t.calculations[outcome]; ok {
+				points = append(points, otlpNumberPoint{
+					Attributes: []otlpKeyValue{otlpAttribute("outcome", outcome)},
+					StartTime:  start,
+					Time:       now,
+					AsInt:      strconv.FormatInt(count, 10),
+				})
+			}
+		}
+		metrics = append(metrics, otlpMetric{
+			Name:        "cidr_calc.calculations",
+			Description: "CIDRs calculated, by outcome",
+			Unit:        "{cidr}",
+			Sum:         &otlpSum{AggregationTemporality: otlpTemporalityCumulative, IsMonotonic: true, DataPoints: points},
+		})
+	}
+
+	if t.hasBatch {
+		throughput := t.throughput
+		metrics = append(metrics, otlpMetric{
+			Name:        "cidr_calc.batch.throughput",
+			Description: "CIDRs calculated per second in batch mode",
+			Unit:        "{cidr}/s",
+			Gauge:       &otlpGauge{DataPoints: []otlpNumberPoint{{Time: now, AsDouble: &throughput}}},
+		})
+	}
+
+	return metrics
+}
+
+// post sends an OTLP JSON request to the collector
+func (t *Telemetry) post(path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode telemetry: %v", err)
+	}
+
+	target := t.endpoint + path
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid OTLP endpoint %s: %v", t.endpoint, err)
+	}
+	for name, values := range t.headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export telemetry to %s: %v", target, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to export telemetry to %s: %s", target, resp.Status)
+	}
+	return nil
+}
+
+// randomHex returns n random bytes as lowercase hex, the OTLP JSON encoding of trace and span IDs
+func randomHex(n int) string {
+	id := make([]byte, n)
+	if _, err := rand.Read(id); err != nil {
+		// An all-zero ID is invalid, so fall back to the clock
+		copy(id, strconv.FormatInt(time.Now().UnixNano(), 10))
+	}
+	return hex.EncodeToString(id)
+}
+
+// unixNano formats a time as the decimal string OTLP JSON uses for 64-bit integers
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// otlpAttribute converts a Go value into an OTLP key/value attribute
+func otlpAttribute(key string, value interface{}) otlpKeyValue {
+	switch v := value.(type) {
+	case int:
+		s := strconv.Itoa(v)
+		return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: &s}}
+	case bool:
+		return otlpKeyValue{Key: key, Value: otlpAnyValue{BoolValue: &v}}
+	default:
+		s := fmt.Sprint(v)
+		return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &s}}
+	}
+}
+
+// OTLP/HTTP JSON payloads, following opentelemetry-proto's JSON mapping
+
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID      string         `json:"traceId"`
+	SpanID       string         `json:"spanId"`
+	ParentSpanID string         `json:"parentSpanId,omitempty"`
+	Name         string         `json:"name"`
+	Kind         int            `json:"kind"`
+	StartTime    string         `json:"startTimeUnixNano"`
+	EndTime      string         `json:"endTimeUnixNano"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	Status       *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpMetrics struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Unit        string         `json:"unit,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+}
+
+type otlpHistogram struct {
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+	DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+}
+
+type otlpHistogramPoint struct {
+	StartTime      string    `json:"startTimeUnixNano"`
+	Time           string    `json:"timeUnixNano"`
+	Count          string    `json:"count"`
+	Sum            float64   `json:"sum"`
+	BucketCounts   []string  `json:"bucketCounts"`
+	ExplicitBounds []float64 `json:"explicitBounds"`
+}
+
+type otlpSum struct {
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+	DataPoints             []otlpNumberPoint `json:"dataPoints"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberPoint `json:"dataPoints"`
+}
+
+type otlpNumberPoint struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	StartTime  string         `json:"startTimeUnixNano,omitempty"`
+	Time       string         `json:"timeUnixNano"`
+	AsInt      string         `json:"asInt,omitempty"`
+	AsDouble   *float64       `json:"asDouble,omitempty"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseOTLPHeaders(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{value: "", want: map[string]string{}},
+		{value: "api-key=secret", want: map[string]string{"Api-Key": "secret"}},
+		{value: "Authorization=Basic%20dXNlcg==, x-tenant = prod", want: map[string]string{"Authorization": "Basic dXNlcg==", "X-Tenant": "prod"}},
+		{value: "no-value", wantErr: true},
+		{value: "=secret", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		headers, err := ParseOTLPHeaders(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseOTLPHeaders(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if len(headers) != len(tt.want) {
+			t.Errorf("ParseOTLPHeaders(%q) = %v, want %v", tt.value, headers, tt.want)
+		}
+		for name, value := range tt.want {
+			if got := headers.Get(name); got != value {
+				t.Errorf("ParseOTLPHeaders(%q) %s = %q, want %q", tt.value, name, got, value)
+			}
+		}
+	}
+}
+
+func TestTelemetry_Disabled(t *testing.T) {
+	telemetry := NewTelemetry("", nil)
+	if telemetry != nil {
+		t.Fatalf("expected no telemetry without an endpoint")
+	}
+
+	span := telemetry.StartSpan("calculate", nil)
+	span.SetAttribute("cidr", "10.0.0.0/24")
+	span.End(errors.New("ignored"))
+	telemetry.RecordCalculation(time.Millisecond, nil)
+	telemetry.RecordBatch(1, time.Millisecond)
+	if err := telemetry.Export(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTelemetry_Metrics(t *testing.T) {
+	telemetry := NewTelemetry("http://collector:4318", nil)
+	telemetry.RecordCalculation(300*time.Microsecond, nil)
+	telemetry.RecordCalculation(3*time.Millisecond, nil)
+	telemetry.RecordCalculation(2*time.Second, errors.New("failed"))
+	telemetry.RecordBatch(3, 500*time.Millisecond)
+
+	metrics := telemetry.metrics()
+	if len(metrics) != 3 {
+		t.Fatalf("expected 3 metrics, got %d", len(metrics))
+	}
+
+	histogram := metrics[0].Histogram.DataPoints[0]
+	if histogram.Count != "3" {
+		t.Errorf("expected 3 latency samples, got %s", histogram.Count)
+	}
+	expectedBins := "0,0,1,0,0,1,0,0,0,0,0,0,0,1"
+	if bins := strings.Join(histogram.BucketCounts, ","); bins != expectedBins {
+		t.Errorf("expected bucket counts %s, got %s", expectedBins, bins)
+	}
+
+	points := metrics[1].Sum.DataPoints
+	if len(points) != 2 || points[0].AsInt != "2" || points[1].AsInt != "1" {
+		t.Errorf("expected 2 ok and 1 error calculations, got %+v", points)
+	}
+
+	if throughput := *metrics[2].Gauge.DataPoints[0].AsDouble; throughput != 6 {
+		t.Errorf("expected throughput 6, got %v", throughput)
+	}
+}
+
+func TestTelemetry_ExportEvery(t *testing.T) {
+	collector := &otlpCollector{requests: make(map[string][]byte)}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	telemetry := NewTelemetry(server.URL, nil)
+	telemetry.RecordCalculation(time.Millisecond, nil)
+	stop := telemetry.ExportEvery(10*time.Millisecond, func(err error) { t.Errorf("unexpected error: %v", err) })
+	defer stop()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		collector.mu.Lock()
+		_, exported := collector.requests["/v1/metrics"]
+		collector.mu.Unlock()
+		if exported {
+			return
+		}
+	}
+	t.Error("expected the metrics to be exported in the background")
+}
+
+// otlpCollector records the OTLP requests it receives
+type otlpCollector struct {
+	mu       sync.Mutex
+	requests map[string][]byte
+	headers  http.Header
+}
+
+func (o *otlpCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.requests[r.URL.Path] = body
+	o.headers = r.Header.Clone()
+}
+
+func TestCLIHandler_OTLPExport(t *testing.T) {
+	collector := &otlpCollector{requests: make(map[string][]byte)}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	t.Setenv(otlpHeadersEnv, "api-key=secret")
+	t.Setenv(otelServiceEnv, "ipam-batch")
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "cidrs.txt")
+	if err := os.WriteFile(input, []byte("10.0.0.0/24\n192.168.0.0/16\n"), 0644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	output := filepath.Join(dir, "report.csv")
+	if err := handler.Run([]string{"cidr-calc", "--otlp-endpoint", server.URL + "/", "-f", input, "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := collector.headers.Get("Api-Key"); got != "secret" {
+		t.Errorf("expected api-key header, got %q", got)
+	}
+	if got := collector.headers.Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected JSON content type, got %q", got)
+	}
+
+	var traces otlpTraces
+	if err := json.Unmarshal(collector.requests["/v1/traces"], &traces); err != nil {
+		t.Fatalf("failed to decode traces: %v", err)
+	}
+	resource := traces.ResourceSpans[0].Resource.Attributes[0]
+	if resource.Key != "service.name" || *resource.Value.StringValue != "ipam-batch" {
+		t.Errorf("unexpected resource attribute %+v", resource)
+	}
+
+	spans := traces.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("expected 2 calculate spans and 1 batch span, got %d", len(spans))
+	}
+	batch := spans[2]
+	if batch.Name != "batch" || batch.ParentSpanID != "" {
+		t.Errorf("expected a root batch span last, got %+v", batch)
+	}
+	for _, span := range spans[:2] {
+		if span.Name != "calculate" || span.ParentSpanID != batch.SpanID || span.TraceID != batch.TraceID {
+			t.Errorf("expected calculate spans under the batch span, got %+v", span)
+		}
+	}
+	if len(batch.TraceID) != 32 || len(batch.SpanID) != 16 {
+		t.Errorf("expected hex trace and span IDs, got %s and %s", batch.TraceID, batch.SpanID)
+	}
+
+	var metrics otlpMetrics
+	if err := json.Unmarshal(collector.requests["/v1/metrics"], &metrics); err != nil {
+		t.Fatalf("failed to decode metrics: %v", err)
+	}
+	var names []string
+	for _, metric := range metrics.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		names = append(names, metric.Name)
+	}
+	expected := "cidr_calc.calculation.duration,cidr_calc.calculations,cidr_calc.batch.throughput"
+	if strings.Join(names, ",") != expected {
+		t.Errorf("expected metrics %s, got %v", expected, names)
+	}
+}
+
+func TestCLIHandler_OTLPExportFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "collector overloaded", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	handler := NewCLIHandler()
+	var stderr strings.Builder
+	handler.stderr = &stderr
+
+	output := filepath.Join(t.TempDir(), "report.txt")
+	err := handler.Run([]string{"cidr-calc", "--otlp-endpoint", server.URL, "-o", output, "10.0.0.0/24"})
+	if err != nil {
+		t.Fatalf("expected the calculation to succeed, got %v", err)
+	}
+	if !strings.Contains(stderr.String(), "Warning: failed to export telemetry") {
+		t.Errorf("expected an export warning, got %q", stderr.String())
+	}
+
+	err = handler.Run([]string{"cidr-calc", "--otlp-endpoint", server.URL, "-o", output, "10.0.0.0/33"})
+	if err == nil || !strings.Contains(err.Error(), "failed to parse CIDR") {
+		t.Errorf("expected the calculation error, got %v", err)
+	}
+}
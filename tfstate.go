@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// tf-check rule names
+const (
+	RuleUnplanned   = "unplanned"
+	RuleReserved    = "reserved"
+	RuleNotDeployed = "not-deployed"
+)
+
+// terraformCIDRAttributes are the resource attributes known to hold network CIDRs
+// (AWS VPCs and subnets, Azure virtual networks and subnets, GCP subnetworks)
+var terraformCIDRAttributes = []string{"cidr_block", "address_space", "address_prefix", "address_prefixes", "ip_cidr_range"}
+
+// TerraformCIDR is a CIDR found on a managed resource in a Terraform state file
+type TerraformCIDR struct {
+	Address string
+	CIDR    string
+}
+
+// terraformState is the subset of the Terraform state format (version 4) read by tf-check
+type terraformState struct {
+	Version   int `json:"version"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   interface{}            `json:"index_key"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// ReadTerraformState extracts the CIDRs of managed resources from a Terraform state file
+func ReadTerraformState(filename string) ([]TerraformCIDR, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %v", err)
+	}
+
+	return parseTerraformState(data)
+}
+
+// parseTerraformState extracts CIDRs from the JSON contents of a state file
+func parseTerraformState(data []byte) ([]TerraformCIDR, error) {
+	var state terraformState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %v", err)
+	}
+	if state.Version != 4 {
+		return nil, fmt.Errorf("unsupported state file version %d (expected 4)", state.Version)
+	}
+
+	var cidrs []TerraformCIDR
+	for _, resource := range state.Resources {
+		if resource.Mode != "managed" {
+			continue
+		}
+
+		for _, instance := range resource.Instances {
+			address := terraformAddress(resource.Module, resource.Type, resource.Name, instance.IndexKey)
+
+			for _, attribute := range terraformCIDRAttributes {
+				for _, cidr := range terraformStrings(instance.Attributes[attribute]) {
+					cidrs = append(cidrs, TerraformCIDR{Address: address, CIDR: cidr})
+				}
+			}
+		}
+	}
+
+	return cidrs, nil
+}
+
+// terraformAddress builds a resource address such as module.net.aws_subnet.app["a"]
+func terraformAddress(module, resourceType, name string, indexKey interface{}) string {
+	address := resourceType + "." + name
+	if module != "" {
+		address = module + "." + address
+	}
+
+	switch key := indexKey.(type) {
+	case string:
+		address += fmt.Sprintf("[%q]", key)
+	case float64:
+		address += fmt.Sprintf("[%d]", int(key))
+	}
+
+	return address
+}
+
+// terraformStrings returns the non-empty strings held by a string or list attribute
+func terraformStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// StateChecker compares deployed CIDRs against an approved plan and reserved space
+type StateChecker struct {
+	calculator *CIDRCalculator
+}
+
+// NewStateChecker creates a new state checker
+func NewStateChecker() *StateChecker {
+	return &StateChecker{calculator: NewCIDRCalculator()}
+}
+
+// Check reports deployed CIDRs missing from the plan, deployed CIDRs overlapping
+// reserved space, and planned CIDRs that are not deployed
+func (s *StateChecker) Check(stateFile string, deployed []TerraformCIDR, plan, reserved []BatchEntry) []Finding {
+	var findings []Finding
+
+	planned := make(map[string]BatchEntry)
+	for _, entry := range plan {
+		info, err := s.calculator.ParseCIDR(entry.CIDR)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Rule:     RuleInvalidCIDR,
+				Message:  err.Error(),
+				File:     entry.Source,
+				Line:     entry.Line,
+				CIDR:     entry.CIDR,
+			})
+			continue
+		}
+		planned[info.CIDR()] = entry
+	}
+
+	var reservedNetworks []planNetwork
+	for _, entry := range reserved {
+		info, err := s.calculator.ParseCIDR(entry.CIDR)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Rule:     RuleInvalidCIDR,
+				Message:  err.Error(),
+				File:     entry.Source,
+				Line:     entry.Line,
+				CIDR:     entry.CIDR,
+			})
+			continue
+		}
+		reservedNetworks = append(reservedNetworks, planNetwork{entry: entry, info: info})
+	}
+
+	seen := make(map[string]bool)
+	for _, resource := range deployed {
+		info, err := s.calculator.ParseCIDR(resource.CIDR)
+		if err != nil {
+			// Unparseable values are outside the plan's scope
+			continue
+		}
+		seen[info.CIDR()] = true
+
+		if _, ok := planned[info.CIDR()]; !ok {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Rule:     RuleUnplanned,
+				Message:  fmt.Sprintf("%s (%s) is not in the approved plan", info.CIDR(), resource.Address),
+				File:     stateFile,
+				CIDR:     resource.CIDR,
+			})
+		}
+
+		for _, other := range reservedNetworks {
+			if info.Overlaps(other.info) {
+				findings = append(findings, Finding{
+					Severity: SeverityError,
+					Rule:     RuleReserved,
+					Message:  fmt.Sprintf("%s (%s) overlaps %s", info.CIDR(), resource.Address, describeReserved(other)),
+					File:     stateFile,
+					CIDR:     resource.CIDR,
+				})
+			}
+		}
+	}
+
+	for _, entry := range plan {
+		info, err := s.calculator.ParseCIDR(entry.CIDR)
+		if err != nil || seen[info.CIDR()] {
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityNotice,
+			Rule:     RuleNotDeployed,
+			Message:  fmt.Sprintf("%s is planned but not deployed", info.CIDR()),
+			File:     entry.Source,
+			Line:     entry.Line,
+			CIDR:     entry.CIDR,
+		})
+	}
+
+	return findings
+}
+
+// runTFCheck implements the tf-check subcommand
+func (c *CLIHandler) runTFCheck(args []string) error {
+	flagSet := flag.NewFlagSet("tf-check", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var planSource, reservedSource, format, outputFile string
+	var emit stringList
+	flagSet.StringVar(&planSource, "plan", "", "Approved plan file, URL or - for stdin")
+	flagSet.StringVar(&reservedSource, "reserved", "", "File of reserved CIDRs that must not be deployed")
+	flagSet.StringVar(&format, "format", FormatText, "Findings format: text, gh-annotations")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	flagSet.Var(&emit, "emit", emitFlagUsage)
+
+	// Accept the state file anywhere among the flags
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	emitTargets, err := parseEmitTargets(emit)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) > 1 {
+		return fmt.Errorf("tf-check accepts a single state file, got %d", len(positional))
+	}
+
+	var stateFile string
+	if len(positional) == 1 {
+		stateFile = positional[0]
+	}
+	if stateFile == "" {
+		return fmt.Errorf("tf-check requires a Terraform state file")
+	}
+	if planSource == "" {
+		return fmt.Errorf("tf-check requires --plan")
+	}
+
+	deployed, err := ReadTerraformState(stateFile)
+	if err != nil {
+		return err
+	}
+
+	reader := NewBatchReader(defaultFetchTimeout, nil)
+
+	plan, err := reader.Read(planSource)
+	if err != nil {
+		return err
+	}
+
+	var reserved []BatchEntry
+	if reservedSource != "" {
+		if reserved, err = reader.Read(reservedSource); err != nil {
+			return err
+		}
+	}
+
+	findings := NewStateChecker().Check(stateFile, deployed, plan, reserved)
+
+	if err := c.emitFindings(emitTargets, findings); err != nil {
+		return err
+	}
+
+	content, err := c.formatter.RenderFindings(format, findings)
+	if err != nil {
+		return err
+	}
+
+	// A clean check produces no GitHub annotations at all
+	if content != "" {
+		if err := c.writeOutput(content, outputFile); err != nil {
+			return err
+		}
+	}
+
+	if errors := countFindings(findings, SeverityError); errors > 0 {
+		return fmt.Errorf("tf-check found %s", plural(errors, "error"))
+	}
+
+	return nil
+}
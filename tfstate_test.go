@@ -0,0 +1,166 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testTerraformState = `{
+  "version": 4,
+  "terraform_version": "1.6.0",
+  "resources": [
+    {
+      "mode": "managed", "type": "aws_vpc", "name": "main",
+      "instances": [{"attributes": {"cidr_block": "10.0.0.0/16", "ipv6_cidr_block": "2001:db8::/56"}}]
+    },
+    {
+      "mode": "managed", "type": "aws_subnet", "name": "app",
+      "instances": [
+        {"index_key": 0, "attributes": {"cidr_block": "10.0.1.0/24"}},
+        {"index_key": 1, "attributes": {"cidr_block": "10.0.2.0/24"}}
+      ]
+    },
+    {
+      "module": "module.vpn", "mode": "managed", "type": "azurerm_subnet", "name": "edge",
+      "instances": [{"index_key": "west", "attributes": {"address_prefixes": ["192.168.0.0/24"]}}]
+    },
+    {
+      "mode": "data", "type": "aws_vpc", "name": "shared",
+      "instances": [{"attributes": {"cidr_block": "172.31.0.0/16"}}]
+    }
+  ]
+}`
+
+func TestParseTerraformState(t *testing.T) {
+	cidrs, err := parseTerraformState([]byte(testTerraformState))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []TerraformCIDR{
+		{Address: "aws_vpc.main", CIDR: "10.0.0.0/16"},
+		{Address: "aws_subnet.app[0]", CIDR: "10.0.1.0/24"},
+		{Address: "aws_subnet.app[1]", CIDR: "10.0.2.0/24"},
+		{Address: `module.vpn.azurerm_subnet.edge["west"]`, CIDR: "192.168.0.0/24"},
+	}
+
+	if len(cidrs) != len(expected) {
+		t.Fatalf("expected %d CIDRs, got %d: %+v", len(expected), len(cidrs), cidrs)
+	}
+	for i, exp := range expected {
+		if cidrs[i] != exp {
+			t.Errorf("CIDR %d: expected %+v, got %+v", i, exp, cidrs[i])
+		}
+	}
+
+	errorCases := map[string]string{
+		"invalid JSON":        "{",
+		"unsupported version": `{"version": 3}`,
+	}
+	for name, data := range errorCases {
+		if _, err := parseTerraformState([]byte(data)); err == nil {
+			t.Errorf("%s: expected error but got none", name)
+		}
+	}
+}
+
+func TestStateChecker_Check(t *testing.T) {
+	deployed := []TerraformCIDR{
+		{Address: "aws_vpc.main", CIDR: "10.0.0.0/16"},
+		{Address: "aws_subnet.app[0]", CIDR: "10.0.1.0/24"},
+		{Address: "aws_subnet.scratch", CIDR: "10.9.0.0/24"},
+		{Address: "aws_subnet.edge", CIDR: "192.168.0.0/24"},
+		{Address: "aws_vpc.main", CIDR: "2001:db8::/56"},
+	}
+	plan := []BatchEntry{
+		{Source: "plan.txt", Line: 1, CIDR: "10.0.0.0/16"},
+		{Source: "plan.txt", Line: 2, CIDR: "10.0.1.0/24"},
+		{Source: "plan.txt", Line: 3, CIDR: "192.168.0.0/24"},
+		{Source: "plan.txt", Line: 4, CIDR: "10.0.3.0/24"},
+		{Source: "plan.txt", Line: 5, CIDR: "2001:db8::/56"},
+	}
+	reserved := []BatchEntry{{Source: "reserved.txt", Line: 1, CIDR: "192.168.0.0/16"}}
+
+	findings := NewStateChecker().Check("terraform.tfstate", deployed, plan, reserved)
+
+	expected := []struct {
+		rule     string
+		severity FindingSeverity
+		location string
+	}{
+		{RuleUnplanned, SeverityError, "terraform.tfstate"},
+		{RuleReserved, SeverityError, "terraform.tfstate"},
+		{RuleNotDeployed, SeverityNotice, "plan.txt:4"},
+	}
+
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for i, exp := range expected {
+		got := findings[i]
+		if got.Rule != exp.rule || got.Severity != exp.severity || got.Location() != exp.location {
+			t.Errorf("finding %d: expected %s/%s at %s, got %+v", i, exp.severity, exp.rule, exp.location, got)
+		}
+	}
+	if !strings.Contains(findings[0].Message, "aws_subnet.scratch") {
+		t.Errorf("expected unplanned finding to name the resource, got %q", findings[0].Message)
+	}
+}
+
+func TestCLIHandler_TFCheck(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	state := write("terraform.tfstate", testTerraformState)
+	matching := write("plan.txt", "10.0.0.0/16\n10.0.1.0/24\n10.0.2.0/24\n192.168.0.0/24\n")
+	drifted := write("drifted.txt", "10.0.0.0/16\n10.0.1.0/24\n")
+	reserved := write("reserved.txt", "192.168.0.0/16\n")
+	output := filepath.Join(dir, "report.txt")
+
+	handler := NewCLIHandler()
+
+	if err := handler.Run([]string{"cidr-calc", "tf-check", state, "--plan", matching, "-o", output}); err != nil {
+		t.Fatalf("unexpected error for matching plan: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	if !strings.Contains(string(content), "No problems found") {
+		t.Errorf("expected clean report, got:\n%s", content)
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectError string
+	}{
+		{name: "drift", args: []string{state, "--plan", drifted}, expectError: "tf-check found 2 errors"},
+		{name: "reserved space", args: []string{"--plan", matching, "--reserved", reserved, state}, expectError: "tf-check found 1 error"},
+		{name: "missing plan", args: []string{state}, expectError: "requires --plan"},
+		{name: "missing state", args: []string{"--plan", matching}, expectError: "requires a Terraform state file"},
+		{name: "two state files", args: []string{state, state, "--plan", matching}, expectError: "single state file"},
+		{name: "unreadable state", args: []string{filepath.Join(dir, "missing.tfstate"), "--plan", matching}, expectError: "failed to read state file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"cidr-calc", "tf-check", "-o", output}, tt.args...)
+			err := handler.Run(args)
+			if err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// maxTerraformNewbits is how far Terraform extends a prefix in one call
+const maxTerraformNewbits = 32
+
+// TerraformSubnet is a subnet with the arguments of the Terraform
+// cidrsubnet call that returns it
+type TerraformSubnet struct {
+	Newbits int
+	Netnum  *big.Int
+	Subnet  *NetworkInfo
+}
+
+// Expression returns the cidrsubnet call for the subnet of base
+func (s TerraformSubnet) Expression(base *NetworkInfo) string {
+	return fmt.Sprintf("cidrsubnet(%q, %d, %s)", base.CIDR(), s.Newbits, s.Netnum)
+}
+
+// terraformNetwork builds the network at an address given as an integer
+func terraformNetwork(base *NetworkInfo, start *big.Int, prefix int) (*NetworkInfo, error) {
+	address := make(net.IP, len(addressBytes(base.NetworkID)))
+	start.FillBytes(address)
+	return NewCIDRCalculator().ParseCIDR(fmt.Sprintf("%s/%d", address, prefix))
+}
+
+// checkTerraformNewbits applies the limits Terraform puts on newbits
+func checkTerraformNewbits(base *NetworkInfo, newbits int) error {
+	if newbits < 1 {
+		return fmt.Errorf("must extend prefix by at least one bit")
+	}
+	if newbits > maxTerraformNewbits {
+		return fmt.Errorf("may not extend prefix by more than %d bits", maxTerraformNewbits)
+	}
+	if length := base.PrefixLength + newbits; length > base.MaxPrefix() {
+		protocol := "IPv4"
+		if base.IsIPv6() {
+			protocol = "IPv6"
+		}
+		return fmt.Errorf("would extend prefix to %d bits, which is too long for an %s address", length, protocol)
+	}
+	return nil
+}
+
+// TerraformCIDRSubnets returns the subnets of Terraform's
+// cidrsubnets(base, newbits...): each one is the next aligned block after the
+// previous one, so a larger block may skip space to stay aligned. The steps
+// and error messages follow Terraform's implementation.
+func (c *CIDRCalculator) TerraformCIDRSubnets(base *NetworkInfo, newbits []int) ([]TerraformSubnet, error) {
+	bits := base.MaxPrefix()
+	space := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	baseStart := addressInt(base.NetworkID)
+	baseEnd := new(big.Int).Add(baseStart, new(big.Int).Lsh(big.NewInt(1), uint(bits-base.PrefixLength)))
+
+	blockMask := func(prefix int) *big.Int {
+		return new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(bits-prefix)), big.NewInt(1))
+	}
+
+	// Start from the block just before the base at the first length; like
+	// Terraform, wrap around below the zero address
+	var current *big.Int
+	var currentPrefix int
+	if len(newbits) > 0 {
+		if err := checkTerraformNewbits(base, newbits[0]); err != nil {
+			return nil, fmt.Errorf("newbits %d (argument 1): %v", newbits[0], err)
+		}
+		currentPrefix = base.PrefixLength + newbits[0]
+		current = new(big.Int).Sub(baseStart, big.NewInt(1))
+		current.Mod(current, space)
+		current.AndNot(current, blockMask(currentPrefix))
+	}
+
+	subnets := make([]TerraformSubnet, 0, len(newbits))
+	for i, extension := range newbits {
+		if err := checkTerraformNewbits(base, extension); err != nil {
+			return nil, fmt.Errorf("newbits %d (argument %d): %v", extension, i+1, err)
+		}
+		prefix := base.PrefixLength + extension
+
+		// The next block starts after the block of this length that holds
+		// the last address of the current one
+		last := new(big.Int).Or(current, blockMask(currentPrefix))
+		next := new(big.Int).Or(last, blockMask(prefix))
+		next.Add(next, big.NewInt(1))
+		if next.Cmp(space) == 0 || next.Cmp(baseStart) < 0 || next.Cmp(baseEnd) >= 0 {
+			previous, err := terraformNetwork(base, current, currentPrefix)
+			if err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("newbits %d (argument %d): not enough remaining address space for a subnet with a prefix of %d bits after %s", extension, i+1, prefix, previous.CIDR())
+		}
+
+		subnet, err := terraformNetwork(base, next, prefix)
+		if err != nil {
+			return nil, err
+		}
+		netnum := new(big.Int).Rsh(new(big.Int).Sub(next, baseStart), uint(bits-prefix))
+		subnets = append(subnets, TerraformSubnet{Newbits: extension, Netnum: netnum, Subnet: subnet})
+		current, currentPrefix = next, prefix
+	}
+	return subnets, nil
+}
+
+// TerraformCIDRSubnet returns the newbits and netnum arguments for which
+// Terraform's cidrsubnet(base, newbits, netnum) returns the subnet
+func (c *CIDRCalculator) TerraformCIDRSubnet(base, subnet *NetworkInfo) (TerraformSubnet, error) {
+	if base.IsIPv6() != subnet.IsIPv6() || !base.Contains(subnet) {
+		return TerraformSubnet{}, fmt.Errorf("%s is not a subnet of %s", subnet.CIDR(), base.CIDR())
+	}
+	newbits := subnet.PrefixLength - base.PrefixLength
+	if err := checkTerraformNewbits(base, newbits); err != nil {
+		return TerraformSubnet{}, fmt.Errorf("%s: %v", subnet.CIDR(), err)
+	}
+
+	offset := new(big.Int).Sub(addressInt(subnet.NetworkID), addressInt(base.NetworkID))
+	netnum := offset.Rsh(offset, uint(subnet.MaxPrefix()-subnet.PrefixLength))
+	return TerraformSubnet{Newbits: newbits, Netnum: netnum, Subnet: subnet}, nil
+}
+
+// TerraformSubnetsCall returns the cidrsubnets call that yields exactly the
+// subnets, in order, or "" when no single call does
+func (c *CIDRCalculator) TerraformSubnetsCall(base *NetworkInfo, subnets []TerraformSubnet) string {
+	newbits := make([]int, len(subnets))
+	for i, subnet := range subnets {
+		newbits[i] = subnet.Newbits
+	}
+	computed, err := c.TerraformCIDRSubnets(base, newbits)
+	if err != nil {
+		return ""
+	}
+	for i := range computed {
+		if computed[i].Subnet.CIDR() != subnets[i].Subnet.CIDR() {
+			return ""
+		}
+	}
+	return terraformSubnetsExpression(base, newbits)
+}
+
+// terraformSubnetsExpression writes a cidrsubnets call
+func terraformSubnetsExpression(base *NetworkInfo, newbits []int) string {
+	arguments := []string{strconv.Quote(base.CIDR())}
+	for _, extension := range newbits {
+		arguments = append(arguments, strconv.Itoa(extension))
+	}
+	return "cidrsubnets(" + strings.Join(arguments, ", ") + ")"
+}
+
+// FormatTerraformSubnets renders the cidrsubnet arguments of each subnet and,
+// when one call yields them all, the cidrsubnets call with its result as
+// terraform console prints it
+func (f *OutputFormatter) FormatTerraformSubnets(base *NetworkInfo, subnets []TerraformSubnet, call string) string {
+	var output strings.Builder
+
+	expressions := make([]string, len(subnets))
+	width := len("Expression")
+	for i, subnet := range subnets {
+		expressions[i] = subnet.Expression(base)
+		if len(expressions[i]) > width {
+			width = len(expressions[i])
+		}
+	}
+
+	output.WriteString(fmt.Sprintf("Terraform Subnets of %s:\n", base.CIDR()))
+	output.WriteString(fmt.Sprintf("  %-8s %-10s %-*s  %s\n", "Newbits", "Netnum", width, "Expression", "Prefix"))
+	for i, subnet := range subnets {
+		output.WriteString(fmt.Sprintf("  %-8d %-10s %-*s  %s\n", subnet.Newbits, subnet.Netnum, width, expressions[i], subnet.Subnet.CIDR()))
+	}
+
+	output.WriteString("\n")
+	if call == "" {
+		output.WriteString("No single cidrsubnets call yields these subnets in this order; use the cidrsubnet calls above.\n")
+		return output.String()
+	}
+	output.WriteString("> " + call + "\n")
+	output.WriteString("tolist([\n")
+	for _, subnet := range subnets {
+		output.WriteString(fmt.Sprintf("  %q,\n", subnet.Subnet.CIDR()))
+	}
+	output.WriteString("])\n")
+
+	return output.String()
+}
+
+// jsonTerraformSubnets is the cidrsubnet arguments of the subnets for scripts
+type jsonTerraformSubnets struct {
+	Base        string                `json:"base"`
+	CIDRSubnets string                `json:"cidrsubnets,omitempty"`
+	Subnets     []jsonTerraformSubnet `json:"subnets"`
+}
+
+// jsonTerraformSubnet is one subnet and its cidrsubnet call
+type jsonTerraformSubnet struct {
+	CIDR       string `json:"cidr"`
+	Newbits    int    `json:"newbits"`
+	Netnum     string `json:"netnum"` // a string, as IPv6 subnet numbers outgrow JSON numbers
+	Expression string `json:"expression"`
+}
+
+// FormatTerraformSubnetsAsJSON renders the subnets and their cidrsubnet calls as JSON
+func (f *OutputFormatter) FormatTerraformSubnetsAsJSON(base *NetworkInfo, subnets []TerraformSubnet, call string) (string, error) {
+	document := jsonTerraformSubnets{Base: base.CIDR(), CIDRSubnets: call, Subnets: []jsonTerraformSubnet{}}
+	for _, subnet := range subnets {
+		document.Subnets = append(document.Subnets, jsonTerraformSubnet{
+			CIDR:       subnet.Subnet.CIDR(),
+			Newbits:    subnet.Newbits,
+			Netnum:     subnet.Netnum.String(),
+			Expression: subnet.Expression(base),
+		})
+	}
+
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JSON: %v", err)
+	}
+	return string(content) + "\n", nil
+}
+
+// runTerraformSubnets implements the tf-subnets subcommand
+func (c *CLIHandler) runTerraformSubnets(args []string) error {
+	flagSet := flag.NewFlagSet("tf-subnets", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var format, outputFile string
+	flagSet.StringVar(&format, "format", FormatText, "Output format: text or json")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+
+	// Accept the arguments anywhere among the flags
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+
+	if len(positional) < 2 {
+		return fmt.Errorf("tf-subnets requires a base CIDR followed by newbits values or subnet CIDRs")
+	}
+	if format != FormatText && format != FormatJSON {
+		return fmt.Errorf("tf-subnets supports %s and %s output, not %s", FormatText, FormatJSON, format)
+	}
+
+	base, err := c.calculator.ParseCIDR(positional[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse CIDR %s: %v", positional[0], err)
+	}
+
+	// Numbers are cidrsubnets newbits; CIDRs are subnets to express as calls
+	var subnets []TerraformSubnet
+	var call string
+	if !strings.Contains(positional[1], "/") {
+		newbits := make([]int, 0, len(positional)-1)
+		for _, value := range positional[1:] {
+			extension, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid newbits %q: give either numbers or subnet CIDRs after the base", value)
+			}
+			newbits = append(newbits, extension)
+		}
+		if subnets, err = c.calculator.TerraformCIDRSubnets(base, newbits); err != nil {
+			return err
+		}
+		call = terraformSubnetsExpression(base, newbits)
+	} else {
+		for _, cidr := range positional[1:] {
+			info, err := c.calculator.ParseCIDR(cidr)
+			if err != nil {
+				return fmt.Errorf("failed to parse CIDR %s: %v", cidr, err)
+			}
+			subnet, err := c.calculator.TerraformCIDRSubnet(base, info)
+			if err != nil {
+				return err
+			}
+			subnets = append(subnets, subnet)
+		}
+		call = c.calculator.TerraformSubnetsCall(base, subnets)
+	}
+
+	var content string
+	if format == FormatJSON {
+		if content, err = c.formatter.FormatTerraformSubnetsAsJSON(base, subnets, call); err != nil {
+			return err
+		}
+	} else {
+		content = c.formatter.FormatTerraformSubnets(base, subnets, call)
+	}
+	return c.writeOutput(content, outputFile)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIDRCalculator_TerraformCIDRSubnets(t *testing.T) {
+	calculator := NewCIDRCalculator()
+
+	// The examples of the Terraform documentation
+	tests := []struct {
+		base     string
+		newbits  []int
+		expected []string
+	}{
+		{"10.1.0.0/16", []int{4, 4, 8, 4}, []string{"10.1.0.0/20", "10.1.16.0/20", "10.1.32.0/24", "10.1.48.0/20"}},
+		{"fd00:fd12:3456:7890::/56", []int{16, 16, 16, 32}, []string{"fd00:fd12:3456:7800::/72", "fd00:fd12:3456:7800:100::/72", "fd00:fd12:3456:7800:200::/72", "fd00:fd12:3456:7800:300::/88"}},
+	}
+	for _, tt := range tests {
+		subnets, err := calculator.TerraformCIDRSubnets(mustParseCIDR(t, calculator, tt.base), tt.newbits)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.base, err)
+		}
+		var got []string
+		for _, subnet := range subnets {
+			got = append(got, subnet.Subnet.CIDR())
+		}
+		if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("%s %v: expected %v, got %v", tt.base, tt.newbits, tt.expected, got)
+		}
+	}
+
+	base := mustParseCIDR(t, calculator, "10.1.0.0/16")
+	subnets, _ := calculator.TerraformCIDRSubnets(base, []int{4, 4, 8, 4})
+	if subnets[3].Netnum.String() != "3" || subnets[3].Expression(base) != `cidrsubnet("10.1.0.0/16", 4, 3)` {
+		t.Errorf("unexpected cidrsubnet arguments %+v", subnets[3])
+	}
+
+	errorCases := []struct {
+		base    string
+		newbits []int
+		err     string
+	}{
+		{"10.0.0.0/24", []int{1, 1, 1}, "newbits 1 (argument 3): not enough remaining address space for a subnet with a prefix of 25 bits after 10.0.0.128/25"},
+		{"10.0.0.0/24", []int{0}, "must extend prefix by at least one bit"},
+		{"10.0.0.0/24", []int{9}, "would extend prefix to 33 bits, which is too long for an IPv4 address"},
+		{"2001:db8::/32", []int{33}, "may not extend prefix by more than 32 bits"},
+	}
+	for _, tt := range errorCases {
+		_, err := calculator.TerraformCIDRSubnets(mustParseCIDR(t, calculator, tt.base), tt.newbits)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s %v: expected error %q, got %v", tt.base, tt.newbits, tt.err, err)
+		}
+	}
+}
+
+func TestCIDRCalculator_TerraformCIDRSubnet(t *testing.T) {
+	calculator := NewCIDRCalculator()
+	base := mustParseCIDR(t, calculator, "10.1.0.0/16")
+
+	subnet, err := calculator.TerraformCIDRSubnet(base, mustParseCIDR(t, calculator, "10.1.48.0/20"))
+	if err != nil || subnet.Newbits != 4 || subnet.Netnum.String() != "3" {
+		t.Errorf("expected newbits 4 and netnum 3, got %+v, %v", subnet, err)
+	}
+	for _, cidr := range []string{"10.2.0.0/24", "10.0.0.0/8", "10.1.0.0/16"} {
+		if _, err := calculator.TerraformCIDRSubnet(base, mustParseCIDR(t, calculator, cidr)); err == nil {
+			t.Errorf("%s: expected an error", cidr)
+		}
+	}
+
+	// Only subnets in cidrsubnets order make a single call
+	var ordered, swapped []TerraformSubnet
+	for _, cidr := range []string{"10.1.0.0/20", "10.1.16.0/20", "10.1.32.0/24"} {
+		subnet, _ := calculator.TerraformCIDRSubnet(base, mustParseCIDR(t, calculator, cidr))
+		ordered = append(ordered, subnet)
+		swapped = append([]TerraformSubnet{subnet}, swapped...)
+	}
+	if call := calculator.TerraformSubnetsCall(base, ordered); call != `cidrsubnets("10.1.0.0/16", 4, 4, 8)` {
+		t.Errorf("unexpected call %q", call)
+	}
+	if call := calculator.TerraformSubnetsCall(base, swapped); call != "" {
+		t.Errorf("expected no single call for %v, got %q", swapped, call)
+	}
+}
+
+func TestCLIHandler_TerraformSubnets(t *testing.T) {
+	handler := NewCLIHandler()
+	output := filepath.Join(t.TempDir(), "subnets.txt")
+
+	if err := handler.Run([]string{"cidr-calc", "tf-subnets", "10.1.0.0/16", "4", "4", "8", "4", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	expected := `> cidrsubnets("10.1.0.0/16", 4, 4, 8, 4)
+tolist([
+  "10.1.0.0/20",
+  "10.1.16.0/20",
+  "10.1.32.0/24",
+  "10.1.48.0/20",
+])
+`
+	if !strings.HasSuffix(string(content), expected) || !strings.Contains(string(content), `cidrsubnet("10.1.0.0/16", 8, 32)  10.1.32.0/24`) {
+		t.Errorf("unexpected output:\n%s", content)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "tf-subnets", "--format", "json", "10.1.0.0/16", "10.1.32.0/24", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(output)
+	if !strings.Contains(string(content), `"netnum": "32"`) || strings.Contains(string(content), `"cidrsubnets"`) {
+		t.Errorf("unexpected JSON:\n%s", content)
+	}
+
+	for _, args := range [][]string{
+		{"tf-subnets", "10.1.0.0/16"},
+		{"tf-subnets", "10.1.0.0/16", "4", "10.1.0.0/20"},
+		{"tf-subnets", "10.1.0.0/16", "4", "--format", "csv"},
+		{"tf-subnets", "10.1.0.0/16", "20"},
+	} {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+)
+
+// HTML report themes. Light is the built-in stylesheet; auto is light, and
+// dark when the reader's system prefers dark colors.
+const (
+	ThemeLight        = "light"
+	ThemeDark         = "dark"
+	ThemeHighContrast = "high-contrast"
+	ThemeNeutral      = "neutral"
+	ThemeAuto         = "auto"
+)
+
+// htmlPalette is the set of colors a theme gives the HTML report
+type htmlPalette struct {
+	Scheme     string // color-scheme of the page, for form controls and scrollbars
+	Page       string
+	Surface    string // the report card, tables and lists
+	Text       string
+	Muted      string
+	Border     string
+	Shade      string // table headings and hovered rows
+	Accent     string // section headings, subnet CIDRs and buttons
+	AccentText string // text on accent backgrounds
+	Secondary  string // network headings and the bit boundary
+	HostBits   string
+	Header     string // background of the page header
+	HeaderText string
+	Warning    [3]string // background, border and text of notes
+	Special    [3]string
+	ScreenOnly bool // printed reports keep the light colors
+}
+
+// htmlPalettes are the themes that restyle the report; light has none
+var htmlPalettes = map[string]htmlPalette{
+	ThemeDark: {
+		Scheme: "dark", Page: "#121212", Surface: "#1e1e1e", Text: "#e0e0e0", Muted: "#a0a0a0",
+		Border: "#333", Shade: "#2a2a2a", Accent: "#8c9eff", AccentText: "#121212", Secondary: "#b39ddb",
+		HostBits: "#ffb74d", Header: "linear-gradient(135deg, #3949ab 0%, #5e35b1 100%)", HeaderText: "#fff",
+		Warning: [3]string{"#3e3418", "#6d5b1f", "#ffe08a"}, Special: [3]string{"#10283d", "#42a5f5", "#90caf9"},
+		ScreenOnly: true,
+	},
+	ThemeHighContrast: {
+		Scheme: "dark", Page: "#000", Surface: "#000", Text: "#fff", Muted: "#fff",
+		Border: "#fff", Shade: "#000", Accent: "#ffff00", AccentText: "#000", Secondary: "#00ffff",
+		HostBits: "#ff9dff", Header: "#000", HeaderText: "#fff",
+		Warning: [3]string{"#000", "#ffff00", "#ffff00"}, Special: [3]string{"#000", "#00ffff", "#fff"},
+		ScreenOnly: true,
+	},
+	ThemeNeutral: {
+		Scheme: "light", Page: "#f4f5f7", Surface: "#fff", Text: "#1f2328", Muted: "#59636e",
+		Border: "#d1d9e0", Shade: "#f6f8fa", Accent: "#0b5cad", AccentText: "#fff", Secondary: "#1f2328",
+		HostBits: "#9a6700", Header: "#24292f", HeaderText: "#fff",
+		Warning: [3]string{"#fff8c5", "#d4a72c", "#4d2d00"}, Special: [3]string{"#ddf4ff", "#0969da", "#0550ae"},
+	},
+}
+
+// HTMLThemes returns the names --theme accepts
+func HTMLThemes() []string {
+	themes := []string{ThemeLight, ThemeAuto}
+	for name := range htmlPalettes {
+		themes = append(themes, name)
+	}
+	sort.Strings(themes[2:])
+	return themes
+}
+
+// IsHTMLTheme reports whether --theme accepts the name
+func IsHTMLTheme(name string) bool {
+	_, ok := htmlPalettes[name]
+	return ok || name == ThemeLight || name == ThemeAuto
+}
+
+// htmlThemeCSS returns the rules that restyle the HTML report in the theme,
+// after the built-in light stylesheet
+func (f *OutputFormatter) htmlThemeCSS() template.CSS {
+	switch f.Theme {
+	case "", ThemeLight:
+		return ""
+	case ThemeAuto:
+		return template.CSS("        :root {\n            color-scheme: light dark;\n        }\n" +
+			"        @media screen and (prefers-color-scheme: dark) {\n" + htmlPalettes[ThemeDark].css("    ") + "        }\n")
+	}
+
+	palette := htmlPalettes[f.Theme]
+	if palette.ScreenOnly {
+		return template.CSS("        @media screen {\n" + palette.css("    ") + "        }\n")
+	}
+	return template.CSS(palette.css(""))
+}
+
+// css writes the palette as rules indented like the built-in stylesheet,
+// plus indent
+func (p htmlPalette) css(indent string) string {
+	rules := []struct {
+		selector     string
+		declarations string
+	}{
+		{":root", "color-scheme: " + p.Scheme},
+		{"body", fmt.Sprintf("color: %s; background-color: %s", p.Text, p.Page)},
+		{".container, .info-table, .subnet-list", "background: " + p.Surface},
+		{".header", fmt.Sprintf("background: %s !important; color: %s", p.Header, p.HeaderText)},
+		{".network-heading", "color: " + p.Secondary},
+		{".section h2", fmt.Sprintf("color: %s; border-bottom-color: %s", p.Accent, p.Accent)},
+		{".info-table th, .info-table td, .subnet-table th, .subnet-item td", "border-bottom-color: " + p.Border},
+		{".info-table th, .subnet-table th", fmt.Sprintf("background: %s; color: %s", p.Shade, p.Muted)},
+		{".info-table td", "color: " + p.Text},
+		{".info-table td span", "color: " + p.Muted + " !important"},
+		{".info-table tr:hover, .subnet-item:hover", "background: " + p.Shade},
+		{".binary-legend, .subnet-range, .subnet-matches, .no-matches", "color: " + p.Muted},
+		{".network-bits, .subnet-cidr", "color: " + p.Accent},
+		{".host-bits", fmt.Sprintf("color: %s; border-left-color: %s", p.HostBits, p.Secondary)},
+		{".toggle-btn, .toggle-btn:hover", fmt.Sprintf("background: %s; color: %s", p.Accent, p.AccentText)},
+		{".subnet-list", "border-color: " + p.Border},
+		{".usage-bar, .usage-key", "border-color: " + p.Border},
+		{".usage-bar, .usage-segment.free, .usage-key.free", "background: " + p.Shade},
+		{".usage-segment.used, .usage-key.used", "background: " + p.Accent},
+		{".usage-segment.used", "border-right-color: " + p.Surface},
+		{".usage-legend", "color: " + p.Muted},
+		{".subnet-search", fmt.Sprintf("background: %s; color: %s; border-color: %s", p.Surface, p.Text, p.Border)},
+		{".warning", fmt.Sprintf("background: %s; border-color: %s; color: %s", p.Warning[0], p.Warning[1], p.Warning[2])},
+		{".no-subnets", fmt.Sprintf("background: %s; color: %s", p.Shade, p.Muted)},
+		{".special-case", fmt.Sprintf("background: %s; border-left-color: %s", p.Special[0], p.Special[1])},
+		{".special-case .label", "color: " + p.Special[2]},
+	}
+
+	var css strings.Builder
+	for _, rule := range rules {
+		css.WriteString(fmt.Sprintf("%s        %s {\n", indent, rule.selector))
+		for _, declaration := range strings.Split(rule.declarations, "; ") {
+			css.WriteString(fmt.Sprintf("%s            %s;\n", indent, declaration))
+		}
+		css.WriteString(fmt.Sprintf("%s        }\n", indent))
+	}
+	return css.String()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputFormatter_HTMLThemes(t *testing.T) {
+	info, err := NewCIDRCalculator().ParseCIDR("10.0.0.0/30")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+	reports := []NetworkReport{{Info: info}}
+	formatter := NewOutputFormatter()
+
+	light := formatter.FormatReportsAsHTML(reports)
+	formatter.Theme = ThemeLight
+	if output := formatter.FormatReportsAsHTML(reports); output != light {
+		t.Errorf("expected the light theme to keep the built-in stylesheet")
+	}
+
+	testCases := []struct {
+		theme    string
+		expected []string
+		absent   []string
+	}{
+		{ThemeDark, []string{"        @media screen {\n            :root {\n                color-scheme: dark;\n", "background-color: #121212;"}, []string{"prefers-color-scheme"}},
+		{ThemeHighContrast, []string{"@media screen {", "color: #ffff00;"}, nil},
+		{ThemeNeutral, []string{"        .header {\n            background: #24292f !important;\n"}, []string{"@media screen {"}},
+		{ThemeAuto, []string{"color-scheme: light dark;", "@media screen and (prefers-color-scheme: dark) {", "background-color: #121212;"}, nil},
+	}
+	for _, tt := range testCases {
+		formatter.Theme = tt.theme
+		output := formatter.FormatReportsAsHTML(reports)
+		for _, expected := range tt.expected {
+			if !strings.Contains(output, expected) {
+				t.Errorf("%s: expected %q in the report", tt.theme, expected)
+			}
+		}
+		for _, absent := range tt.absent {
+			if strings.Contains(output, absent) {
+				t.Errorf("%s: expected no %q in the report", tt.theme, absent)
+			}
+		}
+		// The theme follows the built-in rules, so it wins over them
+		if strings.Index(output, "color-scheme:") < strings.Index(output, "@media print") {
+			t.Errorf("%s: expected the theme after the built-in stylesheet", tt.theme)
+		}
+	}
+
+	if themes := strings.Join(HTMLThemes(), " "); themes != "light auto dark high-contrast neutral" {
+		t.Errorf("unexpected themes %s", themes)
+	}
+}
+
+func TestCLIHandler_Theme(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	output := filepath.Join(t.TempDir(), "report.html")
+
+	if err := handler.Run([]string{"cidr-calc", "--theme", "high-contrast", "--minify", "-o", output, "10.0.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); !strings.Contains(string(content), "\n@media screen {\n:root {\ncolor-scheme: dark;\n}\n") {
+		t.Errorf("expected a minified high-contrast report, got:\n%s", content)
+	}
+
+	errorCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--theme", "dark", "10.0.0.0/24"}, "--theme applies to html output, not text"},
+		{[]string{"--html", "--theme", "pink", "10.0.0.0/24"}, "unknown --theme pink (available: light, auto, dark, high-contrast, neutral)"},
+	}
+	for _, tt := range errorCases {
+		if err := handler.Run(append([]string{"cidr-calc"}, tt.args...)); err == nil || err.Error() != tt.expected {
+			t.Errorf("%v: expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,539 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI escape sequences used by the full-screen UI
+const (
+	ansiAltScreen   = "\x1b[?1049h"
+	ansiMainScreen  = "\x1b[?1049l"
+	ansiHideCursor  = "\x1b[?25l"
+	ansiShowCursor  = "\x1b[?25h"
+	ansiClearScreen = "\x1b[H\x1b[2J"
+	ansiReverse     = "\x1b[7m"
+	ansiBold        = "\x1b[1m"
+	ansiReset       = "\x1b[0m"
+)
+
+// tuiKeyHelp is shown in the status line when there is no message
+const tuiKeyHelp = "↑↓ PgUp/PgDn move  Enter drill in  ← back  +/- prefix  b mark  f favs  q quit"
+
+// tuiFavoritesHelp is shown in the status line of the favorites list
+const tuiFavoritesHelp = "↑↓ move  Enter open  d delete  Esc close  q quit"
+
+// tuiFactsWidth is the width of the network pane when the panes sit side by side
+const tuiFactsWidth = 40
+
+// tuiView is one network on the drill-down stack and its subnet list
+type tuiView struct {
+	info    *NetworkInfo
+	prefix  int // prefix of the listed subnets; 0 when the network has none
+	subnets []SubnetInfo
+	cursor  int
+	offset  int
+}
+
+// tuiModel is the state of the full-screen UI. It only turns keys into state
+// changes and state into screens, so it can be driven without a terminal.
+type tuiModel struct {
+	calculator *CIDRCalculator
+	formatter  *OutputFormatter
+	views      []*tuiView // the drill-down path; the last view is shown
+	message    string     // a one-off status message, cleared by the next key
+	rows       int        // subnet rows on the last screen, for paging
+
+	favorites *Favorites // bookmarked networks
+	picking   bool       // the favorites list replaces the subnet list
+	picked    int        // the selected favorite
+	labeling  string     // the network a label is being typed for
+	label     []rune     // the label typed so far
+}
+
+// newTUIModel creates the model showing the network's subnets at the next prefix
+func newTUIModel(info *NetworkInfo) (*tuiModel, error) {
+	m := &tuiModel{calculator: NewCIDRCalculator(), formatter: NewOutputFormatter(), rows: 1, favorites: &Favorites{}}
+	view, err := m.newView(info, info.NextPrefix())
+	if err != nil {
+		return nil, err
+	}
+	m.views = []*tuiView{view}
+	return m, nil
+}
+
+// newView lists the subnets of a network at the given prefix
+func (m *tuiModel) newView(info *NetworkInfo, prefix int) (*tuiView, error) {
+	if info.PrefixLength == info.MaxPrefix() {
+		return &tuiView{info: info}, nil
+	}
+	subnets, err := m.calculator.SplitSubnets(info, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return &tuiView{info: info, prefix: prefix, subnets: subnets}, nil
+}
+
+// current returns the view on screen
+func (m *tuiModel) current() *tuiView {
+	return m.views[len(m.views)-1]
+}
+
+// Update applies a key and reports whether the UI should quit
+func (m *tuiModel) Update(key string) bool {
+	m.message = ""
+	if m.labeling != "" {
+		m.typeLabel(key)
+		return false
+	}
+	if m.picking {
+		return m.pick(key)
+	}
+	view := m.current()
+
+	switch key {
+	case "q", "ctrl-c":
+		return true
+	case "b":
+		m.labeling = view.info.CIDR()
+		if len(view.subnets) > 0 {
+			m.labeling = view.subnets[view.cursor].CIDR
+		}
+		m.label = nil
+	case "f":
+		if len(m.favorites.List) == 0 {
+			m.message = "no favorites yet; press b to bookmark the selected network"
+		} else {
+			m.picking, m.picked = true, 0
+		}
+	case "up", "k":
+		view.cursor--
+	case "down", "j":
+		view.cursor++
+	case "pgup":
+		view.cursor -= m.rows
+	case "pgdown", " ":
+		view.cursor += m.rows
+	case "home", "g":
+		view.cursor = 0
+	case "end", "G":
+		view.cursor = len(view.subnets) - 1
+	case "enter", "right", "l":
+		m.drillIn()
+	case "backspace", "left", "h", "esc":
+		if len(m.views) > 1 {
+			m.views = m.views[:len(m.views)-1]
+		} else {
+			m.message = "already at the top network"
+		}
+	case "+", "=":
+		m.resplit(view.prefix + 1)
+	case "-", "_":
+		m.resplit(view.prefix - 1)
+	}
+
+	view = m.current()
+	if view.cursor >= len(view.subnets) {
+		view.cursor = len(view.subnets) - 1
+	}
+	if view.cursor < 0 {
+		view.cursor = 0
+	}
+	return false
+}
+
+// typeLabel edits the label of the network being bookmarked, saving it on
+// Enter and dropping it on Esc
+func (m *tuiModel) typeLabel(key string) {
+	switch key {
+	case "enter":
+		cidr := m.labeling
+		m.labeling = ""
+		position := m.favorites.Add(cidr, strings.TrimSpace(string(m.label)))
+		if err := m.favorites.Save(); err != nil {
+			m.message = err.Error()
+			return
+		}
+		m.message = fmt.Sprintf("saved %s as favorite %d", cidr, position)
+	case "esc", "ctrl-c":
+		m.labeling = ""
+	case "backspace":
+		if len(m.label) > 0 {
+			m.label = m.label[:len(m.label)-1]
+		}
+	default:
+		if r := []rune(key); len(r) == 1 && r[0] >= ' ' {
+			m.label = append(m.label, r[0])
+		}
+	}
+}
+
+// pick moves through the favorites list, opening the selected network on
+// Enter, and reports whether the UI should quit
+func (m *tuiModel) pick(key string) bool {
+	switch key {
+	case "q", "ctrl-c":
+		return true
+	case "up", "k":
+		m.picked--
+	case "down", "j":
+		m.picked++
+	case "esc", "f", "left", "h", "backspace":
+		m.picking = false
+	case "enter", "right", "l":
+		m.picking = false
+		m.open(m.favorites.List[m.picked].CIDR)
+	case "d", "x":
+		favorite, _ := m.favorites.Remove(m.picked + 1)
+		if err := m.favorites.Save(); err != nil {
+			m.message = err.Error()
+		} else {
+			m.message = "removed favorite " + favorite.CIDR
+		}
+		if len(m.favorites.List) == 0 {
+			m.picking = false
+		}
+	}
+
+	if m.picked >= len(m.favorites.List) {
+		m.picked = len(m.favorites.List) - 1
+	}
+	if m.picked < 0 {
+		m.picked = 0
+	}
+	return false
+}
+
+// open pushes a network given by its CIDR, so ← returns to where the user was
+func (m *tuiModel) open(cidr string) {
+	info, err := m.calculator.ParseCIDR(cidr)
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+	next, err := m.newView(info, info.NextPrefix())
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+	m.views = append(m.views, next)
+}
+
+// drillIn pushes the selected subnet, listing its own subnets
+func (m *tuiModel) drillIn() {
+	view := m.current()
+	if len(view.subnets) == 0 {
+		m.message = view.info.CIDR() + " has no subnets"
+		return
+	}
+
+	m.open(view.subnets[view.cursor].CIDR)
+}
+
+// resplit lists the current network's subnets at another prefix, keeping the
+// selection on the subnet that contains the selected address
+func (m *tuiModel) resplit(prefix int) {
+	view := m.current()
+	if view.prefix == 0 {
+		m.message = view.info.CIDR() + " has no subnets"
+		return
+	}
+	if prefix <= view.info.PrefixLength {
+		m.message = fmt.Sprintf("/%d is the shortest prefix for %s", view.prefix, view.info.CIDR())
+		return
+	}
+
+	next, err := m.newView(view.info, prefix)
+	if err != nil {
+		m.message = err.Error()
+		return
+	}
+
+	// Subnets are in address order, so the selection maps by position
+	if prefix > view.prefix {
+		next.cursor = view.cursor << uint(prefix-view.prefix)
+	} else {
+		next.cursor = view.cursor >> uint(view.prefix-prefix)
+	}
+	m.views[len(m.views)-1] = next
+}
+
+// Render draws the screen for a terminal of the given size. Lines end in
+// "\r\n" since the terminal is in raw mode.
+func (m *tuiModel) Render(width, height int) string {
+	view := m.current()
+	var lines []string
+
+	// Title bar with the drill-down path
+	path := make([]string, len(m.views))
+	for i, v := range m.views {
+		path[i] = v.info.CIDR()
+	}
+	lines = append(lines, ansiReverse+fitLine(" cidr-calc  "+strings.Join(path, " › "), width)+ansiReset)
+
+	// Network pane: network and host facts side by side on wide terminals
+	lines = append(lines, paneHeader("Network", "", width))
+	network := m.factLines(m.formatter.networkFacts(view.info))
+	hosts := m.factLines(m.formatter.hostFacts(view.info))
+	if width >= 2*tuiFactsWidth {
+		for i := 0; i < len(network) || i < len(hosts); i++ {
+			left, right := "", ""
+			if i < len(network) {
+				left = network[i]
+			}
+			if i < len(hosts) {
+				right = hosts[i]
+			}
+			lines = append(lines, fitLine(padLine(left, tuiFactsWidth)+right, width))
+		}
+	} else {
+		for _, line := range append(network, hosts...) {
+			lines = append(lines, fitLine(line, width))
+		}
+	}
+
+	if m.picking {
+		return m.renderFavorites(lines, width, height)
+	}
+
+	// Subnet pane fills the rest of the screen above the status line
+	title := "Subnets"
+	position := ""
+	if view.prefix > 0 {
+		title = fmt.Sprintf("Subnets /%d (%d)", view.prefix, len(view.subnets))
+		position = fmt.Sprintf("%d/%d", view.cursor+1, len(view.subnets))
+	}
+	lines = append(lines, paneHeader(title, position, width))
+
+	m.rows = height - len(lines) - 1
+	if m.rows < 1 {
+		m.rows = 1
+	}
+	if view.cursor < view.offset {
+		view.offset = view.cursor
+	}
+	if view.cursor >= view.offset+m.rows {
+		view.offset = view.cursor - m.rows + 1
+	}
+
+	if len(view.subnets) == 0 {
+		lines = append(lines, fitLine("  "+noSubnetsMessage(view.info.PrefixLength), width))
+	}
+	for i := view.offset; i < len(view.subnets) && i < view.offset+m.rows; i++ {
+		subnet := view.subnets[i]
+		row := fmt.Sprintf("  %-20s %s", subnet.CIDR, m.formatter.formatSubnetRange(subnet))
+		if i == view.cursor {
+			row = ansiReverse + fitLine("▸"+row[1:], width) + ansiReset
+		} else {
+			row = fitLine(row, width)
+		}
+		lines = append(lines, row)
+	}
+
+	return m.finishScreen(lines, tuiKeyHelp, width, height)
+}
+
+// renderFavorites draws the favorites list in place of the subnet pane
+func (m *tuiModel) renderFavorites(lines []string, width, height int) string {
+	lines = append(lines, paneHeader(fmt.Sprintf("Favorites (%d)", len(m.favorites.List)), fmt.Sprintf("%d/%d", m.picked+1, len(m.favorites.List)), width))
+
+	rows := height - len(lines) - 1
+	if rows < 1 {
+		rows = 1
+	}
+	offset := 0
+	if m.picked >= rows {
+		offset = m.picked - rows + 1
+	}
+	for i, line := range m.favorites.Lines() {
+		if i < offset || i >= offset+rows {
+			continue
+		}
+		if i == m.picked {
+			lines = append(lines, ansiReverse+fitLine("▸"+line, width)+ansiReset)
+		} else {
+			lines = append(lines, fitLine(" "+line, width))
+		}
+	}
+	return m.finishScreen(lines, tuiFavoritesHelp, width, height)
+}
+
+// finishScreen pads the lines to the screen and adds the status line: the
+// label being typed, a message or the key help
+func (m *tuiModel) finishScreen(lines []string, help string, width, height int) string {
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	status := m.message
+	if m.labeling != "" {
+		status = fmt.Sprintf("Label for %s: %s▏ (Enter save, Esc cancel)", m.labeling, string(m.label))
+	}
+	if status == "" {
+		status = help
+	}
+	lines = append(lines, ansiBold+fitLine(" "+status, width)+ansiReset)
+
+	return ansiClearScreen + strings.Join(lines, "\r\n")
+}
+
+// factLines formats label/value facts like the text report
+func (m *tuiModel) factLines(facts []reportFact) []string {
+	lines := make([]string, len(facts))
+	for i, fact := range facts {
+		lines[i] = fmt.Sprintf("  %-15s %s", fact.Label+":", fact.Value)
+	}
+	return lines
+}
+
+// paneHeader draws a pane title rule with optional text on the right
+func paneHeader(title, right string, width int) string {
+	line := "── " + title + " "
+	if right != "" {
+		right = " " + right + " "
+	}
+	fill := width - utf8.RuneCountInString(line) - utf8.RuneCountInString(right)
+	if fill < 0 {
+		fill = 0
+	}
+	return fitLine(line+strings.Repeat("─", fill)+right, width)
+}
+
+// fitLine pads or truncates a line to exactly width characters
+func fitLine(line string, width int) string {
+	if utf8.RuneCountInString(line) > width {
+		return string([]rune(line)[:width])
+	}
+	return padLine(line, width)
+}
+
+// padLine pads a line with spaces to at least width characters
+func padLine(line string, width int) string {
+	if n := utf8.RuneCountInString(line); n < width {
+		return line + strings.Repeat(" ", width-n)
+	}
+	return line
+}
+
+// tuiKeySequences maps terminal escape sequences to key names
+var tuiKeySequences = map[string]string{
+	"\x1b[A": "up", "\x1bOA": "up",
+	"\x1b[B": "down", "\x1bOB": "down",
+	"\x1b[C": "right", "\x1bOC": "right",
+	"\x1b[D": "left", "\x1bOD": "left",
+	"\x1b[5~": "pgup", "\x1b[6~": "pgdown",
+	"\x1b[H": "home", "\x1bOH": "home", "\x1b[1~": "home",
+	"\x1b[F": "end", "\x1bOF": "end", "\x1b[4~": "end",
+}
+
+// decodeKeys turns bytes read from a raw terminal into key names
+func decodeKeys(input []byte) []string {
+	var keys []string
+	for i := 0; i < len(input); {
+		if input[i] == 0x1b {
+			matched := false
+			for sequence, key := range tuiKeySequences {
+				if strings.HasPrefix(string(input[i:]), sequence) {
+					keys = append(keys, key)
+					i += len(sequence)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				keys = append(keys, "esc")
+				i++
+			}
+			continue
+		}
+
+		switch b := input[i]; b {
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case 0x7f, 0x08:
+			keys = append(keys, "backspace")
+		case 0x03:
+			keys = append(keys, "ctrl-c")
+		default:
+			keys = append(keys, string(rune(b)))
+		}
+		i++
+	}
+	return keys
+}
+
+// stty runs stty on the controlling terminal and returns its trimmed output
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s: %v", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// terminalSize returns the terminal width and height, falling back to 80x24
+func terminalSize() (int, int) {
+	size, err := stty("size")
+	if err == nil {
+		if rows, cols, ok := strings.Cut(size, " "); ok {
+			height, heightErr := strconv.Atoi(rows)
+			width, widthErr := strconv.Atoi(cols)
+			if heightErr == nil && widthErr == nil && width > 0 && height > 0 {
+				return width, height
+			}
+		}
+	}
+	return 80, 24
+}
+
+// runTUI shows the network in the full-screen UI until the user quits. The
+// terminal is switched to raw mode with stty and restored on exit.
+func (c *CLIHandler) runTUI(config *Config) (err error) {
+	info, err := c.calculator.ParseCIDR(config.CIDR)
+	if err != nil {
+		return fmt.Errorf("failed to parse CIDR: %v", err)
+	}
+	model, err := newTUIModel(info)
+	if err != nil {
+		return err
+	}
+	model.formatter.ClassRules = c.formatter.ClassRules
+	if model.favorites, err = c.loadFavorites(); err != nil {
+		return err
+	}
+
+	saved, err := stty("-g")
+	if err != nil {
+		return fmt.Errorf("--tui needs an interactive terminal: %v", err)
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return fmt.Errorf("--tui needs an interactive terminal: %v", err)
+	}
+	fmt.Print(ansiAltScreen + ansiHideCursor)
+	defer func() {
+		fmt.Print(ansiShowCursor + ansiMainScreen)
+		if _, restoreErr := stty(saved); restoreErr != nil && err == nil {
+			err = fmt.Errorf("failed to restore the terminal: %v", restoreErr)
+		}
+	}()
+
+	buffer := make([]byte, 64)
+	for {
+		fmt.Print(model.Render(terminalSize()))
+
+		n, readErr := os.Stdin.Read(buffer)
+		if readErr != nil {
+			return nil
+		}
+		for _, key := range decodeKeys(buffer[:n]) {
+			if model.Update(key) {
+				return nil
+			}
+		}
+	}
+}
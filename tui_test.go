@@ -0,0 +1,162 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestTUIModel(t *testing.T, cidr string) *tuiModel {
+	t.Helper()
+	info, err := NewCIDRCalculator().ParseCIDR(cidr)
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+	model, err := newTUIModel(info)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return model
+}
+
+func TestTUIModel_Navigation(t *testing.T) {
+	model := newTestTUIModel(t, "10.0.0.0/24")
+
+	steps := []struct {
+		key      string
+		network  string
+		prefix   int
+		selected string
+	}{
+		{"down", "10.0.0.0/24", 25, "10.0.0.128/25"},
+		{"down", "10.0.0.0/24", 25, "10.0.0.128/25"},
+		{"+", "10.0.0.0/24", 26, "10.0.0.128/26"},
+		{"end", "10.0.0.0/24", 26, "10.0.0.192/26"},
+		{"-", "10.0.0.0/24", 25, "10.0.0.128/25"},
+		{"enter", "10.0.0.128/25", 26, "10.0.0.128/26"},
+		{"j", "10.0.0.128/25", 26, "10.0.0.192/26"},
+		{"l", "10.0.0.192/26", 27, "10.0.0.192/27"},
+		{"left", "10.0.0.128/25", 26, "10.0.0.192/26"},
+		{"backspace", "10.0.0.0/24", 25, "10.0.0.128/25"},
+		{"home", "10.0.0.0/24", 25, "10.0.0.0/25"},
+		{"up", "10.0.0.0/24", 25, "10.0.0.0/25"},
+	}
+
+	for i, step := range steps {
+		if model.Update(step.key) {
+			t.Fatalf("step %d: %s should not quit", i, step.key)
+		}
+		view := model.current()
+		if view.info.CIDR() != step.network || view.prefix != step.prefix || view.subnets[view.cursor].CIDR != step.selected {
+			t.Errorf("step %d (%s): expected %s /%d at %s, got %s /%d at %s", i, step.key,
+				step.network, step.prefix, step.selected, view.info.CIDR(), view.prefix, view.subnets[view.cursor].CIDR)
+		}
+	}
+
+	if !model.Update("q") {
+		t.Errorf("expected q to quit")
+	}
+}
+
+func TestTUIModel_Messages(t *testing.T) {
+	model := newTestTUIModel(t, "10.0.0.0/31")
+
+	model.Update("left")
+	if model.message != "already at the top network" {
+		t.Errorf("unexpected message: %q", model.message)
+	}
+	model.Update("-")
+	if !strings.Contains(model.message, "shortest prefix") {
+		t.Errorf("unexpected message: %q", model.message)
+	}
+
+	model.Update("enter")
+	if model.current().info.CIDR() != "10.0.0.0/32" {
+		t.Fatalf("expected to drill into the /32")
+	}
+	model.Update("enter")
+	if model.message != "10.0.0.0/32 has no subnets" {
+		t.Errorf("unexpected message: %q", model.message)
+	}
+
+	model = newTestTUIModel(t, "10.0.0.0/8")
+	for i := 0; i < 16; i++ {
+		model.Update("+")
+	}
+	if model.current().prefix != 24 || !strings.Contains(model.message, "limit 65536") {
+		t.Errorf("expected the split limit to stop at /24, got /%d (%q)", model.current().prefix, model.message)
+	}
+}
+
+func TestTUIModel_Render(t *testing.T) {
+	model := newTestTUIModel(t, "10.0.0.0/20")
+	model.Update("enter")
+
+	screen := model.Render(80, 16)
+	lines := strings.Split(strings.TrimPrefix(screen, ansiClearScreen), "\r\n")
+	if len(lines) != 16 {
+		t.Fatalf("expected 16 lines, got %d", len(lines))
+	}
+
+	for _, expected := range []string{
+		"cidr-calc  10.0.0.0/20 › 10.0.0.0/21",
+		padLine("  CIDR:           10.0.0.0/21", tuiFactsWidth) + "  First Usable:   10.0.0.1",
+		"── Subnets /22 (2) ",
+		" 1/2 ",
+		"▸ 10.0.0.0/22",
+		tuiKeyHelp,
+	} {
+		if !strings.Contains(screen, expected) {
+			t.Errorf("expected screen to contain %q, got:\n%s", expected, screen)
+		}
+	}
+
+	// Narrow terminals stack the panes and scroll the subnet list
+	model = newTestTUIModel(t, "10.0.0.0/24")
+	model.Update("+")
+	model.Update("+")
+	model.Update("+")
+	model.Update("end")
+	screen = model.Render(40, 20)
+	if !strings.Contains(screen, "▸ 10.0.0.240/28") || strings.Contains(screen, "10.0.0.0/28 ") {
+		t.Errorf("expected the list to scroll to the selection, got:\n%s", screen)
+	}
+	if model.rows != 7 {
+		t.Errorf("expected 7 subnet rows, got %d", model.rows)
+	}
+}
+
+func TestDecodeKeys(t *testing.T) {
+	input := []byte("\x1b[A\x1b[B\x1bOC\x1b[D\x1b[5~\x1b[6~\x1b[H\x1b[4~\r\x7f\x03q+\x1b")
+	expected := []string{"up", "down", "right", "left", "pgup", "pgdown", "home", "end", "enter", "backspace", "ctrl-c", "q", "+", "esc"}
+	if keys := decodeKeys(input); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected %v, got %v", expected, keys)
+	}
+}
+
+func TestCLIHandler_TUIFlags(t *testing.T) {
+	handler := NewCLIHandler()
+
+	tests := []struct {
+		args        []string
+		expectedErr string
+	}{
+		{[]string{"cidr-calc", "--tui", "10.0.0.0/16"}, ""},
+		{[]string{"cidr-calc", "--tui"}, "--tui takes a single CIDR, got 0"},
+		{[]string{"cidr-calc", "--tui", "10.0.0.0/16", "10.1.0.0/16"}, "--tui takes a single CIDR, got 2"},
+		{[]string{"cidr-calc", "--tui", "-o", "out.txt", "10.0.0.0/16"}, "cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		config, err := handler.parseFlags(tt.args)
+		if tt.expectedErr == "" {
+			if err != nil || !config.TUI {
+				t.Errorf("%v: unexpected result %+v, %v", tt.args, config, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+			t.Errorf("%v: expected error containing %q, got %v", tt.args, tt.expectedErr, err)
+		}
+	}
+}
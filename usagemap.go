@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// UsedRanges are the subnets and hosts of a --used file, drawn against the
+// networks of HTML reports
+type UsedRanges struct {
+	ranges []planNetwork
+}
+
+// LoadUsedRanges reads a plan file of used subnets or host addresses from a
+// path, "-" or a URL; a plan document gives its allocations
+func LoadUsedRanges(source string) (*UsedRanges, error) {
+	entries, err := NewBatchReader(defaultFetchTimeout, nil).ReadSection(source, SectionAllocations)
+	if err != nil {
+		return nil, err
+	}
+
+	calculator := NewCIDRCalculator()
+	used := &UsedRanges{}
+	for _, entry := range entries {
+		cidr, err := calculator.NormalizeCIDR(entry.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid used range: %v", entry.Source, entry.Line, err)
+		}
+		info, err := calculator.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid used range: %v", entry.Source, entry.Line, err)
+		}
+		used.ranges = append(used.ranges, planNetwork{entry: entry, info: info})
+	}
+	return used, nil
+}
+
+// UsageSegment is a run of addresses of a network that is either free or
+// covered by used ranges
+type UsageSegment struct {
+	First, Last *big.Int
+	Used        bool
+	Names       []string // the used ranges of the segment, by name tag or CIDR
+}
+
+// Size returns the number of addresses in the segment
+func (s UsageSegment) Size() *big.Int {
+	size := new(big.Int).Sub(s.Last, s.First)
+	return size.Add(size, big.NewInt(1))
+}
+
+// AddressUsage is how much of a network the used ranges cover, as segments
+// in address order
+type AddressUsage struct {
+	Network     *NetworkInfo
+	Used        *big.Int
+	Total       *big.Int
+	Allocations int // used ranges overlapping the network
+	Segments    []UsageSegment
+}
+
+// Usage lays the used ranges over the network. Overlapping ranges share a
+// segment; adjacent ones keep their own, so every allocation stays visible.
+func (u *UsedRanges) Usage(network *NetworkInfo) *AddressUsage {
+	start, end := addressInt(network.NetworkID), addressInt(network.BroadcastAddr)
+	usage := &AddressUsage{Network: network, Used: new(big.Int), Total: new(big.Int).Add(new(big.Int).Sub(end, start), big.NewInt(1))}
+
+	var used []UsageSegment
+	for _, r := range u.ranges {
+		if r.info.IsIPv6() != network.IsIPv6() || !network.Overlaps(r.info) {
+			continue
+		}
+		first, last := addressInt(r.info.NetworkID), addressInt(r.info.BroadcastAddr)
+		if first.Cmp(start) < 0 {
+			first = start
+		}
+		if last.Cmp(end) > 0 {
+			last = end
+		}
+		name := r.entry.Tags["name"]
+		if name == "" {
+			name = r.info.CIDR()
+		}
+		used = append(used, UsageSegment{First: first, Last: last, Used: true, Names: []string{name}})
+	}
+	usage.Allocations = len(used)
+	sort.SliceStable(used, func(i, j int) bool { return used[i].First.Cmp(used[j].First) < 0 })
+
+	next := start
+	for _, segment := range used {
+		if count := len(usage.Segments); count > 0 && usage.Segments[count-1].Used && segment.First.Cmp(usage.Segments[count-1].Last) <= 0 {
+			previous := &usage.Segments[count-1]
+			if segment.Last.Cmp(previous.Last) > 0 {
+				previous.Last = segment.Last
+			}
+			previous.Names = append(previous.Names, segment.Names...)
+		} else {
+			if segment.First.Cmp(next) > 0 {
+				usage.Segments = append(usage.Segments, UsageSegment{First: next, Last: new(big.Int).Sub(segment.First, big.NewInt(1))})
+			}
+			usage.Segments = append(usage.Segments, segment)
+		}
+		if after := new(big.Int).Add(segment.Last, big.NewInt(1)); after.Cmp(next) > 0 {
+			next = after
+		}
+	}
+	if next.Cmp(end) <= 0 {
+		usage.Segments = append(usage.Segments, UsageSegment{First: next, Last: end})
+	}
+
+	for _, segment := range usage.Segments {
+		if segment.Used {
+			usage.Used.Add(usage.Used, segment.Size())
+		}
+	}
+	return usage
+}
+
+// Percent returns the share of addresses the segment or count takes of the
+// network, such as 37.5
+func (u *AddressUsage) Percent(count *big.Int) float64 {
+	percent, _ := new(big.Float).Quo(new(big.Float).SetInt(count), new(big.Float).SetInt(u.Total)).Float64()
+	return percent * 100
+}
+
+// htmlUsage is the template data of the utilization bar of a network
+type htmlUsage struct {
+	Used        string
+	Free        string
+	Total       string
+	Percent     string
+	Allocations int
+	Segments    []htmlUsageSegment
+}
+
+// htmlUsageSegment is a segment of the utilization bar; Width is its share
+// of the bar in percent
+type htmlUsageSegment struct {
+	Range     string
+	Addresses string
+	Width     string
+	Used      bool
+	Label     string
+	Title     string // the range, size and label shown on hover
+}
+
+// htmlUsage converts the usage of a network to its template data
+func (f *OutputFormatter) htmlUsage(usage *AddressUsage) *htmlUsage {
+	data := &htmlUsage{
+		Used:        f.Locale.Integer(usage.Used.String()),
+		Free:        f.Locale.Integer(new(big.Int).Sub(usage.Total, usage.Used).String()),
+		Total:       f.Locale.Integer(usage.Total.String()),
+		Percent:     fmt.Sprintf("%.1f%%", usage.Percent(usage.Used)),
+		Allocations: usage.Allocations,
+	}
+	for _, segment := range usage.Segments {
+		label := "free"
+		if segment.Used {
+			label = strings.Join(segment.Names, ", ")
+		}
+		addresses := f.Locale.Integer(segment.Size().String())
+		noun := "addresses"
+		if segment.Size().Cmp(big.NewInt(1)) == 0 {
+			noun = "address"
+		}
+		addressRange := fmt.Sprintf("%s - %s", usage.Network.addrFromInt(segment.First), usage.Network.addrFromInt(segment.Last))
+		data.Segments = append(data.Segments, htmlUsageSegment{
+			Range:     addressRange,
+			Addresses: addresses,
+			Width:     fmt.Sprintf("%.4f", usage.Percent(segment.Size())),
+			Used:      segment.Used,
+			Label:     label,
+			Title:     fmt.Sprintf("%s (%s %s): %s", addressRange, addresses, noun, label),
+		})
+	}
+	return data
+}
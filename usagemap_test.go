@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUsedRanges_Usage(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "used.txt")
+	content := "10.0.0.0/24 name=web\n10.0.0.128/25 name=web-canary\n10.0.1.0/25\n10.0.2.17 name=bastion\n192.168.0.0/24\n10.0.0.0/8 name=corp\n"
+	if err := os.WriteFile(source, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	used, err := LoadUsedRanges(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	usage := used.Usage(mustParseCIDR(t, NewCIDRCalculator(), "10.0.0.0/22"))
+	if usage.Used.String() != "1024" || usage.Allocations != 5 || len(usage.Segments) != 1 {
+		t.Errorf("expected the covering /8 to use the whole network, got %s in %d segments", usage.Used, len(usage.Segments))
+	}
+
+	used.ranges = used.ranges[:len(used.ranges)-1]
+	usage = used.Usage(mustParseCIDR(t, NewCIDRCalculator(), "10.0.0.0/22"))
+	var segments []string
+	for _, segment := range usage.Segments {
+		segments = append(segments, segment.Size().String()+" "+strings.Join(segment.Names, ","))
+	}
+	// The canary overlaps web and shares its segment; the adjacent /25 and the
+	// host keep their own, and 192.168.0.0/24 is outside the network
+	if got := strings.Join(segments, "; "); got != "256 web,web-canary; 128 10.0.1.0/25; 145 ; 1 bastion; 494 " {
+		t.Errorf("unexpected segments %q", got)
+	}
+	if usage.Used.String() != "385" || usage.Allocations != 4 {
+		t.Errorf("expected 385 addresses in 4 ranges, got %s in %d", usage.Used, usage.Allocations)
+	}
+
+	if err := os.WriteFile(source, []byte("10.0.0.0/33\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadUsedRanges(source); err == nil || !strings.Contains(err.Error(), "line 1: invalid used range") {
+		t.Errorf("expected an invalid range error, got %v", err)
+	}
+}
+
+func TestCLIHandler_Used(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+	dir := t.TempDir()
+	source := filepath.Join(dir, "used.txt")
+	output := filepath.Join(dir, "report.html")
+	if err := os.WriteFile(source, []byte("10.0.0.0/25 name=web\n10.0.0.200\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "--used", source, "-o", output, "10.0.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	for _, expected := range []string{
+		"<h2>Address Space Utilization</h2>",
+		"<td>129 of 256 addresses (50.4%)</td>",
+		`<div class="usage-bar" role="img" aria-label="50.4% of 10.0.0.0/24 allocated">`,
+		`<div class="usage-segment used" style="width: 50.0000%" title="10.0.0.0 - 10.0.0.127 (128 addresses): web"></div>`,
+		`<div class="usage-segment free" style="width: 28.1250%" title="10.0.0.128 - 10.0.0.199 (72 addresses): free"></div>`,
+		`title="10.0.0.200 - 10.0.0.200 (1 address): 10.0.0.200/32"`,
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("expected %q in the report", expected)
+		}
+	}
+
+	// Without --used the section is left out
+	if err := handler.Run([]string{"cidr-calc", "-o", output, "10.0.0.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content, _ := os.ReadFile(output); strings.Contains(string(content), "Address Space Utilization") {
+		t.Errorf("expected no utilization section without --used")
+	}
+
+	if err := handler.Run([]string{"cidr-calc", "--used", source, "10.0.0.0/24"}); err == nil || err.Error() != "--used applies to html output, not text" {
+		t.Errorf("expected an html-only error, got %v", err)
+	}
+}
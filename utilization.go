@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Utilization rule names
+const (
+	RuleHighUtilization    = "high-utilization"
+	RuleExhaustionForecast = "exhaustion-forecast"
+)
+
+// Default utilization thresholds in percent and forecast horizon in days
+const (
+	defaultWarnPercent     = 80.0
+	defaultCriticalPercent = 95.0
+	defaultHorizonDays     = 30
+)
+
+// utilizationTimeLayout is the timestamp format of utilization history files
+const utilizationTimeLayout = time.RFC3339
+
+// Utilization is the number of usable addresses of a network that are in use
+type Utilization struct {
+	Network *NetworkInfo
+	Used    uint64
+	Total   uint64
+}
+
+// NewUtilization creates a utilization for the usable addresses of an IPv4 network
+func NewUtilization(network *NetworkInfo, used uint64) Utilization {
+	return Utilization{Network: network, Used: used, Total: uint64(network.TotalHosts)}
+}
+
+// Percent returns the share of usable addresses in use
+func (u Utilization) Percent() float64 {
+	if u.Total == 0 {
+		return 0
+	}
+	return float64(u.Used) * 100 / float64(u.Total)
+}
+
+// Free returns the number of usable addresses not in use
+func (u Utilization) Free() uint64 {
+	if u.Used >= u.Total {
+		return 0
+	}
+	return u.Total - u.Used
+}
+
+// UtilizationSample is one recorded utilization measurement of a network
+type UtilizationSample struct {
+	Time  time.Time
+	CIDR  string
+	Used  uint64
+	Total uint64
+}
+
+// Forecast is a linear projection of utilization growth
+type Forecast struct {
+	Samples    int
+	PerDay     float64
+	Exhaustion time.Time // zero when utilization is not growing
+}
+
+// DaysLeft returns the days from now until the network is projected to be full
+func (f Forecast) DaysLeft(now time.Time) float64 {
+	return f.Exhaustion.Sub(now).Hours() / 24
+}
+
+// ForecastExhaustion fits a least-squares line through the samples and projects
+// when the network runs out of addresses. ok is false with fewer than two samples
+// or when all samples were taken at the same time.
+func ForecastExhaustion(samples []UtilizationSample, total uint64) (forecast Forecast, ok bool) {
+	if len(samples) < 2 {
+		return Forecast{Samples: len(samples)}, false
+	}
+
+	origin := samples[0].Time
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x := sample.Time.Sub(origin).Hours() / 24
+		y := float64(sample.Used)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return Forecast{Samples: len(samples)}, false
+	}
+
+	slope := (n*sumXY - sumX*sumY) / denominator
+	intercept := (sumY - slope*sumX) / n
+	forecast = Forecast{Samples: len(samples), PerDay: slope}
+
+	if slope > 0 {
+		days := (float64(total) - intercept) / slope
+		forecast.Exhaustion = origin.Add(time.Duration(days * 24 * float64(time.Hour)))
+	}
+
+	return forecast, true
+}
+
+// ReadUtilizationHistory loads the samples recorded for a network. A missing
+// history file is not an error; it simply has no samples yet.
+func ReadUtilizationHistory(path, cidr string) ([]UtilizationSample, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file %s: %v", path, err)
+	}
+	defer file.Close()
+
+	return parseUtilizationHistory(file, path, cidr)
+}
+
+// parseUtilizationHistory reads time,cidr,used,total rows, keeping those for cidr
+func parseUtilizationHistory(r io.Reader, path, cidr string) ([]UtilizationSample, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.Comment = '#'
+
+	var samples []UtilizationSample
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if record[1] != cidr {
+			continue
+		}
+
+		sample := UtilizationSample{CIDR: record[1]}
+		if sample.Time, err = time.Parse(utilizationTimeLayout, record[0]); err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid time: %v", path, line, err)
+		}
+		if sample.Used, err = strconv.ParseUint(record[2], 10, 64); err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid used count: %v", path, line, err)
+		}
+		if sample.Total, err = strconv.ParseUint(record[3], 10, 64); err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid total: %v", path, line, err)
+		}
+		samples = append(samples, sample)
+	}
+
+	return samples, nil
+}
+
+// AppendUtilizationSample records a sample at the end of the history file
+func AppendUtilizationSample(path string, sample UtilizationSample) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file %s: %v", path, err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{
+		sample.Time.UTC().Format(utilizationTimeLayout),
+		sample.CIDR,
+		strconv.FormatUint(sample.Used, 10),
+		strconv.FormatUint(sample.Total, 10),
+	})
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write history file %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// UtilizationThresholds configures when utilization becomes a finding
+type UtilizationThresholds struct {
+	WarnPercent     float64
+	CriticalPercent float64
+	HorizonDays     int
+}
+
+// UtilizationFindings reports utilization above the thresholds, and a forecast
+// exhaustion date that falls within the horizon. The messages write dates and
+// numbers in the locale.
+func UtilizationFindings(u Utilization, forecast Forecast, hasForecast bool, thresholds UtilizationThresholds, source string, now time.Time, locale Locale) []Finding {
+	var findings []Finding
+	cidr := u.Network.CIDR()
+
+	percent := u.Percent()
+	switch {
+	case percent >= thresholds.CriticalPercent:
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Rule:     RuleHighUtilization,
+			Message:  fmt.Sprintf("%s is %s%% used (%s of %s addresses), at or above %s%%", cidr, locale.Number(percent, 1, false), locale.Uint(u.Used), locale.Uint(u.Total), locale.Number(thresholds.CriticalPercent, 0, false)),
+			File:     source,
+			CIDR:     cidr,
+		})
+	case percent >= thresholds.WarnPercent:
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Rule:     RuleHighUtilization,
+			Message:  fmt.Sprintf("%s is %s%% used (%s of %s addresses), at or above %s%%", cidr, locale.Number(percent, 1, false), locale.Uint(u.Used), locale.Uint(u.Total), locale.Number(thresholds.WarnPercent, 0, false)),
+			File:     source,
+			CIDR:     cidr,
+		})
+	}
+
+	if hasForecast && !forecast.Exhaustion.IsZero() && forecast.DaysLeft(now) <= float64(thresholds.HorizonDays) {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Rule:     RuleExhaustionForecast,
+			Message:  fmt.Sprintf("%s is projected to run out of addresses by %s", cidr, locale.Date(forecast.Exhaustion)),
+			File:     source,
+			CIDR:     cidr,
+		})
+	}
+
+	return findings
+}
+
+// FormatUtilization renders utilization, the forecast and the findings as
+// text, with dates and numbers in the locale of the formatter
+func (f *OutputFormatter) FormatUtilization(title string, u Utilization, forecast Forecast, hasForecast bool, findings []Finding, now time.Time) string {
+	var output strings.Builder
+	locale := f.Locale
+
+	output.WriteString(title + ":\n")
+	output.WriteString(fmt.Sprintf("  %-15s %s\n", "Network:", u.Network.CIDR()))
+	output.WriteString(fmt.Sprintf("  %-15s %s of %s (%s%%)\n", "Used:", locale.Uint(u.Used), locale.Uint(u.Total), locale.Number(u.Percent(), 1, false)))
+	output.WriteString(fmt.Sprintf("  %-15s %s\n", "Free:", locale.Uint(u.Free())))
+
+	switch {
+	case !hasForecast:
+		output.WriteString(fmt.Sprintf("  %-15s not enough history (%d samples)\n", "Forecast:", forecast.Samples))
+	case forecast.Exhaustion.IsZero():
+		output.WriteString(fmt.Sprintf("  %-15s not growing (%s addresses/day over %d samples)\n", "Forecast:", locale.Number(forecast.PerDay, 1, true), forecast.Samples))
+	default:
+		output.WriteString(fmt.Sprintf("  %-15s full by %s (%s days, %s addresses/day over %d samples)\n",
+			"Forecast:", locale.Date(forecast.Exhaustion), locale.Number(forecast.DaysLeft(now), 0, false), locale.Number(forecast.PerDay, 1, true), forecast.Samples))
+	}
+
+	output.WriteString("\n")
+	output.WriteString(f.FormatFindings(findings))
+
+	return output.String()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUtilization(t *testing.T) {
+	network, err := NewCIDRCalculator().ParseCIDR("10.0.0.0/24")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+
+	u := NewUtilization(network, 127)
+	if u.Total != 254 || u.Free() != 127 || u.Percent() != 50 {
+		t.Errorf("unexpected utilization: total %d, free %d, %.1f%%", u.Total, u.Free(), u.Percent())
+	}
+
+	if free := NewUtilization(network, 300).Free(); free != 0 {
+		t.Errorf("expected no free addresses when over-allocated, got %d", free)
+	}
+}
+
+func TestForecastExhaustion(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sample := func(days int, used uint64) UtilizationSample {
+		return UtilizationSample{Time: start.AddDate(0, 0, days), Used: used, Total: 254}
+	}
+
+	tests := []struct {
+		name           string
+		samples        []UtilizationSample
+		expectOK       bool
+		expectPerDay   float64
+		expectExhausts string
+	}{
+		{name: "no samples", expectOK: false},
+		{name: "single sample", samples: []UtilizationSample{sample(0, 100)}, expectOK: false},
+		{name: "same time", samples: []UtilizationSample{sample(0, 100), sample(0, 110)}, expectOK: false},
+		{name: "steady growth", samples: []UtilizationSample{sample(0, 100), sample(10, 150), sample(20, 200)}, expectOK: true, expectPerDay: 5, expectExhausts: "2024-01-31"},
+		{name: "shrinking", samples: []UtilizationSample{sample(0, 200), sample(10, 150)}, expectOK: true, expectPerDay: -5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forecast, ok := ForecastExhaustion(tt.samples, 254)
+			if ok != tt.expectOK {
+				t.Fatalf("expected ok %v, got %v", tt.expectOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if math.Abs(forecast.PerDay-tt.expectPerDay) > 0.001 {
+				t.Errorf("expected %.1f addresses/day, got %.3f", tt.expectPerDay, forecast.PerDay)
+			}
+			exhausts := ""
+			if !forecast.Exhaustion.IsZero() {
+				exhausts = forecast.Exhaustion.Format("2006-01-02")
+			}
+			if exhausts != tt.expectExhausts {
+				t.Errorf("expected exhaustion %q, got %q", tt.expectExhausts, exhausts)
+			}
+		})
+	}
+}
+
+func TestUtilizationHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.csv")
+
+	samples, err := ReadUtilizationHistory(path, "10.0.0.0/24")
+	if err != nil || len(samples) != 0 {
+		t.Fatalf("expected no samples from a missing file, got %v (%v)", samples, err)
+	}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, sample := range []UtilizationSample{
+		{Time: start, CIDR: "10.0.0.0/24", Used: 10, Total: 254},
+		{Time: start, CIDR: "10.1.0.0/24", Used: 99, Total: 254},
+		{Time: start.AddDate(0, 0, 1), CIDR: "10.0.0.0/24", Used: 12, Total: 254},
+	} {
+		if err := AppendUtilizationSample(path, sample); err != nil {
+			t.Fatalf("sample %d: unexpected error: %v", i, err)
+		}
+	}
+
+	samples, err = ReadUtilizationHistory(path, "10.0.0.0/24")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(samples) != 2 || samples[1].Used != 12 || !samples[1].Time.Equal(start.AddDate(0, 0, 1)) {
+		t.Errorf("unexpected samples: %+v", samples)
+	}
+
+	if _, err := parseUtilizationHistory(strings.NewReader("yesterday,10.0.0.0/24,1,254\n"), "bad.csv", "10.0.0.0/24"); err == nil {
+		t.Errorf("expected error for an invalid timestamp")
+	}
+}
+
+func TestUtilizationFindings(t *testing.T) {
+	network, err := NewCIDRCalculator().ParseCIDR("10.0.0.0/24")
+	if err != nil {
+		t.Fatalf("failed to parse CIDR: %v", err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	thresholds := UtilizationThresholds{WarnPercent: 80, CriticalPercent: 95, HorizonDays: 30}
+	soon := Forecast{Samples: 3, PerDay: 2, Exhaustion: now.AddDate(0, 0, 10)}
+	later := Forecast{Samples: 3, PerDay: 2, Exhaustion: now.AddDate(0, 0, 90)}
+
+	tests := []struct {
+		name        string
+		used        uint64
+		forecast    Forecast
+		hasForecast bool
+		expected    []string
+	}{
+		{name: "healthy", used: 100},
+		{name: "warning", used: 210, expected: []string{"warning/high-utilization"}},
+		{name: "critical", used: 250, expected: []string{"error/high-utilization"}},
+		{name: "forecast within horizon", used: 100, forecast: soon, hasForecast: true, expected: []string{"warning/exhaustion-forecast"}},
+		{name: "forecast beyond horizon", used: 100, forecast: later, hasForecast: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := UtilizationFindings(NewUtilization(network, tt.used), tt.forecast, tt.hasForecast, thresholds, "dhcpd.leases", now, Locale{})
+			var got []string
+			for _, finding := range findings {
+				got = append(got, string(finding.Severity)+"/"+finding.Rule)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected findings %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
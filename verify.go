@@ -0,0 +1,364 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxVerifySplitBits bounds how many prefix bits the split property adds, so
+// one case splits into at most 16 subnets
+const maxVerifySplitBits = 4
+
+// verifyCase is the random input of one iteration: a network, a second
+// network that is often related to it, and the generator for further choices
+type verifyCase struct {
+	network *NetworkInfo
+	other   *NetworkInfo
+	random  *rand.Rand
+}
+
+// verifyProperty is an invariant checked against every case. check returns
+// nil when the invariant holds and a description of the violation otherwise.
+type verifyProperty struct {
+	name        string
+	description string
+	check       func(c *CIDRCalculator, input verifyCase) error
+}
+
+// verifyProperties are the invariants checked by the verify subcommand
+var verifyProperties = []verifyProperty{
+	{name: "bounds", description: "network <= first usable <= last usable <= broadcast, and the block has 2^(bits-prefix) addresses", check: checkBounds},
+	{name: "split-aggregate", description: "aggregating the subnets of a split gives back the network", check: checkSplitAggregate},
+	{name: "contains-overlaps", description: "contains and overlaps agree with each other and with the address ranges", check: checkContainsOverlaps},
+	{name: "adjacent", description: "the next network starts after the broadcast address and steps back to the network", check: checkAdjacent},
+	{name: "supernet", description: "a supernet at a shorter prefix contains the network", check: checkSupernet},
+	{name: "text", description: "a network survives its text form", check: checkText},
+}
+
+// VerifyFailure is the first counterexample found for a property
+type VerifyFailure struct {
+	Property string
+	Expected string // what the property asserts
+	Input    string
+	Detail   string
+	Count    int
+}
+
+// VerifyReport is the outcome of a verification run
+type VerifyReport struct {
+	Iterations int
+	Seed       int64
+	Properties []string
+	Failures   []VerifyFailure
+}
+
+// addressInt returns an address as an integer
+func addressInt(ip net.IP) *big.Int {
+	return new(big.Int).SetBytes(addressBytes(ip))
+}
+
+// checkBounds verifies the ordering of the addresses of a network and its size
+func checkBounds(c *CIDRCalculator, input verifyCase) error {
+	n := input.network
+	network, first, last, broadcast := addressInt(n.NetworkID), addressInt(n.FirstUsableIP), addressInt(n.LastUsableIP), addressInt(n.BroadcastAddr)
+	if network.Cmp(first) > 0 || first.Cmp(last) > 0 || last.Cmp(broadcast) > 0 {
+		return fmt.Errorf("addresses out of order: network %s, first %s, last %s, broadcast %s", n.NetworkID, n.FirstUsableIP, n.LastUsableIP, n.BroadcastAddr)
+	}
+	size := new(big.Int).Sub(broadcast, network)
+	size.Add(size, big.NewInt(1))
+	if expected := new(big.Int).Lsh(big.NewInt(1), uint(n.MaxPrefix()-n.PrefixLength)); size.Cmp(expected) != 0 {
+		return fmt.Errorf("block spans %s addresses, expected %s", size, expected)
+	}
+	return nil
+}
+
+// checkSplitAggregate splits the network a few bits deeper and aggregates the
+// subnets again
+func checkSplitAggregate(c *CIDRCalculator, input verifyCase) error {
+	n := input.network
+	room := n.MaxPrefix() - n.PrefixLength
+	if room == 0 {
+		return nil
+	}
+	if room > maxVerifySplitBits {
+		room = maxVerifySplitBits
+	}
+	prefix := n.PrefixLength + 1 + input.random.Intn(room)
+
+	subnets, err := n.Split(prefix)
+	if err != nil {
+		return fmt.Errorf("split at /%d: %v", prefix, err)
+	}
+	networks := make([]*NetworkInfo, len(subnets))
+	for i := range subnets {
+		networks[i] = &subnets[i]
+	}
+	aggregated := c.Aggregate(networks)
+	if len(aggregated) != 1 || aggregated[0].CIDR() != n.CIDR() {
+		var got []string
+		for _, network := range aggregated {
+			got = append(got, network.CIDR())
+		}
+		return fmt.Errorf("split at /%d aggregates to %s", prefix, strings.Join(got, ", "))
+	}
+	return nil
+}
+
+// checkContainsOverlaps compares Contains and Overlaps with the address
+// ranges of both networks
+func checkContainsOverlaps(c *CIDRCalculator, input verifyCase) error {
+	a, b := input.network, input.other
+	contains, overlaps := a.Contains(b), a.Overlaps(b)
+
+	if contains && !overlaps {
+		return fmt.Errorf("%s contains %s but does not overlap it", a.CIDR(), b.CIDR())
+	}
+	if overlaps != b.Overlaps(a) {
+		return fmt.Errorf("%s overlaps %s is %t, but the reverse is %t", a.CIDR(), b.CIDR(), overlaps, b.Overlaps(a))
+	}
+
+	wantContains, wantOverlaps := false, false
+	if a.IsIPv6() == b.IsIPv6() {
+		aStart, aEnd := addressInt(a.NetworkID), addressInt(a.BroadcastAddr)
+		bStart, bEnd := addressInt(b.NetworkID), addressInt(b.BroadcastAddr)
+		wantContains = aStart.Cmp(bStart) <= 0 && bEnd.Cmp(aEnd) <= 0
+		wantOverlaps = aStart.Cmp(bEnd) <= 0 && bStart.Cmp(aEnd) <= 0
+	}
+	if contains != wantContains {
+		return fmt.Errorf("%s contains %s is %t, but the address ranges say %t", a.CIDR(), b.CIDR(), contains, wantContains)
+	}
+	if overlaps != wantOverlaps {
+		return fmt.Errorf("%s overlaps %s is %t, but the address ranges say %t", a.CIDR(), b.CIDR(), overlaps, wantOverlaps)
+	}
+	return nil
+}
+
+// checkAdjacent steps to the next network and back
+func checkAdjacent(c *CIDRCalculator, input verifyCase) error {
+	n := input.network
+	next, err := n.Adjacent(1)
+	if err != nil {
+		// Only the last block of the address space has no successor
+		end := new(big.Int).Lsh(big.NewInt(1), uint(len(addressBytes(n.NetworkID))*8))
+		if addressInt(n.BroadcastAddr).Cmp(end.Sub(end, big.NewInt(1))) != 0 {
+			return fmt.Errorf("next network: %v", err)
+		}
+		return nil
+	}
+
+	expected := addressInt(n.BroadcastAddr)
+	if addressInt(next.NetworkID).Cmp(expected.Add(expected, big.NewInt(1))) != 0 {
+		return fmt.Errorf("next network %s does not start right after broadcast %s", next.CIDR(), n.BroadcastAddr)
+	}
+	if next.Overlaps(n) {
+		return fmt.Errorf("next network %s overlaps the network", next.CIDR())
+	}
+	back, err := next.Adjacent(-1)
+	if err != nil {
+		return fmt.Errorf("stepping back from %s: %v", next.CIDR(), err)
+	}
+	if back.CIDR() != n.CIDR() {
+		return fmt.Errorf("stepping back from %s gives %s", next.CIDR(), back.CIDR())
+	}
+	return nil
+}
+
+// checkSupernet widens the network to a random shorter prefix
+func checkSupernet(c *CIDRCalculator, input verifyCase) error {
+	n := input.network
+	prefix := input.random.Intn(n.PrefixLength + 1)
+	supernet, err := n.Supernet(prefix)
+	if err != nil {
+		return fmt.Errorf("supernet at /%d: %v", prefix, err)
+	}
+	if supernet.PrefixLength != prefix || !supernet.Contains(n) {
+		return fmt.Errorf("supernet at /%d is %s, which does not contain the network", prefix, supernet.CIDR())
+	}
+	return nil
+}
+
+// checkText marshals the network to text and parses it again
+func checkText(c *CIDRCalculator, input verifyCase) error {
+	text, err := input.network.MarshalText()
+	if err != nil {
+		return fmt.Errorf("marshal: %v", err)
+	}
+	var parsed NetworkInfo
+	if err := parsed.UnmarshalText(text); err != nil {
+		return fmt.Errorf("unmarshal %q: %v", text, err)
+	}
+	if parsed.CIDR() != input.network.CIDR() || !parsed.BroadcastAddr.Equal(input.network.BroadcastAddr) {
+		return fmt.Errorf("%q parses back as %s", text, parsed.CIDR())
+	}
+	return nil
+}
+
+// randomNetwork returns a network with random address bits and prefix length,
+// IPv4 three times out of four
+func (c *CIDRCalculator) randomNetwork(random *rand.Rand) (*NetworkInfo, error) {
+	address := make(net.IP, net.IPv6len)
+	bits := 128
+	if random.Intn(4) > 0 {
+		address, bits = make(net.IP, net.IPv4len), 32
+	}
+	random.Read(address)
+	return c.ParseCIDR(fmt.Sprintf("%s/%d", address, random.Intn(bits+1)))
+}
+
+// relatedNetwork returns a network sharing the address of n at another prefix
+// length, so it is a subnet or supernet of n, or an unrelated random network
+func (c *CIDRCalculator) relatedNetwork(random *rand.Rand, n *NetworkInfo) (*NetworkInfo, error) {
+	if random.Intn(2) == 0 {
+		return c.randomNetwork(random)
+	}
+	address := addressInt(n.NetworkID)
+	hostBits := n.MaxPrefix() - n.PrefixLength
+	if hostBits > 0 {
+		offset := new(big.Int).Rand(random, new(big.Int).Lsh(big.NewInt(1), uint(hostBits)))
+		address.Add(address, offset)
+	}
+	ip := make(net.IP, len(addressBytes(n.NetworkID)))
+	address.FillBytes(ip)
+	return c.ParseCIDR(fmt.Sprintf("%s/%d", ip, random.Intn(n.MaxPrefix()+1)))
+}
+
+// Verify checks the properties against iterations random cases generated from
+// the seed. The same seed always produces the same cases, so a counterexample
+// can be reproduced.
+func (c *CIDRCalculator) Verify(properties []verifyProperty, iterations int, seed int64) (*VerifyReport, error) {
+	report := &VerifyReport{Iterations: iterations, Seed: seed}
+	failures := make(map[string]*VerifyFailure)
+	for _, property := range properties {
+		report.Properties = append(report.Properties, property.name)
+	}
+
+	random := rand.New(rand.NewSource(seed))
+	for i := 0; i < iterations; i++ {
+		network, err := c.randomNetwork(random)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate a network: %v", err)
+		}
+		other, err := c.relatedNetwork(random, network)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate a network: %v", err)
+		}
+
+		for _, property := range properties {
+			input := verifyCase{network: network, other: other, random: random}
+			err := property.check(c, input)
+			if err == nil {
+				continue
+			}
+			if failure, ok := failures[property.name]; ok {
+				failure.Count++
+				continue
+			}
+			description := network.CIDR()
+			if property.name == "contains-overlaps" {
+				description += " " + other.CIDR()
+			}
+			failures[property.name] = &VerifyFailure{Property: property.name, Expected: property.description, Input: description, Detail: err.Error(), Count: 1}
+		}
+	}
+
+	for _, property := range properties {
+		if failure, ok := failures[property.name]; ok {
+			report.Failures = append(report.Failures, *failure)
+		}
+	}
+	return report, nil
+}
+
+// FormatVerifyReport lists each property with its outcome and the first
+// counterexample of failing properties
+func (f *OutputFormatter) FormatVerifyReport(report *VerifyReport) string {
+	var output strings.Builder
+	failed := make(map[string]VerifyFailure)
+	for _, failure := range report.Failures {
+		failed[failure.Property] = failure
+	}
+
+	output.WriteString(fmt.Sprintf("Verified %d properties over %d random networks (seed %d)\n", len(report.Properties), report.Iterations, report.Seed))
+	for _, name := range report.Properties {
+		failure, ok := failed[name]
+		if !ok {
+			output.WriteString(fmt.Sprintf("  %-18s ok\n", name))
+			continue
+		}
+		output.WriteString(fmt.Sprintf("  %-18s FAILED in %d of %d cases\n", name, failure.Count, report.Iterations))
+		output.WriteString(fmt.Sprintf("    expected: %s\n", failure.Expected))
+		output.WriteString(fmt.Sprintf("    counterexample: %s\n", failure.Input))
+		output.WriteString(fmt.Sprintf("    %s\n", failure.Detail))
+	}
+	return output.String()
+}
+
+// parseIterations accepts an iteration count as an integer or in exponent
+// form such as 1e6
+func parseIterations(value string) (int, error) {
+	count, err := strconv.ParseFloat(value, 64)
+	if err != nil || count != math.Trunc(count) || count < 1 || count > math.MaxInt32 {
+		return 0, fmt.Errorf("--iterations must be a whole number between 1 and %d, got %s", math.MaxInt32, value)
+	}
+	return int(count), nil
+}
+
+// flagWasSet reports whether the flag was given on the command line
+func flagWasSet(flagSet *flag.FlagSet, name string) bool {
+	set := false
+	flagSet.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// runVerify implements the verify subcommand
+func (c *CLIHandler) runVerify(args []string) error {
+	flagSet := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flagSet.SetOutput(c.stderr)
+
+	var iterations, outputFile string
+	var seed int64
+	flagSet.StringVar(&iterations, "iterations", "10000", "Number of random networks to check, e.g. 1e6")
+	flagSet.Int64Var(&seed, "seed", 0, "Seed of the random networks (default: time based)")
+	flagSet.StringVar(&outputFile, "o", "", "Save output to file")
+	flagSet.StringVar(&outputFile, "output", "", "Save output to file")
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("flag parsing error: %v", err)
+	}
+	if flagSet.NArg() > 0 {
+		return fmt.Errorf("verify takes no arguments, got %s", strings.Join(flagSet.Args(), " "))
+	}
+
+	count, err := parseIterations(iterations)
+	if err != nil {
+		return err
+	}
+	if !flagWasSet(flagSet, "seed") {
+		seed = time.Now().UnixNano()
+	}
+
+	started := time.Now()
+	report, err := c.calculator.Verify(verifyProperties, count, seed)
+	if err != nil {
+		return err
+	}
+	c.notef("checked in %s", time.Since(started).Round(time.Millisecond))
+
+	if err := c.writeOutput(c.formatter.FormatVerifyReport(report), outputFile); err != nil {
+		return err
+	}
+	if len(report.Failures) > 0 {
+		return fmt.Errorf("%d of %d properties failed; rerun with --seed %d to reproduce", len(report.Failures), len(report.Properties), seed)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCIDRCalculator_Verify(t *testing.T) {
+	calculator := NewCIDRCalculator()
+
+	report, err := calculator.Verify(verifyProperties, 2000, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Properties) != len(verifyProperties) || len(report.Failures) != 0 {
+		t.Errorf("expected every property to hold, got %+v", report.Failures)
+	}
+
+	// A property that fails on every IPv6 network reports its first counterexample
+	broken := verifyProperty{name: "ipv4-only", check: func(c *CIDRCalculator, input verifyCase) error {
+		if input.network.IsIPv6() {
+			return fmt.Errorf("got an IPv6 network")
+		}
+		return nil
+	}}
+	first, err := calculator.Verify([]verifyProperty{broken}, 200, 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(first.Failures) != 1 || first.Failures[0].Count == 0 || !strings.Contains(first.Failures[0].Input, ":") {
+		t.Fatalf("expected an IPv6 counterexample, got %+v", first.Failures)
+	}
+
+	// The same seed reproduces the same counterexample
+	again, _ := calculator.Verify([]verifyProperty{broken}, 200, 7)
+	if again.Failures[0] != first.Failures[0] {
+		t.Errorf("expected seed 7 to reproduce %+v, got %+v", first.Failures[0], again.Failures[0])
+	}
+
+	text := NewOutputFormatter().FormatVerifyReport(first)
+	for _, expected := range []string{"(seed 7)", "ipv4-only          FAILED in", "counterexample: " + first.Failures[0].Input, "got an IPv6 network"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("expected %q in:\n%s", expected, text)
+		}
+	}
+}
+
+func TestParseIterations(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+		valid    bool
+	}{
+		{"1e6", 1000000, true},
+		{"250", 250, true},
+		{"0", 0, false},
+		{"1.5", 0, false},
+		{"many", 0, false},
+	}
+
+	for _, tt := range tests {
+		count, err := parseIterations(tt.value)
+		if (err == nil) != tt.valid || count != tt.expected {
+			t.Errorf("%s: expected %d (valid %t), got %d, %v", tt.value, tt.expected, tt.valid, count, err)
+		}
+	}
+}
+
+func TestCLIHandler_Verify(t *testing.T) {
+	handler := NewCLIHandler()
+	handler.stderr = io.Discard
+
+	output := filepath.Join(t.TempDir(), "verify.txt")
+	if err := handler.Run([]string{"cidr-calc", "verify", "--iterations", "1e3", "--seed", "3", "-o", output}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, _ := os.ReadFile(output)
+	if !strings.Contains(string(content), "Verified 6 properties over 1000 random networks (seed 3)") || strings.Contains(string(content), "FAILED") {
+		t.Errorf("expected a passing report, got:\n%s", content)
+	}
+
+	for _, args := range [][]string{{"verify", "--iterations", "0"}, {"verify", "10.0.0.0/8"}} {
+		if err := handler.Run(append([]string{"cidr-calc"}, args...)); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
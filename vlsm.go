@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hostList collects a comma separated --vlsm list of host counts
+type hostList []int
+
+// String returns the host counts as given
+func (h *hostList) String() string {
+	values := make([]string, len(*h))
+	for i, hosts := range *h {
+		values[i] = strconv.Itoa(hosts)
+	}
+	return strings.Join(values, ",")
+}
+
+// Set parses a list such as "100,50,20,2,2"; repeated flags are appended
+func (h *hostList) Set(value string) error {
+	for _, field := range strings.Split(value, ",") {
+		hosts, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || hosts < 1 {
+			return fmt.Errorf("invalid host count %q", field)
+		}
+		*h = append(*h, hosts)
+	}
+	return nil
+}
+
+// VLSMAllocation is a subnet assigned to one host count requirement
+type VLSMAllocation struct {
+	Hosts  int
+	Subnet *NetworkInfo
+	Index  int // position of the host count in the requirements
+}
+
+// VLSMPlan is a variable-length subnet plan packed into a parent network
+type VLSMPlan struct {
+	Network     *NetworkInfo
+	Allocations []VLSMAllocation
+	Allocated   uint64
+	Free        []string
+	Reserved    int // addresses a --cloud provider reserves in every subnet
+}
+
+// AllocateVLSM gives every host count the smallest subnet that holds it, packed
+// into the IPv4 network from the start. Requirements are placed largest first so
+// every subnet stays aligned without gaps; allocations are returned in address order.
+func (c *CIDRCalculator) AllocateVLSM(network *NetworkInfo, hosts []int) (*VLSMPlan, error) {
+	if network.IsIPv6() {
+		return nil, fmt.Errorf("VLSM plans support IPv4 networks only")
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no host counts to allocate")
+	}
+
+	order := make([]int, len(hosts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return hosts[order[i]] > hosts[order[j]] })
+
+	start := uint64(ipv4ToUint32(network.NetworkID))
+	end := uint64(ipv4ToUint32(network.BroadcastAddr))
+	size := end - start + 1
+
+	plan := &VLSMPlan{Network: network}
+	next := start
+	for _, i := range order {
+		prefix := prefixForHosts(false, hosts[i])
+		if prefix < network.PrefixLength {
+			return nil, fmt.Errorf("%d hosts do not fit in %s", hosts[i], network.CIDR())
+		}
+
+		blockSize := uint64(1) << uint(32-prefix)
+		if next+blockSize-1 > end {
+			return nil, fmt.Errorf("%s cannot fit all requirements: %d hosts need a /%d but only %d of %d addresses remain",
+				network.CIDR(), hosts[i], prefix, end+1-next, size)
+		}
+
+		subnet, err := c.ParseCIDR(fmt.Sprintf("%s/%d", uint32ToIPv4(uint32(next)), prefix))
+		if err != nil {
+			return nil, err
+		}
+		subnet.Derivation = hostsDerivation(network, "vlsm", hosts[i], prefix)
+		plan.Allocations = append(plan.Allocations, VLSMAllocation{Hosts: hosts[i], Subnet: subnet, Index: i})
+		plan.Allocated += blockSize
+		next += blockSize
+	}
+
+	if next <= end {
+		plan.Free = alignedBlocks(next, end)
+	}
+
+	return plan, nil
+}
+
+// Usable returns the hosts a subnet of the plan holds, after the addresses
+// the cloud provider reserves when the plan has any
+func (p *VLSMPlan) Usable(subnet *NetworkInfo) uint64 {
+	if p.Reserved == 0 {
+		return uint64(subnet.TotalHosts)
+	}
+	size := uint64(1) << uint(32-subnet.PrefixLength)
+	if size < uint64(p.Reserved) {
+		return 0
+	}
+	return size - uint64(p.Reserved)
+}
+
+// Size returns the number of addresses in the parent network
+func (p *VLSMPlan) Size() uint64 {
+	return uint64(1) << uint(32-p.Network.PrefixLength)
+}
+
+// FormatVLSMPlan renders the allocations and the remaining free space as text
+func (f *OutputFormatter) FormatVLSMPlan(plan *VLSMPlan) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("VLSM Plan for %s:\n", plan.Network.CIDR()))
+	output.WriteString(fmt.Sprintf("  %-8s %-18s %-33s %s\n", "Hosts", "Subnet", "Usable Range", "Usable"))
+	for _, allocation := range plan.Allocations {
+		subnet := allocation.Subnet
+		output.WriteString(fmt.Sprintf("  %-8d %-18s %-33s %d\n", allocation.Hosts, subnet.CIDR(),
+			fmt.Sprintf("%s - %s", subnet.FirstUsableIP, subnet.LastUsableIP), plan.Usable(subnet)))
+	}
+
+	output.WriteString("\n")
+	output.WriteString(fmt.Sprintf("  %-15s %d of %d addresses (%.1f%%)\n", "Allocated:", plan.Allocated, plan.Size(),
+		float64(plan.Allocated)*100/float64(plan.Size())))
+
+	output.WriteString("\nFree Space:\n")
+	if len(plan.Free) == 0 {
+		output.WriteString("  none\n")
+	}
+	for _, block := range plan.Free {
+		output.WriteString(fmt.Sprintf("  %s\n", block))
+	}
+
+	return output.String()
+}
+
+// Reports returns a network report per allocation, in address order, for the
+// output formats without a plan layout of their own
+func (p *VLSMPlan) Reports(calculator *CIDRCalculator) []NetworkReport {
+	reports := make([]NetworkReport, 0, len(p.Allocations))
+	for _, allocation := range p.Allocations {
+		reports = append(reports, NetworkReport{Info: allocation.Subnet, Subnets: calculator.CalculateSubnets(allocation.Subnet)})
+	}
+	return reports
+}
+
+// FormatVLSMPlanAsCSV renders the plan as CSV with the subnet columns of the
+// regular export. Free blocks follow the allocations with an empty Required column.
+func (f *OutputFormatter) FormatVLSMPlanAsCSV(plan *VLSMPlan) (string, error) {
+	var output strings.Builder
+	writer := csv.NewWriter(&output)
+
+	header := append([]string{"Required"}, csvHeaders...)
+	if err := writer.Write(header); err != nil {
+		return "", fmt.Errorf("failed to write CSV header: %v", err)
+	}
+
+	parent := plan.Network.CIDR()
+	for _, allocation := range plan.Allocations {
+		if err := writer.Write(append([]string{strconv.Itoa(allocation.Hosts)}, f.csvRow(parent, allocation.Subnet)...)); err != nil {
+			return "", fmt.Errorf("failed to write CSV rows: %v", err)
+		}
+	}
+
+	calculator := NewCIDRCalculator()
+	for _, block := range plan.Free {
+		info, err := calculator.ParseCIDR(block)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse free block %s: %v", block, err)
+		}
+		if err := writer.Write(append([]string{""}, f.csvRow(parent, info)...)); err != nil {
+			return "", fmt.Errorf("failed to write CSV rows: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %v", err)
+	}
+
+	return output.String(), nil
+}
+
+// runVLSM allocates the --vlsm host counts inside the network and writes the plan
+func (c *CLIHandler) runVLSM(networkInfo *NetworkInfo, config *Config) error {
+	// Under --cloud every subnet must also hold the reserved addresses, and
+	// be no smaller than the smallest subnet the provider allows
+	hosts := make([]int, len(config.VLSM))
+	for i, count := range config.VLSM {
+		hosts[i] = count
+		if rules := config.Cloud; rules != nil {
+			hosts[i] += rules.Reserved - 2
+			if prefixForHosts(false, hosts[i]) > rules.IPv4Max {
+				hosts[i] = int(usableHosts(false, 32-rules.IPv4Max))
+			}
+		}
+	}
+	plan, err := c.calculator.AllocateVLSM(networkInfo, hosts)
+	if err != nil {
+		return err
+	}
+	if config.Cloud != nil {
+		plan.Reserved = config.Cloud.Reserved
+		for i := range plan.Allocations {
+			allocation := &plan.Allocations[i]
+			allocation.Hosts = config.VLSM[allocation.Index]
+			allocation.Subnet.Derivation.Requested = plural(allocation.Hosts, "host")
+			if allocation.Subnet.PrefixLength == config.Cloud.IPv4Max {
+				c.warnf("%s is a /%d, the smallest subnet %s allows, and leaves %d usable hosts for the %s required",
+					allocation.Subnet.CIDR(), config.Cloud.IPv4Max, config.Cloud.Name, plan.Usable(allocation.Subnet), plural(allocation.Hosts, "host"))
+			}
+		}
+		c.notef("%s reserves %d addresses in every subnet; the Usable column leaves them out", config.Cloud.Name, config.Cloud.Reserved)
+	}
+
+	for _, allocation := range plan.Allocations {
+		if violation, ok := config.Reserved.Violation(allocation.Subnet); ok {
+			return fmt.Errorf("%s for %d hosts %s", allocation.Subnet.CIDR(), allocation.Hosts, violation)
+		}
+	}
+
+	// Every allocation must be a subnet the provider can create and still
+	// hold its hosts once the provider's reserved addresses are taken out
+	if rules := config.Provider; rules != nil {
+		for _, allocation := range plan.Allocations {
+			subnet := allocation.Subnet
+			if err := rules.CheckPrefix(false, subnet.PrefixLength); err != nil {
+				return fmt.Errorf("--validate-for %s: %s for %d hosts: %v", strings.ToLower(rules.Name), subnet.CIDR(), allocation.Hosts, err)
+			}
+			if usable := rules.UsableHosts(subnet.PrefixLength); usable < allocation.Hosts {
+				c.warnf("%s reserves %d addresses in every subnet, so %s holds %d hosts, fewer than the %d required",
+					rules.Name, rules.Reserved, subnet.CIDR(), usable, allocation.Hosts)
+			}
+		}
+	}
+
+	var content string
+	switch format := config.OutputFormat(); format {
+	case FormatText:
+		content = c.formatter.FormatVLSMPlan(plan)
+	case FormatCSV:
+		if content, err = c.formatter.FormatVLSMPlanAsCSV(plan); err != nil {
+			return err
+		}
+	default:
+		// Every allocation records the requirement it was sized for
+		if content, err = c.formatter.RenderReports(format, plan.Reports(c.calculator)); err != nil {
+			return err
+		}
+	}
+
+	return c.writeOutput(content, config.OutputFile)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHostList_Set(t *testing.T) {
+	var hosts hostList
+	if err := hosts.Set("100, 50,20"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hosts.Set("2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hosts.String() != "100,50,20,2" {
+		t.Errorf("expected 100,50,20,2, got %s", hosts.String())
+	}
+
+	for _, value := range []string{"", "0", "ten", "10,,2"} {
+		var invalid hostList
+		if err := invalid.Set(value); err == nil {
+			t.Errorf("%q: expected error but got none", value)
+		}
+	}
+}
+
+func TestCIDRCalculator_AllocateVLSM(t *testing.T) {
+	calc := NewCIDRCalculator()
+
+	tests := []struct {
+		name       string
+		cidr       string
+		hosts      []int
+		wantSubnet []string
+		wantFree   []string
+		wantErr    string
+	}{
+		{
+			name:       "largest first",
+			cidr:       "192.168.10.0/24",
+			hosts:      []int{20, 100, 2, 50, 2},
+			wantSubnet: []string{"192.168.10.0/25", "192.168.10.128/26", "192.168.10.192/27", "192.168.10.224/31", "192.168.10.226/31"},
+			wantFree:   []string{"192.168.10.228/30", "192.168.10.232/29", "192.168.10.240/28"},
+		},
+		{
+			name:       "exact fit",
+			cidr:       "10.0.0.0/24",
+			hosts:      []int{126, 126},
+			wantSubnet: []string{"10.0.0.0/25", "10.0.0.128/25"},
+		},
+		{name: "too many", cidr: "10.0.0.0/24", hosts: []int{100, 100, 100}, wantErr: "only 0 of 256 addresses remain"},
+		{name: "too large", cidr: "10.0.0.0/24", hosts: []int{300}, wantErr: "300 hosts do not fit in 10.0.0.0/24"},
+		{name: "IPv6", cidr: "2001:db8::/64", hosts: []int{10}, wantErr: "IPv4 networks only"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := calc.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatalf("ParseCIDR() error = %v", err)
+			}
+
+			plan, err := calc.AllocateVLSM(info, tt.hosts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var subnets []string
+			for _, allocation := range plan.Allocations {
+				subnets = append(subnets, allocation.Subnet.CIDR())
+			}
+			if strings.Join(subnets, ",") != strings.Join(tt.wantSubnet, ",") {
+				t.Errorf("expected subnets %v, got %v", tt.wantSubnet, subnets)
+			}
+			if strings.Join(plan.Free, ",") != strings.Join(tt.wantFree, ",") {
+				t.Errorf("expected free space %v, got %v", tt.wantFree, plan.Free)
+			}
+		})
+	}
+}
+
+func TestCLIHandler_VLSM(t *testing.T) {
+	dir := t.TempDir()
+	handler := NewCLIHandler()
+
+	output := filepath.Join(dir, "plan.txt")
+	if err := handler.Run([]string{"cidr-calc", "--vlsm", "100,50,20,2,2", "-o", output, "192.168.10.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	for _, exp := range []string{
+		"VLSM Plan for 192.168.10.0/24:",
+		"  50       192.168.10.128/26  192.168.10.129 - 192.168.10.190   62\n",
+		"Allocated:      228 of 256 addresses (89.1%)",
+		"Free Space:\n  192.168.10.228/30\n",
+	} {
+		if !strings.Contains(string(content), exp) {
+			t.Errorf("expected plan to contain %q, got:\n%s", exp, content)
+		}
+	}
+
+	csvOutput := filepath.Join(dir, "plan.csv")
+	if err := handler.Run([]string{"cidr-calc", "--vlsm", "100,50", "-o", csvOutput, "192.168.10.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err = os.ReadFile(csvOutput)
+	if err != nil {
+		t.Fatalf("failed to read output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "Required,Network,CIDR") || !strings.HasPrefix(lines[3], ",192.168.10.0/24,192.168.10.192/26") {
+		t.Errorf("unexpected CSV plan:\n%s", content)
+	}
+
+	errorCases := [][]string{
+		{"cidr-calc", "--vlsm", "100,100,100", "192.168.10.0/24"},
+		{"cidr-calc", "--vlsm", "100", "--hosts", "20", "192.168.10.0/24"},
+		{"cidr-calc", "--vlsm", "100", "-f", "plan.txt"},
+		{"cidr-calc", "--vlsm", "a,b", "192.168.10.0/24"},
+	}
+	for _, args := range errorCases {
+		if err := handler.Run(args); err == nil {
+			t.Errorf("%v: expected error but got none", args)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a significant line of a YAML document, without its comment
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+// yamlParser reads the block subset of YAML that plan documents are written
+// in: mappings, sequences, plain and quoted scalars, comments and the empty
+// flow collections {} and []. Mappings decode to map[string]interface{},
+// sequences to []interface{} and scalars to strings; null is nil.
+type yamlParser struct {
+	source    string
+	lines     []yamlLine
+	pos       int
+	positions map[string]int
+}
+
+// parseYAML decodes a YAML document
+func parseYAML(source, content string) (interface{}, error) {
+	value, _, err := parseYAMLPositions(source, content)
+	return value, err
+}
+
+// parseYAMLPositions decodes a YAML document and returns the line each
+// sequence item starts on, by its path: the keys and indexes leading to it
+// joined by dots, such as policies.reserved.0
+func parseYAMLPositions(source, content string) (interface{}, map[string]int, error) {
+	p := &yamlParser{source: source, positions: map[string]int{}}
+	for i, raw := range strings.Split(strings.TrimPrefix(content, "\ufeff"), "\n") {
+		text := strings.TrimRight(stripYAMLComment(raw), " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" || trimmed == "..." {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, nil, fmt.Errorf("%s line %d: tabs cannot indent YAML", source, i+1)
+		}
+		p.lines = append(p.lines, yamlLine{number: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(p.lines) == 0 {
+		return nil, p.positions, nil
+	}
+
+	value, err := p.node(p.lines[0].indent, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, nil, p.errorf("unexpected indentation")
+	}
+	return value, p.positions, nil
+}
+
+// yamlPath returns the path of a key or index below path
+func yamlPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// stripYAMLComment removes a # comment that is outside quotes and starts the
+// line or follows whitespace
+func stripYAMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// errorf reports an error at the current line
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	number := 0
+	if p.pos < len(p.lines) {
+		number = p.lines[p.pos].number
+	} else if len(p.lines) > 0 {
+		number = p.lines[len(p.lines)-1].number
+	}
+	return fmt.Errorf("%s line %d: %s", p.source, number, fmt.Sprintf(format, args...))
+}
+
+// isSequenceItem reports whether a line starts a sequence item
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// node reads the mapping or sequence at path whose lines start at indent
+func (p *yamlParser) node(indent int, path string) (interface{}, error) {
+	if isSequenceItem(p.lines[p.pos].text) {
+		return p.sequence(indent, path)
+	}
+	return p.mapping(indent, path)
+}
+
+// sequence reads the items of a sequence at indent
+func (p *yamlParser) sequence(indent int, path string) ([]interface{}, error) {
+	items := []interface{}{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isSequenceItem(p.lines[p.pos].text) {
+		line := p.lines[p.pos]
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		itemPath := yamlPath(path, strconv.Itoa(len(items)))
+		p.positions[itemPath] = line.number
+
+		switch {
+		case rest == "":
+			// The item is the block on the following lines
+			p.pos++
+			if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+				items = append(items, nil)
+				continue
+			}
+			item, err := p.node(p.lines[p.pos].indent, itemPath)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		case yamlKeyEnd(rest) >= 0:
+			// "- key: value" starts a mapping indented past the dash
+			p.lines[p.pos] = yamlLine{number: line.number, indent: line.indent + len(line.text) - len(rest), text: rest}
+			item, err := p.mapping(p.lines[p.pos].indent, itemPath)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		default:
+			item, err := p.scalar(rest)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			p.pos++
+		}
+	}
+	return items, nil
+}
+
+// mapping reads the keys of a mapping at indent
+func (p *yamlParser) mapping(indent int, path string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && !isSequenceItem(p.lines[p.pos].text) {
+		text := p.lines[p.pos].text
+		end := yamlKeyEnd(text)
+		if end < 0 {
+			return nil, p.errorf("expected key: value, got %q", text)
+		}
+		key, err := p.scalar(text[:end])
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(string)
+		if !ok || name == "" {
+			return nil, p.errorf("invalid key %q", text[:end])
+		}
+		if _, ok := values[name]; ok {
+			return nil, p.errorf("duplicate key %q", name)
+		}
+		rest := strings.TrimSpace(text[end+1:])
+		p.pos++
+
+		switch {
+		case rest != "":
+			if values[name], err = p.scalar(rest); err != nil {
+				p.pos--
+				return nil, err
+			}
+		case p.pos < len(p.lines) && p.lines[p.pos].indent > indent:
+			if values[name], err = p.node(p.lines[p.pos].indent, yamlPath(path, name)); err != nil {
+				return nil, err
+			}
+		case p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isSequenceItem(p.lines[p.pos].text):
+			// A sequence may sit at the indentation of its key
+			if values[name], err = p.sequence(indent, yamlPath(path, name)); err != nil {
+				return nil, err
+			}
+		default:
+			values[name] = nil
+		}
+	}
+	return values, nil
+}
+
+// yamlKeyEnd returns the index of the colon ending the key of a mapping line,
+// or -1 when the line is not one
+func yamlKeyEnd(text string) int {
+	var quote rune
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case (r == '"' || r == '\'') && i == 0:
+			quote = r
+		case r == ':' && (i == len(text)-1 || text[i+1] == ' '):
+			return i
+		}
+	}
+	return -1
+}
+
+// scalar decodes a plain or quoted scalar, or an empty flow collection
+func (p *yamlParser) scalar(text string) (interface{}, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "{}":
+		return map[string]interface{}{}, nil
+	case text == "[]":
+		return []interface{}{}, nil
+	case text == "~" || text == "null":
+		return nil, nil
+	case strings.HasPrefix(text, `"`):
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, p.errorf("invalid double-quoted string %s", text)
+		}
+		return value, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, p.errorf("invalid single-quoted string %s", text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	case strings.HasPrefix(text, "{") || strings.HasPrefix(text, "["):
+		return nil, p.errorf("flow collections such as %s are not supported; use block style", text)
+	case strings.HasPrefix(text, "&") || strings.HasPrefix(text, "*") || strings.HasPrefix(text, "!") || strings.HasPrefix(text, "|") || strings.HasPrefix(text, ">"):
+		return nil, p.errorf("anchors, tags and block scalars are not supported")
+	}
+	return text, nil
+}
+
+// yamlScalar writes a string as a plain scalar when YAML reads it back as the
+// same string, and double-quoted otherwise
+func yamlScalar(value string) string {
+	plain := value != "" && strings.TrimSpace(value) == value &&
+		!strings.ContainsAny(value, ":#'\"{}[],&*!|>%@`\\\n\t") &&
+		!strings.HasPrefix(value, "-") && !strings.HasPrefix(value, "?")
+	switch strings.ToLower(value) {
+	case "~", "null", "true", "false", "yes", "no", "on", "off":
+		plain = false
+	}
+	// Numbers are quoted so that other YAML readers keep them strings
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		plain = false
+	}
+	if plain {
+		return value
+	}
+	return strconv.Quote(value)
+}
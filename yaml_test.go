@@ -0,0 +1,86 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	content := `---
+# a plan
+version: 1
+pools: []
+allocations:
+  - cidr: 10.0.0.0/24   # web
+    labels:
+      name: web
+      "vlan": '100'
+  -
+    cidr: "2001:db8::/48"
+    comment: "uplink: \"primary\" # not a comment"
+policies:
+  quotas:
+  - tenant: payments
+    quota: 4096
+  quotaTag: ~
+`
+	value, err := parseYAML("plan.yaml", content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]interface{}{
+		"version": "1",
+		"pools":   []interface{}{},
+		"allocations": []interface{}{
+			map[string]interface{}{"cidr": "10.0.0.0/24", "labels": map[string]interface{}{"name": "web", "vlan": "100"}},
+			map[string]interface{}{"cidr": "2001:db8::/48", "comment": `uplink: "primary" # not a comment`},
+		},
+		"policies": map[string]interface{}{
+			"quotas":   []interface{}{map[string]interface{}{"tenant": "payments", "quota": "4096"}},
+			"quotaTag": nil,
+		},
+	}
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("expected %#v, got %#v", expected, value)
+	}
+
+	_, positions, _ := parseYAMLPositions("plan.yaml", content)
+	for path, line := range map[string]int{"allocations.0": 6, "allocations.1": 10, "policies.quotas.0": 15} {
+		if positions[path] != line {
+			t.Errorf("%s: expected line %d, got %d", path, line, positions[path])
+		}
+	}
+
+	errorTests := map[string]string{
+		"a: 1\na: 2":        "plan.yaml line 2: duplicate key \"a\"",
+		"a: 1\n  b: 2":      "plan.yaml line 2: unexpected indentation",
+		"a: {b: 1}":         "flow collections",
+		"a: &anchor 1":      "anchors, tags and block scalars are not supported",
+		"a:\n\t- 1":         "plan.yaml line 2: tabs cannot indent YAML",
+		"just text":         "expected key: value",
+		"a: \"unterminated": "invalid double-quoted string",
+	}
+	for content, expected := range errorTests {
+		if _, err := parseYAML("plan.yaml", content); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%q: expected error %q, got %v", content, expected, err)
+		}
+	}
+}
+
+func TestYAMLScalar(t *testing.T) {
+	for _, value := range []string{"web", "10.0.0.0/24", "2001:db8::/48", "", " padded", "100", "yes", "- dash", "a # b", `quote "me"`, "line\nbreak"} {
+		encoded := yamlScalar(value)
+		decoded, err := parseYAML("scalar.yaml", "key: "+encoded)
+		if err != nil {
+			t.Errorf("%q: unexpected error for %s: %v", value, encoded, err)
+			continue
+		}
+		if got := decoded.(map[string]interface{})["key"]; got != value {
+			t.Errorf("%q: written as %s, read back as %q", value, encoded, got)
+		}
+	}
+	if yamlScalar("web") != "web" || yamlScalar("100") != `"100"` {
+		t.Errorf("unexpected quoting: %s %s", yamlScalar("web"), yamlScalar("100"))
+	}
+}